@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/robfig/cron/v3"
@@ -19,6 +20,10 @@ type commonConfig struct {
 	ExperimentalExporters       bool                         `mapstructure:"experimental-exporters"`
 	SemconvMetricsNames         bool                         `mapstructure:"semconv-metrics-names"`
 	SchemaEnforcementMode       ledger.SchemaEnforcementMode `mapstructure:"schema-enforcement-mode"`
+	CurrencyRefreshInterval     time.Duration                `mapstructure:"currency-refresh-interval"`
+	ScreeningWatchlists         []string                     `mapstructure:"cba-screening-watchlists"`
+	ScreeningThreshold          float64                      `mapstructure:"cba-screening-threshold"`
+	MonitoringRulesFile         string                       `mapstructure:"cba-monitoring-rules-file"`
 }
 
 func decodeCronSchedule(sourceType, destType reflect.Type, value any) (any, error) {
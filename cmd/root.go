@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 	"github.com/uptrace/bun"
 
@@ -19,6 +21,10 @@ const (
 	NumscriptInterpreterFlagsToPass = "experimental-numscript-interpreter-flags"
 	ExperimentalFeaturesFlag        = "experimental-features"
 	ExperimentalExporters           = "experimental-exporters"
+	CurrencyRefreshIntervalFlag     = "currency-refresh-interval"
+	ScreeningWatchlistsFlag         = "cba-screening-watchlists"
+	ScreeningThresholdFlag          = "cba-screening-threshold"
+	MonitoringRulesFileFlag         = "cba-monitoring-rules-file"
 )
 
 var (
@@ -37,6 +43,10 @@ func NewRootCommand() *cobra.Command {
 
 	root.PersistentFlags().Bool(ExperimentalFeaturesFlag, false, "Enable features configurability")
 	root.PersistentFlags().Bool(ExperimentalExporters, false, "Enable exporters support")
+	root.PersistentFlags().Duration(CurrencyRefreshIntervalFlag, 30*time.Second, "Interval between reloads of the currency registry, 0 to disable")
+	root.PersistentFlags().StringSlice(ScreeningWatchlistsFlag, nil, "Sanctions and PEP watchlists (CSV, or UN consolidated list XML) clients and counterparties are screened against")
+	root.PersistentFlags().Float64(ScreeningThresholdFlag, 0.9, "Minimum name similarity, between 0 and 1, for a watchlist entry to be a hit")
+	root.PersistentFlags().String(MonitoringRulesFileFlag, "", "JSON file replacing the default transaction monitoring rules")
 
 	root.AddCommand(NewServeCommand())
 	root.AddCommand(NewBucketsCommand())
@@ -39,12 +39,14 @@ import (
 	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
 	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
 	"github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/exchange"
 	"github.com/formancehq/ledger/internal/replication"
 	"github.com/formancehq/ledger/internal/replication/drivers"
 	"github.com/formancehq/ledger/internal/replication/drivers/alldrivers"
 	"github.com/formancehq/ledger/internal/storage"
 	systemstore "github.com/formancehq/ledger/internal/storage/system"
 	"github.com/formancehq/ledger/internal/tracing"
+	"github.com/formancehq/ledger/internal/wallets"
 	"github.com/formancehq/ledger/internal/worker"
 )
 
@@ -62,6 +64,21 @@ type ServeCommandConfig struct {
 	MaxPageSize            uint64 `mapstructure:"max-page-size"`
 	WorkerEnabled          bool   `mapstructure:"worker"`
 	WorkerAddress          string `mapstructure:"worker-grpc-address"`
+	FXRatesFile            string `mapstructure:"fx-rates-file"`
+	KYCRequirementsFile    string `mapstructure:"cba-kyc-requirements-file"`
+	KYCProviderURL         string `mapstructure:"cba-kyc-provider-url"`
+	KYCProviderSecret      string `mapstructure:"cba-kyc-provider-secret"`
+	TrustActorHeader       bool   `mapstructure:"cba-trust-actor-header"`
+}
+
+func (cfg ServeCommandConfig) Validate() error {
+	if err := cfg.WorkerConfiguration.Validate(); err != nil {
+		return err
+	}
+	if cfg.KYCProviderURL != "" && cfg.KYCProviderSecret == "" {
+		return fmt.Errorf("--%s is required with --%s", KYCProviderSecretFlag, KYCProviderURLFlag)
+	}
+	return nil
 }
 
 const (
@@ -75,8 +92,14 @@ const (
 	DefaultPageSizeFlag   = "default-page-size"
 	MaxPageSizeFlag       = "max-page-size"
 	WorkerEnabledFlag     = "worker"
+	FXRatesFileFlag       = "fx-rates-file"
 	SemconvMetricsNames   = "semconv-metrics-names"
 	SchemaEnforcementMode = "schema-enforcement-mode"
+
+	KYCRequirementsFileFlag = "cba-kyc-requirements-file"
+	KYCProviderURLFlag      = "cba-kyc-provider-url"
+	KYCProviderSecretFlag   = "cba-kyc-provider-secret"
+	TrustActorHeaderFlag    = "cba-trust-actor-header"
 )
 
 func NewServeCommand() *cobra.Command {
@@ -125,9 +148,22 @@ func NewServeCommand() *cobra.Command {
 					SchemaEnforcementMode: cfg.commonConfig.SchemaEnforcementMode,
 				}),
 				bus.NewFxModule(),
-				currency.NewFXModule(),
-				cba.NewFXModule(),
+				currency.NewFXModule(currency.ModuleConfig{
+					RefreshInterval: cfg.CurrencyRefreshInterval,
+				}),
+				cba.NewFXModule(cba.ModuleConfig{
+					KYCRequirementsFile: cfg.KYCRequirementsFile,
+					KYCProviderURL:      cfg.KYCProviderURL,
+					KYCProviderSecret:   cfg.KYCProviderSecret,
+					ScreeningWatchlists: cfg.ScreeningWatchlists,
+					ScreeningThreshold:  cfg.ScreeningThreshold,
+					MonitoringRulesFile: cfg.MonitoringRulesFile,
+				}),
 				channels.NewFXModule(),
+				wallets.NewFXModule(),
+				exchange.NewFXModule(exchange.ModuleConfig{
+					RatesFile: cfg.FXRatesFile,
+				}),
 				ballast.Module(cfg.BallastSizeInBytes),
 				api.Module(api.Config{
 					Version: Version,
@@ -140,7 +176,8 @@ func NewServeCommand() *cobra.Command {
 						MaxPageSize:     cfg.MaxPageSize,
 						DefaultPageSize: cfg.DefaultPageSize,
 					},
-					Exporters: cfg.ExperimentalExporters,
+					Exporters:        cfg.ExperimentalExporters,
+					TrustActorHeader: cfg.TrustActorHeader,
 				}),
 				fx.Decorate(func(
 					params struct {
@@ -195,6 +232,11 @@ func NewServeCommand() *cobra.Command {
 	cmd.Flags().Uint64(MaxPageSizeFlag, 100, "Max page size")
 	cmd.Flags().Uint64(DefaultPageSizeFlag, 15, "Default page size")
 	cmd.Flags().Bool(WorkerEnabledFlag, false, "Enable worker")
+	cmd.Flags().String(FXRatesFileFlag, "", "JSON file of FX rates to import on startup")
+	cmd.Flags().String(KYCRequirementsFileFlag, "", "JSON file of per-level KYC requirements")
+	cmd.Flags().String(KYCProviderURLFlag, "", "URL of the HTTP KYC provider, verification stays manual if empty")
+	cmd.Flags().String(KYCProviderSecretFlag, "", "Secret shared with the KYC provider to sign requests and callbacks, required with --"+KYCProviderURLFlag)
+	cmd.Flags().Bool(TrustActorHeaderFlag, false, "Identify callers by the Formance-Actor header when authentication is disabled (development only)")
 	cmd.Flags().Bool(ExperimentalFeaturesFlag, false, "Enable features configurability")
 	cmd.Flags().Bool(NumscriptInterpreterFlag, false, "Enable experimental numscript rewrite")
 	cmd.Flags().StringSlice(NumscriptInterpreterFlagsToPass, nil, "Feature flags to pass to the experimental numscript interpreter")
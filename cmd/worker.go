@@ -25,6 +25,8 @@ import (
 	"github.com/formancehq/ledger/internal/replication/drivers"
 	"github.com/formancehq/ledger/internal/replication/drivers/alldrivers"
 	"github.com/formancehq/ledger/internal/storage"
+	"github.com/formancehq/ledger/internal/wallets"
+	walletscheduler "github.com/formancehq/ledger/internal/wallets/scheduler"
 	"github.com/formancehq/ledger/internal/worker"
 )
 
@@ -44,9 +46,31 @@ const (
 	WorkerCBAInterestPostingScheduleFlag = "worker-cba-interest-posting-schedule"
 	WorkerCBAMaintenanceFeeScheduleFlag  = "worker-cba-maintenance-fee-schedule"
 	WorkerCBADormancyScheduleFlag        = "worker-cba-dormancy-schedule"
+	WorkerCBATermMaturityScheduleFlag    = "worker-cba-term-maturity-schedule"
+	WorkerCBALoanServicingScheduleFlag   = "worker-cba-loan-servicing-schedule"
+	WorkerCBAPenaltyFeeScheduleFlag      = "worker-cba-penalty-fee-schedule"
+	WorkerCBAFeeRecoveryScheduleFlag     = "worker-cba-fee-recovery-schedule"
+	WorkerCBAFeeWriteoffAfterDaysFlag    = "worker-cba-fee-writeoff-after-days"
+	WorkerCBAKYCExpiryScheduleFlag       = "worker-cba-kyc-expiry-schedule"
+	WorkerCBAKYCExpiryNoticeDaysFlag     = "worker-cba-kyc-expiry-notice-days"
+	WorkerCBAKYCExpiryWebhookURLFlag     = "worker-cba-kyc-expiry-webhook-url"
+	WorkerCBAScreeningScheduleFlag       = "worker-cba-screening-schedule"
 	WorkerCBALedgerNameFlag              = "worker-cba-ledger-name"
 	WorkerCBAFeeIncomeAccountFlag        = "worker-cba-fee-income-account"
 	WorkerCBAInterestExpenseAccountFlag  = "worker-cba-interest-expense-account"
+	WorkerCBAInterestIncomeAccountFlag   = "worker-cba-interest-income-account"
+	WorkerCBATaxLiabilityAccountFlag     = "worker-cba-tax-liability-account"
+	WorkerCBAJobCatchUpDaysFlag          = "worker-cba-job-catch-up-days"
+	WorkerCBAJobPollIntervalFlag         = "worker-cba-job-poll-interval"
+	WorkerCBALeaderIdentityFlag          = "worker-cba-leader-identity"
+	WorkerCBALeaderLeaseDurationFlag     = "worker-cba-leader-lease-duration"
+	WorkerCBALeaderRenewIntervalFlag     = "worker-cba-leader-renew-interval"
+
+	WorkerWalletDebitSagaRecoveryScheduleFlag    = "worker-wallet-debit-saga-recovery-schedule"
+	WorkerWalletDebitSagaRecoveryStaleAfterFlag  = "worker-wallet-debit-saga-recovery-stale-after"
+	WorkerWalletDebitSagaRecoveryMaxAttemptsFlag = "worker-wallet-debit-saga-recovery-max-attempts"
+	WorkerWalletLienExpiryScheduleFlag           = "worker-wallet-lien-expiry-schedule"
+	WorkerWalletStandingInstructionScheduleFlag  = "worker-wallet-standing-instruction-schedule"
 
 	WorkerGRPCAddressFlag = "worker-grpc-address"
 )
@@ -71,9 +95,31 @@ type WorkerConfiguration struct {
 	CBAInterestPostingCRONSpec cron.Schedule `mapstructure:"worker-cba-interest-posting-schedule"`
 	CBAMaintenanceFeeCRONSpec  cron.Schedule `mapstructure:"worker-cba-maintenance-fee-schedule"`
 	CBADormancyCRONSpec        cron.Schedule `mapstructure:"worker-cba-dormancy-schedule"`
+	CBATermMaturityCRONSpec    cron.Schedule `mapstructure:"worker-cba-term-maturity-schedule"`
+	CBALoanServicingCRONSpec   cron.Schedule `mapstructure:"worker-cba-loan-servicing-schedule"`
+	CBAPenaltyFeeCRONSpec      cron.Schedule `mapstructure:"worker-cba-penalty-fee-schedule"`
+	CBAFeeRecoveryCRONSpec     cron.Schedule `mapstructure:"worker-cba-fee-recovery-schedule"`
+	CBAFeeWriteoffAfterDays    int           `mapstructure:"worker-cba-fee-writeoff-after-days"`
+	CBAKYCExpiryCRONSpec       cron.Schedule `mapstructure:"worker-cba-kyc-expiry-schedule"`
+	CBAKYCExpiryNoticeDays     int           `mapstructure:"worker-cba-kyc-expiry-notice-days"`
+	CBAKYCExpiryWebhookURL     string        `mapstructure:"worker-cba-kyc-expiry-webhook-url"`
+	CBAScreeningCRONSpec       cron.Schedule `mapstructure:"worker-cba-screening-schedule"`
 	CBALedgerName              string        `mapstructure:"worker-cba-ledger-name"`
 	CBAFeeIncomeAccount        string        `mapstructure:"worker-cba-fee-income-account"`
 	CBAInterestExpenseAccount  string        `mapstructure:"worker-cba-interest-expense-account"`
+	CBAInterestIncomeAccount   string        `mapstructure:"worker-cba-interest-income-account"`
+	CBATaxLiabilityAccount     string        `mapstructure:"worker-cba-tax-liability-account"`
+	CBAJobCatchUpDays          int           `mapstructure:"worker-cba-job-catch-up-days"`
+	CBAJobPollInterval         time.Duration `mapstructure:"worker-cba-job-poll-interval"`
+	CBALeaderIdentity          string        `mapstructure:"worker-cba-leader-identity"`
+	CBALeaderLeaseDuration     time.Duration `mapstructure:"worker-cba-leader-lease-duration"`
+	CBALeaderRenewInterval     time.Duration `mapstructure:"worker-cba-leader-renew-interval"`
+
+	WalletDebitSagaRecoveryCRONSpec    cron.Schedule `mapstructure:"worker-wallet-debit-saga-recovery-schedule"`
+	WalletDebitSagaRecoveryStaleAfter  time.Duration `mapstructure:"worker-wallet-debit-saga-recovery-stale-after"`
+	WalletDebitSagaRecoveryMaxAttempts int           `mapstructure:"worker-wallet-debit-saga-recovery-max-attempts"`
+	WalletLienExpiryCRONSpec           cron.Schedule `mapstructure:"worker-wallet-lien-expiry-schedule"`
+	WalletStandingInstructionCRONSpec  cron.Schedule `mapstructure:"worker-wallet-standing-instruction-schedule"`
 }
 
 func (cfg WorkerConfiguration) Validate() error {
@@ -95,6 +141,30 @@ func (cfg WorkerConfiguration) Validate() error {
 	if cfg.CBADormancyCRONSpec == nil {
 		return fmt.Errorf("cba dormancy schedule must be set")
 	}
+	if cfg.CBATermMaturityCRONSpec == nil {
+		return fmt.Errorf("cba term maturity schedule must be set")
+	}
+	if cfg.CBALoanServicingCRONSpec == nil {
+		return fmt.Errorf("cba loan servicing schedule must be set")
+	}
+	if cfg.CBAPenaltyFeeCRONSpec == nil {
+		return fmt.Errorf("cba penalty fee schedule must be set")
+	}
+	if cfg.CBAFeeRecoveryCRONSpec == nil {
+		return fmt.Errorf("cba fee recovery schedule must be set")
+	}
+	if cfg.CBAFeeWriteoffAfterDays < 0 {
+		return fmt.Errorf("cba fee write-off after days must not be negative")
+	}
+	if cfg.CBAKYCExpiryCRONSpec == nil {
+		return fmt.Errorf("cba kyc expiry schedule must be set")
+	}
+	if cfg.CBAKYCExpiryNoticeDays < 0 {
+		return fmt.Errorf("cba kyc expiry notice days must not be negative")
+	}
+	if cfg.CBAScreeningCRONSpec == nil {
+		return fmt.Errorf("cba screening schedule must be set")
+	}
 	if cfg.CBALedgerName == "" {
 		return fmt.Errorf("cba ledger name must be set")
 	}
@@ -104,6 +174,39 @@ func (cfg WorkerConfiguration) Validate() error {
 	if cfg.CBAInterestExpenseAccount == "" {
 		return fmt.Errorf("cba interest expense account must be set")
 	}
+	if cfg.CBAInterestIncomeAccount == "" {
+		return fmt.Errorf("cba interest income account must be set")
+	}
+	if cfg.CBATaxLiabilityAccount == "" {
+		return fmt.Errorf("cba tax liability account must be set")
+	}
+	if cfg.CBAJobCatchUpDays < 0 {
+		return fmt.Errorf("cba job catch up days must not be negative")
+	}
+	if cfg.CBAJobPollInterval < 0 {
+		return fmt.Errorf("cba job poll interval must not be negative")
+	}
+	if cfg.CBALeaderLeaseDuration < 0 {
+		return fmt.Errorf("cba leader lease duration must not be negative")
+	}
+	if cfg.CBALeaderLeaseDuration > 0 && (cfg.CBALeaderRenewInterval <= 0 || cfg.CBALeaderRenewInterval >= cfg.CBALeaderLeaseDuration) {
+		return fmt.Errorf("cba leader renew interval must be greater than zero and shorter than the lease duration")
+	}
+	if cfg.WalletDebitSagaRecoveryCRONSpec == nil {
+		return fmt.Errorf("wallet debit saga recovery schedule must be set")
+	}
+	if cfg.WalletDebitSagaRecoveryStaleAfter <= 0 {
+		return fmt.Errorf("wallet debit saga recovery stale after must be greater than zero")
+	}
+	if cfg.WalletDebitSagaRecoveryMaxAttempts <= 0 {
+		return fmt.Errorf("wallet debit saga recovery max attempts must be greater than zero")
+	}
+	if cfg.WalletLienExpiryCRONSpec == nil {
+		return fmt.Errorf("wallet lien expiry schedule must be set")
+	}
+	if cfg.WalletStandingInstructionCRONSpec == nil {
+		return fmt.Errorf("wallet standing instruction schedule must be set")
+	}
 
 	return nil
 }
@@ -129,9 +232,30 @@ func addWorkerFlags(cmd *cobra.Command) {
 	cmd.Flags().String(WorkerCBAInterestPostingScheduleFlag, "0 10 0 * * *", "Schedule for CBA interest posting (cron format)")
 	cmd.Flags().String(WorkerCBAMaintenanceFeeScheduleFlag, "0 15 0 * * *", "Schedule for CBA maintenance fee processing (cron format)")
 	cmd.Flags().String(WorkerCBADormancyScheduleFlag, "0 20 0 * * *", "Schedule for CBA dormancy detection (cron format)")
+	cmd.Flags().String(WorkerCBATermMaturityScheduleFlag, "0 12 0 * * *", "Schedule for CBA term deposit maturity (cron format)")
+	cmd.Flags().String(WorkerCBALoanServicingScheduleFlag, "0 25 0 * * *", "Schedule for CBA loan disbursement, accrual and repayment collection (cron format)")
+	cmd.Flags().String(WorkerCBAPenaltyFeeScheduleFlag, "0 22 0 * * *", "Schedule for CBA minimum balance and dormancy penalty fees (cron format)")
+	cmd.Flags().String(WorkerCBAFeeRecoveryScheduleFlag, "0 30 0 * * *", "Schedule for CBA recovery of outstanding fees (cron format)")
+	cmd.Flags().Int(WorkerCBAFeeWriteoffAfterDaysFlag, 90, "Number of days after which an outstanding CBA fee requires a write-off (0 disables it)")
+	cmd.Flags().String(WorkerCBAKYCExpiryScheduleFlag, "0 35 0 * * *", "Schedule for CBA KYC expiry and the resulting account restrictions (cron format)")
+	cmd.Flags().Int(WorkerCBAKYCExpiryNoticeDaysFlag, 30, "Number of days ahead of their expiry CBA KYC records are notified (0 disables the notices)")
+	cmd.Flags().String(WorkerCBAKYCExpiryWebhookURLFlag, "", "Webhook receiving the CBA KYC expiry notices (notices are logged if empty)")
+	cmd.Flags().String(WorkerCBAScreeningScheduleFlag, "0 40 0 * * *", "Schedule for CBA screening of the clients against updated watchlists (cron format)")
 	cmd.Flags().String(WorkerCBALedgerNameFlag, "ledgertrack", "Ledger name used for CBA account wallet postings")
 	cmd.Flags().String(WorkerCBAFeeIncomeAccountFlag, "revenue:fee_income", "Revenue account used for CBA fee income postings")
 	cmd.Flags().String(WorkerCBAInterestExpenseAccountFlag, "revenue:interest_expense", "Revenue account used for CBA interest expense postings")
+	cmd.Flags().String(WorkerCBAInterestIncomeAccountFlag, "revenue:interest_income", "Revenue account used for CBA loan interest income postings")
+	cmd.Flags().String(WorkerCBATaxLiabilityAccountFlag, "liabilities:tax:withholding", "Revenue ledger account used for CBA withholding tax owed on interest")
+	cmd.Flags().Int(WorkerCBAJobCatchUpDaysFlag, 31, "Maximum number of missed business dates replayed per CBA job on startup (0 disables catch-up)")
+	cmd.Flags().Duration(WorkerCBAJobPollIntervalFlag, 30*time.Second, "Interval at which manually triggered CBA job runs are picked up (0 disables them)")
+	cmd.Flags().String(WorkerCBALeaderIdentityFlag, "", "Identity of this worker in the CBA scheduler lease (defaults to the host name with a random suffix)")
+	cmd.Flags().Duration(WorkerCBALeaderLeaseDurationFlag, 30*time.Second, "Validity of the CBA scheduler lease held by the leading worker (0 disables leader election)")
+	cmd.Flags().Duration(WorkerCBALeaderRenewIntervalFlag, 10*time.Second, "Interval at which the CBA scheduler lease is renewed or claimed")
+	cmd.Flags().String(WorkerWalletDebitSagaRecoveryScheduleFlag, "0 * * * * *", "Schedule for wallet debit saga recovery (cron format)")
+	cmd.Flags().Duration(WorkerWalletDebitSagaRecoveryStaleAfterFlag, time.Minute, "Idle time after which an unfinished wallet debit saga is recovered")
+	cmd.Flags().Int(WorkerWalletDebitSagaRecoveryMaxAttemptsFlag, 5, "Forward attempts before an unfinished wallet debit saga is compensated")
+	cmd.Flags().String(WorkerWalletLienExpiryScheduleFlag, "0 * * * * *", "Schedule for releasing expired wallet liens (cron format)")
+	cmd.Flags().String(WorkerWalletStandingInstructionScheduleFlag, "0 * * * * *", "Schedule for executing due wallet standing instructions (cron format)")
 }
 
 // NewWorkerCommand constructs the "worker" Cobra command which initializes and runs the worker service using loaded configuration and composed FX modules.
@@ -177,8 +301,15 @@ func NewWorkerCommand() *cobra.Command {
 					SchemaEnforcementMode: cfg.commonConfig.SchemaEnforcementMode,
 				}),
 				bus.NewFxModule(),
-				currency.NewFXModule(),
-				cba.NewFXModule(),
+				currency.NewFXModule(currency.ModuleConfig{
+					RefreshInterval: cfg.CurrencyRefreshInterval,
+				}),
+				cba.NewFXModule(cba.ModuleConfig{
+					ScreeningWatchlists: cfg.ScreeningWatchlists,
+					ScreeningThreshold:  cfg.ScreeningThreshold,
+					MonitoringRulesFile: cfg.MonitoringRulesFile,
+				}),
+				wallets.NewFXModule(),
 				newWorkerModule(cfg.WorkerConfiguration),
 				worker.NewGRPCServerFXModule(worker.GRPCServerModuleConfig{
 					Address: cfg.Address,
@@ -224,6 +355,8 @@ func newWorkerModule(configuration WorkerConfiguration) fx.Option {
 				LedgerName:             configuration.CBALedgerName,
 				FeeIncomeAccount:       configuration.CBAFeeIncomeAccount,
 				InterestExpenseAccount: configuration.CBAInterestExpenseAccount,
+				InterestIncomeAccount:  configuration.CBAInterestIncomeAccount,
+				TaxLiabilityAccount:    configuration.CBATaxLiabilityAccount,
 			},
 			InterestAccrualRunnerConfig: scheduler.InterestAccrualRunnerConfig{
 				Schedule: configuration.CBAInterestAccrualCRONSpec,
@@ -237,6 +370,49 @@ func newWorkerModule(configuration WorkerConfiguration) fx.Option {
 			DormancyRunnerConfig: scheduler.DormancyRunnerConfig{
 				Schedule: configuration.CBADormancyCRONSpec,
 			},
+			TermMaturityRunnerConfig: scheduler.TermMaturityRunnerConfig{
+				Schedule: configuration.CBATermMaturityCRONSpec,
+			},
+			LoanServicingRunnerConfig: scheduler.LoanServicingRunnerConfig{
+				Schedule: configuration.CBALoanServicingCRONSpec,
+			},
+			PenaltyFeeRunnerConfig: scheduler.PenaltyFeeRunnerConfig{
+				Schedule: configuration.CBAPenaltyFeeCRONSpec,
+			},
+			FeeRecoveryRunnerConfig: scheduler.FeeRecoveryRunnerConfig{
+				Schedule:          configuration.CBAFeeRecoveryCRONSpec,
+				WriteoffAfterDays: configuration.CBAFeeWriteoffAfterDays,
+			},
+			KYCExpiryRunnerConfig: scheduler.KYCExpiryRunnerConfig{
+				Schedule:   configuration.CBAKYCExpiryCRONSpec,
+				NoticeDays: configuration.CBAKYCExpiryNoticeDays,
+				WebhookURL: configuration.CBAKYCExpiryWebhookURL,
+			},
+			ScreeningRunnerConfig: scheduler.ScreeningRunnerConfig{
+				Schedule: configuration.CBAScreeningCRONSpec,
+			},
+			JobsConfig: scheduler.JobsConfig{
+				CatchUpDays:  configuration.CBAJobCatchUpDays,
+				PollInterval: configuration.CBAJobPollInterval,
+			},
+			LeaderElectionConfig: scheduler.LeaderElectionConfig{
+				Identity:      configuration.CBALeaderIdentity,
+				LeaseDuration: configuration.CBALeaderLeaseDuration,
+				RenewInterval: configuration.CBALeaderRenewInterval,
+			},
+		},
+		WalletSchedulerConfig: walletscheduler.ModuleConfig{
+			DebitSagaRecoveryRunnerConfig: walletscheduler.DebitSagaRecoveryRunnerConfig{
+				Schedule:    configuration.WalletDebitSagaRecoveryCRONSpec,
+				StaleAfter:  configuration.WalletDebitSagaRecoveryStaleAfter,
+				MaxAttempts: configuration.WalletDebitSagaRecoveryMaxAttempts,
+			},
+			LienExpiryRunnerConfig: walletscheduler.LienExpiryRunnerConfig{
+				Schedule: configuration.WalletLienExpiryCRONSpec,
+			},
+			StandingInstructionRunnerConfig: walletscheduler.StandingInstructionRunnerConfig{
+				Schedule: configuration.WalletStandingInstructionCRONSpec,
+			},
 		},
 	})
 }
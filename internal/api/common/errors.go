@@ -26,6 +26,7 @@ const (
 	ErrLedgerAlreadyExists = "LEDGER_ALREADY_EXISTS"
 	ErrSchemaAlreadyExists = "SCHEMA_ALREADY_EXISTS"
 	ErrSchemaNotSpecified  = "SCHEMA_NOT_SPECIFIED"
+	ErrForbidden           = "FORBIDDEN"
 
 	ErrInterpreterParse   = "INTERPRETER_PARSE"
 	ErrInterpreterRuntime = "INTERPRETER_RUNTIME"
@@ -15,6 +15,9 @@ import (
 	"github.com/formancehq/ledger/internal/cba/services"
 	channelservices "github.com/formancehq/ledger/internal/channels/services"
 	"github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	exchangeservices "github.com/formancehq/ledger/internal/exchange/services"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
 )
 
 type BulkConfig struct {
@@ -28,6 +31,9 @@ type Config struct {
 	Bulk       BulkConfig
 	Pagination common.PaginationConfig
 	Exporters  bool
+	// TrustActorHeader identifies callers by the actor header when
+	// authentication is disabled, for development.
+	TrustActorHeader bool
 }
 
 func Module(cfg Config) fx.Option {
@@ -44,6 +50,21 @@ func Module(cfg Config) fx.Option {
 			financeReportingService services.FinanceReportingService,
 			channelFeeConfigService channelservices.ChannelFeeConfigService,
 			channelRevenueReportingService channelservices.ChannelRevenueReportingService,
+			debitSagaCoordinator walletservices.DebitSagaCoordinator,
+			lienService walletservices.LienService,
+			fxRateService exchangeservices.RateService,
+			fxConversionService exchangeservices.ConversionService,
+			currencyAdminService currencyregistry.AdminService,
+			walletService walletservices.WalletService,
+			standingInstructionService walletservices.StandingInstructionService,
+			jobService services.JobService,
+			termDepositService services.TermDepositService,
+			loanService services.LoanService,
+			feeService services.FeeService,
+			accountHolderService services.AccountHolderService,
+			approvalService services.ApprovalService,
+			screeningService services.ScreeningService,
+			monitoringService services.MonitoringService,
 		) chi.Router {
 			return NewRouter(
 				backend,
@@ -58,6 +79,7 @@ func Module(cfg Config) fx.Option {
 				)),
 				WithPaginationConfiguration(cfg.Pagination),
 				WithExporters(cfg.Exporters),
+				WithTrustActorHeader(cfg.TrustActorHeader),
 				WithProductService(productService),
 				WithClientService(clientService),
 				WithKYCService(kycService),
@@ -66,6 +88,21 @@ func Module(cfg Config) fx.Option {
 				WithFinanceReportingService(financeReportingService),
 				WithChannelFeeConfigService(channelFeeConfigService),
 				WithChannelRevenueReportingService(channelRevenueReportingService),
+				WithDebitSagaCoordinator(debitSagaCoordinator),
+				WithLienService(lienService),
+				WithFXRateService(fxRateService),
+				WithFXConversionService(fxConversionService),
+				WithCurrencyAdminService(currencyAdminService),
+				WithWalletService(walletService),
+				WithStandingInstructionService(standingInstructionService),
+				WithJobService(jobService),
+				WithTermDepositService(termDepositService),
+				WithLoanService(loanService),
+				WithFeeService(feeService),
+				WithAccountHolderService(accountHolderService),
+				WithApprovalService(approvalService),
+				WithScreeningService(screeningService),
+				WithMonitoringService(monitoringService),
 			)
 		}),
 		health.Module(),
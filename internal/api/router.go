@@ -26,6 +26,9 @@ import (
 	"github.com/formancehq/ledger/internal/cba/services"
 	channelservices "github.com/formancehq/ledger/internal/channels/services"
 	"github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	exchangeservices "github.com/formancehq/ledger/internal/exchange/services"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
 )
 
 // todo: refine textual errors
@@ -99,6 +102,7 @@ func NewRouter(
 		v2.WithDefaultBulkHandlerFactories(routerOptions.bulkMaxSize),
 		v2.WithPaginationConfig(routerOptions.paginationConfig),
 		v2.WithExporters(routerOptions.exporters),
+		v2.WithTrustActorHeader(routerOptions.trustActorHeader),
 		v2.WithProductService(routerOptions.productService),
 		v2.WithClientService(routerOptions.clientService),
 		v2.WithKYCService(routerOptions.kycService),
@@ -107,6 +111,21 @@ func NewRouter(
 		v2.WithFinanceReportingService(routerOptions.financeReportingService),
 		v2.WithChannelFeeConfigService(routerOptions.channelFeeConfigService),
 		v2.WithChannelRevenueReportingService(routerOptions.channelRevenueReportingService),
+		v2.WithDebitSagaCoordinator(routerOptions.debitSagaCoordinator),
+		v2.WithLienService(routerOptions.lienService),
+		v2.WithFXRateService(routerOptions.fxRateService),
+		v2.WithFXConversionService(routerOptions.fxConversionService),
+		v2.WithCurrencyAdminService(routerOptions.currencyAdminService),
+		v2.WithWalletService(routerOptions.walletService),
+		v2.WithStandingInstructionService(routerOptions.standingInstructionService),
+		v2.WithJobService(routerOptions.jobService),
+		v2.WithTermDepositService(routerOptions.termDepositService),
+		v2.WithLoanService(routerOptions.loanService),
+		v2.WithFeeService(routerOptions.feeService),
+		v2.WithAccountHolderService(routerOptions.accountHolderService),
+		v2.WithApprovalService(routerOptions.approvalService),
+		v2.WithScreeningService(routerOptions.screeningService),
+		v2.WithMonitoringService(routerOptions.monitoringService),
 	)
 	mux.Handle("/v2*", http.StripPrefix("/v2", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		chi.RouteContext(r.Context()).Reset()
@@ -130,6 +149,7 @@ type routerOptions struct {
 	bulkerFactory           bulking.BulkerFactory
 	paginationConfig        common.PaginationConfig
 	exporters               bool
+	trustActorHeader        bool
 	productService          services.ProductService
 	clientService           services.ClientService
 	kycService              services.KYCService
@@ -138,6 +158,21 @@ type routerOptions struct {
 	financeReportingService services.FinanceReportingService
 	channelFeeConfigService channelservices.ChannelFeeConfigService
 	channelRevenueReportingService channelservices.ChannelRevenueReportingService
+	debitSagaCoordinator           walletservices.DebitSagaCoordinator
+	lienService                    walletservices.LienService
+	fxRateService                  exchangeservices.RateService
+	fxConversionService            exchangeservices.ConversionService
+	currencyAdminService           currencyregistry.AdminService
+	walletService                  walletservices.WalletService
+	standingInstructionService     walletservices.StandingInstructionService
+	jobService                     services.JobService
+	termDepositService             services.TermDepositService
+	loanService                    services.LoanService
+	feeService                     services.FeeService
+	accountHolderService           services.AccountHolderService
+	approvalService                services.ApprovalService
+	screeningService               services.ScreeningService
+	monitoringService              services.MonitoringService
 }
 
 type RouterOption func(ro *routerOptions)
@@ -172,6 +207,12 @@ func WithExporters(v bool) RouterOption {
 	}
 }
 
+func WithTrustActorHeader(v bool) RouterOption {
+	return func(ro *routerOptions) {
+		ro.trustActorHeader = v
+	}
+}
+
 func WithProductService(productService services.ProductService) RouterOption {
 	return func(ro *routerOptions) {
 		ro.productService = productService
@@ -220,6 +261,96 @@ func WithChannelRevenueReportingService(channelRevenueReportingService channelse
 	}
 }
 
+func WithDebitSagaCoordinator(debitSagaCoordinator walletservices.DebitSagaCoordinator) RouterOption {
+	return func(ro *routerOptions) {
+		ro.debitSagaCoordinator = debitSagaCoordinator
+	}
+}
+
+func WithLienService(lienService walletservices.LienService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.lienService = lienService
+	}
+}
+
+func WithFXRateService(fxRateService exchangeservices.RateService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.fxRateService = fxRateService
+	}
+}
+
+func WithFXConversionService(fxConversionService exchangeservices.ConversionService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.fxConversionService = fxConversionService
+	}
+}
+
+func WithCurrencyAdminService(currencyAdminService currencyregistry.AdminService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.currencyAdminService = currencyAdminService
+	}
+}
+
+func WithWalletService(walletService walletservices.WalletService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.walletService = walletService
+	}
+}
+
+func WithStandingInstructionService(standingInstructionService walletservices.StandingInstructionService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.standingInstructionService = standingInstructionService
+	}
+}
+
+func WithJobService(jobService services.JobService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.jobService = jobService
+	}
+}
+
+func WithTermDepositService(termDepositService services.TermDepositService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.termDepositService = termDepositService
+	}
+}
+
+func WithAccountHolderService(accountHolderService services.AccountHolderService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.accountHolderService = accountHolderService
+	}
+}
+
+func WithApprovalService(approvalService services.ApprovalService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.approvalService = approvalService
+	}
+}
+
+func WithScreeningService(screeningService services.ScreeningService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.screeningService = screeningService
+	}
+}
+
+func WithMonitoringService(monitoringService services.MonitoringService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.monitoringService = monitoringService
+	}
+}
+
+func WithLoanService(loanService services.LoanService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.loanService = loanService
+	}
+}
+
+func WithFeeService(feeService services.FeeService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.feeService = feeService
+	}
+}
+
 func WithMeterProvider(mp metric.MeterProvider) RouterOption {
 	return func(ro *routerOptions) {
 		ro.meterProvider = mp
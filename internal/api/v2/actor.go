@@ -0,0 +1,62 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/oidc"
+)
+
+// HeaderActor identifies who calls the API when authentication is disabled
+// and the router was told to trust it, for development. It is otherwise
+// ignored, callers being identified by their token.
+const HeaderActor = "Formance-Actor"
+
+type actorKey struct{}
+
+// resolveActor records who calls the API, to attribute the decisions of the
+// maker-checker and review workflows. Authenticated callers are the subject
+// of their token, or its client when the token was issued to a client. The
+// token has been verified by the authentication middleware by then.
+func resolveActor(authenticator auth.Authenticator, trustActorHeader bool) func(http.Handler) http.Handler {
+	_, authenticated := authenticator.(*auth.JWTAuth)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var actor string
+			switch {
+			case authenticated:
+				actor = tokenActor(r)
+			case trustActorHeader:
+				actor = strings.TrimSpace(r.Header.Get(HeaderActor))
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorKey{}, actor)))
+		})
+	}
+}
+
+func tokenActor(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token, ok = strings.CutPrefix(r.Header.Get("Authorization"), "bearer ")
+	}
+	if !ok {
+		return ""
+	}
+	claims := &oidc.AccessTokenClaims{}
+	if _, err := oidc.ParseToken(strings.TrimSpace(token), claims); err != nil {
+		return ""
+	}
+	if claims.Subject != "" {
+		return claims.Subject
+	}
+	return claims.ClientID
+}
+
+// actorFromRequest returns who calls the API, or an empty string when the
+// caller cannot be identified.
+func actorFromRequest(r *http.Request) string {
+	actor, _ := r.Context().Value(actorKey{}).(string)
+	return actor
+}
@@ -0,0 +1,53 @@
+package v2
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/auth"
+)
+
+func TestResolveActor(t *testing.T) {
+	t.Parallel()
+
+	token := func(claims string) string {
+		return "Bearer " + base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." +
+			base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+	}
+	jwtAuth := auth.NewJWTAuth(nil, "https://issuer", "ledger", false)
+
+	for name, tc := range map[string]struct {
+		authenticator    auth.Authenticator
+		trustActorHeader bool
+		authorization    string
+		expected         string
+	}{
+		"token subject":                {authenticator: jwtAuth, authorization: token(`{"sub":"alice"}`), expected: "alice"},
+		"token client":                 {authenticator: jwtAuth, authorization: token(`{"client_id":"backoffice"}`), expected: "backoffice"},
+		"header ignored with auth":     {authenticator: jwtAuth, trustActorHeader: true, authorization: token(`{"sub":"alice"}`), expected: "alice"},
+		"header trusted without auth":  {authenticator: auth.NewNoAuth(), trustActorHeader: true, expected: "mallory"},
+		"header ignored without trust": {authenticator: auth.NewNoAuth(), expected: ""},
+		"token ignored without auth":   {authenticator: auth.NewNoAuth(), authorization: token(`{"sub":"alice"}`), expected: ""},
+		"malformed token":              {authenticator: jwtAuth, authorization: "Bearer nope", expected: ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var actor string
+			handler := resolveActor(tc.authenticator, tc.trustActorHeader)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				actor = actorFromRequest(r)
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set(HeaderActor, "mallory")
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			require.Equal(t, tc.expected, actor)
+		})
+	}
+}
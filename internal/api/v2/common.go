@@ -1,20 +1,25 @@
 package v2
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/iancoleman/strcase"
 
+	"github.com/formancehq/go-libs/v3/api"
 	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
 	. "github.com/formancehq/go-libs/v3/collectionutils"
 	"github.com/formancehq/go-libs/v3/query"
 	"github.com/formancehq/go-libs/v3/time"
 
 	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/repositories"
 	storagecommon "github.com/formancehq/ledger/internal/storage/common"
 )
 
@@ -164,3 +169,165 @@ func getResourceQuery[v any](r *http.Request, modifiers ...func(*v) error) (*sto
 		Opts:    options,
 	}, nil
 }
+
+// getLimitOffset reads the limit and offset query parameters of the lists
+// served from the system schema. It writes the error response and returns
+// false when one of them is invalid.
+func getLimitOffset(w http.ResponseWriter, r *http.Request, defaultLimit int) (int, int, bool) {
+	limit, offset := defaultLimit, 0
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			api.BadRequest(w, common.ErrValidation, errors.New("invalid limit"))
+			return 0, 0, false
+		}
+		limit = i
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("offset")); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			api.BadRequest(w, common.ErrValidation, errors.New("invalid offset"))
+			return 0, 0, false
+		}
+		offset = i
+	}
+	return limit, offset, true
+}
+
+// systemListOptions are the options of the cursors on a listing served from
+// the system schema: its filters and, past the first page, the position the
+// page starts after or, for a reversed cursor, ends before.
+type systemListOptions[FILTER any] struct {
+	Filter   FILTER               `json:"filter"`
+	Position *repositories.Keyset `json:"position,omitempty"`
+}
+
+// getSystemPaginatedQuery reads the page of a listing served from the
+// system schema. The first page is read from the page size, the sort, on the
+// first of columns by default, and the filters read by readFilter, the next
+// ones from the cursor which carries them.
+func getSystemPaginatedQuery[FILTER any](
+	r *http.Request,
+	paginationConfig common.PaginationConfig,
+	columns []string,
+	readFilter func(filter *FILTER) error,
+) (*bunpaginate.ColumnPaginatedQuery[systemListOptions[FILTER]], error) {
+	query, err := bunpaginate.Extract(r, func() (*bunpaginate.ColumnPaginatedQuery[systemListOptions[FILTER]], error) {
+		query := &bunpaginate.ColumnPaginatedQuery[systemListOptions[FILTER]]{
+			Column: columns[0],
+			Order:  bunpaginate.OrderDesc,
+		}
+		if value := strings.TrimSpace(r.URL.Query().Get("sort")); value != "" {
+			parts := strings.SplitN(value, ":", 2)
+			query.Column = strcase.ToSnake(parts[0])
+			if !slices.Contains(columns, query.Column) {
+				return nil, fmt.Errorf("invalid sort column %s, expected one of %s", parts[0], strings.Join(columns, ", "))
+			}
+			if len(parts) > 1 {
+				switch strings.ToLower(parts[1]) {
+				case "desc":
+				case "asc":
+					query.Order = bunpaginate.OrderAsc
+				default:
+					return nil, fmt.Errorf("invalid order: %s", parts[1])
+				}
+			}
+		}
+
+		if err := readFilter(&query.Options.Filter); err != nil {
+			return nil, err
+		}
+		return query, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	defaultPageSize := paginationConfig.DefaultPageSize
+	if query.PageSize != 0 {
+		defaultPageSize = query.PageSize
+	}
+	query.PageSize, err = bunpaginate.GetPageSize(
+		r,
+		bunpaginate.WithMaxPageSize(paginationConfig.MaxPageSize),
+		bunpaginate.WithDefaultPageSize(defaultPageSize),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+// paginateSystemList lists a page of a listing served from the system
+// schema, asking list for one more item than the page holds to know whether
+// there is a page past it. The cursors carry the positions of the first and
+// last items of the page, read by position.
+func paginateSystemList[FILTER, T any](
+	query bunpaginate.ColumnPaginatedQuery[systemListOptions[FILTER]],
+	list func(filter FILTER, page repositories.Page) ([]T, error),
+	position func(item T, column string) repositories.Keyset,
+) (*bunpaginate.Cursor[T], error) {
+	page := repositories.Page{
+		Sort: &repositories.Sort{
+			Column: query.Column,
+			Order:  query.Order,
+		},
+		Limit: int(query.PageSize) + 1,
+	}
+	if query.Reverse {
+		page.Before = query.Options.Position
+	} else {
+		page.After = query.Options.Position
+	}
+	items, err := list(query.Options.Filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(items) > int(query.PageSize)
+	if hasMore {
+		items = items[:query.PageSize]
+	}
+	if query.Reverse {
+		slices.Reverse(items)
+	}
+
+	var previous, next *bunpaginate.ColumnPaginatedQuery[systemListOptions[FILTER]]
+	if len(items) > 0 {
+		if (query.Reverse && hasMore) || (!query.Reverse && query.Options.Position != nil) {
+			first := position(items[0], query.Column)
+			cp := query
+			cp.Reverse = true
+			cp.Options.Position = &first
+			previous = &cp
+		}
+		if (!query.Reverse && hasMore) || (query.Reverse && query.Options.Position != nil) {
+			last := position(items[len(items)-1], query.Column)
+			cp := query
+			cp.Reverse = false
+			cp.Options.Position = &last
+			next = &cp
+		}
+	}
+
+	return &bunpaginate.Cursor[T]{
+		PageSize: int(query.PageSize),
+		HasMore:  next != nil,
+		Previous: previous.EncodeAsCursor(),
+		Next:     next.EncodeAsCursor(),
+		Data:     items,
+	}, nil
+}
+
+// getDateRange reads the created_after and created_before query parameters.
+func getDateRange(r *http.Request) (*time.Time, *time.Time, error) {
+	after, err := getDate(r, "created_after")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid created_after: %w", err)
+	}
+	before, err := getDate(r, "created_before")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid created_before: %w", err)
+	}
+	return after, before, nil
+}
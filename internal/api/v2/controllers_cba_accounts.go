@@ -28,6 +28,7 @@ import (
 	"github.com/formancehq/ledger/internal/cba/services"
 	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
 	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
 	"github.com/formancehq/ledger/internal/machine/vm"
 	storagecommon "github.com/formancehq/ledger/internal/storage/common"
 	ledgerstore "github.com/formancehq/ledger/internal/storage/ledger"
@@ -48,6 +49,14 @@ type accountWalletInfoResponse struct {
 	LienAddress      string `json:"lien_address"`
 }
 
+// AccountDebitRequest debits an account or places a lien on it. Signatories
+// are the clients authorizing the operation when the account has signing
+// rules.
+type AccountDebitRequest struct {
+	WalletTransactionRequest
+	Signatories []uuid.UUID `json:"signatories,omitempty"`
+}
+
 type accountDetailsResponse struct {
 	models.Account
 	WalletInfo accountWalletInfoResponse `json:"wallet_info"`
@@ -75,43 +84,57 @@ func openAccount(accountService services.AccountService) http.HandlerFunc {
 	}
 }
 
-func listCBAAccounts(accountService services.AccountService) http.HandlerFunc {
+func listCBAAccounts(accountService services.AccountService, paginationConfig common.PaginationConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		filter := repositories.AccountFilter{}
-		if clientID := strings.TrimSpace(r.URL.Query().Get("client_id")); clientID != "" {
-			id, err := uuid.Parse(clientID)
-			if err != nil {
-				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid client_id: %w", err))
-				return
+		query, err := getSystemPaginatedQuery(r, paginationConfig, repositories.AccountSortColumns, func(filter *repositories.AccountFilter) error {
+			for name, target := range map[string]**uuid.UUID{
+				"client_id":  &filter.ClientID,
+				"holder_id":  &filter.HolderID,
+				"product_id": &filter.ProductID,
+			} {
+				value := strings.TrimSpace(r.URL.Query().Get(name))
+				if value == "" {
+					continue
+				}
+				id, err := uuid.Parse(value)
+				if err != nil {
+					return fmt.Errorf("invalid %s: %w", name, err)
+				}
+				*target = &id
 			}
-			filter.ClientID = &id
-		}
-		if productID := strings.TrimSpace(r.URL.Query().Get("product_id")); productID != "" {
-			id, err := uuid.Parse(productID)
+			if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
+				filter.Status = &status
+			}
+			if search := strings.TrimSpace(r.URL.Query().Get("search")); search != "" {
+				filter.Search = &search
+			}
+			after, before, err := getDateRange(r)
 			if err != nil {
-				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid product_id: %w", err))
-				return
+				return err
 			}
-			filter.ProductID = &id
-		}
-		if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
-			filter.Status = &status
+			filter.OpenedAfter, filter.OpenedBefore = toStdTimePtr(after), toStdTimePtr(before)
+			return nil
+		})
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
 		}
 
-		accounts, err := accountService.List(r.Context(), filter)
+		cursor, err := paginateSystemList(*query, func(filter repositories.AccountFilter, page repositories.Page) ([]models.Account, error) {
+			filter.Page = &page
+			return accountService.List(r.Context(), filter)
+		}, repositories.AccountKeyset)
 		if err != nil {
 			handleAccountError(w, r, err)
 			return
 		}
-		api.Ok(w, map[string]any{
-			"accounts": accounts,
-		})
+		api.RenderCursor(w, *cursor)
 	}
 }
 
 func ledgerAwareAccountList(routerOptions routerOptions) http.HandlerFunc {
 	coreHandler := listAccounts(routerOptions.paginationConfig)
-	cbaHandler := listCBAAccounts(routerOptions.accountService)
+	cbaHandler := listCBAAccounts(routerOptions.accountService, routerOptions.paginationConfig)
 	return func(w http.ResponseWriter, r *http.Request) {
 		if chi.URLParam(r, "ledger") == "ledgertrack" {
 			cbaHandler.ServeHTTP(w, r)
@@ -190,7 +213,7 @@ func reactivateAccount(accountService services.AccountService) http.HandlerFunc
 	return lifecycleAccountHandler(accountService.Reactivate)
 }
 
-func closeAccount(accountService services.AccountService) http.HandlerFunc {
+func closeAccount(accountService services.AccountService, feeService services.FeeService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		accountID, err := getCBAAccountID(r)
 		if err != nil {
@@ -210,6 +233,14 @@ func closeAccount(accountService services.AccountService) http.HandlerFunc {
 			common.HandleCommonErrors(w, r, err)
 			return
 		}
+		if account.Status != models.AccountStatusClosed {
+			penalty, err := debitEarlyClosurePenalty(r, feeService, account, balance)
+			if err != nil {
+				handleFeeError(w, r, err)
+				return
+			}
+			balance -= penalty
+		}
 
 		account, err = accountService.Close(r.Context(), accountID, balance)
 		if err != nil {
@@ -279,7 +310,7 @@ func creditAccount(accountService services.AccountService) http.HandlerFunc {
 
 		amount, err := parseAmount(req.Amount, account.Currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid amount: %v", err))
+			writeAmountError(w, "amount", err)
 			return
 		}
 		if req.Reference == "" {
@@ -302,11 +333,11 @@ func creditAccount(accountService services.AccountService) http.HandlerFunc {
 		accountUser := walletAvailableAddress(account.WalletID, account.Currency)
 		accountSystem := systemControlAddress(account.WalletID, account.Currency)
 		script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @%s allowing unbounded overdraft
 			destination = @%s
 		)
-	`, account.Currency, amount, accountSystem, accountUser)
+	`, currencyregistry.Asset(account.Currency), amount, accountSystem, accountUser)
 
 		params := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
 			IdempotencyKey: r.Header.Get("Idempotency-Key"),
@@ -343,7 +374,7 @@ func creditAccount(accountService services.AccountService) http.HandlerFunc {
 	}
 }
 
-func debitAccount(accountService services.AccountService, sys systemcontroller.Controller) http.HandlerFunc {
+func debitAccount(accountService services.AccountService, holderService services.AccountHolderService, feeService services.FeeService, sys systemcontroller.Controller) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		accountID, err := getCBAAccountID(r)
 		if err != nil {
@@ -356,23 +387,29 @@ func debitAccount(accountService services.AccountService, sys systemcontroller.C
 			return
 		}
 
-		var req WalletTransactionRequest
+		var req AccountDebitRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			api.BadRequest(w, common.ErrValidation, err)
 			return
 		}
 		amount, err := parseAmount(req.Amount, account.Currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid amount: %v", err))
+			writeAmountError(w, "amount", err)
 			return
 		}
 		if req.Reference == "" {
 			api.BadRequest(w, common.ErrValidation, fmt.Errorf("reference is required"))
 			return
 		}
+		if holderService != nil {
+			if err := holderService.AuthorizeDebit(r.Context(), accountID, amount, req.Signatories); err != nil {
+				handleAccountError(w, r, err)
+				return
+			}
+		}
 		channelAmount, err := parseAmount(req.ChannelAmount, account.Currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid channelAmount: %v", err))
+			writeAmountError(w, "channelAmount", err)
 			return
 		}
 		if req.ChannelID != "" {
@@ -394,6 +431,19 @@ func debitAccount(accountService services.AccountService, sys systemcontroller.C
 		}
 		usageAt := time.Now().UTC()
 		if _, err := accountService.ValidateDebit(r.Context(), accountID, amount, currentBalance, usageAt); err != nil {
+			if errors.Is(err, services.ErrAccountInsufficientFunds) {
+				chargeDebitPenalty(r, feeService, accountID, services.PenaltyTrigger{
+					Penalty:   models.PenaltyInsufficientFunds,
+					Amount:    amount,
+					Reference: req.Reference,
+					At:        usageAt,
+				})
+			}
+			handleAccountError(w, r, err)
+			return
+		}
+		overdraft, err := accountService.Overdraft(r.Context(), accountID)
+		if err != nil {
 			handleAccountError(w, r, err)
 			return
 		}
@@ -401,11 +451,11 @@ func debitAccount(accountService services.AccountService, sys systemcontroller.C
 		accountUser := walletAvailableAddress(account.WalletID, account.Currency)
 		accountSystem := systemControlAddress(account.WalletID, account.Currency)
 		script := fmt.Sprintf(`
-		send [%s/2 %d] (
-			source = @%s
+		send [%s %d] (
+			source = %s
 			destination = @%s
 		)
-	`, account.Currency, amount, accountUser, accountSystem)
+	`, currencyregistry.Asset(account.Currency), amount, walletDebitSource(accountUser, account.Currency, overdraft), accountSystem)
 
 		params := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
 			IdempotencyKey: r.Header.Get("Idempotency-Key"),
@@ -422,6 +472,12 @@ func debitAccount(accountService services.AccountService, sys systemcontroller.C
 		_, tx, _, err := l.CreateTransaction(r.Context(), params)
 		if err != nil {
 			if strings.Contains(strings.ToLower(err.Error()), "insufficient fund") {
+				chargeDebitPenalty(r, feeService, accountID, services.PenaltyTrigger{
+					Penalty:   models.PenaltyInsufficientFunds,
+					Amount:    amount,
+					Reference: req.Reference,
+					At:        usageAt,
+				})
 				api.WriteErrorResponse(w, http.StatusPaymentRequired, common.ErrInsufficientFund, err)
 				return
 			}
@@ -444,11 +500,11 @@ func debitAccount(accountService services.AccountService, sys systemcontroller.C
 			}
 			channelAccount := fmt.Sprintf("channel:%s", req.ChannelID)
 			channelScript := fmt.Sprintf(`
-				send [%s/2 %d] (
+				send [%s %d] (
 					source = @%s allowing unbounded overdraft
 					destination = @world
 				)
-			`, account.Currency, channelAmount, channelAccount)
+			`, currencyregistry.Asset(account.Currency), channelAmount, channelAccount)
 			cParams := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
 				Input: ledgercontroller.CreateTransaction{
 					RunScript: vm.RunScript{
@@ -467,7 +523,7 @@ func debitAccount(accountService services.AccountService, sys systemcontroller.C
 			respMetadata["channel_tx_id"] = fmt.Sprintf("%d", cTx.Transaction.ID)
 
 			if volumes, ok := cTx.Transaction.PostCommitVolumes[channelAccount]; ok {
-				asset := fmt.Sprintf("%s/2", account.Currency)
+				asset := currencyregistry.Asset(account.Currency)
 				if vol, ok := volumes[asset]; ok {
 					warningMsg = fmt.Sprintf("Channel balance: %s %s", vol.Balance().String(), account.Currency)
 					if vol.Balance().Sign() < 0 {
@@ -485,11 +541,11 @@ func debitAccount(accountService services.AccountService, sys systemcontroller.C
 					return
 				}
 				revenueScript := fmt.Sprintf(`
-					send [%s/2 %d] (
+					send [%s %d] (
 						source = @world
 						destination = @revenue:accumulated
 					)
-				`, account.Currency, revenue)
+				`, currencyregistry.Asset(account.Currency), revenue)
 				rParams := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
 					Input: ledgercontroller.CreateTransaction{
 						RunScript: vm.RunScript{
@@ -523,11 +579,19 @@ func debitAccount(accountService services.AccountService, sys systemcontroller.C
 		if warningMsg != "" {
 			response["warning"] = warningMsg
 		}
+		if penalty := chargeDebitPenalty(r, feeService, accountID, services.PenaltyTrigger{
+			Penalty:   models.PenaltyExcessWithdrawals,
+			Amount:    amount,
+			Reference: req.Reference,
+			At:        usageAt,
+		}); penalty != nil {
+			response["penalty"] = penalty
+		}
 		api.Created(w, response)
 	}
 }
 
-func lienAccount(accountService services.AccountService) http.HandlerFunc {
+func lienAccount(accountService services.AccountService, holderService services.AccountHolderService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		accountID, err := getCBAAccountID(r)
 		if err != nil {
@@ -540,20 +604,26 @@ func lienAccount(accountService services.AccountService) http.HandlerFunc {
 			return
 		}
 
-		var req WalletTransactionRequest
+		var req AccountDebitRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			api.BadRequest(w, common.ErrValidation, err)
 			return
 		}
 		amount, err := parseAmount(req.Amount, account.Currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid amount: %v", err))
+			writeAmountError(w, "amount", err)
 			return
 		}
 		if req.Reference == "" {
 			api.BadRequest(w, common.ErrValidation, fmt.Errorf("reference is required"))
 			return
 		}
+		if holderService != nil {
+			if err := holderService.AuthorizeDebit(r.Context(), accountID, amount, req.Signatories); err != nil {
+				handleAccountError(w, r, err)
+				return
+			}
+		}
 
 		l := common.LedgerFromContext(r.Context())
 		currentBalance, err := readAvailableBalance(r.Context(), l, account.WalletID, account.Currency)
@@ -570,11 +640,11 @@ func lienAccount(accountService services.AccountService) http.HandlerFunc {
 		accountAvailable := walletAvailableAddress(account.WalletID, account.Currency)
 		accountLien := walletLienAddress(account.WalletID, account.Currency)
 		script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @%s
 			destination = @%s
 		)
-	`, account.Currency, amount, accountAvailable, accountLien)
+	`, currencyregistry.Asset(account.Currency), amount, accountAvailable, accountLien)
 
 		params := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
 			IdempotencyKey: r.Header.Get("Idempotency-Key"),
@@ -630,7 +700,7 @@ func releaseAccountLien(accountService services.AccountService, sys systemcontro
 		}
 		amount, err := parseAmount(req.Amount, account.Currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid amount: %v", err))
+			writeAmountError(w, "amount", err)
 			return
 		}
 		if req.Reference == "" {
@@ -643,7 +713,7 @@ func releaseAccountLien(accountService services.AccountService, sys systemcontro
 		}
 		channelAmount, err := parseAmount(req.ChannelAmount, account.Currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid channelAmount: %v", err))
+			writeAmountError(w, "channelAmount", err)
 			return
 		}
 		if req.ChannelID != "" {
@@ -670,18 +740,18 @@ func releaseAccountLien(accountService services.AccountService, sys systemcontro
 		mode := strings.TrimSpace(req.Mode)
 		if mode == "PAY" {
 			script = fmt.Sprintf(`
-				send [%s/2 %d] (
+				send [%s %d] (
 					source = @%s
 					destination = @world
 				)
-			`, account.Currency, amount, accountLien)
+			`, currencyregistry.Asset(account.Currency), amount, accountLien)
 		} else {
 			script = fmt.Sprintf(`
-				send [%s/2 %d] (
+				send [%s %d] (
 					source = @%s
 					destination = @%s
 				)
-			`, account.Currency, amount, accountLien, accountAvailable)
+			`, currencyregistry.Asset(account.Currency), amount, accountLien, accountAvailable)
 		}
 
 		params := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
@@ -717,11 +787,11 @@ func releaseAccountLien(accountService services.AccountService, sys systemcontro
 			}
 			channelAccount := fmt.Sprintf("channel:%s", req.ChannelID)
 			channelScript := fmt.Sprintf(`
-				send [%s/2 %d] (
+				send [%s %d] (
 					source = @%s allowing unbounded overdraft
 					destination = @world
 				)
-			`, account.Currency, channelAmount, channelAccount)
+			`, currencyregistry.Asset(account.Currency), channelAmount, channelAccount)
 			cParams := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
 				Input: ledgercontroller.CreateTransaction{
 					RunScript: vm.RunScript{
@@ -739,7 +809,7 @@ func releaseAccountLien(accountService services.AccountService, sys systemcontro
 			respMetadata["channel_ledger"] = channelLedgerName
 			respMetadata["channel_tx_id"] = fmt.Sprintf("%d", cTx.Transaction.ID)
 			if volumes, ok := cTx.Transaction.PostCommitVolumes[channelAccount]; ok {
-				asset := fmt.Sprintf("%s/2", account.Currency)
+				asset := currencyregistry.Asset(account.Currency)
 				if vol, ok := volumes[asset]; ok && vol.Balance().Sign() < 0 {
 					warningMsg = fmt.Sprintf("Channel balance is negative: %s %s", vol.Balance().String(), account.Currency)
 				}
@@ -754,11 +824,11 @@ func releaseAccountLien(accountService services.AccountService, sys systemcontro
 					return
 				}
 				revenueScript := fmt.Sprintf(`
-					send [%s/2 %d] (
+					send [%s %d] (
 						source = @world
 						destination = @revenue:accumulated
 					)
-				`, account.Currency, revenue)
+				`, currencyregistry.Asset(account.Currency), revenue)
 				rParams := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
 					Input: ledgercontroller.CreateTransaction{
 						RunScript: vm.RunScript{
@@ -872,7 +942,7 @@ func lifecycleAccountHandler(fn func(context.Context, uuid.UUID) (*models.Accoun
 
 func accountTransactionResponse(tx ledgerinternal.Transaction, trackedAccount string, currency string) map[string]any {
 	var balanceBefore, balanceAfter int64
-	assetName := fmt.Sprintf("%s/2", currency)
+	assetName := currencyregistry.Asset(currency)
 	if vol, ok := tx.PostCommitVolumes[trackedAccount]; ok {
 		if v, ok := vol[assetName]; ok {
 			bal := new(big.Int).Sub(v.Input, v.Output)
@@ -935,11 +1005,11 @@ func creditOpeningDeposit(ctx context.Context, l ledgercontroller.Controller, ac
 	accountUser := walletAvailableAddress(account.WalletID, account.Currency)
 	accountSystem := fmt.Sprintf("system:control:%s", account.Currency)
 	script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @%s allowing unbounded overdraft
 			destination = @%s
 		)
-	`, account.Currency, amount, accountSystem, accountUser)
+	`, currencyregistry.Asset(account.Currency), amount, accountSystem, accountUser)
 
 	params := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
 		Input: ledgercontroller.CreateTransaction{
@@ -964,7 +1034,7 @@ func creditOpeningDeposit(ctx context.Context, l ledgercontroller.Controller, ac
 
 func readAvailableBalance(ctx context.Context, l ledgercontroller.Controller, walletID, currency string) (int64, error) {
 	address := walletAvailableAddress(walletID, currency)
-	asset := fmt.Sprintf("%s/2", currency)
+	asset := currencyregistry.Asset(currency)
 	var order bunpaginate.Order = bunpaginate.OrderAsc
 	rq := storagecommon.InitialPaginatedQuery[ledgerstore.GetVolumesOptions]{
 		Column:   "account",
@@ -999,6 +1069,19 @@ func walletAvailableAddress(walletID, currency string) string {
 	return fmt.Sprintf("users:%s:wallets:%s:available", walletID, currency)
 }
 
+// walletDebitSource is the source clause debiting a wallet, overdrawn up to
+// the overdraft of its product if any.
+func walletDebitSource(address, currency string, overdraft *services.Overdraft) string {
+	switch {
+	case overdraft == nil:
+		return "@" + address
+	case overdraft.Unbounded:
+		return fmt.Sprintf("@%s allowing unbounded overdraft", address)
+	default:
+		return fmt.Sprintf("@%s allowing overdraft up to [%s %d]", address, currencyregistry.Asset(currency), overdraft.Limit)
+	}
+}
+
 func walletLienAddress(walletID, currency string) string {
 	return fmt.Sprintf("users:%s:wallets:%s:lien", walletID, currency)
 }
@@ -1064,6 +1147,9 @@ func handleAccountError(w http.ResponseWriter, r *http.Request, err error) {
 		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
 	case errors.Is(err, services.ErrAccountNotFound):
 		api.NotFound(w, err)
+	case errors.Is(err, services.ErrAccountAuthorizationRequired),
+		errors.Is(err, services.ErrScreeningBlocked):
+		api.WriteErrorResponse(w, http.StatusForbidden, common.ErrForbidden, err)
 	default:
 		common.InternalServerError(w, r, err)
 	}
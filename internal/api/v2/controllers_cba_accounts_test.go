@@ -123,9 +123,8 @@ func TestListAccounts(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
-	response, ok := api.DecodeSingleResponse[accountListResponse](t, rec.Body)
-	require.True(t, ok)
-	require.Len(t, response.Accounts, 1)
+	cursor := api.DecodeCursorResponse[models.Account](t, rec.Body)
+	require.Len(t, cursor.Data, 1)
 }
 
 func TestGetAccountBalance(t *testing.T) {
@@ -388,6 +387,163 @@ func TestDebitAccount(t *testing.T) {
 	require.Equal(t, int64(1), usage.DebitCount)
 }
 
+func TestDebitAccountWithinOverdraftLimit(t *testing.T) {
+	accountService, accountRepo, _, productRepo, _ := newAccountServiceForHTTPTests()
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithAccountService(accountService))
+
+	productID := uuid.New()
+	overdraftLimit := "500.00"
+	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
+		ID:       productID,
+		Code:     "OD-USD-001",
+		Name:     "Overdraft USD",
+		Category: "current",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			AllowCredits:         true,
+			AllowDebits:          true,
+			AllowNegativeBalance: true,
+			OverdraftLimit:       &overdraftLimit,
+			MinBalance:           "0",
+		},
+	}))
+
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "0000000044",
+		ClientID:      uuid.New(),
+		ProductID:     productID,
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "client-CL-2026-000014-OD-USD-001",
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	ledgerController.EXPECT().
+		GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+		Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{
+			Data: []ledger.VolumesWithBalanceByAssetByAccount{{
+				Account: "users:client-CL-2026-000014-OD-USD-001:wallets:USD:available",
+				Asset:   "USD/2",
+				VolumesWithBalance: ledger.VolumesWithBalance{
+					Input:   big.NewInt(200),
+					Output:  big.NewInt(0),
+					Balance: big.NewInt(200),
+				},
+			}},
+		}, nil)
+	ledgerController.EXPECT().
+		CreateTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+			require.Contains(t, params.Input.RunScript.Plain, "source = @users:client-CL-2026-000014-OD-USD-001:wallets:USD:available allowing overdraft up to [USD/2 50000]")
+			return &ledger.Log{}, &ledger.CreatedTransaction{
+				Transaction: ledger.NewTransaction().
+					WithPostings(ledger.NewPosting("users:client-CL-2026-000014-OD-USD-001:wallets:USD:available", "system:control:USD", "USD/2", big.NewInt(30_000))).
+					WithPostCommitVolumes(ledger.PostCommitVolumes{
+						"users:client-CL-2026-000014-OD-USD-001:wallets:USD:available": {
+							"USD/2": ledger.NewVolumesInt64(200, 30_000),
+						},
+					}),
+			}, false, nil
+		})
+
+	req := httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/debit", api.Buffer(t, WalletTransactionRequest{
+		Amount:    json.Number("300.00"),
+		Reference: "debit-overdraft-1",
+	}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	response, ok := api.DecodeSingleResponse[walletTransactionResponse](t, rec.Body)
+	require.True(t, ok)
+	require.EqualValues(t, -29_800, response.BalanceAfter)
+
+	req = httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/debit", api.Buffer(t, WalletTransactionRequest{
+		Amount:    json.Number("600.00"),
+		Reference: "debit-overdraft-2",
+	}))
+	ledgerController.EXPECT().
+		GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+		Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{}, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDebitAccountChargesInsufficientFundsPenalty(t *testing.T) {
+	accountService, accountRepo, _, productRepo, dailyUsageRepo := newAccountServiceForHTTPTests()
+	feeRepo := newFeePostingRepositoryForHTTPTests()
+	feeService := services.NewFeeService(accountRepo, productRepo, feeRepo, dailyUsageRepo)
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithAccountService(accountService), WithFeeService(feeService))
+
+	productID := uuid.New()
+	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
+		ID:       productID,
+		Code:     "CUR-USD-PEN",
+		Name:     "Current USD With Penalties",
+		Category: "current",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			AllowCredits: true,
+			AllowDebits:  true,
+			MinBalance:   "0",
+		},
+		FeeSchedule: &models.FeeSchedule{
+			PenaltyFees: map[string]models.PenaltyFee{
+				models.PenaltyInsufficientFunds: {Type: "flat", Value: "15.00"},
+			},
+		},
+	}))
+
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "0000000045",
+		ClientID:      uuid.New(),
+		ProductID:     productID,
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "client-CL-2026-000014-CUR-USD-PEN",
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	ledgerController.EXPECT().
+		GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+		Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{
+			Data: []ledger.VolumesWithBalanceByAssetByAccount{{
+				Account: "users:client-CL-2026-000014-CUR-USD-PEN:wallets:USD:available",
+				Asset:   "USD/2",
+				VolumesWithBalance: ledger.VolumesWithBalance{
+					Input:   big.NewInt(200),
+					Output:  big.NewInt(0),
+					Balance: big.NewInt(200),
+				},
+			}},
+		}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/debit", api.Buffer(t, WalletTransactionRequest{
+		Amount:    json.Number("300.00"),
+		Reference: "debit-bounce-1",
+	}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	postings, err := feeRepo.ListByAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 1)
+	require.Equal(t, "penalty:insufficient_funds:debit-bounce-1", postings[0].Reference)
+	require.Equal(t, "15", postings[0].Amount.String())
+	require.Equal(t, models.FeePostingStatusPendingRecovery, postings[0].Status)
+}
+
 func TestDebitAccountRejectsFrozenAccount(t *testing.T) {
 	accountService, accountRepo, _, productRepo, _ := newAccountServiceForHTTPTests()
 	systemController, ledgerController := newTestingSystemController(t, false)
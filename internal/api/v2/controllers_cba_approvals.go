@@ -0,0 +1,446 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+// approvalExecutionKey marks the requests executing an approved operation so
+// that they are not held for approval again.
+type approvalExecutionKey struct{}
+
+// approvalSubjectFunc describes the operation a request performs, from its
+// URL and its payload, for the approval policies to be evaluated.
+type approvalSubjectFunc func(r *http.Request, payload []byte) (services.SubmitApprovalInput, error)
+
+// requireApproval holds the operation for approval when a policy applies to
+// it, and answers with the pending approval request instead of performing it.
+func requireApproval(approvalService services.ApprovalService, operation string, subject approvalSubjectFunc) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if approvalService == nil {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Context().Value(approvalExecutionKey{}) != nil {
+				next(w, r)
+				return
+			}
+
+			payload, err := io.ReadAll(r.Body)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(payload))
+			if len(bytes.TrimSpace(payload)) == 0 {
+				payload = nil
+			} else if !json.Valid(payload) {
+				// Let the operation report the malformed payload.
+				next(w, r)
+				return
+			}
+
+			input, err := subject(r, payload)
+			if err != nil {
+				handleApprovalError(w, r, err)
+				return
+			}
+			input.Operation = operation
+			input.Method = r.Method
+			input.Path = r.URL.RequestURI()
+			input.Payload = payload
+			input.IdempotencyKey = r.Header.Get("Idempotency-Key")
+			input.RequestedBy = actorFromRequest(r)
+
+			request, err := approvalService.Submit(r.Context(), input)
+			switch {
+			case errors.Is(err, services.ErrApprovalNotRequired):
+				next(w, r)
+			case err != nil:
+				handleApprovalError(w, r, err)
+			default:
+				api.Accepted(w, request)
+			}
+		}
+	}
+}
+
+func accountApprovalSubject(accountService services.AccountService) approvalSubjectFunc {
+	return func(r *http.Request, _ []byte) (services.SubmitApprovalInput, error) {
+		accountID, err := getCBAAccountID(r)
+		if err != nil {
+			return services.SubmitApprovalInput{}, fmt.Errorf("%w: %w", services.ErrApprovalValidation, err)
+		}
+		account, err := accountService.Get(r.Context(), accountID)
+		if err != nil {
+			return services.SubmitApprovalInput{}, err
+		}
+		return services.SubmitApprovalInput{
+			ResourceID: account.ID,
+			ProductID:  &account.ProductID,
+			Currency:   account.Currency,
+		}, nil
+	}
+}
+
+func accountDebitApprovalSubject(accountService services.AccountService) approvalSubjectFunc {
+	return func(r *http.Request, payload []byte) (services.SubmitApprovalInput, error) {
+		input, err := accountApprovalSubject(accountService)(r, payload)
+		if err != nil {
+			return input, err
+		}
+		var req AccountDebitRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return input, fmt.Errorf("%w: %w", services.ErrApprovalValidation, err)
+		}
+		amount, err := parseAmount(req.Amount, input.Currency)
+		if err != nil {
+			return input, fmt.Errorf("%w: invalid amount: %w", services.ErrApprovalValidation, err)
+		}
+		input.Amount = &amount
+		return input, nil
+	}
+}
+
+// feeWaiveApprovalSubject evaluates the waiver of a fee against what is left
+// outstanding of it.
+func feeWaiveApprovalSubject(feeService services.FeeService, accountService services.AccountService) approvalSubjectFunc {
+	return func(r *http.Request, _ []byte) (services.SubmitApprovalInput, error) {
+		feeID, err := uuid.Parse(chi.URLParam(r, "feeID"))
+		if err != nil {
+			return services.SubmitApprovalInput{}, fmt.Errorf("%w: %w", services.ErrApprovalValidation, err)
+		}
+		fee, err := feeService.Get(r.Context(), feeID)
+		if err != nil {
+			return services.SubmitApprovalInput{}, err
+		}
+		amount, err := feeAmountToAtomic(services.FeeOutstanding(*fee), fee.Currency)
+		if err != nil {
+			return services.SubmitApprovalInput{}, err
+		}
+		input := services.SubmitApprovalInput{
+			ResourceID: fee.ID,
+			Currency:   fee.Currency,
+			Amount:     &amount,
+		}
+		if accountService != nil {
+			account, err := accountService.Get(r.Context(), fee.AccountID)
+			if err != nil {
+				return input, err
+			}
+			input.ProductID = &account.ProductID
+		}
+		return input, nil
+	}
+}
+
+func productApprovalSubject(productService services.ProductService) approvalSubjectFunc {
+	return func(r *http.Request, _ []byte) (services.SubmitApprovalInput, error) {
+		productID, err := getProductID(r)
+		if err != nil {
+			return services.SubmitApprovalInput{}, fmt.Errorf("%w: %w", services.ErrApprovalValidation, err)
+		}
+		product, err := productService.Get(r.Context(), productID)
+		if err != nil {
+			return services.SubmitApprovalInput{}, err
+		}
+		return services.SubmitApprovalInput{
+			ResourceID: product.ID,
+			ProductID:  &product.ID,
+			Currency:   product.Currency,
+		}, nil
+	}
+}
+
+func createCBAApprovalPolicy(approvalService services.ApprovalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		common.WithBody[services.CreateApprovalPolicyInput](w, r, func(req services.CreateApprovalPolicyInput) {
+			req.CreatedBy = actorFromRequest(r)
+			policy, err := approvalService.CreatePolicy(r.Context(), req)
+			if err != nil {
+				handleApprovalError(w, r, err)
+				return
+			}
+			api.Created(w, policy)
+		})
+	}
+}
+
+func listCBAApprovalPolicies(approvalService services.ApprovalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := repositories.ApprovalPolicyFilter{}
+		if operation := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("operation"))); operation != "" {
+			filter.Operation = &operation
+		}
+		policies, err := approvalService.ListPolicies(r.Context(), filter)
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"policies": policies,
+		})
+	}
+}
+
+func updateCBAApprovalPolicy(approvalService services.ApprovalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		policyID, err := uuid.Parse(chi.URLParam(r, "policyID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		common.WithBody[services.UpdateApprovalPolicyInput](w, r, func(req services.UpdateApprovalPolicyInput) {
+			policy, err := approvalService.UpdatePolicy(r.Context(), policyID, req)
+			if err != nil {
+				handleApprovalError(w, r, err)
+				return
+			}
+			api.Ok(w, policy)
+		})
+	}
+}
+
+func listCBAApprovals(approvalService services.ApprovalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := repositories.ApprovalRequestFilter{
+			Limit: 50,
+		}
+		if operation := strings.ToLower(strings.TrimSpace(query.Get("operation"))); operation != "" {
+			filter.Operation = &operation
+		}
+		if value := strings.TrimSpace(query.Get("resource_id")); value != "" {
+			resourceID, err := uuid.Parse(value)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid resource_id: %w", err))
+				return
+			}
+			filter.ResourceID = &resourceID
+		}
+		for _, status := range query["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		requests, err := approvalService.List(r.Context(), filter)
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"approvals": requests,
+		})
+	}
+}
+
+func readCBAApproval(approvalService services.ApprovalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		approvalID, err := uuid.Parse(chi.URLParam(r, "approvalID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		request, err := approvalService.Get(r.Context(), approvalID)
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		api.Ok(w, request)
+	}
+}
+
+func listCBAApprovalEvents(approvalService services.ApprovalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		approvalID, err := uuid.Parse(chi.URLParam(r, "approvalID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		events, err := approvalService.Events(r.Context(), approvalID)
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"events": events,
+		})
+	}
+}
+
+// approveCBAApproval approves a pending request and executes its operation
+// by replaying the original API call through the router. The call keeps the
+// idempotency key of the request, so the ledger does not apply it twice.
+func approveCBAApproval(approvalService services.ApprovalService, executor http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		approvalID, input, ok := decodeDecideApprovalRequest(w, r)
+		if !ok {
+			return
+		}
+
+		request, err := approvalService.Approve(r.Context(), approvalID, input)
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		request, err = executeApprovalRequest(r, approvalService, executor, request)
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		api.Ok(w, request)
+	}
+}
+
+// executeCBAApproval executes again the operation of an approved request
+// whose execution was interrupted before its outcome was recorded. The replay
+// keeps the idempotency key of the request, so an operation which did reach
+// the ledger is not applied twice.
+func executeCBAApproval(approvalService services.ApprovalService, executor http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		approvalID, err := uuid.Parse(chi.URLParam(r, "approvalID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		request, err := approvalService.RetryExecution(r.Context(), approvalID, actorFromRequest(r))
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		request, err = executeApprovalRequest(r, approvalService, executor, request)
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		api.Ok(w, request)
+	}
+}
+
+func rejectCBAApproval(approvalService services.ApprovalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		approvalID, input, ok := decodeDecideApprovalRequest(w, r)
+		if !ok {
+			return
+		}
+
+		request, err := approvalService.Reject(r.Context(), approvalID, input)
+		if err != nil {
+			handleApprovalError(w, r, err)
+			return
+		}
+		api.Ok(w, request)
+	}
+}
+
+func executeApprovalRequest(r *http.Request, approvalService services.ApprovalService, executor http.Handler, request *models.ApprovalRequest) (*models.ApprovalRequest, error) {
+	// The route context of the approval call is dropped for the router to
+	// route the replayed call from scratch.
+	ctx := context.WithValue(r.Context(), chi.RouteCtxKey, nil)
+	ctx = context.WithValue(ctx, approvalExecutionKey{}, request.ID)
+	replay, err := http.NewRequestWithContext(ctx, request.Method, request.Path, bytes.NewReader(request.Payload))
+	if err != nil {
+		return nil, err
+	}
+	replay.Header = r.Header.Clone()
+	replay.Header.Del("Content-Length")
+	replay.Header.Set("Content-Type", "application/json")
+	replay.Header.Set("Idempotency-Key", request.IdempotencyKey)
+
+	recorder := &approvalResponseRecorder{header: http.Header{}}
+	executor.ServeHTTP(recorder, replay)
+
+	return approvalService.CompleteExecution(r.Context(), request.ID, services.ApprovalExecution{
+		Status: recorder.statusCode(),
+		Body:   recorder.body.Bytes(),
+	})
+}
+
+// approvalResponseRecorder keeps the response of an approved operation to
+// record it on the approval request.
+type approvalResponseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *approvalResponseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *approvalResponseRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(data)
+}
+
+func (r *approvalResponseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *approvalResponseRecorder) statusCode() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+func decodeDecideApprovalRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, services.DecideApprovalInput, bool) {
+	var input services.DecideApprovalInput
+	approvalID, err := uuid.Parse(chi.URLParam(r, "approvalID"))
+	if err != nil {
+		api.BadRequest(w, common.ErrValidation, err)
+		return uuid.Nil, input, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		api.BadRequest(w, common.ErrValidation, err)
+		return uuid.Nil, input, false
+	}
+	input.DecidedBy = actorFromRequest(r)
+	return approvalID, input, true
+}
+
+func handleApprovalError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrApprovalValidation),
+		errors.Is(err, services.ErrApprovalActorRequired):
+		api.BadRequest(w, common.ErrValidation, err)
+	case errors.Is(err, services.ErrApprovalSameActor):
+		api.WriteErrorResponse(w, http.StatusForbidden, common.ErrForbidden, err)
+	case errors.Is(err, services.ErrApprovalDecided):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	case errors.Is(err, services.ErrApprovalNotFound),
+		errors.Is(err, services.ErrApprovalPolicyNotFound),
+		errors.Is(err, services.ErrProductNotFound):
+		api.NotFound(w, err)
+	default:
+		handleFeeError(w, r, err)
+	}
+}
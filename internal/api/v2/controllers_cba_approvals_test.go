@@ -0,0 +1,331 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+type approvalPolicyRepositoryForHTTPTests struct {
+	policies []*models.ApprovalPolicy
+}
+
+func (s *approvalPolicyRepositoryForHTTPTests) Create(_ context.Context, policy *models.ApprovalPolicy) error {
+	if policy.ID == uuid.Nil {
+		policy.ID = uuid.New()
+	}
+	copied := *policy
+	s.policies = append(s.policies, &copied)
+	return nil
+}
+
+func (s *approvalPolicyRepositoryForHTTPTests) Update(_ context.Context, policy *models.ApprovalPolicy) error {
+	for i, existing := range s.policies {
+		if existing.ID == policy.ID {
+			copied := *policy
+			s.policies[i] = &copied
+			return nil
+		}
+	}
+	return postgres.ErrNotFound
+}
+
+func (s *approvalPolicyRepositoryForHTTPTests) Get(_ context.Context, id uuid.UUID) (*models.ApprovalPolicy, error) {
+	for _, policy := range s.policies {
+		if policy.ID == id {
+			copied := *policy
+			return &copied, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *approvalPolicyRepositoryForHTTPTests) List(_ context.Context, filter repositories.ApprovalPolicyFilter) ([]models.ApprovalPolicy, error) {
+	ret := make([]models.ApprovalPolicy, 0)
+	for _, policy := range s.policies {
+		if filter.Operation != nil && policy.Operation != *filter.Operation {
+			continue
+		}
+		if filter.Enabled != nil && policy.Enabled != *filter.Enabled {
+			continue
+		}
+		ret = append(ret, *policy)
+	}
+	return ret, nil
+}
+
+type approvalRequestRepositoryForHTTPTests struct {
+	requests map[uuid.UUID]*models.ApprovalRequest
+}
+
+func (s *approvalRequestRepositoryForHTTPTests) Create(_ context.Context, request *models.ApprovalRequest) error {
+	if request.ID == uuid.Nil {
+		request.ID = uuid.New()
+	}
+	copied := *request
+	s.requests[request.ID] = &copied
+	return nil
+}
+
+func (s *approvalRequestRepositoryForHTTPTests) Transition(_ context.Context, request *models.ApprovalRequest, from string) error {
+	existing, ok := s.requests[request.ID]
+	if !ok || existing.Status != from {
+		return postgres.ErrNotFound
+	}
+	copied := *request
+	s.requests[request.ID] = &copied
+	return nil
+}
+
+func (s *approvalRequestRepositoryForHTTPTests) Get(_ context.Context, id uuid.UUID) (*models.ApprovalRequest, error) {
+	request, ok := s.requests[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	copied := *request
+	return &copied, nil
+}
+
+func (s *approvalRequestRepositoryForHTTPTests) List(_ context.Context, filter repositories.ApprovalRequestFilter) ([]models.ApprovalRequest, error) {
+	ret := make([]models.ApprovalRequest, 0)
+	for _, request := range s.requests {
+		if filter.ResourceID != nil && request.ResourceID != *filter.ResourceID {
+			continue
+		}
+		ret = append(ret, *request)
+	}
+	return ret, nil
+}
+
+type approvalEventRepositoryForHTTPTests struct {
+	events []models.ApprovalEvent
+}
+
+func (s *approvalEventRepositoryForHTTPTests) Create(_ context.Context, event *models.ApprovalEvent) error {
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *approvalEventRepositoryForHTTPTests) ListByRequest(_ context.Context, requestID uuid.UUID) ([]models.ApprovalEvent, error) {
+	ret := make([]models.ApprovalEvent, 0)
+	for _, event := range s.events {
+		if event.RequestID == requestID {
+			ret = append(ret, event)
+		}
+	}
+	return ret, nil
+}
+
+func newApprovalServiceForHTTPTests() services.ApprovalService {
+	return services.NewApprovalService(
+		&approvalPolicyRepositoryForHTTPTests{},
+		&approvalRequestRepositoryForHTTPTests{requests: map[uuid.UUID]*models.ApprovalRequest{}},
+		&approvalEventRepositoryForHTTPTests{},
+	)
+}
+
+func TestCBAApprovalOfProductActivation(t *testing.T) {
+	t.Parallel()
+
+	productService, _ := newProductServiceForHTTPTests()
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop",
+		WithProductService(productService),
+		WithApprovalService(newApprovalServiceForHTTPTests()),
+		WithTrustActorHeader(true),
+	)
+
+	product, err := productService.Create(context.Background(), services.CreateProductInput{
+		Code:     "CUR-USD-002",
+		Name:     "Corporate Current USD",
+		Category: "current",
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	// Without a policy, the product is activated right away.
+	other, err := productService.Create(context.Background(), services.CreateProductInput{
+		Code:     "CUR-USD-003",
+		Name:     "Retail Current USD",
+		Category: "current",
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/test/products/"+other.ID.String()+"/activate", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/_/cba/approvals/policies", api.Buffer(t, services.CreateApprovalPolicyInput{
+		Operation: models.ApprovalOperationProductActivate,
+		ProductID: &product.ID,
+	}))
+	req.Header.Set(HeaderActor, "admin")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	activate := func(actor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/test/products/"+product.ID.String()+"/activate", nil)
+		if actor != "" {
+			req.Header.Set(HeaderActor, actor)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	require.Equal(t, http.StatusBadRequest, activate("").Code)
+
+	rec = activate("maker")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	pending, ok := api.DecodeSingleResponse[models.ApprovalRequest](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.ApprovalStatusPending, pending.Status)
+	require.Equal(t, product.ID, pending.ResourceID)
+	stored, err := productService.Get(context.Background(), product.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ProductStatusDraft, stored.Status)
+
+	decide := func(action, actor string, input services.DecideApprovalInput) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/_/cba/approvals/"+pending.ID.String()+"/"+action, api.Buffer(t, input))
+		req.Header.Set(HeaderActor, actor)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	require.Equal(t, http.StatusForbidden, decide("approve", "maker", services.DecideApprovalInput{}).Code)
+	require.Equal(t, http.StatusBadRequest, decide("reject", "checker", services.DecideApprovalInput{}).Code)
+
+	rec = decide("approve", "checker", services.DecideApprovalInput{Comment: "reviewed"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	executed, ok := api.DecodeSingleResponse[models.ApprovalRequest](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.ApprovalStatusExecuted, executed.Status)
+	require.Equal(t, http.StatusOK, executed.ResultStatus)
+	stored, err = productService.Get(context.Background(), product.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ProductStatusActive, stored.Status)
+
+	require.Equal(t, http.StatusConflict, decide("approve", "other", services.DecideApprovalInput{}).Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/_/cba/approvals/"+pending.ID.String()+"/events", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	events, ok := api.DecodeSingleResponse[map[string][]models.ApprovalEvent](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, events["events"], 3)
+	require.Equal(t, models.ApprovalStatusExecuted, events["events"][2].Action)
+
+	req = httptest.NewRequest(http.MethodGet, "/_/cba/approvals/"+uuid.NewString(), nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCBAApprovalIgnoresUntrustedActorHeader(t *testing.T) {
+	t.Parallel()
+
+	productService, _ := newProductServiceForHTTPTests()
+	approvalService := newApprovalServiceForHTTPTests()
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop",
+		WithProductService(productService),
+		WithApprovalService(approvalService),
+	)
+
+	product, err := productService.Create(context.Background(), services.CreateProductInput{
+		Code:     "CUR-USD-004",
+		Name:     "Corporate Current USD",
+		Category: "current",
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+	_, err = approvalService.CreatePolicy(context.Background(), services.CreateApprovalPolicyInput{
+		Operation: models.ApprovalOperationProductActivate,
+		ProductID: &product.ID,
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/test/products/"+product.ID.String()+"/activate", nil)
+	req.Header.Set(HeaderActor, "maker")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCBAApprovalExecutionRetry(t *testing.T) {
+	t.Parallel()
+
+	productService, _ := newProductServiceForHTTPTests()
+	approvalService := newApprovalServiceForHTTPTests()
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop",
+		WithProductService(productService),
+		WithApprovalService(approvalService),
+		WithTrustActorHeader(true),
+	)
+
+	product, err := productService.Create(context.Background(), services.CreateProductInput{
+		Code:     "CUR-USD-005",
+		Name:     "Corporate Current USD",
+		Category: "current",
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+	_, err = approvalService.CreatePolicy(context.Background(), services.CreateApprovalPolicyInput{
+		Operation: models.ApprovalOperationProductActivate,
+		ProductID: &product.ID,
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/test/products/"+product.ID.String()+"/activate", nil)
+	req.Header.Set(HeaderActor, "maker")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	pending, ok := api.DecodeSingleResponse[models.ApprovalRequest](t, rec.Body)
+	require.True(t, ok)
+
+	// The approval was recorded but its execution was interrupted.
+	_, err = approvalService.Approve(context.Background(), pending.ID, services.DecideApprovalInput{DecidedBy: "checker"})
+	require.NoError(t, err)
+
+	execute := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/_/cba/approvals/"+pending.ID.String()+"/execute", nil)
+		req.Header.Set(HeaderActor, "operator")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	rec = execute()
+	require.Equal(t, http.StatusOK, rec.Code)
+	executed, ok := api.DecodeSingleResponse[models.ApprovalRequest](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.ApprovalStatusExecuted, executed.Status)
+	stored, err := productService.Get(context.Background(), product.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ProductStatusActive, stored.Status)
+
+	require.Equal(t, http.StatusConflict, execute().Code)
+
+	events, err := approvalService.Events(context.Background(), pending.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ApprovalEventExecutionRetried, events[2].Action)
+	require.Equal(t, "operator", events[2].Actor)
+}
@@ -3,6 +3,7 @@ package v2
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 
@@ -12,10 +13,14 @@ import (
 	"github.com/formancehq/go-libs/v3/api"
 
 	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
 	"github.com/formancehq/ledger/internal/cba/repositories"
 	"github.com/formancehq/ledger/internal/cba/services"
 )
 
+// maxKYCCallbackSize bounds the payload accepted from a KYC provider.
+const maxKYCCallbackSize = 1 << 20
+
 func createClient(clientService services.ClientService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		common.WithBody[services.CreateClientInput](w, r, func(req services.CreateClientInput) {
@@ -29,27 +34,42 @@ func createClient(clientService services.ClientService) http.HandlerFunc {
 	}
 }
 
-func listClients(clientService services.ClientService) http.HandlerFunc {
+func listClients(clientService services.ClientService, paginationConfig common.PaginationConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		filter := repositories.ClientFilter{}
-		if clientType := strings.TrimSpace(r.URL.Query().Get("type")); clientType != "" {
-			filter.Type = &clientType
-		}
-		if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
-			filter.Status = &status
-		}
-		if kycStatus := strings.TrimSpace(r.URL.Query().Get("kyc_status")); kycStatus != "" {
-			filter.KYCStatus = &kycStatus
+		query, err := getSystemPaginatedQuery(r, paginationConfig, repositories.ClientSortColumns, func(filter *repositories.ClientFilter) error {
+			if clientType := strings.TrimSpace(r.URL.Query().Get("type")); clientType != "" {
+				filter.Type = &clientType
+			}
+			if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
+				filter.Status = &status
+			}
+			if kycStatus := strings.TrimSpace(r.URL.Query().Get("kyc_status")); kycStatus != "" {
+				filter.KYCStatus = &kycStatus
+			}
+			if search := strings.TrimSpace(r.URL.Query().Get("search")); search != "" {
+				filter.Search = &search
+			}
+			after, before, err := getDateRange(r)
+			if err != nil {
+				return err
+			}
+			filter.CreatedAfter, filter.CreatedBefore = toStdTimePtr(after), toStdTimePtr(before)
+			return nil
+		})
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
 		}
 
-		clients, err := clientService.List(r.Context(), filter)
+		cursor, err := paginateSystemList(*query, func(filter repositories.ClientFilter, page repositories.Page) ([]models.Client, error) {
+			filter.Page = &page
+			return clientService.List(r.Context(), filter)
+		}, repositories.ClientKeyset)
 		if err != nil {
 			handleClientError(w, r, err)
 			return
 		}
-		api.Ok(w, map[string]any{
-			"clients": clients,
-		})
+		api.RenderCursor(w, *cursor)
 	}
 }
 
@@ -242,6 +262,40 @@ func rejectKYC(kycService services.KYCService) http.HandlerFunc {
 	}
 }
 
+func refreshKYC(kycService services.KYCService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clientID, kycID, err := getClientAndKYCIDs(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		record, err := kycService.Refresh(r.Context(), clientID, kycID)
+		if err != nil {
+			handleClientError(w, r, err)
+			return
+		}
+		api.Ok(w, record)
+	}
+}
+
+func handleKYCCallback(kycService services.KYCService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxKYCCallbackSize))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		record, err := kycService.HandleCallback(r.Context(), r.Header, body)
+		if err != nil {
+			handleClientError(w, r, err)
+			return
+		}
+		api.Ok(w, record)
+	}
+}
+
 func listClientKYC(kycService services.KYCService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		clientID, err := getClientID(r)
@@ -301,6 +355,11 @@ func handleClientError(w http.ResponseWriter, r *http.Request, err error) {
 		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
 	case errors.Is(err, services.ErrClientNotFound), errors.Is(err, services.ErrKYCNotFound):
 		api.NotFound(w, err)
+	case errors.Is(err, services.ErrKYCCallbackSignature),
+		errors.Is(err, services.ErrScreeningBlocked):
+		api.WriteErrorResponse(w, http.StatusForbidden, common.ErrForbidden, err)
+	case errors.Is(err, services.ErrKYCProvider):
+		api.WriteErrorResponse(w, http.StatusBadGateway, api.ErrorInternal, err)
 	default:
 		common.InternalServerError(w, r, err)
 	}
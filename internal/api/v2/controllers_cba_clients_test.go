@@ -1,9 +1,12 @@
 package v2
 
 import (
+	"cmp"
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 
 	"github.com/formancehq/go-libs/v3/api"
 	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
 	"github.com/formancehq/go-libs/v3/platform/postgres"
 	"github.com/formancehq/ledger/internal/api/common"
 	"github.com/formancehq/ledger/internal/cba/models"
@@ -20,10 +24,6 @@ import (
 	"github.com/formancehq/ledger/internal/cba/services"
 )
 
-type clientListResponse struct {
-	Clients []models.Client `json:"clients"`
-}
-
 type kycListResponse struct {
 	Records []models.KYCRecord `json:"records"`
 }
@@ -94,8 +94,43 @@ func (s *clientRepositoryForHTTPTests) List(_ context.Context, filter repositori
 		if filter.KYCStatus != nil && client.KYCStatus != *filter.KYCStatus {
 			continue
 		}
+		if filter.Search != nil && !strings.Contains(client.ClientNumber, *filter.Search) && !strings.Contains(client.Contact.Email, *filter.Search) {
+			continue
+		}
 		ret = append(ret, *client)
 	}
+	if filter.Page == nil {
+		return ret, nil
+	}
+
+	// Clients are ordered and paged on their positions, as the repository
+	// does in SQL.
+	column, order := repositories.ClientSortColumns[0], bunpaginate.Order(bunpaginate.OrderDesc)
+	if filter.Page.Sort != nil {
+		column, order = filter.Page.Sort.Column, filter.Page.Sort.Order
+	}
+	position := filter.Page.After
+	if filter.Page.Before != nil {
+		position, order = filter.Page.Before, order.Reverse()
+	}
+	compare := func(a, b repositories.Keyset) int {
+		ret := cmp.Or(strings.Compare(a.Value, b.Value), strings.Compare(a.ID.String(), b.ID.String()))
+		if order == bunpaginate.OrderDesc {
+			return -ret
+		}
+		return ret
+	}
+	slices.SortFunc(ret, func(a, b models.Client) int {
+		return compare(repositories.ClientKeyset(a, column), repositories.ClientKeyset(b, column))
+	})
+	if position != nil {
+		ret = slices.DeleteFunc(ret, func(client models.Client) bool {
+			return compare(repositories.ClientKeyset(client, column), *position) <= 0
+		})
+	}
+	if filter.Page.Limit > 0 && len(ret) > filter.Page.Limit {
+		ret = ret[:filter.Page.Limit]
+	}
 	return ret, nil
 }
 
@@ -201,6 +236,16 @@ func (s *kycRepositoryForHTTPTests) Get(_ context.Context, id uuid.UUID) (*model
 	return &copied, nil
 }
 
+func (s *kycRepositoryForHTTPTests) GetByProviderReference(_ context.Context, provider, reference string) (*models.KYCRecord, error) {
+	for _, record := range s.records {
+		if record.Provider == provider && record.ProviderReference == reference {
+			copied := *record
+			return &copied, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
 func (s *kycRepositoryForHTTPTests) ListByClient(_ context.Context, clientID uuid.UUID) ([]models.KYCRecord, error) {
 	ret := make([]models.KYCRecord, 0)
 	for _, record := range s.records {
@@ -211,6 +256,17 @@ func (s *kycRepositoryForHTTPTests) ListByClient(_ context.Context, clientID uui
 	return ret, nil
 }
 
+func (s *kycRepositoryForHTTPTests) List(_ context.Context, filter repositories.KYCFilter) ([]models.KYCRecord, error) {
+	ret := make([]models.KYCRecord, 0)
+	for _, record := range s.records {
+		if filter.Status != nil && record.Status != *filter.Status {
+			continue
+		}
+		ret = append(ret, *record)
+	}
+	return ret, nil
+}
+
 type dailyUsageRepositoryForHTTPTests struct {
 	usages map[string]*models.AccountDailyUsage
 }
@@ -249,6 +305,18 @@ func (s *dailyUsageRepositoryForHTTPTests) GetForDate(_ context.Context, account
 	return &copied, nil
 }
 
+func (s *dailyUsageRepositoryForHTTPTests) ListBetween(_ context.Context, accountID uuid.UUID, from, to time.Time) ([]models.AccountDailyUsage, error) {
+	ret := make([]models.AccountDailyUsage, 0)
+	for _, usage := range s.usages {
+		date := usage.UsageDate.UTC().Truncate(24 * time.Hour)
+		if usage.AccountID != accountID || date.Before(from.UTC().Truncate(24*time.Hour)) || date.After(to.UTC().Truncate(24*time.Hour)) {
+			continue
+		}
+		ret = append(ret, *usage)
+	}
+	return ret, nil
+}
+
 func dailyUsageHTTPKey(accountID uuid.UUID, usageDate time.Time) string {
 	return accountID.String() + "|" + usageDate.UTC().Truncate(24*time.Hour).Format("2006-01-02")
 }
@@ -257,7 +325,7 @@ func newClientAndKYCServicesForHTTPTests() (services.ClientService, services.KYC
 	clientRepo := newClientRepositoryForHTTPTests()
 	accountRepo := newAccountRepositoryForHTTPTests()
 	kycRepo := newKYCRepositoryForHTTPTests()
-	return services.NewClientService(clientRepo, accountRepo), services.NewKYCService(clientRepo, kycRepo), clientRepo, kycRepo
+	return services.NewClientService(clientRepo, accountRepo, nil), services.NewKYCService(clientRepo, kycRepo, nil, nil), clientRepo, kycRepo
 }
 
 func TestCreateClient(t *testing.T) {
@@ -313,15 +381,83 @@ func TestListClientsAppliesFilters(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
-	response, ok := api.DecodeSingleResponse[clientListResponse](t, rec.Body)
-	require.True(t, ok)
-	require.Len(t, response.Clients, 1)
+	cursor := api.DecodeCursorResponse[models.Client](t, rec.Body)
+	require.Len(t, cursor.Data, 1)
 	require.NotNil(t, repo.lastFilter.Type)
 	require.Equal(t, "corporate", *repo.lastFilter.Type)
 	require.NotNil(t, repo.lastFilter.KYCStatus)
 	require.Equal(t, "verified", *repo.lastFilter.KYCStatus)
 }
 
+func TestListClientsPaginatesWithCursors(t *testing.T) {
+	clientService, kycService, repo, _ := newClientAndKYCServicesForHTTPTests()
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithClientService(clientService), WithKYCService(kycService))
+
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, number := range []string{"CL-2026-000001", "CL-2026-000002", "CL-2026-000003", "CL-2026-000004", "CL-2026-000005"} {
+		require.NoError(t, repo.Create(context.Background(), &models.Client{
+			ClientNumber: number,
+			Type:         models.ClientTypeIndividual,
+			Status:       models.ClientStatusActive,
+			Contact:      models.ClientContact{Email: strings.ToLower(number) + "@example.com"},
+			CreatedAt:    createdAt.AddDate(0, 0, i),
+		}))
+	}
+	list := func(query string) *bunpaginate.Cursor[models.Client] {
+		req := httptest.NewRequest(http.MethodGet, "/test/clients?"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		return api.DecodeCursorResponse[models.Client](t, rec.Body)
+	}
+
+	page := list("pageSize=2&created_after=2026-01-01T00:00:00Z")
+	require.Len(t, page.Data, 2)
+	require.True(t, page.HasMore)
+	require.Empty(t, page.Previous)
+	require.Equal(t, "CL-2026-000005", page.Data[0].ClientNumber)
+	require.NotNil(t, repo.lastFilter.CreatedAfter)
+
+	page = list("cursor=" + page.Next)
+	require.Len(t, page.Data, 2)
+	require.Equal(t, "CL-2026-000003", page.Data[0].ClientNumber)
+	require.NotEmpty(t, page.Previous)
+	require.NotNil(t, repo.lastFilter.CreatedAfter, "the cursor keeps the filters")
+	require.NotNil(t, repo.lastFilter.Page.After)
+	second := page
+
+	page = list("cursor=" + second.Previous)
+	require.Len(t, page.Data, 2)
+	require.Equal(t, "CL-2026-000005", page.Data[0].ClientNumber)
+	require.Equal(t, "CL-2026-000004", page.Data[1].ClientNumber)
+	require.Empty(t, page.Previous)
+	require.True(t, page.HasMore)
+
+	page = list("cursor=" + second.Next)
+	require.Len(t, page.Data, 1)
+	require.False(t, page.HasMore)
+
+	page = list("sort=clientNumber:asc&pageSize=3")
+	require.Len(t, page.Data, 3)
+	require.Equal(t, "CL-2026-000001", page.Data[0].ClientNumber)
+	page = list("cursor=" + page.Next)
+	require.Len(t, page.Data, 2)
+	require.Equal(t, "CL-2026-000004", page.Data[0].ClientNumber)
+
+	page = list("search=000004")
+	require.Len(t, page.Data, 1)
+	require.Equal(t, "000004", *repo.lastFilter.Search)
+
+	for _, query := range []string{"sort=kyc_data", "sort=created_at:sideways", "created_before=yesterday", "cursor=invalid"} {
+		req := httptest.NewRequest(http.MethodGet, "/test/clients?"+query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
 func TestSubmitKYCValidationError(t *testing.T) {
 	clientService, kycService, _, _ := newClientAndKYCServicesForHTTPTests()
 	systemController, _ := newTestingSystemController(t, true)
@@ -405,3 +541,72 @@ func TestVerifyKYCAndActivateClient(t *testing.T) {
 	require.True(t, ok)
 	require.Len(t, history.Records, 1)
 }
+
+type denyAllAuthenticator struct{}
+
+func (denyAllAuthenticator) Authenticate(_ http.ResponseWriter, _ *http.Request) (bool, error) {
+	return false, nil
+}
+
+func TestKYCProviderCallbackAndRefresh(t *testing.T) {
+	clientRepo := newClientRepositoryForHTTPTests()
+	clientService := services.NewClientService(clientRepo, newAccountRepositoryForHTTPTests(), nil)
+	provider := services.NewLocalKYCProvider()
+	kycService := services.NewKYCService(clientRepo, newKYCRepositoryForHTTPTests(), nil, provider)
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithClientService(clientService), WithKYCService(kycService))
+
+	client, err := clientService.Create(context.Background(), services.CreateClientInput{
+		Type: "individual",
+		Contact: models.ClientContact{
+			Phone: "08000000000",
+		},
+		IndividualData: &models.IndividualData{
+			FirstName:        "Ada",
+			LastName:         "Lovelace",
+			NationalIDNumber: "NIN-123",
+		},
+	})
+	require.NoError(t, err)
+
+	submit := func() models.KYCRecord {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/test/clients/"+client.ID.String()+"/kyc", api.Buffer(t, services.SubmitKYCInput{
+			Level: 1,
+		})))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		record, ok := api.DecodeSingleResponse[models.KYCRecord](t, rec.Body)
+		require.True(t, ok)
+		require.Equal(t, models.KYCStatusPending, record.Status)
+		return record
+	}
+
+	polled := submit()
+	_, err = provider.Decide(polled.ProviderReference, models.KYCStatusVerified, "")
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/test/clients/"+client.ID.String()+"/kyc/"+polled.ID.String()+"/refresh", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	refreshed, ok := api.DecodeSingleResponse[models.KYCRecord](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.KYCStatusVerified, refreshed.Status)
+
+	// Callbacks do not go through API authentication.
+	called := submit()
+	router = NewRouter(systemController, denyAllAuthenticator{}, "develop", WithClientService(clientService), WithKYCService(kycService))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/_/cba/kyc/callbacks", api.Buffer(t, services.KYCDecision{
+		Reference: called.ProviderReference,
+		Status:    models.KYCStatusRejected,
+		Reason:    "document mismatch",
+	})))
+	require.Equal(t, http.StatusOK, rec.Code)
+	rejected, ok := api.DecodeSingleResponse[models.KYCRecord](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.KYCStatusRejected, rejected.Status)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/test/clients/"+client.ID.String()+"/kyc/"+called.ID.String()+"/refresh", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,203 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/metadata"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/machine/vm"
+)
+
+// feeBadDebtAccount is the revenue ledger account written off fees are
+// expensed to.
+const feeBadDebtAccount = "revenue:fee_bad_debt"
+
+func listCBAFees(feeService services.FeeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := repositories.FeePostingFilter{
+			Limit: 50,
+		}
+		if value := strings.TrimSpace(query.Get("account_id")); value != "" {
+			accountID, err := uuid.Parse(value)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid account_id: %w", err))
+				return
+			}
+			filter.AccountID = &accountID
+		}
+		if eventType := strings.ToLower(strings.TrimSpace(query.Get("event_type"))); eventType != "" {
+			filter.EventType = &eventType
+		}
+		for _, status := range query["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		fees, err := feeService.List(r.Context(), filter)
+		if err != nil {
+			handleFeeError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"fees": fees,
+		})
+	}
+}
+
+func readCBAFee(feeService services.FeeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feeID, err := uuid.Parse(chi.URLParam(r, "feeID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		fee, err := feeService.Get(r.Context(), feeID)
+		if err != nil {
+			handleFeeError(w, r, err)
+			return
+		}
+		api.Ok(w, fee)
+	}
+}
+
+func waiveCBAFee(feeService services.FeeService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feeID, input, ok := decodeResolveFeeRequest(w, r)
+		if !ok {
+			return
+		}
+
+		fee, err := feeService.Waive(r.Context(), feeID, input)
+		if err != nil {
+			handleFeeError(w, r, err)
+			return
+		}
+		api.Ok(w, fee)
+	}
+}
+
+// writeOffCBAFee writes off what is left outstanding of a fee and expenses it
+// as a bad debt in the revenue ledger of the fee currency. The fee is marked
+// first so that a failed bad debt entry is retried by writing it off again.
+func writeOffCBAFee(feeService services.FeeService, sys systemcontroller.Controller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feeID, input, ok := decodeResolveFeeRequest(w, r)
+		if !ok {
+			return
+		}
+
+		fee, err := feeService.WriteOff(r.Context(), feeID, input)
+		if err != nil {
+			handleFeeError(w, r, err)
+			return
+		}
+		amount, err := feeAmountToAtomic(services.FeeOutstanding(*fee), fee.Currency)
+		if err != nil {
+			common.InternalServerError(w, r, err)
+			return
+		}
+		if amount > 0 {
+			if err := postFeeBadDebt(r, sys, fee, amount); err != nil {
+				common.HandleCommonWriteErrors(w, r, err)
+				return
+			}
+		}
+		api.Ok(w, fee)
+	}
+}
+
+func postFeeBadDebt(r *http.Request, sys systemcontroller.Controller, fee *models.FeePosting, amount int64) error {
+	l, err := sys.GetLedgerController(r.Context(), fmt.Sprintf("revenue-%s", fee.Currency))
+	if err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s allowing unbounded overdraft
+			destination = @world
+		)
+	`, currencyregistry.Asset(fee.Currency), amount, feeBadDebtAccount)
+	params := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
+		Input: ledgercontroller.CreateTransaction{
+			RunScript: vm.RunScript{
+				Script:    vm.Script{Plain: script},
+				Reference: fee.Reference + ":writeoff",
+				Metadata: metadata.Metadata{
+					"cba_operation":  "fee_writeoff",
+					"account_id":     fee.AccountID.String(),
+					"fee_posting_id": fee.ID.String(),
+					"fee_event_type": fee.EventType,
+				},
+			},
+			Runtime: ledgerinternal.RuntimeMachine,
+		},
+	}
+	if _, _, _, err := l.CreateTransaction(r.Context(), params); err != nil && !strings.Contains(strings.ToLower(err.Error()), "duplicate reference") {
+		return err
+	}
+	return nil
+}
+
+func decodeResolveFeeRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, services.ResolveFeeInput, bool) {
+	var input services.ResolveFeeInput
+	feeID, err := uuid.Parse(chi.URLParam(r, "feeID"))
+	if err != nil {
+		api.BadRequest(w, common.ErrValidation, err)
+		return uuid.Nil, input, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		api.BadRequest(w, common.ErrValidation, err)
+		return uuid.Nil, input, false
+	}
+	return feeID, input, true
+}
+
+// feeAmountToAtomic converts a fee amount from major to atomic units.
+func feeAmountToAtomic(amount decimal.Decimal, currency string) (int64, error) {
+	definition, ok := currencyregistry.Lookup(currency)
+	if !ok {
+		return 0, fmt.Errorf("currency %s is not registered", currency)
+	}
+	return currencyregistry.ParseAmount(amount.StringFixed(int32(definition.Precision)), currency)
+}
+
+func handleFeeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrFeeValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	case errors.Is(err, services.ErrFeePostingNotFound):
+		api.NotFound(w, err)
+	case errors.Is(err, services.ErrFeeResolved):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	case strings.Contains(strings.ToLower(err.Error()), "insufficient fund"):
+		api.WriteErrorResponse(w, http.StatusPaymentRequired, common.ErrInsufficientFund, err)
+	default:
+		handleAccountError(w, r, err)
+	}
+}
@@ -0,0 +1,118 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	ledger "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+)
+
+func TestCBAFeeResolution(t *testing.T) {
+	t.Parallel()
+
+	_, accountRepo, _, productRepo, dailyUsageRepo := newAccountServiceForHTTPTests()
+	feeRepo := newFeePostingRepositoryForHTTPTests()
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithFeeService(services.NewFeeService(accountRepo, productRepo, feeRepo, dailyUsageRepo)))
+
+	accountID := uuid.New()
+	aged := &models.FeePosting{
+		AccountID:       accountID,
+		EventType:       "maintenance",
+		Reference:       "fee:maintenance:aged",
+		Amount:          decimal.RequireFromString("10.00"),
+		RecoveredAmount: decimal.RequireFromString("4.00"),
+		Currency:        "USD",
+		Status:          models.FeePostingStatusWriteoffRequired,
+		CreatedAt:       time.Now().UTC().AddDate(0, 0, -120),
+	}
+	recent := &models.FeePosting{
+		AccountID: accountID,
+		EventType: "maintenance",
+		Reference: "fee:maintenance:recent",
+		Amount:    decimal.RequireFromString("10.00"),
+		Currency:  "USD",
+		Status:    models.FeePostingStatusPendingRecovery,
+		CreatedAt: time.Now().UTC(),
+	}
+	require.NoError(t, feeRepo.Create(context.Background(), aged))
+	require.NoError(t, feeRepo.Create(context.Background(), recent))
+
+	req := httptest.NewRequest(http.MethodGet, "/_/cba/fees?status=writeoff_required&account_id="+accountID.String(), nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	listed, ok := api.DecodeSingleResponse[map[string][]models.FeePosting](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, listed["fees"], 1)
+	require.Equal(t, aged.ID, listed["fees"][0].ID)
+
+	// Only the outstanding part of the fee is expensed as a bad debt.
+	ledgerController.EXPECT().
+		CreateTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+			require.Equal(t, "fee:maintenance:aged:writeoff", params.Input.RunScript.Reference)
+			require.Equal(t, "fee_writeoff", params.Input.RunScript.Metadata["cba_operation"])
+			require.Contains(t, params.Input.RunScript.Plain, "[USD/2 600]")
+			require.Contains(t, params.Input.RunScript.Plain, "@revenue:fee_bad_debt")
+			return &ledger.Log{}, &ledger.CreatedTransaction{}, false, nil
+		})
+
+	req = httptest.NewRequest(http.MethodPost, "/_/cba/fees/"+aged.ID.String()+"/write-off", api.Buffer(t, services.ResolveFeeInput{
+		Reason:     "uncollectable",
+		ResolvedBy: "ops",
+	}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	writtenOff, ok := api.DecodeSingleResponse[models.FeePosting](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.FeePostingStatusWrittenOff, writtenOff.Status)
+
+	req = httptest.NewRequest(http.MethodPost, "/_/cba/fees/"+aged.ID.String()+"/waive", api.Buffer(t, services.ResolveFeeInput{
+		Reason: "goodwill",
+	}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/_/cba/fees/"+recent.ID.String()+"/waive", api.Buffer(t, services.ResolveFeeInput{}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/_/cba/fees/"+recent.ID.String()+"/waive", api.Buffer(t, services.ResolveFeeInput{
+		Reason: "goodwill",
+	}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	waived, ok := api.DecodeSingleResponse[models.FeePosting](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.FeePostingStatusWaived, waived.Status)
+
+	req = httptest.NewRequest(http.MethodGet, "/_/cba/fees/"+uuid.NewString(), nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
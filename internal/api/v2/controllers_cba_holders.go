@@ -0,0 +1,67 @@
+package v2
+
+import (
+	"net/http"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+func addAccountHolder(holderService services.AccountHolderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := getCBAAccountID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		common.WithBody[services.AddAccountHolderInput](w, r, func(req services.AddAccountHolderInput) {
+			account, err := holderService.AddHolder(r.Context(), accountID, req)
+			if err != nil {
+				handleAccountError(w, r, err)
+				return
+			}
+			api.Ok(w, account)
+		})
+	}
+}
+
+func removeAccountHolder(holderService services.AccountHolderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := getCBAAccountID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		clientID, err := getClientID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		account, err := holderService.RemoveHolder(r.Context(), accountID, clientID)
+		if err != nil {
+			handleAccountError(w, r, err)
+			return
+		}
+		api.Ok(w, account)
+	}
+}
+
+func setAccountSigningRule(holderService services.AccountHolderService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := getCBAAccountID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		common.WithBody[services.SetSigningRuleInput](w, r, func(req services.SetSigningRuleInput) {
+			account, err := holderService.SetSigningRule(r.Context(), accountID, req)
+			if err != nil {
+				handleAccountError(w, r, err)
+				return
+			}
+			api.Ok(w, account)
+		})
+	}
+}
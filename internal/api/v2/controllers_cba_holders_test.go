@@ -0,0 +1,130 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
+	ledger "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+)
+
+func TestJointAccountDebitRequiresSignatories(t *testing.T) {
+	accountService, accountRepo, clientRepo, productRepo, _ := newAccountServiceForHTTPTests()
+	holderService := services.NewAccountHolderService(accountRepo, clientRepo)
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithAccountService(accountService), WithAccountHolderService(holderService))
+
+	primary := &models.Client{ID: uuid.New(), ClientNumber: "CL-2026-000301", Type: models.ClientTypeIndividual, Status: models.ClientStatusActive}
+	secondary := &models.Client{ID: uuid.New(), ClientNumber: "CL-2026-000302", Type: models.ClientTypeIndividual, Status: models.ClientStatusActive}
+	require.NoError(t, clientRepo.Create(context.Background(), primary))
+	require.NoError(t, clientRepo.Create(context.Background(), secondary))
+
+	productID := uuid.New()
+	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
+		ID:       productID,
+		Code:     "CUR-USD-001",
+		Name:     "Current USD",
+		Category: "current",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			AllowCredits: true,
+			AllowDebits:  true,
+			MinBalance:   "0",
+		},
+	}))
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "0000003001",
+		ClientID:      primary.ID,
+		ProductID:     productID,
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "client-CL-2026-000301-CUR-USD-001",
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	req := httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/holders", api.Buffer(t, services.AddAccountHolderInput{
+		ClientID: secondary.ID,
+		Role:     models.AccountHolderRoleSecondary,
+	}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	joint, ok := api.DecodeSingleResponse[models.Account](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, joint.Holders, 2)
+	require.Equal(t, models.SigningRuleAnyOne, joint.SigningRule)
+
+	req = httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/signing-rule", api.Buffer(t, services.SetSigningRuleInput{
+		SigningRule: models.SigningRuleAll,
+	}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/debit", api.Buffer(t, AccountDebitRequest{
+		WalletTransactionRequest: WalletTransactionRequest{
+			Amount:    json.Number("75"),
+			Reference: "joint-debit-1",
+		},
+		Signatories: []uuid.UUID{secondary.ID},
+	}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	ledgerController.EXPECT().
+		GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+		Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{
+			Data: []ledger.VolumesWithBalanceByAssetByAccount{{
+				Account: "users:client-CL-2026-000301-CUR-USD-001:wallets:USD:available",
+				Asset:   "USD/2",
+				VolumesWithBalance: ledger.VolumesWithBalance{
+					Input:   big.NewInt(0),
+					Output:  big.NewInt(0),
+					Balance: big.NewInt(200),
+				},
+			}},
+		}, nil)
+	ledgerController.EXPECT().
+		CreateTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+			require.Equal(t, "joint-debit-1", params.Input.RunScript.Reference)
+			return &ledger.Log{}, &ledger.CreatedTransaction{
+				Transaction: ledger.NewTransaction().
+					WithPostings(ledger.NewPosting("users:client-CL-2026-000301-CUR-USD-001:wallets:USD:available", "system:control:USD", "USD/2", big.NewInt(75))),
+			}, false, nil
+		})
+
+	req = httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/debit", api.Buffer(t, AccountDebitRequest{
+		WalletTransactionRequest: WalletTransactionRequest{
+			Amount:    json.Number("75"),
+			Reference: "joint-debit-1",
+		},
+		Signatories: []uuid.UUID{primary.ID, secondary.ID},
+	}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/ledgertrack/accounts/"+account.ID.String()+"/holders/"+primary.ID.String(), nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
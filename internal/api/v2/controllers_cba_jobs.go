@@ -0,0 +1,159 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+// TriggerCBAJobRequest queues runs of a job for the business dates from From
+// to To inclusive, both formatted as YYYY-MM-DD.
+type TriggerCBAJobRequest struct {
+	From        string `json:"from"`
+	To          string `json:"to,omitempty"`
+	RequestedBy string `json:"requested_by,omitempty"`
+}
+
+func triggerCBAJob(jobService services.JobService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req TriggerCBAJobRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		from, err := time.Parse(time.DateOnly, strings.TrimSpace(req.From))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid from: %w", err))
+			return
+		}
+		input := services.TriggerJobInput{
+			Job:         chi.URLParam(r, "job"),
+			From:        from,
+			RequestedBy: req.RequestedBy,
+		}
+		if req.To != "" {
+			to, err := time.Parse(time.DateOnly, strings.TrimSpace(req.To))
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid to: %w", err))
+				return
+			}
+			input.To = &to
+		}
+
+		runs, err := jobService.Trigger(r.Context(), input)
+		if err != nil {
+			handleJobError(w, r, err)
+			return
+		}
+		api.Created(w, runs)
+	}
+}
+
+func listCBAJobRuns(jobService services.JobService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := repositories.JobRunFilter{
+			Limit: 50,
+		}
+		if job := strings.ToLower(strings.TrimSpace(query.Get("job"))); job != "" {
+			filter.Job = &job
+		}
+		for _, status := range query["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		var err error
+		if filter.From, err = parseDateQuery(r, "from"); err != nil {
+			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid from: %w", err))
+			return
+		}
+		if filter.To, err = parseDateQuery(r, "to"); err != nil {
+			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid to: %w", err))
+			return
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		runs, err := jobService.List(r.Context(), filter)
+		if err != nil {
+			handleJobError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"runs": runs,
+		})
+	}
+}
+
+func readCBAJobRun(jobService services.JobService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		runID, err := uuid.Parse(chi.URLParam(r, "runID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		run, err := jobService.Get(r.Context(), runID)
+		if err != nil {
+			handleJobError(w, r, err)
+			return
+		}
+		api.Ok(w, run)
+	}
+}
+
+func listCBAJobLeases(jobService services.JobService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		leases, err := jobService.Leases(r.Context())
+		if err != nil {
+			handleJobError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"leases": leases,
+		})
+	}
+}
+
+func parseDateQuery(r *http.Request, key string) (*time.Time, error) {
+	value := strings.TrimSpace(r.URL.Query().Get(key))
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+func handleJobError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrJobValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	case errors.Is(err, services.ErrJobRunConflict):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	case errors.Is(err, services.ErrJobRunNotFound):
+		api.NotFound(w, err)
+	default:
+		common.InternalServerError(w, r, err)
+	}
+}
@@ -0,0 +1,205 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+type jobRunRepositoryForHTTPTests struct {
+	runs []models.JobRun
+}
+
+func (s *jobRunRepositoryForHTTPTests) Create(_ context.Context, run *models.JobRun) error {
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+func (s *jobRunRepositoryForHTTPTests) Update(context.Context, *models.JobRun) error {
+	return nil
+}
+
+func (s *jobRunRepositoryForHTTPTests) Get(_ context.Context, id uuid.UUID) (*models.JobRun, error) {
+	for _, run := range s.runs {
+		if run.ID == id {
+			return &run, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *jobRunRepositoryForHTTPTests) List(_ context.Context, filter repositories.JobRunFilter) ([]models.JobRun, error) {
+	ret := make([]models.JobRun, 0)
+	for _, run := range s.runs {
+		if filter.Job != nil && run.Job != *filter.Job {
+			continue
+		}
+		if filter.From != nil && run.BusinessDate.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && run.BusinessDate.After(*filter.To) {
+			continue
+		}
+		ret = append(ret, run)
+	}
+	return ret, nil
+}
+
+func (s *jobRunRepositoryForHTTPTests) LastCompleted(context.Context, string) (*models.JobRun, error) {
+	return nil, postgres.ErrNotFound
+}
+
+func (s *jobRunRepositoryForHTTPTests) ClaimPending(context.Context, string, string) (*models.JobRun, error) {
+	return nil, postgres.ErrNotFound
+}
+
+func (s *jobRunRepositoryForHTTPTests) Heartbeat(context.Context, uuid.UUID, string) error {
+	return postgres.ErrNotFound
+}
+
+func (s *jobRunRepositoryForHTTPTests) FailStale(context.Context, string, time.Time) ([]models.JobRun, error) {
+	return nil, nil
+}
+
+type jobLeaseRepositoryForHTTPTests struct {
+	leases []models.JobLease
+}
+
+func (s *jobLeaseRepositoryForHTTPTests) Acquire(context.Context, string, string, time.Duration) (*models.JobLease, bool, error) {
+	return nil, false, nil
+}
+
+func (s *jobLeaseRepositoryForHTTPTests) Release(context.Context, string, string) error {
+	return nil
+}
+
+func (s *jobLeaseRepositoryForHTTPTests) List(context.Context) ([]models.JobLease, error) {
+	return s.leases, nil
+}
+
+func TestTriggerCBAJob(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, false)
+	repository := &jobRunRepositoryForHTTPTests{}
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithJobService(services.NewJobService(repository, &jobLeaseRepositoryForHTTPTests{})))
+
+	from := time.Now().UTC().AddDate(0, 0, -3).Truncate(24 * time.Hour)
+	to := from.AddDate(0, 0, 2)
+	req := httptest.NewRequest(http.MethodPost, "/_/cba/jobs/interest_accrual/runs", api.Buffer(t, TriggerCBAJobRequest{
+		From:        from.Format(time.DateOnly),
+		To:          to.Format(time.DateOnly),
+		RequestedBy: "ops",
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	runs, ok := api.DecodeSingleResponse[[]models.JobRun](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, runs, 3)
+	for i, run := range runs {
+		require.Equal(t, models.JobInterestAccrual, run.Job)
+		require.Equal(t, models.JobRunTriggerManual, run.Trigger)
+		require.Equal(t, models.JobRunStatusPending, run.Status)
+		require.Equal(t, "ops", run.RequestedBy)
+		require.True(t, from.AddDate(0, 0, i).Equal(run.BusinessDate))
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/_/cba/jobs/interest_accrual/runs", api.Buffer(t, TriggerCBAJobRequest{
+		From: to.Format(time.DateOnly),
+	}))
+	rec = httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestTriggerCBAJobValidation(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, false)
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithJobService(services.NewJobService(&jobRunRepositoryForHTTPTests{}, &jobLeaseRepositoryForHTTPTests{})))
+	today := time.Now().UTC()
+
+	for name, tc := range map[string]struct {
+		job string
+		req TriggerCBAJobRequest
+	}{
+		"unknown job":   {job: "reconciliation", req: TriggerCBAJobRequest{From: today.Format(time.DateOnly)}},
+		"invalid date":  {job: "dormancy", req: TriggerCBAJobRequest{From: "15/05/2026"}},
+		"future date":   {job: "dormancy", req: TriggerCBAJobRequest{From: today.AddDate(0, 0, 1).Format(time.DateOnly)}},
+		"reversed":      {job: "dormancy", req: TriggerCBAJobRequest{From: today.Format(time.DateOnly), To: today.AddDate(0, 0, -1).Format(time.DateOnly)}},
+		"range too big": {job: "dormancy", req: TriggerCBAJobRequest{From: today.AddDate(-1, 0, 0).Format(time.DateOnly), To: today.Format(time.DateOnly)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, "/_/cba/jobs/"+tc.job+"/runs", api.Buffer(t, tc.req))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestReadCBAJobRunNotFound(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, false)
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithJobService(services.NewJobService(&jobRunRepositoryForHTTPTests{}, &jobLeaseRepositoryForHTTPTests{})))
+	req := httptest.NewRequest(http.MethodGet, "/_/cba/jobs/runs/"+uuid.NewString(), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	err := api.ErrorResponse{}
+	api.Decode(t, rec.Body, &err)
+	require.EqualValues(t, api.ErrorCodeNotFound, err.ErrorCode)
+}
+
+func TestListCBAJobLeases(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.Now().UTC().Add(30 * time.Second).Truncate(time.Millisecond)
+	systemController, _ := newTestingSystemController(t, false)
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithJobService(services.NewJobService(&jobRunRepositoryForHTTPTests{}, &jobLeaseRepositoryForHTTPTests{
+		leases: []models.JobLease{{
+			Name:      models.SchedulerLease,
+			Holder:    "worker-1",
+			ExpiresAt: expiresAt,
+		}},
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/_/cba/jobs/leases", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	response, ok := api.DecodeSingleResponse[map[string][]models.JobLease](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, response["leases"], 1)
+	require.Equal(t, "worker-1", response["leases"][0].Holder)
+	require.True(t, expiresAt.Equal(response["leases"][0].ExpiresAt))
+}
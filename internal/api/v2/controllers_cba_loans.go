@@ -0,0 +1,36 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+func getLoanSchedule(loanService services.LoanService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := getCBAAccountID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		installments, err := loanService.Schedule(r.Context(), accountID)
+		if err != nil {
+			handleLoanError(w, r, err)
+			return
+		}
+		api.Ok(w, installments)
+	}
+}
+
+func handleLoanError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrLoanValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	default:
+		handleAccountError(w, r, err)
+	}
+}
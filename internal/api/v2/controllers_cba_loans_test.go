@@ -0,0 +1,116 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+type loanInstallmentRepositoryForHTTPTests struct {
+	installments map[uuid.UUID][]models.LoanInstallment
+}
+
+func newLoanInstallmentRepositoryForHTTPTests() *loanInstallmentRepositoryForHTTPTests {
+	return &loanInstallmentRepositoryForHTTPTests{installments: map[uuid.UUID][]models.LoanInstallment{}}
+}
+
+func (s *loanInstallmentRepositoryForHTTPTests) Create(_ context.Context, installment *models.LoanInstallment) error {
+	if installment.ID == uuid.Nil {
+		installment.ID = uuid.New()
+	}
+	s.installments[installment.AccountID] = append(s.installments[installment.AccountID], *installment)
+	return nil
+}
+
+func (s *loanInstallmentRepositoryForHTTPTests) Update(_ context.Context, installment *models.LoanInstallment) error {
+	items := s.installments[installment.AccountID]
+	for i := range items {
+		if items[i].ID == installment.ID {
+			items[i] = *installment
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *loanInstallmentRepositoryForHTTPTests) ListByAccount(_ context.Context, accountID uuid.UUID) ([]models.LoanInstallment, error) {
+	return append([]models.LoanInstallment(nil), s.installments[accountID]...), nil
+}
+
+func TestGetLoanSchedule(t *testing.T) {
+	accountService, accountRepo, _, _, _ := newAccountServiceForHTTPTests()
+	loanService := services.NewLoanService(accountRepo, newLoanInstallmentRepositoryForHTTPTests())
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithAccountService(accountService), WithLoanService(loanService))
+
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "0000000100",
+		ClientID:      uuid.New(),
+		ProductID:     uuid.New(),
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "client-CL-2026-000100-LN-USD-12M",
+		Loan: &models.AccountLoan{
+			Principal:           120_000,
+			Rate:                "12",
+			Amortization:        models.LoanAmortizationFlat,
+			Installments:        12,
+			RepaymentFrequency:  models.RepaymentFrequencyMonthly,
+			Status:              models.LoanStatusApproved,
+			SettlementAccountID: uuid.New(),
+			PenaltyAccrued:      decimal.Zero,
+		},
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+	_, err := loanService.CompleteDisbursement(context.Background(), account.ID, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/ledgertrack/accounts/"+account.ID.String()+"/loan/schedule", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	installments, ok := api.DecodeSingleResponse[[]models.LoanInstallment](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, installments, 12)
+	require.EqualValues(t, 10_000, installments[0].Principal)
+	require.EqualValues(t, 1_200, installments[0].Interest)
+	require.Equal(t, models.InstallmentStatusScheduled, installments[0].Status)
+}
+
+func TestGetLoanScheduleRejectsDepositAccount(t *testing.T) {
+	accountService, accountRepo, _, _, _ := newAccountServiceForHTTPTests()
+	loanService := services.NewLoanService(accountRepo, newLoanInstallmentRepositoryForHTTPTests())
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithAccountService(accountService), WithLoanService(loanService))
+
+	account := &models.Account{
+		ID:       uuid.New(),
+		ClientID: uuid.New(),
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	req := httptest.NewRequest(http.MethodGet, "/ledgertrack/accounts/"+account.ID.String()+"/loan/schedule", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
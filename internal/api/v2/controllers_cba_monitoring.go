@@ -0,0 +1,154 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+func listCBAMonitoringRules(monitoringService services.MonitoringService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		api.Ok(w, map[string]any{
+			"rules": monitoringService.Rules(),
+		})
+	}
+}
+
+func listCBAMonitoringAlerts(monitoringService services.MonitoringService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := repositories.MonitoringAlertFilter{
+			Limit: 50,
+		}
+		for name, target := range map[string]**uuid.UUID{
+			"account_id": &filter.AccountID,
+			"client_id":  &filter.ClientID,
+		} {
+			value := strings.TrimSpace(query.Get(name))
+			if value == "" {
+				continue
+			}
+			id, err := uuid.Parse(value)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid %s: %w", name, err))
+				return
+			}
+			*target = &id
+		}
+		if rule := strings.TrimSpace(query.Get("rule")); rule != "" {
+			filter.Rule = &rule
+		}
+		if ruleType := strings.ToLower(strings.TrimSpace(query.Get("rule_type"))); ruleType != "" {
+			filter.RuleType = &ruleType
+		}
+		if severity := strings.ToLower(strings.TrimSpace(query.Get("severity"))); severity != "" {
+			filter.Severity = &severity
+		}
+		for _, status := range query["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		alerts, err := monitoringService.ListAlerts(r.Context(), filter)
+		if err != nil {
+			handleMonitoringError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"alerts": alerts,
+		})
+	}
+}
+
+func readCBAMonitoringAlert(monitoringService services.MonitoringService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		alertID, err := uuid.Parse(chi.URLParam(r, "alertID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		alert, err := monitoringService.GetAlert(r.Context(), alertID)
+		if err != nil {
+			handleMonitoringError(w, r, err)
+			return
+		}
+		api.Ok(w, alert)
+	}
+}
+
+func assignCBAMonitoringAlert(monitoringService services.MonitoringService) http.HandlerFunc {
+	return triageCBAMonitoringAlert(func(ctx context.Context, id uuid.UUID, input services.AssignAlertInput, actor string) (*models.MonitoringAlert, error) {
+		input.Actor = actor
+		return monitoringService.AssignAlert(ctx, id, input)
+	})
+}
+
+func escalateCBAMonitoringAlert(monitoringService services.MonitoringService) http.HandlerFunc {
+	return triageCBAMonitoringAlert(func(ctx context.Context, id uuid.UUID, input services.TriageAlertInput, actor string) (*models.MonitoringAlert, error) {
+		input.Actor = actor
+		return monitoringService.EscalateAlert(ctx, id, input)
+	})
+}
+
+func closeCBAMonitoringAlert(monitoringService services.MonitoringService) http.HandlerFunc {
+	return triageCBAMonitoringAlert(func(ctx context.Context, id uuid.UUID, input services.CloseAlertInput, actor string) (*models.MonitoringAlert, error) {
+		input.Actor = actor
+		return monitoringService.CloseAlert(ctx, id, input)
+	})
+}
+
+func triageCBAMonitoringAlert[INPUT any](triage func(context.Context, uuid.UUID, INPUT, string) (*models.MonitoringAlert, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		alertID, err := uuid.Parse(chi.URLParam(r, "alertID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		var input INPUT
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		alert, err := triage(r.Context(), alertID, input, actorFromRequest(r))
+		if err != nil {
+			handleMonitoringError(w, r, err)
+			return
+		}
+		api.Ok(w, alert)
+	}
+}
+
+func handleMonitoringError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrMonitoringValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	case errors.Is(err, services.ErrMonitoringAlertInvalidState):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	case errors.Is(err, services.ErrMonitoringAlertNotFound):
+		api.NotFound(w, err)
+	default:
+		handleClientError(w, r, err)
+	}
+}
@@ -0,0 +1,153 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+type monitoringAlertRepositoryForHTTPTests struct {
+	alerts map[uuid.UUID]*models.MonitoringAlert
+}
+
+func (s *monitoringAlertRepositoryForHTTPTests) Create(_ context.Context, alert *models.MonitoringAlert) error {
+	if alert.ID == uuid.Nil {
+		alert.ID = uuid.New()
+	}
+	copied := *alert
+	s.alerts[alert.ID] = &copied
+	return nil
+}
+
+func (s *monitoringAlertRepositoryForHTTPTests) Update(_ context.Context, alert *models.MonitoringAlert) error {
+	copied := *alert
+	s.alerts[alert.ID] = &copied
+	return nil
+}
+
+func (s *monitoringAlertRepositoryForHTTPTests) Transition(_ context.Context, alert *models.MonitoringAlert, from string) error {
+	existing, ok := s.alerts[alert.ID]
+	if !ok || existing.Status != from {
+		return postgres.ErrNotFound
+	}
+	copied := *alert
+	s.alerts[alert.ID] = &copied
+	return nil
+}
+
+func (s *monitoringAlertRepositoryForHTTPTests) Get(_ context.Context, id uuid.UUID) (*models.MonitoringAlert, error) {
+	alert, ok := s.alerts[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	copied := *alert
+	return &copied, nil
+}
+
+func (s *monitoringAlertRepositoryForHTTPTests) List(_ context.Context, filter repositories.MonitoringAlertFilter) ([]models.MonitoringAlert, error) {
+	ret := make([]models.MonitoringAlert, 0)
+	for _, alert := range s.alerts {
+		if filter.AccountID != nil && alert.AccountID != *filter.AccountID {
+			continue
+		}
+		if filter.Severity != nil && alert.Severity != *filter.Severity {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, alert.Status) {
+			continue
+		}
+		ret = append(ret, *alert)
+	}
+	return ret, nil
+}
+
+func TestCBAMonitoringAlertTriage(t *testing.T) {
+	t.Parallel()
+
+	alertRepo := &monitoringAlertRepositoryForHTTPTests{alerts: map[uuid.UUID]*models.MonitoringAlert{}}
+	monitoringService := services.NewMonitoringService(nil, nil, alertRepo, nil)
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithMonitoringService(monitoringService), WithTrustActorHeader(true))
+
+	alert := &models.MonitoringAlert{
+		AccountID: uuid.New(),
+		Rule:      "structuring",
+		RuleType:  models.MonitoringRuleStructuring,
+		Severity:  models.AlertSeverityHigh,
+		Status:    models.AlertStatusOpen,
+		Evidence: []models.AlertEvidence{{
+			Ledger:        "ledgertrack",
+			TransactionID: 12,
+			Direction:     models.MonitoringDirectionCredit,
+			Currency:      "USD",
+			Link:          "/v2/ledgertrack/transactions/12",
+		}},
+	}
+	require.NoError(t, alertRepo.Create(context.Background(), alert))
+
+	req := httptest.NewRequest(http.MethodGet, "/_/cba/monitoring/rules", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rules, ok := api.DecodeSingleResponse[map[string][]services.MonitoringRule](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, rules["rules"], len(services.DefaultMonitoringRules()))
+
+	req = httptest.NewRequest(http.MethodGet, "/_/cba/monitoring/alerts?account_id="+alert.AccountID.String()+"&severity=high&status=open,investigating", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	listed, ok := api.DecodeSingleResponse[map[string][]models.MonitoringAlert](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, listed["alerts"], 1)
+	require.Equal(t, "/v2/ledgertrack/transactions/12", listed["alerts"][0].Evidence[0].Link)
+
+	triage := func(action, actor string, input any) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/_/cba/monitoring/alerts/"+alert.ID.String()+"/"+action, api.Buffer(t, input))
+		if actor != "" {
+			req.Header.Set(HeaderActor, actor)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	require.Equal(t, http.StatusBadRequest, triage("assign", "", services.AssignAlertInput{}).Code)
+
+	rec = triage("assign", "analyst", services.AssignAlertInput{})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assigned, ok := api.DecodeSingleResponse[models.MonitoringAlert](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.AlertStatusInvestigating, assigned.Status)
+	require.Equal(t, "analyst", assigned.Assignee)
+
+	rec = triage("close", "analyst", services.CloseAlertInput{
+		TriageAlertInput: services.TriageAlertInput{Note: "salary paid in three instalments"},
+		Resolution:       models.AlertResolutionLegitimate,
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+	closed, ok := api.DecodeSingleResponse[models.MonitoringAlert](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.AlertStatusClosed, closed.Status)
+	require.Len(t, closed.Notes, 2)
+
+	require.Equal(t, http.StatusConflict, triage("escalate", "analyst", services.TriageAlertInput{Note: "reopen"}).Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/_/cba/monitoring/alerts/"+uuid.NewString(), nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,96 @@
+package v2
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/machine/vm"
+)
+
+// preparePenaltyFee records the penalty fee incurred by trigger. It returns
+// nil when no fee service is configured or the penalty does not apply.
+func preparePenaltyFee(r *http.Request, feeService services.FeeService, accountID uuid.UUID, trigger services.PenaltyTrigger) (*models.FeePosting, error) {
+	if feeService == nil {
+		return nil, nil
+	}
+	posting, err := feeService.PreparePenaltyFee(r.Context(), accountID, trigger)
+	if errors.Is(err, services.ErrFeeNotApplicable) {
+		return nil, nil
+	}
+	return posting, err
+}
+
+// chargeDebitPenalty records a penalty triggered by a debit. The fee is
+// collected later with the other fees pending recovery, so failing to record
+// it does not fail the debit.
+func chargeDebitPenalty(r *http.Request, feeService services.FeeService, accountID uuid.UUID, trigger services.PenaltyTrigger) *models.FeePosting {
+	posting, err := preparePenaltyFee(r, feeService, accountID, trigger)
+	if err != nil {
+		logging.FromContext(r.Context()).Errorf("preparing %s penalty for account %s: %v", trigger.Penalty, accountID, err)
+		return nil
+	}
+	return posting
+}
+
+// debitEarlyClosurePenalty debits the early closure penalty from the wallet
+// of an account being closed, since fees cannot be recovered from closed
+// accounts. It returns the amount debited.
+func debitEarlyClosurePenalty(r *http.Request, feeService services.FeeService, account *models.Account, balance int64) (int64, error) {
+	posting, err := preparePenaltyFee(r, feeService, account.ID, services.PenaltyTrigger{
+		Penalty:   models.PenaltyEarlyClosure,
+		Amount:    balance,
+		Reference: account.ID.String(),
+		At:        time.Now().UTC(),
+	})
+	if err != nil || posting == nil || posting.Status != models.FeePostingStatusPendingRecovery {
+		return 0, err
+	}
+	amount, err := feeAmountToAtomic(posting.Amount, posting.Currency)
+	if err != nil {
+		return 0, err
+	}
+	if walletPosted, _ := posting.Metadata["wallet_posted"].(bool); walletPosted {
+		return amount, nil
+	}
+
+	script := fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, currencyregistry.Asset(account.Currency), amount, walletAvailableAddress(account.WalletID, account.Currency), systemControlAddress(account.WalletID, account.Currency))
+
+	l := common.LedgerFromContext(r.Context())
+	params := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
+		Input: ledgercontroller.CreateTransaction{
+			RunScript: vm.RunScript{
+				Script:    vm.Script{Plain: script},
+				Reference: posting.Reference,
+				Metadata:  buildAccountMetadata(nil, account, "early_closure_penalty"),
+			},
+			Runtime: ledgerinternal.RuntimeMachine,
+		},
+	}
+	// A duplicate reference means a previous attempt already debited the
+	// penalty.
+	if _, _, _, err := l.CreateTransaction(r.Context(), params); err != nil && !strings.Contains(strings.ToLower(err.Error()), "duplicate reference") {
+		return 0, err
+	}
+	if _, err := feeService.MarkPendingRecovery(r.Context(), posting.Reference, map[string]any{"wallet_posted": true}); err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/formancehq/go-libs/v3/api"
 
 	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
 	"github.com/formancehq/ledger/internal/cba/repositories"
 	"github.com/formancehq/ledger/internal/cba/services"
 )
@@ -29,28 +30,43 @@ func createProduct(productService services.ProductService) http.HandlerFunc {
 	}
 }
 
-func listProducts(productService services.ProductService) http.HandlerFunc {
+func listProducts(productService services.ProductService, paginationConfig common.PaginationConfig) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		filter := repositories.ProductFilter{}
-		if category := strings.TrimSpace(r.URL.Query().Get("category")); category != "" {
-			filter.Category = &category
-		}
-		if currency := strings.TrimSpace(r.URL.Query().Get("currency")); currency != "" {
-			currency = strings.ToUpper(currency)
-			filter.Currency = &currency
-		}
-		if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
-			filter.Status = &status
+		query, err := getSystemPaginatedQuery(r, paginationConfig, repositories.ProductSortColumns, func(filter *repositories.ProductFilter) error {
+			if category := strings.TrimSpace(r.URL.Query().Get("category")); category != "" {
+				filter.Category = &category
+			}
+			if currency := strings.TrimSpace(r.URL.Query().Get("currency")); currency != "" {
+				currency = strings.ToUpper(currency)
+				filter.Currency = &currency
+			}
+			if status := strings.TrimSpace(r.URL.Query().Get("status")); status != "" {
+				filter.Status = &status
+			}
+			if search := strings.TrimSpace(r.URL.Query().Get("search")); search != "" {
+				filter.Search = &search
+			}
+			after, before, err := getDateRange(r)
+			if err != nil {
+				return err
+			}
+			filter.CreatedAfter, filter.CreatedBefore = toStdTimePtr(after), toStdTimePtr(before)
+			return nil
+		})
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
 		}
 
-		products, err := productService.List(r.Context(), filter)
+		cursor, err := paginateSystemList(*query, func(filter repositories.ProductFilter, page repositories.Page) ([]models.Product, error) {
+			filter.Page = &page
+			return productService.List(r.Context(), filter)
+		}, repositories.ProductKeyset)
 		if err != nil {
 			handleProductError(w, r, err)
 			return
 		}
-		api.Ok(w, map[string]any{
-			"products": products,
-		})
+		api.RenderCursor(w, *cursor)
 	}
 }
 
@@ -124,6 +140,44 @@ func retireProduct(productService services.ProductService) http.HandlerFunc {
 	}
 }
 
+func changeProductInterestRate(productService services.ProductService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := getProductID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		common.WithBody[services.ChangeInterestRateInput](w, r, func(req services.ChangeInterestRateInput) {
+			version, err := productService.ChangeInterestRate(r.Context(), productID, req)
+			if err != nil {
+				handleProductError(w, r, err)
+				return
+			}
+			api.Created(w, version)
+		})
+	}
+}
+
+func listProductInterestRates(productService services.ProductService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productID, err := getProductID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		versions, err := productService.ListInterestRates(r.Context(), productID)
+		if err != nil {
+			handleProductError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"interest_rates": versions,
+		})
+	}
+}
+
 func getProductID(r *http.Request) (uuid.UUID, error) {
 	productID := chi.URLParam(r, "productID")
 	if productID == "" {
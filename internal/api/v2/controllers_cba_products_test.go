@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
 
 	"github.com/formancehq/go-libs/v3/api"
 	"github.com/formancehq/go-libs/v3/auth"
@@ -18,10 +20,6 @@ import (
 	"github.com/formancehq/ledger/internal/cba/services"
 )
 
-type productResponse struct {
-	Products []models.Product `json:"products"`
-}
-
 type productRepositoryForHTTPTests struct {
 	products    map[uuid.UUID]*models.Product
 	lastFilter  repositories.ProductFilter
@@ -98,9 +96,31 @@ func (s *productRepositoryForHTTPTests) List(_ context.Context, filter repositor
 	return ret, nil
 }
 
+type interestRateRepositoryForHTTPTests struct {
+	versions []models.InterestRateVersion
+}
+
+func (s *interestRateRepositoryForHTTPTests) Create(_ context.Context, version *models.InterestRateVersion) error {
+	if version.ID == uuid.Nil {
+		version.ID = uuid.New()
+	}
+	s.versions = append(s.versions, *version)
+	return nil
+}
+
+func (s *interestRateRepositoryForHTTPTests) ListByProduct(_ context.Context, productID uuid.UUID) ([]models.InterestRateVersion, error) {
+	ret := make([]models.InterestRateVersion, 0)
+	for _, version := range s.versions {
+		if version.ProductID == productID {
+			ret = append(ret, version)
+		}
+	}
+	return ret, nil
+}
+
 func newProductServiceForHTTPTests() (services.ProductService, *productRepositoryForHTTPTests) {
 	repo := newProductRepositoryForHTTPTests()
-	return services.NewProductService(repo), repo
+	return services.NewProductService(repo, &interestRateRepositoryForHTTPTests{}), repo
 }
 
 func TestCreateProduct(t *testing.T) {
@@ -169,10 +189,10 @@ func TestListProductsAppliesFilters(t *testing.T) {
 	router.ServeHTTP(rec, req)
 
 	require.Equal(t, http.StatusOK, rec.Code)
-	response, ok := api.DecodeSingleResponse[productResponse](t, rec.Body)
-	require.True(t, ok)
-	require.Len(t, response.Products, 1)
-	require.Equal(t, "SAV-NGN-001", response.Products[0].Code)
+	cursor := api.DecodeCursorResponse[models.Product](t, rec.Body)
+	require.Len(t, cursor.Data, 1)
+	require.Equal(t, "SAV-NGN-001", cursor.Data[0].Code)
+	require.False(t, cursor.HasMore)
 
 	require.NotNil(t, repo.lastFilter.Category)
 	require.Equal(t, "savings", *repo.lastFilter.Category)
@@ -206,3 +226,54 @@ func TestActivateProduct(t *testing.T) {
 	require.Equal(t, product.ID, activated.ID)
 	require.Equal(t, models.ProductStatusActive, activated.Status)
 }
+
+func TestChangeProductInterestRate(t *testing.T) {
+	productService, _ := newProductServiceForHTTPTests()
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithProductService(productService))
+
+	product, err := productService.Create(context.Background(), services.CreateProductInput{
+		Code:           "SAV-USD-001",
+		Name:           "Savings USD",
+		Category:       "savings",
+		Currency:       "USD",
+		InterestConfig: &models.InterestConfig{Type: "simple", Rate: "4", AccrualFrequency: "daily", PostingFrequency: "monthly"},
+	})
+	require.NoError(t, err)
+
+	effectiveFrom := time.Now().UTC().AddDate(0, 0, 1).Format(time.DateOnly)
+	req := httptest.NewRequest(http.MethodPost, "/test/products/"+product.ID.String()+"/interest-rates", api.Buffer(t, services.ChangeInterestRateInput{
+		Rate:          "4.5",
+		EffectiveFrom: effectiveFrom,
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	version, ok := api.DecodeSingleResponse[models.InterestRateVersion](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, 2, version.Version)
+	require.Equal(t, "4.5", version.Rate)
+
+	req = httptest.NewRequest(http.MethodPost, "/test/products/"+product.ID.String()+"/interest-rates", api.Buffer(t, services.ChangeInterestRateInput{
+		Rate:          "5",
+		EffectiveFrom: effectiveFrom,
+	}))
+	rec = httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/test/products/"+product.ID.String()+"/interest-rates", nil)
+	rec = httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	versions, ok := api.DecodeSingleResponse[map[string][]models.InterestRateVersion](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, versions["interest_rates"], 1)
+}
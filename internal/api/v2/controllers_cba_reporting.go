@@ -105,6 +105,23 @@ func getInterestFeeReport(reportingService services.ReportingService) http.Handl
 	}
 }
 
+func getWithholdingTaxReport(reportingService services.ReportingService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := extractInterestFeeReportFilter(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		report, err := reportingService.WithholdingTaxSummary(r.Context(), filter)
+		if err != nil {
+			handleReportingError(w, r, err)
+			return
+		}
+		api.Ok(w, report)
+	}
+}
+
 func extractStatementReportFilter(r *http.Request) (services.StatementReportFilter, error) {
 	startTime, err := parseRFC3339Query(r, "startTime")
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
 
@@ -16,8 +17,10 @@ import (
 	"github.com/formancehq/go-libs/v3/api"
 	"github.com/formancehq/go-libs/v3/auth"
 	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
 	ledger "github.com/formancehq/ledger/internal"
 	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
 	"github.com/formancehq/ledger/internal/cba/services"
 )
 
@@ -107,7 +110,38 @@ func (s *feePostingRepositoryForHTTPTests) GetByReference(_ context.Context, ref
 			}
 		}
 	}
-	return nil, nil
+	return nil, postgres.ErrNotFound
+}
+
+func (s *feePostingRepositoryForHTTPTests) Get(_ context.Context, id uuid.UUID) (*models.FeePosting, error) {
+	for _, items := range s.postings {
+		for _, item := range items {
+			if item.ID == id {
+				copied := item
+				return &copied, nil
+			}
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *feePostingRepositoryForHTTPTests) List(_ context.Context, filter repositories.FeePostingFilter) ([]models.FeePosting, error) {
+	ret := make([]models.FeePosting, 0)
+	for _, items := range s.postings {
+		for _, item := range items {
+			if filter.AccountID != nil && item.AccountID != *filter.AccountID {
+				continue
+			}
+			if filter.EventType != nil && item.EventType != *filter.EventType {
+				continue
+			}
+			if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, item.Status) {
+				continue
+			}
+			ret = append(ret, item)
+		}
+	}
+	return ret, nil
 }
 
 func (s *feePostingRepositoryForHTTPTests) ListByAccount(_ context.Context, accountID uuid.UUID) ([]models.FeePosting, error) {
@@ -134,7 +168,43 @@ func newReportingServiceForHTTPTests() (services.ReportingService, *clientReposi
 	accountRepo := newAccountRepositoryForHTTPTests()
 	interestRepo := newInterestAccrualRepositoryForHTTPTests()
 	feeRepo := newFeePostingRepositoryForHTTPTests()
-	return services.NewReportingService(clientRepo, accountRepo, interestRepo, feeRepo), clientRepo, accountRepo, interestRepo, feeRepo
+	return services.NewReportingService(clientRepo, accountRepo, interestRepo, feeRepo, newTaxPostingRepositoryForHTTPTests()), clientRepo, accountRepo, interestRepo, feeRepo
+}
+
+type taxPostingRepositoryForHTTPTests struct {
+	postings []models.TaxPosting
+}
+
+func newTaxPostingRepositoryForHTTPTests() *taxPostingRepositoryForHTTPTests {
+	return &taxPostingRepositoryForHTTPTests{}
+}
+
+func (s *taxPostingRepositoryForHTTPTests) Create(_ context.Context, posting *models.TaxPosting) error {
+	if posting.ID == uuid.Nil {
+		posting.ID = uuid.New()
+	}
+	s.postings = append(s.postings, *posting)
+	return nil
+}
+
+func (s *taxPostingRepositoryForHTTPTests) GetByInterestReference(_ context.Context, reference string) (*models.TaxPosting, error) {
+	for _, posting := range s.postings {
+		if posting.InterestReference == reference {
+			copied := posting
+			return &copied, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *taxPostingRepositoryForHTTPTests) ListByAccount(_ context.Context, accountID uuid.UUID) ([]models.TaxPosting, error) {
+	ret := make([]models.TaxPosting, 0)
+	for _, posting := range s.postings {
+		if posting.AccountID == accountID {
+			ret = append(ret, posting)
+		}
+	}
+	return ret, nil
 }
 
 type clientPortfolioResponse struct {
@@ -177,8 +247,8 @@ type interestFeeReportResponse struct {
 func TestListClientAccounts(t *testing.T) {
 	accountService, accountRepo, clientRepo, _, _ := newAccountServiceForHTTPTests()
 	kycRepo := newKYCRepositoryForHTTPTests()
-	clientService := services.NewClientService(clientRepo, accountRepo)
-	kycService := services.NewKYCService(clientRepo, kycRepo)
+	clientService := services.NewClientService(clientRepo, accountRepo, nil)
+	kycService := services.NewKYCService(clientRepo, kycRepo, nil, nil)
 	systemController, ledgerController := newTestingSystemController(t, true)
 	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
 	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithClientService(clientService), WithKYCService(kycService), WithAccountService(accountService))
@@ -225,8 +295,8 @@ func TestListClientAccounts(t *testing.T) {
 func TestGetClientPortfolioReport(t *testing.T) {
 	reportingService, clientRepo, accountRepo, _, _ := newReportingServiceForHTTPTests()
 	kycRepo := newKYCRepositoryForHTTPTests()
-	clientService := services.NewClientService(clientRepo, accountRepo)
-	kycService := services.NewKYCService(clientRepo, kycRepo)
+	clientService := services.NewClientService(clientRepo, accountRepo, nil)
+	kycService := services.NewKYCService(clientRepo, kycRepo, nil, nil)
 	systemController, ledgerController := newTestingSystemController(t, false)
 	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
 	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithClientService(clientService), WithKYCService(kycService), WithReportingService(reportingService))
@@ -427,3 +497,58 @@ func TestGetInterestFeeReport(t *testing.T) {
 	require.EqualValues(t, 1, response.Totals.FeePostedCount)
 	require.EqualValues(t, 1, response.Totals.FeePendingRecoveryCount)
 }
+
+func TestGetWithholdingTaxReport(t *testing.T) {
+	clientRepo := newClientRepositoryForHTTPTests()
+	accountRepo := newAccountRepositoryForHTTPTests()
+	interestRepo := newInterestAccrualRepositoryForHTTPTests()
+	taxRepo := newTaxPostingRepositoryForHTTPTests()
+	reportingService := services.NewReportingService(clientRepo, accountRepo, interestRepo, newFeePostingRepositoryForHTTPTests(), taxRepo)
+	systemController, ledgerController := newTestingSystemController(t, true)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithReportingService(reportingService))
+
+	accountID := uuid.New()
+	require.NoError(t, accountRepo.Create(context.Background(), &models.Account{
+		ID:            accountID,
+		AccountNumber: "0000006001",
+		ClientID:      uuid.New(),
+		ProductID:     uuid.New(),
+		Currency:      "EUR",
+		Status:        models.AccountStatusActive,
+		WalletID:      "wallet-withholding-1",
+	}))
+	require.NoError(t, interestRepo.Create(context.Background(), &models.InterestAccrual{
+		AccountID:   accountID,
+		AccrualDate: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC),
+		Amount:      decimal.RequireFromString("10"),
+	}))
+	for _, posting := range []models.TaxPosting{
+		{InterestReference: "interest-1", Residency: "FR", GrossAmount: 1_000, TaxAmount: 300, NetAmount: 700},
+		{InterestReference: "interest-2", Residency: "FR", GrossAmount: 500, NetAmount: 500, Exempt: true},
+	} {
+		posting.AccountID = accountID
+		posting.Currency = "EUR"
+		posting.CreatedAt = time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)
+		require.NoError(t, taxRepo.Create(context.Background(), &posting))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ledgertrack/reports/withholding-tax?account_id="+accountID.String(), nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	response, ok := api.DecodeSingleResponse[services.WithholdingTaxReport](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, response.Accounts, 1)
+	require.Equal(t, "10", response.Accounts[0].InterestAccrued)
+	require.Equal(t, []services.WithholdingTaxTotals{{
+		Currency:       "EUR",
+		Residency:      "FR",
+		GrossInterest:  1_500,
+		TaxWithheld:    300,
+		NetInterest:    1_200,
+		ExemptInterest: 500,
+		PostingCount:   2,
+	}}, response.Totals)
+}
@@ -0,0 +1,250 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+)
+
+// requireScreening screens the counterparties named in the metadata of an
+// account credit or debit, and refuses the transaction when its client or
+// one of the counterparties is blocked.
+func requireScreening(screeningService services.ScreeningService, accountService services.AccountService) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if screeningService == nil {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			payload, err := io.ReadAll(r.Body)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(payload))
+
+			var req WalletTransactionRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				// Let the operation report the malformed payload.
+				next(w, r)
+				return
+			}
+			accountID, err := getCBAAccountID(r)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, err)
+				return
+			}
+			account, err := accountService.Get(r.Context(), accountID)
+			if err != nil {
+				handleAccountError(w, r, err)
+				return
+			}
+
+			if err := screeningService.ScreenTransaction(r.Context(), services.ScreenTransactionInput{
+				ClientID:  account.ClientID,
+				AccountID: account.ID,
+				Reference: req.Reference,
+				Metadata:  req.Metadata,
+			}); err != nil {
+				handleScreeningError(w, r, err)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// requireWalletScreening screens the debits and transfers of the wallets of
+// accounts like requireScreening, together with the account receiving a
+// transfer. Wallets which are not the wallet of an account have no client to
+// screen.
+func requireWalletScreening(screeningService services.ScreeningService, accountService services.AccountService) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if screeningService == nil || accountService == nil {
+			return next
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			// Accounts only hold wallets on ledgertrack.
+			if chi.URLParam(r, "ledger") != "ledgertrack" {
+				next(w, r)
+				return
+			}
+			payload, err := io.ReadAll(r.Body)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(payload))
+
+			var req WalletTransferRequest
+			if err := json.Unmarshal(payload, &req); err != nil {
+				// Let the operation report the malformed payload.
+				next(w, r)
+				return
+			}
+			for _, party := range []struct {
+				walletID string
+				metadata map[string]string
+			}{
+				{walletID: chi.URLParam(r, "walletID"), metadata: req.Metadata},
+				{walletID: req.DestinationWalletID},
+			} {
+				account, err := walletAccount(r.Context(), accountService, party.walletID)
+				if err != nil {
+					handleAccountError(w, r, err)
+					return
+				}
+				if account == nil {
+					continue
+				}
+				if err := screeningService.ScreenTransaction(r.Context(), services.ScreenTransactionInput{
+					ClientID:  account.ClientID,
+					AccountID: account.ID,
+					Reference: req.Reference,
+					Metadata:  party.metadata,
+				}); err != nil {
+					handleScreeningError(w, r, err)
+					return
+				}
+			}
+			next(w, r)
+		}
+	}
+}
+
+// walletAccount returns the account walletID is the wallet of, nil when it
+// is not the wallet of an account.
+func walletAccount(ctx context.Context, accountService services.AccountService, walletID string) (*models.Account, error) {
+	id, err := walletmodels.ParseWalletID(walletID)
+	if err != nil {
+		// The operation reports the invalid wallet.
+		return nil, nil
+	}
+	account, err := accountService.GetByWalletID(ctx, id.UserID)
+	switch {
+	case errors.Is(err, services.ErrAccountNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case account.Currency != id.Currency:
+		return nil, nil
+	}
+	return account, nil
+}
+
+func listCBAScreeningCases(screeningService services.ScreeningService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := repositories.ScreeningCaseFilter{
+			Limit: 50,
+		}
+		if value := strings.TrimSpace(query.Get("client_id")); value != "" {
+			clientID, err := uuid.Parse(value)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid client_id: %w", err))
+				return
+			}
+			filter.ClientID = &clientID
+		}
+		if subjectType := strings.ToLower(strings.TrimSpace(query.Get("subject_type"))); subjectType != "" {
+			filter.SubjectType = &subjectType
+		}
+		for _, status := range query["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		cases, err := screeningService.ListCases(r.Context(), filter)
+		if err != nil {
+			handleScreeningError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"cases": cases,
+		})
+	}
+}
+
+func readCBAScreeningCase(screeningService services.ScreeningService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caseID, err := uuid.Parse(chi.URLParam(r, "caseID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		screeningCase, err := screeningService.GetCase(r.Context(), caseID)
+		if err != nil {
+			handleScreeningError(w, r, err)
+			return
+		}
+		api.Ok(w, screeningCase)
+	}
+}
+
+func clearCBAScreeningCase(screeningService services.ScreeningService) http.HandlerFunc {
+	return reviewCBAScreeningCase(screeningService.ClearCase)
+}
+
+func confirmCBAScreeningCase(screeningService services.ScreeningService) http.HandlerFunc {
+	return reviewCBAScreeningCase(screeningService.ConfirmCase)
+}
+
+func reviewCBAScreeningCase(review func(context.Context, uuid.UUID, services.ReviewScreeningCaseInput) (*models.ScreeningCase, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		caseID, err := uuid.Parse(chi.URLParam(r, "caseID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		var input services.ReviewScreeningCaseInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		input.ReviewedBy = actorFromRequest(r)
+
+		screeningCase, err := review(r.Context(), caseID, input)
+		if err != nil {
+			handleScreeningError(w, r, err)
+			return
+		}
+		api.Ok(w, screeningCase)
+	}
+}
+
+func handleScreeningError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrScreeningValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	case errors.Is(err, services.ErrScreeningBlocked):
+		api.WriteErrorResponse(w, http.StatusForbidden, common.ErrForbidden, err)
+	case errors.Is(err, services.ErrScreeningCaseReviewed):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	case errors.Is(err, services.ErrScreeningCaseNotFound):
+		api.NotFound(w, err)
+	default:
+		handleClientError(w, r, err)
+	}
+}
@@ -0,0 +1,231 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+type screeningCaseRepositoryForHTTPTests struct {
+	cases map[uuid.UUID]*models.ScreeningCase
+}
+
+func (s *screeningCaseRepositoryForHTTPTests) Create(_ context.Context, screeningCase *models.ScreeningCase) error {
+	if screeningCase.ID == uuid.Nil {
+		screeningCase.ID = uuid.New()
+	}
+	copied := *screeningCase
+	s.cases[screeningCase.ID] = &copied
+	return nil
+}
+
+func (s *screeningCaseRepositoryForHTTPTests) Transition(_ context.Context, screeningCase *models.ScreeningCase, from string) error {
+	existing, ok := s.cases[screeningCase.ID]
+	if !ok || existing.Status != from {
+		return postgres.ErrNotFound
+	}
+	copied := *screeningCase
+	s.cases[screeningCase.ID] = &copied
+	return nil
+}
+
+func (s *screeningCaseRepositoryForHTTPTests) Get(_ context.Context, id uuid.UUID) (*models.ScreeningCase, error) {
+	screeningCase, ok := s.cases[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	copied := *screeningCase
+	return &copied, nil
+}
+
+func (s *screeningCaseRepositoryForHTTPTests) List(_ context.Context, filter repositories.ScreeningCaseFilter) ([]models.ScreeningCase, error) {
+	ret := make([]models.ScreeningCase, 0)
+	for _, screeningCase := range s.cases {
+		if filter.ClientID != nil && screeningCase.ClientID != *filter.ClientID {
+			continue
+		}
+		if filter.SubjectType != nil && screeningCase.SubjectType != *filter.SubjectType {
+			continue
+		}
+		ret = append(ret, *screeningCase)
+	}
+	return ret, nil
+}
+
+func TestCBAScreeningOfAccountCounterparties(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sanctions.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id,name\n1,Boris Badenov\n"), 0o600))
+
+	accountService, accountRepo, clientRepo, _, _ := newAccountServiceForHTTPTests()
+	screeningService := services.NewScreeningService(
+		clientRepo,
+		&screeningCaseRepositoryForHTTPTests{cases: map[uuid.UUID]*models.ScreeningCase{}},
+		services.NewWatchlistStore(path),
+		0,
+	)
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop",
+		WithAccountService(accountService),
+		WithScreeningService(screeningService),
+		WithTrustActorHeader(true),
+	)
+
+	client := &models.Client{
+		ID:              uuid.New(),
+		ClientNumber:    "CL-2026-000042",
+		Type:            models.ClientTypeIndividual,
+		Status:          models.ClientStatusActive,
+		ScreeningStatus: models.ScreeningStatusClear,
+	}
+	require.NoError(t, clientRepo.Create(context.Background(), client))
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "0000000042",
+		ClientID:      client.ID,
+		ProductID:     uuid.New(),
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "client-CL-2026-000042-CUR-USD-001",
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	req := httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/credit", api.Buffer(t, WalletTransactionRequest{
+		Amount:    json.Number("20"),
+		Reference: "credit-ref-1",
+		Metadata:  map[string]string{"originator_name": "BADENOV, Boris"},
+	}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	errorResponse := api.ErrorResponse{}
+	api.Decode(t, rec.Body, &errorResponse)
+	require.EqualValues(t, common.ErrForbidden, errorResponse.ErrorCode)
+
+	req = httptest.NewRequest(http.MethodGet, "/_/cba/screening/cases?client_id="+client.ID.String()+"&status=open", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	listed, ok := api.DecodeSingleResponse[map[string][]models.ScreeningCase](t, rec.Body)
+	require.True(t, ok)
+	require.Len(t, listed["cases"], 1)
+	screeningCase := listed["cases"][0]
+	require.Equal(t, models.ScreeningSubjectCounterparty, screeningCase.SubjectType)
+	require.Equal(t, "credit-ref-1", screeningCase.Reference)
+	require.Equal(t, models.ScreeningActionBlock, screeningCase.Action)
+
+	review := func(action, actor string, input services.ReviewScreeningCaseInput) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/_/cba/screening/cases/"+screeningCase.ID.String()+"/"+action, api.Buffer(t, input))
+		if actor != "" {
+			req.Header.Set(HeaderActor, actor)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	require.Equal(t, http.StatusBadRequest, review("clear", "", services.ReviewScreeningCaseInput{Note: "namesake"}).Code)
+	require.Equal(t, http.StatusBadRequest, review("clear", "compliance", services.ReviewScreeningCaseInput{}).Code)
+
+	rec = review("clear", "compliance", services.ReviewScreeningCaseInput{Note: "namesake, different country"})
+	require.Equal(t, http.StatusOK, rec.Code)
+	cleared, ok := api.DecodeSingleResponse[models.ScreeningCase](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.ScreeningCaseStatusCleared, cleared.Status)
+	require.Equal(t, "compliance", cleared.ReviewedBy)
+	stored, err := clientRepo.Get(context.Background(), client.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ScreeningStatusClear, stored.ScreeningStatus)
+
+	require.Equal(t, http.StatusConflict, review("confirm", "compliance", services.ReviewScreeningCaseInput{}).Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/_/cba/screening/cases/"+uuid.NewString(), nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCBAScreeningOfWalletTransactions(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "sanctions.csv")
+	require.NoError(t, os.WriteFile(path, []byte("id,name\n1,Boris Badenov\n"), 0o600))
+
+	accountService, accountRepo, clientRepo, _, _ := newAccountServiceForHTTPTests()
+	screeningService := services.NewScreeningService(
+		clientRepo,
+		&screeningCaseRepositoryForHTTPTests{cases: map[uuid.UUID]*models.ScreeningCase{}},
+		services.NewWatchlistStore(path),
+		0,
+	)
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop",
+		WithAccountService(accountService),
+		WithScreeningService(screeningService),
+	)
+
+	newAccount := func(clientNumber, accountNumber, screeningStatus string) *models.Account {
+		client := &models.Client{
+			ID:              uuid.New(),
+			ClientNumber:    clientNumber,
+			Type:            models.ClientTypeIndividual,
+			Status:          models.ClientStatusActive,
+			ScreeningStatus: screeningStatus,
+		}
+		require.NoError(t, clientRepo.Create(context.Background(), client))
+		account := &models.Account{
+			ID:            uuid.New(),
+			AccountNumber: accountNumber,
+			ClientID:      client.ID,
+			ProductID:     uuid.New(),
+			Currency:      "USD",
+			Status:        models.AccountStatusActive,
+			WalletID:      "client-" + clientNumber + "-CUR-USD-001",
+		}
+		require.NoError(t, accountRepo.Create(context.Background(), account))
+		return account
+	}
+	source := newAccount("CL-2026-000043", "0000000043", models.ScreeningStatusClear)
+	destination := newAccount("CL-2026-000044", "0000000044", models.ScreeningStatusBlocked)
+
+	// The counterparties of a debit from the wallet of an account are screened.
+	req := httptest.NewRequest(http.MethodPost, "/ledgertrack/wallets/"+source.WalletID+"-USD/debit", api.Buffer(t, WalletTransactionRequest{
+		Amount:    json.Number("20"),
+		Reference: "debit-ref-1",
+		Metadata:  map[string]string{"beneficiary_name": "BADENOV, Boris"},
+	}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	errorResponse := api.ErrorResponse{}
+	api.Decode(t, rec.Body, &errorResponse)
+	require.EqualValues(t, common.ErrForbidden, errorResponse.ErrorCode)
+
+	// So is the account receiving a transfer.
+	req = httptest.NewRequest(http.MethodPost, "/ledgertrack/wallets/user123-USD/transfer", api.Buffer(t, WalletTransferRequest{
+		DestinationWalletID: destination.WalletID + "-USD",
+		Amount:              json.Number("20"),
+		Reference:           "transfer-ref-1",
+	}))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+}
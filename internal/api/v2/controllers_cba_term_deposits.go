@@ -0,0 +1,133 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/machine/vm"
+)
+
+// BreakTermDepositRequest breaks a term deposit before maturity. The early
+// withdrawal penalty is debited under a reference derived from Reference.
+type BreakTermDepositRequest struct {
+	Reference string            `json:"reference"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+func setTermDepositRollover(termDepositService services.TermDepositService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := getCBAAccountID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		common.WithBody[services.SetRolloverInput](w, r, func(req services.SetRolloverInput) {
+			account, err := termDepositService.SetRollover(r.Context(), accountID, req)
+			if err != nil {
+				handleTermDepositError(w, r, err)
+				return
+			}
+			api.Ok(w, account)
+		})
+	}
+}
+
+func breakTermDeposit(accountService services.AccountService, termDepositService services.TermDepositService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		accountID, err := getCBAAccountID(r)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		account, err := accountService.Get(r.Context(), accountID)
+		if err != nil {
+			handleAccountError(w, r, err)
+			return
+		}
+
+		var req BreakTermDepositRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		if req.Reference == "" {
+			api.BadRequest(w, common.ErrValidation, fmt.Errorf("reference is required"))
+			return
+		}
+
+		l := common.LedgerFromContext(r.Context())
+		currentBalance, err := readAvailableBalance(r.Context(), l, account.WalletID, account.Currency)
+		if err != nil {
+			common.HandleCommonErrors(w, r, err)
+			return
+		}
+		termBreak, err := termDepositService.Break(r.Context(), accountID, currentBalance, req.Reference)
+		if err != nil {
+			handleTermDepositError(w, r, err)
+			return
+		}
+
+		if termBreak.Penalty != nil {
+			if walletPosted, _ := termBreak.Penalty.Metadata["wallet_posted"].(bool); !walletPosted {
+				script := fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, currencyregistry.Asset(account.Currency), termBreak.PenaltyAmount, walletAvailableAddress(account.WalletID, account.Currency), systemControlAddress(account.WalletID, account.Currency))
+
+				params := ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
+					IdempotencyKey: r.Header.Get("Idempotency-Key"),
+					Input: ledgercontroller.CreateTransaction{
+						RunScript: vm.RunScript{
+							Script:    vm.Script{Plain: script},
+							Reference: termBreak.Penalty.Reference,
+							Metadata:  buildAccountMetadata(req.Metadata, account, "term_break_penalty"),
+						},
+						Runtime: ledgerinternal.RuntimeMachine,
+					},
+				}
+				// A duplicate reference means a previous attempt already
+				// debited the penalty.
+				if _, _, _, err := l.CreateTransaction(r.Context(), params); err != nil && !strings.Contains(strings.ToLower(err.Error()), "duplicate reference") {
+					if strings.Contains(strings.ToLower(err.Error()), "insufficient fund") {
+						api.WriteErrorResponse(w, http.StatusPaymentRequired, common.ErrInsufficientFund, err)
+						return
+					}
+					common.HandleCommonWriteErrors(w, r, err)
+					return
+				}
+				if err := termDepositService.MarkPenaltyDebited(r.Context(), req.Reference); err != nil {
+					handleTermDepositError(w, r, err)
+					return
+				}
+			}
+		}
+
+		api.Ok(w, map[string]any{
+			"account": termBreak.Account,
+			"penalty": termBreak.Penalty,
+		})
+	}
+}
+
+func handleTermDepositError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrTermDepositValidation),
+		errors.Is(err, services.ErrTermDepositNotMatured),
+		errors.Is(err, services.ErrFeeValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	default:
+		handleAccountError(w, r, err)
+	}
+}
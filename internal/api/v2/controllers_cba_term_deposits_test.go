@@ -0,0 +1,175 @@
+package v2
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
+
+	ledger "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+)
+
+func newTermDepositForHTTPTests(t *testing.T, accountRepo *accountRepositoryForHTTPTests, productRepo *productRepositoryForHTTPTests, allowEarlyBreak bool) *models.Account {
+	t.Helper()
+
+	productID := uuid.New()
+	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
+		ID:       productID,
+		Code:     "FD-USD-6M",
+		Name:     "Fixed Deposit 6 Months",
+		Category: "fixed_deposit",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			AllowCredits: true,
+			AllowDebits:  true,
+			MinBalance:   "0",
+		},
+		TermConfig: &models.TermConfig{
+			Length:          6,
+			Unit:            models.TermUnitMonths,
+			AllowEarlyBreak: allowEarlyBreak,
+		},
+		FeeSchedule: &models.FeeSchedule{
+			PenaltyFees: map[string]models.PenaltyFee{
+				models.PenaltyEarlyWithdrawal: {Type: "flat", Value: "15.00"},
+			},
+		},
+	}))
+
+	maturity := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 3, 0)
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "0000000090",
+		ClientID:      uuid.New(),
+		ProductID:     productID,
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "client-CL-2026-000090-FD-USD-6M",
+		MaturityDate:  &maturity,
+		Term: &models.AccountTerm{
+			Length:         6,
+			Unit:           models.TermUnitMonths,
+			Status:         models.TermStatusRunning,
+			Principal:      100_000,
+			RolloverOption: models.RolloverPrincipalAndInterest,
+		},
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+	return account
+}
+
+func TestBreakTermDeposit(t *testing.T) {
+	accountService, accountRepo, _, productRepo, _ := newAccountServiceForHTTPTests()
+	feeRepo := newFeePostingRepositoryForHTTPTests()
+	termDepositService := services.NewTermDepositService(accountRepo, productRepo, feeRepo)
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithAccountService(accountService), WithTermDepositService(termDepositService))
+
+	account := newTermDepositForHTTPTests(t, accountRepo, productRepo, true)
+	available := "users:client-CL-2026-000090-FD-USD-6M:wallets:USD:available"
+
+	ledgerController.EXPECT().
+		GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+		Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{
+			Data: []ledger.VolumesWithBalanceByAssetByAccount{{
+				Account: available,
+				Asset:   "USD/2",
+				VolumesWithBalance: ledger.VolumesWithBalance{
+					Input:   big.NewInt(100_000),
+					Output:  big.NewInt(0),
+					Balance: big.NewInt(100_000),
+				},
+			}},
+		}, nil)
+	ledgerController.EXPECT().
+		CreateTransaction(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+			require.Equal(t, "penalty:early_withdrawal:break-ref-1", params.Input.RunScript.Reference)
+			require.Contains(t, params.Input.RunScript.Plain, "[USD/2 1500]")
+			require.Equal(t, "term_break_penalty", params.Input.RunScript.Metadata["cba_operation"])
+			return &ledger.Log{}, &ledger.CreatedTransaction{
+				Transaction: ledger.NewTransaction().
+					WithPostings(ledger.NewPosting(available, "system:control:USD", "USD/2", big.NewInt(1_500))),
+			}, false, nil
+		})
+
+	req := httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/term/break", api.Buffer(t, BreakTermDepositRequest{
+		Reference: "break-ref-1",
+	}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	response, ok := api.DecodeSingleResponse[struct {
+		Account models.Account    `json:"account"`
+		Penalty models.FeePosting `json:"penalty"`
+	}](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, models.TermStatusBroken, response.Account.Term.Status)
+	require.Nil(t, response.Account.MaturityDate)
+	require.Equal(t, "15", response.Penalty.Amount.String())
+
+	postings, err := feeRepo.ListByAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 1)
+	require.Equal(t, true, postings[0].Metadata["wallet_posted"])
+	require.Equal(t, models.FeePostingStatusPendingRecovery, postings[0].Status)
+}
+
+func TestBreakTermDepositNotAllowed(t *testing.T) {
+	accountService, accountRepo, _, productRepo, _ := newAccountServiceForHTTPTests()
+	termDepositService := services.NewTermDepositService(accountRepo, productRepo, newFeePostingRepositoryForHTTPTests())
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithAccountService(accountService), WithTermDepositService(termDepositService))
+
+	account := newTermDepositForHTTPTests(t, accountRepo, productRepo, false)
+	ledgerController.EXPECT().
+		GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+		Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/term/break", api.Buffer(t, BreakTermDepositRequest{
+		Reference: "break-ref-2",
+	}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	err := api.ErrorResponse{}
+	api.Decode(t, rec.Body, &err)
+	require.EqualValues(t, common.ErrValidation, err.ErrorCode)
+}
+
+func TestSetTermDepositRolloverRequiresPayoutAccount(t *testing.T) {
+	accountService, accountRepo, _, productRepo, _ := newAccountServiceForHTTPTests()
+	termDepositService := services.NewTermDepositService(accountRepo, productRepo, newFeePostingRepositoryForHTTPTests())
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().IsDatabaseUpToDate(gomock.Any()).Return(true, nil).AnyTimes()
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithAccountService(accountService), WithTermDepositService(termDepositService))
+
+	account := newTermDepositForHTTPTests(t, accountRepo, productRepo, true)
+
+	req := httptest.NewRequest(http.MethodPost, "/ledgertrack/accounts/"+account.ID.String()+"/term/rollover", api.Buffer(t, services.SetRolloverInput{
+		RolloverOption: models.RolloverPayout,
+	}))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
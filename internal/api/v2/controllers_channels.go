@@ -13,6 +13,7 @@ import (
 	"github.com/formancehq/ledger/internal/api/common"
 	"github.com/formancehq/ledger/internal/controller/ledger"
 	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
 	"github.com/formancehq/ledger/internal/machine/vm"
 	storagecommon "github.com/formancehq/ledger/internal/storage/common"
 	"github.com/go-chi/chi/v5"
@@ -25,9 +26,9 @@ type CreateChannelRequest struct {
 }
 
 type CreditChannelRequest struct {
-	Amount    int64  `json:"amount"`
-	Currency  string `json:"currency"`
-	Reference string `json:"reference"`
+	Amount    json.Number `json:"amount"`
+	Currency  string      `json:"currency"`
+	Reference string      `json:"reference"`
 }
 
 func createChannel(sys systemcontroller.Controller) http.HandlerFunc {
@@ -98,7 +99,12 @@ func creditChannel(sys systemcontroller.Controller) http.HandlerFunc {
 			api.BadRequest(w, common.ErrValidation, fmt.Errorf("currency is required"))
 			return
 		}
-		if req.Amount <= 0 {
+		amount, err := parseAmount(req.Amount, req.Currency)
+		if err != nil {
+			writeAmountError(w, "amount", err)
+			return
+		}
+		if amount <= 0 {
 			api.BadRequest(w, common.ErrValidation, fmt.Errorf("amount must be positive"))
 			return
 		}
@@ -113,11 +119,11 @@ func creditChannel(sys systemcontroller.Controller) http.HandlerFunc {
 		accountName := fmt.Sprintf("channel:%s", channelID)
 		
 		script := fmt.Sprintf(`
-			send [%s/2 %d] (
+			send [%s %d] (
 				source = @world
 				destination = @%s
 			)
-		`, req.Currency, req.Amount, accountName)
+		`, currencyregistry.Asset(req.Currency), amount, accountName)
 
 		params := ledger.Parameters[ledger.CreateTransaction]{
 			Input: ledger.CreateTransaction{
@@ -0,0 +1,64 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+type RebaseCurrencyRequest struct {
+	// FromPrecision is the precision balances were posted with, 2 when omitted.
+	FromPrecision *int `json:"fromPrecision"`
+	// ToPrecision is the precision balances are moved to, the registered
+	// precision when omitted. Set it to rebase before changing the precision
+	// of the currency.
+	ToPrecision *int   `json:"toPrecision"`
+	Mode        string `json:"mode"`
+	DryRun      bool   `json:"dryRun"`
+}
+
+func rebaseCurrency(w http.ResponseWriter, r *http.Request) {
+	l := common.LedgerFromContext(r.Context())
+
+	req := RebaseCurrencyRequest{}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+	}
+
+	fromPrecision := currencyregistry.DefaultPrecision
+	if req.FromPrecision != nil {
+		fromPrecision = *req.FromPrecision
+	}
+
+	result, err := walletservices.RebaseCurrency(r.Context(), l, walletservices.CurrencyRebaseInput{
+		Currency:      chi.URLParam(r, "currency"),
+		FromPrecision: fromPrecision,
+		ToPrecision:   req.ToPrecision,
+		Mode:          req.Mode,
+		DryRun:        req.DryRun,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, walletservices.ErrCurrencyRebaseValidation):
+			api.BadRequest(w, common.ErrValidation, err)
+		case errors.Is(err, walletservices.ErrCurrencyRebaseIncomplete):
+			api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+		default:
+			common.HandleCommonWriteErrors(w, r, err)
+		}
+		return
+	}
+
+	api.Ok(w, result)
+}
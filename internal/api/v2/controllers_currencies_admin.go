@@ -0,0 +1,134 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+)
+
+type CreateCurrencyRequest struct {
+	Code      string  `json:"code"`
+	Precision *int    `json:"precision"`
+	Enabled   *bool   `json:"enabled,omitempty"`
+	Actor     *string `json:"actor,omitempty"`
+}
+
+type UpdateCurrencyRequest struct {
+	Precision *int    `json:"precision,omitempty"`
+	Enabled   *bool   `json:"enabled,omitempty"`
+	Actor     *string `json:"actor,omitempty"`
+}
+
+func listRegisteredCurrencies(adminService currencyregistry.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		records, err := adminService.List(r.Context())
+		if err != nil {
+			handleCurrencyAdminError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"currencies": records,
+		})
+	}
+}
+
+func createCurrency(adminService currencyregistry.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req CreateCurrencyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		if req.Precision == nil {
+			api.BadRequest(w, common.ErrValidation, errors.New("precision is required"))
+			return
+		}
+
+		record, err := adminService.Create(r.Context(), currencyregistry.CreateInput{
+			Code:      req.Code,
+			Precision: *req.Precision,
+			Enabled:   req.Enabled,
+			Actor:     req.Actor,
+		})
+		if err != nil {
+			handleCurrencyAdminError(w, r, err)
+			return
+		}
+		api.Created(w, record)
+	}
+}
+
+func readRegisteredCurrency(adminService currencyregistry.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		record, err := adminService.Get(r.Context(), chi.URLParam(r, "code"))
+		if err != nil {
+			handleCurrencyAdminError(w, r, err)
+			return
+		}
+		api.Ok(w, record)
+	}
+}
+
+func updateCurrency(adminService currencyregistry.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateCurrencyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		record, err := adminService.Update(r.Context(), chi.URLParam(r, "code"), currencyregistry.UpdateInput{
+			Precision: req.Precision,
+			Enabled:   req.Enabled,
+			Actor:     req.Actor,
+		})
+		if err != nil {
+			handleCurrencyAdminError(w, r, err)
+			return
+		}
+		api.Ok(w, record)
+	}
+}
+
+func listCurrencyAudits(adminService currencyregistry.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := currencyregistry.AuditFilter{Limit: 50}
+		if code := strings.TrimSpace(chi.URLParam(r, "code")); code != "" {
+			filter.Code = &code
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		audits, err := adminService.ListAudits(r.Context(), filter)
+		if err != nil {
+			handleCurrencyAdminError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"audits": audits,
+		})
+	}
+}
+
+func handleCurrencyAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, currencyregistry.ErrCurrencyNotFound):
+		api.NotFound(w, err)
+	case errors.Is(err, currencyregistry.ErrCurrencyValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	case errors.Is(err, currencyregistry.ErrCurrencyExists):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	default:
+		common.HandleCommonWriteErrors(w, r, err)
+	}
+}
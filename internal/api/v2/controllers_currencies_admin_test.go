@@ -0,0 +1,136 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/pointer"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+)
+
+func TestCreateCurrency(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name               string
+		payload            CreateCurrencyRequest
+		createErr          error
+		expectedStatusCode int
+		expectedErrorCode  string
+	}
+
+	testCases := []testCase{
+		{
+			name:               "nominal",
+			payload:            CreateCurrencyRequest{Code: "chf", Precision: pointer.For(2), Actor: pointer.For("ops")},
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "missing precision",
+			payload:            CreateCurrencyRequest{Code: "CHF"},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+		{
+			name:               "already registered",
+			payload:            CreateCurrencyRequest{Code: "USD", Precision: pointer.For(2)},
+			createErr:          fmt.Errorf("%w: USD", currencyregistry.ErrCurrencyExists),
+			expectedStatusCode: http.StatusConflict,
+			expectedErrorCode:  common.ErrConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			systemController, _ := newTestingSystemController(t, false)
+			adminService := &currencyAdminServiceStub{
+				createFunc: func(_ context.Context, input currencyregistry.CreateInput) (*currencyregistry.Record, error) {
+					require.Equal(t, tc.payload.Code, input.Code)
+					require.Equal(t, *tc.payload.Precision, input.Precision)
+					require.Equal(t, tc.payload.Actor, input.Actor)
+					if tc.createErr != nil {
+						return nil, tc.createErr
+					}
+					return &currencyregistry.Record{Code: "CHF", Precision: input.Precision, Enabled: true}, nil
+				},
+			}
+
+			router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithCurrencyAdminService(adminService))
+			req := httptest.NewRequest(http.MethodPost, "/_/currencies", api.Buffer(t, tc.payload))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedStatusCode, rec.Code)
+			if tc.expectedErrorCode != "" {
+				err := api.ErrorResponse{}
+				api.Decode(t, rec.Body, &err)
+				require.EqualValues(t, tc.expectedErrorCode, err.ErrorCode)
+				return
+			}
+			record, ok := api.DecodeSingleResponse[currencyregistry.Record](t, rec.Body)
+			require.True(t, ok)
+			require.Equal(t, "CHF", record.Code)
+		})
+	}
+}
+
+func TestUpdateCurrency(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, false)
+	adminService := &currencyAdminServiceStub{
+		updateFunc: func(_ context.Context, code string, input currencyregistry.UpdateInput) (*currencyregistry.Record, error) {
+			require.Equal(t, "JPY", code)
+			require.NotNil(t, input.Enabled)
+			require.False(t, *input.Enabled)
+			require.Nil(t, input.Precision)
+			return &currencyregistry.Record{Code: code, Precision: 0, Enabled: false}, nil
+		},
+	}
+
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithCurrencyAdminService(adminService))
+	req := httptest.NewRequest(http.MethodPatch, "/_/currencies/JPY", api.Buffer(t, UpdateCurrencyRequest{
+		Enabled: pointer.For(false),
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	record, ok := api.DecodeSingleResponse[currencyregistry.Record](t, rec.Body)
+	require.True(t, ok)
+	require.False(t, record.Enabled)
+}
+
+type currencyAdminServiceStub struct {
+	createFunc func(context.Context, currencyregistry.CreateInput) (*currencyregistry.Record, error)
+	updateFunc func(context.Context, string, currencyregistry.UpdateInput) (*currencyregistry.Record, error)
+}
+
+func (s *currencyAdminServiceStub) Create(ctx context.Context, input currencyregistry.CreateInput) (*currencyregistry.Record, error) {
+	return s.createFunc(ctx, input)
+}
+func (s *currencyAdminServiceStub) Update(ctx context.Context, code string, input currencyregistry.UpdateInput) (*currencyregistry.Record, error) {
+	return s.updateFunc(ctx, code, input)
+}
+func (s *currencyAdminServiceStub) Get(context.Context, string) (*currencyregistry.Record, error) {
+	return nil, currencyregistry.ErrCurrencyNotFound
+}
+func (s *currencyAdminServiceStub) List(context.Context) ([]currencyregistry.Record, error) {
+	return nil, nil
+}
+func (s *currencyAdminServiceStub) ListAudits(context.Context, currencyregistry.AuditFilter) ([]currencyregistry.AuditRecord, error) {
+	return nil, nil
+}
@@ -0,0 +1,217 @@
+package v2
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
+	"github.com/formancehq/go-libs/v3/pointer"
+	"github.com/formancehq/go-libs/v3/query"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	ledger "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/api/common"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	storagecommon "github.com/formancehq/ledger/internal/storage/common"
+	ledgerstore "github.com/formancehq/ledger/internal/storage/ledger"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+func TestRebaseCurrency(t *testing.T) {
+	t.Parallel()
+
+	volumes := &bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{
+		Data: []ledger.VolumesWithBalanceByAssetByAccount{
+			{
+				Account:            "system:control:JPY",
+				Asset:              "JPY/2",
+				VolumesWithBalance: ledger.VolumesWithBalance{Balance: big.NewInt(-12345)},
+			},
+			{
+				Account:            "users:user123:wallets:JPY:available",
+				Asset:              "JPY/2",
+				VolumesWithBalance: ledger.VolumesWithBalance{Balance: big.NewInt(12345)},
+			},
+			{
+				Account:            "users:user123:wallets:USD:available",
+				Asset:              "USD/2",
+				VolumesWithBalance: ledger.VolumesWithBalance{Balance: big.NewInt(500)},
+			},
+		},
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		t.Parallel()
+
+		systemController, ledgerController := newTestingSystemController(t, true)
+		ledgerController.EXPECT().
+			GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+			Return(volumes, nil)
+
+		router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+		req := httptest.NewRequest(http.MethodPost, "/test/currencies/JPY/rebase", api.Buffer(t, RebaseCurrencyRequest{DryRun: true}))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		result, ok := api.DecodeSingleResponse[walletservices.CurrencyRebaseResult](t, rec.Body)
+		require.True(t, ok)
+		require.Equal(t, "JPY/2", result.FromAsset)
+		require.Equal(t, "JPY/0", result.ToAsset)
+		require.Len(t, result.Entries, 2)
+		require.EqualValues(t, -123, result.Entries[0].ToAmount.Int64())
+		require.EqualValues(t, 123, result.Entries[1].ToAmount.Int64())
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		t.Parallel()
+
+		systemController, ledgerController := newTestingSystemController(t, true)
+		gomock.InOrder(
+			ledgerController.EXPECT().
+				GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+				Return(volumes, nil),
+			ledgerController.EXPECT().
+				GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+				Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{}, nil),
+		)
+		ledgerController.EXPECT().
+			CreateTransaction(gomock.Any(), gomock.Any()).
+			Times(2).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+				script := params.Input.RunScript.Script.Plain
+				require.True(t, strings.HasPrefix(params.IdempotencyKey, "currency-rebase:JPY/2:JPY/0:"))
+				require.Contains(t, script, "send [JPY/2 12345]")
+				require.Contains(t, script, "send [JPY/0 123]")
+				return &ledger.Log{}, &ledger.CreatedTransaction{
+					Transaction: ledger.NewTransaction().WithID(1),
+				}, false, nil
+			})
+
+		router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+		req := httptest.NewRequest(http.MethodPost, "/test/currencies/JPY/rebase", nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("sweeps balances changed meanwhile", func(t *testing.T) {
+		t.Parallel()
+
+		spent := &bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{
+			Data: []ledger.VolumesWithBalanceByAssetByAccount{{
+				Account:            "users:user123:wallets:JPY:available",
+				Asset:              "JPY/2",
+				VolumesWithBalance: ledger.VolumesWithBalance{Input: big.NewInt(12345), Output: big.NewInt(2345), Balance: big.NewInt(10000)},
+			}},
+		}
+
+		systemController, ledgerController := newTestingSystemController(t, true)
+		gomock.InOrder(
+			ledgerController.EXPECT().
+				GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+				Return(volumes, nil),
+			ledgerController.EXPECT().
+				GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+				Return(spent, nil),
+			ledgerController.EXPECT().
+				GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+				Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{}, nil),
+		)
+		var scripts []string
+		ledgerController.EXPECT().
+			CreateTransaction(gomock.Any(), gomock.Any()).
+			Times(3).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+				script := params.Input.RunScript.Script.Plain
+				scripts = append(scripts, script)
+				require.True(t, strings.HasPrefix(params.IdempotencyKey, "currency-rebase:JPY/2:JPY/4:"))
+				// The wallet spent part of its balance after it was read.
+				if strings.Contains(script, "send [JPY/2 12345]") && strings.Contains(script, "source = @users:user123:wallets:JPY:available") {
+					return nil, nil, false, &ledgercontroller.ErrInsufficientFunds{}
+				}
+				return &ledger.Log{}, &ledger.CreatedTransaction{
+					Transaction: ledger.NewTransaction().WithID(uint64(len(scripts))),
+				}, false, nil
+			})
+
+		router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+		req := httptest.NewRequest(http.MethodPost, "/test/currencies/JPY/rebase", api.Buffer(t, RebaseCurrencyRequest{
+			ToPrecision: pointer.For(4),
+		}))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		result, ok := api.DecodeSingleResponse[walletservices.CurrencyRebaseResult](t, rec.Body)
+		require.True(t, ok)
+		require.Equal(t, "JPY/4", result.ToAsset)
+		require.Len(t, result.Entries, 2)
+		require.EqualValues(t, 10000, result.Entries[1].FromAmount.Int64())
+		require.EqualValues(t, 1000000, result.Entries[1].ToAmount.Int64())
+	})
+
+	t.Run("already registered precision", func(t *testing.T) {
+		t.Parallel()
+
+		systemController, _ := newTestingSystemController(t, true)
+
+		router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+		req := httptest.NewRequest(http.MethodPost, "/test/currencies/USD/rebase", nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		err := api.ErrorResponse{}
+		api.Decode(t, rec.Body, &err)
+		require.EqualValues(t, common.ErrValidation, err.ErrorCode)
+	})
+}
+
+func TestCurrencyBalanceChecker(t *testing.T) {
+	t.Parallel()
+
+	systemController, ledgerController := newTestingSystemController(t, false)
+	systemController.EXPECT().
+		ListLedgers(gomock.Any(), gomock.Any()).
+		Return(&bunpaginate.Cursor[ledger.Ledger]{
+			Data: []ledger.Ledger{{Name: "a"}, {Name: "b"}},
+		}, nil)
+	gomock.InOrder(
+		ledgerController.EXPECT().
+			GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, q storagecommon.PaginatedQuery[ledgerstore.GetVolumesOptions]) (*bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount], error) {
+				// Leftovers of a rebase on the rounding account do not count.
+				require.Equal(t, query.And(
+					query.Or(query.Gt("balance[JPY/2]", 0), query.Lt("balance[JPY/2]", 0)),
+					query.Not(query.Match("address", "system:rebase:JPY")),
+				), q.(storagecommon.OffsetPaginatedQuery[ledgerstore.GetVolumesOptions]).Options.Builder)
+				return &bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{
+					Data: []ledger.VolumesWithBalanceByAssetByAccount{{
+						Account:            "users:user123:wallets:JPY:available",
+						Asset:              "JPY/2",
+						VolumesWithBalance: ledger.VolumesWithBalance{Balance: big.NewInt(12345)},
+					}},
+				}, nil
+			}),
+		ledgerController.EXPECT().
+			GetVolumesWithBalances(gomock.Any(), gomock.Any()).
+			Return(&bunpaginate.Cursor[ledger.VolumesWithBalanceByAssetByAccount]{}, nil),
+	)
+
+	ledgers, err := walletservices.NewCurrencyBalanceChecker(systemController).LedgersHolding(context.Background(), "JPY/2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, ledgers)
+}
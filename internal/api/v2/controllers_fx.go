@@ -0,0 +1,218 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	exchangerepositories "github.com/formancehq/ledger/internal/exchange/repositories"
+	exchangeservices "github.com/formancehq/ledger/internal/exchange/services"
+)
+
+type PublishFXRateRequest struct {
+	BaseCurrency  string      `json:"base_currency"`
+	QuoteCurrency string      `json:"quote_currency"`
+	Rate          json.Number `json:"rate"`
+	SpreadBps     int         `json:"spread_bps"`
+	Source        string      `json:"source"`
+	ValidFrom     *time.Time  `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time  `json:"valid_until,omitempty"`
+	Actor         *string     `json:"actor,omitempty"`
+}
+
+type ImportFXRatesRequest struct {
+	Source string                       `json:"source"`
+	Rates  []exchangeservices.RateQuote `json:"rates"`
+	Actor  *string                      `json:"actor,omitempty"`
+}
+
+type RevokeFXRateRequest struct {
+	Actor *string `json:"actor,omitempty"`
+}
+
+func listFXRates(rateService exchangeservices.RateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := exchangerepositories.RateFilter{Limit: 50}
+		if v := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("base"))); v != "" {
+			filter.BaseCurrency = &v
+		}
+		if v := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("quote"))); v != "" {
+			filter.QuoteCurrency = &v
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("effectiveAt")); v != "" {
+			at, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid effectiveAt: %w", err))
+				return
+			}
+			at = at.UTC()
+			filter.EffectiveAt = &at
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		rates, err := rateService.List(r.Context(), filter)
+		if err != nil {
+			handleFXError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"rates": rates,
+		})
+	}
+}
+
+func publishFXRate(rateService exchangeservices.RateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req PublishFXRateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		rate, err := rateService.Publish(r.Context(), exchangeservices.PublishRateInput{
+			BaseCurrency:  req.BaseCurrency,
+			QuoteCurrency: req.QuoteCurrency,
+			Rate:          req.Rate.String(),
+			SpreadBps:     req.SpreadBps,
+			Source:        req.Source,
+			ValidFrom:     req.ValidFrom,
+			ValidUntil:    req.ValidUntil,
+			Actor:         req.Actor,
+		})
+		if err != nil {
+			handleFXError(w, r, err)
+			return
+		}
+		api.Created(w, rate)
+	}
+}
+
+// importFXRates is the push entry point for rate feeds: the quotes are
+// published in order through the same path as file imports.
+func importFXRates(rateService exchangeservices.RateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req ImportFXRatesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		source := strings.TrimSpace(req.Source)
+		if source == "" {
+			source = "api"
+		}
+
+		rates, err := rateService.Import(r.Context(), exchangeservices.NewStaticRateProvider(source, req.Rates...), req.Actor)
+		if err != nil {
+			handleFXError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"rates": rates,
+		})
+	}
+}
+
+func revokeFXRate(rateService exchangeservices.RateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rateID, err := uuid.Parse(chi.URLParam(r, "rateID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		var req RevokeFXRateRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				api.BadRequest(w, common.ErrValidation, err)
+				return
+			}
+		}
+
+		rate, err := rateService.Revoke(r.Context(), rateID, req.Actor)
+		if err != nil {
+			handleFXError(w, r, err)
+			return
+		}
+		api.Ok(w, rate)
+	}
+}
+
+func listFXRateAudits(rateService exchangeservices.RateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := exchangerepositories.RateAuditFilter{Limit: 50}
+		if v := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("base"))); v != "" {
+			filter.BaseCurrency = &v
+		}
+		if v := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("quote"))); v != "" {
+			filter.QuoteCurrency = &v
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("rateID")); v != "" {
+			rateID, err := uuid.Parse(v)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, err)
+				return
+			}
+			filter.RateID = &rateID
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		audits, err := rateService.ListAudits(r.Context(), filter)
+		if err != nil {
+			handleFXError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"audits": audits,
+		})
+	}
+}
+
+func quoteFX(conversionService exchangeservices.ConversionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("from")))
+		to := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("to")))
+		amount, err := parseAmount(json.Number(r.URL.Query().Get("amount")), from)
+		if err != nil {
+			writeAmountError(w, "amount", err)
+			return
+		}
+
+		quote, err := conversionService.Quote(r.Context(), exchangeservices.QuoteInput{
+			From:   from,
+			To:     to,
+			Amount: amount,
+			Rate:   strings.TrimSpace(r.URL.Query().Get("rate")),
+		})
+		if err != nil {
+			handleFXError(w, r, err)
+			return
+		}
+		api.Ok(w, quote)
+	}
+}
+
+func handleFXError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, exchangeservices.ErrRateNotFound):
+		api.NotFound(w, err)
+	case errors.Is(err, exchangeservices.ErrRateValidation),
+		errors.Is(err, exchangeservices.ErrConversionValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	default:
+		common.HandleCommonWriteErrors(w, r, err)
+	}
+}
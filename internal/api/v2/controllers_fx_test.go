@@ -0,0 +1,82 @@
+package v2
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	exchangeservices "github.com/formancehq/ledger/internal/exchange/services"
+)
+
+func TestQuoteFX(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name               string
+		queryParams        string
+		expectedStatusCode int
+		expectedErrorCode  string
+		expectedQuote      *exchangeservices.Quote
+	}
+
+	testCases := []testCase{
+		{
+			name:               "with rate override",
+			queryParams:        "from=USD&to=JPY&amount=10.00&rate=149.555",
+			expectedStatusCode: http.StatusOK,
+			expectedQuote: &exchangeservices.Quote{
+				From:            "USD",
+				To:              "JPY",
+				Amount:          1000,
+				Rate:            "149.555",
+				CustomerRate:    "149.555",
+				ConvertedAmount: 1495,
+				MidAmount:       1495,
+				PositionAccount: "system:fx:USD_JPY",
+			},
+		},
+		{
+			name:               "without rate registry",
+			queryParams:        "from=USD&to=EUR&amount=10",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+		{
+			name:               "too precise amount",
+			queryParams:        "from=USD&to=EUR&amount=1.001&rate=0.9",
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			systemController, _ := newTestingSystemController(t, true)
+			router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+
+			req := httptest.NewRequest(http.MethodGet, "/test/fx/quote?"+tc.queryParams, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedStatusCode, rec.Code)
+			if tc.expectedQuote != nil {
+				quote, ok := api.DecodeSingleResponse[exchangeservices.Quote](t, rec.Body)
+				require.True(t, ok)
+				require.Equal(t, *tc.expectedQuote, quote)
+			}
+			if tc.expectedErrorCode != "" {
+				err := api.ErrorResponse{}
+				api.Decode(t, rec.Body, &err)
+				require.EqualValues(t, tc.expectedErrorCode, err.ErrorCode)
+			}
+		})
+	}
+}
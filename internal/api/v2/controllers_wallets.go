@@ -1,11 +1,9 @@
 package v2
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"math"
 	"math/big"
 	"net/http"
 	"os"
@@ -26,6 +24,8 @@ import (
 	"github.com/formancehq/ledger/internal/machine/vm"
 	storagecommon "github.com/formancehq/ledger/internal/storage/common"
 	ledgerstore "github.com/formancehq/ledger/internal/storage/ledger"
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
 	"github.com/go-chi/chi/v5"
 )
 
@@ -33,8 +33,9 @@ import (
 // It implements the "Wallet Wrapper" pattern defined in WalletPRD.md
 
 type CreateWalletRequest struct {
-	UserID   string `json:"userID"`
-	Currency string `json:"currency"`
+	UserID   string            `json:"userID"`
+	Currency string            `json:"currency"`
+	Labels   map[string]string `json:"labels,omitempty"`
 }
 
 type WalletTransactionRequest struct {
@@ -53,76 +54,121 @@ type ReleaseLienRequest struct {
 	ChannelAmount json.Number `json:"channelAmount"`
 }
 
-func postRevenueEntries(
-	ctx context.Context,
-	sys systemcontroller.Controller,
+// channelDebitLeg settles the channel side of a debit on channels-{CCY}. It
+// shares the reference of the wallet leg so both can be matched up.
+func channelDebitLeg(currency, channelID, reference string, channelAmount int64) walletservices.DebitSagaLegPlan {
+	return walletservices.DebitSagaLegPlan{
+		Name:      walletmodels.DebitSagaLegChannel,
+		Ledger:    fmt.Sprintf("channels-%s", currency),
+		Reference: reference,
+		Script: fmt.Sprintf(`
+				send [%s %d] (
+					source = @channel:%s allowing unbounded overdraft
+					destination = @world
+				)
+			`, currencyregistry.Asset(currency), channelAmount, channelID),
+	}
+}
+
+// revenueLegs books the user fee and the channel processing cost on
+// revenue-{CCY}, one transaction each. Zero amounts produce no leg.
+func revenueLegs(
 	currency string,
 	baseReference string,
 	userFeeAmount int64,
 	processingFeeAmount int64,
-) (string, *int64, error) {
-	if userFeeAmount <= 0 && processingFeeAmount <= 0 {
-		return "", nil, nil
-	}
-
-	revenueLedgerName := fmt.Sprintf("revenue-%s", currency)
-	rl, err := sys.GetLedgerController(ctx, revenueLedgerName)
-	if err != nil {
-		return "", nil, err
-	}
-
+) []walletservices.DebitSagaLegPlan {
 	entries := []struct {
+		name        string
 		suffix      string
 		destination string
 		amount      int64
 	}{
 		{
+			name:        walletmodels.DebitSagaLegRevenueUserFee,
 			suffix:      "user-fee",
 			destination: "revenue:accumulated",
 			amount:      userFeeAmount,
 		},
 		{
+			name:        walletmodels.DebitSagaLegRevenueProcessingFee,
 			suffix:      "processing-fee",
 			destination: "revenue:channel_processing_cost",
 			amount:      processingFeeAmount,
 		},
 	}
 
-	var revenueTxIDPtr *int64
+	legs := make([]walletservices.DebitSagaLegPlan, 0, len(entries))
 	for _, entry := range entries {
 		if entry.amount <= 0 {
 			continue
 		}
 
-		revenueScript := fmt.Sprintf(`
-			send [%s/2 %d] (
+		legs = append(legs, walletservices.DebitSagaLegPlan{
+			Name:      entry.name,
+			Ledger:    fmt.Sprintf("revenue-%s", currency),
+			Reference: fmt.Sprintf("%s-%s", baseReference, entry.suffix),
+			Script: fmt.Sprintf(`
+			send [%s %d] (
 				source = @world
 				destination = @%s
 			)
-		`, currency, entry.amount, entry.destination)
+		`, currencyregistry.Asset(currency), entry.amount, entry.destination),
+		})
+	}
 
-		rParams := ledger.Parameters[ledger.CreateTransaction]{
-			Input: ledger.CreateTransaction{
-				RunScript: vm.RunScript{
-					Script: vm.Script{
-						Plain: revenueScript,
-					},
-					Reference: fmt.Sprintf("%s-%s", baseReference, entry.suffix),
-				},
-				Runtime: ledgerinternal.RuntimeMachine,
-			},
+	return legs
+}
+
+// applyDebitSagaResult copies the downstream transaction ids of a completed
+// saga into the response metadata.
+func applyDebitSagaResult(respMetadata map[string]string, result *walletservices.DebitSagaResult) {
+	respMetadata["saga_id"] = result.Saga.ID.String()
+	for _, leg := range result.Saga.Legs {
+		if leg.TransactionID == nil {
+			continue
 		}
-		_, rTx, _, err := rl.CreateTransaction(ctx, rParams)
-		if err != nil {
-			return "", nil, err
+		switch leg.Name {
+		case walletmodels.DebitSagaLegChannel:
+			respMetadata["channel_ledger"] = leg.Ledger
+			respMetadata["channel_tx_id"] = fmt.Sprintf("%d", *leg.TransactionID)
+		case walletmodels.DebitSagaLegRevenueUserFee, walletmodels.DebitSagaLegRevenueProcessingFee:
+			respMetadata["revenue_ledger"] = leg.Ledger
+			respMetadata["revenue_tx_id"] = fmt.Sprintf("%d", *leg.TransactionID)
 		}
-		if rTx.Transaction.ID != nil {
-			x := int64(*rTx.Transaction.ID)
-			revenueTxIDPtr = &x
+	}
+}
+
+func debitSagaTransactionID(result *walletservices.DebitSagaResult, name string) *int64 {
+	for _, leg := range result.Saga.Legs {
+		if leg.Name == name {
+			return leg.TransactionID
 		}
 	}
+	return nil
+}
 
-	return revenueLedgerName, revenueTxIDPtr, nil
+func revenueTransactionID(result *walletservices.DebitSagaResult) *int64 {
+	if id := debitSagaTransactionID(result, walletmodels.DebitSagaLegRevenueProcessingFee); id != nil {
+		return id
+	}
+	return debitSagaTransactionID(result, walletmodels.DebitSagaLegRevenueUserFee)
+}
+
+func handleWalletDebitSagaError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, walletservices.ErrDebitSagaConflict),
+		errors.Is(err, walletservices.ErrDebitSagaInProgress):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	case errors.Is(err, walletservices.ErrDebitSagaValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	case errors.Is(err, walletservices.ErrDebitSagaUnreconciled):
+		common.InternalServerError(w, r, err)
+	case strings.Contains(strings.ToLower(err.Error()), "conflict") || strings.Contains(strings.ToLower(err.Error()), "duplicate reference"):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	default:
+		common.HandleCommonWriteErrors(w, r, err)
+	}
 }
 
 func listCurrencies() http.HandlerFunc {
@@ -133,7 +179,7 @@ func listCurrencies() http.HandlerFunc {
 	}
 }
 
-func createWallet(sys systemcontroller.Controller) http.HandlerFunc {
+func createWallet(walletService walletservices.WalletService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req CreateWalletRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -155,38 +201,65 @@ func createWallet(sys systemcontroller.Controller) http.HandlerFunc {
 		}
 
 		// Deterministic Wallet ID
-		walletID := fmt.Sprintf("%s-%s", req.UserID, req.Currency)
+		walletID, err := walletmodels.NewWalletID(req.UserID, req.Currency)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		if walletService == nil {
+			api.Created(w, map[string]string{
+				"walletID": walletID.String(),
+				"userID":   req.UserID,
+				"currency": req.Currency,
+			})
+			return
+		}
 
-		api.Created(w, map[string]string{
-			"walletID": walletID,
-			"userID":   req.UserID,
-			"currency": req.Currency,
+		// Registering is idempotent, which also lets wallets used before the
+		// registry existed be registered after the fact.
+		wallet, created, err := walletService.Create(r.Context(), walletservices.CreateWalletInput{
+			Ledger:   chi.URLParam(r, "ledger"),
+			UserID:   req.UserID,
+			Currency: req.Currency,
+			Labels:   req.Labels,
 		})
+		if err != nil {
+			handleWalletError(w, r, err)
+			return
+		}
+
+		response := map[string]any{
+			"walletID":  wallet.ID,
+			"userID":    wallet.UserID,
+			"currency":  wallet.Currency,
+			"status":    wallet.Status,
+			"labels":    wallet.Labels,
+			"createdAt": wallet.CreatedAt,
+		}
+		if !created {
+			api.Ok(w, response)
+			return
+		}
+		api.Created(w, response)
 	}
 }
 
 func parseAmount(amount json.Number, currency string) (int64, error) {
-	if amount == "" {
-		return 0, nil
-	}
-
-	reg, ok := currencyregistry.Lookup(currency)
-	if !ok {
-		reg = currencyregistry.Definition{Precision: 2, Enabled: true}
-	}
+	return currencyregistry.ParseAmount(amount.String(), currency)
+}
 
-	s := amount.String()
-	if strings.Contains(s, ".") {
-		f, err := amount.Float64()
-		if err != nil {
-			return 0, err
-		}
-		// Convert to atomic units
-		mult := math.Pow(10, float64(reg.Precision))
-		return int64(math.Round(f * mult)), nil
+// writeAmountError reports an amount rejected by parseAmount. The details
+// carry the machine readable reason (malformed, negative, too_precise or
+// overflow) when the parser provided one.
+func writeAmountError(w http.ResponseWriter, field string, err error) {
+	err = fmt.Errorf("invalid %s: %w", field, err)
+	var amountErr *currencyregistry.AmountError
+	if errors.As(err, &amountErr) {
+		api.BadRequestWithDetails(w, common.ErrValidation, err, amountErr.Reason)
+		return
 	}
-
-	return amount.Int64()
+	api.BadRequest(w, common.ErrValidation, err)
 }
 
 func creditWallet(sys systemcontroller.Controller) http.HandlerFunc {
@@ -194,14 +267,12 @@ func creditWallet(sys systemcontroller.Controller) http.HandlerFunc {
 		l := common.LedgerFromContext(r.Context())
 		walletID := chi.URLParam(r, "walletID")
 
-		// Parse walletID to get userID and currency
-		lastDash := strings.LastIndex(walletID, "-")
-		if lastDash == -1 {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid walletID format"))
+		walletRef, err := walletmodels.ParseWalletID(walletID)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
 			return
 		}
-		userID := walletID[:lastDash]
-		currency := walletID[lastDash+1:]
+		currency := walletRef.Currency
 
 		var req WalletTransactionRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -211,7 +282,7 @@ func creditWallet(sys systemcontroller.Controller) http.HandlerFunc {
 
 		amount, err := parseAmount(req.Amount, currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid amount: %v", err))
+			writeAmountError(w, "amount", err)
 			return
 		}
 
@@ -228,15 +299,15 @@ func creditWallet(sys systemcontroller.Controller) http.HandlerFunc {
 		// Credit: users/{user_id}/wallets/{currency}/available
 		// Debit: system/control/{currency}
 
-		accountUser := fmt.Sprintf("users:%s:wallets:%s:available", userID, currency)
+		accountUser := walletRef.AvailableAccount()
 		accountSystem := fmt.Sprintf("system:control:%s", currency)
 
 		script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @%s allowing unbounded overdraft
 			destination = @%s
 		)
-	`, currency, amount, accountSystem, accountUser)
+	`, currencyregistry.Asset(currency), amount, accountSystem, accountUser)
 
 		params := ledger.Parameters[ledger.CreateTransaction]{
 			IdempotencyKey: r.Header.Get("Idempotency-Key"),
@@ -271,7 +342,7 @@ func creditWallet(sys systemcontroller.Controller) http.HandlerFunc {
 
 		// Calculate balances
 		var balanceBefore, balanceAfter int64
-		assetName := fmt.Sprintf("%s/2", currency)
+		assetName := currencyregistry.Asset(currency)
 
 		preCommitVolumes := tx.Transaction.PostCommitVolumes.SubtractPostings(tx.Transaction.Postings)
 
@@ -299,18 +370,16 @@ func creditWallet(sys systemcontroller.Controller) http.HandlerFunc {
 	}
 }
 
-func debitWallet(sys systemcontroller.Controller, channelFeeConfigService channelservices.ChannelFeeConfigService) http.HandlerFunc {
+func debitWallet(debitSagaCoordinator walletservices.DebitSagaCoordinator, channelFeeConfigService channelservices.ChannelFeeConfigService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		l := common.LedgerFromContext(r.Context())
 		walletID := chi.URLParam(r, "walletID")
 
-		lastDash := strings.LastIndex(walletID, "-")
-		if lastDash == -1 {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid walletID format"))
+		walletRef, err := walletmodels.ParseWalletID(walletID)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
 			return
 		}
-		userID := walletID[:lastDash]
-		currency := walletID[lastDash+1:]
+		currency := walletRef.Currency
 
 		var req WalletTransactionRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -326,7 +395,7 @@ func debitWallet(sys systemcontroller.Controller, channelFeeConfigService channe
 		// Validation for Multi-Ledger Logic
 		channelAmount, err := parseAmount(req.ChannelAmount, currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid channelAmount: %v", err))
+			writeAmountError(w, "channelAmount", err)
 			return
 		}
 
@@ -339,7 +408,7 @@ func debitWallet(sys systemcontroller.Controller, channelFeeConfigService channe
 
 		amount, err := parseAmount(req.Amount, currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid amount: %v", err))
+			writeAmountError(w, "amount", err)
 			return
 		}
 
@@ -384,65 +453,84 @@ func debitWallet(sys systemcontroller.Controller, channelFeeConfigService channe
 		// Debit: users/{user_id}/wallets/{currency}/available
 		// Credit: system/control/{currency}
 
-		accountUser := fmt.Sprintf("users:%s:wallets:%s:available", userID, currency)
+		accountUser := walletRef.AvailableAccount()
 		accountSystem := fmt.Sprintf("system:control:%s", currency)
 
 		script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @%s
 			destination = @%s
 		)
-	`, currency, amount, accountUser, accountSystem)
+	`, currencyregistry.Asset(currency), amount, accountUser, accountSystem)
 
-		params := ledger.Parameters[ledger.CreateTransaction]{
-			IdempotencyKey: r.Header.Get("Idempotency-Key"),
-			Input: ledger.CreateTransaction{
-				RunScript: vm.RunScript{
-					Script: vm.Script{
-						Plain: script,
-					},
-					Reference: req.Reference,
-				},
-				Runtime: ledgerinternal.RuntimeMachine,
-			},
-		}
-
-		params.Input.RunScript.Metadata = metadata.Metadata{}
+		runMetadata := map[string]string{}
 		for k, v := range req.Metadata {
-			params.Input.RunScript.Metadata[k] = v
+			runMetadata[k] = v
 		}
 		if req.ChannelID != "" {
-			params.Input.RunScript.Metadata["channel_id"] = req.ChannelID
-			params.Input.RunScript.Metadata["channel_amount"] = fmt.Sprintf("%d", channelAmount)
+			runMetadata["channel_id"] = req.ChannelID
+			runMetadata["channel_amount"] = fmt.Sprintf("%d", channelAmount)
 			if computedFees != nil {
-				params.Input.RunScript.Metadata["channel_user_fee_amount"] = fmt.Sprintf("%d", computedFees.UserFeeAmount)
-				params.Input.RunScript.Metadata["channel_processing_fee_amount"] = fmt.Sprintf("%d", computedFees.ProcessingFee)
-				params.Input.RunScript.Metadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", computedFees.NetRevenueAmount)
+				runMetadata["channel_user_fee_amount"] = fmt.Sprintf("%d", computedFees.UserFeeAmount)
+				runMetadata["channel_processing_fee_amount"] = fmt.Sprintf("%d", computedFees.ProcessingFee)
+				runMetadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", computedFees.NetRevenueAmount)
 			}
 		}
 
+		plan := walletservices.DebitSagaPlan{
+			WalletID:  walletID,
+			Currency:  currency,
+			Operation: walletmodels.DebitSagaOperationDebit,
+			Reference: req.Reference,
+			Legs: []walletservices.DebitSagaLegPlan{{
+				Name:           walletmodels.DebitSagaLegWallet,
+				Ledger:         chi.URLParam(r, "ledger"),
+				Reference:      req.Reference,
+				IdempotencyKey: r.Header.Get("Idempotency-Key"),
+				Script:         script,
+				Metadata:       runMetadata,
+			}},
+		}
+
 		// Store multi-ledger transaction links
 		respMetadata := map[string]string{}
 
-		_, tx, _, err := l.CreateTransaction(r.Context(), params)
+		// 2. Channel & Revenue legs, posted by the saga after the wallet leg
+		var userFeeAmount, processingFeeAmount, netRevenueAmount int64
+		if req.ChannelID != "" {
+			userFeeAmount = amount - channelAmount
+			netRevenueAmount = userFeeAmount
+			if computedFees != nil {
+				userFeeAmount = computedFees.UserFeeAmount
+				processingFeeAmount = computedFees.ProcessingFee
+				netRevenueAmount = computedFees.NetRevenueAmount
+			}
+
+			respMetadata["channel_user_fee_amount"] = fmt.Sprintf("%d", userFeeAmount)
+			respMetadata["channel_processing_fee_amount"] = fmt.Sprintf("%d", processingFeeAmount)
+			respMetadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", netRevenueAmount)
+
+			plan.Legs = append(plan.Legs, channelDebitLeg(currency, req.ChannelID, req.Reference, channelAmount))
+			plan.Legs = append(plan.Legs, revenueLegs(currency, req.Reference, userFeeAmount, processingFeeAmount)...)
+		}
+
+		result, err := debitSagaCoordinator.Run(r.Context(), plan)
 		if err != nil {
 			if strings.Contains(strings.ToLower(err.Error()), "insufficient fund") {
 				api.WriteErrorResponse(w, http.StatusPaymentRequired, common.ErrInsufficientFund, err)
 				return
 			}
-			if strings.Contains(strings.ToLower(err.Error()), "conflict") || strings.Contains(strings.ToLower(err.Error()), "duplicate reference") {
-				api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
-				return
-			}
-			common.HandleCommonWriteErrors(w, r, err)
+			handleWalletDebitSagaError(w, r, err)
 			return
 		}
+		tx := result.Transactions[walletmodels.DebitSagaLegWallet]
+		applyDebitSagaResult(respMetadata, result)
 
 		// Calculate balances
 		var balanceBefore, balanceAfter int64
-		assetName := fmt.Sprintf("%s/2", currency)
+		assetName := currencyregistry.Asset(currency)
 
-		preCommitVolumes := tx.Transaction.PostCommitVolumes.SubtractPostings(tx.Transaction.Postings)
+		preCommitVolumes := tx.PostCommitVolumes.SubtractPostings(tx.Postings)
 
 		if vol, ok := preCommitVolumes[accountUser]; ok {
 			if v, ok := vol[assetName]; ok {
@@ -450,55 +538,20 @@ func debitWallet(sys systemcontroller.Controller, channelFeeConfigService channe
 				balanceBefore = bal.Int64()
 			}
 		}
-		if vol, ok := tx.Transaction.PostCommitVolumes[accountUser]; ok {
+		if vol, ok := tx.PostCommitVolumes[accountUser]; ok {
 			if v, ok := vol[assetName]; ok {
 				bal := new(big.Int).Sub(v.Input, v.Output)
 				balanceAfter = bal.Int64()
 			}
 		}
 
-		// 2. Handle Multi-Ledger (Channel & Revenue) if applicable
 		var warningMsg string
 		if req.ChannelID != "" {
-			// Process Channel Debit
-			channelLedgerName := fmt.Sprintf("channels-%s", currency)
-			cl, err := sys.GetLedgerController(r.Context(), channelLedgerName)
-			if err != nil {
-				common.HandleCommonWriteErrors(w, r, err)
-				return
-			}
-
-			// Debit Channel: Channel -> World
+			// Check Overdraft using PostCommitVolumes from the channel transaction
 			channelAccount := fmt.Sprintf("channel:%s", req.ChannelID)
-			channelScript := fmt.Sprintf(`
-				send [%s/2 %d] (
-					source = @%s allowing unbounded overdraft
-					destination = @world
-				)
-			`, currency, channelAmount, channelAccount)
-
-			cParams := ledger.Parameters[ledger.CreateTransaction]{
-				Input: ledger.CreateTransaction{
-					RunScript: vm.RunScript{
-						Script: vm.Script{
-							Plain: channelScript,
-						},
-						Reference: req.Reference, // Same reference
-					},
-					Runtime: ledgerinternal.RuntimeMachine,
-				},
-			}
-			_, cTx, _, err := cl.CreateTransaction(r.Context(), cParams)
-			if err != nil {
-				common.HandleCommonWriteErrors(w, r, err)
-				return
-			}
-			respMetadata["channel_ledger"] = channelLedgerName
-			respMetadata["channel_tx_id"] = fmt.Sprintf("%d", cTx.Transaction.ID)
-
-			// Check Overdraft using PostCommitVolumes from the transaction result
-			if volumes, ok := cTx.Transaction.PostCommitVolumes[channelAccount]; ok {
-				asset := fmt.Sprintf("%s/2", currency)
+			cTx := result.Transactions[walletmodels.DebitSagaLegChannel]
+			if volumes, ok := cTx.PostCommitVolumes[channelAccount]; ok {
+				asset := currencyregistry.Asset(currency)
 				if vol, ok := volumes[asset]; ok {
 					// ALWAYS return balance for debug
 					warningMsg = fmt.Sprintf("Channel balance: %s %s", vol.Balance().String(), currency)
@@ -511,87 +564,45 @@ func debitWallet(sys systemcontroller.Controller, channelFeeConfigService channe
 				}
 			} else {
 				// DEBUG
-				warningMsg = fmt.Sprintf("DEBUG: Account %s not found. Keys: %v", channelAccount, cTx.Transaction.PostCommitVolumes)
-			}
-
-			userFeeAmount := amount - channelAmount
-			processingFeeAmount := int64(0)
-			netRevenueAmount := userFeeAmount
-			if computedFees != nil {
-				userFeeAmount = computedFees.UserFeeAmount
-				processingFeeAmount = computedFees.ProcessingFee
-				netRevenueAmount = computedFees.NetRevenueAmount
+				warningMsg = fmt.Sprintf("DEBUG: Account %s not found. Keys: %v", channelAccount, cTx.PostCommitVolumes)
 			}
 
-			respMetadata["channel_user_fee_amount"] = fmt.Sprintf("%d", userFeeAmount)
-			respMetadata["channel_processing_fee_amount"] = fmt.Sprintf("%d", processingFeeAmount)
-			respMetadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", netRevenueAmount)
-
-			// Process Revenue Credit
-			if userFeeAmount > 0 || processingFeeAmount > 0 {
-				revenueLedgerName, revenueTxIDPtr, err := postRevenueEntries(
-					r.Context(),
-					sys,
-					currency,
-					req.Reference,
-					userFeeAmount,
-					processingFeeAmount,
-				)
-				if err != nil {
-					common.HandleCommonWriteErrors(w, r, err)
-					return
-				}
-				respMetadata["revenue_ledger"] = revenueLedgerName
-				if revenueTxIDPtr != nil {
-					respMetadata["revenue_tx_id"] = fmt.Sprintf("%d", *revenueTxIDPtr)
-				}
-
-				if channelFeeConfigService != nil {
-					walletIDCopy := walletID
-					var ledgerTxIDPtr *int64
-					if tx.Transaction.ID != nil {
-						x := int64(*tx.Transaction.ID)
-						ledgerTxIDPtr = &x
-					}
-					var channelTxIDPtr *int64
-					if cTx.Transaction.ID != nil {
-						x := int64(*cTx.Transaction.ID)
-						channelTxIDPtr = &x
-					}
-					if err := channelFeeConfigService.Record(r.Context(), &channelmodels.ChannelFeeRecord{
-						ChannelID:           req.ChannelID,
-						Currency:            currency,
-						WalletID:            &walletIDCopy,
-						Reference:           req.Reference,
-						LedgerTxID:          ledgerTxIDPtr,
-						ChannelTxID:         channelTxIDPtr,
-						RevenueTxID:         revenueTxIDPtr,
-						OccurredAt:          time.Now().UTC(),
-						TotalAmount:         amount,
-						PrincipalAmount:     channelAmount,
-						UserFeeAmount:       userFeeAmount,
-						ProcessingFeeAmount: processingFeeAmount,
-						NetRevenueAmount:    netRevenueAmount,
-						Metadata: map[string]any{
-							"wallet_id": walletID,
-						},
-					}); err != nil {
-						warningMsg = fmt.Sprintf("fee record write failed: %s", err.Error())
-					}
+			if (userFeeAmount > 0 || processingFeeAmount > 0) && channelFeeConfigService != nil {
+				walletIDCopy := walletID
+				if err := channelFeeConfigService.Record(r.Context(), &channelmodels.ChannelFeeRecord{
+					ChannelID:           req.ChannelID,
+					Currency:            currency,
+					WalletID:            &walletIDCopy,
+					Reference:           req.Reference,
+					LedgerTxID:          debitSagaTransactionID(result, walletmodels.DebitSagaLegWallet),
+					ChannelTxID:         debitSagaTransactionID(result, walletmodels.DebitSagaLegChannel),
+					RevenueTxID:         revenueTransactionID(result),
+					OccurredAt:          time.Now().UTC(),
+					TotalAmount:         amount,
+					PrincipalAmount:     channelAmount,
+					UserFeeAmount:       userFeeAmount,
+					ProcessingFeeAmount: processingFeeAmount,
+					NetRevenueAmount:    netRevenueAmount,
+					Metadata: map[string]any{
+						"wallet_id": walletID,
+						"saga_id":   result.Saga.ID.String(),
+					},
+				}); err != nil {
+					warningMsg = fmt.Sprintf("fee record write failed: %s", err.Error())
 				}
 			}
 		}
 
 		// Update Metadata in Response
 		// Merge original metadata
-		for k, v := range tx.Transaction.Metadata {
+		for k, v := range tx.Metadata {
 			respMetadata[k] = v
 		}
 
 		response := map[string]interface{}{
-			"txid":           tx.Transaction.ID,
-			"timestamp":      tx.Transaction.Timestamp,
-			"postings":       tx.Transaction.Postings,
+			"txid":           tx.ID,
+			"timestamp":      tx.Timestamp,
+			"postings":       tx.Postings,
 			"metadata":       respMetadata,
 			"balance_before": balanceBefore,
 			"balance_after":  balanceAfter,
@@ -610,13 +621,12 @@ func lienWallet(sys systemcontroller.Controller) http.HandlerFunc {
 		l := common.LedgerFromContext(r.Context())
 		walletID := chi.URLParam(r, "walletID")
 
-		lastDash := strings.LastIndex(walletID, "-")
-		if lastDash == -1 {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid walletID format"))
+		walletRef, err := walletmodels.ParseWalletID(walletID)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
 			return
 		}
-		userID := walletID[:lastDash]
-		currency := walletID[lastDash+1:]
+		currency := walletRef.Currency
 
 		var req WalletTransactionRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -626,7 +636,7 @@ func lienWallet(sys systemcontroller.Controller) http.HandlerFunc {
 
 		amount, err := parseAmount(req.Amount, currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid amount: %v", err))
+			writeAmountError(w, "amount", err)
 			return
 		}
 
@@ -643,15 +653,15 @@ func lienWallet(sys systemcontroller.Controller) http.HandlerFunc {
 		// Debit: users/{user_id}/wallets/{currency}/available
 		// Credit: users/{user_id}/wallets/{currency}/lien
 
-		accountAvailable := fmt.Sprintf("users:%s:wallets:%s:available", userID, currency)
-		accountLien := fmt.Sprintf("users:%s:wallets:%s:lien", userID, currency)
+		accountAvailable := walletRef.AvailableAccount()
+		accountLien := walletRef.PooledLienAccount()
 
 		script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @%s
 			destination = @%s
 		)
-	`, currency, amount, accountAvailable, accountLien)
+	`, currencyregistry.Asset(currency), amount, accountAvailable, accountLien)
 
 		params := ledger.Parameters[ledger.CreateTransaction]{
 			IdempotencyKey: r.Header.Get("Idempotency-Key"),
@@ -680,7 +690,7 @@ func lienWallet(sys systemcontroller.Controller) http.HandlerFunc {
 
 		// Calculate balances
 		var balanceBefore, balanceAfter int64
-		assetName := fmt.Sprintf("%s/2", currency)
+		assetName := currencyregistry.Asset(currency)
 
 		preCommitVolumes := tx.Transaction.PostCommitVolumes.SubtractPostings(tx.Transaction.Postings)
 
@@ -708,18 +718,16 @@ func lienWallet(sys systemcontroller.Controller) http.HandlerFunc {
 	}
 }
 
-func releaseLien(sys systemcontroller.Controller, channelFeeConfigService channelservices.ChannelFeeConfigService) http.HandlerFunc {
+func releaseLien(debitSagaCoordinator walletservices.DebitSagaCoordinator, channelFeeConfigService channelservices.ChannelFeeConfigService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		l := common.LedgerFromContext(r.Context())
 		walletID := chi.URLParam(r, "walletID")
 
-		lastDash := strings.LastIndex(walletID, "-")
-		if lastDash == -1 {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid walletID format"))
+		walletRef, err := walletmodels.ParseWalletID(walletID)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
 			return
 		}
-		userID := walletID[:lastDash]
-		currency := walletID[lastDash+1:]
+		currency := walletRef.Currency
 
 		var req ReleaseLienRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -734,13 +742,13 @@ func releaseLien(sys systemcontroller.Controller, channelFeeConfigService channe
 
 		amount, err := parseAmount(req.Amount, currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid amount: %v", err))
+			writeAmountError(w, "amount", err)
 			return
 		}
 
 		channelAmount, err := parseAmount(req.ChannelAmount, currency)
 		if err != nil {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid channelAmount: %v", err))
+			writeAmountError(w, "channelAmount", err)
 			return
 		}
 
@@ -807,78 +815,90 @@ func releaseLien(sys systemcontroller.Controller, channelFeeConfigService channe
 		}
 
 		// Lien Release Logic
-		accountLien := fmt.Sprintf("users:%s:wallets:%s:lien", userID, currency)
+		accountLien := walletRef.PooledLienAccount()
+		accountAvailable := walletRef.AvailableAccount()
 
 		var script string
 		if mode == "PAY" {
 			// Pay: Lien -> World (Spend)
 			script = fmt.Sprintf(`
-				send [%s/2 %d] (
+				send [%s %d] (
 					source = @%s
 					destination = @world
 				)
-			`, currency, amount, accountLien)
+			`, currencyregistry.Asset(currency), amount, accountLien)
 		} else {
 			// Release/Cancel: Lien -> Available
-			accountAvailable := fmt.Sprintf("users:%s:wallets:%s:available", userID, currency)
 			script = fmt.Sprintf(`
-				send [%s/2 %d] (
+				send [%s %d] (
 					source = @%s
 					destination = @%s
 				)
-			`, currency, amount, accountLien, accountAvailable)
+			`, currencyregistry.Asset(currency), amount, accountLien, accountAvailable)
 		}
 
-		runMetadata := metadata.Metadata{}
+		runMetadata := map[string]string{}
+		var userFeeAmount, processingFeeAmount, netRevenueAmount int64
 		if req.ChannelID != "" && mode == "PAY" {
-			runMetadata["channel_id"] = req.ChannelID
-			runMetadata["channel_amount"] = fmt.Sprintf("%d", channelAmount)
+			userFeeAmount = amount - channelAmount
+			netRevenueAmount = userFeeAmount
 			if computedFees != nil {
-				runMetadata["channel_user_fee_amount"] = fmt.Sprintf("%d", computedFees.UserFeeAmount)
-				runMetadata["channel_processing_fee_amount"] = fmt.Sprintf("%d", computedFees.ProcessingFee)
-				runMetadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", computedFees.NetRevenueAmount)
-			} else {
-				userFeeAmount := amount - channelAmount
-				if userFeeAmount < 0 {
-					userFeeAmount = 0
-				}
-				runMetadata["channel_user_fee_amount"] = fmt.Sprintf("%d", userFeeAmount)
-				runMetadata["channel_processing_fee_amount"] = "0"
-				runMetadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", userFeeAmount)
+				userFeeAmount = computedFees.UserFeeAmount
+				processingFeeAmount = computedFees.ProcessingFee
+				netRevenueAmount = computedFees.NetRevenueAmount
+			} else if userFeeAmount < 0 {
+				userFeeAmount = 0
+				netRevenueAmount = 0
 			}
-		}
 
-		params := ledger.Parameters[ledger.CreateTransaction]{
-			IdempotencyKey: r.Header.Get("Idempotency-Key"),
-			Input: ledger.CreateTransaction{
-				RunScript: vm.RunScript{
-					Script: vm.Script{
-						Plain: script,
-					},
-					Reference: req.Reference,
-					Metadata:  runMetadata,
-				},
-				Runtime: ledgerinternal.RuntimeMachine,
+			runMetadata["channel_id"] = req.ChannelID
+			runMetadata["channel_amount"] = fmt.Sprintf("%d", channelAmount)
+			runMetadata["channel_user_fee_amount"] = fmt.Sprintf("%d", userFeeAmount)
+			runMetadata["channel_processing_fee_amount"] = fmt.Sprintf("%d", processingFeeAmount)
+			runMetadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", netRevenueAmount)
+		}
+
+		plan := walletservices.DebitSagaPlan{
+			WalletID:  walletID,
+			Currency:  currency,
+			Operation: walletmodels.DebitSagaOperationLienRelease,
+			Reference: req.Reference,
+			Metadata: map[string]any{
+				"mode": mode,
 			},
+			Legs: []walletservices.DebitSagaLegPlan{{
+				Name:           walletmodels.DebitSagaLegWallet,
+				Ledger:         chi.URLParam(r, "ledger"),
+				Reference:      req.Reference,
+				IdempotencyKey: r.Header.Get("Idempotency-Key"),
+				Script:         script,
+				Metadata:       runMetadata,
+			}},
 		}
 
 		respMetadata := map[string]string{}
+		if req.ChannelID != "" && mode == "PAY" {
+			respMetadata["channel_user_fee_amount"] = fmt.Sprintf("%d", userFeeAmount)
+			respMetadata["channel_processing_fee_amount"] = fmt.Sprintf("%d", processingFeeAmount)
+			respMetadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", netRevenueAmount)
 
-		_, tx, _, err := l.CreateTransaction(r.Context(), params)
+			plan.Legs = append(plan.Legs, channelDebitLeg(currency, req.ChannelID, req.Reference, channelAmount))
+			plan.Legs = append(plan.Legs, revenueLegs(currency, req.Reference, userFeeAmount, processingFeeAmount)...)
+		}
+
+		result, err := debitSagaCoordinator.Run(r.Context(), plan)
 		if err != nil {
-			if strings.Contains(strings.ToLower(err.Error()), "conflict") || strings.Contains(strings.ToLower(err.Error()), "duplicate reference") {
-				api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
-				return
-			}
-			common.HandleCommonWriteErrors(w, r, err)
+			handleWalletDebitSagaError(w, r, err)
 			return
 		}
+		tx := result.Transactions[walletmodels.DebitSagaLegWallet]
+		applyDebitSagaResult(respMetadata, result)
 
 		// Calculate balances
 		var balanceBefore, balanceAfter int64
-		assetName := fmt.Sprintf("%s/2", currency)
+		assetName := currencyregistry.Asset(currency)
 
-		preCommitVolumes := tx.Transaction.PostCommitVolumes.SubtractPostings(tx.Transaction.Postings)
+		preCommitVolumes := tx.PostCommitVolumes.SubtractPostings(tx.Postings)
 
 		// For release, the relevant account depends on Mode.
 		// If PAY, we might care about Lien balance?
@@ -886,15 +906,13 @@ func releaseLien(sys systemcontroller.Controller, channelFeeConfigService channe
 		// User likely wants "Available Balance" of the wallet.
 		// So we always track 'accountAvailable'.
 
-		accountAvailable := fmt.Sprintf("users:%s:wallets:%s:available", userID, currency)
-
 		if vol, ok := preCommitVolumes[accountAvailable]; ok {
 			if v, ok := vol[assetName]; ok {
 				bal := new(big.Int).Sub(v.Input, v.Output)
 				balanceBefore = bal.Int64()
 			}
 		}
-		if vol, ok := tx.Transaction.PostCommitVolumes[accountAvailable]; ok {
+		if vol, ok := tx.PostCommitVolumes[accountAvailable]; ok {
 			if v, ok := vol[assetName]; ok {
 				bal := new(big.Int).Sub(v.Input, v.Output)
 				balanceAfter = bal.Int64()
@@ -902,121 +920,41 @@ func releaseLien(sys systemcontroller.Controller, channelFeeConfigService channe
 		}
 
 		var warningMsg string
-		if req.ChannelID != "" && mode == "PAY" {
-			channelLedgerName := fmt.Sprintf("channels-%s", currency)
-			cl, err := sys.GetLedgerController(r.Context(), channelLedgerName)
-			if err != nil {
-				common.HandleCommonWriteErrors(w, r, err)
-				return
-			}
-
-			channelAccount := fmt.Sprintf("channel:%s", req.ChannelID)
-			channelScript := fmt.Sprintf(`
-				send [%s/2 %d] (
-					source = @%s allowing unbounded overdraft
-					destination = @world
-				)
-			`, currency, channelAmount, channelAccount)
-
-			cParams := ledger.Parameters[ledger.CreateTransaction]{
-				Input: ledger.CreateTransaction{
-					RunScript: vm.RunScript{
-						Script: vm.Script{
-							Plain: channelScript,
-						},
-						Reference: req.Reference,
-					},
-					Runtime: ledgerinternal.RuntimeMachine,
+		if req.ChannelID != "" && mode == "PAY" && (userFeeAmount > 0 || processingFeeAmount > 0) && channelFeeConfigService != nil {
+			walletIDCopy := walletID
+			if err := channelFeeConfigService.Record(r.Context(), &channelmodels.ChannelFeeRecord{
+				ChannelID:           req.ChannelID,
+				Currency:            currency,
+				WalletID:            &walletIDCopy,
+				Reference:           req.Reference,
+				LedgerTxID:          debitSagaTransactionID(result, walletmodels.DebitSagaLegWallet),
+				ChannelTxID:         debitSagaTransactionID(result, walletmodels.DebitSagaLegChannel),
+				RevenueTxID:         revenueTransactionID(result),
+				OccurredAt:          time.Now().UTC(),
+				TotalAmount:         amount,
+				PrincipalAmount:     channelAmount,
+				UserFeeAmount:       userFeeAmount,
+				ProcessingFeeAmount: processingFeeAmount,
+				NetRevenueAmount:    netRevenueAmount,
+				Metadata: map[string]any{
+					"wallet_id": walletID,
+					"mode":      mode,
+					"saga_id":   result.Saga.ID.String(),
 				},
-			}
-			_, cTx, _, err := cl.CreateTransaction(r.Context(), cParams)
-			if err != nil {
-				common.HandleCommonWriteErrors(w, r, err)
-				return
-			}
-			respMetadata["channel_ledger"] = channelLedgerName
-			respMetadata["channel_tx_id"] = fmt.Sprintf("%d", cTx.Transaction.ID)
-
-			userFeeAmount := amount - channelAmount
-			processingFeeAmount := int64(0)
-			netRevenueAmount := userFeeAmount
-			if computedFees != nil {
-				userFeeAmount = computedFees.UserFeeAmount
-				processingFeeAmount = computedFees.ProcessingFee
-				netRevenueAmount = computedFees.NetRevenueAmount
-			} else if userFeeAmount < 0 {
-				userFeeAmount = 0
-				netRevenueAmount = 0
-			}
-
-			respMetadata["channel_user_fee_amount"] = fmt.Sprintf("%d", userFeeAmount)
-			respMetadata["channel_processing_fee_amount"] = fmt.Sprintf("%d", processingFeeAmount)
-			respMetadata["channel_net_revenue_amount"] = fmt.Sprintf("%d", netRevenueAmount)
-
-			if userFeeAmount > 0 || processingFeeAmount > 0 {
-				revenueLedgerName, revenueTxIDPtr, err := postRevenueEntries(
-					r.Context(),
-					sys,
-					currency,
-					req.Reference,
-					userFeeAmount,
-					processingFeeAmount,
-				)
-				if err != nil {
-					common.HandleCommonWriteErrors(w, r, err)
-					return
-				}
-				respMetadata["revenue_ledger"] = revenueLedgerName
-				if revenueTxIDPtr != nil {
-					respMetadata["revenue_tx_id"] = fmt.Sprintf("%d", *revenueTxIDPtr)
-				}
-
-				if channelFeeConfigService != nil {
-					walletIDCopy := walletID
-					var ledgerTxIDPtr *int64
-					if tx.Transaction.ID != nil {
-						x := int64(*tx.Transaction.ID)
-						ledgerTxIDPtr = &x
-					}
-					var channelTxIDPtr *int64
-					if cTx.Transaction.ID != nil {
-						x := int64(*cTx.Transaction.ID)
-						channelTxIDPtr = &x
-					}
-					if err := channelFeeConfigService.Record(r.Context(), &channelmodels.ChannelFeeRecord{
-						ChannelID:           req.ChannelID,
-						Currency:            currency,
-						WalletID:            &walletIDCopy,
-						Reference:           req.Reference,
-						LedgerTxID:          ledgerTxIDPtr,
-						ChannelTxID:         channelTxIDPtr,
-						RevenueTxID:         revenueTxIDPtr,
-						OccurredAt:          time.Now().UTC(),
-						TotalAmount:         amount,
-						PrincipalAmount:     channelAmount,
-						UserFeeAmount:       userFeeAmount,
-						ProcessingFeeAmount: processingFeeAmount,
-						NetRevenueAmount:    netRevenueAmount,
-						Metadata: map[string]any{
-							"wallet_id": walletID,
-							"mode":      mode,
-						},
-					}); err != nil {
-						warningMsg = fmt.Sprintf("fee record write failed: %s", err.Error())
-					}
-				}
+			}); err != nil {
+				warningMsg = fmt.Sprintf("fee record write failed: %s", err.Error())
 			}
 		}
 
 		response := map[string]interface{}{
-			"txid":           tx.Transaction.ID,
-			"timestamp":      tx.Transaction.Timestamp,
-			"postings":       tx.Transaction.Postings,
+			"txid":           tx.ID,
+			"timestamp":      tx.Timestamp,
+			"postings":       tx.Postings,
 			"metadata":       respMetadata,
 			"balance_before": balanceBefore,
 			"balance_after":  balanceAfter,
 		}
-		for k, v := range tx.Transaction.Metadata {
+		for k, v := range tx.Metadata {
 			respMetadata[k] = v
 		}
 
@@ -1033,23 +971,24 @@ func getWalletHistory(sys systemcontroller.Controller) http.HandlerFunc {
 		l := common.LedgerFromContext(r.Context())
 		walletID := chi.URLParam(r, "walletID")
 
-		lastDash := strings.LastIndex(walletID, "-")
-		if lastDash == -1 {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid walletID format"))
+		walletRef, err := walletmodels.ParseWalletID(walletID)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
 			return
 		}
-		userID := walletID[:lastDash]
-		currency := walletID[lastDash+1:]
 
 		// Define accounts associated with the wallet
-		accountAvailable := fmt.Sprintf("users:%s:wallets:%s:available", userID, currency)
-		accountLien := fmt.Sprintf("users:%s:wallets:%s:lien", userID, currency)
+		accountAvailable := walletRef.AvailableAccount()
+		accountLien := walletRef.PooledLienAccount()
+		// Trailing empty segment: matches every tracked lien sub-account
+		accountLiens := walletRef.LienAccountPrefix()
 
 		// Build Query
-		// Filter by accounts: account = available OR account = lien
+		// Filter by accounts: account = available OR account = lien OR account = liens:*
 		var qb query.Builder = query.Or(
 			query.Match("account", accountAvailable),
 			query.Match("account", accountLien),
+			query.Match("account", accountLiens),
 		)
 
 		// Add optional filters
@@ -1105,6 +1044,10 @@ func getWalletBalances(sys systemcontroller.Controller) http.HandlerFunc {
 			api.BadRequest(w, common.ErrValidation, fmt.Errorf("userID is required"))
 			return
 		}
+		if err := walletmodels.ValidateUserID(userID); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
 
 		// Pattern to match wallet accounts
 		// Default: metadata[user_id]=userID AND metadata[type]=wallet (all currencies)
@@ -1114,11 +1057,11 @@ func getWalletBalances(sys systemcontroller.Controller) http.HandlerFunc {
 		interestedAccounts := []string{}
 
 		if currency != "" {
-			interestedAccounts = append(interestedAccounts, fmt.Sprintf("users:%s:wallets:%s:available", userID, currency))
+			interestedAccounts = append(interestedAccounts, walletmodels.WalletID{UserID: userID, Currency: currency}.AvailableAccount())
 		} else {
 			// Iterate over all supported currencies
 			for _, c := range currencyregistry.EnabledCodes() {
-				interestedAccounts = append(interestedAccounts, fmt.Sprintf("users:%s:wallets:%s:available", userID, c))
+				interestedAccounts = append(interestedAccounts, walletmodels.WalletID{UserID: userID, Currency: c}.AvailableAccount())
 			}
 		}
 
@@ -1149,6 +1092,11 @@ func getWalletBalances(sys systemcontroller.Controller) http.HandlerFunc {
 			for asset, amount := range balancesMap {
 				// Strip precision suffix (e.g. USD/2 -> USD)
 				assetName, _, _ := strings.Cut(asset, "/")
+				if asset != currencyregistry.Asset(assetName) {
+					// Balances left in another precision are not in the
+					// currency's unit until they are rebased.
+					continue
+				}
 
 				var found bool
 				for i := range balances {
@@ -1179,16 +1127,14 @@ func getWalletStatement(sys systemcontroller.Controller) http.HandlerFunc {
 		l := common.LedgerFromContext(r.Context())
 		walletID := chi.URLParam(r, "walletID")
 
-		lastDash := strings.LastIndex(walletID, "-")
-		if lastDash == -1 {
-			api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid walletID format"))
+		walletRef, err := walletmodels.ParseWalletID(walletID)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
 			return
 		}
-		userID := walletID[:lastDash]
-		currency := walletID[lastDash+1:]
 
-		accountAvailable := fmt.Sprintf("users:%s:wallets:%s:available", userID, currency)
-		accountLien := fmt.Sprintf("users:%s:wallets:%s:lien", userID, currency)
+		accountAvailable := walletRef.AvailableAccount()
+		accountLien := walletRef.PooledLienAccount()
 
 		var qb query.Builder = query.Or(
 			query.Match("account", accountAvailable),
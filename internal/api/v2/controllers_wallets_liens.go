@@ -0,0 +1,265 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+	walletrepositories "github.com/formancehq/ledger/internal/wallets/repositories"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+type PlaceWalletLienRequest struct {
+	Amount    json.Number       `json:"amount"`
+	Reference string            `json:"reference"`
+	ExpiresAt *time.Time        `json:"expiresAt"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+type WalletLienMovementRequest struct {
+	Amount           json.Number       `json:"amount"`
+	Reference        string            `json:"reference"`
+	ExpiresAt        *time.Time        `json:"expiresAt"`
+	ReleaseRemainder bool              `json:"releaseRemainder"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+type WalletLienResponse struct {
+	*walletmodels.Lien
+	TransactionID *uint64 `json:"transaction_id,omitempty"`
+}
+
+func placeWalletLien(lienService walletservices.LienService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		walletID := chi.URLParam(r, "walletID")
+		walletRef, err := walletmodels.ParseWalletID(walletID)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		var req PlaceWalletLienRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		amount, err := parseAmount(req.Amount, walletRef.Currency)
+		if err != nil {
+			writeAmountError(w, "amount", err)
+			return
+		}
+
+		result, err := lienService.Place(r.Context(), walletservices.PlaceLienInput{
+			Ledger:         chi.URLParam(r, "ledger"),
+			WalletID:       walletID,
+			Amount:         amount,
+			Reference:      req.Reference,
+			IdempotencyKey: r.Header.Get("Idempotency-Key"),
+			ExpiresAt:      req.ExpiresAt,
+			Metadata:       req.Metadata,
+		})
+		if err != nil {
+			handleWalletLienError(w, r, err)
+			return
+		}
+		api.Created(w, newWalletLienResponse(result))
+	}
+}
+
+func listWalletLiens(lienService walletservices.LienService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ledgerName := chi.URLParam(r, "ledger")
+		walletID := chi.URLParam(r, "walletID")
+		filter := walletrepositories.LienFilter{
+			Ledger:   &ledgerName,
+			WalletID: &walletID,
+			Limit:    50,
+		}
+
+		for _, status := range r.URL.Query()["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil || i < 0 {
+				api.BadRequest(w, common.ErrValidation, errors.New("invalid limit"))
+				return
+			}
+			filter.Limit = i
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("offset")); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil || i < 0 {
+				api.BadRequest(w, common.ErrValidation, errors.New("invalid offset"))
+				return
+			}
+			filter.Offset = i
+		}
+
+		liens, err := lienService.List(r.Context(), filter)
+		if err != nil {
+			handleWalletLienError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"liens": liens,
+		})
+	}
+}
+
+func readWalletLien(lienService walletservices.LienService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lien, ok := walletLienFromRequest(w, r, lienService)
+		if !ok {
+			return
+		}
+		api.Ok(w, lien)
+	}
+}
+
+func topUpWalletLien(lienService walletservices.LienService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lien, req, amount, ok := walletLienMovementFromRequest(w, r, lienService)
+		if !ok {
+			return
+		}
+
+		result, err := lienService.TopUp(r.Context(), lien.ID, walletservices.LienMovementInput{
+			Amount:         amount,
+			Reference:      req.Reference,
+			IdempotencyKey: r.Header.Get("Idempotency-Key"),
+			ExpiresAt:      req.ExpiresAt,
+			Metadata:       req.Metadata,
+		})
+		if err != nil {
+			handleWalletLienError(w, r, err)
+			return
+		}
+		api.Ok(w, newWalletLienResponse(result))
+	}
+}
+
+func captureWalletLien(lienService walletservices.LienService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lien, req, amount, ok := walletLienMovementFromRequest(w, r, lienService)
+		if !ok {
+			return
+		}
+
+		result, err := lienService.Capture(r.Context(), lien.ID, walletservices.CaptureLienInput{
+			LienMovementInput: walletservices.LienMovementInput{
+				Amount:         amount,
+				Reference:      req.Reference,
+				IdempotencyKey: r.Header.Get("Idempotency-Key"),
+				Metadata:       req.Metadata,
+			},
+			ReleaseRemainder: req.ReleaseRemainder,
+		})
+		if err != nil {
+			handleWalletLienError(w, r, err)
+			return
+		}
+		api.Ok(w, newWalletLienResponse(result))
+	}
+}
+
+func releaseWalletLien(lienService walletservices.LienService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lien, req, amount, ok := walletLienMovementFromRequest(w, r, lienService)
+		if !ok {
+			return
+		}
+
+		result, err := lienService.Release(r.Context(), lien.ID, walletservices.LienMovementInput{
+			Amount:         amount,
+			Reference:      req.Reference,
+			IdempotencyKey: r.Header.Get("Idempotency-Key"),
+			Metadata:       req.Metadata,
+		})
+		if err != nil {
+			handleWalletLienError(w, r, err)
+			return
+		}
+		api.Ok(w, newWalletLienResponse(result))
+	}
+}
+
+// walletLienFromRequest loads the lien named in the path and checks that it
+// belongs to the wallet of the path, so that a lien ID cannot be used
+// through another wallet or ledger.
+func walletLienFromRequest(w http.ResponseWriter, r *http.Request, lienService walletservices.LienService) (*walletmodels.Lien, bool) {
+	lienID, err := uuid.Parse(chi.URLParam(r, "lienID"))
+	if err != nil {
+		api.BadRequest(w, common.ErrValidation, err)
+		return nil, false
+	}
+
+	lien, err := lienService.Get(r.Context(), lienID)
+	if err != nil {
+		handleWalletLienError(w, r, err)
+		return nil, false
+	}
+	if lien.Ledger != chi.URLParam(r, "ledger") || lien.WalletID != chi.URLParam(r, "walletID") {
+		api.NotFound(w, walletservices.ErrLienNotFound)
+		return nil, false
+	}
+	return lien, true
+}
+
+func walletLienMovementFromRequest(w http.ResponseWriter, r *http.Request, lienService walletservices.LienService) (*walletmodels.Lien, *WalletLienMovementRequest, int64, bool) {
+	req := &WalletLienMovementRequest{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return nil, nil, 0, false
+		}
+	}
+
+	lien, ok := walletLienFromRequest(w, r, lienService)
+	if !ok {
+		return nil, nil, 0, false
+	}
+
+	amount, err := parseAmount(req.Amount, lien.Currency)
+	if err != nil {
+		writeAmountError(w, "amount", err)
+		return nil, nil, 0, false
+	}
+	return lien, req, amount, true
+}
+
+func newWalletLienResponse(result *walletservices.LienResult) WalletLienResponse {
+	ret := WalletLienResponse{Lien: result.Lien}
+	if result.Transaction != nil {
+		ret.TransactionID = result.Transaction.ID
+	}
+	return ret
+}
+
+func handleWalletLienError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, walletservices.ErrLienNotFound):
+		api.NotFound(w, err)
+	case errors.Is(err, walletservices.ErrLienConflict):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	case errors.Is(err, walletservices.ErrLienInvalidState),
+		errors.Is(err, walletservices.ErrLienValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	default:
+		common.HandleCommonWriteErrors(w, r, err)
+	}
+}
@@ -0,0 +1,162 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+
+	ledger "github.com/formancehq/ledger/internal"
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+	walletrepositories "github.com/formancehq/ledger/internal/wallets/repositories"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+func TestPlaceWalletLien(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, true)
+	lienService := &lienServiceStub{
+		placeFunc: func(_ context.Context, input walletservices.PlaceLienInput) (*walletservices.LienResult, error) {
+			require.Equal(t, "test", input.Ledger)
+			require.Equal(t, "user123-USD", input.WalletID)
+			require.EqualValues(t, 1050, input.Amount)
+			require.Equal(t, "order-1", input.Reference)
+			tx := ledger.NewTransaction().WithID(3)
+			return &walletservices.LienResult{
+				Lien: &walletmodels.Lien{
+					ID:       uuid.New(),
+					WalletID: input.WalletID,
+					Amount:   input.Amount,
+					Status:   walletmodels.LienStatusActive,
+				},
+				Transaction: &tx,
+			}, nil
+		},
+	}
+
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithLienService(lienService))
+	req := httptest.NewRequest(http.MethodPost, "/test/wallets/user123-USD/lien", api.Buffer(t, PlaceWalletLienRequest{
+		Amount:    testJSONNumber("10.50"),
+		Reference: "order-1",
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	response, ok := api.DecodeSingleResponse[WalletLienResponse](t, rec.Body)
+	require.True(t, ok)
+	require.EqualValues(t, 1050, response.Amount)
+	require.NotNil(t, response.TransactionID)
+	require.EqualValues(t, 3, *response.TransactionID)
+}
+
+func TestCaptureWalletLien(t *testing.T) {
+	t.Parallel()
+
+	lienID := uuid.New()
+	lien := &walletmodels.Lien{
+		ID:       lienID,
+		Ledger:   "test",
+		WalletID: "user123-USD",
+		Currency: "USD",
+		Status:   walletmodels.LienStatusActive,
+		Amount:   1000,
+	}
+
+	t.Run("partial capture", func(t *testing.T) {
+		t.Parallel()
+
+		systemController, _ := newTestingSystemController(t, true)
+		lienService := &lienServiceStub{
+			getFunc: func(context.Context, uuid.UUID) (*walletmodels.Lien, error) {
+				return lien, nil
+			},
+			captureFunc: func(_ context.Context, id uuid.UUID, input walletservices.CaptureLienInput) (*walletservices.LienResult, error) {
+				require.Equal(t, lienID, id)
+				require.EqualValues(t, 400, input.Amount)
+				require.True(t, input.ReleaseRemainder)
+				return &walletservices.LienResult{Lien: &walletmodels.Lien{
+					ID:             lienID,
+					Status:         walletmodels.LienStatusCaptured,
+					Amount:         1000,
+					CapturedAmount: 400,
+					ReleasedAmount: 600,
+				}}, nil
+			},
+		}
+
+		router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithLienService(lienService))
+		req := httptest.NewRequest(http.MethodPost, "/test/wallets/user123-USD/liens/"+lienID.String()+"/capture", api.Buffer(t, WalletLienMovementRequest{
+			Amount:           testJSONNumber("400"),
+			ReleaseRemainder: true,
+		}))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		response, ok := api.DecodeSingleResponse[WalletLienResponse](t, rec.Body)
+		require.True(t, ok)
+		require.Equal(t, walletmodels.LienStatusCaptured, response.Status)
+		require.EqualValues(t, 600, response.ReleasedAmount)
+	})
+
+	t.Run("lien of another wallet", func(t *testing.T) {
+		t.Parallel()
+
+		systemController, _ := newTestingSystemController(t, true)
+		lienService := &lienServiceStub{
+			getFunc: func(context.Context, uuid.UUID) (*walletmodels.Lien, error) {
+				return lien, nil
+			},
+		}
+
+		router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithLienService(lienService))
+		req := httptest.NewRequest(http.MethodPost, "/test/wallets/user456-USD/liens/"+lienID.String()+"/capture", nil)
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+type lienServiceStub struct {
+	placeFunc   func(context.Context, walletservices.PlaceLienInput) (*walletservices.LienResult, error)
+	captureFunc func(context.Context, uuid.UUID, walletservices.CaptureLienInput) (*walletservices.LienResult, error)
+	getFunc     func(context.Context, uuid.UUID) (*walletmodels.Lien, error)
+}
+
+func (s *lienServiceStub) Place(ctx context.Context, input walletservices.PlaceLienInput) (*walletservices.LienResult, error) {
+	return s.placeFunc(ctx, input)
+}
+func (s *lienServiceStub) TopUp(context.Context, uuid.UUID, walletservices.LienMovementInput) (*walletservices.LienResult, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) Capture(ctx context.Context, id uuid.UUID, input walletservices.CaptureLienInput) (*walletservices.LienResult, error) {
+	return s.captureFunc(ctx, id, input)
+}
+func (s *lienServiceStub) Release(context.Context, uuid.UUID, walletservices.LienMovementInput) (*walletservices.LienResult, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) Expire(context.Context, uuid.UUID, time.Time) (*walletservices.LienResult, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) Settle(context.Context, uuid.UUID) (*walletservices.LienResult, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) Get(ctx context.Context, id uuid.UUID) (*walletmodels.Lien, error) {
+	return s.getFunc(ctx, id)
+}
+func (s *lienServiceStub) List(context.Context, walletrepositories.LienFilter) ([]walletmodels.Lien, error) {
+	return nil, nil
+}
@@ -0,0 +1,165 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+	walletrepositories "github.com/formancehq/ledger/internal/wallets/repositories"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+type UpdateWalletLabelsRequest struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// requireWallet rejects operations on wallets missing from the registry, or
+// whose status forbids the operation. Without a registry every well formed
+// wallet ID is accepted, as before wallets were persisted.
+func requireWallet(walletService walletservices.WalletService, operation string) func(http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		if walletService == nil {
+			return handler
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := walletService.Check(r.Context(), chi.URLParam(r, "ledger"), chi.URLParam(r, "walletID"), operation); err != nil {
+				handleWalletError(w, r, err)
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func listWallets(walletService walletservices.WalletService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ledgerName := chi.URLParam(r, "ledger")
+		filter := walletrepositories.WalletFilter{
+			Ledger: &ledgerName,
+			Limit:  50,
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("userID")); v != "" {
+			filter.UserID = &v
+		}
+		if v := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("currency"))); v != "" {
+			filter.Currency = &v
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("status")); v != "" {
+			for _, status := range strings.Split(v, ",") {
+				if status = strings.TrimSpace(status); status != "" {
+					filter.Statuses = append(filter.Statuses, status)
+				}
+			}
+		}
+		// Labels are given as label=key:value, once per label.
+		for _, v := range r.URL.Query()["label"] {
+			key, value, ok := strings.Cut(v, ":")
+			if !ok || key == "" {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid label filter %q, expected key:value", v))
+				return
+			}
+			if filter.Labels == nil {
+				filter.Labels = map[string]string{}
+			}
+			filter.Labels[key] = value
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("search")); v != "" {
+			filter.Search = &v
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		wallets, err := walletService.List(r.Context(), filter)
+		if err != nil {
+			handleWalletError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"wallets": wallets,
+		})
+	}
+}
+
+func readWallet(walletService walletservices.WalletService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wallet, err := walletService.Get(r.Context(), chi.URLParam(r, "ledger"), chi.URLParam(r, "walletID"))
+		if err != nil {
+			handleWalletError(w, r, err)
+			return
+		}
+		api.Ok(w, wallet)
+	}
+}
+
+func freezeWallet(walletService walletservices.WalletService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wallet, err := walletService.Freeze(r.Context(), chi.URLParam(r, "ledger"), chi.URLParam(r, "walletID"))
+		if err != nil {
+			handleWalletError(w, r, err)
+			return
+		}
+		api.Ok(w, wallet)
+	}
+}
+
+func unfreezeWallet(walletService walletservices.WalletService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wallet, err := walletService.Unfreeze(r.Context(), chi.URLParam(r, "ledger"), chi.URLParam(r, "walletID"))
+		if err != nil {
+			handleWalletError(w, r, err)
+			return
+		}
+		api.Ok(w, wallet)
+	}
+}
+
+func closeWallet(walletService walletservices.WalletService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		wallet, err := walletService.Close(r.Context(), chi.URLParam(r, "ledger"), chi.URLParam(r, "walletID"))
+		if err != nil {
+			handleWalletError(w, r, err)
+			return
+		}
+		api.Ok(w, wallet)
+	}
+}
+
+func updateWalletLabels(walletService walletservices.WalletService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateWalletLabelsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		wallet, err := walletService.UpdateLabels(r.Context(), chi.URLParam(r, "ledger"), chi.URLParam(r, "walletID"), req.Labels)
+		if err != nil {
+			handleWalletError(w, r, err)
+			return
+		}
+		api.Ok(w, wallet)
+	}
+}
+
+func handleWalletError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, walletservices.ErrWalletNotFound):
+		api.NotFound(w, err)
+	case errors.Is(err, walletservices.ErrWalletInvalidState),
+		errors.Is(err, walletservices.ErrWalletValidation),
+		errors.Is(err, walletmodels.ErrInvalidWalletID):
+		api.BadRequest(w, common.ErrValidation, err)
+	default:
+		common.HandleCommonWriteErrors(w, r, err)
+	}
+}
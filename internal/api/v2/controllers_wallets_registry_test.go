@@ -0,0 +1,235 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	"github.com/formancehq/go-libs/v3/query"
+
+	ledger "github.com/formancehq/ledger/internal"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	storagecommon "github.com/formancehq/ledger/internal/storage/common"
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+	walletrepositories "github.com/formancehq/ledger/internal/wallets/repositories"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+func TestWalletRegistryGuards(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name               string
+		path               string
+		payload            any
+		expectedOperation  string
+		checkErr           error
+		expectedStatusCode int
+		expectedErrorCode  string
+	}
+
+	testCases := []testCase{
+		{
+			name:               "credit unknown wallet",
+			path:               "/test/wallets/ghost-USD/credit",
+			payload:            WalletTransactionRequest{Amount: testJSONNumber("10"), Reference: "c1"},
+			expectedOperation:  walletservices.WalletOperationCredit,
+			checkErr:           walletservices.ErrWalletNotFound,
+			expectedStatusCode: http.StatusNotFound,
+			expectedErrorCode:  api.ErrorCodeNotFound,
+		},
+		{
+			name:               "debit frozen wallet",
+			path:               "/test/wallets/user123-USD/debit",
+			payload:            WalletTransactionRequest{Amount: testJSONNumber("10"), Reference: "d1"},
+			expectedOperation:  walletservices.WalletOperationDebit,
+			checkErr:           fmt.Errorf("%w: wallet user123-USD is frozen", walletservices.ErrWalletInvalidState),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+		{
+			name:               "lien on closed wallet",
+			path:               "/test/wallets/user123-USD/lien",
+			payload:            WalletTransactionRequest{Amount: testJSONNumber("10"), Reference: "l1"},
+			expectedOperation:  walletservices.WalletOperationDebit,
+			checkErr:           fmt.Errorf("%w: wallet user123-USD is closed", walletservices.ErrWalletInvalidState),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+		{
+			name:               "lien release and debit on frozen wallet",
+			path:               "/test/wallets/user123-USD/lien/release",
+			payload:            ReleaseLienRequest{Amount: testJSONNumber("10"), Reference: "r1", Mode: "release_and_debit"},
+			expectedOperation:  walletservices.WalletOperationDebit,
+			checkErr:           fmt.Errorf("%w: wallet user123-USD is frozen", walletservices.ErrWalletInvalidState),
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			systemController, _ := newTestingSystemController(t, true)
+			walletService := &walletServiceStub{
+				checkFunc: func(_ context.Context, ledgerName, walletID, operation string) (*walletmodels.Wallet, error) {
+					require.Equal(t, "test", ledgerName)
+					require.Equal(t, tc.expectedOperation, operation)
+					return nil, tc.checkErr
+				},
+			}
+
+			router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithWalletService(walletService))
+			req := httptest.NewRequest(http.MethodPost, tc.path, api.Buffer(t, tc.payload))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedStatusCode, rec.Code)
+			err := api.ErrorResponse{}
+			api.Decode(t, rec.Body, &err)
+			require.EqualValues(t, tc.expectedErrorCode, err.ErrorCode)
+		})
+	}
+}
+
+func TestCreateRegisteredWallet(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, true)
+	walletService := &walletServiceStub{
+		createFunc: func(_ context.Context, input walletservices.CreateWalletInput) (*walletmodels.Wallet, bool, error) {
+			require.Equal(t, "test", input.Ledger)
+			require.Equal(t, "user123", input.UserID)
+			require.Equal(t, "USD", input.Currency)
+			require.Equal(t, map[string]string{"tier": "gold"}, input.Labels)
+			return &walletmodels.Wallet{
+				Ledger:   input.Ledger,
+				ID:       "user123-USD",
+				UserID:   input.UserID,
+				Currency: input.Currency,
+				Status:   walletmodels.WalletStatusActive,
+				Labels:   input.Labels,
+			}, true, nil
+		},
+	}
+
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithWalletService(walletService))
+	req := httptest.NewRequest(http.MethodPost, "/test/wallets", api.Buffer(t, CreateWalletRequest{
+		UserID:   "user123",
+		Currency: "usd",
+		Labels:   map[string]string{"tier": "gold"},
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	response, ok := api.DecodeSingleResponse[map[string]any](t, rec.Body)
+	require.True(t, ok)
+	require.Equal(t, "user123-USD", response["walletID"])
+	require.Equal(t, walletmodels.WalletStatusActive, response["status"])
+}
+
+func TestWalletServiceAdoptsFundedWallets(t *testing.T) {
+	t.Parallel()
+
+	systemController, ledgerController := newTestingSystemController(t, false)
+	ledgerController.EXPECT().
+		GetAccount(gomock.Any(), storagecommon.ResourceQuery[any]{
+			Builder: query.Match("address", "users:legacy:wallets:USD:available"),
+		}).
+		Return(&ledger.Account{Address: "users:legacy:wallets:USD:available"}, nil)
+	ledgerController.EXPECT().
+		GetAccount(gomock.Any(), storagecommon.ResourceQuery[any]{
+			Builder: query.Match("address", "users:ghost:wallets:USD:available"),
+		}).
+		Return(nil, postgres.ErrNotFound)
+
+	repository := &walletRepositoryStub{wallets: map[string]walletmodels.Wallet{}}
+	walletService := walletservices.NewWalletService(systemController, repository)
+
+	// Funded before the registry existed, the wallet is registered on first use.
+	wallet, err := walletService.Check(context.Background(), "test", "legacy-usd", walletservices.WalletOperationDebit)
+	require.NoError(t, err)
+	require.Equal(t, "legacy-USD", wallet.ID)
+	require.Equal(t, walletmodels.WalletStatusActive, wallet.Status)
+	require.Contains(t, repository.wallets, "test/legacy-USD")
+
+	// Once registered, the ledger is not looked up again.
+	_, err = walletService.Get(context.Background(), "test", "legacy-USD")
+	require.NoError(t, err)
+
+	_, err = walletService.Check(context.Background(), "test", "ghost-USD", walletservices.WalletOperationCredit)
+	require.ErrorIs(t, err, walletservices.ErrWalletNotFound)
+	require.NotContains(t, repository.wallets, "test/ghost-USD")
+}
+
+type walletRepositoryStub struct {
+	wallets map[string]walletmodels.Wallet
+}
+
+func (r *walletRepositoryStub) Create(_ context.Context, wallet *walletmodels.Wallet) error {
+	if _, ok := r.wallets[wallet.Ledger+"/"+wallet.ID]; ok {
+		return postgres.ErrConstraintsFailed{}
+	}
+	r.wallets[wallet.Ledger+"/"+wallet.ID] = *wallet
+	return nil
+}
+
+func (r *walletRepositoryStub) Update(_ context.Context, wallet *walletmodels.Wallet) error {
+	r.wallets[wallet.Ledger+"/"+wallet.ID] = *wallet
+	return nil
+}
+
+func (r *walletRepositoryStub) Get(_ context.Context, ledger, id string) (*walletmodels.Wallet, error) {
+	wallet, ok := r.wallets[ledger+"/"+id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	return &wallet, nil
+}
+
+func (r *walletRepositoryStub) List(context.Context, walletrepositories.WalletFilter) ([]walletmodels.Wallet, error) {
+	return nil, nil
+}
+
+type walletServiceStub struct {
+	createFunc func(context.Context, walletservices.CreateWalletInput) (*walletmodels.Wallet, bool, error)
+	checkFunc  func(context.Context, string, string, string) (*walletmodels.Wallet, error)
+}
+
+func (s *walletServiceStub) Create(ctx context.Context, input walletservices.CreateWalletInput) (*walletmodels.Wallet, bool, error) {
+	return s.createFunc(ctx, input)
+}
+func (s *walletServiceStub) Get(context.Context, string, string) (*walletmodels.Wallet, error) {
+	return nil, walletservices.ErrWalletNotFound
+}
+func (s *walletServiceStub) List(context.Context, walletrepositories.WalletFilter) ([]walletmodels.Wallet, error) {
+	return nil, nil
+}
+func (s *walletServiceStub) Freeze(context.Context, string, string) (*walletmodels.Wallet, error) {
+	return nil, nil
+}
+func (s *walletServiceStub) Unfreeze(context.Context, string, string) (*walletmodels.Wallet, error) {
+	return nil, nil
+}
+func (s *walletServiceStub) Close(context.Context, string, string) (*walletmodels.Wallet, error) {
+	return nil, nil
+}
+func (s *walletServiceStub) UpdateLabels(context.Context, string, string, map[string]string) (*walletmodels.Wallet, error) {
+	return nil, nil
+}
+func (s *walletServiceStub) Check(ctx context.Context, ledgerName, walletID, operation string) (*walletmodels.Wallet, error) {
+	return s.checkFunc(ctx, ledgerName, walletID, operation)
+}
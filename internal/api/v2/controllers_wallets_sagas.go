@@ -0,0 +1,126 @@
+package v2
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	walletrepositories "github.com/formancehq/ledger/internal/wallets/repositories"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+func listWalletDebitSagas(debitSagaCoordinator walletservices.DebitSagaCoordinator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ledgerName := chi.URLParam(r, "ledger")
+		filter := walletrepositories.DebitSagaFilter{
+			Ledger: &ledgerName,
+			Limit:  50,
+		}
+
+		if walletID := strings.TrimSpace(r.URL.Query().Get("walletID")); walletID != "" {
+			filter.WalletID = &walletID
+		}
+		for _, status := range r.URL.Query()["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil || i < 0 {
+				api.BadRequest(w, common.ErrValidation, errors.New("invalid limit"))
+				return
+			}
+			filter.Limit = i
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("offset")); v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil || i < 0 {
+				api.BadRequest(w, common.ErrValidation, errors.New("invalid offset"))
+				return
+			}
+			filter.Offset = i
+		}
+
+		sagas, err := debitSagaCoordinator.List(r.Context(), filter)
+		if err != nil {
+			handleDebitSagaError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"sagas": sagas,
+		})
+	}
+}
+
+func readWalletDebitSaga(debitSagaCoordinator walletservices.DebitSagaCoordinator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sagaID, err := uuid.Parse(chi.URLParam(r, "sagaID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		saga, err := debitSagaCoordinator.Get(r.Context(), sagaID)
+		if err != nil {
+			handleDebitSagaError(w, r, err)
+			return
+		}
+		api.Ok(w, saga)
+	}
+}
+
+func resumeWalletDebitSaga(debitSagaCoordinator walletservices.DebitSagaCoordinator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sagaID, err := uuid.Parse(chi.URLParam(r, "sagaID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		result, err := debitSagaCoordinator.Resume(r.Context(), sagaID)
+		if err != nil {
+			handleDebitSagaError(w, r, err)
+			return
+		}
+		api.Ok(w, result.Saga)
+	}
+}
+
+func compensateWalletDebitSaga(debitSagaCoordinator walletservices.DebitSagaCoordinator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sagaID, err := uuid.Parse(chi.URLParam(r, "sagaID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		saga, err := debitSagaCoordinator.Compensate(r.Context(), sagaID)
+		if err != nil {
+			handleDebitSagaError(w, r, err)
+			return
+		}
+		api.Ok(w, saga)
+	}
+}
+
+func handleDebitSagaError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, walletservices.ErrDebitSagaNotFound):
+		api.NotFound(w, err)
+	case errors.Is(err, walletservices.ErrDebitSagaInvalidState),
+		errors.Is(err, walletservices.ErrDebitSagaValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	default:
+		common.HandleCommonWriteErrors(w, r, err)
+	}
+}
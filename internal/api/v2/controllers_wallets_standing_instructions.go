@@ -0,0 +1,296 @@
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/api"
+
+	"github.com/formancehq/ledger/internal/api/common"
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+	walletrepositories "github.com/formancehq/ledger/internal/wallets/repositories"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+type CreateStandingInstructionRequest struct {
+	DestinationWalletID string      `json:"destinationWalletID"`
+	Amount              json.Number `json:"amount"`
+	// Schedule is a cron expression, or one of the calendar rules daily,
+	// weekly:<mon..sun> and monthly:<1..31|last>.
+	Schedule    string     `json:"schedule"`
+	StartAt     *time.Time `json:"startAt"`
+	EndAt       *time.Time `json:"endAt"`
+	MaxAttempts int        `json:"maxAttempts"`
+	// RetryInterval is a duration such as 30m or 6h.
+	RetryInterval string            `json:"retryInterval"`
+	FailurePolicy string            `json:"failurePolicy"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+type UpdateStandingInstructionRequest struct {
+	Amount        json.Number       `json:"amount"`
+	Schedule      *string           `json:"schedule"`
+	EndAt         *time.Time        `json:"endAt"`
+	MaxAttempts   *int              `json:"maxAttempts"`
+	RetryInterval *string           `json:"retryInterval"`
+	FailurePolicy *string           `json:"failurePolicy"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+func createWalletStandingInstruction(standingInstructionService walletservices.StandingInstructionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		walletRef, err := walletmodels.ParseWalletID(chi.URLParam(r, "walletID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		var req CreateStandingInstructionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		amount, err := parseAmount(req.Amount, walletRef.Currency)
+		if err != nil {
+			writeAmountError(w, "amount", err)
+			return
+		}
+		var retryInterval time.Duration
+		if req.RetryInterval != "" {
+			if retryInterval, err = time.ParseDuration(req.RetryInterval); err != nil {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid retryInterval: %w", err))
+				return
+			}
+		}
+
+		instruction, err := standingInstructionService.Create(r.Context(), walletservices.CreateStandingInstructionInput{
+			Ledger:              chi.URLParam(r, "ledger"),
+			SourceWalletID:      chi.URLParam(r, "walletID"),
+			DestinationWalletID: req.DestinationWalletID,
+			Amount:              amount,
+			Schedule:            req.Schedule,
+			StartAt:             req.StartAt,
+			EndAt:               req.EndAt,
+			MaxAttempts:         req.MaxAttempts,
+			RetryInterval:       retryInterval,
+			FailurePolicy:       req.FailurePolicy,
+			Metadata:            req.Metadata,
+		})
+		if err != nil {
+			handleStandingInstructionError(w, r, err)
+			return
+		}
+		api.Created(w, instruction)
+	}
+}
+
+func listWalletStandingInstructions(standingInstructionService walletservices.StandingInstructionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ledgerName := chi.URLParam(r, "ledger")
+		walletID := chi.URLParam(r, "walletID")
+		filter := walletrepositories.StandingInstructionFilter{
+			Ledger:         &ledgerName,
+			SourceWalletID: &walletID,
+			Limit:          50,
+		}
+		for _, status := range r.URL.Query()["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		var ok bool
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		instructions, err := standingInstructionService.List(r.Context(), filter)
+		if err != nil {
+			handleStandingInstructionError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"standingInstructions": instructions,
+		})
+	}
+}
+
+func readWalletStandingInstruction(standingInstructionService walletservices.StandingInstructionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instruction, ok := walletStandingInstructionFromRequest(w, r, standingInstructionService)
+		if !ok {
+			return
+		}
+		api.Ok(w, instruction)
+	}
+}
+
+func updateWalletStandingInstruction(standingInstructionService walletservices.StandingInstructionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req UpdateStandingInstructionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		instruction, ok := walletStandingInstructionFromRequest(w, r, standingInstructionService)
+		if !ok {
+			return
+		}
+
+		input := walletservices.UpdateStandingInstructionInput{
+			Schedule:      req.Schedule,
+			EndAt:         req.EndAt,
+			MaxAttempts:   req.MaxAttempts,
+			FailurePolicy: req.FailurePolicy,
+			Metadata:      req.Metadata,
+		}
+		if req.Amount != "" {
+			amount, err := parseAmount(req.Amount, instruction.Currency)
+			if err != nil {
+				writeAmountError(w, "amount", err)
+				return
+			}
+			input.Amount = &amount
+		}
+		if req.RetryInterval != nil {
+			retryInterval, err := time.ParseDuration(*req.RetryInterval)
+			if err != nil {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("invalid retryInterval: %w", err))
+				return
+			}
+			input.RetryInterval = &retryInterval
+		}
+
+		instruction, err := standingInstructionService.Update(r.Context(), instruction.ID, input)
+		if err != nil {
+			handleStandingInstructionError(w, r, err)
+			return
+		}
+		api.Ok(w, instruction)
+	}
+}
+
+func pauseWalletStandingInstruction(standingInstructionService walletservices.StandingInstructionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instruction, ok := walletStandingInstructionFromRequest(w, r, standingInstructionService)
+		if !ok {
+			return
+		}
+		instruction, err := standingInstructionService.Pause(r.Context(), instruction.ID)
+		if err != nil {
+			handleStandingInstructionError(w, r, err)
+			return
+		}
+		api.Ok(w, instruction)
+	}
+}
+
+func resumeWalletStandingInstruction(standingInstructionService walletservices.StandingInstructionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instruction, ok := walletStandingInstructionFromRequest(w, r, standingInstructionService)
+		if !ok {
+			return
+		}
+		instruction, err := standingInstructionService.Resume(r.Context(), instruction.ID, time.Now().UTC())
+		if err != nil {
+			handleStandingInstructionError(w, r, err)
+			return
+		}
+		api.Ok(w, instruction)
+	}
+}
+
+func cancelWalletStandingInstruction(standingInstructionService walletservices.StandingInstructionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instruction, ok := walletStandingInstructionFromRequest(w, r, standingInstructionService)
+		if !ok {
+			return
+		}
+		instruction, err := standingInstructionService.Cancel(r.Context(), instruction.ID)
+		if err != nil {
+			handleStandingInstructionError(w, r, err)
+			return
+		}
+		api.Ok(w, instruction)
+	}
+}
+
+func listWalletStandingInstructionExecutions(standingInstructionService walletservices.StandingInstructionService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		instruction, ok := walletStandingInstructionFromRequest(w, r, standingInstructionService)
+		if !ok {
+			return
+		}
+		filter := walletrepositories.StandingInstructionExecutionFilter{
+			InstructionID: &instruction.ID,
+			Limit:         50,
+		}
+		for _, status := range r.URL.Query()["status"] {
+			for _, s := range strings.Split(status, ",") {
+				if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+					filter.Statuses = append(filter.Statuses, s)
+				}
+			}
+		}
+		if filter.Limit, filter.Offset, ok = getLimitOffset(w, r, filter.Limit); !ok {
+			return
+		}
+
+		executions, err := standingInstructionService.ListExecutions(r.Context(), filter)
+		if err != nil {
+			handleStandingInstructionError(w, r, err)
+			return
+		}
+		api.Ok(w, map[string]any{
+			"executions": executions,
+		})
+	}
+}
+
+// walletStandingInstructionFromRequest loads the instruction named in the
+// path and checks that it debits the wallet of the path.
+func walletStandingInstructionFromRequest(
+	w http.ResponseWriter,
+	r *http.Request,
+	standingInstructionService walletservices.StandingInstructionService,
+) (*walletmodels.StandingInstruction, bool) {
+	instructionID, err := uuid.Parse(chi.URLParam(r, "instructionID"))
+	if err != nil {
+		api.BadRequest(w, common.ErrValidation, err)
+		return nil, false
+	}
+
+	instruction, err := standingInstructionService.Get(r.Context(), instructionID)
+	if err != nil {
+		handleStandingInstructionError(w, r, err)
+		return nil, false
+	}
+	if instruction.Ledger != chi.URLParam(r, "ledger") || instruction.SourceWalletID != chi.URLParam(r, "walletID") {
+		api.NotFound(w, walletservices.ErrStandingInstructionNotFound)
+		return nil, false
+	}
+	return instruction, true
+}
+
+func handleStandingInstructionError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, walletservices.ErrStandingInstructionNotFound):
+		api.NotFound(w, err)
+	case errors.Is(err, walletservices.ErrStandingInstructionConflict):
+		api.WriteErrorResponse(w, http.StatusConflict, common.ErrConflict, err)
+	case errors.Is(err, walletservices.ErrStandingInstructionInvalidState),
+		errors.Is(err, walletservices.ErrStandingInstructionValidation):
+		api.BadRequest(w, common.ErrValidation, err)
+	default:
+		common.HandleCommonWriteErrors(w, r, err)
+	}
+}
@@ -0,0 +1,125 @@
+package v2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+func TestCreateWalletStandingInstruction(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, true)
+	standingInstructionService := &standingInstructionServiceStub{
+		createFunc: func(_ context.Context, input walletservices.CreateStandingInstructionInput) (*walletmodels.StandingInstruction, error) {
+			require.Equal(t, "test", input.Ledger)
+			require.Equal(t, "user123-USD", input.SourceWalletID)
+			require.Equal(t, "savings-USD", input.DestinationWalletID)
+			require.EqualValues(t, 2500, input.Amount)
+			require.Equal(t, "monthly:1", input.Schedule)
+			require.Equal(t, 6*time.Hour, input.RetryInterval)
+			return &walletmodels.StandingInstruction{
+				ID:                  uuid.New(),
+				Ledger:              input.Ledger,
+				SourceWalletID:      input.SourceWalletID,
+				DestinationWalletID: input.DestinationWalletID,
+				Amount:              input.Amount,
+				Schedule:            input.Schedule,
+				Status:              walletmodels.StandingInstructionStatusActive,
+			}, nil
+		},
+	}
+
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithStandingInstructionService(standingInstructionService))
+	req := httptest.NewRequest(http.MethodPost, "/test/wallets/user123-USD/standing-instructions", api.Buffer(t, CreateStandingInstructionRequest{
+		DestinationWalletID: "savings-USD",
+		Amount:              testJSONNumber("25.00"),
+		Schedule:            "monthly:1",
+		RetryInterval:       "6h",
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+	response, ok := api.DecodeSingleResponse[walletmodels.StandingInstruction](t, rec.Body)
+	require.True(t, ok)
+	require.EqualValues(t, 2500, response.Amount)
+	require.Equal(t, walletmodels.StandingInstructionStatusActive, response.Status)
+}
+
+func TestCreateWalletStandingInstructionValidation(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, true)
+	standingInstructionService := &standingInstructionServiceStub{
+		createFunc: func(context.Context, walletservices.CreateStandingInstructionInput) (*walletmodels.StandingInstruction, error) {
+			return nil, walletservices.ErrStandingInstructionValidation
+		},
+	}
+
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithStandingInstructionService(standingInstructionService))
+	req := httptest.NewRequest(http.MethodPost, "/test/wallets/user123-USD/standing-instructions", api.Buffer(t, CreateStandingInstructionRequest{
+		DestinationWalletID: "savings-USD",
+		Amount:              testJSONNumber("25.00"),
+		Schedule:            "every tuesday",
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestReadWalletStandingInstructionOfAnotherWallet(t *testing.T) {
+	t.Parallel()
+
+	instructionID := uuid.New()
+	systemController, _ := newTestingSystemController(t, true)
+	standingInstructionService := &standingInstructionServiceStub{
+		getFunc: func(_ context.Context, id uuid.UUID) (*walletmodels.StandingInstruction, error) {
+			require.Equal(t, instructionID, id)
+			return &walletmodels.StandingInstruction{
+				ID:             instructionID,
+				Ledger:         "test",
+				SourceWalletID: "other-USD",
+			}, nil
+		},
+	}
+
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop", WithStandingInstructionService(standingInstructionService))
+	req := httptest.NewRequest(http.MethodGet, "/test/wallets/user123-USD/standing-instructions/"+instructionID.String(), nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	err := api.ErrorResponse{}
+	api.Decode(t, rec.Body, &err)
+	require.EqualValues(t, api.ErrorCodeNotFound, err.ErrorCode)
+}
+
+type standingInstructionServiceStub struct {
+	walletservices.StandingInstructionService
+	createFunc func(context.Context, walletservices.CreateStandingInstructionInput) (*walletmodels.StandingInstruction, error)
+	getFunc    func(context.Context, uuid.UUID) (*walletmodels.StandingInstruction, error)
+}
+
+func (s *standingInstructionServiceStub) Create(ctx context.Context, input walletservices.CreateStandingInstructionInput) (*walletmodels.StandingInstruction, error) {
+	return s.createFunc(ctx, input)
+}
+
+func (s *standingInstructionServiceStub) Get(ctx context.Context, id uuid.UUID) (*walletmodels.StandingInstruction, error) {
+	return s.getFunc(ctx, id)
+}
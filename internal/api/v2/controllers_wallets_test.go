@@ -3,6 +3,7 @@ package v2
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
@@ -185,6 +186,16 @@ func TestCreditWallet(t *testing.T) {
 			expectedStatusCode: http.StatusBadRequest,
 			expectedErrorCode:  common.ErrValidation,
 		},
+		{
+			name:     "too many decimals",
+			walletID: "user123-USD",
+			payload: WalletTransactionRequest{
+				Amount:    testJSONNumber("1.005"),
+				Reference: "ref1",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -236,6 +247,62 @@ func TestCreditWallet(t *testing.T) {
 	}
 }
 
+func TestCreditWalletUsesCurrencyPrecision(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		currency string
+		amount   string
+		asset    string
+		posted   int64
+	}{
+		{currency: "JPY", amount: "1500", asset: "JPY/0", posted: 1500},
+		{currency: "KWD", amount: "1.25", asset: "KWD/3", posted: 1250},
+	} {
+		t.Run(tc.currency, func(t *testing.T) {
+			t.Parallel()
+
+			systemController, ledgerController := newTestingSystemController(t, true)
+			account := fmt.Sprintf("users:user123:wallets:%s:available", tc.currency)
+			ledgerController.EXPECT().
+				CreateTransaction(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+					require.Contains(t, params.Input.RunScript.Script.Plain, fmt.Sprintf("send [%s %d]", tc.asset, tc.posted))
+					return &ledger.Log{}, &ledger.CreatedTransaction{
+						Transaction: ledger.NewTransaction().
+							WithPostings(
+								ledger.NewPosting("system:control:"+tc.currency, account, tc.asset, big.NewInt(tc.posted)),
+							).
+							WithPostCommitVolumes(ledger.PostCommitVolumes{
+								"system:control:" + tc.currency: {
+									tc.asset: ledger.NewVolumesInt64(0, tc.posted),
+								},
+								account: {
+									tc.asset: ledger.NewVolumesInt64(tc.posted, 0),
+								},
+							}),
+					}, false, nil
+				})
+
+			router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/test/wallets/user123-%s/credit", tc.currency), api.Buffer(t, WalletTransactionRequest{
+				Amount:    testJSONNumber(tc.amount),
+				Reference: "ref1",
+			}))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusCreated, rec.Code)
+			response, ok := api.DecodeSingleResponse[walletTransactionResponse](t, rec.Body)
+			require.True(t, ok)
+			require.EqualValues(t, 0, response.BalanceBefore)
+			require.EqualValues(t, tc.posted, response.BalanceAfter)
+		})
+	}
+}
+
 func TestDebitWallet(t *testing.T) {
 	type testCase struct {
 		name                 string
@@ -309,6 +376,118 @@ func TestDebitWallet(t *testing.T) {
 	}
 }
 
+func TestDebitWalletCompensatesFailedChannelLeg(t *testing.T) {
+	t.Parallel()
+
+	systemController, ledgerController := newTestingSystemController(t, true)
+
+	gomock.InOrder(
+		ledgerController.EXPECT().
+			CreateTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+				require.Contains(t, params.Input.RunScript.Script.Plain, "@users:user123:wallets:USD:available")
+				return &ledger.Log{}, &ledger.CreatedTransaction{
+					Transaction: ledger.NewTransaction().WithID(7),
+				}, false, nil
+			}),
+		ledgerController.EXPECT().
+			CreateTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+				require.Contains(t, params.Input.RunScript.Script.Plain, "@channel:ch1")
+				return nil, nil, false, errors.New("channel ledger unavailable")
+			}),
+		// The failed leg is replayed in case it was committed all the same.
+		ledgerController.EXPECT().
+			CreateTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+				require.Contains(t, params.Input.RunScript.Script.Plain, "@channel:ch1")
+				return nil, nil, false, errors.New("channel ledger unavailable")
+			}),
+		ledgerController.EXPECT().
+			RevertTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.RevertTransaction]) (*ledger.Log, *ledger.RevertedTransaction, bool, error) {
+				require.EqualValues(t, 7, params.Input.TransactionID)
+				require.True(t, params.Input.Force)
+				return &ledger.Log{}, &ledger.RevertedTransaction{
+					RevertTransaction: ledger.NewTransaction().WithID(8),
+				}, false, nil
+			}),
+	)
+
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+
+	req := httptest.NewRequest(http.MethodPost, "/test/wallets/user123-USD/debit", api.Buffer(t, WalletTransactionRequest{
+		Amount:        testJSONNumber("100"),
+		ChannelAmount: testJSONNumber("100"),
+		ChannelID:     "ch1",
+		Reference:     "ref1",
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestDebitWalletRevertsTimedOutLegCommittedAllTheSame(t *testing.T) {
+	t.Parallel()
+
+	systemController, ledgerController := newTestingSystemController(t, true)
+
+	var channelIdempotencyKey string
+	gomock.InOrder(
+		ledgerController.EXPECT().
+			CreateTransaction(gomock.Any(), gomock.Any()).
+			Return(&ledger.Log{}, &ledger.CreatedTransaction{
+				Transaction: ledger.NewTransaction().WithID(7),
+			}, false, nil),
+		ledgerController.EXPECT().
+			CreateTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+				channelIdempotencyKey = params.IdempotencyKey
+				return nil, nil, false, context.DeadlineExceeded
+			}),
+		ledgerController.EXPECT().
+			CreateTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+				require.Equal(t, channelIdempotencyKey, params.IdempotencyKey)
+				return &ledger.Log{}, &ledger.CreatedTransaction{
+					Transaction: ledger.NewTransaction().WithID(9),
+				}, true, nil
+			}),
+		ledgerController.EXPECT().
+			RevertTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.RevertTransaction]) (*ledger.Log, *ledger.RevertedTransaction, bool, error) {
+				require.EqualValues(t, 9, params.Input.TransactionID)
+				return &ledger.Log{}, &ledger.RevertedTransaction{
+					RevertTransaction: ledger.NewTransaction().WithID(10),
+				}, false, nil
+			}),
+		ledgerController.EXPECT().
+			RevertTransaction(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.RevertTransaction]) (*ledger.Log, *ledger.RevertedTransaction, bool, error) {
+				require.EqualValues(t, 7, params.Input.TransactionID)
+				return &ledger.Log{}, &ledger.RevertedTransaction{
+					RevertTransaction: ledger.NewTransaction().WithID(11),
+				}, false, nil
+			}),
+	)
+
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+
+	req := httptest.NewRequest(http.MethodPost, "/test/wallets/user123-USD/debit", api.Buffer(t, WalletTransactionRequest{
+		Amount:        testJSONNumber("100"),
+		ChannelAmount: testJSONNumber("100"),
+		ChannelID:     "ch1",
+		Reference:     "ref1",
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
 func TestLienWallet(t *testing.T) {
 	type testCase struct {
 		name                 string
@@ -0,0 +1,197 @@
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/logging"
+	"github.com/formancehq/go-libs/v3/metadata"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/api/common"
+	"github.com/formancehq/ledger/internal/controller/ledger"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	exchangeservices "github.com/formancehq/ledger/internal/exchange/services"
+	"github.com/formancehq/ledger/internal/machine/vm"
+	walletmodels "github.com/formancehq/ledger/internal/wallets/models"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
+)
+
+type WalletTransferRequest struct {
+	DestinationWalletID string            `json:"destinationWalletID"`
+	Amount              json.Number       `json:"amount"`
+	Reference           string            `json:"reference"`
+	Metadata            map[string]string `json:"metadata"`
+	// FXRate is the amount of destination currency bought by one unit of
+	// source currency. When empty, transfers between different currencies
+	// use the published rate of the pair.
+	FXRate json.Number `json:"fxRate,omitempty"`
+}
+
+func transferWallet(conversionService exchangeservices.ConversionService, walletService walletservices.WalletService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		l := common.LedgerFromContext(r.Context())
+
+		source, err := walletmodels.ParseWalletID(chi.URLParam(r, "walletID"))
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+		sourceCurrency := source.Currency
+
+		var req WalletTransferRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.BadRequest(w, common.ErrValidation, err)
+			return
+		}
+
+		destination, err := walletmodels.ParseWalletID(req.DestinationWalletID)
+		if err != nil {
+			api.BadRequest(w, common.ErrValidation, fmt.Errorf("destinationWalletID: %w", err))
+			return
+		}
+		destinationCurrency := destination.Currency
+		if req.DestinationWalletID == chi.URLParam(r, "walletID") {
+			api.BadRequest(w, common.ErrValidation, fmt.Errorf("source and destination wallets must differ"))
+			return
+		}
+		if req.Reference == "" {
+			api.BadRequest(w, common.ErrValidation, fmt.Errorf("reference is required"))
+			return
+		}
+
+		if walletService != nil {
+			// The source wallet is checked by the route middleware.
+			if _, err := walletService.Check(r.Context(), chi.URLParam(r, "ledger"), req.DestinationWalletID, walletservices.WalletOperationCredit); err != nil {
+				handleWalletError(w, r, fmt.Errorf("destination wallet: %w", err))
+				return
+			}
+		}
+
+		amount, err := parseAmount(req.Amount, sourceCurrency)
+		if err != nil {
+			writeAmountError(w, "amount", err)
+			return
+		}
+		if amount <= 0 {
+			api.BadRequest(w, common.ErrValidation, fmt.Errorf("amount must be positive"))
+			return
+		}
+
+		accountSource := source.AvailableAccount()
+		accountDestination := destination.AvailableAccount()
+		sourceAsset := currencyregistry.Asset(sourceCurrency)
+
+		txMetadata := metadata.Metadata{}
+		for k, v := range req.Metadata {
+			txMetadata[k] = v
+		}
+		txMetadata["transfer_source_wallet"] = chi.URLParam(r, "walletID")
+		txMetadata["transfer_destination_wallet"] = req.DestinationWalletID
+
+		idempotencyKey := r.Header.Get("Idempotency-Key")
+		if idempotencyKey == "" {
+			// Retrying a transfer with the same reference replays it instead of
+			// failing on the reference.
+			idempotencyKey = fmt.Sprintf("wallet-transfer:%s:%s", chi.URLParam(r, "walletID"), req.Reference)
+		}
+
+		var (
+			tx                *ledgerinternal.Transaction
+			destinationAmount = amount
+			pnlErr            error
+		)
+		if strings.EqualFold(sourceCurrency, destinationCurrency) {
+			if req.FXRate != "" {
+				api.BadRequest(w, common.ErrValidation, fmt.Errorf("fxRate is only allowed between different currencies"))
+				return
+			}
+			_, created, _, err := l.CreateTransaction(r.Context(), ledger.Parameters[ledger.CreateTransaction]{
+				IdempotencyKey: idempotencyKey,
+				Input: ledger.CreateTransaction{
+					RunScript: vm.RunScript{
+						Script: vm.Script{
+							Plain: fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, sourceAsset, amount, accountSource, accountDestination),
+						},
+						Reference: req.Reference,
+						Metadata:  txMetadata,
+					},
+					Runtime: ledgerinternal.RuntimeMachine,
+				},
+			})
+			if err != nil {
+				common.HandleCommonWriteErrors(w, r, err)
+				return
+			}
+			tx = &created.Transaction
+		} else {
+			// Both legs go through the position account of the pair, priced
+			// with the stored rate unless the request carries its own.
+			result, err := conversionService.Convert(r.Context(), exchangeservices.ConvertInput{
+				QuoteInput: exchangeservices.QuoteInput{
+					From:   sourceCurrency,
+					To:     destinationCurrency,
+					Amount: amount,
+					Rate:   req.FXRate.String(),
+				},
+				Ledger:             chi.URLParam(r, "ledger"),
+				SourceAccount:      accountSource,
+				DestinationAccount: accountDestination,
+				Reference:          req.Reference,
+				IdempotencyKey:     idempotencyKey,
+				Metadata:           txMetadata,
+			})
+			if err != nil && (result == nil || result.Transaction == nil) {
+				handleFXError(w, r, err)
+				return
+			}
+			// A failed P&L posting does not undo the transfer: it is reported
+			// again, and fixed, when the transfer is retried.
+			if err != nil {
+				logging.FromContext(r.Context()).Errorf("wallet transfer %s is booked without its fx pnl: %v", req.Reference, err)
+				pnlErr = err
+			}
+			tx = result.Transaction
+			destinationAmount = result.Quote.ConvertedAmount
+		}
+
+		preCommitVolumes := tx.PostCommitVolumes.SubtractPostings(tx.Postings)
+
+		ret := map[string]interface{}{
+			"txid":               tx.ID,
+			"timestamp":          tx.Timestamp,
+			"postings":           tx.Postings,
+			"metadata":           tx.Metadata,
+			"amount":             amount,
+			"destination_amount": destinationAmount,
+			"balance_before":     walletVolumeBalance(preCommitVolumes, accountSource, sourceAsset),
+			"balance_after":      walletVolumeBalance(tx.PostCommitVolumes, accountSource, sourceAsset),
+		}
+		if pnlErr != nil {
+			// Left for reconciliation: the caller can retry the transfer to
+			// book the missing P&L.
+			ret["fx_pnl_error"] = pnlErr.Error()
+		}
+		api.Created(w, ret)
+	}
+}
+
+func walletVolumeBalance(volumes ledgerinternal.PostCommitVolumes, account, asset string) int64 {
+	if vol, ok := volumes[account]; ok {
+		if v, ok := vol[asset]; ok {
+			return new(big.Int).Sub(v.Input, v.Output).Int64()
+		}
+	}
+	return 0
+}
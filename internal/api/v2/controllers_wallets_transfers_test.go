@@ -0,0 +1,184 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/formancehq/go-libs/v3/api"
+	"github.com/formancehq/go-libs/v3/auth"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	"github.com/formancehq/go-libs/v3/pointer"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	ledger "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/api/common"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	exchangeservices "github.com/formancehq/ledger/internal/exchange/services"
+)
+
+func TestTransferWallet(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		name                 string
+		walletID             string
+		payload              WalletTransferRequest
+		expectedStatusCode   int
+		expectedErrorCode    string
+		expectControllerCall bool
+		expectedScript       string
+	}
+
+	testCases := []testCase{
+		{
+			name:     "same currency",
+			walletID: "user123-USD",
+			payload: WalletTransferRequest{
+				DestinationWalletID: "user456-USD",
+				Amount:              testJSONNumber("12.50"),
+				Reference:           "p2p-1",
+			},
+			expectedStatusCode:   http.StatusCreated,
+			expectControllerCall: true,
+			expectedScript: `
+		send [USD/2 1250] (
+			source = @users:user123:wallets:USD:available
+			destination = @users:user456:wallets:USD:available
+		)
+	`,
+		},
+		{
+			name:     "with fx leg",
+			walletID: "user123-USD",
+			payload: WalletTransferRequest{
+				DestinationWalletID: "user456-JPY",
+				Amount:              testJSONNumber("10"),
+				Reference:           "p2p-2",
+				FXRate:              testJSONNumber("149.555"),
+			},
+			expectedStatusCode:   http.StatusCreated,
+			expectControllerCall: true,
+			expectedScript: `
+		send [USD/2 10] (
+			source = @users:user123:wallets:USD:available
+			destination = @system:fx:USD_JPY
+		)
+		send [JPY/0 14] (
+			source = @system:fx:USD_JPY allowing unbounded overdraft
+			destination = @users:user456:wallets:JPY:available
+		)
+	`,
+		},
+		{
+			name:     "missing fx rate",
+			walletID: "user123-USD",
+			payload: WalletTransferRequest{
+				DestinationWalletID: "user456-EUR",
+				Amount:              testJSONNumber("100"),
+				Reference:           "p2p-3",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+		{
+			name:     "same wallet",
+			walletID: "user123-USD",
+			payload: WalletTransferRequest{
+				DestinationWalletID: "user123-USD",
+				Amount:              testJSONNumber("100"),
+				Reference:           "p2p-4",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+		{
+			name:     "missing reference",
+			walletID: "user123-USD",
+			payload: WalletTransferRequest{
+				DestinationWalletID: "user456-USD",
+				Amount:              testJSONNumber("100"),
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedErrorCode:  common.ErrValidation,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			systemController, ledgerController := newTestingSystemController(t, true)
+			// Conversions first look for an earlier attempt of the transfer.
+			ledgerController.EXPECT().
+				GetTransaction(gomock.Any(), gomock.Any()).
+				Return(nil, postgres.ErrNotFound).
+				AnyTimes()
+
+			if tc.expectControllerCall {
+				ledgerController.EXPECT().
+					CreateTransaction(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledger.Log, *ledger.CreatedTransaction, bool, error) {
+						require.Equal(t, tc.payload.Reference, params.Input.RunScript.Reference)
+						require.Equal(t, "wallet-transfer:"+tc.walletID+":"+tc.payload.Reference, params.IdempotencyKey)
+						require.Equal(t, strings.TrimSpace(tc.expectedScript), strings.TrimSpace(params.Input.RunScript.Script.Plain))
+						return &ledger.Log{}, &ledger.CreatedTransaction{
+							Transaction: ledger.NewTransaction().WithID(1),
+						}, false, nil
+					})
+			}
+
+			router := NewRouter(systemController, auth.NewNoAuth(), "develop")
+
+			req := httptest.NewRequest(http.MethodPost, "/test/wallets/"+tc.walletID+"/transfer", api.Buffer(t, tc.payload))
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedStatusCode, rec.Code)
+			if tc.expectedErrorCode != "" {
+				err := api.ErrorResponse{}
+				api.Decode(t, rec.Body, &err)
+				require.EqualValues(t, tc.expectedErrorCode, err.ErrorCode)
+			}
+		})
+	}
+}
+
+type failingPnLConversionServiceStub struct {
+	exchangeservices.ConversionService
+}
+
+func (failingPnLConversionServiceStub) Convert(_ context.Context, input exchangeservices.ConvertInput) (*exchangeservices.ConversionResult, error) {
+	return &exchangeservices.ConversionResult{
+		Quote:       &exchangeservices.Quote{From: input.From, To: input.To, Amount: input.Amount, ConvertedAmount: 9154},
+		Transaction: pointer.For(ledger.NewTransaction().WithID(1)),
+	}, errors.New("reporting fx pnl: ledger unavailable")
+}
+
+func TestTransferWalletReportsFailedPnL(t *testing.T) {
+	t.Parallel()
+
+	systemController, _ := newTestingSystemController(t, true)
+	router := NewRouter(systemController, auth.NewNoAuth(), "develop",
+		WithFXConversionService(failingPnLConversionServiceStub{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/test/wallets/user123-USD/transfer", api.Buffer(t, WalletTransferRequest{
+		DestinationWalletID: "user456-EUR",
+		Amount:              testJSONNumber("100"),
+		Reference:           "p2p-5",
+	}))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	// The transfer is booked: the failed P&L is reported for reconciliation.
+	require.Equal(t, http.StatusCreated, rec.Code)
+	response, _ := api.DecodeSingleResponse[map[string]any](t, rec.Body)
+	require.EqualValues(t, 9154, response["destination_amount"])
+	require.Equal(t, "reporting fx pnl: ledger unavailable", response["fx_pnl_error"])
+}
@@ -14,9 +14,13 @@ import (
 	"github.com/formancehq/ledger/internal/api/bulking"
 	"github.com/formancehq/ledger/internal/api/common"
 	v1 "github.com/formancehq/ledger/internal/api/v1"
+	"github.com/formancehq/ledger/internal/cba/models"
 	"github.com/formancehq/ledger/internal/cba/services"
 	channelservices "github.com/formancehq/ledger/internal/channels/services"
 	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	exchangeservices "github.com/formancehq/ledger/internal/exchange/services"
+	walletservices "github.com/formancehq/ledger/internal/wallets/services"
 )
 
 // NewRouter creates a chi.Router configured with the v2 HTTP API routes for the ledger service.
@@ -38,10 +42,36 @@ func NewRouter(
 		opt(&routerOptions)
 	}
 
+	debitSagaCoordinator := routerOptions.debitSagaCoordinator
+	if debitSagaCoordinator == nil {
+		debitSagaCoordinator = walletservices.NewDebitSagaCoordinator(systemController, nil)
+	}
+	fxConversionService := routerOptions.fxConversionService
+	if fxConversionService == nil {
+		fxConversionService = exchangeservices.NewConversionService(systemController, routerOptions.fxRateService)
+	}
+
 	router := chi.NewMux()
+	// Approved operations are executed by replaying their call through the
+	// whole router.
+	executor := http.Handler(router)
+
+	requireAccountClose := requireApproval(routerOptions.approvalService, models.ApprovalOperationAccountClose, accountApprovalSubject(routerOptions.accountService))
+	requireAccountDebit := requireApproval(routerOptions.approvalService, models.ApprovalOperationAccountDebit, accountDebitApprovalSubject(routerOptions.accountService))
+	requireFeeWaive := requireApproval(routerOptions.approvalService, models.ApprovalOperationFeeWaive, feeWaiveApprovalSubject(routerOptions.feeService, routerOptions.accountService))
+	requireProductActivate := requireApproval(routerOptions.approvalService, models.ApprovalOperationProductActivate, productApprovalSubject(routerOptions.productService))
+	requireAccountScreening := requireScreening(routerOptions.screeningService, routerOptions.accountService)
+	requireWalletTransactionScreening := requireWalletScreening(routerOptions.screeningService, routerOptions.accountService)
+
+	if routerOptions.kycService != nil {
+		// Providers authenticate their callbacks by signing them, they do
+		// not hold API credentials.
+		router.Post("/_/cba/kyc/callbacks", handleKYCCallback(routerOptions.kycService))
+	}
 
 	router.Group(func(router chi.Router) {
 		router.Use(auth.Middleware(authenticator))
+		router.Use(resolveActor(authenticator, routerOptions.trustActorHeader))
 
 		router.Get("/_info", v1.GetInfo(systemController, version))
 
@@ -55,6 +85,86 @@ func NewRouter(
 					router.Post("/", createExporter(systemController))
 				})
 			}
+			if routerOptions.currencyAdminService != nil {
+				router.Route("/currencies", func(router chi.Router) {
+					router.Get("/", listRegisteredCurrencies(routerOptions.currencyAdminService))
+					router.Post("/", createCurrency(routerOptions.currencyAdminService))
+					router.Get("/audits", listCurrencyAudits(routerOptions.currencyAdminService))
+					router.Route("/{code}", func(router chi.Router) {
+						router.Get("/", readRegisteredCurrency(routerOptions.currencyAdminService))
+						router.Patch("/", updateCurrency(routerOptions.currencyAdminService))
+						router.Get("/audits", listCurrencyAudits(routerOptions.currencyAdminService))
+					})
+				})
+			}
+			if routerOptions.fxRateService != nil {
+				router.Route("/fx/rates", func(router chi.Router) {
+					router.Get("/", listFXRates(routerOptions.fxRateService))
+					router.Post("/", publishFXRate(routerOptions.fxRateService))
+					router.Post("/import", importFXRates(routerOptions.fxRateService))
+					router.Get("/audits", listFXRateAudits(routerOptions.fxRateService))
+					router.Post("/{rateID}/revoke", revokeFXRate(routerOptions.fxRateService))
+				})
+			}
+			if routerOptions.jobService != nil {
+				router.Route("/cba/jobs", func(router chi.Router) {
+					router.Get("/runs", listCBAJobRuns(routerOptions.jobService))
+					router.Get("/runs/{runID}", readCBAJobRun(routerOptions.jobService))
+					router.Get("/leases", listCBAJobLeases(routerOptions.jobService))
+					router.Post("/{job}/runs", triggerCBAJob(routerOptions.jobService))
+				})
+			}
+			if routerOptions.feeService != nil {
+				router.Route("/cba/fees", func(router chi.Router) {
+					router.Get("/", listCBAFees(routerOptions.feeService))
+					router.Route("/{feeID}", func(router chi.Router) {
+						router.Get("/", readCBAFee(routerOptions.feeService))
+						router.Post("/waive", requireFeeWaive(waiveCBAFee(routerOptions.feeService)))
+						router.Post("/write-off", writeOffCBAFee(routerOptions.feeService, systemController))
+					})
+				})
+			}
+			if routerOptions.approvalService != nil {
+				router.Route("/cba/approvals", func(router chi.Router) {
+					router.Get("/", listCBAApprovals(routerOptions.approvalService))
+					router.Route("/policies", func(router chi.Router) {
+						router.Get("/", listCBAApprovalPolicies(routerOptions.approvalService))
+						router.Post("/", createCBAApprovalPolicy(routerOptions.approvalService))
+						router.Patch("/{policyID}", updateCBAApprovalPolicy(routerOptions.approvalService))
+					})
+					router.Route("/{approvalID}", func(router chi.Router) {
+						router.Get("/", readCBAApproval(routerOptions.approvalService))
+						router.Get("/events", listCBAApprovalEvents(routerOptions.approvalService))
+						router.Post("/approve", approveCBAApproval(routerOptions.approvalService, executor))
+						router.Post("/execute", executeCBAApproval(routerOptions.approvalService, executor))
+						router.Post("/reject", rejectCBAApproval(routerOptions.approvalService))
+					})
+				})
+			}
+			if routerOptions.screeningService != nil {
+				router.Route("/cba/screening/cases", func(router chi.Router) {
+					router.Get("/", listCBAScreeningCases(routerOptions.screeningService))
+					router.Route("/{caseID}", func(router chi.Router) {
+						router.Get("/", readCBAScreeningCase(routerOptions.screeningService))
+						router.Post("/clear", clearCBAScreeningCase(routerOptions.screeningService))
+						router.Post("/confirm", confirmCBAScreeningCase(routerOptions.screeningService))
+					})
+				})
+			}
+			if routerOptions.monitoringService != nil {
+				router.Route("/cba/monitoring", func(router chi.Router) {
+					router.Get("/rules", listCBAMonitoringRules(routerOptions.monitoringService))
+					router.Route("/alerts", func(router chi.Router) {
+						router.Get("/", listCBAMonitoringAlerts(routerOptions.monitoringService))
+						router.Route("/{alertID}", func(router chi.Router) {
+							router.Get("/", readCBAMonitoringAlert(routerOptions.monitoringService))
+							router.Post("/assign", assignCBAMonitoringAlert(routerOptions.monitoringService))
+							router.Post("/escalate", escalateCBAMonitoringAlert(routerOptions.monitoringService))
+							router.Post("/close", closeCBAMonitoringAlert(routerOptions.monitoringService))
+						})
+					})
+				})
+			}
 			router.Route("/buckets", func(router chi.Router) {
 				router.Delete("/{bucket}", deleteBucket(systemController))
 				router.Post("/{bucket}/restore", restoreBucket(systemController))
@@ -124,16 +234,28 @@ func NewRouter(
 							router.Get("/balance", ledgertrackOnly(getAccountBalance(routerOptions.accountService)))
 							router.Get("/history", ledgertrackOnly(getAccountHistory(routerOptions.accountService)))
 							router.Get("/statement", ledgertrackOnly(getAccountStatement(routerOptions.accountService)))
-							router.Post("/credit", ledgertrackOnly(creditAccount(routerOptions.accountService)))
-							router.Post("/debit", ledgertrackOnly(debitAccount(routerOptions.accountService, systemController)))
-							router.Post("/lien", ledgertrackOnly(lienAccount(routerOptions.accountService)))
+							router.Post("/credit", ledgertrackOnly(requireAccountScreening(creditAccount(routerOptions.accountService))))
+							router.Post("/debit", ledgertrackOnly(requireAccountScreening(requireAccountDebit(debitAccount(routerOptions.accountService, routerOptions.accountHolderService, routerOptions.feeService, systemController)))))
+							router.Post("/lien", ledgertrackOnly(lienAccount(routerOptions.accountService, routerOptions.accountHolderService)))
 							router.Post("/lien/release", ledgertrackOnly(releaseAccountLien(routerOptions.accountService, systemController)))
 							router.Post("/activate", ledgertrackOnly(activateAccount(routerOptions.accountService)))
 							router.Post("/suspend", ledgertrackOnly(suspendAccount(routerOptions.accountService)))
 							router.Post("/freeze", ledgertrackOnly(freezeAccount(routerOptions.accountService)))
 							router.Post("/dormant", ledgertrackOnly(dormantAccount(routerOptions.accountService)))
 							router.Post("/reactivate", ledgertrackOnly(reactivateAccount(routerOptions.accountService)))
-							router.Post("/close", ledgertrackOnly(closeAccount(routerOptions.accountService)))
+							router.Post("/close", ledgertrackOnly(requireAccountClose(closeAccount(routerOptions.accountService, routerOptions.feeService))))
+							if routerOptions.accountHolderService != nil {
+								router.Post("/holders", ledgertrackOnly(addAccountHolder(routerOptions.accountHolderService)))
+								router.Delete("/holders/{clientID}", ledgertrackOnly(removeAccountHolder(routerOptions.accountHolderService)))
+								router.Post("/signing-rule", ledgertrackOnly(setAccountSigningRule(routerOptions.accountHolderService)))
+							}
+							if routerOptions.termDepositService != nil {
+								router.Post("/term/rollover", ledgertrackOnly(setTermDepositRollover(routerOptions.termDepositService)))
+								router.Post("/term/break", ledgertrackOnly(breakTermDeposit(routerOptions.accountService, routerOptions.termDepositService)))
+							}
+							if routerOptions.loanService != nil {
+								router.Get("/loan/schedule", ledgertrackOnly(getLoanSchedule(routerOptions.loanService)))
+							}
 						} else {
 							router.Get("/", readAccount)
 						}
@@ -156,15 +278,68 @@ func NewRouter(
 
 				router.Get("/volumes", readVolumes(routerOptions.paginationConfig))
 				router.Get("/currencies", listCurrencies())
+				router.Post("/currencies/{currency}/rebase", rebaseCurrency)
+				router.Get("/fx/quote", quoteFX(fxConversionService))
 
 				router.Route("/wallets", func(router chi.Router) {
-					router.Post("/", createWallet(systemController))
+					router.Post("/", createWallet(routerOptions.walletService))
+					if routerOptions.walletService != nil {
+						router.Get("/", listWallets(routerOptions.walletService))
+					}
 					router.Get("/balances", getWalletBalances(systemController))
+					if routerOptions.debitSagaCoordinator != nil {
+						router.Route("/sagas", func(router chi.Router) {
+							router.Get("/", listWalletDebitSagas(routerOptions.debitSagaCoordinator))
+							router.Route("/{sagaID}", func(router chi.Router) {
+								router.Get("/", readWalletDebitSaga(routerOptions.debitSagaCoordinator))
+								router.Post("/resume", resumeWalletDebitSaga(routerOptions.debitSagaCoordinator))
+								router.Post("/compensate", compensateWalletDebitSaga(routerOptions.debitSagaCoordinator))
+							})
+						})
+					}
 					router.Route("/{walletID}", func(router chi.Router) {
-						router.Post("/credit", creditWallet(systemController))
-						router.Post("/debit", debitWallet(systemController, routerOptions.channelFeeConfigService))
-						router.Post("/lien", lienWallet(systemController))
-						router.Post("/lien/release", releaseLien(systemController, routerOptions.channelFeeConfigService))
+						requireCredit := requireWallet(routerOptions.walletService, walletservices.WalletOperationCredit)
+						requireDebit := requireWallet(routerOptions.walletService, walletservices.WalletOperationDebit)
+
+						if routerOptions.walletService != nil {
+							router.Get("/", readWallet(routerOptions.walletService))
+							router.Put("/labels", updateWalletLabels(routerOptions.walletService))
+							router.Post("/freeze", freezeWallet(routerOptions.walletService))
+							router.Post("/unfreeze", unfreezeWallet(routerOptions.walletService))
+							router.Post("/close", closeWallet(routerOptions.walletService))
+						}
+						router.With(requireCredit).Post("/credit", creditWallet(systemController))
+						router.With(requireDebit).Post("/debit", requireWalletTransactionScreening(debitWallet(debitSagaCoordinator, routerOptions.channelFeeConfigService)))
+						router.With(requireDebit).Post("/transfer", requireWalletTransactionScreening(transferWallet(fxConversionService, routerOptions.walletService)))
+						if routerOptions.standingInstructionService != nil {
+							router.Route("/standing-instructions", func(router chi.Router) {
+								router.Get("/", listWalletStandingInstructions(routerOptions.standingInstructionService))
+								router.With(requireDebit).Post("/", createWalletStandingInstruction(routerOptions.standingInstructionService))
+								router.Route("/{instructionID}", func(router chi.Router) {
+									router.Get("/", readWalletStandingInstruction(routerOptions.standingInstructionService))
+									router.Patch("/", updateWalletStandingInstruction(routerOptions.standingInstructionService))
+									router.Post("/pause", pauseWalletStandingInstruction(routerOptions.standingInstructionService))
+									router.Post("/resume", resumeWalletStandingInstruction(routerOptions.standingInstructionService))
+									router.Post("/cancel", cancelWalletStandingInstruction(routerOptions.standingInstructionService))
+									router.Get("/executions", listWalletStandingInstructionExecutions(routerOptions.standingInstructionService))
+								})
+							})
+						}
+						if routerOptions.lienService != nil {
+							router.With(requireDebit).Post("/lien", placeWalletLien(routerOptions.lienService))
+							router.Route("/liens", func(router chi.Router) {
+								router.Get("/", listWalletLiens(routerOptions.lienService))
+								router.Route("/{lienID}", func(router chi.Router) {
+									router.Get("/", readWalletLien(routerOptions.lienService))
+									router.With(requireDebit).Post("/top-up", topUpWalletLien(routerOptions.lienService))
+									router.With(requireDebit).Post("/capture", captureWalletLien(routerOptions.lienService))
+									router.Post("/release", releaseWalletLien(routerOptions.lienService))
+								})
+							})
+						} else {
+							router.With(requireDebit).Post("/lien", lienWallet(systemController))
+						}
+						router.With(requireDebit).Post("/lien/release", releaseLien(debitSagaCoordinator, routerOptions.channelFeeConfigService))
 						router.Get("/statement", getWalletStatement(systemController))
 						router.Get("/history", getWalletHistory(systemController))
 					})
@@ -192,12 +367,14 @@ func NewRouter(
 				if routerOptions.productService != nil {
 					router.Route("/products", func(router chi.Router) {
 						router.Post("/", createProduct(routerOptions.productService))
-						router.Get("/", listProducts(routerOptions.productService))
+						router.Get("/", listProducts(routerOptions.productService, routerOptions.paginationConfig))
 						router.Route("/{productID}", func(router chi.Router) {
 							router.Get("/", readProduct(routerOptions.productService))
 							router.Patch("/", patchProduct(routerOptions.productService))
-							router.Post("/activate", activateProduct(routerOptions.productService))
+							router.Post("/activate", requireProductActivate(activateProduct(routerOptions.productService)))
 							router.Post("/retire", retireProduct(routerOptions.productService))
+							router.Get("/interest-rates", listProductInterestRates(routerOptions.productService))
+							router.Post("/interest-rates", changeProductInterestRate(routerOptions.productService))
 						})
 					})
 				}
@@ -205,7 +382,7 @@ func NewRouter(
 				if routerOptions.clientService != nil && routerOptions.kycService != nil {
 					router.Route("/clients", func(router chi.Router) {
 						router.Post("/", createClient(routerOptions.clientService))
-						router.Get("/", listClients(routerOptions.clientService))
+						router.Get("/", listClients(routerOptions.clientService, routerOptions.paginationConfig))
 						router.Route("/{clientID}", func(router chi.Router) {
 							router.Get("/", readClient(routerOptions.clientService))
 							if routerOptions.accountService != nil {
@@ -222,6 +399,7 @@ func NewRouter(
 								router.Route("/{kycID}", func(router chi.Router) {
 									router.Post("/verify", verifyKYC(routerOptions.kycService))
 									router.Post("/reject", rejectKYC(routerOptions.kycService))
+									router.Post("/refresh", refreshKYC(routerOptions.kycService))
 								})
 							})
 						})
@@ -235,6 +413,7 @@ func NewRouter(
 							router.Get("/accounts/{address}/statement", ledgertrackOnly(getAccountStatementReport(routerOptions.reportingService)))
 							router.Get("/transactions/daily", ledgertrackOnly(getDailyTransactionSummaryReport(routerOptions.reportingService)))
 							router.Get("/interest-fees", ledgertrackOnly(getInterestFeeReport(routerOptions.reportingService)))
+							router.Get("/withholding-tax", ledgertrackOnly(getWithholdingTaxReport(routerOptions.reportingService)))
 						}
 						if routerOptions.financeReportingService != nil {
 							router.Route("/finance", func(router chi.Router) {
@@ -268,6 +447,7 @@ type routerOptions struct {
 	bulkHandlerFactories           map[string]bulking.HandlerFactory
 	paginationConfig               common.PaginationConfig
 	exporters                      bool
+	trustActorHeader               bool
 	productService                 services.ProductService
 	clientService                  services.ClientService
 	kycService                     services.KYCService
@@ -276,6 +456,21 @@ type routerOptions struct {
 	financeReportingService        services.FinanceReportingService
 	channelFeeConfigService        channelservices.ChannelFeeConfigService
 	channelRevenueReportingService channelservices.ChannelRevenueReportingService
+	debitSagaCoordinator           walletservices.DebitSagaCoordinator
+	lienService                    walletservices.LienService
+	fxRateService                  exchangeservices.RateService
+	fxConversionService            exchangeservices.ConversionService
+	currencyAdminService           currencyregistry.AdminService
+	walletService                  walletservices.WalletService
+	standingInstructionService     walletservices.StandingInstructionService
+	jobService                     services.JobService
+	termDepositService             services.TermDepositService
+	loanService                    services.LoanService
+	feeService                     services.FeeService
+	accountHolderService           services.AccountHolderService
+	approvalService                services.ApprovalService
+	screeningService               services.ScreeningService
+	monitoringService              services.MonitoringService
 }
 
 type RouterOption func(ro *routerOptions)
@@ -310,6 +505,15 @@ func WithExporters(v bool) RouterOption {
 	}
 }
 
+// WithTrustActorHeader identifies the callers of an API without
+// authentication by the HeaderActor header. It is meant for development only,
+// any caller being able to claim to be anyone.
+func WithTrustActorHeader(v bool) RouterOption {
+	return func(ro *routerOptions) {
+		ro.trustActorHeader = v
+	}
+}
+
 func WithProductService(productService services.ProductService) RouterOption {
 	return func(ro *routerOptions) {
 		ro.productService = productService
@@ -358,6 +562,96 @@ func WithChannelRevenueReportingService(channelRevenueReportingService channelse
 	}
 }
 
+func WithDebitSagaCoordinator(debitSagaCoordinator walletservices.DebitSagaCoordinator) RouterOption {
+	return func(ro *routerOptions) {
+		ro.debitSagaCoordinator = debitSagaCoordinator
+	}
+}
+
+func WithLienService(lienService walletservices.LienService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.lienService = lienService
+	}
+}
+
+func WithFXRateService(fxRateService exchangeservices.RateService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.fxRateService = fxRateService
+	}
+}
+
+func WithFXConversionService(fxConversionService exchangeservices.ConversionService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.fxConversionService = fxConversionService
+	}
+}
+
+func WithCurrencyAdminService(currencyAdminService currencyregistry.AdminService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.currencyAdminService = currencyAdminService
+	}
+}
+
+func WithWalletService(walletService walletservices.WalletService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.walletService = walletService
+	}
+}
+
+func WithStandingInstructionService(standingInstructionService walletservices.StandingInstructionService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.standingInstructionService = standingInstructionService
+	}
+}
+
+func WithJobService(jobService services.JobService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.jobService = jobService
+	}
+}
+
+func WithTermDepositService(termDepositService services.TermDepositService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.termDepositService = termDepositService
+	}
+}
+
+func WithAccountHolderService(accountHolderService services.AccountHolderService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.accountHolderService = accountHolderService
+	}
+}
+
+func WithApprovalService(approvalService services.ApprovalService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.approvalService = approvalService
+	}
+}
+
+func WithScreeningService(screeningService services.ScreeningService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.screeningService = screeningService
+	}
+}
+
+func WithMonitoringService(monitoringService services.MonitoringService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.monitoringService = monitoringService
+	}
+}
+
+func WithLoanService(loanService services.LoanService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.loanService = loanService
+	}
+}
+
+func WithFeeService(feeService services.FeeService) RouterOption {
+	return func(ro *routerOptions) {
+		ro.feeService = feeService
+	}
+}
+
 func WithDefaultBulkHandlerFactories(bulkMaxSize int) RouterOption {
 	return WithBulkHandlerFactories(map[string]bulking.HandlerFactory{
 		"application/json": bulking.NewJSONBulkHandlerFactory(bulkMaxSize),
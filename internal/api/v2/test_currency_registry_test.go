@@ -11,5 +11,7 @@ func init() {
 		"GHS": {Precision: 2, Enabled: true},
 		"KES": {Precision: 2, Enabled: true},
 		"ZMW": {Precision: 2, Enabled: true},
+		"JPY": {Precision: 0, Enabled: true},
+		"KWD": {Precision: 3, Enabled: true},
 	})
 }
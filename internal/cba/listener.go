@@ -0,0 +1,248 @@
+package cba
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
+	"github.com/formancehq/go-libs/v3/logging"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	"github.com/formancehq/go-libs/v3/pointer"
+	"github.com/formancehq/go-libs/v3/query"
+
+	ledger "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	storagecommon "github.com/formancehq/ledger/internal/storage/common"
+	systemstore "github.com/formancehq/ledger/internal/storage/system"
+)
+
+const (
+	monitoringQueueSize      = 1024
+	monitoringReplayPageSize = 100
+	// MonitoringReplayInterval is the delay between two replays of the
+	// ledger logs by the MonitoringReplayer.
+	MonitoringReplayInterval = time.Minute
+)
+
+type monitoredTransaction struct {
+	ledger      string
+	transaction ledger.Transaction
+}
+
+// MonitoringListener passes the committed transactions to the monitoring
+// service before forwarding every event to the listener it decorates.
+// Transactions are monitored in the background so that the rules never slow
+// down or fail a commit. Those dropped because the queue is full, or still
+// queued when the process stops, are monitored by the MonitoringReplayer.
+type MonitoringListener struct {
+	ledgercontroller.Listener
+
+	stopChannel       chan chan struct{}
+	queue             chan monitoredTransaction
+	logger            logging.Logger
+	monitoringService services.MonitoringService
+}
+
+var _ ledgercontroller.Listener = &MonitoringListener{}
+
+func NewMonitoringListener(listener ledgercontroller.Listener, logger logging.Logger, monitoringService services.MonitoringService) *MonitoringListener {
+	return &MonitoringListener{
+		Listener:          listener,
+		stopChannel:       make(chan chan struct{}),
+		queue:             make(chan monitoredTransaction, monitoringQueueSize),
+		logger:            logger,
+		monitoringService: monitoringService,
+	}
+}
+
+func (l *MonitoringListener) CommittedTransactions(ctx context.Context, name string, transaction ledger.Transaction, accountMetadata ledger.AccountMetadata) {
+	select {
+	case l.queue <- monitoredTransaction{ledger: name, transaction: transaction}:
+	default:
+		l.logger.Errorf("monitoring queue is full, transaction %d of ledger %s will be monitored on replay", *transaction.ID, name)
+	}
+	l.Listener.CommittedTransactions(ctx, name, transaction, accountMetadata)
+}
+
+func (l *MonitoringListener) Run(ctx context.Context) {
+	for {
+		select {
+		case ch := <-l.stopChannel:
+			close(ch)
+			return
+		case monitored := <-l.queue:
+			alerts, err := l.monitoringService.Monitor(ctx, monitored.ledger, monitored.transaction)
+			if err != nil {
+				l.logger.Errorf("monitoring transaction %d of ledger %s: %v", *monitored.transaction.ID, monitored.ledger, err)
+				continue
+			}
+			for _, alert := range alerts {
+				l.logger.Infof("monitoring alert %s raised by rule %s on account %s", alert.ID, alert.Rule, alert.AccountID)
+			}
+		}
+	}
+}
+
+func (l *MonitoringListener) Stop(ctx context.Context) error {
+	ch := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case l.stopChannel <- ch:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+	return nil
+}
+
+// MonitoringReplayer follows the log of every ledger from a cursor saved in
+// the database and monitors the transactions it finds, so that none is lost
+// by the MonitoringListener. Monitoring a transaction twice is a no-op.
+// Ledgers are followed from their last log when first seen.
+type MonitoringReplayer struct {
+	stopChannel       chan chan struct{}
+	logger            logging.Logger
+	system            systemcontroller.Controller
+	monitoringService services.MonitoringService
+	cursorRepository  repositories.MonitoringCursorRepository
+	interval          time.Duration
+}
+
+func NewMonitoringReplayer(
+	logger logging.Logger,
+	system systemcontroller.Controller,
+	monitoringService services.MonitoringService,
+	cursorRepository repositories.MonitoringCursorRepository,
+	interval time.Duration,
+) *MonitoringReplayer {
+	return &MonitoringReplayer{
+		stopChannel:       make(chan chan struct{}),
+		logger:            logger,
+		system:            system,
+		monitoringService: monitoringService,
+		cursorRepository:  cursorRepository,
+		interval:          interval,
+	}
+}
+
+func (r *MonitoringReplayer) Run(ctx context.Context) {
+	for {
+		if err := r.Replay(ctx); err != nil {
+			r.logger.Errorf("replaying monitoring: %v", err)
+		}
+		select {
+		case ch := <-r.stopChannel:
+			close(ch)
+			return
+		case <-time.After(r.interval):
+		}
+	}
+}
+
+// Replay monitors the transactions logged by every ledger since its cursor.
+// A ledger failing to be replayed is retried on the next replay.
+func (r *MonitoringReplayer) Replay(ctx context.Context) error {
+	return storagecommon.Iterate(ctx, storagecommon.InitialPaginatedQuery[systemstore.ListLedgersQueryPayload]{
+		PageSize: monitoringReplayPageSize,
+	},
+		r.system.ListLedgers,
+		func(cursor *bunpaginate.Cursor[ledger.Ledger]) error {
+			for _, l := range cursor.Data {
+				if err := r.replayLedger(ctx, l.Name); err != nil {
+					r.logger.Errorf("replaying monitoring of ledger %s: %v", l.Name, err)
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (r *MonitoringReplayer) replayLedger(ctx context.Context, name string) error {
+	l, err := r.system.GetLedgerController(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := r.cursorRepository.Get(ctx, name)
+	if err != nil {
+		if !postgres.IsNotFoundError(err) {
+			return err
+		}
+		last, err := l.ListLogs(ctx, storagecommon.InitialPaginatedQuery[any]{
+			PageSize: 1,
+			Column:   "id",
+			Order:    pointer.For(bunpaginate.Order(bunpaginate.OrderDesc)),
+		})
+		if err != nil {
+			return err
+		}
+		cursor = &models.MonitoringCursor{Ledger: name}
+		if len(last.Data) > 0 {
+			cursor.LastLogID = last.Data[0].ID
+		}
+		return r.cursorRepository.Save(ctx, cursor)
+	}
+
+	for {
+		var builder query.Builder
+		if cursor.LastLogID != nil {
+			builder = query.Gt("id", *cursor.LastLogID)
+		}
+		logs, err := l.ListLogs(ctx, storagecommon.InitialPaginatedQuery[any]{
+			PageSize: monitoringReplayPageSize,
+			Column:   "id",
+			Options: storagecommon.ResourceQuery[any]{
+				Builder: builder,
+			},
+			Order: pointer.For(bunpaginate.Order(bunpaginate.OrderAsc)),
+		})
+		if err != nil {
+			return err
+		}
+		if len(logs.Data) == 0 {
+			return nil
+		}
+
+		for _, log := range logs.Data {
+			if created, ok := log.Data.(ledger.CreatedTransaction); ok {
+				if _, err := r.monitoringService.Monitor(ctx, name, created.Transaction); err != nil {
+					// Save the progress made so far.
+					if saveErr := r.cursorRepository.Save(ctx, cursor); saveErr != nil {
+						r.logger.Errorf("saving monitoring cursor of ledger %s: %v", name, saveErr)
+					}
+					return fmt.Errorf("monitoring transaction %d: %w", *created.Transaction.ID, err)
+				}
+			}
+			cursor.LastLogID = log.ID
+		}
+		if err := r.cursorRepository.Save(ctx, cursor); err != nil {
+			return err
+		}
+		if !logs.HasMore {
+			return nil
+		}
+	}
+}
+
+func (r *MonitoringReplayer) Stop(ctx context.Context) error {
+	ch := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case r.stopChannel <- ch:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+	return nil
+}
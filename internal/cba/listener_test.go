@@ -0,0 +1,152 @@
+package cba
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
+	"github.com/formancehq/go-libs/v3/logging"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	ledger "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	storagecommon "github.com/formancehq/ledger/internal/storage/common"
+	systemstore "github.com/formancehq/ledger/internal/storage/system"
+)
+
+func TestMonitoringReplayerReplaysMissedTransactions(t *testing.T) {
+	t.Parallel()
+
+	logs := []ledger.Log{
+		newTransactionLog(0, 0),
+		newTransactionLog(1, 1),
+	}
+	ledgerController := &logLedgerControllerStub{logs: func() []ledger.Log { return logs }}
+	system := &replayerSystemControllerStub{ledgerController: ledgerController}
+	cursors := &monitoringCursorRepositoryStub{cursors: map[string]models.MonitoringCursor{}}
+
+	var monitored []uint64
+	var monitorErr error
+	monitoring := &replayerMonitoringServiceStub{
+		monitorFunc: func(_ context.Context, name string, transaction ledger.Transaction) ([]models.MonitoringAlert, error) {
+			require.Equal(t, "default", name)
+			if monitorErr != nil {
+				return nil, monitorErr
+			}
+			monitored = append(monitored, *transaction.ID)
+			return nil, nil
+		},
+	}
+	replayer := NewMonitoringReplayer(logging.Testing(), system, monitoring, cursors, MonitoringReplayInterval)
+
+	// A ledger seen for the first time is followed from its last log.
+	require.NoError(t, replayer.Replay(context.Background()))
+	require.Empty(t, monitored)
+	require.EqualValues(t, 1, *cursors.cursors["default"].LastLogID)
+
+	// Transactions committed meanwhile are monitored, whatever the listener did.
+	logs = append(logs,
+		newTransactionLog(2, 2),
+		ledger.NewLog(ledger.SavedMetadata{TargetType: ledger.MetaTargetTypeAccount, TargetID: "world"}).WithID(3),
+		newTransactionLog(4, 3),
+	)
+	require.NoError(t, replayer.Replay(context.Background()))
+	require.Equal(t, []uint64{2, 3}, monitored)
+	require.EqualValues(t, 4, *cursors.cursors["default"].LastLogID)
+
+	// A transaction failing to be monitored is retried on the next replay.
+	logs = append(logs, newTransactionLog(5, 4))
+	monitorErr = errors.New("database unavailable")
+	require.NoError(t, replayer.Replay(context.Background()))
+	require.EqualValues(t, 4, *cursors.cursors["default"].LastLogID)
+
+	monitorErr = nil
+	require.NoError(t, replayer.Replay(context.Background()))
+	require.Equal(t, []uint64{2, 3, 4}, monitored)
+	require.EqualValues(t, 5, *cursors.cursors["default"].LastLogID)
+}
+
+func newTransactionLog(id, transactionID uint64) ledger.Log {
+	return ledger.NewLog(ledger.CreatedTransaction{
+		Transaction: ledger.NewTransaction().WithID(transactionID),
+	}).WithID(id)
+}
+
+type replayerSystemControllerStub struct {
+	systemcontroller.Controller
+	ledgerController ledgercontroller.Controller
+}
+
+func (s *replayerSystemControllerStub) ListLedgers(context.Context, storagecommon.PaginatedQuery[systemstore.ListLedgersQueryPayload]) (*bunpaginate.Cursor[ledger.Ledger], error) {
+	return &bunpaginate.Cursor[ledger.Ledger]{Data: []ledger.Ledger{{Name: "default"}}}, nil
+}
+
+func (s *replayerSystemControllerStub) GetLedgerController(context.Context, string) (ledgercontroller.Controller, error) {
+	return s.ledgerController, nil
+}
+
+// logLedgerControllerStub serves the logs in the order asked, after the id
+// the query starts from.
+type logLedgerControllerStub struct {
+	ledgercontroller.Controller
+	logs func() []ledger.Log
+}
+
+func (s *logLedgerControllerStub) ListLogs(_ context.Context, q storagecommon.PaginatedQuery[any]) (*bunpaginate.Cursor[ledger.Log], error) {
+	initial := q.(storagecommon.InitialPaginatedQuery[any])
+	logs := s.logs()
+	if *initial.Order == bunpaginate.Order(bunpaginate.OrderDesc) {
+		return &bunpaginate.Cursor[ledger.Log]{Data: logs[len(logs)-1:]}, nil
+	}
+	after := -1
+	if initial.Options.Builder != nil {
+		_ = initial.Options.Builder.Walk(func(_, _ string, value any) error {
+			after = int(value.(uint64))
+			return nil
+		})
+	}
+	ret := make([]ledger.Log, 0)
+	for _, log := range logs {
+		if int(*log.ID) > after {
+			ret = append(ret, log)
+		}
+	}
+	return &bunpaginate.Cursor[ledger.Log]{Data: ret}, nil
+}
+
+type monitoringCursorRepositoryStub struct {
+	cursors map[string]models.MonitoringCursor
+}
+
+func (r *monitoringCursorRepositoryStub) Get(_ context.Context, name string) (*models.MonitoringCursor, error) {
+	cursor, ok := r.cursors[name]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	return &cursor, nil
+}
+
+func (r *monitoringCursorRepositoryStub) Save(_ context.Context, cursor *models.MonitoringCursor) error {
+	saved := *cursor
+	if cursor.LastLogID != nil {
+		saved.LastLogID = new(uint64)
+		*saved.LastLogID = *cursor.LastLogID
+	}
+	r.cursors[cursor.Ledger] = saved
+	return nil
+}
+
+type replayerMonitoringServiceStub struct {
+	services.MonitoringService
+	monitorFunc func(context.Context, string, ledger.Transaction) ([]models.MonitoringAlert, error)
+}
+
+func (s *replayerMonitoringServiceStub) Monitor(ctx context.Context, name string, transaction ledger.Transaction) ([]models.MonitoringAlert, error) {
+	return s.monitorFunc(ctx, name, transaction)
+}
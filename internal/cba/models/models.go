@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -32,11 +33,150 @@ const (
 	AccountStatusSuspended = "suspended"
 	AccountStatusClosed    = "closed"
 
+	AccountHolderRolePrimary   = "primary"
+	AccountHolderRoleSecondary = "secondary"
+	AccountHolderRoleMandate   = "mandate"
+
+	SigningRuleAnyOne = "any_one"
+	SigningRuleAll    = "all"
+
 	FeePostingStatusPendingRecovery  = "pending_recovery"
 	FeePostingStatusPosted           = "posted"
 	FeePostingStatusWriteoffRequired = "writeoff_required"
+	FeePostingStatusWaived           = "waived"
+	FeePostingStatusWrittenOff       = "written_off"
+
+	TermUnitDays   = "days"
+	TermUnitMonths = "months"
+
+	TermStatusRunning = "running"
+	TermStatusMatured = "matured"
+	TermStatusBroken  = "broken"
+
+	RolloverPrincipal            = "principal"
+	RolloverPrincipalAndInterest = "principal_and_interest"
+	RolloverPayout               = "payout"
+
+	PenaltyEarlyWithdrawal   = "early_withdrawal"
+	PenaltyMinimumBalance    = "minimum_balance"
+	PenaltyInsufficientFunds = "insufficient_funds"
+	PenaltyExcessWithdrawals = "excess_withdrawals"
+	PenaltyDormancy          = "dormancy"
+	PenaltyEarlyClosure      = "early_closure"
+
+	DayCountActual365 = "act/365"
+	DayCountActual360 = "act/360"
+	DayCount30360     = "30/360"
+
+	PenaltyPeriodDaily   = "daily"
+	PenaltyPeriodMonthly = "monthly"
+
+	LoanAmortizationFlat            = "flat"
+	LoanAmortizationReducingBalance = "reducing_balance"
+	LoanAmortizationBullet          = "bullet"
+
+	RepaymentFrequencyWeekly  = "weekly"
+	RepaymentFrequencyMonthly = "monthly"
+
+	LoanStatusApproved  = "approved"
+	LoanStatusActive    = "active"
+	LoanStatusInArrears = "in_arrears"
+	LoanStatusRepaid    = "repaid"
+
+	InstallmentStatusScheduled = "scheduled"
+	InstallmentStatusDue       = "due"
+	InstallmentStatusOverdue   = "overdue"
+	InstallmentStatusPaid      = "paid"
+
+	JobInterestAccrual = "interest_accrual"
+	JobInterestPosting = "interest_posting"
+	JobMaintenanceFee  = "maintenance_fee"
+	JobDormancy        = "dormancy"
+	JobTermMaturity    = "term_maturity"
+	JobLoanServicing   = "loan_servicing"
+	JobPenaltyFees     = "penalty_fees"
+	JobFeeRecovery     = "fee_recovery"
+	JobKYCExpiry       = "kyc_expiry"
+	JobScreening       = "screening"
+
+	JobRunStatusPending   = "pending"
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusPartial   = "partial"
+	JobRunStatusFailed    = "failed"
+
+	JobRunTriggerSchedule = "schedule"
+	JobRunTriggerCatchUp  = "catch_up"
+	JobRunTriggerManual   = "manual"
+
+	// SchedulerLease is the lease held by the worker which runs the jobs.
+	SchedulerLease = "cba_scheduler"
+
+	ApprovalOperationAccountClose    = "account_close"
+	ApprovalOperationAccountDebit    = "account_debit"
+	ApprovalOperationFeeWaive        = "fee_waive"
+	ApprovalOperationProductActivate = "product_activate"
+
+	ApprovalStatusPending  = "pending"
+	ApprovalStatusApproved = "approved"
+	ApprovalStatusRejected = "rejected"
+	ApprovalStatusExecuted = "executed"
+	ApprovalStatusFailed   = "failed"
+
+	// ApprovalEventSubmitted is the first event of every approval request,
+	// the next ones are named after the status the request moved to.
+	ApprovalEventSubmitted = "submitted"
+	// ApprovalEventExecutionRetried records that the operation of an approved
+	// request whose execution was not recorded is executed again.
+	ApprovalEventExecutionRetried = "execution_retried"
+
+	ScreeningStatusClear   = "clear"
+	ScreeningStatusFlagged = "flagged"
+	ScreeningStatusBlocked = "blocked"
+
+	WatchlistCategorySanctions = "sanctions"
+	WatchlistCategoryPEP       = "pep"
+
+	ScreeningSubjectClient          = "client"
+	ScreeningSubjectBeneficialOwner = "beneficial_owner"
+	ScreeningSubjectCounterparty    = "counterparty"
+
+	ScreeningActionBlock = "block"
+	ScreeningActionFlag  = "flag"
+
+	ScreeningCaseStatusOpen      = "open"
+	ScreeningCaseStatusCleared   = "cleared"
+	ScreeningCaseStatusConfirmed = "confirmed"
+
+	MonitoringRuleVelocity        = "velocity"
+	MonitoringRuleStructuring     = "structuring"
+	MonitoringRuleRoundAmount     = "round_amount"
+	MonitoringRuleRapidInOut      = "rapid_in_out"
+	MonitoringRuleDormantActivity = "dormant_activity"
+
+	MonitoringDirectionCredit = "credit"
+	MonitoringDirectionDebit  = "debit"
+
+	AlertSeverityLow    = "low"
+	AlertSeverityMedium = "medium"
+	AlertSeverityHigh   = "high"
+
+	AlertStatusOpen          = "open"
+	AlertStatusInvestigating = "investigating"
+	AlertStatusEscalated     = "escalated"
+	AlertStatusClosed        = "closed"
+
+	AlertResolutionFalsePositive = "false_positive"
+	AlertResolutionLegitimate    = "legitimate"
+	AlertResolutionReported      = "reported"
 )
 
+// Jobs lists the scheduler jobs which record their runs.
+var Jobs = []string{JobInterestAccrual, JobInterestPosting, JobMaintenanceFee, JobDormancy, JobTermMaturity, JobLoanServicing, JobPenaltyFees, JobFeeRecovery, JobKYCExpiry, JobScreening}
+
+// ApprovalOperations lists the operations approval policies can apply to.
+var ApprovalOperations = []string{ApprovalOperationAccountClose, ApprovalOperationAccountDebit, ApprovalOperationFeeWaive, ApprovalOperationProductActivate}
+
 type TransactionLimits struct {
 	DailyDebitLimit   *string `json:"daily_debit_limit,omitempty"`
 	DailyCreditLimit  *string `json:"daily_credit_limit,omitempty"`
@@ -49,6 +189,7 @@ type ProductRules struct {
 	MinBalance           string             `json:"min_balance,omitempty"`
 	MaxBalance           *string            `json:"max_balance,omitempty"`
 	AllowNegativeBalance bool               `json:"allow_negative_balance,omitempty"`
+	OverdraftLimit       *string            `json:"overdraft_limit,omitempty"`
 	AllowDebits          bool               `json:"allow_debits,omitempty"`
 	AllowCredits         bool               `json:"allow_credits,omitempty"`
 	RequiresKYCLevel     int                `json:"requires_kyc_level,omitempty"`
@@ -69,6 +210,24 @@ type InterestConfig struct {
 	AccrualFrequency string         `json:"accrual_frequency,omitempty"`
 	PostingFrequency string         `json:"posting_frequency,omitempty"`
 	Tiers            []InterestTier `json:"tiers,omitempty"`
+	// DayCount is the convention splitting the annual rate over the accrual
+	// period. Products without one accrue daily on ACT/365 and monthly on
+	// 30/360.
+	DayCount string `json:"day_count,omitempty"`
+}
+
+// TaxConfig is the tax withheld on the interest paid by a product.
+type TaxConfig struct {
+	// WithholdingRates are matched in order against the tax residency and
+	// type of the client, the first match applying. A rate without residency
+	// or client type matches any.
+	WithholdingRates []WithholdingTaxRate `json:"withholding_rates"`
+}
+
+type WithholdingTaxRate struct {
+	Residency  string `json:"residency,omitempty"`
+	ClientType string `json:"client_type,omitempty"`
+	Rate       string `json:"rate"`
 }
 
 type MaintenanceFee struct {
@@ -89,7 +248,146 @@ type TransactionFee struct {
 type FeeSchedule struct {
 	MaintenanceFee  *MaintenanceFee  `json:"maintenance_fee,omitempty"`
 	TransactionFees []TransactionFee `json:"transaction_fees,omitempty"`
-	PenaltyFees     map[string]any   `json:"penalty_fees,omitempty"`
+	PenaltyFees     PenaltyFees      `json:"penalty_fees,omitempty"`
+}
+
+// PenaltyFees are the penalty fees of a fee schedule, keyed by penalty.
+type PenaltyFees map[string]PenaltyFee
+
+// UnmarshalJSON drops the entries which are not penalty fees. Penalty fees
+// used to be free-form and only early_withdrawal was ever charged, as a fee
+// rule which still decodes: the other entries products were saved with are
+// not charged now either.
+func (p *PenaltyFees) UnmarshalJSON(data []byte) error {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	if entries == nil {
+		*p = nil
+		return nil
+	}
+	ret := make(PenaltyFees, len(entries))
+	for name, entry := range entries {
+		switch name {
+		case PenaltyEarlyWithdrawal, PenaltyMinimumBalance, PenaltyInsufficientFunds,
+			PenaltyExcessWithdrawals, PenaltyDormancy, PenaltyEarlyClosure:
+		default:
+			continue
+		}
+		var penalty PenaltyFee
+		if err := json.Unmarshal(entry, &penalty); err != nil || penalty.Type == "" {
+			continue
+		}
+		ret[name] = penalty
+	}
+	*p = ret
+	return nil
+}
+
+// PenaltyFee is the fee rule charged when the penalty it is keyed by in
+// FeeSchedule.PenaltyFees is triggered. Percentages apply to the amount the
+// penalty is assessed on: the shortfall below min_balance, the bounced or
+// excess debit, or the balance of the account. FreeWithdrawals and Period
+// only apply to excess_withdrawals and WithinDays to early_closure.
+type PenaltyFee struct {
+	Type            string  `json:"type"`
+	Value           string  `json:"value"`
+	Min             *string `json:"min,omitempty"`
+	Max             *string `json:"max,omitempty"`
+	Currency        string  `json:"currency,omitempty"`
+	FreeWithdrawals int     `json:"free_withdrawals,omitempty"`
+	Period          string  `json:"period,omitempty"`
+	WithinDays      int     `json:"within_days,omitempty"`
+}
+
+// TermConfig makes a product a fixed-term deposit. RolloverOptions restricts
+// what happens at maturity; all options are allowed when it is empty.
+type TermConfig struct {
+	Length          int      `json:"length"`
+	Unit            string   `json:"unit"`
+	RolloverOptions []string `json:"rollover_options,omitempty"`
+	DefaultRollover string   `json:"default_rollover,omitempty"`
+	AllowEarlyBreak bool     `json:"allow_early_break,omitempty"`
+}
+
+// AccountTerm is the current term of a fixed-term deposit account. Principal
+// is in atomic units. Principal rollovers pay the interest out to
+// PayoutAccountID, payouts send principal and interest there and close the
+// account.
+type AccountTerm struct {
+	Length          int        `json:"length"`
+	Unit            string     `json:"unit"`
+	Status          string     `json:"status"`
+	Principal       int64      `json:"principal"`
+	StartDate       *time.Time `json:"start_date,omitempty"`
+	RolloverOption  string     `json:"rollover_option"`
+	PayoutAccountID *uuid.UUID `json:"payout_account_id,omitempty"`
+	Rollovers       int        `json:"rollovers,omitempty"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+}
+
+// LoanConfig makes a product a loan product. Rate is the annual interest rate
+// in percent and PenaltyRate the annual rate charged on overdue amounts.
+// Installments fall due every repayment period after disbursement and are in
+// arrears once unpaid for more than GraceDays.
+type LoanConfig struct {
+	Amortization       string  `json:"amortization"`
+	Rate               string  `json:"rate"`
+	Installments       int     `json:"installments"`
+	RepaymentFrequency string  `json:"repayment_frequency"`
+	MinPrincipal       string  `json:"min_principal,omitempty"`
+	MaxPrincipal       *string `json:"max_principal,omitempty"`
+	PenaltyRate        string  `json:"penalty_rate,omitempty"`
+	GraceDays          int     `json:"grace_days,omitempty"`
+}
+
+// AccountHolder is a client holding or operating an account. The primary
+// holder is the client the account was opened for. Mandate holders operate the
+// account on behalf of its holders; SigningLimit, in major units, caps the
+// debits a holder may authorize.
+type AccountHolder struct {
+	ClientID     uuid.UUID `json:"client_id"`
+	Role         string    `json:"role"`
+	SigningLimit *string   `json:"signing_limit,omitempty"`
+	AddedAt      time.Time `json:"added_at"`
+}
+
+// AccountLoan is the state of a loan account. The loan is disbursed to and
+// repaid from SettlementAccountID, so the loan wallet only carries the
+// outstanding principal as a negative balance. AmountRepaid sums the
+// penalty, interest and principal of every repayment collected. Amounts are
+// in atomic units; the accrued penalty is in major units like
+// Account.InterestAccrued.
+type AccountLoan struct {
+	Principal            int64           `json:"principal"`
+	Rate                 string          `json:"rate"`
+	PenaltyRate          string          `json:"penalty_rate,omitempty"`
+	Amortization         string          `json:"amortization"`
+	Installments         int             `json:"installments"`
+	RepaymentFrequency   string          `json:"repayment_frequency"`
+	GraceDays            int             `json:"grace_days,omitempty"`
+	Status               string          `json:"status"`
+	SettlementAccountID  uuid.UUID       `json:"settlement_account_id"`
+	DisbursedAt          *time.Time      `json:"disbursed_at,omitempty"`
+	OutstandingPrincipal int64           `json:"outstanding_principal"`
+	PenaltyAccrued       decimal.Decimal `json:"penalty_accrued"`
+	AmountRepaid         int64           `json:"amount_repaid"`
+	ArrearsAmount        int64           `json:"arrears_amount"`
+	DaysPastDue          int             `json:"days_past_due"`
+	AccruedThrough       *time.Time      `json:"accrued_through,omitempty"`
+	PendingRepayment     *LoanRepayment  `json:"pending_repayment,omitempty"`
+	RepaidAt             *time.Time      `json:"repaid_at,omitempty"`
+}
+
+// LoanRepayment is a repayment collected from the settlement account and
+// allocated to the penalty, then interest, then principal due.
+type LoanRepayment struct {
+	Reference string    `json:"reference"`
+	Date      time.Time `json:"date"`
+	Penalty   int64     `json:"penalty"`
+	Interest  int64     `json:"interest"`
+	Principal int64     `json:"principal"`
 }
 
 type Address struct {
@@ -107,6 +405,13 @@ type ClientContact struct {
 	Address *Address `json:"address,omitempty"`
 }
 
+// TaxExemption exempts a client from withholding tax until it expires.
+type TaxExemption struct {
+	Reason      string     `json:"reason"`
+	Certificate string     `json:"certificate,omitempty"`
+	ValidUntil  *time.Time `json:"valid_until,omitempty"`
+}
+
 type IndividualData struct {
 	FirstName        string  `json:"first_name,omitempty"`
 	MiddleName       string  `json:"middle_name,omitempty"`
@@ -164,6 +469,9 @@ type Product struct {
 	Rules          ProductRules    `json:"rules" bun:"rules,type:jsonb,notnull,default:'{}'::jsonb"`
 	InterestConfig *InterestConfig `json:"interest_config,omitempty" bun:"interest_config,type:jsonb,nullzero"`
 	FeeSchedule    *FeeSchedule    `json:"fee_schedule,omitempty" bun:"fee_schedule,type:jsonb,nullzero"`
+	TermConfig     *TermConfig     `json:"term_config,omitempty" bun:"term_config,type:jsonb,nullzero"`
+	LoanConfig     *LoanConfig     `json:"loan_config,omitempty" bun:"loan_config,type:jsonb,nullzero"`
+	TaxConfig      *TaxConfig      `json:"tax_config,omitempty" bun:"tax_config,type:jsonb,nullzero"`
 	CreatedAt      time.Time       `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
 	UpdatedAt      time.Time       `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
 }
@@ -181,8 +489,18 @@ type Client struct {
 	Contact        ClientContact   `json:"contact" bun:"contact,type:jsonb,notnull,default:'{}'::jsonb"`
 	IndividualData *IndividualData `json:"individual_data,omitempty" bun:"individual_data,type:jsonb,nullzero"`
 	CorporateData  *CorporateData  `json:"corporate_data,omitempty" bun:"corporate_data,type:jsonb,nullzero"`
-	CreatedAt      time.Time       `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
-	UpdatedAt      time.Time       `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+	// TaxResidency is the country the client is resident of for tax
+	// purposes. The country of its address applies when it is not set.
+	TaxResidency string        `json:"tax_residency,omitempty" bun:"tax_residency,type:varchar(2),nullzero"`
+	TaxExemption *TaxExemption `json:"tax_exemption,omitempty" bun:"tax_exemption,type:jsonb,nullzero"`
+	// ScreeningStatus is the outcome of the sanctions and PEP screening of
+	// the client and its beneficial owners, against the watchlists of
+	// ScreeningVersion.
+	ScreeningStatus  string     `json:"screening_status" bun:"screening_status,type:varchar(32),notnull"`
+	ScreeningVersion string     `json:"screening_version,omitempty" bun:"screening_version,type:varchar(64),nullzero"`
+	ScreenedAt       *time.Time `json:"screened_at,omitempty" bun:"screened_at,type:timestamp without time zone,nullzero"`
+	CreatedAt        time.Time  `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt        time.Time  `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
 }
 
 type Account struct {
@@ -201,7 +519,18 @@ type Account struct {
 	ClosedAt        *time.Time      `json:"closed_at,omitempty" bun:"closed_at,type:timestamp without time zone,nullzero"`
 	LastActivityAt  *time.Time      `json:"last_activity_at,omitempty" bun:"last_activity_at,type:timestamp without time zone,nullzero"`
 	InterestAccrued decimal.Decimal `json:"interest_accrued" bun:"interest_accrued,type:numeric"`
-	Metadata        map[string]any  `json:"metadata,omitempty" bun:"metadata,type:jsonb,notnull,default:'{}'::jsonb"`
+	Term            *AccountTerm    `json:"term,omitempty" bun:"term,type:jsonb,nullzero"`
+	MaturityDate    *time.Time      `json:"maturity_date,omitempty" bun:"maturity_date,type:date,nullzero"`
+	Loan            *AccountLoan    `json:"loan,omitempty" bun:"loan,type:jsonb,nullzero"`
+	Holders         []AccountHolder `json:"holders,omitempty" bun:"holders,type:jsonb,notnull,default:'[]'::jsonb"`
+	// SigningRule is how many holders must authorize the debits of the
+	// account. Debits need no authorization when it is not set.
+	SigningRule string `json:"signing_rule,omitempty" bun:"signing_rule,type:varchar(16),nullzero"`
+	// KYCRestricted flags the accounts whose client's KYC level fell below
+	// the one required by the product after a KYC record expired. Debits are
+	// refused on the client's level itself, whether the flag is set yet or not.
+	KYCRestricted bool           `json:"kyc_restricted" bun:"kyc_restricted,type:boolean,notnull"`
+	Metadata      map[string]any `json:"metadata,omitempty" bun:"metadata,type:jsonb,notnull,default:'{}'::jsonb"`
 }
 
 type KYCRecord struct {
@@ -218,6 +547,12 @@ type KYCRecord struct {
 	Reason      string         `json:"reason,omitempty" bun:"reason,type:text,nullzero"`
 	Documents   []KYCDocument  `json:"documents,omitempty" bun:"documents,type:jsonb,notnull,default:'[]'::jsonb"`
 	Payload     map[string]any `json:"payload,omitempty" bun:"payload,type:jsonb,notnull,default:'{}'::jsonb"`
+	// Provider is the KYC provider checking the record, and ProviderReference
+	// the reference of the check at the provider.
+	Provider          string `json:"provider,omitempty" bun:"provider,type:varchar(64),nullzero"`
+	ProviderReference string `json:"provider_reference,omitempty" bun:"provider_reference,type:varchar(255),nullzero"`
+	// ExpiryNotifiedAt is when the upcoming expiry of the record was notified.
+	ExpiryNotifiedAt *time.Time `json:"expiry_notified_at,omitempty" bun:"expiry_notified_at,type:timestamp without time zone,nullzero"`
 }
 
 type InterestAccrual struct {
@@ -231,10 +566,48 @@ type InterestAccrual struct {
 	Amount          decimal.Decimal `json:"amount" bun:"amount,type:numeric"`
 	Posted          bool            `json:"posted" bun:"posted,type:boolean,notnull"`
 	PostedReference string          `json:"posted_reference,omitempty" bun:"posted_reference,type:varchar(255),nullzero"`
+	DayCount        string          `json:"day_count" bun:"day_count,type:varchar(16),notnull"`
+	RateVersion     int             `json:"rate_version" bun:"rate_version,type:integer,notnull"`
 	Metadata        map[string]any  `json:"metadata,omitempty" bun:"metadata,type:jsonb,notnull,default:'{}'::jsonb"`
 	CreatedAt       time.Time       `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
 }
 
+// InterestRateVersion is a rate change of a product applying to the accruals
+// from its effective date. The rate and tiers of the interest config of the
+// product are the first version.
+type InterestRateVersion struct {
+	bun.BaseModel `bun:"_system.interest_rate_versions,alias:interest_rate_versions"`
+
+	ID            uuid.UUID      `json:"id" bun:"id,type:uuid,pk"`
+	ProductID     uuid.UUID      `json:"product_id" bun:"product_id,type:uuid,notnull"`
+	Version       int            `json:"version" bun:"version,type:integer,notnull"`
+	EffectiveFrom time.Time      `json:"effective_from" bun:"effective_from,type:date,notnull"`
+	Rate          string         `json:"rate,omitempty" bun:"rate,type:varchar(32),nullzero"`
+	Tiers         []InterestTier `json:"tiers,omitempty" bun:"tiers,type:jsonb,nullzero"`
+	CreatedBy     string         `json:"created_by,omitempty" bun:"created_by,type:varchar(255),nullzero"`
+	CreatedAt     time.Time      `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+}
+
+// TaxPosting is the tax withheld from an interest posting. Exempt postings are
+// recorded too, without tax, so that the interest paid gross is reported.
+type TaxPosting struct {
+	bun.BaseModel `bun:"_system.tax_postings,alias:tax_postings"`
+
+	ID                uuid.UUID       `json:"id" bun:"id,type:uuid,pk"`
+	AccountID         uuid.UUID       `json:"account_id" bun:"account_id,type:uuid,notnull"`
+	ClientID          uuid.UUID       `json:"client_id" bun:"client_id,type:uuid,notnull"`
+	InterestReference string          `json:"interest_reference" bun:"interest_reference,type:varchar(255),notnull"`
+	Currency          string          `json:"currency" bun:"currency,type:varchar(16),notnull"`
+	Residency         string          `json:"residency,omitempty" bun:"residency,type:varchar(2),nullzero"`
+	ClientType        string          `json:"client_type" bun:"client_type,type:varchar(32),notnull"`
+	Rate              decimal.Decimal `json:"rate" bun:"rate,type:numeric,notnull"`
+	GrossAmount       int64           `json:"gross_amount" bun:"gross_amount,type:bigint,notnull"`
+	TaxAmount         int64           `json:"tax_amount" bun:"tax_amount,type:bigint,notnull"`
+	NetAmount         int64           `json:"net_amount" bun:"net_amount,type:bigint,notnull"`
+	Exempt            bool            `json:"exempt" bun:"exempt,type:boolean,notnull"`
+	CreatedAt         time.Time       `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+}
+
 type FeePosting struct {
 	bun.BaseModel `bun:"_system.fee_postings,alias:fee_postings"`
 
@@ -246,10 +619,39 @@ type FeePosting struct {
 	Amount          decimal.Decimal `json:"amount" bun:"amount,type:numeric"`
 	Currency        string          `json:"currency" bun:"currency,type:varchar(16),notnull"`
 	Status          string          `json:"status" bun:"status,type:varchar(64),notnull"`
+	RecoveredAmount decimal.Decimal `json:"recovered_amount" bun:"recovered_amount,type:numeric,notnull,default:0"`
+	Recovery        *FeeRecovery    `json:"recovery,omitempty" bun:"recovery,type:jsonb"`
 	Metadata        map[string]any  `json:"metadata,omitempty" bun:"metadata,type:jsonb,notnull,default:'{}'::jsonb"`
 	CreatedAt       time.Time       `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
 }
 
+// FeeRecovery is a part of an outstanding fee being collected from the
+// account wallet. Amount is in atomic units of the fee currency.
+type FeeRecovery struct {
+	Reference string    `json:"reference"`
+	Date      time.Time `json:"date"`
+	Amount    int64     `json:"amount"`
+}
+
+// LoanInstallment is one installment of a loan repayment schedule. Amounts
+// are in atomic units of the loan currency.
+type LoanInstallment struct {
+	bun.BaseModel `bun:"_system.loan_installments,alias:loan_installments"`
+
+	ID            uuid.UUID  `json:"id" bun:"id,type:uuid,pk"`
+	AccountID     uuid.UUID  `json:"account_id" bun:"account_id,type:uuid,notnull"`
+	Number        int        `json:"number" bun:"number,type:integer,notnull"`
+	DueDate       time.Time  `json:"due_date" bun:"due_date,type:date,notnull"`
+	Principal     int64      `json:"principal" bun:"principal,type:bigint,notnull"`
+	Interest      int64      `json:"interest" bun:"interest,type:bigint,notnull"`
+	PrincipalPaid int64      `json:"principal_paid" bun:"principal_paid,type:bigint,notnull"`
+	InterestPaid  int64      `json:"interest_paid" bun:"interest_paid,type:bigint,notnull"`
+	Status        string     `json:"status" bun:"status,type:varchar(32),notnull"`
+	PaidAt        *time.Time `json:"paid_at,omitempty" bun:"paid_at,type:timestamp without time zone,nullzero"`
+	CreatedAt     time.Time  `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt     time.Time  `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
+
 type AccountDailyUsage struct {
 	bun.BaseModel `bun:"_system.account_daily_usages,alias:account_daily_usages"`
 
@@ -264,3 +666,200 @@ type AccountDailyUsage struct {
 	CreatedAt     time.Time       `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
 	UpdatedAt     time.Time       `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
 }
+
+// JobRun records one execution of a scheduler job for a business date. A run
+// is partial when the job completed but some accounts could not be processed.
+// A running run is heartbeated by the worker owning it, so that a run left
+// behind by a worker which stopped can be failed and run again.
+type JobRun struct {
+	bun.BaseModel `bun:"_system.cba_job_runs,alias:cba_job_runs"`
+
+	ID           uuid.UUID  `json:"id" bun:"id,type:uuid,pk"`
+	Job          string     `json:"job" bun:"job,type:varchar(64),notnull"`
+	BusinessDate time.Time  `json:"business_date" bun:"business_date,type:date,notnull"`
+	Trigger      string     `json:"trigger" bun:"trigger,type:varchar(32),notnull"`
+	Status       string     `json:"status" bun:"status,type:varchar(32),notnull"`
+	RequestedBy  string     `json:"requested_by,omitempty" bun:"requested_by,type:varchar(255),nullzero"`
+	Owner        string     `json:"owner,omitempty" bun:"owner,type:varchar(255),nullzero"`
+	Processed    int        `json:"processed" bun:"processed,type:integer,notnull"`
+	Skipped      int        `json:"skipped" bun:"skipped,type:integer,notnull"`
+	Failed       int        `json:"failed" bun:"failed,type:integer,notnull"`
+	Errors       []string   `json:"errors,omitempty" bun:"errors,type:jsonb,notnull,default:'[]'::jsonb"`
+	StartedAt    *time.Time `json:"started_at,omitempty" bun:"started_at,type:timestamp without time zone,nullzero"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty" bun:"finished_at,type:timestamp without time zone,nullzero"`
+	HeartbeatAt  *time.Time `json:"heartbeat_at,omitempty" bun:"heartbeat_at,type:timestamp without time zone,nullzero"`
+	CreatedAt    time.Time  `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt    time.Time  `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
+
+// JobLease is a time bound claim on a named role held by one worker. Another
+// worker may take it over once it expires.
+type JobLease struct {
+	bun.BaseModel `bun:"_system.cba_job_leases,alias:cba_job_leases"`
+
+	Name       string    `json:"name" bun:"name,type:varchar(64),pk"`
+	Holder     string    `json:"holder" bun:"holder,type:varchar(255),notnull"`
+	AcquiredAt time.Time `json:"acquired_at" bun:"acquired_at,type:timestamp without time zone,notnull"`
+	RenewedAt  time.Time `json:"renewed_at" bun:"renewed_at,type:timestamp without time zone,notnull"`
+	ExpiresAt  time.Time `json:"expires_at" bun:"expires_at,type:timestamp without time zone,notnull"`
+}
+
+// ApprovalPolicy requires a second actor to approve an operation. A policy
+// applies to the operations on its product and in its currency when set, and
+// to the ones of at least MinAmount, in major units, when set.
+type ApprovalPolicy struct {
+	bun.BaseModel `bun:"_system.approval_policies,alias:approval_policies"`
+
+	ID        uuid.UUID  `json:"id" bun:"id,type:uuid,pk"`
+	Operation string     `json:"operation" bun:"operation,type:varchar(64),notnull"`
+	ProductID *uuid.UUID `json:"product_id,omitempty" bun:"product_id,type:uuid,nullzero"`
+	Currency  string     `json:"currency,omitempty" bun:"currency,type:varchar(16),nullzero"`
+	MinAmount *string    `json:"min_amount,omitempty" bun:"min_amount,type:varchar(64),nullzero"`
+	Enabled   bool       `json:"enabled" bun:"enabled,type:boolean,notnull"`
+	CreatedBy string     `json:"created_by,omitempty" bun:"created_by,type:varchar(255),nullzero"`
+	CreatedAt time.Time  `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt time.Time  `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
+
+// ApprovalRequest is an operation held for approval. The original API call is
+// kept so that it can be executed once approved. Amount is in atomic units.
+type ApprovalRequest struct {
+	bun.BaseModel `bun:"_system.approval_requests,alias:approval_requests"`
+
+	ID             uuid.UUID       `json:"id" bun:"id,type:uuid,pk"`
+	PolicyID       uuid.UUID       `json:"policy_id" bun:"policy_id,type:uuid,notnull"`
+	Operation      string          `json:"operation" bun:"operation,type:varchar(64),notnull"`
+	Status         string          `json:"status" bun:"status,type:varchar(32),notnull"`
+	ResourceID     uuid.UUID       `json:"resource_id" bun:"resource_id,type:uuid,notnull"`
+	ProductID      *uuid.UUID      `json:"product_id,omitempty" bun:"product_id,type:uuid,nullzero"`
+	Currency       string          `json:"currency,omitempty" bun:"currency,type:varchar(16),nullzero"`
+	Amount         *int64          `json:"amount,omitempty" bun:"amount,type:bigint,nullzero"`
+	Method         string          `json:"method" bun:"method,type:varchar(16),notnull"`
+	Path           string          `json:"path" bun:"path,type:text,notnull"`
+	Payload        json.RawMessage `json:"payload,omitempty" bun:"payload,type:jsonb,nullzero"`
+	IdempotencyKey string          `json:"idempotency_key" bun:"idempotency_key,type:varchar(255),notnull"`
+	RequestedBy    string          `json:"requested_by" bun:"requested_by,type:varchar(255),notnull"`
+	DecidedBy      string          `json:"decided_by,omitempty" bun:"decided_by,type:varchar(255),nullzero"`
+	DecidedAt      *time.Time      `json:"decided_at,omitempty" bun:"decided_at,type:timestamp without time zone,nullzero"`
+	Comment        string          `json:"comment,omitempty" bun:"comment,type:text,nullzero"`
+	ResultStatus   int             `json:"result_status,omitempty" bun:"result_status,type:integer,nullzero"`
+	Result         json.RawMessage `json:"result,omitempty" bun:"result,type:jsonb,nullzero"`
+	ExecutedAt     *time.Time      `json:"executed_at,omitempty" bun:"executed_at,type:timestamp without time zone,nullzero"`
+	CreatedAt      time.Time       `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt      time.Time       `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
+
+// ApprovalEvent is one entry of the audit trail of an approval request.
+type ApprovalEvent struct {
+	bun.BaseModel `bun:"_system.approval_events,alias:approval_events"`
+
+	ID        uuid.UUID `json:"id" bun:"id,type:uuid,pk"`
+	RequestID uuid.UUID `json:"request_id" bun:"request_id,type:uuid,notnull"`
+	Action    string    `json:"action" bun:"action,type:varchar(32),notnull"`
+	Actor     string    `json:"actor,omitempty" bun:"actor,type:varchar(255),nullzero"`
+	Comment   string    `json:"comment,omitempty" bun:"comment,type:text,nullzero"`
+	CreatedAt time.Time `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+}
+
+// ScreeningCase is a watchlist hit awaiting review. Its subject is the
+// client, one of its beneficial owners, or a counterparty named in the
+// metadata of one of its transactions. Hits on sanctions lists block the
+// client until the case is cleared, PEP hits only flag it.
+type ScreeningCase struct {
+	bun.BaseModel `bun:"_system.screening_cases,alias:screening_cases"`
+
+	ID          uuid.UUID  `json:"id" bun:"id,type:uuid,pk"`
+	ClientID    uuid.UUID  `json:"client_id" bun:"client_id,type:uuid,notnull"`
+	AccountID   *uuid.UUID `json:"account_id,omitempty" bun:"account_id,type:uuid,nullzero"`
+	SubjectType string     `json:"subject_type" bun:"subject_type,type:varchar(32),notnull"`
+	SubjectName string     `json:"subject_name" bun:"subject_name,type:varchar(255),notnull"`
+	Reference   string     `json:"reference,omitempty" bun:"reference,type:varchar(255),nullzero"`
+	List        string     `json:"list" bun:"list,type:varchar(255),notnull"`
+	ListVersion string     `json:"list_version" bun:"list_version,type:varchar(64),notnull"`
+	Category    string     `json:"category" bun:"category,type:varchar(32),notnull"`
+	EntryID     string     `json:"entry_id" bun:"entry_id,type:varchar(255),notnull"`
+	EntryName   string     `json:"entry_name" bun:"entry_name,type:varchar(255),notnull"`
+	Score       float64    `json:"score" bun:"score,type:double precision,notnull"`
+	Action      string     `json:"action" bun:"action,type:varchar(32),notnull"`
+	Status      string     `json:"status" bun:"status,type:varchar(32),notnull"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty" bun:"reviewed_by,type:varchar(255),nullzero"`
+	ReviewNote  string     `json:"review_note,omitempty" bun:"review_note,type:text,nullzero"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty" bun:"reviewed_at,type:timestamp without time zone,nullzero"`
+	CreatedAt   time.Time  `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt   time.Time  `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
+
+// MonitoredTransaction is a credit or a debit of an account's available
+// balance, recorded when its ledger transaction is committed so that the
+// monitoring rules can look back over a window. Amount is in major units.
+type MonitoredTransaction struct {
+	bun.BaseModel `bun:"_system.monitored_transactions,alias:monitored_transactions"`
+
+	ID            uuid.UUID       `json:"id" bun:"id,type:uuid,pk"`
+	AccountID     uuid.UUID       `json:"account_id" bun:"account_id,type:uuid,notnull"`
+	Ledger        string          `json:"ledger" bun:"ledger,type:varchar(255),notnull"`
+	TransactionID uint64          `json:"transaction_id" bun:"transaction_id,type:numeric,notnull"`
+	Reference     string          `json:"reference,omitempty" bun:"reference,type:varchar(255),nullzero"`
+	Direction     string          `json:"direction" bun:"direction,type:varchar(32),notnull"`
+	Amount        decimal.Decimal `json:"amount" bun:"amount,type:numeric,notnull"`
+	Currency      string          `json:"currency" bun:"currency,type:varchar(16),notnull"`
+	Timestamp     time.Time       `json:"timestamp" bun:"timestamp,type:timestamp without time zone,notnull"`
+	// EvaluatedAt is set once the rules were evaluated against the
+	// transaction.
+	EvaluatedAt *time.Time `json:"evaluated_at,omitempty" bun:"evaluated_at,type:timestamp without time zone"`
+	CreatedAt   time.Time  `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+}
+
+// MonitoringCursor is the last log of a ledger whose transaction, if any,
+// went through the monitoring rules. LastLogID is nil until a log is.
+type MonitoringCursor struct {
+	bun.BaseModel `bun:"_system.cba_monitoring_cursors,alias:cba_monitoring_cursors"`
+
+	Ledger    string    `json:"ledger" bun:"ledger,type:varchar(255),pk"`
+	LastLogID *uint64   `json:"last_log_id,omitempty" bun:"last_log_id,type:numeric"`
+	UpdatedAt time.Time `json:"updated_at" bun:"updated_at,type:timestamp without time zone,notnull"`
+}
+
+// AlertEvidence is a ledger transaction which triggered an alert. Link is
+// the API path of the transaction.
+type AlertEvidence struct {
+	Ledger        string          `json:"ledger"`
+	TransactionID uint64          `json:"transaction_id"`
+	Reference     string          `json:"reference,omitempty"`
+	Direction     string          `json:"direction"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	Timestamp     time.Time       `json:"timestamp"`
+	Link          string          `json:"link"`
+}
+
+// AlertNote records a triage action on an alert.
+type AlertNote struct {
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MonitoringAlert is raised when transactions of an account match a
+// monitoring rule. Further matches of the same rule are added to the
+// evidence of the alert until it is closed.
+type MonitoringAlert struct {
+	bun.BaseModel `bun:"_system.monitoring_alerts,alias:monitoring_alerts"`
+
+	ID         uuid.UUID       `json:"id" bun:"id,type:uuid,pk"`
+	AccountID  uuid.UUID       `json:"account_id" bun:"account_id,type:uuid,notnull"`
+	ClientID   uuid.UUID       `json:"client_id" bun:"client_id,type:uuid,notnull"`
+	Rule       string          `json:"rule" bun:"rule,type:varchar(255),notnull"`
+	RuleType   string          `json:"rule_type" bun:"rule_type,type:varchar(32),notnull"`
+	Severity   string          `json:"severity" bun:"severity,type:varchar(32),notnull"`
+	Status     string          `json:"status" bun:"status,type:varchar(32),notnull"`
+	Summary    string          `json:"summary" bun:"summary,type:text,notnull"`
+	Evidence   []AlertEvidence `json:"evidence" bun:"evidence,type:jsonb,notnull,default:'[]'::jsonb"`
+	Assignee   string          `json:"assignee,omitempty" bun:"assignee,type:varchar(255),nullzero"`
+	Resolution string          `json:"resolution,omitempty" bun:"resolution,type:varchar(32),nullzero"`
+	Notes      []AlertNote     `json:"notes" bun:"notes,type:jsonb,notnull,default:'[]'::jsonb"`
+	ClosedAt   *time.Time      `json:"closed_at,omitempty" bun:"closed_at,type:timestamp without time zone,nullzero"`
+	CreatedAt  time.Time       `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt  time.Time       `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
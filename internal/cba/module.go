@@ -1,16 +1,57 @@
 package cba
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/uptrace/bun"
 	"go.uber.org/fx"
 
+	"github.com/formancehq/go-libs/v3/logging"
+
 	"github.com/formancehq/ledger/internal/cba/repositories"
 	"github.com/formancehq/ledger/internal/cba/services"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
 )
 
-func NewFXModule() fx.Option {
+type ModuleConfig struct {
+	// KYCRequirementsFile, when set, replaces the default per-level KYC
+	// requirements with the JSON array it contains.
+	KYCRequirementsFile string
+	// KYCProviderURL, when set, sends KYC submissions to an HTTP provider
+	// whose requests and callbacks are signed with KYCProviderSecret, which
+	// is then required.
+	KYCProviderURL    string
+	KYCProviderSecret string
+	// ScreeningWatchlists are the sanctions and PEP lists clients and
+	// counterparties are screened against. ScreeningThreshold is the
+	// minimum name similarity of a hit, DefaultScreeningThreshold if 0.
+	ScreeningWatchlists []string
+	ScreeningThreshold  float64
+	// MonitoringRulesFile, when set, replaces the default transaction
+	// monitoring rules with the JSON array it contains.
+	MonitoringRulesFile string
+}
+
+func NewFXModule(cfg ModuleConfig) fx.Option {
 	return fx.Options(
 		fx.Provide(
+			func() (services.KYCRequirements, error) {
+				if cfg.KYCRequirementsFile == "" {
+					return services.DefaultKYCRequirements(), nil
+				}
+				return services.LoadKYCRequirements(cfg.KYCRequirementsFile)
+			},
+			func() (services.KYCProvider, error) {
+				if cfg.KYCProviderURL == "" {
+					return nil, nil
+				}
+				if cfg.KYCProviderSecret == "" {
+					return nil, fmt.Errorf("a secret is required to sign the requests and callbacks of the kyc provider")
+				}
+				return services.NewHTTPKYCProvider(cfg.KYCProviderURL, cfg.KYCProviderSecret, nil), nil
+			},
 			func(db *bun.DB) repositories.ProductRepository {
 				return repositories.NewProductRepository(db)
 			},
@@ -23,23 +64,70 @@ func NewFXModule() fx.Option {
 			func(db *bun.DB) repositories.KYCRepository {
 				return repositories.NewKYCRepository(db)
 			},
+			func(db *bun.DB) repositories.LoanInstallmentRepository {
+				return repositories.NewLoanInstallmentRepository(db)
+			},
 			func(db *bun.DB) repositories.DailyUsageRepository {
 				return repositories.NewDailyUsageRepository(db)
 			},
 			func(db *bun.DB) repositories.InterestAccrualRepository {
 				return repositories.NewInterestAccrualRepository(db)
 			},
+			func(db *bun.DB) repositories.InterestRateRepository {
+				return repositories.NewInterestRateRepository(db)
+			},
+			func(db *bun.DB) repositories.TaxPostingRepository {
+				return repositories.NewTaxPostingRepository(db)
+			},
 			func(db *bun.DB) repositories.FeePostingRepository {
 				return repositories.NewFeePostingRepository(db)
 			},
-			func(productRepository repositories.ProductRepository) services.ProductService {
-				return services.NewProductService(productRepository)
+			func(db *bun.DB) repositories.JobRunRepository {
+				return repositories.NewJobRunRepository(db)
+			},
+			func(db *bun.DB) repositories.JobLeaseRepository {
+				return repositories.NewJobLeaseRepository(db)
+			},
+			func(db *bun.DB) repositories.ApprovalPolicyRepository {
+				return repositories.NewApprovalPolicyRepository(db)
+			},
+			func(db *bun.DB) repositories.ApprovalRequestRepository {
+				return repositories.NewApprovalRequestRepository(db)
+			},
+			func(db *bun.DB) repositories.ApprovalEventRepository {
+				return repositories.NewApprovalEventRepository(db)
+			},
+			func(db *bun.DB) repositories.ScreeningCaseRepository {
+				return repositories.NewScreeningCaseRepository(db)
+			},
+			func(db *bun.DB) repositories.MonitoredTransactionRepository {
+				return repositories.NewMonitoredTransactionRepository(db)
+			},
+			func(db *bun.DB) repositories.MonitoringAlertRepository {
+				return repositories.NewMonitoringAlertRepository(db)
+			},
+			func(
+				clientRepository repositories.ClientRepository,
+				caseRepository repositories.ScreeningCaseRepository,
+			) (services.ScreeningService, error) {
+				watchlists := services.NewWatchlistStore(cfg.ScreeningWatchlists...)
+				if _, err := watchlists.Current(); err != nil {
+					return nil, fmt.Errorf("loading screening watchlists: %w", err)
+				}
+				return services.NewScreeningService(clientRepository, caseRepository, watchlists, cfg.ScreeningThreshold), nil
+			},
+			func(
+				productRepository repositories.ProductRepository,
+				rateRepository repositories.InterestRateRepository,
+			) services.ProductService {
+				return services.NewProductService(productRepository, rateRepository)
 			},
 			func(
 				clientRepository repositories.ClientRepository,
 				accountRepository repositories.AccountRepository,
+				screeningService services.ScreeningService,
 			) services.ClientService {
-				return services.NewClientService(clientRepository, accountRepository)
+				return services.NewClientService(clientRepository, accountRepository, screeningService)
 			},
 			func(
 				accountRepository repositories.AccountRepository,
@@ -52,34 +140,127 @@ func NewFXModule() fx.Option {
 			func(
 				clientRepository repositories.ClientRepository,
 				kycRepository repositories.KYCRepository,
+				requirements services.KYCRequirements,
+				provider services.KYCProvider,
 			) services.KYCService {
-				return services.NewKYCService(clientRepository, kycRepository)
+				return services.NewKYCService(clientRepository, kycRepository, requirements, provider)
 			},
 			func(
 				accountRepository repositories.AccountRepository,
 				productRepository repositories.ProductRepository,
 				interestRepository repositories.InterestAccrualRepository,
+				rateRepository repositories.InterestRateRepository,
+				clientRepository repositories.ClientRepository,
+				taxRepository repositories.TaxPostingRepository,
 			) services.InterestService {
-				return services.NewInterestService(accountRepository, productRepository, interestRepository)
+				return services.NewInterestService(accountRepository, productRepository, interestRepository, rateRepository, clientRepository, taxRepository)
 			},
 			func(
 				accountRepository repositories.AccountRepository,
 				productRepository repositories.ProductRepository,
 				feeRepository repositories.FeePostingRepository,
+				dailyUsageRepository repositories.DailyUsageRepository,
 			) services.FeeService {
-				return services.NewFeeService(accountRepository, productRepository, feeRepository)
+				return services.NewFeeService(accountRepository, productRepository, feeRepository, dailyUsageRepository)
+			},
+			func(
+				accountRepository repositories.AccountRepository,
+				productRepository repositories.ProductRepository,
+				feeRepository repositories.FeePostingRepository,
+			) services.TermDepositService {
+				return services.NewTermDepositService(accountRepository, productRepository, feeRepository)
+			},
+			func(
+				accountRepository repositories.AccountRepository,
+				clientRepository repositories.ClientRepository,
+			) services.AccountHolderService {
+				return services.NewAccountHolderService(accountRepository, clientRepository)
+			},
+			func(
+				accountRepository repositories.AccountRepository,
+				installmentRepository repositories.LoanInstallmentRepository,
+			) services.LoanService {
+				return services.NewLoanService(accountRepository, installmentRepository)
 			},
 			func(
 				clientRepository repositories.ClientRepository,
 				accountRepository repositories.AccountRepository,
 				interestRepository repositories.InterestAccrualRepository,
 				feeRepository repositories.FeePostingRepository,
+				taxRepository repositories.TaxPostingRepository,
 			) services.ReportingService {
-				return services.NewReportingService(clientRepository, accountRepository, interestRepository, feeRepository)
+				return services.NewReportingService(clientRepository, accountRepository, interestRepository, feeRepository, taxRepository)
+			},
+			func(
+				jobRunRepository repositories.JobRunRepository,
+				jobLeaseRepository repositories.JobLeaseRepository,
+			) services.JobService {
+				return services.NewJobService(jobRunRepository, jobLeaseRepository)
+			},
+			func(
+				policyRepository repositories.ApprovalPolicyRepository,
+				requestRepository repositories.ApprovalRequestRepository,
+				eventRepository repositories.ApprovalEventRepository,
+			) services.ApprovalService {
+				return services.NewApprovalService(policyRepository, requestRepository, eventRepository)
 			},
 			func() services.FinanceReportingService {
 				return services.NewFinanceReportingService()
 			},
+			func(db *bun.DB) repositories.MonitoringCursorRepository {
+				return repositories.NewMonitoringCursorRepository(db)
+			},
+			func(
+				accountRepository repositories.AccountRepository,
+				transactionRepository repositories.MonitoredTransactionRepository,
+				alertRepository repositories.MonitoringAlertRepository,
+			) (services.MonitoringService, error) {
+				var rules []services.MonitoringRule
+				if cfg.MonitoringRulesFile != "" {
+					var err error
+					rules, err = services.LoadMonitoringRules(cfg.MonitoringRulesFile)
+					if err != nil {
+						return nil, fmt.Errorf("loading monitoring rules: %w", err)
+					}
+				}
+				return services.NewMonitoringService(accountRepository, transactionRepository, alertRepository, rules), nil
+			},
 		),
+		// decorate the ledger listener so that every committed transaction
+		// goes through the monitoring rules
+		fx.Decorate(func(
+			lc fx.Lifecycle,
+			listener ledgercontroller.Listener,
+			logger logging.Logger,
+			monitoringService services.MonitoringService,
+		) ledgercontroller.Listener {
+			monitoringListener := NewMonitoringListener(listener, logger, monitoringService)
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go monitoringListener.Run(context.WithoutCancel(ctx))
+					return nil
+				},
+				OnStop: monitoringListener.Stop,
+			})
+			return monitoringListener
+		}),
+		// replay the ledger logs so that the transactions the listener missed
+		// are monitored too
+		fx.Invoke(func(
+			lc fx.Lifecycle,
+			logger logging.Logger,
+			system systemcontroller.Controller,
+			monitoringService services.MonitoringService,
+			cursorRepository repositories.MonitoringCursorRepository,
+		) {
+			replayer := NewMonitoringReplayer(logger, system, monitoringService, cursorRepository, MonitoringReplayInterval)
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go replayer.Run(context.WithoutCancel(ctx))
+					return nil
+				},
+				OnStop: replayer.Stop,
+			})
+		}),
 	)
 }
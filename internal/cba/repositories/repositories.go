@@ -2,32 +2,164 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
 	"github.com/formancehq/go-libs/v3/platform/postgres"
 
 	"github.com/formancehq/ledger/internal/cba/models"
 )
 
+// Sort orders a listing on a column. Listings fall back to their default
+// order when the column is not one of their sort columns.
+type Sort struct {
+	Column string            `json:"column"`
+	Order  bunpaginate.Order `json:"order"`
+}
+
+// Keyset is the position of a row in a listing: the value of the column the
+// listing is sorted on and the id of the row, which breaks the ties.
+type Keyset struct {
+	Value string    `json:"value"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// Page selects a page of a listing sorted on Sort then on the id: at most
+// Limit rows, following After or, nearest first, preceding Before.
+type Page struct {
+	Sort   *Sort
+	After  *Keyset
+	Before *Keyset
+	Limit  int
+}
+
+// The sort columns of the listings, the first one being the default.
+var (
+	ProductSortColumns = []string{"created_at", "updated_at", "code", "name"}
+	ClientSortColumns  = []string{"created_at", "updated_at", "client_number"}
+	AccountSortColumns = []string{"opened_at", "account_number", "last_activity_at"}
+)
+
 type ProductFilter struct {
 	Category *string
 	Currency *string
 	Status   *string
+	// Search selects the products whose code or name contains it.
+	Search        *string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Page          *Page
 }
 
 type ClientFilter struct {
 	Type      *string
 	Status    *string
 	KYCStatus *string
+	// NotScreenedWith selects the clients which have not been screened
+	// against the watchlists of this version yet.
+	NotScreenedWith *string
+	// Search selects the clients whose number, name, email, phone or
+	// national ID, or the number of one of whose accounts, contains it.
+	Search        *string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Page          *Page
 }
 
 type AccountFilter struct {
 	ClientID  *uuid.UUID
 	ProductID *uuid.UUID
 	Status    *string
+	// Search selects the accounts whose number contains it.
+	Search *string
+	// OpenedAfter and OpenedBefore select the accounts opened within the
+	// range, bounds included.
+	OpenedAfter  *time.Time
+	OpenedBefore *time.Time
+	Page         *Page
+	// HolderID selects the accounts the client holds, alone or jointly.
+	HolderID *uuid.UUID
+	// MaturesBy selects the fixed-term accounts maturing on or before the date.
+	MaturesBy *time.Time
+	// Loans selects the accounts opened on loan products.
+	Loans bool
+	// KYCRestricted selects the accounts whose debits are restricted by an
+	// expired KYC.
+	KYCRestricted bool
+}
+
+type KYCFilter struct {
+	Status *string
+	// ExpiresBefore selects the records expiring before the date.
+	ExpiresBefore *time.Time
+	// Unnotified selects the records whose upcoming expiry was not notified.
+	Unnotified bool
+}
+
+type FeePostingFilter struct {
+	AccountID *uuid.UUID
+	Statuses  []string
+	EventType *string
+	Limit     int
+	Offset    int
+}
+
+type JobRunFilter struct {
+	Job      *string
+	Statuses []string
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Offset   int
+}
+
+type ApprovalPolicyFilter struct {
+	Operation *string
+	Enabled   *bool
+}
+
+type ApprovalRequestFilter struct {
+	Operation  *string
+	Statuses   []string
+	ResourceID *uuid.UUID
+	Limit      int
+	Offset     int
+}
+
+type ScreeningCaseFilter struct {
+	ClientID    *uuid.UUID
+	SubjectType *string
+	Statuses    []string
+	Limit       int
+	Offset      int
+}
+
+type MonitoredTransactionFilter struct {
+	AccountID *uuid.UUID
+	// From includes the transactions at or after it, Before the ones
+	// strictly before it.
+	From   *time.Time
+	Before *time.Time
+	Limit  int
+}
+
+type MonitoringAlertFilter struct {
+	AccountID *uuid.UUID
+	ClientID  *uuid.UUID
+	Rule      *string
+	RuleType  *string
+	Severity  *string
+	Statuses  []string
+	Limit     int
+	Offset    int
 }
 
 type ProductRepository interface {
@@ -59,7 +191,9 @@ type KYCRepository interface {
 	Create(context.Context, *models.KYCRecord) error
 	Update(context.Context, *models.KYCRecord) error
 	Get(context.Context, uuid.UUID) (*models.KYCRecord, error)
+	GetByProviderReference(ctx context.Context, provider, reference string) (*models.KYCRecord, error)
 	ListByClient(context.Context, uuid.UUID) ([]models.KYCRecord, error)
+	List(context.Context, KYCFilter) ([]models.KYCRecord, error)
 }
 
 type InterestAccrualRepository interface {
@@ -69,18 +203,129 @@ type InterestAccrualRepository interface {
 	ListByAccount(context.Context, uuid.UUID) ([]models.InterestAccrual, error)
 }
 
+type InterestRateRepository interface {
+	Create(context.Context, *models.InterestRateVersion) error
+	// ListByProduct returns the rate changes of the product ordered by
+	// version.
+	ListByProduct(context.Context, uuid.UUID) ([]models.InterestRateVersion, error)
+}
+
+type TaxPostingRepository interface {
+	Create(context.Context, *models.TaxPosting) error
+	GetByInterestReference(context.Context, string) (*models.TaxPosting, error)
+	ListByAccount(context.Context, uuid.UUID) ([]models.TaxPosting, error)
+}
+
 type FeePostingRepository interface {
 	Create(context.Context, *models.FeePosting) error
 	Update(context.Context, *models.FeePosting) error
+	Get(context.Context, uuid.UUID) (*models.FeePosting, error)
 	GetByReference(context.Context, string) (*models.FeePosting, error)
+	List(context.Context, FeePostingFilter) ([]models.FeePosting, error)
 	ListByAccount(context.Context, uuid.UUID) ([]models.FeePosting, error)
 	ListPendingRecovery(context.Context) ([]models.FeePosting, error)
 }
 
+type LoanInstallmentRepository interface {
+	Create(context.Context, *models.LoanInstallment) error
+	Update(context.Context, *models.LoanInstallment) error
+	// ListByAccount returns the schedule of the loan ordered by installment
+	// number.
+	ListByAccount(context.Context, uuid.UUID) ([]models.LoanInstallment, error)
+}
+
 type DailyUsageRepository interface {
 	Create(context.Context, *models.AccountDailyUsage) error
 	Update(context.Context, *models.AccountDailyUsage) error
 	GetForDate(context.Context, uuid.UUID, time.Time) (*models.AccountDailyUsage, error)
+	// ListBetween returns the usage of the account from the first date to
+	// the second one, both included.
+	ListBetween(context.Context, uuid.UUID, time.Time, time.Time) ([]models.AccountDailyUsage, error)
+}
+
+type JobRunRepository interface {
+	Create(context.Context, *models.JobRun) error
+	Update(context.Context, *models.JobRun) error
+	Get(context.Context, uuid.UUID) (*models.JobRun, error)
+	List(context.Context, JobRunFilter) ([]models.JobRun, error)
+	// LastCompleted returns the succeeded run of the job with the latest
+	// business date. Partial runs still have failed items to retry.
+	LastCompleted(context.Context, string) (*models.JobRun, error)
+	// ClaimPending moves the oldest pending run of the job to running on
+	// behalf of the owner and returns it. Runs claimed by another worker are
+	// skipped.
+	ClaimPending(ctx context.Context, job, owner string) (*models.JobRun, error)
+	// Heartbeat records that the owner is still running the run.
+	Heartbeat(ctx context.Context, id uuid.UUID, owner string) error
+	// FailStale fails the running runs of the job whose last heartbeat is
+	// older than before and returns them.
+	FailStale(ctx context.Context, job string, before time.Time) ([]models.JobRun, error)
+}
+
+type JobLeaseRepository interface {
+	// Acquire takes or renews the lease for the holder. It reports false and
+	// the current lease when another holder has not let it expire yet.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*models.JobLease, bool, error)
+	// Release expires the lease if the holder still owns it.
+	Release(ctx context.Context, name, holder string) error
+	List(context.Context) ([]models.JobLease, error)
+}
+
+type ApprovalPolicyRepository interface {
+	Create(context.Context, *models.ApprovalPolicy) error
+	Update(context.Context, *models.ApprovalPolicy) error
+	Get(context.Context, uuid.UUID) (*models.ApprovalPolicy, error)
+	// List returns the policies ordered by creation date.
+	List(context.Context, ApprovalPolicyFilter) ([]models.ApprovalPolicy, error)
+}
+
+type ApprovalRequestRepository interface {
+	Create(context.Context, *models.ApprovalRequest) error
+	// Transition updates the request only if it is still in the given status
+	// and returns postgres.ErrNotFound otherwise.
+	Transition(context.Context, *models.ApprovalRequest, string) error
+	Get(context.Context, uuid.UUID) (*models.ApprovalRequest, error)
+	List(context.Context, ApprovalRequestFilter) ([]models.ApprovalRequest, error)
+}
+
+type ApprovalEventRepository interface {
+	Create(context.Context, *models.ApprovalEvent) error
+	ListByRequest(context.Context, uuid.UUID) ([]models.ApprovalEvent, error)
+}
+
+type ScreeningCaseRepository interface {
+	Create(context.Context, *models.ScreeningCase) error
+	// Transition updates the case only if it is still in the given status
+	// and returns postgres.ErrNotFound otherwise.
+	Transition(context.Context, *models.ScreeningCase, string) error
+	Get(context.Context, uuid.UUID) (*models.ScreeningCase, error)
+	List(context.Context, ScreeningCaseFilter) ([]models.ScreeningCase, error)
+}
+
+type MonitoredTransactionRepository interface {
+	Create(context.Context, *models.MonitoredTransaction) error
+	// GetByPosting returns the transaction monitored for the movement of
+	// the account in the given direction by a ledger transaction.
+	GetByPosting(ctx context.Context, ledger string, transactionID uint64, accountID uuid.UUID, direction string) (*models.MonitoredTransaction, error)
+	MarkEvaluated(context.Context, *models.MonitoredTransaction) error
+	// List returns the most recent transactions first.
+	List(context.Context, MonitoredTransactionFilter) ([]models.MonitoredTransaction, error)
+}
+
+type MonitoringAlertRepository interface {
+	Create(context.Context, *models.MonitoringAlert) error
+	Update(context.Context, *models.MonitoringAlert) error
+	// Transition updates the alert only if it is still in the given status
+	// and returns postgres.ErrNotFound otherwise.
+	Transition(context.Context, *models.MonitoringAlert, string) error
+	Get(context.Context, uuid.UUID) (*models.MonitoringAlert, error)
+	List(context.Context, MonitoringAlertFilter) ([]models.MonitoringAlert, error)
+}
+
+type MonitoringCursorRepository interface {
+	Get(ctx context.Context, ledger string) (*models.MonitoringCursor, error)
+	// Save never moves a cursor backward.
+	Save(context.Context, *models.MonitoringCursor) error
 }
 
 type BunProductRepository struct {
@@ -103,14 +348,62 @@ type BunInterestAccrualRepository struct {
 	db bun.IDB
 }
 
+type BunInterestRateRepository struct {
+	db bun.IDB
+}
+
+type BunTaxPostingRepository struct {
+	db bun.IDB
+}
+
 type BunFeePostingRepository struct {
 	db bun.IDB
 }
 
+type BunLoanInstallmentRepository struct {
+	db bun.IDB
+}
+
 type BunDailyUsageRepository struct {
 	db bun.IDB
 }
 
+type BunJobRunRepository struct {
+	db bun.IDB
+}
+
+type BunJobLeaseRepository struct {
+	db bun.IDB
+}
+
+type BunApprovalPolicyRepository struct {
+	db bun.IDB
+}
+
+type BunApprovalRequestRepository struct {
+	db bun.IDB
+}
+
+type BunApprovalEventRepository struct {
+	db bun.IDB
+}
+
+type BunScreeningCaseRepository struct {
+	db bun.IDB
+}
+
+type BunMonitoredTransactionRepository struct {
+	db bun.IDB
+}
+
+type BunMonitoringAlertRepository struct {
+	db bun.IDB
+}
+
+type BunMonitoringCursorRepository struct {
+	db bun.IDB
+}
+
 func NewProductRepository(db bun.IDB) *BunProductRepository {
 	return &BunProductRepository{db: db}
 }
@@ -131,14 +424,62 @@ func NewInterestAccrualRepository(db bun.IDB) *BunInterestAccrualRepository {
 	return &BunInterestAccrualRepository{db: db}
 }
 
+func NewInterestRateRepository(db bun.IDB) *BunInterestRateRepository {
+	return &BunInterestRateRepository{db: db}
+}
+
+func NewTaxPostingRepository(db bun.IDB) *BunTaxPostingRepository {
+	return &BunTaxPostingRepository{db: db}
+}
+
 func NewFeePostingRepository(db bun.IDB) *BunFeePostingRepository {
 	return &BunFeePostingRepository{db: db}
 }
 
+func NewLoanInstallmentRepository(db bun.IDB) *BunLoanInstallmentRepository {
+	return &BunLoanInstallmentRepository{db: db}
+}
+
 func NewDailyUsageRepository(db bun.IDB) *BunDailyUsageRepository {
 	return &BunDailyUsageRepository{db: db}
 }
 
+func NewJobRunRepository(db bun.IDB) *BunJobRunRepository {
+	return &BunJobRunRepository{db: db}
+}
+
+func NewJobLeaseRepository(db bun.IDB) *BunJobLeaseRepository {
+	return &BunJobLeaseRepository{db: db}
+}
+
+func NewApprovalPolicyRepository(db bun.IDB) *BunApprovalPolicyRepository {
+	return &BunApprovalPolicyRepository{db: db}
+}
+
+func NewApprovalRequestRepository(db bun.IDB) *BunApprovalRequestRepository {
+	return &BunApprovalRequestRepository{db: db}
+}
+
+func NewApprovalEventRepository(db bun.IDB) *BunApprovalEventRepository {
+	return &BunApprovalEventRepository{db: db}
+}
+
+func NewScreeningCaseRepository(db bun.IDB) *BunScreeningCaseRepository {
+	return &BunScreeningCaseRepository{db: db}
+}
+
+func NewMonitoredTransactionRepository(db bun.IDB) *BunMonitoredTransactionRepository {
+	return &BunMonitoredTransactionRepository{db: db}
+}
+
+func NewMonitoringAlertRepository(db bun.IDB) *BunMonitoringAlertRepository {
+	return &BunMonitoringAlertRepository{db: db}
+}
+
+func NewMonitoringCursorRepository(db bun.IDB) *BunMonitoringCursorRepository {
+	return &BunMonitoringCursorRepository{db: db}
+}
+
 func (r *BunProductRepository) Create(ctx context.Context, product *models.Product) error {
 	setUUID(&product.ID)
 	_, err := r.db.NewInsert().Model(product).Returning("*").Exec(ctx)
@@ -149,7 +490,7 @@ func (r *BunProductRepository) Update(ctx context.Context, product *models.Produ
 	product.UpdatedAt = time.Now().UTC()
 	_, err := r.db.NewUpdate().
 		Model(product).
-		Column("code", "name", "description", "category", "currency", "status", "rules", "interest_config", "fee_schedule", "updated_at").
+		Column("code", "name", "description", "category", "currency", "status", "rules", "interest_config", "fee_schedule", "term_config", "loan_config", "tax_config", "updated_at").
 		WherePK().
 		Returning("*").
 		Exec(ctx)
@@ -170,7 +511,20 @@ func (r *BunProductRepository) GetByCode(ctx context.Context, code string) (*mod
 
 func (r *BunProductRepository) List(ctx context.Context, filter ProductFilter) ([]models.Product, error) {
 	products := make([]models.Product, 0)
-	query := r.db.NewSelect().Model(&products).OrderExpr("created_at desc")
+	query := r.db.NewSelect().Model(&products)
+	query = applyPage(query, filter.Page, ProductSortColumns)
+	if filter.Search != nil {
+		pattern := searchPattern(*filter.Search)
+		query = query.WhereGroup(" and ", func(query *bun.SelectQuery) *bun.SelectQuery {
+			return query.Where("code ilike ?", pattern).WhereOr("name ilike ?", pattern)
+		})
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedBefore)
+	}
 	if filter.Category != nil {
 		query = query.Where("category = ?", *filter.Category)
 	}
@@ -194,7 +548,7 @@ func (r *BunClientRepository) Update(ctx context.Context, client *models.Client)
 	client.UpdatedAt = time.Now().UTC()
 	_, err := r.db.NewUpdate().
 		Model(client).
-		Column("client_number", "type", "status", "kyc_level", "kyc_status", "kyc_data", "contact", "individual_data", "corporate_data", "updated_at").
+		Column("client_number", "type", "status", "kyc_level", "kyc_status", "kyc_data", "contact", "individual_data", "corporate_data", "tax_residency", "tax_exemption", "screening_status", "screening_version", "screened_at", "updated_at").
 		WherePK().
 		Returning("*").
 		Exec(ctx)
@@ -213,9 +567,35 @@ func (r *BunClientRepository) GetByNumber(ctx context.Context, clientNumber stri
 	return client, postgres.ResolveError(err)
 }
 
+// clientFullNameExpression joins the names of an individual client like
+// concat_ws, which cannot be indexed. It must match the expression of the
+// idx_clients_search_full_name index.
+const clientFullNameExpression = "trim(coalesce(individual_data->>'first_name', '') || coalesce(' ' || (individual_data->>'middle_name'), '') || coalesce(' ' || (individual_data->>'last_name'), ''))"
+
 func (r *BunClientRepository) List(ctx context.Context, filter ClientFilter) ([]models.Client, error) {
 	clients := make([]models.Client, 0)
-	query := r.db.NewSelect().Model(&clients).OrderExpr("created_at desc")
+	query := r.db.NewSelect().Model(&clients)
+	query = applyPage(query, filter.Page, ClientSortColumns)
+	if filter.Search != nil {
+		pattern := searchPattern(*filter.Search)
+		query = query.WhereGroup(" and ", func(query *bun.SelectQuery) *bun.SelectQuery {
+			return query.
+				Where("client_number ilike ?", pattern).
+				WhereOr("contact->>'email' ilike ?", pattern).
+				WhereOr("contact->>'phone' ilike ?", pattern).
+				WhereOr(clientFullNameExpression+" ilike ?", pattern).
+				WhereOr("individual_data->>'national_id_number' ilike ?", pattern).
+				WhereOr("corporate_data->>'legal_name' ilike ?", pattern).
+				WhereOr("corporate_data->>'trading_name' ilike ?", pattern).
+				WhereOr("exists (select 1 from _system.accounts where accounts.client_id = clients.id and accounts.account_number ilike ?)", pattern)
+		})
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedBefore)
+	}
 	if filter.Type != nil {
 		query = query.Where("type = ?", *filter.Type)
 	}
@@ -225,6 +605,9 @@ func (r *BunClientRepository) List(ctx context.Context, filter ClientFilter) ([]
 	if filter.KYCStatus != nil {
 		query = query.Where("kyc_status = ?", *filter.KYCStatus)
 	}
+	if filter.NotScreenedWith != nil {
+		query = query.Where("screening_version is distinct from ?", *filter.NotScreenedWith)
+	}
 	err := query.Scan(ctx)
 	return clients, postgres.ResolveError(err)
 }
@@ -238,7 +621,7 @@ func (r *BunAccountRepository) Create(ctx context.Context, account *models.Accou
 func (r *BunAccountRepository) Update(ctx context.Context, account *models.Account) error {
 	_, err := r.db.NewUpdate().
 		Model(account).
-		Column("account_number", "client_id", "product_id", "currency", "status", "wallet_id", "freeze_debits", "activated_at", "closed_at", "last_activity_at", "interest_accrued", "metadata").
+		Column("account_number", "client_id", "product_id", "currency", "status", "wallet_id", "freeze_debits", "kyc_restricted", "activated_at", "closed_at", "last_activity_at", "interest_accrued", "term", "maturity_date", "loan", "holders", "signing_rule", "metadata").
 		WherePK().
 		Returning("*").
 		Exec(ctx)
@@ -265,16 +648,38 @@ func (r *BunAccountRepository) GetByWalletID(ctx context.Context, walletID strin
 
 func (r *BunAccountRepository) List(ctx context.Context, filter AccountFilter) ([]models.Account, error) {
 	accounts := make([]models.Account, 0)
-	query := r.db.NewSelect().Model(&accounts).OrderExpr("opened_at desc")
+	query := r.db.NewSelect().Model(&accounts)
+	query = applyPage(query, filter.Page, AccountSortColumns)
+	if filter.Search != nil {
+		query = query.Where("account_number ilike ?", searchPattern(*filter.Search))
+	}
+	if filter.OpenedAfter != nil {
+		query = query.Where("opened_at >= ?", *filter.OpenedAfter)
+	}
+	if filter.OpenedBefore != nil {
+		query = query.Where("opened_at <= ?", *filter.OpenedBefore)
+	}
 	if filter.ClientID != nil {
 		query = query.Where("client_id = ?", *filter.ClientID)
 	}
+	if filter.HolderID != nil {
+		query = query.Where("holders @> jsonb_build_array(jsonb_build_object('client_id', ?::text))", filter.HolderID.String())
+	}
 	if filter.ProductID != nil {
 		query = query.Where("product_id = ?", *filter.ProductID)
 	}
 	if filter.Status != nil {
 		query = query.Where("status = ?", *filter.Status)
 	}
+	if filter.MaturesBy != nil {
+		query = query.Where("maturity_date <= ?", *filter.MaturesBy)
+	}
+	if filter.Loans {
+		query = query.Where("loan is not null")
+	}
+	if filter.KYCRestricted {
+		query = query.Where("kyc_restricted")
+	}
 	err := query.Scan(ctx)
 	return accounts, postgres.ResolveError(err)
 }
@@ -288,7 +693,7 @@ func (r *BunKYCRepository) Create(ctx context.Context, record *models.KYCRecord)
 func (r *BunKYCRepository) Update(ctx context.Context, record *models.KYCRecord) error {
 	_, err := r.db.NewUpdate().
 		Model(record).
-		Column("client_id", "level", "status", "submitted_at", "verified_at", "expires_at", "verifier", "reason", "documents", "payload").
+		Column("client_id", "level", "status", "submitted_at", "verified_at", "expires_at", "verifier", "reason", "documents", "payload", "provider", "provider_reference", "expiry_notified_at").
 		WherePK().
 		Returning("*").
 		Exec(ctx)
@@ -301,6 +706,16 @@ func (r *BunKYCRepository) Get(ctx context.Context, id uuid.UUID) (*models.KYCRe
 	return record, postgres.ResolveError(err)
 }
 
+func (r *BunKYCRepository) GetByProviderReference(ctx context.Context, provider, reference string) (*models.KYCRecord, error) {
+	record := &models.KYCRecord{}
+	err := r.db.NewSelect().
+		Model(record).
+		Where("provider = ?", provider).
+		Where("provider_reference = ?", reference).
+		Scan(ctx)
+	return record, postgres.ResolveError(err)
+}
+
 func (r *BunKYCRepository) ListByClient(ctx context.Context, clientID uuid.UUID) ([]models.KYCRecord, error) {
 	records := make([]models.KYCRecord, 0)
 	err := r.db.NewSelect().
@@ -311,6 +726,22 @@ func (r *BunKYCRepository) ListByClient(ctx context.Context, clientID uuid.UUID)
 	return records, postgres.ResolveError(err)
 }
 
+func (r *BunKYCRepository) List(ctx context.Context, filter KYCFilter) ([]models.KYCRecord, error) {
+	records := make([]models.KYCRecord, 0)
+	query := r.db.NewSelect().Model(&records).OrderExpr("expires_at asc nulls last, submitted_at asc")
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.ExpiresBefore != nil {
+		query = query.Where("expires_at <= ?", *filter.ExpiresBefore)
+	}
+	if filter.Unnotified {
+		query = query.Where("expiry_notified_at is null")
+	}
+	err := query.Scan(ctx)
+	return records, postgres.ResolveError(err)
+}
+
 func (r *BunInterestAccrualRepository) Create(ctx context.Context, accrual *models.InterestAccrual) error {
 	setUUID(&accrual.ID)
 	_, err := r.db.NewInsert().Model(accrual).Returning("*").Exec(ctx)
@@ -320,7 +751,7 @@ func (r *BunInterestAccrualRepository) Create(ctx context.Context, accrual *mode
 func (r *BunInterestAccrualRepository) Update(ctx context.Context, accrual *models.InterestAccrual) error {
 	_, err := r.db.NewUpdate().
 		Model(accrual).
-		Column("account_id", "accrual_date", "balance_basis", "rate", "amount", "posted", "posted_reference", "metadata").
+		Column("account_id", "accrual_date", "balance_basis", "rate", "amount", "posted", "posted_reference", "day_count", "rate_version", "metadata").
 		WherePK().
 		Returning("*").
 		Exec(ctx)
@@ -343,6 +774,44 @@ func (r *BunInterestAccrualRepository) ListByAccount(ctx context.Context, accoun
 	return accruals, postgres.ResolveError(err)
 }
 
+func (r *BunInterestRateRepository) Create(ctx context.Context, version *models.InterestRateVersion) error {
+	setUUID(&version.ID)
+	_, err := r.db.NewInsert().Model(version).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunInterestRateRepository) ListByProduct(ctx context.Context, productID uuid.UUID) ([]models.InterestRateVersion, error) {
+	versions := make([]models.InterestRateVersion, 0)
+	err := r.db.NewSelect().
+		Model(&versions).
+		Where("product_id = ?", productID).
+		OrderExpr("version asc").
+		Scan(ctx)
+	return versions, postgres.ResolveError(err)
+}
+
+func (r *BunTaxPostingRepository) Create(ctx context.Context, posting *models.TaxPosting) error {
+	setUUID(&posting.ID)
+	_, err := r.db.NewInsert().Model(posting).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunTaxPostingRepository) GetByInterestReference(ctx context.Context, reference string) (*models.TaxPosting, error) {
+	posting := &models.TaxPosting{}
+	err := r.db.NewSelect().Model(posting).Where("interest_reference = ?", reference).Scan(ctx)
+	return posting, postgres.ResolveError(err)
+}
+
+func (r *BunTaxPostingRepository) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]models.TaxPosting, error) {
+	postings := make([]models.TaxPosting, 0)
+	err := r.db.NewSelect().
+		Model(&postings).
+		Where("account_id = ?", accountID).
+		OrderExpr("created_at desc").
+		Scan(ctx)
+	return postings, postgres.ResolveError(err)
+}
+
 func (r *BunFeePostingRepository) Create(ctx context.Context, feePosting *models.FeePosting) error {
 	setUUID(&feePosting.ID)
 	_, err := r.db.NewInsert().Model(feePosting).Returning("*").Exec(ctx)
@@ -352,19 +821,47 @@ func (r *BunFeePostingRepository) Create(ctx context.Context, feePosting *models
 func (r *BunFeePostingRepository) Update(ctx context.Context, feePosting *models.FeePosting) error {
 	_, err := r.db.NewUpdate().
 		Model(feePosting).
-		Column("account_id", "event_type", "reference", "linked_reference", "amount", "currency", "status", "metadata").
+		Column("account_id", "event_type", "reference", "linked_reference", "amount", "currency", "status", "recovered_amount", "recovery", "metadata").
 		WherePK().
 		Returning("*").
 		Exec(ctx)
 	return postgres.ResolveError(err)
 }
 
+func (r *BunFeePostingRepository) Get(ctx context.Context, id uuid.UUID) (*models.FeePosting, error) {
+	feePosting := &models.FeePosting{}
+	err := r.db.NewSelect().Model(feePosting).Where("id = ?", id).Scan(ctx)
+	return feePosting, postgres.ResolveError(err)
+}
+
 func (r *BunFeePostingRepository) GetByReference(ctx context.Context, reference string) (*models.FeePosting, error) {
 	feePosting := &models.FeePosting{}
 	err := r.db.NewSelect().Model(feePosting).Where("reference = ?", reference).Scan(ctx)
 	return feePosting, postgres.ResolveError(err)
 }
 
+func (r *BunFeePostingRepository) List(ctx context.Context, filter FeePostingFilter) ([]models.FeePosting, error) {
+	feePostings := make([]models.FeePosting, 0)
+	query := r.db.NewSelect().Model(&feePostings)
+	if filter.AccountID != nil {
+		query = query.Where("account_id = ?", *filter.AccountID)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.EventType != nil {
+		query = query.Where("event_type = ?", *filter.EventType)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	err := query.OrderExpr("created_at desc").Scan(ctx)
+	return feePostings, postgres.ResolveError(err)
+}
+
 func (r *BunFeePostingRepository) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]models.FeePosting, error) {
 	feePostings := make([]models.FeePosting, 0)
 	err := r.db.NewSelect().
@@ -385,6 +882,33 @@ func (r *BunFeePostingRepository) ListPendingRecovery(ctx context.Context) ([]mo
 	return feePostings, postgres.ResolveError(err)
 }
 
+func (r *BunLoanInstallmentRepository) Create(ctx context.Context, installment *models.LoanInstallment) error {
+	setUUID(&installment.ID)
+	_, err := r.db.NewInsert().Model(installment).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunLoanInstallmentRepository) Update(ctx context.Context, installment *models.LoanInstallment) error {
+	installment.UpdatedAt = time.Now().UTC()
+	_, err := r.db.NewUpdate().
+		Model(installment).
+		Column("due_date", "principal", "interest", "principal_paid", "interest_paid", "status", "paid_at", "updated_at").
+		WherePK().
+		Returning("*").
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunLoanInstallmentRepository) ListByAccount(ctx context.Context, accountID uuid.UUID) ([]models.LoanInstallment, error) {
+	installments := make([]models.LoanInstallment, 0)
+	err := r.db.NewSelect().
+		Model(&installments).
+		Where("account_id = ?", accountID).
+		OrderExpr("number asc").
+		Scan(ctx)
+	return installments, postgres.ResolveError(err)
+}
+
 func (r *BunDailyUsageRepository) Create(ctx context.Context, usage *models.AccountDailyUsage) error {
 	setUUID(&usage.ID)
 	_, err := r.db.NewInsert().Model(usage).Returning("*").Exec(ctx)
@@ -412,8 +936,546 @@ func (r *BunDailyUsageRepository) GetForDate(ctx context.Context, accountID uuid
 	return usage, postgres.ResolveError(err)
 }
 
+func (r *BunDailyUsageRepository) ListBetween(ctx context.Context, accountID uuid.UUID, from, to time.Time) ([]models.AccountDailyUsage, error) {
+	usages := make([]models.AccountDailyUsage, 0)
+	err := r.db.NewSelect().
+		Model(&usages).
+		Where("account_id = ?", accountID).
+		Where("usage_date >= ?", from.UTC().Truncate(24*time.Hour)).
+		Where("usage_date <= ?", to.UTC().Truncate(24*time.Hour)).
+		OrderExpr("usage_date asc").
+		Scan(ctx)
+	return usages, postgres.ResolveError(err)
+}
+
+func (r *BunJobRunRepository) Create(ctx context.Context, run *models.JobRun) error {
+	setUUID(&run.ID)
+	_, err := r.db.NewInsert().Model(run).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunJobRunRepository) Update(ctx context.Context, run *models.JobRun) error {
+	run.UpdatedAt = time.Now().UTC()
+	_, err := r.db.NewUpdate().
+		Model(run).
+		Column("status", "processed", "skipped", "failed", "errors", "started_at", "finished_at", "updated_at").
+		WherePK().
+		Returning("*").
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunJobRunRepository) Get(ctx context.Context, id uuid.UUID) (*models.JobRun, error) {
+	run := &models.JobRun{}
+	err := r.db.NewSelect().Model(run).Where("id = ?", id).Scan(ctx)
+	return run, postgres.ResolveError(err)
+}
+
+func (r *BunJobRunRepository) List(ctx context.Context, filter JobRunFilter) ([]models.JobRun, error) {
+	runs := make([]models.JobRun, 0)
+	query := r.db.NewSelect().Model(&runs)
+	if filter.Job != nil {
+		query = query.Where("job = ?", *filter.Job)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.From != nil {
+		query = query.Where("business_date >= ?", filter.From.UTC().Truncate(24*time.Hour))
+	}
+	if filter.To != nil {
+		query = query.Where("business_date <= ?", filter.To.UTC().Truncate(24*time.Hour))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	err := query.OrderExpr("business_date desc, created_at desc").Scan(ctx)
+	return runs, postgres.ResolveError(err)
+}
+
+func (r *BunJobRunRepository) LastCompleted(ctx context.Context, job string) (*models.JobRun, error) {
+	run := &models.JobRun{}
+	err := r.db.NewSelect().
+		Model(run).
+		Where("job = ?", job).
+		Where("status = ?", models.JobRunStatusSucceeded).
+		OrderExpr("business_date desc").
+		Limit(1).
+		Scan(ctx)
+	return run, postgres.ResolveError(err)
+}
+
+func (r *BunJobRunRepository) ClaimPending(ctx context.Context, job, owner string) (*models.JobRun, error) {
+	now := time.Now().UTC()
+	run := &models.JobRun{}
+	err := r.db.NewUpdate().
+		Model(run).
+		Set("status = ?", models.JobRunStatusRunning).
+		Set("owner = ?", owner).
+		Set("started_at = ?", now).
+		Set("heartbeat_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("id = (?)", r.db.NewSelect().
+			Model((*models.JobRun)(nil)).
+			Column("id").
+			Where("job = ?", job).
+			Where("status = ?", models.JobRunStatusPending).
+			OrderExpr("business_date asc, created_at asc").
+			Limit(1).
+			For("update skip locked")).
+		Returning("*").
+		Scan(ctx)
+	return run, postgres.ResolveError(err)
+}
+
+func (r *BunJobRunRepository) Heartbeat(ctx context.Context, id uuid.UUID, owner string) error {
+	err := r.db.NewUpdate().
+		Model(&models.JobRun{}).
+		Set("heartbeat_at = ?", time.Now().UTC()).
+		Where("id = ?", id).
+		Where("owner = ?", owner).
+		Where("status = ?", models.JobRunStatusRunning).
+		Returning("id").
+		Scan(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunJobRunRepository) FailStale(ctx context.Context, job string, before time.Time) ([]models.JobRun, error) {
+	now := time.Now().UTC()
+	runs := make([]models.JobRun, 0)
+	err := r.db.NewUpdate().
+		Model(&runs).
+		Set("status = ?", models.JobRunStatusFailed).
+		Set("errors = errors || jsonb_build_array('abandoned by ' || coalesce(owner, 'an unknown worker'))").
+		Set("finished_at = ?", now).
+		Set("updated_at = ?", now).
+		Where("job = ?", job).
+		Where("status = ?", models.JobRunStatusRunning).
+		Where("coalesce(heartbeat_at, started_at, created_at) < ?", before.UTC()).
+		Returning("*").
+		Scan(ctx)
+	return runs, postgres.ResolveError(err)
+}
+
+func (r *BunJobLeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (*models.JobLease, bool, error) {
+	lease := &models.JobLease{}
+	err := r.db.NewRaw(`
+		insert into _system.cba_job_leases (name, holder, acquired_at, renewed_at, expires_at)
+		values (?, ?, now() at time zone 'utc', now() at time zone 'utc', now() at time zone 'utc' + ? * interval '1 millisecond')
+		on conflict (name) do update set
+			holder = excluded.holder,
+			acquired_at = case when cba_job_leases.holder = excluded.holder then cba_job_leases.acquired_at else excluded.acquired_at end,
+			renewed_at = excluded.renewed_at,
+			expires_at = excluded.expires_at
+		where cba_job_leases.holder = excluded.holder or cba_job_leases.expires_at <= excluded.renewed_at
+		returning *
+	`, name, holder, ttl.Milliseconds()).Scan(ctx, lease)
+	if err == nil {
+		return lease, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, postgres.ResolveError(err)
+	}
+
+	lease = &models.JobLease{}
+	err = r.db.NewSelect().Model(lease).Where("name = ?", name).Scan(ctx)
+	return lease, false, postgres.ResolveError(err)
+}
+
+func (r *BunJobLeaseRepository) Release(ctx context.Context, name, holder string) error {
+	_, err := r.db.NewUpdate().
+		Model((*models.JobLease)(nil)).
+		Set("expires_at = now() at time zone 'utc'").
+		Where("name = ?", name).
+		Where("holder = ?", holder).
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunJobLeaseRepository) List(ctx context.Context) ([]models.JobLease, error) {
+	leases := make([]models.JobLease, 0)
+	err := r.db.NewSelect().Model(&leases).OrderExpr("name asc").Scan(ctx)
+	return leases, postgres.ResolveError(err)
+}
+
+func (r *BunApprovalPolicyRepository) Create(ctx context.Context, policy *models.ApprovalPolicy) error {
+	setUUID(&policy.ID)
+	_, err := r.db.NewInsert().Model(policy).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunApprovalPolicyRepository) Update(ctx context.Context, policy *models.ApprovalPolicy) error {
+	policy.UpdatedAt = time.Now().UTC()
+	_, err := r.db.NewUpdate().
+		Model(policy).
+		Column("product_id", "currency", "min_amount", "enabled", "updated_at").
+		WherePK().
+		Returning("*").
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunApprovalPolicyRepository) Get(ctx context.Context, id uuid.UUID) (*models.ApprovalPolicy, error) {
+	policy := &models.ApprovalPolicy{}
+	err := r.db.NewSelect().Model(policy).Where("id = ?", id).Scan(ctx)
+	return policy, postgres.ResolveError(err)
+}
+
+func (r *BunApprovalPolicyRepository) List(ctx context.Context, filter ApprovalPolicyFilter) ([]models.ApprovalPolicy, error) {
+	policies := make([]models.ApprovalPolicy, 0)
+	query := r.db.NewSelect().Model(&policies)
+	if filter.Operation != nil {
+		query = query.Where("operation = ?", *filter.Operation)
+	}
+	if filter.Enabled != nil {
+		query = query.Where("enabled = ?", *filter.Enabled)
+	}
+	err := query.OrderExpr("created_at asc").Scan(ctx)
+	return policies, postgres.ResolveError(err)
+}
+
+func (r *BunApprovalRequestRepository) Create(ctx context.Context, request *models.ApprovalRequest) error {
+	setUUID(&request.ID)
+	_, err := r.db.NewInsert().Model(request).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunApprovalRequestRepository) Transition(ctx context.Context, request *models.ApprovalRequest, from string) error {
+	request.UpdatedAt = time.Now().UTC()
+	err := r.db.NewUpdate().
+		Model(request).
+		Column("status", "decided_by", "decided_at", "comment", "result_status", "result", "executed_at", "updated_at").
+		WherePK().
+		Where("status = ?", from).
+		Returning("*").
+		Scan(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunApprovalRequestRepository) Get(ctx context.Context, id uuid.UUID) (*models.ApprovalRequest, error) {
+	request := &models.ApprovalRequest{}
+	err := r.db.NewSelect().Model(request).Where("id = ?", id).Scan(ctx)
+	return request, postgres.ResolveError(err)
+}
+
+func (r *BunApprovalRequestRepository) List(ctx context.Context, filter ApprovalRequestFilter) ([]models.ApprovalRequest, error) {
+	requests := make([]models.ApprovalRequest, 0)
+	query := r.db.NewSelect().Model(&requests)
+	if filter.Operation != nil {
+		query = query.Where("operation = ?", *filter.Operation)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.ResourceID != nil {
+		query = query.Where("resource_id = ?", *filter.ResourceID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	err := query.OrderExpr("created_at desc").Scan(ctx)
+	return requests, postgres.ResolveError(err)
+}
+
+func (r *BunApprovalEventRepository) Create(ctx context.Context, event *models.ApprovalEvent) error {
+	setUUID(&event.ID)
+	_, err := r.db.NewInsert().Model(event).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunApprovalEventRepository) ListByRequest(ctx context.Context, requestID uuid.UUID) ([]models.ApprovalEvent, error) {
+	events := make([]models.ApprovalEvent, 0)
+	err := r.db.NewSelect().
+		Model(&events).
+		Where("request_id = ?", requestID).
+		OrderExpr("created_at asc").
+		Scan(ctx)
+	return events, postgres.ResolveError(err)
+}
+
+func (r *BunScreeningCaseRepository) Create(ctx context.Context, screeningCase *models.ScreeningCase) error {
+	setUUID(&screeningCase.ID)
+	_, err := r.db.NewInsert().Model(screeningCase).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunScreeningCaseRepository) Transition(ctx context.Context, screeningCase *models.ScreeningCase, from string) error {
+	screeningCase.UpdatedAt = time.Now().UTC()
+	err := r.db.NewUpdate().
+		Model(screeningCase).
+		Column("status", "reviewed_by", "review_note", "reviewed_at", "updated_at").
+		WherePK().
+		Where("status = ?", from).
+		Returning("*").
+		Scan(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunScreeningCaseRepository) Get(ctx context.Context, id uuid.UUID) (*models.ScreeningCase, error) {
+	screeningCase := &models.ScreeningCase{}
+	err := r.db.NewSelect().Model(screeningCase).Where("id = ?", id).Scan(ctx)
+	return screeningCase, postgres.ResolveError(err)
+}
+
+func (r *BunScreeningCaseRepository) List(ctx context.Context, filter ScreeningCaseFilter) ([]models.ScreeningCase, error) {
+	cases := make([]models.ScreeningCase, 0)
+	query := r.db.NewSelect().Model(&cases)
+	if filter.ClientID != nil {
+		query = query.Where("client_id = ?", *filter.ClientID)
+	}
+	if filter.SubjectType != nil {
+		query = query.Where("subject_type = ?", *filter.SubjectType)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	err := query.OrderExpr("created_at desc").Scan(ctx)
+	return cases, postgres.ResolveError(err)
+}
+
+func (r *BunMonitoredTransactionRepository) Create(ctx context.Context, transaction *models.MonitoredTransaction) error {
+	setUUID(&transaction.ID)
+	_, err := r.db.NewInsert().Model(transaction).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunMonitoredTransactionRepository) GetByPosting(ctx context.Context, ledger string, transactionID uint64, accountID uuid.UUID, direction string) (*models.MonitoredTransaction, error) {
+	transaction := &models.MonitoredTransaction{}
+	err := r.db.NewSelect().
+		Model(transaction).
+		Where("ledger = ?", ledger).
+		Where("transaction_id = ?", transactionID).
+		Where("account_id = ?", accountID).
+		Where("direction = ?", direction).
+		Scan(ctx)
+	if err != nil {
+		return nil, postgres.ResolveError(err)
+	}
+	return transaction, nil
+}
+
+func (r *BunMonitoredTransactionRepository) MarkEvaluated(ctx context.Context, transaction *models.MonitoredTransaction) error {
+	evaluatedAt := time.Now().UTC()
+	transaction.EvaluatedAt = &evaluatedAt
+	_, err := r.db.NewUpdate().
+		Model(transaction).
+		Column("evaluated_at").
+		WherePK().
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunMonitoredTransactionRepository) List(ctx context.Context, filter MonitoredTransactionFilter) ([]models.MonitoredTransaction, error) {
+	transactions := make([]models.MonitoredTransaction, 0)
+	query := r.db.NewSelect().Model(&transactions)
+	if filter.AccountID != nil {
+		query = query.Where("account_id = ?", *filter.AccountID)
+	}
+	if filter.From != nil {
+		query = query.Where("timestamp >= ?", filter.From.UTC())
+	}
+	if filter.Before != nil {
+		query = query.Where("timestamp < ?", filter.Before.UTC())
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	err := query.OrderExpr("timestamp desc, transaction_id desc").Scan(ctx)
+	return transactions, postgres.ResolveError(err)
+}
+
+func (r *BunMonitoringAlertRepository) Create(ctx context.Context, alert *models.MonitoringAlert) error {
+	setUUID(&alert.ID)
+	_, err := r.db.NewInsert().Model(alert).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunMonitoringAlertRepository) Update(ctx context.Context, alert *models.MonitoringAlert) error {
+	alert.UpdatedAt = time.Now().UTC()
+	_, err := r.db.NewUpdate().
+		Model(alert).
+		Column("severity", "summary", "evidence", "updated_at").
+		WherePK().
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunMonitoringAlertRepository) Transition(ctx context.Context, alert *models.MonitoringAlert, from string) error {
+	alert.UpdatedAt = time.Now().UTC()
+	err := r.db.NewUpdate().
+		Model(alert).
+		Column("status", "assignee", "resolution", "notes", "closed_at", "updated_at").
+		WherePK().
+		Where("status = ?", from).
+		Returning("*").
+		Scan(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunMonitoringAlertRepository) Get(ctx context.Context, id uuid.UUID) (*models.MonitoringAlert, error) {
+	alert := &models.MonitoringAlert{}
+	err := r.db.NewSelect().Model(alert).Where("id = ?", id).Scan(ctx)
+	return alert, postgres.ResolveError(err)
+}
+
+func (r *BunMonitoringAlertRepository) List(ctx context.Context, filter MonitoringAlertFilter) ([]models.MonitoringAlert, error) {
+	alerts := make([]models.MonitoringAlert, 0)
+	query := r.db.NewSelect().Model(&alerts)
+	if filter.AccountID != nil {
+		query = query.Where("account_id = ?", *filter.AccountID)
+	}
+	if filter.ClientID != nil {
+		query = query.Where("client_id = ?", *filter.ClientID)
+	}
+	if filter.Rule != nil {
+		query = query.Where("rule = ?", *filter.Rule)
+	}
+	if filter.RuleType != nil {
+		query = query.Where("rule_type = ?", *filter.RuleType)
+	}
+	if filter.Severity != nil {
+		query = query.Where("severity = ?", *filter.Severity)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	err := query.OrderExpr("created_at desc").Scan(ctx)
+	return alerts, postgres.ResolveError(err)
+}
+
+func (r *BunMonitoringCursorRepository) Get(ctx context.Context, ledger string) (*models.MonitoringCursor, error) {
+	cursor := &models.MonitoringCursor{}
+	err := r.db.NewSelect().Model(cursor).Where("ledger = ?", ledger).Scan(ctx)
+	return cursor, postgres.ResolveError(err)
+}
+
+func (r *BunMonitoringCursorRepository) Save(ctx context.Context, cursor *models.MonitoringCursor) error {
+	cursor.UpdatedAt = time.Now().UTC()
+	_, err := r.db.NewInsert().
+		Model(cursor).
+		On("conflict (ledger) do update").
+		Set("last_log_id = greatest(cba_monitoring_cursors.last_log_id, excluded.last_log_id)").
+		Set("updated_at = excluded.updated_at").
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
 func setUUID(id *uuid.UUID) {
 	if *id == uuid.Nil {
 		*id = uuid.New()
 	}
 }
+
+// sortExpressions are the expressions a listing is sorted on in place of
+// the nullable columns, which cannot be compared to a position.
+var sortExpressions = map[string]string{
+	"last_activity_at": "coalesce(last_activity_at, '-infinity')",
+}
+
+// keysetTimeLayout formats the dates of the positions as timestamps without
+// time zone, which are stored in UTC.
+const keysetTimeLayout = "2006-01-02 15:04:05.999999"
+
+// applyPage orders a listing on the sort of page when it is valid for the
+// listing, on its default column, most recent first, otherwise, the id
+// breaking the ties, and selects the page from the position of page. A nil
+// page selects the whole listing.
+func applyPage(query *bun.SelectQuery, page *Page, columns []string) *bun.SelectQuery {
+	column, order := columns[0], bunpaginate.Order(bunpaginate.OrderDesc)
+	if page != nil && page.Sort != nil && slices.Contains(columns, page.Sort.Column) &&
+		(page.Sort.Order == bunpaginate.OrderAsc || page.Sort.Order == bunpaginate.OrderDesc) {
+		column, order = page.Sort.Column, page.Sort.Order
+	}
+	if expression, ok := sortExpressions[column]; ok {
+		column = expression
+	}
+	if page == nil {
+		return query.OrderExpr(fmt.Sprintf("%s %s, id %s", column, order, order))
+	}
+
+	position := page.After
+	if page.Before != nil {
+		position, order = page.Before, order.Reverse()
+	}
+	if position != nil {
+		comparison := ">"
+		if order == bunpaginate.OrderDesc {
+			comparison = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, comparison), position.Value, position.ID)
+	}
+	query = query.OrderExpr(fmt.Sprintf("%s %s, id %s", column, order, order))
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	return query
+}
+
+// ProductKeyset is the position of a product in the listing sorted on column.
+func ProductKeyset(product models.Product, column string) Keyset {
+	switch column {
+	case "updated_at":
+		return timeKeyset(product.UpdatedAt, product.ID)
+	case "code":
+		return Keyset{Value: product.Code, ID: product.ID}
+	case "name":
+		return Keyset{Value: product.Name, ID: product.ID}
+	default:
+		return timeKeyset(product.CreatedAt, product.ID)
+	}
+}
+
+// ClientKeyset is the position of a client in the listing sorted on column.
+func ClientKeyset(client models.Client, column string) Keyset {
+	switch column {
+	case "updated_at":
+		return timeKeyset(client.UpdatedAt, client.ID)
+	case "client_number":
+		return Keyset{Value: client.ClientNumber, ID: client.ID}
+	default:
+		return timeKeyset(client.CreatedAt, client.ID)
+	}
+}
+
+// AccountKeyset is the position of an account in the listing sorted on
+// column.
+func AccountKeyset(account models.Account, column string) Keyset {
+	switch column {
+	case "account_number":
+		return Keyset{Value: account.AccountNumber, ID: account.ID}
+	case "last_activity_at":
+		if account.LastActivityAt == nil {
+			return Keyset{Value: "-infinity", ID: account.ID}
+		}
+		return timeKeyset(*account.LastActivityAt, account.ID)
+	default:
+		return timeKeyset(account.OpenedAt, account.ID)
+	}
+}
+
+func timeKeyset(date time.Time, id uuid.UUID) Keyset {
+	return Keyset{Value: date.UTC().Format(keysetTimeLayout), ID: id}
+}
+
+// searchPattern is the ilike pattern of the values containing search.
+func searchPattern(search string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.TrimSpace(search)) + "%"
+}
@@ -11,7 +11,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/formancehq/go-libs/v3/bun/bunconnect"
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
 	"github.com/formancehq/go-libs/v3/logging"
+	"github.com/formancehq/go-libs/v3/pointer"
 	"github.com/formancehq/go-libs/v3/testing/docker"
 	"github.com/formancehq/go-libs/v3/testing/platform/pgtesting"
 
@@ -108,6 +110,27 @@ func TestRepositories(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, account.AccountNumber, storedAccount.AccountNumber)
 
+	search := "3456789"
+	clients, err := clientRepo.List(ctx, ClientFilter{Search: &search, Page: &Page{Limit: 10}})
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	clients, err = clientRepo.List(ctx, ClientFilter{Page: &Page{
+		After: pointer.For(ClientKeyset(clients[0], "created_at")),
+	}})
+	require.NoError(t, err)
+	require.Empty(t, clients)
+
+	sort := &Sort{Column: "last_activity_at", Order: bunpaginate.OrderAsc}
+	accounts, err := accountRepo.List(ctx, AccountFilter{Page: &Page{Sort: sort, Limit: 1}})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	accounts, err = accountRepo.List(ctx, AccountFilter{Page: &Page{
+		Sort:   sort,
+		Before: pointer.For(AccountKeyset(accounts[0], sort.Column)),
+	}})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
 	kycRecord := &models.KYCRecord{
 		ClientID:    client.ID,
 		Level:       1,
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/shopspring/decimal"
 
 	"github.com/formancehq/go-libs/v3/metadata"
 	"github.com/formancehq/go-libs/v3/query"
+	libtime "github.com/formancehq/go-libs/v3/time"
 
 	ledgerinternal "github.com/formancehq/ledger/internal"
 	"github.com/formancehq/ledger/internal/cba/models"
@@ -22,10 +24,17 @@ import (
 
 type PostingEngine interface {
 	AvailableBalance(context.Context, models.Account) (int64, error)
+	// BalanceAt returns the available balance of the account as of the given
+	// point in time.
+	BalanceAt(context.Context, models.Account, time.Time) (int64, error)
 	Credit(context.Context, models.Account, int64, string, map[string]string) error
 	Debit(context.Context, models.Account, int64, string, map[string]string) error
+	Transfer(context.Context, models.Account, models.Account, int64, string, map[string]string) error
+	Disburse(context.Context, models.Account, models.Account, int64, string, map[string]string) error
 	RecordFeeIncome(context.Context, string, string, int64, map[string]string) error
 	RecordInterestExpense(context.Context, string, string, int64, map[string]string) error
+	RecordInterestIncome(context.Context, string, string, int64, map[string]string) error
+	RecordTaxLiability(context.Context, string, string, int64, map[string]string) error
 }
 
 type ledgerPostingEngine struct {
@@ -41,12 +50,22 @@ func NewLedgerPostingEngine(system systemcontroller.Controller, cfg LedgerPostin
 }
 
 func (e *ledgerPostingEngine) AvailableBalance(ctx context.Context, account models.Account) (int64, error) {
+	return e.balance(ctx, account, nil)
+}
+
+func (e *ledgerPostingEngine) BalanceAt(ctx context.Context, account models.Account, at time.Time) (int64, error) {
+	pit := libtime.New(at)
+	return e.balance(ctx, account, &pit)
+}
+
+func (e *ledgerPostingEngine) balance(ctx context.Context, account models.Account, pit *libtime.Time) (int64, error) {
 	l, err := e.system.GetLedgerController(ctx, e.cfg.LedgerName)
 	if err != nil {
 		return 0, err
 	}
 
 	balancesQ := storagecommon.ResourceQuery[ledgerstore.GetAggregatedVolumesOptions]{
+		PIT:     pit,
 		Opts:    ledgerstore.GetAggregatedVolumesOptions{},
 		Builder: query.Match("address", walletAvailableAddress(account.WalletID, account.Currency)),
 	}
@@ -55,7 +74,7 @@ func (e *ledgerPostingEngine) AvailableBalance(ctx context.Context, account mode
 		return 0, err
 	}
 
-	asset := fmt.Sprintf("%s/2", account.Currency)
+	asset := currencyregistry.Asset(account.Currency)
 	if amount, ok := balancesMap[asset]; ok && amount != nil {
 		return amount.Int64(), nil
 	}
@@ -66,11 +85,11 @@ func (e *ledgerPostingEngine) Credit(ctx context.Context, account models.Account
 	accountUser := walletAvailableAddress(account.WalletID, account.Currency)
 	accountSystem := fmt.Sprintf("system:control:%s", account.Currency)
 	script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @%s allowing unbounded overdraft
 			destination = @%s
 		)
-	`, account.Currency, amount, accountSystem, accountUser)
+	`, currencyregistry.Asset(account.Currency), amount, accountSystem, accountUser)
 
 	return e.createTransaction(ctx, e.cfg.LedgerName, script, reference, txnMetadata)
 }
@@ -79,11 +98,41 @@ func (e *ledgerPostingEngine) Debit(ctx context.Context, account models.Account,
 	accountUser := walletAvailableAddress(account.WalletID, account.Currency)
 	accountSystem := fmt.Sprintf("system:control:%s", account.Currency)
 	script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, currencyregistry.Asset(account.Currency), amount, accountUser, accountSystem)
+
+	return e.createTransaction(ctx, e.cfg.LedgerName, script, reference, txnMetadata)
+}
+
+func (e *ledgerPostingEngine) Transfer(ctx context.Context, from, to models.Account, amount int64, reference string, txnMetadata map[string]string) error {
+	if from.Currency != to.Currency {
+		return fmt.Errorf("cannot transfer from %s to %s", from.Currency, to.Currency)
+	}
+	script := fmt.Sprintf(`
+		send [%s %d] (
 			source = @%s
 			destination = @%s
 		)
-	`, account.Currency, amount, accountUser, accountSystem)
+	`, currencyregistry.Asset(from.Currency), amount, walletAvailableAddress(from.WalletID, from.Currency), walletAvailableAddress(to.WalletID, to.Currency))
+
+	return e.createTransaction(ctx, e.cfg.LedgerName, script, reference, txnMetadata)
+}
+
+// Disburse moves the principal of a loan to another wallet. The loan wallet is
+// overdrawn by the outstanding principal.
+func (e *ledgerPostingEngine) Disburse(ctx context.Context, loan, to models.Account, amount int64, reference string, txnMetadata map[string]string) error {
+	if loan.Currency != to.Currency {
+		return fmt.Errorf("cannot disburse from %s to %s", loan.Currency, to.Currency)
+	}
+	script := fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s allowing unbounded overdraft
+			destination = @%s
+		)
+	`, currencyregistry.Asset(loan.Currency), amount, walletAvailableAddress(loan.WalletID, loan.Currency), walletAvailableAddress(to.WalletID, to.Currency))
 
 	return e.createTransaction(ctx, e.cfg.LedgerName, script, reference, txnMetadata)
 }
@@ -91,11 +140,11 @@ func (e *ledgerPostingEngine) Debit(ctx context.Context, account models.Account,
 func (e *ledgerPostingEngine) RecordFeeIncome(ctx context.Context, currency, reference string, amount int64, txnMetadata map[string]string) error {
 	revenueLedgerName := fmt.Sprintf("revenue-%s", currency)
 	script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @world
 			destination = @%s
 		)
-	`, currency, amount, e.cfg.FeeIncomeAccount)
+	`, currencyregistry.Asset(currency), amount, e.cfg.FeeIncomeAccount)
 
 	return e.createTransaction(ctx, revenueLedgerName, script, reference, txnMetadata)
 }
@@ -103,11 +152,37 @@ func (e *ledgerPostingEngine) RecordFeeIncome(ctx context.Context, currency, ref
 func (e *ledgerPostingEngine) RecordInterestExpense(ctx context.Context, currency, reference string, amount int64, txnMetadata map[string]string) error {
 	revenueLedgerName := fmt.Sprintf("revenue-%s", currency)
 	script := fmt.Sprintf(`
-		send [%s/2 %d] (
+		send [%s %d] (
 			source = @%s allowing unbounded overdraft
 			destination = @world
 		)
-	`, currency, amount, e.cfg.InterestExpenseAccount)
+	`, currencyregistry.Asset(currency), amount, e.cfg.InterestExpenseAccount)
+
+	return e.createTransaction(ctx, revenueLedgerName, script, reference, txnMetadata)
+}
+
+func (e *ledgerPostingEngine) RecordInterestIncome(ctx context.Context, currency, reference string, amount int64, txnMetadata map[string]string) error {
+	revenueLedgerName := fmt.Sprintf("revenue-%s", currency)
+	script := fmt.Sprintf(`
+		send [%s %d] (
+			source = @world
+			destination = @%s
+		)
+	`, currencyregistry.Asset(currency), amount, e.cfg.InterestIncomeAccount)
+
+	return e.createTransaction(ctx, revenueLedgerName, script, reference, txnMetadata)
+}
+
+// RecordTaxLiability books the tax withheld from interest as owed to the tax
+// authority until it is remitted.
+func (e *ledgerPostingEngine) RecordTaxLiability(ctx context.Context, currency, reference string, amount int64, txnMetadata map[string]string) error {
+	revenueLedgerName := fmt.Sprintf("revenue-%s", currency)
+	script := fmt.Sprintf(`
+		send [%s %d] (
+			source = @world
+			destination = @%s
+		)
+	`, currencyregistry.Asset(currency), amount, e.cfg.TaxLiabilityAccount)
 
 	return e.createTransaction(ctx, revenueLedgerName, script, reference, txnMetadata)
 }
@@ -0,0 +1,383 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+// maxJobRunErrors caps the item errors stored on a job run.
+const maxJobRunErrors = 50
+
+// defaultStaleRunAfter is how long a run may go without heartbeat before it
+// is considered abandoned, when the configuration does not say.
+const defaultStaleRunAfter = 5 * time.Minute
+
+// JobReport counts the items a job handled for one business date.
+type JobReport struct {
+	Processed int
+	Skipped   int
+	Failed    int
+	Errors    []string
+}
+
+func (r *JobReport) fail(format string, args ...any) {
+	r.Failed++
+	if len(r.Errors) < maxJobRunErrors {
+		r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+	}
+}
+
+// JobFunc runs a job for the business date of when.
+type JobFunc func(ctx context.Context, when time.Time) (JobReport, error)
+
+type JobsConfig struct {
+	// CatchUpDays bounds how far back missed business dates are replayed when
+	// the worker becomes leader. Zero disables catch-up.
+	CatchUpDays int
+	// PollInterval is how often manually triggered runs are picked up. Zero
+	// disables manual runs on this worker.
+	PollInterval time.Duration
+	// StaleRunAfter is how long a run may go without heartbeat before it is
+	// failed as abandoned, so that its business date can be run again. Runs
+	// are heartbeated three times in that period.
+	StaleRunAfter time.Duration
+}
+
+// JobExecutor runs jobs and records each run in the job run table, on behalf
+// of owner.
+type JobExecutor struct {
+	logger     logging.Logger
+	repository repositories.JobRunRepository
+	owner      string
+	cfg        JobsConfig
+}
+
+func NewJobExecutor(logger logging.Logger, repository repositories.JobRunRepository, owner string, cfg JobsConfig) *JobExecutor {
+	if cfg.StaleRunAfter <= 0 {
+		cfg.StaleRunAfter = defaultStaleRunAfter
+	}
+	return &JobExecutor{
+		logger:     logger,
+		repository: repository,
+		owner:      owner,
+		cfg:        cfg,
+	}
+}
+
+// Execute records a new run of the job for the business date of when and
+// runs it. Scheduled and catch-up runs are not repeated for a business date
+// which already succeeded; the succeeded run is returned instead. A partial
+// run is run again: jobs skip the items they already processed, so only the
+// failed ones are retried.
+func (e *JobExecutor) Execute(ctx context.Context, job, trigger string, when time.Time, fn JobFunc) (*models.JobRun, error) {
+	businessDate := normalizeScheduleDate(when)
+	if trigger != models.JobRunTriggerManual {
+		completed, err := e.repository.List(ctx, repositories.JobRunFilter{
+			Job:      &job,
+			Statuses: []string{models.JobRunStatusSucceeded},
+			From:     &businessDate,
+			To:       &businessDate,
+			Limit:    1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(completed) > 0 {
+			return &completed[0], nil
+		}
+	}
+
+	startedAt := time.Now().UTC()
+	run := &models.JobRun{
+		Job:          job,
+		BusinessDate: businessDate,
+		Trigger:      trigger,
+		Status:       models.JobRunStatusRunning,
+		Owner:        e.owner,
+		StartedAt:    &startedAt,
+		HeartbeatAt:  &startedAt,
+	}
+	if err := e.repository.Create(ctx, run); err != nil {
+		return nil, fmt.Errorf("recording %s run for %s: %w", job, run.BusinessDate.Format(time.DateOnly), err)
+	}
+	return run, e.complete(ctx, run, when, fn)
+}
+
+// CatchUp replays the ticks of schedule which fell between the last succeeded
+// run of the job and now, at most CatchUpDays back, retrying the business
+// dates which failed or partially failed on the way. Nothing is replayed for a
+// job none of whose runs ever succeeded.
+func (e *JobExecutor) CatchUp(ctx context.Context, job string, schedule cron.Schedule, now time.Time, fn JobFunc) error {
+	if e.cfg.CatchUpDays <= 0 {
+		return nil
+	}
+	last, err := e.repository.LastCompleted(ctx, job)
+	if err != nil {
+		if postgres.IsNotFoundError(err) || errors.Is(err, postgres.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	from := normalizeScheduleDate(last.BusinessDate).AddDate(0, 0, 1)
+	if earliest := normalizeScheduleDate(now).AddDate(0, 0, -e.cfg.CatchUpDays); from.Before(earliest) {
+		e.logger.Errorf("%s last completed for %s, only catching up from %s", job, last.BusinessDate.Format(time.DateOnly), earliest.Format(time.DateOnly))
+		from = earliest
+	}
+
+	var previous time.Time
+	for tick := schedule.Next(from.Add(-time.Second)); !tick.After(now); tick = schedule.Next(tick) {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		date := normalizeScheduleDate(tick)
+		if date.Equal(previous) {
+			continue
+		}
+		previous = date
+		if _, err := e.Execute(ctx, job, models.JobRunTriggerCatchUp, tick, fn); err != nil {
+			e.logger.Errorf("catching up %s for %s: %v", job, date.Format(time.DateOnly), err)
+		}
+	}
+	return nil
+}
+
+// RunPending runs the pending manual runs of the job, oldest business date
+// first.
+func (e *JobExecutor) RunPending(ctx context.Context, job string, fn JobFunc) error {
+	for {
+		run, err := e.repository.ClaimPending(ctx, job, e.owner)
+		if err != nil {
+			if postgres.IsNotFoundError(err) || errors.Is(err, postgres.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+		if err := e.complete(ctx, run, run.BusinessDate, fn); err != nil {
+			e.logger.Errorf("running %s for %s: %v", job, run.BusinessDate.Format(time.DateOnly), err)
+		}
+	}
+}
+
+// Recover fails the runs of the job left running by a worker which stopped
+// heartbeating them, then runs their business dates again.
+func (e *JobExecutor) Recover(ctx context.Context, job string, now time.Time, fn JobFunc) error {
+	runs, err := e.repository.FailStale(ctx, job, now.Add(-e.cfg.StaleRunAfter))
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		e.logger.Errorf("%s run %s for %s abandoned by %s, running it again", job, run.ID, run.BusinessDate.Format(time.DateOnly), run.Owner)
+		if _, err := e.Execute(ctx, job, models.JobRunTriggerCatchUp, run.BusinessDate, fn); err != nil {
+			e.logger.Errorf("recovering %s for %s: %v", job, run.BusinessDate.Format(time.DateOnly), err)
+		}
+	}
+	return nil
+}
+
+func (e *JobExecutor) complete(ctx context.Context, run *models.JobRun, when time.Time, fn JobFunc) error {
+	stopHeartbeat := e.heartbeat(ctx, run)
+	report, err := fn(ctx, when)
+	stopHeartbeat()
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(err, cause) {
+		// The run was interrupted, by the loss of leadership for instance,
+		// before every item was processed.
+		err = errors.Join(err, cause)
+	}
+
+	finishedAt := time.Now().UTC()
+	run.Processed = report.Processed
+	run.Skipped = report.Skipped
+	run.Failed = report.Failed
+	run.Errors = report.Errors
+	run.FinishedAt = &finishedAt
+	switch {
+	case err != nil:
+		run.Status = models.JobRunStatusFailed
+		run.Errors = append(run.Errors, err.Error())
+	case report.Failed > 0:
+		run.Status = models.JobRunStatusPartial
+		e.logger.Errorf("%s for %s: %d items failed", run.Job, run.BusinessDate.Format(time.DateOnly), report.Failed)
+	default:
+		run.Status = models.JobRunStatusSucceeded
+	}
+
+	if updateErr := e.repository.Update(context.WithoutCancel(ctx), run); updateErr != nil {
+		return errors.Join(err, fmt.Errorf("recording %s run %s: %w", run.Job, run.ID, updateErr))
+	}
+	return err
+}
+
+// heartbeat records the run as alive until the returned function is called.
+func (e *JobExecutor) heartbeat(ctx context.Context, run *models.JobRun) func() {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(e.cfg.StaleRunAfter / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := e.repository.Heartbeat(ctx, run.ID, e.owner); err != nil {
+					e.logger.Errorf("recording heartbeat of %s run %s: %v", run.Job, run.ID, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+	}
+}
+
+// leadershipCheckInterval is how often a scheduler which does not poll for
+// manual runs checks whether its worker became leader.
+const leadershipCheckInterval = 10 * time.Second
+
+// JobScheduler runs a job on its schedule while its worker holds the scheduler
+// lease. The leader runs again the business dates whose run was abandoned by
+// a former leader. Each time the worker becomes leader it catches up the
+// business dates missed since the last completed run, then it picks up
+// manually triggered runs.
+type JobScheduler struct {
+	stopChannel chan chan struct{}
+	logger      logging.Logger
+	executor    *JobExecutor
+	elector     *LeaderElector
+	job         string
+	schedule    cron.Schedule
+	fn          JobFunc
+	leading     bool
+}
+
+func NewJobScheduler(
+	logger logging.Logger,
+	executor *JobExecutor,
+	elector *LeaderElector,
+	job string,
+	schedule cron.Schedule,
+	fn JobFunc,
+) *JobScheduler {
+	return &JobScheduler{
+		stopChannel: make(chan chan struct{}),
+		logger:      logger,
+		executor:    executor,
+		elector:     elector,
+		job:         job,
+		schedule:    schedule,
+		fn:          fn,
+	}
+}
+
+func (s *JobScheduler) Run(ctx context.Context) error {
+	s.lead(ctx)
+
+	now := time.Now()
+	timer := time.NewTimer(s.schedule.Next(now).Sub(now))
+	defer timer.Stop()
+
+	checkInterval := s.executor.cfg.PollInterval
+	if checkInterval <= 0 {
+		checkInterval = leadershipCheckInterval
+	}
+	check := time.NewTicker(checkInterval)
+	defer check.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if s.lead(ctx) {
+				runCtx, cancel := s.elector.WithLeadership(ctx)
+				if _, err := s.executor.Execute(runCtx, s.job, models.JobRunTriggerSchedule, time.Now().UTC(), s.fn); err != nil {
+					s.logger.Errorf("error running %s: %v", s.job, err)
+				}
+				cancel()
+			}
+
+			now = time.Now()
+			timer.Reset(s.schedule.Next(now).Sub(now))
+		case <-check.C:
+			if s.lead(ctx) && s.executor.cfg.PollInterval > 0 {
+				runCtx, cancel := s.elector.WithLeadership(ctx)
+				if err := s.executor.RunPending(runCtx, s.job, s.fn); err != nil {
+					s.logger.Errorf("error running pending %s runs: %v", s.job, err)
+				}
+				cancel()
+			}
+		case ch := <-s.stopChannel:
+			close(ch)
+			return nil
+		}
+	}
+}
+
+// lead reports whether the worker is leader. The leader recovers the runs
+// abandoned by former leaders and, when it just became leader, catches up
+// missed business dates. Runs are interrupted when the worker loses the lease
+// so that they never go on next to the runs of the new leader.
+func (s *JobScheduler) lead(ctx context.Context) bool {
+	if !s.elector.IsLeader() {
+		s.leading = false
+		return false
+	}
+	ctx, cancel := s.elector.WithLeadership(ctx)
+	defer cancel()
+
+	if err := s.executor.Recover(ctx, s.job, time.Now().UTC(), s.fn); err != nil {
+		s.logger.Errorf("error recovering abandoned %s runs: %v", s.job, err)
+	}
+	if !s.leading {
+		s.leading = true
+		if err := s.executor.CatchUp(ctx, s.job, s.schedule, time.Now().UTC(), s.fn); err != nil {
+			s.logger.Errorf("error catching up %s: %v", s.job, err)
+		}
+	}
+	return ctx.Err() == nil
+}
+
+func (s *JobScheduler) Stop(ctx context.Context) error {
+	ch := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s.stopChannel <- ch:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+	return nil
+}
+
+func registerJobScheduler(lc fx.Lifecycle, scheduler *JobScheduler) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				if err := scheduler.Run(context.WithoutCancel(ctx)); err != nil {
+					panic(err)
+				}
+			}()
+			return nil
+		},
+		OnStop: scheduler.Stop,
+	})
+}
@@ -0,0 +1,405 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/logging"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+func TestJobExecutorCatchUpReplaysMissedDates(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobRunRepositoryStub{
+		runs: []*models.JobRun{{
+			ID:           uuid.New(),
+			Job:          models.JobInterestAccrual,
+			BusinessDate: time.Date(2026, 5, 12, 0, 0, 0, 0, time.UTC),
+			Status:       models.JobRunStatusSucceeded,
+		}},
+	}
+	executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{CatchUpDays: 7})
+	schedule, err := cron.ParseStandard("5 0 * * *")
+	require.NoError(t, err)
+
+	var ran []time.Time
+	err = executor.CatchUp(context.Background(), models.JobInterestAccrual, schedule, time.Date(2026, 5, 15, 0, 30, 0, 0, time.UTC), func(_ context.Context, when time.Time) (JobReport, error) {
+		ran = append(ran, when)
+		return JobReport{Processed: 1}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2026, 5, 13, 0, 5, 0, 0, time.UTC),
+		time.Date(2026, 5, 14, 0, 5, 0, 0, time.UTC),
+		time.Date(2026, 5, 15, 0, 5, 0, 0, time.UTC),
+	}, ran)
+	require.Len(t, repository.runs, 4)
+	for _, run := range repository.runs[1:] {
+		require.Equal(t, models.JobRunTriggerCatchUp, run.Trigger)
+		require.Equal(t, models.JobRunStatusSucceeded, run.Status)
+		require.Equal(t, 1, run.Processed)
+	}
+}
+
+func TestJobExecutorCatchUpIsBounded(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobRunRepositoryStub{
+		runs: []*models.JobRun{{
+			ID:           uuid.New(),
+			Job:          models.JobDormancy,
+			BusinessDate: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			Status:       models.JobRunStatusSucceeded,
+		}},
+	}
+	executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{CatchUpDays: 2})
+	schedule, err := cron.ParseStandard("20 0 * * *")
+	require.NoError(t, err)
+
+	var ran []time.Time
+	err = executor.CatchUp(context.Background(), models.JobDormancy, schedule, time.Date(2026, 5, 15, 0, 10, 0, 0, time.UTC), func(_ context.Context, when time.Time) (JobReport, error) {
+		ran = append(ran, when)
+		return JobReport{}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2026, 5, 13, 0, 20, 0, 0, time.UTC),
+		time.Date(2026, 5, 14, 0, 20, 0, 0, time.UTC),
+	}, ran)
+}
+
+func TestJobExecutorCatchUpWithoutCompletedRun(t *testing.T) {
+	t.Parallel()
+
+	executor := NewJobExecutor(logging.Testing(), &jobRunRepositoryStub{}, "worker-1", JobsConfig{CatchUpDays: 7})
+	err := executor.CatchUp(context.Background(), models.JobMaintenanceFee, cron.Every(time.Hour), time.Now(), func(context.Context, time.Time) (JobReport, error) {
+		t.Fatal("job should not run without a completed run to resume from")
+		return JobReport{}, nil
+	})
+	require.NoError(t, err)
+}
+
+func TestJobExecutorRecordsOutcome(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2026, 5, 15, 0, 5, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name     string
+		report   JobReport
+		err      error
+		expected string
+	}{
+		{name: "succeeded", report: JobReport{Processed: 2, Skipped: 1}, expected: models.JobRunStatusSucceeded},
+		{name: "partial", report: JobReport{Processed: 1, Failed: 1, Errors: []string{"boom"}}, expected: models.JobRunStatusPartial},
+		{name: "failed", err: errors.New("listing accounts"), expected: models.JobRunStatusFailed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repository := &jobRunRepositoryStub{}
+			executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{})
+			run, err := executor.Execute(context.Background(), models.JobInterestPosting, models.JobRunTriggerSchedule, when, func(context.Context, time.Time) (JobReport, error) {
+				return tc.report, tc.err
+			})
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.expected, run.Status)
+			require.Equal(t, time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), run.BusinessDate)
+			require.Equal(t, tc.report.Failed, run.Failed)
+			require.NotNil(t, run.FinishedAt)
+			if tc.err != nil {
+				require.Contains(t, run.Errors, tc.err.Error())
+			}
+		})
+	}
+}
+
+func TestJobExecutorFailsInterruptedRuns(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobRunRepositoryStub{}
+	executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{})
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	run, err := executor.Execute(ctx, models.JobInterestPosting, models.JobRunTriggerSchedule, time.Now(), func(context.Context, time.Time) (JobReport, error) {
+		cancel(ErrLeadershipLost)
+		return JobReport{Processed: 1}, nil
+	})
+	require.ErrorIs(t, err, ErrLeadershipLost)
+	require.Equal(t, models.JobRunStatusFailed, run.Status)
+	require.Equal(t, models.JobRunStatusFailed, repository.runs[0].Status)
+}
+
+func TestJobExecutorDoesNotRepeatCompletedBusinessDate(t *testing.T) {
+	t.Parallel()
+
+	completed := &models.JobRun{
+		ID:           uuid.New(),
+		Job:          models.JobMaintenanceFee,
+		BusinessDate: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC),
+		Trigger:      models.JobRunTriggerSchedule,
+		Status:       models.JobRunStatusSucceeded,
+	}
+	repository := &jobRunRepositoryStub{runs: []*models.JobRun{completed}}
+	executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{})
+
+	calls := 0
+	fn := func(context.Context, time.Time) (JobReport, error) {
+		calls++
+		return JobReport{}, nil
+	}
+
+	run, err := executor.Execute(context.Background(), models.JobMaintenanceFee, models.JobRunTriggerSchedule, time.Date(2026, 5, 15, 0, 15, 0, 0, time.UTC), fn)
+	require.NoError(t, err)
+	require.Equal(t, completed.ID, run.ID)
+	require.Zero(t, calls)
+
+	_, err = executor.Execute(context.Background(), models.JobMaintenanceFee, models.JobRunTriggerManual, time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), fn)
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+	require.Len(t, repository.runs, 2)
+}
+
+func TestJobExecutorCatchUpRetriesPartialBusinessDate(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobRunRepositoryStub{
+		runs: []*models.JobRun{{
+			ID:           uuid.New(),
+			Job:          models.JobMaintenanceFee,
+			BusinessDate: time.Date(2026, 5, 13, 0, 0, 0, 0, time.UTC),
+			Status:       models.JobRunStatusSucceeded,
+		}, {
+			ID:           uuid.New(),
+			Job:          models.JobMaintenanceFee,
+			BusinessDate: time.Date(2026, 5, 14, 0, 0, 0, 0, time.UTC),
+			Status:       models.JobRunStatusPartial,
+			Failed:       1,
+		}},
+	}
+	executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{CatchUpDays: 7})
+	schedule, err := cron.ParseStandard("5 0 * * *")
+	require.NoError(t, err)
+
+	var ran []time.Time
+	err = executor.CatchUp(context.Background(), models.JobMaintenanceFee, schedule, time.Date(2026, 5, 14, 12, 0, 0, 0, time.UTC), func(_ context.Context, when time.Time) (JobReport, error) {
+		ran = append(ran, when)
+		return JobReport{Processed: 1}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{time.Date(2026, 5, 14, 0, 5, 0, 0, time.UTC)}, ran)
+	require.Equal(t, models.JobRunStatusSucceeded, repository.runs[2].Status)
+}
+
+func TestJobExecutorRunPendingRunsManualRuns(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobRunRepositoryStub{
+		runs: []*models.JobRun{
+			{ID: uuid.New(), Job: models.JobInterestAccrual, BusinessDate: time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC), Trigger: models.JobRunTriggerManual, Status: models.JobRunStatusPending},
+			{ID: uuid.New(), Job: models.JobDormancy, BusinessDate: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Trigger: models.JobRunTriggerManual, Status: models.JobRunStatusPending},
+			{ID: uuid.New(), Job: models.JobInterestAccrual, BusinessDate: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), Trigger: models.JobRunTriggerManual, Status: models.JobRunStatusPending},
+		},
+	}
+	executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{})
+
+	var ran []time.Time
+	err := executor.RunPending(context.Background(), models.JobInterestAccrual, func(_ context.Context, when time.Time) (JobReport, error) {
+		ran = append(ran, when)
+		return JobReport{}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{
+		time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, 5, 2, 0, 0, 0, 0, time.UTC),
+	}, ran)
+	require.Equal(t, models.JobRunStatusSucceeded, repository.runs[0].Status)
+	require.Equal(t, models.JobRunStatusPending, repository.runs[1].Status)
+	require.Equal(t, models.JobRunStatusSucceeded, repository.runs[2].Status)
+}
+
+func TestJobExecutorRecoversAbandonedRuns(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 15, 10, 0, 0, 0, time.UTC)
+	abandonedAt := now.Add(-time.Minute)
+	aliveAt := now.Add(-5 * time.Second)
+	abandoned := &models.JobRun{
+		ID:           uuid.New(),
+		Job:          models.JobInterestPosting,
+		BusinessDate: time.Date(2026, 5, 14, 0, 0, 0, 0, time.UTC),
+		Trigger:      models.JobRunTriggerSchedule,
+		Status:       models.JobRunStatusRunning,
+		Owner:        "worker-0",
+		HeartbeatAt:  &abandonedAt,
+	}
+	alive := &models.JobRun{
+		ID:           uuid.New(),
+		Job:          models.JobInterestPosting,
+		BusinessDate: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC),
+		Trigger:      models.JobRunTriggerSchedule,
+		Status:       models.JobRunStatusRunning,
+		Owner:        "worker-0",
+		HeartbeatAt:  &aliveAt,
+	}
+	repository := &jobRunRepositoryStub{runs: []*models.JobRun{abandoned, alive}}
+	executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{StaleRunAfter: 30 * time.Second})
+
+	var ran []time.Time
+	err := executor.Recover(context.Background(), models.JobInterestPosting, now, func(_ context.Context, when time.Time) (JobReport, error) {
+		ran = append(ran, when)
+		return JobReport{Processed: 1}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []time.Time{abandoned.BusinessDate}, ran)
+	require.Equal(t, models.JobRunStatusFailed, abandoned.Status)
+	require.Equal(t, models.JobRunStatusRunning, alive.Status)
+	require.Len(t, repository.runs, 3)
+	require.Equal(t, models.JobRunTriggerCatchUp, repository.runs[2].Trigger)
+	require.Equal(t, models.JobRunStatusSucceeded, repository.runs[2].Status)
+	require.Equal(t, "worker-1", repository.runs[2].Owner)
+}
+
+func TestJobExecutorHeartbeatsRunningRuns(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobRunRepositoryStub{}
+	executor := NewJobExecutor(logging.Testing(), repository, "worker-1", JobsConfig{StaleRunAfter: 30 * time.Millisecond})
+
+	run, err := executor.Execute(context.Background(), models.JobDormancy, models.JobRunTriggerSchedule, time.Now(), func(_ context.Context, _ time.Time) (JobReport, error) {
+		repository.mu.Lock()
+		startedAt := *repository.runs[0].HeartbeatAt
+		repository.mu.Unlock()
+		require.Eventually(t, func() bool {
+			return repository.heartbeatAfter(startedAt)
+		}, time.Second, 5*time.Millisecond)
+		return JobReport{}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.JobRunStatusSucceeded, run.Status)
+}
+
+type jobRunRepositoryStub struct {
+	mu   sync.Mutex
+	runs []*models.JobRun
+}
+
+func (s *jobRunRepositoryStub) heartbeatAfter(date time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.runs[0].HeartbeatAt.After(date)
+}
+
+func (s *jobRunRepositoryStub) Create(_ context.Context, run *models.JobRun) error {
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func (s *jobRunRepositoryStub) Update(_ context.Context, run *models.JobRun) error {
+	for i := range s.runs {
+		if s.runs[i].ID == run.ID {
+			s.runs[i] = run
+			return nil
+		}
+	}
+	return postgres.ErrNotFound
+}
+
+func (s *jobRunRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.JobRun, error) {
+	for _, run := range s.runs {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *jobRunRepositoryStub) List(_ context.Context, filter repositories.JobRunFilter) ([]models.JobRun, error) {
+	ret := make([]models.JobRun, 0)
+	for _, run := range s.runs {
+		if filter.Job != nil && run.Job != *filter.Job {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, run.Status) {
+			continue
+		}
+		if filter.From != nil && run.BusinessDate.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && run.BusinessDate.After(*filter.To) {
+			continue
+		}
+		ret = append(ret, *run)
+	}
+	return ret, nil
+}
+
+func (s *jobRunRepositoryStub) LastCompleted(_ context.Context, job string) (*models.JobRun, error) {
+	var last *models.JobRun
+	for _, run := range s.runs {
+		if run.Job != job || run.Status != models.JobRunStatusSucceeded {
+			continue
+		}
+		if last == nil || run.BusinessDate.After(last.BusinessDate) {
+			last = run
+		}
+	}
+	if last == nil {
+		return nil, postgres.ErrNotFound
+	}
+	return last, nil
+}
+
+func (s *jobRunRepositoryStub) ClaimPending(_ context.Context, job, owner string) (*models.JobRun, error) {
+	var oldest *models.JobRun
+	for _, run := range s.runs {
+		if run.Job == job && run.Status == models.JobRunStatusPending && (oldest == nil || run.BusinessDate.Before(oldest.BusinessDate)) {
+			oldest = run
+		}
+	}
+	if oldest == nil {
+		return nil, postgres.ErrNotFound
+	}
+	oldest.Status = models.JobRunStatusRunning
+	oldest.Owner = owner
+	return oldest, nil
+}
+
+func (s *jobRunRepositoryStub) Heartbeat(_ context.Context, id uuid.UUID, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.runs {
+		if run.ID == id && run.Owner == owner && run.Status == models.JobRunStatusRunning {
+			now := time.Now().UTC()
+			run.HeartbeatAt = &now
+			return nil
+		}
+	}
+	return postgres.ErrNotFound
+}
+
+func (s *jobRunRepositoryStub) FailStale(_ context.Context, job string, before time.Time) ([]models.JobRun, error) {
+	ret := make([]models.JobRun, 0)
+	for _, run := range s.runs {
+		if run.Job != job || run.Status != models.JobRunStatusRunning || run.HeartbeatAt == nil || !run.HeartbeatAt.Before(before) {
+			continue
+		}
+		run.Status = models.JobRunStatusFailed
+		ret = append(ret, *run)
+	}
+	return ret, nil
+}
@@ -0,0 +1,203 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+// ErrLeadershipLost interrupts the work of a worker which lost the scheduler
+// lease.
+var ErrLeadershipLost = errors.New("scheduler leadership lost")
+
+type LeaderElectionConfig struct {
+	// Identity names the worker in the lease table. It defaults to the host
+	// name followed by a random suffix.
+	Identity string
+	// LeaseDuration is how long the lease stays valid without renewal. Zero
+	// disables election, making every worker leader.
+	LeaseDuration time.Duration
+	RenewInterval time.Duration
+}
+
+// LeaderElector keeps the scheduler lease of the worker. A worker is leader
+// from a successful acquisition or renewal until a renewal fails or the lease
+// expires, so the lease has to outlive RenewInterval for leadership to be
+// continuous.
+type LeaderElector struct {
+	stopChannel chan chan struct{}
+	logger      logging.Logger
+	repository  repositories.JobLeaseRepository
+	cfg         LeaderElectionConfig
+	leader      atomic.Bool
+
+	mu sync.Mutex
+	// lost is closed when the worker steps down.
+	lost chan struct{}
+	// expiry steps down when the lease expires before it is renewed.
+	expiry *time.Timer
+}
+
+func NewLeaderElector(logger logging.Logger, repository repositories.JobLeaseRepository, cfg LeaderElectionConfig) *LeaderElector {
+	if cfg.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "worker"
+		}
+		cfg.Identity = fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+	}
+	lost := make(chan struct{})
+	close(lost)
+	return &LeaderElector{
+		stopChannel: make(chan chan struct{}),
+		logger:      logger,
+		repository:  repository,
+		cfg:         cfg,
+		lost:        lost,
+	}
+}
+
+func (e *LeaderElector) Identity() string {
+	return e.cfg.Identity
+}
+
+func (e *LeaderElector) IsLeader() bool {
+	return e.cfg.LeaseDuration <= 0 || e.leader.Load()
+}
+
+// WithLeadership returns a copy of ctx which is cancelled with
+// ErrLeadershipLost as soon as the worker is no longer leader, so that work
+// started as leader does not go on next to the new leader.
+func (e *LeaderElector) WithLeadership(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if e.cfg.LeaseDuration <= 0 {
+		return ctx, func() { cancel(nil) }
+	}
+
+	e.mu.Lock()
+	lost := e.lost
+	e.mu.Unlock()
+
+	go func() {
+		select {
+		case <-lost:
+			cancel(ErrLeadershipLost)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() { cancel(nil) }
+}
+
+// setLeader records whether the worker is leader and reports whether it was.
+func (e *LeaderElector) setLeader(leader bool) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	wasLeader := e.leader.Swap(leader)
+	switch {
+	case leader && !wasLeader:
+		e.lost = make(chan struct{})
+	case !leader && wasLeader:
+		close(e.lost)
+	}
+	return wasLeader
+}
+
+func (e *LeaderElector) Run(ctx context.Context) error {
+	if e.cfg.LeaseDuration <= 0 {
+		ch := <-e.stopChannel
+		close(ch)
+		return nil
+	}
+
+	e.renew(ctx)
+
+	ticker := time.NewTicker(e.cfg.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			e.renew(ctx)
+		case ch := <-e.stopChannel:
+			if e.setLeader(false) {
+				if err := e.repository.Release(ctx, models.SchedulerLease, e.cfg.Identity); err != nil {
+					e.logger.Errorf("releasing %s lease: %v", models.SchedulerLease, err)
+				}
+			}
+			close(ch)
+			return nil
+		}
+	}
+}
+
+func (e *LeaderElector) Stop(ctx context.Context) error {
+	ch := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case e.stopChannel <- ch:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+	return nil
+}
+
+func (e *LeaderElector) renew(ctx context.Context) {
+	// The lease runs from before the request, and a request hanging on the
+	// database must not delay the next renewal.
+	requestedAt := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.RenewInterval)
+	defer cancel()
+
+	lease, acquired, err := e.repository.Acquire(ctx, models.SchedulerLease, e.cfg.Identity, e.cfg.LeaseDuration)
+	if err != nil {
+		if e.setLeader(false) {
+			e.logger.Errorf("stepping down, renewing %s lease: %v", models.SchedulerLease, err)
+		} else {
+			e.logger.Errorf("acquiring %s lease: %v", models.SchedulerLease, err)
+		}
+		return
+	}
+
+	switch wasLeader := e.setLeader(acquired); {
+	case acquired && !wasLeader:
+		e.logger.Infof("%s acquired %s lease", e.cfg.Identity, models.SchedulerLease)
+	case !acquired && wasLeader:
+		e.logger.Infof("%s lost %s lease to %s", e.cfg.Identity, models.SchedulerLease, lease.Holder)
+	}
+	if acquired {
+		e.expireAfter(e.cfg.LeaseDuration - time.Since(requestedAt))
+	}
+}
+
+// expireAfter steps down after d unless the lease is renewed in the meantime,
+// as another worker may then acquire it.
+func (e *LeaderElector) expireAfter(d time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.expiry != nil {
+		e.expiry.Reset(d)
+		return
+	}
+	e.expiry = time.AfterFunc(d, func() {
+		if e.setLeader(false) {
+			e.logger.Errorf("stepping down, %s lease expired before it was renewed", models.SchedulerLease)
+		}
+	})
+}
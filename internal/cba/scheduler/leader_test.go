@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+)
+
+func TestLeaderElectorFollowsLease(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobLeaseRepositoryStub{holder: "other"}
+	elector := NewLeaderElector(logging.Testing(), repository, LeaderElectionConfig{
+		Identity:      "worker-1",
+		LeaseDuration: 30 * time.Second,
+		RenewInterval: 10 * time.Second,
+	})
+	require.Equal(t, "worker-1", elector.Identity())
+
+	elector.renew(context.Background())
+	require.False(t, elector.IsLeader())
+
+	repository.holder = ""
+	elector.renew(context.Background())
+	require.True(t, elector.IsLeader())
+	require.Equal(t, "worker-1", repository.holder)
+
+	repository.err = errors.New("connection refused")
+	elector.renew(context.Background())
+	require.False(t, elector.IsLeader())
+}
+
+func TestLeaderElectorCancelsWorkOnLostLease(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobLeaseRepositoryStub{}
+	elector := NewLeaderElector(logging.Testing(), repository, LeaderElectionConfig{
+		Identity:      "worker-1",
+		LeaseDuration: 30 * time.Second,
+		RenewInterval: 10 * time.Second,
+	})
+
+	notLeading, cancel := elector.WithLeadership(context.Background())
+	defer cancel()
+	require.Eventually(t, func() bool { return notLeading.Err() != nil }, time.Second, 10*time.Millisecond)
+
+	elector.renew(context.Background())
+	require.True(t, elector.IsLeader())
+	ctx, cancel := elector.WithLeadership(context.Background())
+	defer cancel()
+	require.NoError(t, ctx.Err())
+
+	elector.renew(context.Background())
+	require.NoError(t, ctx.Err())
+
+	repository.holder = "other"
+	elector.renew(context.Background())
+	require.Eventually(t, func() bool { return ctx.Err() != nil }, time.Second, 10*time.Millisecond)
+	require.ErrorIs(t, context.Cause(ctx), ErrLeadershipLost)
+}
+
+func TestLeaderElectorStepsDownWhenLeaseExpires(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobLeaseRepositoryStub{}
+	elector := NewLeaderElector(logging.Testing(), repository, LeaderElectionConfig{
+		Identity:      "worker-1",
+		LeaseDuration: 100 * time.Millisecond,
+		RenewInterval: 50 * time.Millisecond,
+	})
+
+	elector.renew(context.Background())
+	require.True(t, elector.IsLeader())
+	ctx, cancel := elector.WithLeadership(context.Background())
+	defer cancel()
+
+	// A renewal hanging on the database times out instead of keeping the
+	// worker leader.
+	repository.hang = true
+	elector.renew(context.Background())
+	require.False(t, elector.IsLeader())
+	require.Eventually(t, func() bool { return ctx.Err() != nil }, time.Second, 10*time.Millisecond)
+
+	// Without any renewal, the worker steps down once the lease expires.
+	repository.hang = false
+	elector.renew(context.Background())
+	require.True(t, elector.IsLeader())
+	require.Eventually(t, func() bool { return !elector.IsLeader() }, time.Second, 10*time.Millisecond)
+}
+
+func TestLeaderElectorReleasesLeaseOnStop(t *testing.T) {
+	t.Parallel()
+
+	repository := &jobLeaseRepositoryStub{}
+	elector := NewLeaderElector(logging.Testing(), repository, LeaderElectionConfig{
+		Identity:      "worker-1",
+		LeaseDuration: 30 * time.Second,
+		RenewInterval: time.Hour,
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- elector.Run(context.Background())
+	}()
+	require.Eventually(t, elector.IsLeader, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, elector.Stop(context.Background()))
+	require.NoError(t, <-done)
+	require.False(t, elector.IsLeader())
+	require.Equal(t, []string{"worker-1"}, repository.released)
+}
+
+func TestLeaderElectorDisabled(t *testing.T) {
+	t.Parallel()
+
+	elector := NewLeaderElector(logging.Testing(), &jobLeaseRepositoryStub{holder: "other"}, LeaderElectionConfig{})
+	require.NotEmpty(t, elector.Identity())
+	require.True(t, elector.IsLeader())
+}
+
+type jobLeaseRepositoryStub struct {
+	holder   string
+	err      error
+	hang     bool
+	released []string
+}
+
+func (s *jobLeaseRepositoryStub) Acquire(ctx context.Context, name, holder string, _ time.Duration) (*models.JobLease, bool, error) {
+	if s.hang {
+		<-ctx.Done()
+		return nil, false, ctx.Err()
+	}
+	if s.err != nil {
+		return nil, false, s.err
+	}
+	if s.holder != "" && s.holder != holder {
+		return &models.JobLease{Name: name, Holder: s.holder}, false, nil
+	}
+	s.holder = holder
+	return &models.JobLease{Name: name, Holder: holder}, true, nil
+}
+
+func (s *jobLeaseRepositoryStub) Release(_ context.Context, _, holder string) error {
+	s.released = append(s.released, holder)
+	if s.holder == holder {
+		s.holder = ""
+	}
+	return nil
+}
+
+func (s *jobLeaseRepositoryStub) List(context.Context) ([]models.JobLease, error) {
+	return nil, nil
+}
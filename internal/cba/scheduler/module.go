@@ -1,9 +1,14 @@
 package scheduler
 
 import (
+	"context"
+
 	"github.com/robfig/cron/v3"
 	"go.uber.org/fx"
 
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/repositories"
 	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
 )
 
@@ -11,6 +16,8 @@ type LedgerPostingConfig struct {
 	LedgerName             string
 	FeeIncomeAccount       string
 	InterestExpenseAccount string
+	InterestIncomeAccount  string
+	TaxLiabilityAccount    string
 }
 
 type InterestAccrualRunnerConfig struct {
@@ -29,12 +36,52 @@ type DormancyRunnerConfig struct {
 	Schedule cron.Schedule
 }
 
+type TermMaturityRunnerConfig struct {
+	Schedule cron.Schedule
+}
+
+type LoanServicingRunnerConfig struct {
+	Schedule cron.Schedule
+}
+
+type PenaltyFeeRunnerConfig struct {
+	Schedule cron.Schedule
+}
+
+type FeeRecoveryRunnerConfig struct {
+	Schedule cron.Schedule
+	// WriteoffAfterDays escalates the fees still outstanding that many days
+	// after they were charged. Zero disables the escalation.
+	WriteoffAfterDays int
+}
+
+type KYCExpiryRunnerConfig struct {
+	Schedule cron.Schedule
+	// NoticeDays is how many days ahead of their expiry KYC records are
+	// notified. Zero disables the notices.
+	NoticeDays int
+	// WebhookURL receives the notices, which are logged when it is not set.
+	WebhookURL string
+}
+
+type ScreeningRunnerConfig struct {
+	Schedule cron.Schedule
+}
+
 type ModuleConfig struct {
 	LedgerPostingConfig         LedgerPostingConfig
 	InterestAccrualRunnerConfig InterestAccrualRunnerConfig
 	InterestPostingRunnerConfig InterestPostingRunnerConfig
 	MaintenanceFeeRunnerConfig  MaintenanceFeeRunnerConfig
 	DormancyRunnerConfig        DormancyRunnerConfig
+	TermMaturityRunnerConfig    TermMaturityRunnerConfig
+	LoanServicingRunnerConfig   LoanServicingRunnerConfig
+	PenaltyFeeRunnerConfig      PenaltyFeeRunnerConfig
+	FeeRecoveryRunnerConfig     FeeRecoveryRunnerConfig
+	KYCExpiryRunnerConfig       KYCExpiryRunnerConfig
+	ScreeningRunnerConfig       ScreeningRunnerConfig
+	JobsConfig                  JobsConfig
+	LeaderElectionConfig        LeaderElectionConfig
 }
 
 func NewFXModule(cfg ModuleConfig) fx.Option {
@@ -42,9 +89,39 @@ func NewFXModule(cfg ModuleConfig) fx.Option {
 		fx.Provide(func(system systemcontroller.Controller) PostingEngine {
 			return NewLedgerPostingEngine(system, cfg.LedgerPostingConfig)
 		}),
+		fx.Provide(func(logger logging.Logger, jobRunRepository repositories.JobRunRepository, elector *LeaderElector) *JobExecutor {
+			jobsConfig := cfg.JobsConfig
+			if jobsConfig.StaleRunAfter <= 0 && cfg.LeaderElectionConfig.LeaseDuration > 0 {
+				// A run outliving the lease of its worker belongs to a former leader.
+				jobsConfig.StaleRunAfter = cfg.LeaderElectionConfig.LeaseDuration
+			}
+			return NewJobExecutor(logger, jobRunRepository, elector.Identity(), jobsConfig)
+		}),
+		fx.Provide(func(logger logging.Logger, jobLeaseRepository repositories.JobLeaseRepository) *LeaderElector {
+			return NewLeaderElector(logger, jobLeaseRepository, cfg.LeaderElectionConfig)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, elector *LeaderElector) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go func() {
+						if err := elector.Run(context.WithoutCancel(ctx)); err != nil {
+							panic(err)
+						}
+					}()
+					return nil
+				},
+				OnStop: elector.Stop,
+			})
+		}),
 		NewInterestAccrualRunnerModule(cfg.InterestAccrualRunnerConfig),
 		NewInterestPostingRunnerModule(cfg.InterestPostingRunnerConfig),
 		NewMaintenanceFeeRunnerModule(cfg.MaintenanceFeeRunnerConfig),
 		NewDormancyRunnerModule(cfg.DormancyRunnerConfig),
+		NewTermMaturityRunnerModule(cfg.TermMaturityRunnerConfig),
+		NewLoanServicingRunnerModule(cfg.LoanServicingRunnerConfig),
+		NewPenaltyFeeRunnerModule(cfg.PenaltyFeeRunnerConfig),
+		NewFeeRecoveryRunnerModule(cfg.FeeRecoveryRunnerConfig),
+		NewKYCExpiryRunnerModule(cfg.KYCExpiryRunnerConfig),
+		NewScreeningRunnerModule(cfg.ScreeningRunnerConfig),
 	)
 }
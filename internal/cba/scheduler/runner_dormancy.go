@@ -14,7 +14,6 @@ import (
 )
 
 type DormancyRunner struct {
-	stopChannel       chan chan struct{}
 	logger            logging.Logger
 	accountRepository repositories.AccountRepository
 	productRepository repositories.ProductRepository
@@ -30,7 +29,6 @@ func NewDormancyRunner(
 	cfg DormancyRunnerConfig,
 ) *DormancyRunner {
 	return &DormancyRunner{
-		stopChannel:       make(chan chan struct{}),
 		logger:            logger,
 		accountRepository: accountRepository,
 		productRepository: productRepository,
@@ -39,55 +37,27 @@ func NewDormancyRunner(
 	}
 }
 
-func (r *DormancyRunner) Run(ctx context.Context) error {
-	now := time.Now()
-	next := r.cfg.Schedule.Next(now).Sub(now)
-
-	for {
-		select {
-		case <-time.After(next):
-			if err := r.run(ctx, time.Now().UTC()); err != nil {
-				r.logger.Errorf("error running dormancy detection: %v", err)
-			}
-
-			now = time.Now()
-			next = r.cfg.Schedule.Next(now).Sub(now)
-		case ch := <-r.stopChannel:
-			close(ch)
-			return nil
-		}
-	}
-}
-
-func (r *DormancyRunner) Stop(ctx context.Context) error {
-	ch := make(chan struct{})
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case r.stopChannel <- ch:
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ch:
-		}
-	}
-	return nil
-}
-
-func (r *DormancyRunner) run(ctx context.Context, when time.Time) error {
+func (r *DormancyRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
 	status := models.AccountStatusActive
 	accounts, err := r.accountRepository.List(ctx, repositories.AccountFilter{Status: &status})
 	if err != nil {
-		return err
+		return report, err
 	}
 
 	for _, account := range accounts {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
 		product, err := r.productRepository.Get(ctx, account.ProductID)
 		if err != nil {
-			r.logger.Errorf("loading product for account %s: %v", account.ID, err)
+			report.fail("loading product for account %s: %v", account.ID, err)
 			continue
 		}
-		if product.Rules.DormancyDays == nil || *product.Rules.DormancyDays <= 0 {
+		// Running term deposits see no activity until they mature and loans
+		// are serviced by the loan servicing job.
+		if product.Rules.DormancyDays == nil || *product.Rules.DormancyDays <= 0 || (account.Term != nil && account.Term.Status == models.TermStatusRunning) || account.Loan != nil {
+			report.Skipped++
 			continue
 		}
 
@@ -96,14 +66,17 @@ func (r *DormancyRunner) run(ctx context.Context, when time.Time) error {
 			lastActivity = *account.LastActivityAt
 		}
 		if normalizeScheduleDate(lastActivity).AddDate(0, 0, *product.Rules.DormancyDays).After(normalizeScheduleDate(when)) {
+			report.Skipped++
 			continue
 		}
 		if _, err := r.accountService.Dormant(ctx, account.ID); err != nil {
-			r.logger.Errorf("marking account %s dormant: %v", account.ID, err)
+			report.fail("marking account %s dormant: %v", account.ID, err)
+			continue
 		}
+		report.Processed++
 	}
 
-	return nil
+	return report, nil
 }
 
 func NewDormancyRunnerModule(cfg DormancyRunnerConfig) fx.Option {
@@ -116,18 +89,8 @@ func NewDormancyRunnerModule(cfg DormancyRunnerConfig) fx.Option {
 		) *DormancyRunner {
 			return NewDormancyRunner(logger, accountRepository, productRepository, accountService, cfg)
 		}),
-		fx.Invoke(func(lc fx.Lifecycle, runner *DormancyRunner) {
-			lc.Append(fx.Hook{
-				OnStart: func(ctx context.Context) error {
-					go func() {
-						if err := runner.Run(context.WithoutCancel(ctx)); err != nil {
-							panic(err)
-						}
-					}()
-					return nil
-				},
-				OnStop: runner.Stop,
-			})
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *DormancyRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobDormancy, cfg.Schedule, runner.run))
 		}),
 	)
 }
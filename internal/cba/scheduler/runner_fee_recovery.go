@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+// FeeRecoveryRunner collects the fees pending recovery. Each run debits as
+// much of a fee as the wallet allows and books it as fee income; fees which
+// could not be collected are retried once the account is credited. Fees
+// still outstanding WriteoffAfterDays after they were charged are escalated
+// to be waived or written off.
+type FeeRecoveryRunner struct {
+	logger         logging.Logger
+	feeRepository  repositories.FeePostingRepository
+	accountService services.AccountService
+	feeService     services.FeeService
+	engine         PostingEngine
+	cfg            FeeRecoveryRunnerConfig
+}
+
+func NewFeeRecoveryRunner(
+	logger logging.Logger,
+	feeRepository repositories.FeePostingRepository,
+	accountService services.AccountService,
+	feeService services.FeeService,
+	engine PostingEngine,
+	cfg FeeRecoveryRunnerConfig,
+) *FeeRecoveryRunner {
+	return &FeeRecoveryRunner{
+		logger:         logger,
+		feeRepository:  feeRepository,
+		accountService: accountService,
+		feeService:     feeService,
+		engine:         engine,
+		cfg:            cfg,
+	}
+}
+
+func (r *FeeRecoveryRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
+	postings, err := r.feeRepository.ListPendingRecovery(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	for _, posting := range postings {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		recovered, err := r.recover(ctx, posting, when)
+		if err != nil {
+			report.fail("recovering fee posting %s: %v", posting.Reference, err)
+			continue
+		}
+		if recovered {
+			report.Processed++
+			continue
+		}
+		// A fee recovered from, even partially, is not escalated in the same run.
+		if err := r.escalate(ctx, posting, when); err != nil {
+			report.fail("escalating fee posting %s: %v", posting.Reference, err)
+			continue
+		}
+		report.Skipped++
+	}
+
+	return report, nil
+}
+
+func (r *FeeRecoveryRunner) recover(ctx context.Context, posting models.FeePosting, when time.Time) (bool, error) {
+	account, err := r.accountService.Get(ctx, posting.AccountID)
+	if err != nil {
+		return false, err
+	}
+
+	// The wallet was already debited for the whole fee under its reference,
+	// only the income is left to book.
+	if metadataBool(posting.Metadata, "wallet_posted") {
+		amount, err := decimalToMinorHalfUp(posting.Amount, posting.Currency)
+		if err != nil {
+			return false, err
+		}
+		if err := r.engine.RecordFeeIncome(ctx, posting.Currency, posting.Reference, amount, feePostingMetadata(posting, account)); err != nil {
+			return false, fmt.Errorf("recording fee income: %w", err)
+		}
+		if _, err := r.feeService.MarkPosted(ctx, posting.Reference); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	available, err := r.engine.AvailableBalance(ctx, *account)
+	if err != nil {
+		return false, fmt.Errorf("reading balance: %w", err)
+	}
+	recovery, err := r.feeService.PlanRecovery(ctx, posting.Reference, available, when)
+	switch {
+	case errors.Is(err, services.ErrFeeNoRecovery):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("planning recovery: %w", err)
+	}
+	// A recovery planned by a previous run was validated then and may
+	// already have been debited.
+	if posting.Recovery == nil {
+		if _, err := r.accountService.ValidateDebit(ctx, account.ID, recovery.Amount, available, when); err != nil {
+			if _, abandonErr := r.feeService.AbandonRecovery(ctx, posting.Reference, *recovery, err.Error()); abandonErr != nil {
+				return false, fmt.Errorf("abandoning recovery: %w", abandonErr)
+			}
+			if errors.Is(err, services.ErrAccountValidation) {
+				return false, nil
+			}
+			return false, fmt.Errorf("validating debit: %w", err)
+		}
+	}
+
+	txnMetadata := feePostingMetadata(posting, account)
+	if err := r.engine.Debit(ctx, *account, recovery.Amount, recovery.Reference, txnMetadata); err != nil {
+		return false, fmt.Errorf("debiting wallet: %w", err)
+	}
+	if err := r.engine.RecordFeeIncome(ctx, posting.Currency, recovery.Reference, recovery.Amount, txnMetadata); err != nil {
+		return false, fmt.Errorf("recording fee income: %w", err)
+	}
+	if _, err := r.feeService.CompleteRecovery(ctx, posting.Reference, *recovery); err != nil {
+		return false, fmt.Errorf("completing recovery: %w", err)
+	}
+	return true, nil
+}
+
+func (r *FeeRecoveryRunner) escalate(ctx context.Context, posting models.FeePosting, when time.Time) error {
+	if r.cfg.WriteoffAfterDays <= 0 || normalizeScheduleDate(posting.CreatedAt).AddDate(0, 0, r.cfg.WriteoffAfterDays).After(normalizeScheduleDate(when)) {
+		return nil
+	}
+	current, err := r.feeService.Get(ctx, posting.ID)
+	if err != nil {
+		return err
+	}
+	if current.Status != models.FeePostingStatusPendingRecovery || current.Recovery != nil {
+		return nil
+	}
+	_, err = r.feeService.MarkWriteoffRequired(ctx, posting.Reference, fmt.Sprintf("outstanding for more than %d days", r.cfg.WriteoffAfterDays))
+	return err
+}
+
+func NewFeeRecoveryRunnerModule(cfg FeeRecoveryRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			feeRepository repositories.FeePostingRepository,
+			accountService services.AccountService,
+			feeService services.FeeService,
+			engine PostingEngine,
+		) *FeeRecoveryRunner {
+			return NewFeeRecoveryRunner(logger, feeRepository, accountService, feeService, engine, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *FeeRecoveryRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobFeeRecovery, cfg.Schedule, runner.run))
+		}),
+	)
+}
@@ -14,8 +14,11 @@ import (
 	"github.com/formancehq/ledger/internal/cba/services"
 )
 
+// InterestAccrualRunner accrues the interest of the active accounts on the
+// business date. The balance accruing interest is the one of the account at the
+// end of the business date, read from the ledger as of that time so that a
+// run catching up on a past date does not accrue on the current balance.
 type InterestAccrualRunner struct {
-	stopChannel       chan chan struct{}
 	logger            logging.Logger
 	accountRepository repositories.AccountRepository
 	interestService   services.InterestService
@@ -31,7 +34,6 @@ func NewInterestAccrualRunner(
 	cfg InterestAccrualRunnerConfig,
 ) *InterestAccrualRunner {
 	return &InterestAccrualRunner{
-		stopChannel:       make(chan chan struct{}),
 		logger:            logger,
 		accountRepository: accountRepository,
 		interestService:   interestService,
@@ -40,60 +42,36 @@ func NewInterestAccrualRunner(
 	}
 }
 
-func (r *InterestAccrualRunner) Run(ctx context.Context) error {
-	now := time.Now()
-	next := r.cfg.Schedule.Next(now).Sub(now)
-
-	for {
-		select {
-		case <-time.After(next):
-			if err := r.run(ctx, time.Now().UTC()); err != nil {
-				r.logger.Errorf("error running interest accrual: %v", err)
-			}
-
-			now = time.Now()
-			next = r.cfg.Schedule.Next(now).Sub(now)
-		case ch := <-r.stopChannel:
-			close(ch)
-			return nil
-		}
-	}
-}
-
-func (r *InterestAccrualRunner) Stop(ctx context.Context) error {
-	ch := make(chan struct{})
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case r.stopChannel <- ch:
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ch:
-		}
-	}
-	return nil
-}
-
-func (r *InterestAccrualRunner) run(ctx context.Context, when time.Time) error {
+func (r *InterestAccrualRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
 	status := models.AccountStatusActive
 	accounts, err := r.accountRepository.List(ctx, repositories.AccountFilter{Status: &status})
 	if err != nil {
-		return err
+		return report, err
 	}
 
+	endOfDay := normalizeScheduleDate(when).AddDate(0, 0, 1).Add(-time.Microsecond)
 	for _, account := range accounts {
-		balance, err := r.engine.AvailableBalance(ctx, account)
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		balance, err := r.engine.BalanceAt(ctx, account, endOfDay)
 		if err != nil {
-			r.logger.Errorf("reading available balance for account %s: %v", account.ID, err)
+			report.fail("reading end of day balance for account %s: %v", account.ID, err)
 			continue
 		}
-		if _, err := r.interestService.Accrue(ctx, account.ID, balance, when); err != nil && !errors.Is(err, services.ErrInterestNotApplicable) {
-			r.logger.Errorf("accruing interest for account %s: %v", account.ID, err)
+		if _, err := r.interestService.Accrue(ctx, account.ID, balance, when); err != nil {
+			if errors.Is(err, services.ErrInterestNotApplicable) {
+				report.Skipped++
+				continue
+			}
+			report.fail("accruing interest for account %s: %v", account.ID, err)
+			continue
 		}
+		report.Processed++
 	}
 
-	return nil
+	return report, nil
 }
 
 func NewInterestAccrualRunnerModule(cfg InterestAccrualRunnerConfig) fx.Option {
@@ -106,18 +84,8 @@ func NewInterestAccrualRunnerModule(cfg InterestAccrualRunnerConfig) fx.Option {
 		) *InterestAccrualRunner {
 			return NewInterestAccrualRunner(logger, accountRepository, interestService, engine, cfg)
 		}),
-		fx.Invoke(func(lc fx.Lifecycle, runner *InterestAccrualRunner) {
-			lc.Append(fx.Hook{
-				OnStart: func(ctx context.Context) error {
-					go func() {
-						if err := runner.Run(context.WithoutCancel(ctx)); err != nil {
-							panic(err)
-						}
-					}()
-					return nil
-				},
-				OnStop: runner.Stop,
-			})
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *InterestAccrualRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobInterestAccrual, cfg.Schedule, runner.run))
 		}),
 	)
 }
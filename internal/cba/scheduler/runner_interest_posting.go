@@ -10,12 +10,12 @@ import (
 
 	"github.com/formancehq/go-libs/v3/logging"
 
+	"github.com/formancehq/ledger/internal/cba/models"
 	"github.com/formancehq/ledger/internal/cba/repositories"
 	"github.com/formancehq/ledger/internal/cba/services"
 )
 
 type InterestPostingRunner struct {
-	stopChannel       chan chan struct{}
 	logger            logging.Logger
 	accountRepository repositories.AccountRepository
 	interestService   services.InterestService
@@ -31,7 +31,6 @@ func NewInterestPostingRunner(
 	cfg InterestPostingRunnerConfig,
 ) *InterestPostingRunner {
 	return &InterestPostingRunner{
-		stopChannel:       make(chan chan struct{}),
 		logger:            logger,
 		accountRepository: accountRepository,
 		interestService:   interestService,
@@ -40,89 +39,89 @@ func NewInterestPostingRunner(
 	}
 }
 
-func (r *InterestPostingRunner) Run(ctx context.Context) error {
-	now := time.Now()
-	next := r.cfg.Schedule.Next(now).Sub(now)
-
-	for {
-		select {
-		case <-time.After(next):
-			if err := r.run(ctx, time.Now().UTC()); err != nil {
-				r.logger.Errorf("error running interest posting: %v", err)
-			}
-
-			now = time.Now()
-			next = r.cfg.Schedule.Next(now).Sub(now)
-		case ch := <-r.stopChannel:
-			close(ch)
-			return nil
-		}
-	}
-}
-
-func (r *InterestPostingRunner) Stop(ctx context.Context) error {
-	ch := make(chan struct{})
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case r.stopChannel <- ch:
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ch:
-		}
-	}
-	return nil
-}
-
-func (r *InterestPostingRunner) run(ctx context.Context, when time.Time) error {
+func (r *InterestPostingRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
 	accounts, err := r.accountRepository.List(ctx, repositories.AccountFilter{})
 	if err != nil {
-		return err
+		return report, err
 	}
 
 	for _, account := range accounts {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
 		due, err := r.interestService.IsPostingDue(ctx, account.ID, when)
 		if err != nil {
-			r.logger.Errorf("checking interest posting due for account %s: %v", account.ID, err)
+			report.fail("checking interest posting due for account %s: %v", account.ID, err)
 			continue
 		}
 		if !due {
+			report.Skipped++
 			continue
 		}
 
 		preview, err := r.interestService.PreviewPosting(ctx, account.ID)
 		if err != nil {
 			if errors.Is(err, services.ErrInterestNotApplicable) {
+				report.Skipped++
 				continue
 			}
-			r.logger.Errorf("previewing interest posting for account %s: %v", account.ID, err)
+			report.fail("previewing interest posting for account %s: %v", account.ID, err)
 			continue
 		}
 		if preview.PostableAmount <= 0 {
+			report.Skipped++
 			continue
 		}
 
 		reference := fmt.Sprintf("interest:%s:%s", account.ID.String(), normalizeScheduleDate(when).Format("2006-01-02"))
-		txnMetadata := map[string]string{
-			"cba_operation": "interest_posting",
-			"account_id":    account.ID.String(),
-			"wallet_id":     account.WalletID,
+		if err := postInterest(ctx, r.engine, r.interestService, account, *preview, reference); err != nil {
+			report.fail("posting interest for account %s: %v", account.ID, err)
+			continue
 		}
+		report.Processed++
+	}
 
-		if err := r.engine.Credit(ctx, account, preview.PostableAmount, reference, txnMetadata); err != nil {
-			r.logger.Errorf("posting wallet interest for account %s: %v", account.ID, err)
-			continue
+	return report, nil
+}
+
+// postInterest credits the account with the interest net of the tax withheld.
+// The gross interest is expensed and the tax withheld is owed to the tax
+// authority.
+func postInterest(ctx context.Context, engine PostingEngine, interestService services.InterestService, account models.Account, preview services.InterestPostingPreview, reference string) error {
+	txnMetadata := map[string]string{
+		"cba_operation": "interest_posting",
+		"account_id":    account.ID.String(),
+		"wallet_id":     account.WalletID,
+	}
+	if preview.Withholding != nil {
+		txnMetadata["gross_amount"] = fmt.Sprint(preview.PostableAmount)
+		txnMetadata["withholding_tax"] = fmt.Sprint(preview.Withholding.Amount)
+	}
+
+	if net := preview.NetAmount(); net > 0 {
+		if err := engine.Credit(ctx, account, net, reference, txnMetadata); err != nil {
+			return fmt.Errorf("posting wallet interest: %w", err)
 		}
-		if err := r.engine.RecordInterestExpense(ctx, account.Currency, reference, preview.PostableAmount, txnMetadata); err != nil {
-			r.logger.Errorf("recording interest expense for account %s: %v", account.ID, err)
-			continue
+	}
+	if err := engine.RecordInterestExpense(ctx, account.Currency, reference, preview.PostableAmount, txnMetadata); err != nil {
+		return fmt.Errorf("recording interest expense: %w", err)
+	}
+	if preview.Withholding != nil && preview.Withholding.Amount > 0 {
+		taxMetadata := map[string]string{
+			"cba_operation":      "withholding_tax",
+			"account_id":         account.ID.String(),
+			"interest_reference": reference,
+			"residency":          preview.Withholding.Residency,
+			"rate":               preview.Withholding.Rate.String(),
 		}
-		if err := r.interestService.MarkPosted(ctx, *preview, reference); err != nil {
-			r.logger.Errorf("marking interest as posted for account %s: %v", account.ID, err)
+		if err := engine.RecordTaxLiability(ctx, account.Currency, reference+":tax", preview.Withholding.Amount, taxMetadata); err != nil {
+			return fmt.Errorf("recording withholding tax: %w", err)
 		}
 	}
-
+	if err := interestService.MarkPosted(ctx, preview, reference); err != nil {
+		return fmt.Errorf("marking interest as posted: %w", err)
+	}
 	return nil
 }
 
@@ -136,18 +135,8 @@ func NewInterestPostingRunnerModule(cfg InterestPostingRunnerConfig) fx.Option {
 		) *InterestPostingRunner {
 			return NewInterestPostingRunner(logger, accountRepository, interestService, engine, cfg)
 		}),
-		fx.Invoke(func(lc fx.Lifecycle, runner *InterestPostingRunner) {
-			lc.Append(fx.Hook{
-				OnStart: func(ctx context.Context) error {
-					go func() {
-						if err := runner.Run(context.WithoutCancel(ctx)); err != nil {
-							panic(err)
-						}
-					}()
-					return nil
-				},
-				OnStop: runner.Stop,
-			})
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *InterestPostingRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobInterestPosting, cfg.Schedule, runner.run))
 		}),
 	)
 }
@@ -0,0 +1,239 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+// KYCExpiryNotice announces that a verified KYC record is about to expire,
+// so that the client can be asked to verify again.
+type KYCExpiryNotice struct {
+	KYCID     uuid.UUID `json:"kyc_id"`
+	ClientID  uuid.UUID `json:"client_id"`
+	Level     int       `json:"level"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type KYCExpiryNotifier interface {
+	NotifyKYCExpiry(context.Context, KYCExpiryNotice) error
+}
+
+// LogKYCExpiryNotifier logs the notices. It is used when no webhook is
+// configured.
+type LogKYCExpiryNotifier struct {
+	logger logging.Logger
+}
+
+func NewLogKYCExpiryNotifier(logger logging.Logger) *LogKYCExpiryNotifier {
+	return &LogKYCExpiryNotifier{logger: logger}
+}
+
+func (n *LogKYCExpiryNotifier) NotifyKYCExpiry(_ context.Context, notice KYCExpiryNotice) error {
+	n.logger.Infof("kyc record %s of client %s expires on %s", notice.KYCID, notice.ClientID, notice.ExpiresAt.Format(time.DateOnly))
+	return nil
+}
+
+// HTTPKYCExpiryNotifier posts the notices as JSON to a webhook.
+type HTTPKYCExpiryNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewHTTPKYCExpiryNotifier(url string, httpClient *http.Client) *HTTPKYCExpiryNotifier {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &HTTPKYCExpiryNotifier{
+		url:        url,
+		httpClient: httpClient,
+	}
+}
+
+func (n *HTTPKYCExpiryNotifier) NotifyKYCExpiry(ctx context.Context, notice KYCExpiryNotice) error {
+	body, err := json.Marshal(notice)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = rsp.Body.Close()
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", rsp.StatusCode)
+	}
+	return nil
+}
+
+// KYCExpiryRunner expires the verified KYC records past their expiry, which
+// downgrades their clients, then restricts the debits of the accounts whose
+// product now requires a higher KYC level than their client holds. The
+// restriction of accounts whose client verified again is lifted. Records
+// expiring within NoticeDays are notified once.
+type KYCExpiryRunner struct {
+	logger            logging.Logger
+	kycRepository     repositories.KYCRepository
+	accountRepository repositories.AccountRepository
+	kycService        services.KYCService
+	accountService    services.AccountService
+	notifier          KYCExpiryNotifier
+	cfg               KYCExpiryRunnerConfig
+}
+
+func NewKYCExpiryRunner(
+	logger logging.Logger,
+	kycRepository repositories.KYCRepository,
+	accountRepository repositories.AccountRepository,
+	kycService services.KYCService,
+	accountService services.AccountService,
+	notifier KYCExpiryNotifier,
+	cfg KYCExpiryRunnerConfig,
+) *KYCExpiryRunner {
+	return &KYCExpiryRunner{
+		logger:            logger,
+		kycRepository:     kycRepository,
+		accountRepository: accountRepository,
+		kycService:        kycService,
+		accountService:    accountService,
+		notifier:          notifier,
+		cfg:               cfg,
+	}
+}
+
+func (r *KYCExpiryRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
+	verified := models.KYCStatusVerified
+	expired, err := r.kycRepository.List(ctx, repositories.KYCFilter{
+		Status:        &verified,
+		ExpiresBefore: &when,
+	})
+	if err != nil {
+		return report, err
+	}
+
+	accounts := make([]models.Account, 0)
+	downgraded := map[uuid.UUID]struct{}{}
+	for _, record := range expired {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		if _, err := r.kycService.Expire(ctx, record.ClientID, record.ID); err != nil {
+			report.fail("expiring kyc record %s: %v", record.ID, err)
+			continue
+		}
+		report.Processed++
+
+		if _, ok := downgraded[record.ClientID]; ok {
+			continue
+		}
+		downgraded[record.ClientID] = struct{}{}
+		clientAccounts, err := r.accountRepository.List(ctx, repositories.AccountFilter{ClientID: &record.ClientID})
+		if err != nil {
+			report.fail("listing accounts of client %s: %v", record.ClientID, err)
+			continue
+		}
+		accounts = append(accounts, clientAccounts...)
+	}
+
+	restricted, err := r.accountRepository.List(ctx, repositories.AccountFilter{KYCRestricted: true})
+	if err != nil {
+		return report, err
+	}
+	accounts = append(accounts, restricted...)
+
+	applied := map[uuid.UUID]struct{}{}
+	for _, account := range accounts {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		if _, ok := applied[account.ID]; ok || account.Status == models.AccountStatusClosed {
+			continue
+		}
+		applied[account.ID] = struct{}{}
+
+		updated, err := r.accountService.ApplyKYCRestriction(ctx, account.ID)
+		if err != nil {
+			report.fail("applying kyc restriction to account %s: %v", account.ID, err)
+			continue
+		}
+		if updated.KYCRestricted == account.KYCRestricted {
+			report.Skipped++
+			continue
+		}
+		report.Processed++
+	}
+
+	if r.cfg.NoticeDays <= 0 {
+		return report, nil
+	}
+	noticeBefore := when.AddDate(0, 0, r.cfg.NoticeDays)
+	expiring, err := r.kycRepository.List(ctx, repositories.KYCFilter{
+		Status:        &verified,
+		ExpiresBefore: &noticeBefore,
+		Unnotified:    true,
+	})
+	if err != nil {
+		return report, err
+	}
+	for _, record := range expiring {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		if err := r.notifier.NotifyKYCExpiry(ctx, KYCExpiryNotice{
+			KYCID:     record.ID,
+			ClientID:  record.ClientID,
+			Level:     record.Level,
+			ExpiresAt: *record.ExpiresAt,
+		}); err != nil {
+			report.fail("notifying expiry of kyc record %s: %v", record.ID, err)
+			continue
+		}
+		if _, err := r.kycService.MarkExpiryNotified(ctx, record.ClientID, record.ID); err != nil {
+			report.fail("recording expiry notice of kyc record %s: %v", record.ID, err)
+			continue
+		}
+		report.Processed++
+	}
+
+	return report, nil
+}
+
+func NewKYCExpiryRunnerModule(cfg KYCExpiryRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			kycRepository repositories.KYCRepository,
+			accountRepository repositories.AccountRepository,
+			kycService services.KYCService,
+			accountService services.AccountService,
+		) *KYCExpiryRunner {
+			var notifier KYCExpiryNotifier = NewLogKYCExpiryNotifier(logger)
+			if cfg.WebhookURL != "" {
+				notifier = NewHTTPKYCExpiryNotifier(cfg.WebhookURL, nil)
+			}
+			return NewKYCExpiryRunner(logger, kycRepository, accountRepository, kycService, accountService, notifier, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *KYCExpiryRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobKYCExpiry, cfg.Schedule, runner.run))
+		}),
+	)
+}
@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+// LoanServicingRunner services the loans on the business date. Approved loans
+// are disbursed to their settlement account, then interest and penalties are
+// accrued and whatever is due is collected from the settlement account. The
+// principal repaid goes back to the loan wallet while the interest and
+// penalty collected are booked as income in the revenue ledger.
+type LoanServicingRunner struct {
+	logger      logging.Logger
+	loanService services.LoanService
+	engine      PostingEngine
+	cfg         LoanServicingRunnerConfig
+}
+
+func NewLoanServicingRunner(
+	logger logging.Logger,
+	loanService services.LoanService,
+	engine PostingEngine,
+	cfg LoanServicingRunnerConfig,
+) *LoanServicingRunner {
+	return &LoanServicingRunner{
+		logger:      logger,
+		loanService: loanService,
+		engine:      engine,
+		cfg:         cfg,
+	}
+}
+
+func (r *LoanServicingRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
+	accounts, err := r.loanService.ListServiced(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	for _, account := range accounts {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		if err := r.service(ctx, account, when); err != nil {
+			report.fail("servicing loan %s: %v", account.ID, err)
+			continue
+		}
+		report.Processed++
+	}
+
+	return report, nil
+}
+
+func (r *LoanServicingRunner) service(ctx context.Context, account models.Account, when time.Time) error {
+	settlement, err := r.loanService.SettlementAccount(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	if account.Loan.Status == models.LoanStatusApproved {
+		if err := r.engine.Disburse(ctx, account, *settlement, account.Loan.Principal, fmt.Sprintf("loan:%s:disbursement", account.ID), map[string]string{
+			"cba_operation":         "loan_disbursement",
+			"account_id":            account.ID.String(),
+			"wallet_id":             account.WalletID,
+			"settlement_account_id": settlement.ID.String(),
+		}); err != nil {
+			return fmt.Errorf("disbursing: %w", err)
+		}
+		if _, err := r.loanService.CompleteDisbursement(ctx, account.ID, when); err != nil {
+			return fmt.Errorf("completing disbursement: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := r.loanService.Accrue(ctx, account.ID, when); err != nil {
+		return fmt.Errorf("accruing: %w", err)
+	}
+
+	available, err := r.engine.AvailableBalance(ctx, *settlement)
+	if err != nil {
+		return fmt.Errorf("reading settlement balance: %w", err)
+	}
+	repayment, err := r.loanService.PlanRepayment(ctx, account.ID, available, when)
+	switch {
+	case errors.Is(err, services.ErrLoanNoRepayment):
+		return nil
+	case err != nil:
+		return fmt.Errorf("planning repayment: %w", err)
+	}
+
+	txnMetadata := map[string]string{
+		"cba_operation":         "loan_repayment",
+		"account_id":            account.ID.String(),
+		"wallet_id":             account.WalletID,
+		"settlement_account_id": settlement.ID.String(),
+	}
+	if repayment.Principal > 0 {
+		if err := r.engine.Transfer(ctx, *settlement, account, repayment.Principal, repayment.Reference+":principal", txnMetadata); err != nil {
+			return fmt.Errorf("collecting principal: %w", err)
+		}
+	}
+	if charges := repayment.Interest + repayment.Penalty; charges > 0 {
+		if err := r.engine.Debit(ctx, *settlement, charges, repayment.Reference+":charges", txnMetadata); err != nil {
+			return fmt.Errorf("collecting interest and penalty: %w", err)
+		}
+	}
+	if repayment.Interest > 0 {
+		if err := r.engine.RecordInterestIncome(ctx, account.Currency, repayment.Reference+":interest", repayment.Interest, txnMetadata); err != nil {
+			return fmt.Errorf("recording interest income: %w", err)
+		}
+	}
+	if repayment.Penalty > 0 {
+		if err := r.engine.RecordFeeIncome(ctx, account.Currency, repayment.Reference+":penalty", repayment.Penalty, txnMetadata); err != nil {
+			return fmt.Errorf("recording penalty income: %w", err)
+		}
+	}
+	if _, err := r.loanService.CompleteRepayment(ctx, account.ID, *repayment); err != nil {
+		return fmt.Errorf("completing repayment: %w", err)
+	}
+	return nil
+}
+
+func NewLoanServicingRunnerModule(cfg LoanServicingRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			loanService services.LoanService,
+			engine PostingEngine,
+		) *LoanServicingRunner {
+			return NewLoanServicingRunner(logger, loanService, engine, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *LoanServicingRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobLoanServicing, cfg.Schedule, runner.run))
+		}),
+	)
+}
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"go.uber.org/fx"
@@ -14,11 +15,12 @@ import (
 	"github.com/formancehq/ledger/internal/cba/services"
 )
 
+// MaintenanceFeeRunner charges the maintenance fees due on the business
+// date. Fees the balance does not cover are left pending recovery and
+// collected by the fee recovery job.
 type MaintenanceFeeRunner struct {
-	stopChannel       chan chan struct{}
 	logger            logging.Logger
 	accountRepository repositories.AccountRepository
-	feeRepository     repositories.FeePostingRepository
 	accountService    services.AccountService
 	feeService        services.FeeService
 	engine            PostingEngine
@@ -28,17 +30,14 @@ type MaintenanceFeeRunner struct {
 func NewMaintenanceFeeRunner(
 	logger logging.Logger,
 	accountRepository repositories.AccountRepository,
-	feeRepository repositories.FeePostingRepository,
 	accountService services.AccountService,
 	feeService services.FeeService,
 	engine PostingEngine,
 	cfg MaintenanceFeeRunnerConfig,
 ) *MaintenanceFeeRunner {
 	return &MaintenanceFeeRunner{
-		stopChannel:       make(chan chan struct{}),
 		logger:            logger,
 		accountRepository: accountRepository,
-		feeRepository:     feeRepository,
 		accountService:    accountService,
 		feeService:        feeService,
 		engine:            engine,
@@ -46,82 +45,45 @@ func NewMaintenanceFeeRunner(
 	}
 }
 
-func (r *MaintenanceFeeRunner) Run(ctx context.Context) error {
-	now := time.Now()
-	next := r.cfg.Schedule.Next(now).Sub(now)
-
-	for {
-		select {
-		case <-time.After(next):
-			if err := r.run(ctx, time.Now().UTC()); err != nil {
-				r.logger.Errorf("error running maintenance fees: %v", err)
-			}
-
-			now = time.Now()
-			next = r.cfg.Schedule.Next(now).Sub(now)
-		case ch := <-r.stopChannel:
-			close(ch)
-			return nil
-		}
-	}
-}
-
-func (r *MaintenanceFeeRunner) Stop(ctx context.Context) error {
-	ch := make(chan struct{})
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case r.stopChannel <- ch:
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-ch:
-		}
-	}
-	return nil
-}
-
-func (r *MaintenanceFeeRunner) run(ctx context.Context, when time.Time) error {
-	if err := r.prepareDueFees(ctx, when); err != nil {
-		return err
-	}
-
-	postings, err := r.feeRepository.ListPendingRecovery(ctx)
-	if err != nil {
-		return err
-	}
-
-	for _, posting := range postings {
-		if err := r.processPendingFee(ctx, posting, when); err != nil {
-			r.logger.Errorf("processing fee posting %s: %v", posting.Reference, err)
-		}
-	}
-
-	return nil
-}
-
-func (r *MaintenanceFeeRunner) prepareDueFees(ctx context.Context, when time.Time) error {
+func (r *MaintenanceFeeRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
 	status := models.AccountStatusActive
 	accounts, err := r.accountRepository.List(ctx, repositories.AccountFilter{Status: &status})
 	if err != nil {
-		return err
+		return report, err
 	}
 
 	for _, account := range accounts {
-		if _, err := r.feeService.PrepareMaintenanceFee(ctx, account.ID, when); err != nil && !errors.Is(err, services.ErrFeeNotApplicable) {
-			r.logger.Errorf("preparing maintenance fee for account %s: %v", account.ID, err)
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		posting, err := r.feeService.PrepareMaintenanceFee(ctx, account.ID, when)
+		if err != nil {
+			if errors.Is(err, services.ErrFeeNotApplicable) {
+				report.Skipped++
+				continue
+			}
+			report.fail("preparing maintenance fee for account %s: %v", account.ID, err)
+			continue
+		}
+		if err := r.charge(ctx, *posting, when); err != nil {
+			report.fail("charging fee posting %s: %v", posting.Reference, err)
+			continue
 		}
+		report.Processed++
 	}
 
-	return nil
+	return report, nil
 }
 
-func (r *MaintenanceFeeRunner) processPendingFee(ctx context.Context, posting models.FeePosting, when time.Time) error {
+func (r *MaintenanceFeeRunner) charge(ctx context.Context, posting models.FeePosting, when time.Time) error {
+	if !chargeableInFull(posting) {
+		return nil
+	}
 	account, err := r.accountService.Get(ctx, posting.AccountID)
 	if err != nil {
 		return err
 	}
-
 	amountMinor, err := decimalToMinorHalfUp(posting.Amount, posting.Currency)
 	if err != nil {
 		return err
@@ -130,40 +92,58 @@ func (r *MaintenanceFeeRunner) processPendingFee(ctx context.Context, posting mo
 		return nil
 	}
 
-	walletPosted := metadataBool(posting.Metadata, "wallet_posted")
-	if !walletPosted {
+	if !metadataBool(posting.Metadata, "wallet_posted") {
 		currentBalance, err := r.engine.AvailableBalance(ctx, *account)
 		if err != nil {
-			r.markPending(ctx, posting.Reference, false, err)
-			return err
+			return fmt.Errorf("reading balance: %w", err)
 		}
 		if _, err := r.accountService.ValidateDebit(ctx, account.ID, amountMinor, currentBalance, when); err != nil {
-			r.markPending(ctx, posting.Reference, false, err)
-			return err
-		}
-		if err := r.engine.Debit(ctx, *account, amountMinor, posting.Reference, feePostingMetadata(posting, account)); err != nil {
-			r.markPending(ctx, posting.Reference, false, err)
+			markFeePendingRecovery(ctx, r.logger, r.feeService, posting.Reference, false, err)
+			if errors.Is(err, services.ErrAccountInsufficientFunds) {
+				return nil
+			}
 			return err
 		}
-		walletPosted = true
 	}
+	return postFee(ctx, r.logger, r.feeService, r.engine, posting, account, amountMinor)
+}
 
-	if err := r.engine.RecordFeeIncome(ctx, posting.Currency, posting.Reference, amountMinor, feePostingMetadata(posting, account)); err != nil {
-		r.markPending(ctx, posting.Reference, walletPosted, err)
-		return err
-	}
+// chargeableInFull reports whether a fee just prepared is still to be
+// charged in full. Fees partially recovered or being recovered are left to
+// the fee recovery job.
+func chargeableInFull(posting models.FeePosting) bool {
+	return posting.Status == models.FeePostingStatusPendingRecovery &&
+		posting.Recovery == nil &&
+		posting.RecoveredAmount.IsZero()
+}
 
-	_, err = r.feeService.MarkPosted(ctx, posting.Reference)
+// postFee debits a fee in full under its reference, unless the wallet was
+// already debited, and books it as fee income.
+func postFee(ctx context.Context, logger logging.Logger, feeService services.FeeService, engine PostingEngine, posting models.FeePosting, account *models.Account, amount int64) error {
+	walletPosted := metadataBool(posting.Metadata, "wallet_posted")
+	if !walletPosted {
+		if err := engine.Debit(ctx, *account, amount, posting.Reference, feePostingMetadata(posting, account)); err != nil {
+			markFeePendingRecovery(ctx, logger, feeService, posting.Reference, false, err)
+			return fmt.Errorf("debiting wallet: %w", err)
+		}
+	}
+	if err := engine.RecordFeeIncome(ctx, posting.Currency, posting.Reference, amount, feePostingMetadata(posting, account)); err != nil {
+		markFeePendingRecovery(ctx, logger, feeService, posting.Reference, true, err)
+		return fmt.Errorf("recording fee income: %w", err)
+	}
+	_, err := feeService.MarkPosted(ctx, posting.Reference)
 	return err
 }
 
-func (r *MaintenanceFeeRunner) markPending(ctx context.Context, reference string, walletPosted bool, reason error) {
-	if _, err := r.feeService.MarkPendingRecovery(ctx, reference, map[string]any{
-		"wallet_posted":      walletPosted,
-		"recovery_error":     reason.Error(),
-		"recovery_attempted": normalizeScheduleDate(time.Now().UTC()).Format(time.RFC3339),
+// markFeePendingRecovery leaves a fee pending recovery after a failed
+// collection attempt, remembering whether the wallet was already debited.
+// The fee recovery job collects what the balance covers from then on.
+func markFeePendingRecovery(ctx context.Context, logger logging.Logger, feeService services.FeeService, reference string, walletPosted bool, reason error) {
+	if _, err := feeService.MarkPendingRecovery(ctx, reference, map[string]any{
+		"wallet_posted":  walletPosted,
+		"recovery_error": reason.Error(),
 	}); err != nil {
-		r.logger.Errorf("marking fee %s pending recovery: %v", reference, err)
+		logger.Errorf("marking fee %s pending recovery: %v", reference, err)
 	}
 }
 
@@ -191,25 +171,14 @@ func NewMaintenanceFeeRunnerModule(cfg MaintenanceFeeRunnerConfig) fx.Option {
 		fx.Provide(func(
 			logger logging.Logger,
 			accountRepository repositories.AccountRepository,
-			feeRepository repositories.FeePostingRepository,
 			accountService services.AccountService,
 			feeService services.FeeService,
 			engine PostingEngine,
 		) *MaintenanceFeeRunner {
-			return NewMaintenanceFeeRunner(logger, accountRepository, feeRepository, accountService, feeService, engine, cfg)
+			return NewMaintenanceFeeRunner(logger, accountRepository, accountService, feeService, engine, cfg)
 		}),
-		fx.Invoke(func(lc fx.Lifecycle, runner *MaintenanceFeeRunner) {
-			lc.Append(fx.Hook{
-				OnStart: func(ctx context.Context) error {
-					go func() {
-						if err := runner.Run(context.WithoutCancel(ctx)); err != nil {
-							panic(err)
-						}
-					}()
-					return nil
-				},
-				OnStop: runner.Stop,
-			})
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *MaintenanceFeeRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobMaintenanceFee, cfg.Schedule, runner.run))
 		}),
 	)
 }
@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+// PenaltyFeeRunner charges the penalty fees triggered by the state of the
+// accounts on the business date: active accounts below their product
+// min_balance and dormant accounts. The fees are collected right away when
+// the balance covers them and otherwise left to the fee recovery job.
+// Penalties triggered by debits and closures are recorded by the API.
+type PenaltyFeeRunner struct {
+	logger            logging.Logger
+	accountRepository repositories.AccountRepository
+	productRepository repositories.ProductRepository
+	feeService        services.FeeService
+	engine            PostingEngine
+	cfg               PenaltyFeeRunnerConfig
+}
+
+func NewPenaltyFeeRunner(
+	logger logging.Logger,
+	accountRepository repositories.AccountRepository,
+	productRepository repositories.ProductRepository,
+	feeService services.FeeService,
+	engine PostingEngine,
+	cfg PenaltyFeeRunnerConfig,
+) *PenaltyFeeRunner {
+	return &PenaltyFeeRunner{
+		logger:            logger,
+		accountRepository: accountRepository,
+		productRepository: productRepository,
+		feeService:        feeService,
+		engine:            engine,
+		cfg:               cfg,
+	}
+}
+
+func (r *PenaltyFeeRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
+	for _, sweep := range []struct {
+		status  string
+		penalty string
+	}{
+		{status: models.AccountStatusActive, penalty: models.PenaltyMinimumBalance},
+		{status: models.AccountStatusDormant, penalty: models.PenaltyDormancy},
+	} {
+		status, penalty := sweep.status, sweep.penalty
+		accounts, err := r.accountRepository.List(ctx, repositories.AccountFilter{Status: &status})
+		if err != nil {
+			return report, err
+		}
+		for _, account := range accounts {
+			if err := context.Cause(ctx); err != nil {
+				return report, err
+			}
+			charged, err := r.charge(ctx, account, penalty, when)
+			switch {
+			case err != nil:
+				report.fail("charging %s penalty to account %s: %v", penalty, account.ID, err)
+			case charged:
+				report.Processed++
+			default:
+				report.Skipped++
+			}
+		}
+	}
+	return report, nil
+}
+
+func (r *PenaltyFeeRunner) charge(ctx context.Context, account models.Account, penalty string, when time.Time) (bool, error) {
+	// Running term deposits are locked and loan wallets carry the
+	// outstanding principal.
+	if account.Loan != nil || (account.Term != nil && account.Term.Status == models.TermStatusRunning) {
+		return false, nil
+	}
+	product, err := r.productRepository.Get(ctx, account.ProductID)
+	if err != nil {
+		return false, fmt.Errorf("loading product: %w", err)
+	}
+	if product.FeeSchedule == nil {
+		return false, nil
+	}
+	if _, ok := product.FeeSchedule.PenaltyFees[penalty]; !ok {
+		return false, nil
+	}
+
+	balance, err := r.engine.AvailableBalance(ctx, account)
+	if err != nil {
+		return false, fmt.Errorf("reading balance: %w", err)
+	}
+	posting, err := r.feeService.PreparePenaltyFee(ctx, account.ID, services.PenaltyTrigger{
+		Penalty: penalty,
+		Amount:  balance,
+		At:      when,
+	})
+	switch {
+	case errors.Is(err, services.ErrFeeNotApplicable):
+		return false, nil
+	case err != nil:
+		return false, err
+	case !chargeableInFull(*posting):
+		return false, nil
+	}
+
+	amount, err := decimalToMinorHalfUp(posting.Amount, posting.Currency)
+	if err != nil {
+		return false, err
+	}
+	if !metadataBool(posting.Metadata, "wallet_posted") && (posting.Currency != account.Currency || amount > balance) {
+		markFeePendingRecovery(ctx, r.logger, r.feeService, posting.Reference, false, fmt.Errorf("available balance does not cover the penalty"))
+		return true, nil
+	}
+	if err := postFee(ctx, r.logger, r.feeService, r.engine, *posting, &account, amount); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func NewPenaltyFeeRunnerModule(cfg PenaltyFeeRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			accountRepository repositories.AccountRepository,
+			productRepository repositories.ProductRepository,
+			feeService services.FeeService,
+			engine PostingEngine,
+		) *PenaltyFeeRunner {
+			return NewPenaltyFeeRunner(logger, accountRepository, productRepository, feeService, engine, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *PenaltyFeeRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobPenaltyFees, cfg.Schedule, runner.run))
+		}),
+	)
+}
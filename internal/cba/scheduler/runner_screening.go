@@ -0,0 +1,86 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+// ScreeningRunner screens again the clients that were not screened against
+// the current version of the watchlists, so that list updates reach the
+// existing clients and not only the new ones.
+type ScreeningRunner struct {
+	logger           logging.Logger
+	clientRepository repositories.ClientRepository
+	screeningService services.ScreeningService
+	cfg              ScreeningRunnerConfig
+}
+
+func NewScreeningRunner(
+	logger logging.Logger,
+	clientRepository repositories.ClientRepository,
+	screeningService services.ScreeningService,
+	cfg ScreeningRunnerConfig,
+) *ScreeningRunner {
+	return &ScreeningRunner{
+		logger:           logger,
+		clientRepository: clientRepository,
+		screeningService: screeningService,
+		cfg:              cfg,
+	}
+}
+
+func (r *ScreeningRunner) run(ctx context.Context, _ time.Time) (JobReport, error) {
+	var report JobReport
+	version, err := r.screeningService.WatchlistVersion()
+	if err != nil {
+		return report, err
+	}
+	if version == "" {
+		return report, nil
+	}
+
+	clients, err := r.clientRepository.List(ctx, repositories.ClientFilter{NotScreenedWith: &version})
+	if err != nil {
+		return report, err
+	}
+	for i := range clients {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		client := &clients[i]
+		if client.Status == models.ClientStatusClosed {
+			report.Skipped++
+			continue
+		}
+		if err := r.screeningService.ScreenClient(ctx, client); err != nil {
+			report.fail("screening client %s: %v", client.ID, err)
+			continue
+		}
+		report.Processed++
+	}
+
+	return report, nil
+}
+
+func NewScreeningRunnerModule(cfg ScreeningRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			clientRepository repositories.ClientRepository,
+			screeningService services.ScreeningService,
+		) *ScreeningRunner {
+			return NewScreeningRunner(logger, clientRepository, screeningService, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *ScreeningRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobScreening, cfg.Schedule, runner.run))
+		}),
+	)
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/services"
+)
+
+// TermMaturityRunner matures the term deposits due on the business date. The
+// interest still accrued on the term is posted first, then the payout the
+// rollover option calls for is moved to the payout account and the account is
+// rolled over or closed. Every step is keyed by the maturity date so a failed
+// maturity is completed by the next run.
+type TermMaturityRunner struct {
+	logger             logging.Logger
+	termDepositService services.TermDepositService
+	interestService    services.InterestService
+	engine             PostingEngine
+	cfg                TermMaturityRunnerConfig
+}
+
+func NewTermMaturityRunner(
+	logger logging.Logger,
+	termDepositService services.TermDepositService,
+	interestService services.InterestService,
+	engine PostingEngine,
+	cfg TermMaturityRunnerConfig,
+) *TermMaturityRunner {
+	return &TermMaturityRunner{
+		logger:             logger,
+		termDepositService: termDepositService,
+		interestService:    interestService,
+		engine:             engine,
+		cfg:                cfg,
+	}
+}
+
+func (r *TermMaturityRunner) run(ctx context.Context, when time.Time) (JobReport, error) {
+	var report JobReport
+	accounts, err := r.termDepositService.ListMaturing(ctx, when)
+	if err != nil {
+		return report, err
+	}
+
+	for _, account := range accounts {
+		if err := context.Cause(ctx); err != nil {
+			return report, err
+		}
+		if err := r.mature(ctx, account, when); err != nil {
+			report.fail("maturing account %s: %v", account.ID, err)
+			continue
+		}
+		report.Processed++
+	}
+
+	return report, nil
+}
+
+func (r *TermMaturityRunner) mature(ctx context.Context, account models.Account, when time.Time) error {
+	reference := fmt.Sprintf("maturity:%s:%s", account.ID.String(), normalizeScheduleDate(*account.MaturityDate).Format("2006-01-02"))
+
+	preview, err := r.interestService.PreviewPosting(ctx, account.ID)
+	switch {
+	case errors.Is(err, services.ErrInterestNotApplicable):
+	case err != nil:
+		return fmt.Errorf("previewing interest posting: %w", err)
+	case preview.PostableAmount > 0:
+		if err := postInterest(ctx, r.engine, r.interestService, account, *preview, reference+":interest"); err != nil {
+			return err
+		}
+	}
+
+	balance, err := r.engine.AvailableBalance(ctx, account)
+	if err != nil {
+		return fmt.Errorf("reading balance: %w", err)
+	}
+	plan, err := r.termDepositService.PlanMaturity(ctx, account.ID, balance, when)
+	if err != nil {
+		return err
+	}
+	if plan.PayoutAmount > 0 {
+		if err := r.engine.Transfer(ctx, account, *plan.PayoutAccount, plan.PayoutAmount, reference, map[string]string{
+			"cba_operation":     "term_maturity",
+			"account_id":        account.ID.String(),
+			"wallet_id":         account.WalletID,
+			"payout_account_id": plan.PayoutAccount.ID.String(),
+			"rollover_option":   plan.RolloverOption,
+		}); err != nil {
+			return fmt.Errorf("paying out to account %s: %w", plan.PayoutAccount.ID, err)
+		}
+	}
+	if _, err := r.termDepositService.CompleteMaturity(ctx, *plan); err != nil {
+		return fmt.Errorf("completing maturity: %w", err)
+	}
+	return nil
+}
+
+func NewTermMaturityRunnerModule(cfg TermMaturityRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			termDepositService services.TermDepositService,
+			interestService services.InterestService,
+			engine PostingEngine,
+		) *TermMaturityRunner {
+			return NewTermMaturityRunner(logger, termDepositService, interestService, engine, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, executor *JobExecutor, elector *LeaderElector, runner *TermMaturityRunner) {
+			registerJobScheduler(lc, NewJobScheduler(logger, executor, elector, models.JobTermMaturity, cfg.Schedule, runner.run))
+		}),
+	)
+}
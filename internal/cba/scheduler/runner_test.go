@@ -2,6 +2,9 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -44,8 +47,9 @@ func TestInterestAccrualRunnerRunAccruesActiveAccounts(t *testing.T) {
 		},
 	}
 	engine := &postingEngineStub{
-		availableBalanceFunc: func(_ context.Context, account models.Account) (int64, error) {
+		balanceAtFunc: func(_ context.Context, account models.Account, at time.Time) (int64, error) {
 			require.Equal(t, accountID, account.ID)
+			require.Equal(t, time.Date(2026, 5, 15, 23, 59, 59, 999_999_000, time.UTC), at)
 			return 125_000, nil
 		},
 	}
@@ -54,9 +58,10 @@ func TestInterestAccrualRunnerRunAccruesActiveAccounts(t *testing.T) {
 		Schedule: cron.Every(time.Minute),
 	})
 
-	err := runner.run(context.Background(), time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
+	report, err := runner.run(context.Background(), time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
 	require.NoError(t, err)
 	require.Equal(t, int64(125_000), accruedBalance)
+	require.Equal(t, 1, report.Processed)
 }
 
 func TestInterestPostingRunnerRunPostsDueInterest(t *testing.T) {
@@ -121,13 +126,86 @@ func TestInterestPostingRunnerRunPostsDueInterest(t *testing.T) {
 		Schedule: cron.Every(time.Minute),
 	})
 
-	err := runner.run(context.Background(), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))
+	_, err := runner.run(context.Background(), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))
 	require.NoError(t, err)
 	require.True(t, creditCalled)
 	require.True(t, expenseCalled)
 	require.Equal(t, "interest:"+accountID.String()+":2026-12-31", postedReference)
 }
 
+func TestInterestPostingRunnerRunWithholdsTax(t *testing.T) {
+	t.Parallel()
+
+	accountID := uuid.New()
+	accountRepo := &accountRepositoryStub{
+		listFunc: func(_ context.Context, _ repositories.AccountFilter) ([]models.Account, error) {
+			return []models.Account{{
+				ID:       accountID,
+				WalletID: "wallet-tax",
+				Currency: "EUR",
+			}}, nil
+		},
+	}
+
+	reference := "interest:" + accountID.String() + ":2026-12-31"
+	posted := false
+	interestService := &interestServiceStub{
+		isPostingDueFunc: func(context.Context, uuid.UUID, time.Time) (bool, error) {
+			return true, nil
+		},
+		previewPostingFunc: func(context.Context, uuid.UUID) (*services.InterestPostingPreview, error) {
+			return &services.InterestPostingPreview{
+				AccountID:      accountID,
+				Currency:       "EUR",
+				PostableAmount: 1_000,
+				Withholding: &services.WithholdingTax{
+					Residency: "FR",
+					Rate:      decimal.RequireFromString("30"),
+					Amount:    300,
+				},
+			}, nil
+		},
+		markPostedFunc: func(_ context.Context, _ services.InterestPostingPreview, got string) error {
+			require.Equal(t, reference, got)
+			posted = true
+			return nil
+		},
+	}
+
+	amounts := map[string]int64{}
+	engine := &postingEngineStub{
+		creditFunc: func(_ context.Context, _ models.Account, amount int64, ref string, metadata map[string]string) error {
+			require.Equal(t, reference, ref)
+			require.Equal(t, "300", metadata["withholding_tax"])
+			amounts["credit"] = amount
+			return nil
+		},
+		recordInterestExpenseFunc: func(_ context.Context, _ string, ref string, amount int64, _ map[string]string) error {
+			require.Equal(t, reference, ref)
+			amounts["expense"] = amount
+			return nil
+		},
+		recordTaxLiabilityFunc: func(_ context.Context, currency, ref string, amount int64, metadata map[string]string) error {
+			require.Equal(t, "EUR", currency)
+			require.Equal(t, reference+":tax", ref)
+			require.Equal(t, "withholding_tax", metadata["cba_operation"])
+			require.Equal(t, "FR", metadata["residency"])
+			amounts["tax"] = amount
+			return nil
+		},
+	}
+
+	runner := NewInterestPostingRunner(logging.Testing(), accountRepo, interestService, engine, InterestPostingRunnerConfig{
+		Schedule: cron.Every(time.Minute),
+	})
+
+	report, err := runner.run(context.Background(), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Processed)
+	require.True(t, posted)
+	require.Equal(t, map[string]int64{"credit": 700, "expense": 1_000, "tax": 300}, amounts)
+}
+
 func TestMaintenanceFeeRunnerRunMarksPostingAsPosted(t *testing.T) {
 	t.Parallel()
 
@@ -153,11 +231,6 @@ func TestMaintenanceFeeRunnerRunMarksPostingAsPosted(t *testing.T) {
 			}}, nil
 		},
 	}
-	feeRepo := &feePostingRepositoryStub{
-		listPendingRecoveryFunc: func(context.Context) ([]models.FeePosting, error) {
-			return []models.FeePosting{posting}, nil
-		},
-	}
 	accountService := &accountServiceStub{
 		getFunc: func(_ context.Context, id uuid.UUID) (*models.Account, error) {
 			require.Equal(t, accountID, id)
@@ -215,16 +288,148 @@ func TestMaintenanceFeeRunnerRunMarksPostingAsPosted(t *testing.T) {
 		},
 	}
 
-	runner := NewMaintenanceFeeRunner(logging.Testing(), accountRepo, feeRepo, accountService, feeService, engine, MaintenanceFeeRunnerConfig{
+	runner := NewMaintenanceFeeRunner(logging.Testing(), accountRepo, accountService, feeService, engine, MaintenanceFeeRunnerConfig{
 		Schedule: cron.Every(time.Minute),
 	})
 
-	err := runner.run(context.Background(), time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
+	_, err := runner.run(context.Background(), time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
 	require.NoError(t, err)
 	require.True(t, prepared)
 	require.Equal(t, posting.Reference, markedPosted)
 }
 
+func TestFeeRecoveryRunnerRecoversAndEscalatesFees(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC)
+	account := models.Account{
+		ID:       uuid.New(),
+		Status:   models.AccountStatusActive,
+		WalletID: "wallet-3",
+		Currency: "USD",
+	}
+	aged := models.FeePosting{
+		ID:              uuid.New(),
+		AccountID:       account.ID,
+		EventType:       "maintenance",
+		Reference:       "maintenance:" + account.ID.String() + ":2026-01-31",
+		LinkedReference: "maintenance:" + account.ID.String() + ":2026-01-31",
+		Amount:          decimal.RequireFromString("2.50"),
+		Currency:        "USD",
+		Status:          models.FeePostingStatusPendingRecovery,
+		CreatedAt:       time.Date(2026, 1, 31, 0, 15, 0, 0, time.UTC),
+	}
+	frozen := aged
+	frozen.ID = uuid.New()
+	frozen.Reference = "maintenance:" + account.ID.String() + ":2026-01-30"
+	frozen.LinkedReference = frozen.Reference
+	closure := models.FeePosting{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		EventType: models.PenaltyEarlyClosure,
+		Reference: "penalty:early_closure:" + account.ID.String(),
+		Amount:    decimal.RequireFromString("25.00"),
+		Currency:  "USD",
+		Status:    models.FeePostingStatusPendingRecovery,
+		Metadata:  map[string]any{"wallet_posted": true},
+		CreatedAt: when,
+	}
+	feeRepo := &feePostingRepositoryStub{
+		listPendingRecoveryFunc: func(context.Context) ([]models.FeePosting, error) {
+			return []models.FeePosting{aged, frozen, closure}, nil
+		},
+	}
+	accountService := &accountServiceStub{
+		getFunc: func(_ context.Context, id uuid.UUID) (*models.Account, error) {
+			require.Equal(t, account.ID, id)
+			return &account, nil
+		},
+		validateDebitFunc: func(_ context.Context, id uuid.UUID, amount, currentBalance int64, usageAt time.Time) (*models.Account, error) {
+			require.Equal(t, account.ID, id)
+			require.EqualValues(t, 100, currentBalance)
+			require.Equal(t, when, usageAt)
+			// The debits of the second fee are refused.
+			if amount != 100 {
+				return nil, fmt.Errorf("%w: account debits are frozen", services.ErrAccountValidation)
+			}
+			return &account, nil
+		},
+	}
+
+	recovery := models.FeeRecovery{Reference: aged.Reference + ":recovery:0", Date: when, Amount: 100}
+	refused := models.FeeRecovery{Reference: frozen.Reference + ":recovery:0", Date: when, Amount: 50}
+	var completed, abandoned, escalated, posted []string
+	feeService := &feeServiceStub{
+		planRecoveryFunc: func(_ context.Context, reference string, available int64, at time.Time) (*models.FeeRecovery, error) {
+			require.EqualValues(t, 100, available)
+			require.Equal(t, when, at)
+			if reference == frozen.Reference {
+				return &refused, nil
+			}
+			require.Equal(t, aged.Reference, reference)
+			return &recovery, nil
+		},
+		completeRecoveryFunc: func(_ context.Context, reference string, planned models.FeeRecovery) (*models.FeePosting, error) {
+			require.Equal(t, recovery, planned)
+			completed = append(completed, reference)
+			return &aged, nil
+		},
+		abandonRecoveryFunc: func(_ context.Context, reference string, planned models.FeeRecovery, reason string) (*models.FeePosting, error) {
+			require.Equal(t, refused, planned)
+			require.Contains(t, reason, "account debits are frozen")
+			abandoned = append(abandoned, reference)
+			return &frozen, nil
+		},
+		getFunc: func(_ context.Context, id uuid.UUID) (*models.FeePosting, error) {
+			require.Equal(t, frozen.ID, id)
+			return &frozen, nil
+		},
+		markWriteoffRequiredFunc: func(_ context.Context, reference, reason string) (*models.FeePosting, error) {
+			escalated = append(escalated, reference)
+			require.Equal(t, "outstanding for more than 90 days", reason)
+			return &frozen, nil
+		},
+		markPostedFunc: func(_ context.Context, reference string) (*models.FeePosting, error) {
+			posted = append(posted, reference)
+			return &closure, nil
+		},
+	}
+	income := map[string]int64{}
+	engine := &postingEngineStub{
+		availableBalanceFunc: func(context.Context, models.Account) (int64, error) {
+			return 100, nil
+		},
+		debitFunc: func(_ context.Context, debited models.Account, amount int64, reference string, metadata map[string]string) error {
+			require.Equal(t, account.ID, debited.ID)
+			require.EqualValues(t, 100, amount)
+			require.Equal(t, recovery.Reference, reference)
+			require.Equal(t, "fee_posting", metadata["cba_operation"])
+			return nil
+		},
+		recordFeeIncomeFunc: func(_ context.Context, currency, reference string, amount int64, _ map[string]string) error {
+			require.Equal(t, "USD", currency)
+			income[reference] = amount
+			return nil
+		},
+	}
+
+	runner := NewFeeRecoveryRunner(logging.Testing(), feeRepo, accountService, feeService, engine, FeeRecoveryRunnerConfig{
+		Schedule:          cron.Every(time.Minute),
+		WriteoffAfterDays: 90,
+	})
+
+	report, err := runner.run(context.Background(), when)
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Processed)
+	require.Equal(t, 1, report.Skipped)
+	require.Equal(t, map[string]int64{recovery.Reference: 100, closure.Reference: 2_500}, income)
+	require.Equal(t, []string{aged.Reference}, completed)
+	require.Equal(t, []string{frozen.Reference}, abandoned)
+	// The fee recovered from is not escalated in the same run.
+	require.Equal(t, []string{frozen.Reference}, escalated)
+	require.Equal(t, []string{closure.Reference}, posted)
+}
+
 func TestDormancyRunnerRunMarksInactiveAccountDormant(t *testing.T) {
 	t.Parallel()
 
@@ -269,11 +474,433 @@ func TestDormancyRunnerRunMarksInactiveAccountDormant(t *testing.T) {
 		Schedule: cron.Every(time.Minute),
 	})
 
-	err := runner.run(context.Background(), time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
+	_, err := runner.run(context.Background(), time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
 	require.NoError(t, err)
 	require.True(t, dormantCalled)
 }
 
+func TestPenaltyFeeRunnerChargesMinimumBalanceAndDormancy(t *testing.T) {
+	t.Parallel()
+
+	activeID := uuid.New()
+	dormantID := uuid.New()
+	productID := uuid.New()
+	accountRepo := &accountRepositoryStub{
+		listFunc: func(_ context.Context, filter repositories.AccountFilter) ([]models.Account, error) {
+			require.NotNil(t, filter.Status)
+			switch *filter.Status {
+			case models.AccountStatusActive:
+				return []models.Account{{ID: activeID, ProductID: productID, Status: models.AccountStatusActive, WalletID: "wallet-active", Currency: "USD"}}, nil
+			case models.AccountStatusDormant:
+				return []models.Account{{ID: dormantID, ProductID: productID, Status: models.AccountStatusDormant, WalletID: "wallet-dormant", Currency: "USD"}}, nil
+			}
+			return nil, nil
+		},
+	}
+	productRepo := &productRepositoryStub{
+		getFunc: func(context.Context, uuid.UUID) (*models.Product, error) {
+			return &models.Product{
+				ID: productID,
+				FeeSchedule: &models.FeeSchedule{
+					PenaltyFees: map[string]models.PenaltyFee{
+						models.PenaltyMinimumBalance: {Type: "flat", Value: "5.00", Currency: "USD"},
+						models.PenaltyDormancy:       {Type: "flat", Value: "20.00", Currency: "USD"},
+					},
+				},
+			}, nil
+		},
+	}
+
+	postings := map[uuid.UUID]*models.FeePosting{}
+	feeService := &feeServiceStub{
+		preparePenaltyFeeFunc: func(_ context.Context, id uuid.UUID, trigger services.PenaltyTrigger) (*models.FeePosting, error) {
+			require.Equal(t, time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), trigger.At)
+			amount := "5.00"
+			if id == dormantID {
+				require.Equal(t, models.PenaltyDormancy, trigger.Penalty)
+				amount = "20.00"
+			} else {
+				require.Equal(t, models.PenaltyMinimumBalance, trigger.Penalty)
+			}
+			require.EqualValues(t, 5_000, trigger.Amount)
+			postings[id] = &models.FeePosting{
+				AccountID: id,
+				EventType: trigger.Penalty,
+				Reference: "penalty:" + trigger.Penalty + ":" + id.String() + ":2026-05",
+				Amount:    decimal.RequireFromString(amount),
+				Currency:  "USD",
+				Status:    models.FeePostingStatusPendingRecovery,
+			}
+			return postings[id], nil
+		},
+		markPostedFunc: func(context.Context, string) (*models.FeePosting, error) {
+			return nil, nil
+		},
+		markPendingRecoveryFunc: func(context.Context, string, map[string]any) (*models.FeePosting, error) {
+			t.Fatalf("did not expect pending recovery")
+			return nil, nil
+		},
+	}
+	debited := map[string]int64{}
+	var income int64
+	engine := &postingEngineStub{
+		availableBalanceFunc: func(context.Context, models.Account) (int64, error) {
+			return 5_000, nil
+		},
+		debitFunc: func(_ context.Context, account models.Account, amount int64, reference string, _ map[string]string) error {
+			require.Equal(t, postings[account.ID].Reference, reference)
+			debited[account.WalletID] = amount
+			return nil
+		},
+		recordFeeIncomeFunc: func(_ context.Context, _, _ string, amount int64, _ map[string]string) error {
+			income += amount
+			return nil
+		},
+	}
+
+	runner := NewPenaltyFeeRunner(logging.Testing(), accountRepo, productRepo, feeService, engine, PenaltyFeeRunnerConfig{
+		Schedule: cron.Every(time.Minute),
+	})
+
+	report, err := runner.run(context.Background(), time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Processed)
+	require.Equal(t, map[string]int64{"wallet-active": 500, "wallet-dormant": 2_000}, debited)
+	require.EqualValues(t, 2_500, income)
+}
+
+func TestTermMaturityRunnerPostsInterestAndPaysOut(t *testing.T) {
+	t.Parallel()
+
+	accountID := uuid.New()
+	maturity := time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC)
+	account := models.Account{
+		ID:           accountID,
+		Status:       models.AccountStatusActive,
+		WalletID:     "wallet-fd",
+		Currency:     "USD",
+		MaturityDate: &maturity,
+	}
+	payout := models.Account{ID: uuid.New(), WalletID: "wallet-current", Currency: "USD"}
+
+	var completed bool
+	termDepositService := &termDepositServiceStub{
+		listMaturingFunc: func(_ context.Context, asOf time.Time) ([]models.Account, error) {
+			require.Equal(t, maturity, asOf)
+			return []models.Account{account}, nil
+		},
+		planMaturityFunc: func(_ context.Context, id uuid.UUID, balance int64, _ time.Time) (*services.MaturityPlan, error) {
+			require.Equal(t, accountID, id)
+			require.Equal(t, int64(101_500), balance)
+			return &services.MaturityPlan{
+				AccountID:      accountID,
+				MaturityDate:   maturity,
+				RolloverOption: models.RolloverPrincipal,
+				Balance:        balance,
+				Interest:       1_500,
+				PayoutAccount:  &payout,
+				PayoutAmount:   1_500,
+			}, nil
+		},
+		completeMaturityFunc: func(_ context.Context, plan services.MaturityPlan) (*models.Account, error) {
+			completed = true
+			require.Equal(t, int64(1_500), plan.PayoutAmount)
+			return &account, nil
+		},
+	}
+	interestService := &interestServiceStub{
+		previewPostingFunc: func(context.Context, uuid.UUID) (*services.InterestPostingPreview, error) {
+			return &services.InterestPostingPreview{AccountID: accountID, Currency: "USD", PostableAmount: 500}, nil
+		},
+	}
+
+	var credited int64
+	engine := &postingEngineStub{
+		creditFunc: func(_ context.Context, _ models.Account, amount int64, reference string, _ map[string]string) error {
+			credited = amount
+			require.Equal(t, "maturity:"+accountID.String()+":2026-05-15:interest", reference)
+			return nil
+		},
+		availableBalanceFunc: func(context.Context, models.Account) (int64, error) {
+			return 101_000 + credited, nil
+		},
+		transferFunc: func(_ context.Context, from, to models.Account, amount int64, reference string, metadata map[string]string) error {
+			require.Equal(t, accountID, from.ID)
+			require.Equal(t, payout.ID, to.ID)
+			require.Equal(t, int64(1_500), amount)
+			require.Equal(t, "maturity:"+accountID.String()+":2026-05-15", reference)
+			require.Equal(t, "term_maturity", metadata["cba_operation"])
+			return nil
+		},
+	}
+
+	runner := NewTermMaturityRunner(logging.Testing(), termDepositService, interestService, engine, TermMaturityRunnerConfig{
+		Schedule: cron.Every(time.Minute),
+	})
+
+	report, err := runner.run(context.Background(), maturity)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Processed)
+	require.Equal(t, int64(500), credited)
+	require.True(t, completed)
+}
+
+func TestLoanServicingRunnerDisbursesApprovedLoans(t *testing.T) {
+	t.Parallel()
+
+	loan := models.Account{
+		ID:       uuid.New(),
+		Status:   models.AccountStatusActive,
+		WalletID: "wallet-loan",
+		Currency: "USD",
+		Loan:     &models.AccountLoan{Principal: 120_000, Status: models.LoanStatusApproved},
+	}
+	settlement := models.Account{ID: uuid.New(), WalletID: "wallet-current", Currency: "USD"}
+	when := time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC)
+
+	var disbursed bool
+	loanService := &loanServiceStub{
+		listServicedFunc: func(context.Context) ([]models.Account, error) {
+			return []models.Account{loan}, nil
+		},
+		settlementAccountFunc: func(context.Context, uuid.UUID) (*models.Account, error) {
+			return &settlement, nil
+		},
+		completeDisbursementFunc: func(_ context.Context, id uuid.UUID, at time.Time) (*models.Account, error) {
+			require.True(t, disbursed)
+			require.Equal(t, loan.ID, id)
+			require.Equal(t, when, at)
+			return &loan, nil
+		},
+	}
+	engine := &postingEngineStub{
+		disburseFunc: func(_ context.Context, from, to models.Account, amount int64, reference string, metadata map[string]string) error {
+			disbursed = true
+			require.Equal(t, loan.ID, from.ID)
+			require.Equal(t, settlement.ID, to.ID)
+			require.Equal(t, int64(120_000), amount)
+			require.Equal(t, "loan:"+loan.ID.String()+":disbursement", reference)
+			require.Equal(t, "loan_disbursement", metadata["cba_operation"])
+			return nil
+		},
+	}
+
+	runner := NewLoanServicingRunner(logging.Testing(), loanService, engine, LoanServicingRunnerConfig{
+		Schedule: cron.Every(time.Minute),
+	})
+
+	report, err := runner.run(context.Background(), when)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Processed)
+	require.True(t, disbursed)
+}
+
+func TestLoanServicingRunnerCollectsRepayment(t *testing.T) {
+	t.Parallel()
+
+	loan := models.Account{
+		ID:       uuid.New(),
+		Status:   models.AccountStatusActive,
+		WalletID: "wallet-loan",
+		Currency: "USD",
+		Loan:     &models.AccountLoan{Principal: 120_000, Status: models.LoanStatusActive},
+	}
+	settlement := models.Account{ID: uuid.New(), WalletID: "wallet-current", Currency: "USD"}
+	when := time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)
+	reference := "loan:" + loan.ID.String() + ":2026-06-15:repayment:0"
+
+	var accrued, completed bool
+	loanService := &loanServiceStub{
+		listServicedFunc: func(context.Context) ([]models.Account, error) {
+			return []models.Account{loan}, nil
+		},
+		settlementAccountFunc: func(context.Context, uuid.UUID) (*models.Account, error) {
+			return &settlement, nil
+		},
+		accrueFunc: func(context.Context, uuid.UUID, time.Time) (*models.Account, error) {
+			accrued = true
+			return &loan, nil
+		},
+		planRepaymentFunc: func(_ context.Context, _ uuid.UUID, available int64, _ time.Time) (*models.LoanRepayment, error) {
+			require.Equal(t, int64(50_000), available)
+			return &models.LoanRepayment{Reference: reference, Date: when, Penalty: 50, Interest: 1_000, Principal: 10_000}, nil
+		},
+		completeRepaymentFunc: func(_ context.Context, _ uuid.UUID, repayment models.LoanRepayment) (*models.Account, error) {
+			completed = true
+			require.Equal(t, reference, repayment.Reference)
+			return &loan, nil
+		},
+	}
+
+	incomes := map[string]int64{}
+	var principal, charges int64
+	engine := &postingEngineStub{
+		availableBalanceFunc: func(_ context.Context, account models.Account) (int64, error) {
+			require.Equal(t, settlement.ID, account.ID)
+			return 50_000, nil
+		},
+		transferFunc: func(_ context.Context, from, to models.Account, amount int64, ref string, _ map[string]string) error {
+			require.Equal(t, settlement.ID, from.ID)
+			require.Equal(t, loan.ID, to.ID)
+			require.Equal(t, reference+":principal", ref)
+			principal = amount
+			return nil
+		},
+		debitFunc: func(_ context.Context, account models.Account, amount int64, ref string, _ map[string]string) error {
+			require.Equal(t, settlement.ID, account.ID)
+			require.Equal(t, reference+":charges", ref)
+			charges = amount
+			return nil
+		},
+		recordInterestIncomeFunc: func(_ context.Context, _ string, ref string, amount int64, _ map[string]string) error {
+			incomes[ref] = amount
+			return nil
+		},
+		recordFeeIncomeFunc: func(_ context.Context, _ string, ref string, amount int64, _ map[string]string) error {
+			incomes[ref] = amount
+			return nil
+		},
+	}
+
+	runner := NewLoanServicingRunner(logging.Testing(), loanService, engine, LoanServicingRunnerConfig{
+		Schedule: cron.Every(time.Minute),
+	})
+
+	report, err := runner.run(context.Background(), when)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.Processed)
+	require.True(t, accrued)
+	require.True(t, completed)
+	require.Equal(t, int64(10_000), principal)
+	require.Equal(t, int64(1_050), charges)
+	require.Equal(t, map[string]int64{
+		reference + ":interest": 1_000,
+		reference + ":penalty":  50,
+	}, incomes)
+}
+
+func TestKYCExpiryRunnerExpiresRestrictsAndNotifies(t *testing.T) {
+	t.Parallel()
+
+	when := time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC)
+	clientID := uuid.New()
+	expiredID := uuid.New()
+	expiringID := uuid.New()
+	downgradedAccountID := uuid.New()
+	renewedAccountID := uuid.New()
+	expiringAt := when.AddDate(0, 0, 10)
+
+	kycRepo := &kycRepositoryStub{
+		listFunc: func(_ context.Context, filter repositories.KYCFilter) ([]models.KYCRecord, error) {
+			require.NotNil(t, filter.Status)
+			require.Equal(t, models.KYCStatusVerified, *filter.Status)
+			if !filter.Unnotified {
+				require.Equal(t, when, *filter.ExpiresBefore)
+				return []models.KYCRecord{{ID: expiredID, ClientID: clientID, Level: 2}}, nil
+			}
+			require.Equal(t, when.AddDate(0, 0, 30), *filter.ExpiresBefore)
+			return []models.KYCRecord{{ID: expiringID, ClientID: clientID, Level: 1, ExpiresAt: &expiringAt}}, nil
+		},
+	}
+	accountRepo := &accountRepositoryStub{
+		listFunc: func(_ context.Context, filter repositories.AccountFilter) ([]models.Account, error) {
+			if filter.KYCRestricted {
+				return []models.Account{{ID: renewedAccountID, KYCRestricted: true, Status: models.AccountStatusActive}}, nil
+			}
+			require.Equal(t, clientID, *filter.ClientID)
+			return []models.Account{
+				{ID: downgradedAccountID, Status: models.AccountStatusActive},
+				{ID: uuid.New(), Status: models.AccountStatusClosed},
+			}, nil
+		},
+	}
+	var expired, notified []uuid.UUID
+	kycService := &kycServiceStub{
+		expireFunc: func(_ context.Context, _ uuid.UUID, id uuid.UUID) (*models.KYCRecord, error) {
+			expired = append(expired, id)
+			return &models.KYCRecord{ID: id, Status: models.KYCStatusExpired}, nil
+		},
+		markExpiryNotifiedFunc: func(_ context.Context, _ uuid.UUID, id uuid.UUID) (*models.KYCRecord, error) {
+			notified = append(notified, id)
+			return &models.KYCRecord{ID: id}, nil
+		},
+	}
+	restrictions := map[uuid.UUID]bool{}
+	accountService := &accountServiceStub{
+		applyKYCRestrictionFunc: func(_ context.Context, id uuid.UUID) (*models.Account, error) {
+			restricted := id == downgradedAccountID
+			restrictions[id] = restricted
+			return &models.Account{ID: id, KYCRestricted: restricted}, nil
+		},
+	}
+	notifier := &kycExpiryNotifierStub{}
+
+	runner := NewKYCExpiryRunner(logging.Testing(), kycRepo, accountRepo, kycService, accountService, notifier, KYCExpiryRunnerConfig{
+		Schedule:   cron.Every(time.Minute),
+		NoticeDays: 30,
+	})
+
+	report, err := runner.run(context.Background(), when)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{expiredID}, expired)
+	require.Equal(t, map[uuid.UUID]bool{
+		downgradedAccountID: true,
+		renewedAccountID:    false,
+	}, restrictions)
+	require.Equal(t, []uuid.UUID{expiringID}, notified)
+	require.Len(t, notifier.notices, 1)
+	require.Equal(t, expiringAt, notifier.notices[0].ExpiresAt)
+	require.Equal(t, 4, report.Processed)
+	require.Zero(t, report.Failed)
+}
+
+func TestScreeningRunnerScreensClientsAgainstNewWatchlists(t *testing.T) {
+	t.Parallel()
+
+	pendingID := uuid.New()
+	failingID := uuid.New()
+	clientRepo := &clientRepositoryStub{
+		listFunc: func(_ context.Context, filter repositories.ClientFilter) ([]models.Client, error) {
+			require.NotNil(t, filter.NotScreenedWith)
+			require.Equal(t, "v2", *filter.NotScreenedWith)
+			return []models.Client{
+				{ID: pendingID, Status: models.ClientStatusPending},
+				{ID: failingID, Status: models.ClientStatusActive},
+				{ID: uuid.New(), Status: models.ClientStatusClosed},
+			}, nil
+		},
+	}
+	var screened []uuid.UUID
+	screeningService := &screeningServiceStub{
+		version: "v2",
+		screenClientFunc: func(_ context.Context, client *models.Client) error {
+			screened = append(screened, client.ID)
+			if client.ID == failingID {
+				return errors.New("watchlist unavailable")
+			}
+			return nil
+		},
+	}
+
+	runner := NewScreeningRunner(logging.Testing(), clientRepo, screeningService, ScreeningRunnerConfig{
+		Schedule: cron.Every(time.Minute),
+	})
+	report, err := runner.run(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{pendingID, failingID}, screened)
+	require.Equal(t, 1, report.Processed)
+	require.Equal(t, 1, report.Skipped)
+	require.Equal(t, 1, report.Failed)
+
+	// Nothing is screened while no watchlist is configured.
+	screened = nil
+	screeningService.version = ""
+	report, err = runner.run(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Empty(t, screened)
+	require.Zero(t, report.Processed)
+}
+
 type accountRepositoryStub struct {
 	listFunc func(context.Context, repositories.AccountFilter) ([]models.Account, error)
 }
@@ -321,9 +948,15 @@ type feePostingRepositoryStub struct {
 
 func (s *feePostingRepositoryStub) Create(context.Context, *models.FeePosting) error { return nil }
 func (s *feePostingRepositoryStub) Update(context.Context, *models.FeePosting) error { return nil }
+func (s *feePostingRepositoryStub) Get(context.Context, uuid.UUID) (*models.FeePosting, error) {
+	return nil, nil
+}
 func (s *feePostingRepositoryStub) GetByReference(context.Context, string) (*models.FeePosting, error) {
 	return nil, nil
 }
+func (s *feePostingRepositoryStub) List(context.Context, repositories.FeePostingFilter) ([]models.FeePosting, error) {
+	return nil, nil
+}
 func (s *feePostingRepositoryStub) ListByAccount(context.Context, uuid.UUID) ([]models.FeePosting, error) {
 	return nil, nil
 }
@@ -335,9 +968,10 @@ func (s *feePostingRepositoryStub) ListPendingRecovery(ctx context.Context) ([]m
 }
 
 type accountServiceStub struct {
-	getFunc           func(context.Context, uuid.UUID) (*models.Account, error)
-	dormantFunc       func(context.Context, uuid.UUID) (*models.Account, error)
-	validateDebitFunc func(context.Context, uuid.UUID, int64, int64, time.Time) (*models.Account, error)
+	getFunc                 func(context.Context, uuid.UUID) (*models.Account, error)
+	dormantFunc             func(context.Context, uuid.UUID) (*models.Account, error)
+	validateDebitFunc       func(context.Context, uuid.UUID, int64, int64, time.Time) (*models.Account, error)
+	applyKYCRestrictionFunc func(context.Context, uuid.UUID) (*models.Account, error)
 }
 
 func (s *accountServiceStub) Open(context.Context, services.OpenAccountInput) (*models.Account, error) {
@@ -352,6 +986,9 @@ func (s *accountServiceStub) Get(ctx context.Context, id uuid.UUID) (*models.Acc
 	}
 	return nil, nil
 }
+func (s *accountServiceStub) GetByWalletID(context.Context, string) (*models.Account, error) {
+	return nil, services.ErrAccountNotFound
+}
 func (s *accountServiceStub) Activate(context.Context, uuid.UUID) (*models.Account, error) {
 	return nil, nil
 }
@@ -397,6 +1034,15 @@ func (s *accountServiceStub) RecordDebitUsage(context.Context, uuid.UUID, int64,
 func (s *accountServiceStub) TouchActivity(context.Context, uuid.UUID, time.Time) (*models.Account, error) {
 	return nil, nil
 }
+func (s *accountServiceStub) Overdraft(context.Context, uuid.UUID) (*services.Overdraft, error) {
+	return nil, nil
+}
+func (s *accountServiceStub) ApplyKYCRestriction(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	if s.applyKYCRestrictionFunc != nil {
+		return s.applyKYCRestrictionFunc(ctx, id)
+	}
+	return nil, nil
+}
 
 type interestServiceStub struct {
 	accrueFunc         func(context.Context, uuid.UUID, int64, time.Time) (*models.InterestAccrual, error)
@@ -430,10 +1076,62 @@ func (s *interestServiceStub) MarkPosted(ctx context.Context, preview services.I
 	return nil
 }
 
+type termDepositServiceStub struct {
+	services.TermDepositService
+	listMaturingFunc     func(context.Context, time.Time) ([]models.Account, error)
+	planMaturityFunc     func(context.Context, uuid.UUID, int64, time.Time) (*services.MaturityPlan, error)
+	completeMaturityFunc func(context.Context, services.MaturityPlan) (*models.Account, error)
+}
+
+func (s *termDepositServiceStub) ListMaturing(ctx context.Context, asOf time.Time) ([]models.Account, error) {
+	return s.listMaturingFunc(ctx, asOf)
+}
+func (s *termDepositServiceStub) PlanMaturity(ctx context.Context, id uuid.UUID, balance int64, when time.Time) (*services.MaturityPlan, error) {
+	return s.planMaturityFunc(ctx, id, balance, when)
+}
+func (s *termDepositServiceStub) CompleteMaturity(ctx context.Context, plan services.MaturityPlan) (*models.Account, error) {
+	return s.completeMaturityFunc(ctx, plan)
+}
+
+type loanServiceStub struct {
+	services.LoanService
+	listServicedFunc         func(context.Context) ([]models.Account, error)
+	settlementAccountFunc    func(context.Context, uuid.UUID) (*models.Account, error)
+	completeDisbursementFunc func(context.Context, uuid.UUID, time.Time) (*models.Account, error)
+	accrueFunc               func(context.Context, uuid.UUID, time.Time) (*models.Account, error)
+	planRepaymentFunc        func(context.Context, uuid.UUID, int64, time.Time) (*models.LoanRepayment, error)
+	completeRepaymentFunc    func(context.Context, uuid.UUID, models.LoanRepayment) (*models.Account, error)
+}
+
+func (s *loanServiceStub) ListServiced(ctx context.Context) ([]models.Account, error) {
+	return s.listServicedFunc(ctx)
+}
+func (s *loanServiceStub) SettlementAccount(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	return s.settlementAccountFunc(ctx, id)
+}
+func (s *loanServiceStub) CompleteDisbursement(ctx context.Context, id uuid.UUID, when time.Time) (*models.Account, error) {
+	return s.completeDisbursementFunc(ctx, id, when)
+}
+func (s *loanServiceStub) Accrue(ctx context.Context, id uuid.UUID, when time.Time) (*models.Account, error) {
+	return s.accrueFunc(ctx, id, when)
+}
+func (s *loanServiceStub) PlanRepayment(ctx context.Context, id uuid.UUID, available int64, when time.Time) (*models.LoanRepayment, error) {
+	return s.planRepaymentFunc(ctx, id, available, when)
+}
+func (s *loanServiceStub) CompleteRepayment(ctx context.Context, id uuid.UUID, repayment models.LoanRepayment) (*models.Account, error) {
+	return s.completeRepaymentFunc(ctx, id, repayment)
+}
+
 type feeServiceStub struct {
 	prepareMaintenanceFeeFunc func(context.Context, uuid.UUID, time.Time) (*models.FeePosting, error)
+	preparePenaltyFeeFunc     func(context.Context, uuid.UUID, services.PenaltyTrigger) (*models.FeePosting, error)
 	markPostedFunc            func(context.Context, string) (*models.FeePosting, error)
 	markPendingRecoveryFunc   func(context.Context, string, map[string]any) (*models.FeePosting, error)
+	getFunc                   func(context.Context, uuid.UUID) (*models.FeePosting, error)
+	planRecoveryFunc          func(context.Context, string, int64, time.Time) (*models.FeeRecovery, error)
+	completeRecoveryFunc      func(context.Context, string, models.FeeRecovery) (*models.FeePosting, error)
+	abandonRecoveryFunc       func(context.Context, string, models.FeeRecovery, string) (*models.FeePosting, error)
+	markWriteoffRequiredFunc  func(context.Context, string, string) (*models.FeePosting, error)
 }
 
 func (s *feeServiceStub) PrepareTransactionFees(context.Context, uuid.UUID, string, int64, string) ([]models.FeePosting, error) {
@@ -445,6 +1143,12 @@ func (s *feeServiceStub) PrepareMaintenanceFee(ctx context.Context, id uuid.UUID
 	}
 	return nil, nil
 }
+func (s *feeServiceStub) PreparePenaltyFee(ctx context.Context, id uuid.UUID, trigger services.PenaltyTrigger) (*models.FeePosting, error) {
+	if s.preparePenaltyFeeFunc != nil {
+		return s.preparePenaltyFeeFunc(ctx, id, trigger)
+	}
+	return nil, services.ErrFeeNotApplicable
+}
 func (s *feeServiceStub) MarkPosted(ctx context.Context, reference string) (*models.FeePosting, error) {
 	if s.markPostedFunc != nil {
 		return s.markPostedFunc(ctx, reference)
@@ -457,13 +1161,57 @@ func (s *feeServiceStub) MarkPendingRecovery(ctx context.Context, reference stri
 	}
 	return nil, nil
 }
+func (s *feeServiceStub) Get(ctx context.Context, id uuid.UUID) (*models.FeePosting, error) {
+	if s.getFunc != nil {
+		return s.getFunc(ctx, id)
+	}
+	return nil, services.ErrFeePostingNotFound
+}
+func (s *feeServiceStub) List(context.Context, repositories.FeePostingFilter) ([]models.FeePosting, error) {
+	return nil, nil
+}
+func (s *feeServiceStub) PlanRecovery(ctx context.Context, reference string, available int64, when time.Time) (*models.FeeRecovery, error) {
+	if s.planRecoveryFunc != nil {
+		return s.planRecoveryFunc(ctx, reference, available, when)
+	}
+	return nil, services.ErrFeeNoRecovery
+}
+func (s *feeServiceStub) CompleteRecovery(ctx context.Context, reference string, recovery models.FeeRecovery) (*models.FeePosting, error) {
+	if s.completeRecoveryFunc != nil {
+		return s.completeRecoveryFunc(ctx, reference, recovery)
+	}
+	return nil, nil
+}
+func (s *feeServiceStub) AbandonRecovery(ctx context.Context, reference string, recovery models.FeeRecovery, reason string) (*models.FeePosting, error) {
+	if s.abandonRecoveryFunc != nil {
+		return s.abandonRecoveryFunc(ctx, reference, recovery, reason)
+	}
+	return nil, nil
+}
+func (s *feeServiceStub) MarkWriteoffRequired(ctx context.Context, reference, reason string) (*models.FeePosting, error) {
+	if s.markWriteoffRequiredFunc != nil {
+		return s.markWriteoffRequiredFunc(ctx, reference, reason)
+	}
+	return nil, nil
+}
+func (s *feeServiceStub) Waive(context.Context, uuid.UUID, services.ResolveFeeInput) (*models.FeePosting, error) {
+	return nil, nil
+}
+func (s *feeServiceStub) WriteOff(context.Context, uuid.UUID, services.ResolveFeeInput) (*models.FeePosting, error) {
+	return nil, nil
+}
 
 type postingEngineStub struct {
 	availableBalanceFunc      func(context.Context, models.Account) (int64, error)
+	balanceAtFunc             func(context.Context, models.Account, time.Time) (int64, error)
 	creditFunc                func(context.Context, models.Account, int64, string, map[string]string) error
 	debitFunc                 func(context.Context, models.Account, int64, string, map[string]string) error
+	transferFunc              func(context.Context, models.Account, models.Account, int64, string, map[string]string) error
+	disburseFunc              func(context.Context, models.Account, models.Account, int64, string, map[string]string) error
 	recordFeeIncomeFunc       func(context.Context, string, string, int64, map[string]string) error
 	recordInterestExpenseFunc func(context.Context, string, string, int64, map[string]string) error
+	recordInterestIncomeFunc  func(context.Context, string, string, int64, map[string]string) error
+	recordTaxLiabilityFunc    func(context.Context, string, string, int64, map[string]string) error
 }
 
 func (s *postingEngineStub) AvailableBalance(ctx context.Context, account models.Account) (int64, error) {
@@ -472,6 +1220,12 @@ func (s *postingEngineStub) AvailableBalance(ctx context.Context, account models
 	}
 	return 0, nil
 }
+func (s *postingEngineStub) BalanceAt(ctx context.Context, account models.Account, at time.Time) (int64, error) {
+	if s.balanceAtFunc != nil {
+		return s.balanceAtFunc(ctx, account, at)
+	}
+	return 0, nil
+}
 func (s *postingEngineStub) Credit(ctx context.Context, account models.Account, amount int64, reference string, metadata map[string]string) error {
 	if s.creditFunc != nil {
 		return s.creditFunc(ctx, account, amount, reference, metadata)
@@ -484,6 +1238,18 @@ func (s *postingEngineStub) Debit(ctx context.Context, account models.Account, a
 	}
 	return nil
 }
+func (s *postingEngineStub) Transfer(ctx context.Context, from, to models.Account, amount int64, reference string, metadata map[string]string) error {
+	if s.transferFunc != nil {
+		return s.transferFunc(ctx, from, to, amount, reference, metadata)
+	}
+	return nil
+}
+func (s *postingEngineStub) Disburse(ctx context.Context, loan, to models.Account, amount int64, reference string, metadata map[string]string) error {
+	if s.disburseFunc != nil {
+		return s.disburseFunc(ctx, loan, to, amount, reference, metadata)
+	}
+	return nil
+}
 func (s *postingEngineStub) RecordFeeIncome(ctx context.Context, currency, reference string, amount int64, metadata map[string]string) error {
 	if s.recordFeeIncomeFunc != nil {
 		return s.recordFeeIncomeFunc(ctx, currency, reference, amount, metadata)
@@ -496,3 +1262,130 @@ func (s *postingEngineStub) RecordInterestExpense(ctx context.Context, currency,
 	}
 	return nil
 }
+func (s *postingEngineStub) RecordInterestIncome(ctx context.Context, currency, reference string, amount int64, metadata map[string]string) error {
+	if s.recordInterestIncomeFunc != nil {
+		return s.recordInterestIncomeFunc(ctx, currency, reference, amount, metadata)
+	}
+	return nil
+}
+
+func (s *postingEngineStub) RecordTaxLiability(ctx context.Context, currency, reference string, amount int64, metadata map[string]string) error {
+	if s.recordTaxLiabilityFunc != nil {
+		return s.recordTaxLiabilityFunc(ctx, currency, reference, amount, metadata)
+	}
+	return nil
+}
+
+type kycRepositoryStub struct {
+	listFunc func(context.Context, repositories.KYCFilter) ([]models.KYCRecord, error)
+}
+
+func (s *kycRepositoryStub) Create(context.Context, *models.KYCRecord) error { return nil }
+func (s *kycRepositoryStub) Update(context.Context, *models.KYCRecord) error { return nil }
+func (s *kycRepositoryStub) Get(context.Context, uuid.UUID) (*models.KYCRecord, error) {
+	return nil, nil
+}
+func (s *kycRepositoryStub) GetByProviderReference(context.Context, string, string) (*models.KYCRecord, error) {
+	return nil, nil
+}
+func (s *kycRepositoryStub) ListByClient(context.Context, uuid.UUID) ([]models.KYCRecord, error) {
+	return nil, nil
+}
+func (s *kycRepositoryStub) List(ctx context.Context, filter repositories.KYCFilter) ([]models.KYCRecord, error) {
+	if s.listFunc != nil {
+		return s.listFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
+type kycServiceStub struct {
+	expireFunc             func(context.Context, uuid.UUID, uuid.UUID) (*models.KYCRecord, error)
+	markExpiryNotifiedFunc func(context.Context, uuid.UUID, uuid.UUID) (*models.KYCRecord, error)
+}
+
+func (s *kycServiceStub) Submit(context.Context, uuid.UUID, services.SubmitKYCInput) (*models.KYCRecord, error) {
+	return nil, nil
+}
+func (s *kycServiceStub) Verify(context.Context, uuid.UUID, uuid.UUID, services.VerifyKYCInput) (*models.KYCRecord, error) {
+	return nil, nil
+}
+func (s *kycServiceStub) Reject(context.Context, uuid.UUID, uuid.UUID, services.RejectKYCInput) (*models.KYCRecord, error) {
+	return nil, nil
+}
+func (s *kycServiceStub) Refresh(context.Context, uuid.UUID, uuid.UUID) (*models.KYCRecord, error) {
+	return nil, nil
+}
+func (s *kycServiceStub) HandleCallback(context.Context, http.Header, []byte) (*models.KYCRecord, error) {
+	return nil, nil
+}
+func (s *kycServiceStub) Expire(ctx context.Context, clientID, kycID uuid.UUID) (*models.KYCRecord, error) {
+	if s.expireFunc != nil {
+		return s.expireFunc(ctx, clientID, kycID)
+	}
+	return nil, nil
+}
+func (s *kycServiceStub) MarkExpiryNotified(ctx context.Context, clientID, kycID uuid.UUID) (*models.KYCRecord, error) {
+	if s.markExpiryNotifiedFunc != nil {
+		return s.markExpiryNotifiedFunc(ctx, clientID, kycID)
+	}
+	return nil, nil
+}
+func (s *kycServiceStub) History(context.Context, uuid.UUID) ([]models.KYCRecord, error) {
+	return nil, nil
+}
+
+type kycExpiryNotifierStub struct {
+	notices []KYCExpiryNotice
+}
+
+func (s *kycExpiryNotifierStub) NotifyKYCExpiry(_ context.Context, notice KYCExpiryNotice) error {
+	s.notices = append(s.notices, notice)
+	return nil
+}
+
+type clientRepositoryStub struct {
+	listFunc func(context.Context, repositories.ClientFilter) ([]models.Client, error)
+}
+
+func (s *clientRepositoryStub) Create(context.Context, *models.Client) error { return nil }
+func (s *clientRepositoryStub) Update(context.Context, *models.Client) error { return nil }
+func (s *clientRepositoryStub) Get(context.Context, uuid.UUID) (*models.Client, error) {
+	return nil, nil
+}
+func (s *clientRepositoryStub) GetByNumber(context.Context, string) (*models.Client, error) {
+	return nil, nil
+}
+func (s *clientRepositoryStub) List(ctx context.Context, filter repositories.ClientFilter) ([]models.Client, error) {
+	if s.listFunc != nil {
+		return s.listFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
+type screeningServiceStub struct {
+	version          string
+	screenClientFunc func(context.Context, *models.Client) error
+}
+
+func (s *screeningServiceStub) ScreenClient(ctx context.Context, client *models.Client) error {
+	if s.screenClientFunc != nil {
+		return s.screenClientFunc(ctx, client)
+	}
+	return nil
+}
+func (s *screeningServiceStub) ScreenTransaction(context.Context, services.ScreenTransactionInput) error {
+	return nil
+}
+func (s *screeningServiceStub) WatchlistVersion() (string, error) { return s.version, nil }
+func (s *screeningServiceStub) ListCases(context.Context, repositories.ScreeningCaseFilter) ([]models.ScreeningCase, error) {
+	return nil, nil
+}
+func (s *screeningServiceStub) GetCase(context.Context, uuid.UUID) (*models.ScreeningCase, error) {
+	return nil, nil
+}
+func (s *screeningServiceStub) ClearCase(context.Context, uuid.UUID, services.ReviewScreeningCaseInput) (*models.ScreeningCase, error) {
+	return nil, nil
+}
+func (s *screeningServiceStub) ConfirmCase(context.Context, uuid.UUID, services.ReviewScreeningCaseInput) (*models.ScreeningCase, error) {
+	return nil, nil
+}
@@ -23,12 +23,14 @@ var (
 	ErrAccountNotFound               = errors.New("account not found")
 	ErrAccountAlreadyExists          = errors.New("account already exists")
 	ErrAccountInvalidStateTransition = errors.New("account state transition is invalid")
+	ErrAccountInsufficientFunds      = errors.New("insufficient funds")
 )
 
 type AccountService interface {
 	Open(context.Context, OpenAccountInput) (*models.Account, error)
 	List(context.Context, repositories.AccountFilter) ([]models.Account, error)
 	Get(context.Context, uuid.UUID) (*models.Account, error)
+	GetByWalletID(context.Context, string) (*models.Account, error)
 	Activate(context.Context, uuid.UUID) (*models.Account, error)
 	Suspend(context.Context, uuid.UUID) (*models.Account, error)
 	Freeze(context.Context, uuid.UUID) (*models.Account, error)
@@ -42,13 +44,30 @@ type AccountService interface {
 	RecordCreditUsage(context.Context, uuid.UUID, int64, string, time.Time) error
 	RecordDebitUsage(context.Context, uuid.UUID, int64, string, time.Time) error
 	TouchActivity(context.Context, uuid.UUID, time.Time) (*models.Account, error)
+	Overdraft(context.Context, uuid.UUID) (*Overdraft, error)
+	ApplyKYCRestriction(context.Context, uuid.UUID) (*models.Account, error)
 }
 
+// OpenAccountInput opens an account. RolloverOption and PayoutAccountID only
+// apply to fixed-term products; the product default rollover is used when no
+// option is given. Principal and SettlementAccountID are required by loan
+// products, which take no opening deposit.
 type OpenAccountInput struct {
-	ClientID       uuid.UUID      `json:"client_id"`
-	ProductID      uuid.UUID      `json:"product_id"`
-	OpeningDeposit json.Number    `json:"opening_deposit"`
-	Metadata       map[string]any `json:"metadata,omitempty"`
+	ClientID            uuid.UUID      `json:"client_id"`
+	ProductID           uuid.UUID      `json:"product_id"`
+	OpeningDeposit      json.Number    `json:"opening_deposit"`
+	RolloverOption      string         `json:"rollover_option,omitempty"`
+	PayoutAccountID     *uuid.UUID     `json:"payout_account_id,omitempty"`
+	Principal           json.Number    `json:"principal,omitempty"`
+	SettlementAccountID *uuid.UUID     `json:"settlement_account_id,omitempty"`
+	Metadata            map[string]any `json:"metadata,omitempty"`
+}
+
+// Overdraft is how far debits may take an account below zero. Limit is in
+// atomic units and ignored when Unbounded is set.
+type Overdraft struct {
+	Unbounded bool
+	Limit     int64
 }
 
 type DefaultAccountService struct {
@@ -92,6 +111,9 @@ func (s *DefaultAccountService) Open(ctx context.Context, input OpenAccountInput
 	if client.Status != models.ClientStatusActive {
 		return nil, fmt.Errorf("%w: client must be active before opening an account", ErrAccountValidation)
 	}
+	if client.ScreeningStatus == models.ScreeningStatusBlocked {
+		return nil, fmt.Errorf("%w: client %s cannot open accounts", ErrScreeningBlocked, client.ClientNumber)
+	}
 	if product.Status != models.ProductStatusActive {
 		return nil, fmt.Errorf("%w: product must be active before opening an account", ErrAccountValidation)
 	}
@@ -127,6 +149,15 @@ func (s *DefaultAccountService) Open(ctx context.Context, input OpenAccountInput
 		}
 	}
 
+	term, err := s.newAccountTerm(ctx, product, client, input)
+	if err != nil {
+		return nil, err
+	}
+	loan, err := s.newAccountLoan(ctx, product, client, input, openingDeposit)
+	if err != nil {
+		return nil, err
+	}
+
 	walletID := fmt.Sprintf("client-%s-%s", strings.TrimSpace(client.ClientNumber), strings.TrimSpace(product.Code))
 	if _, err := s.accountRepository.GetByWalletID(ctx, walletID); err == nil {
 		return nil, ErrAccountAlreadyExists
@@ -149,9 +180,13 @@ func (s *DefaultAccountService) Open(ctx context.Context, input OpenAccountInput
 		FreezeDebits:    false,
 		OpenedAt:        time.Now().UTC(),
 		InterestAccrued: decimal.Zero,
+		Term:            term,
+		Loan:            loan,
 		Metadata:        normalizeAccountMetadata(input.Metadata),
 	}
 
+	account.Holders = accountHolders(account)
+
 	if !openingDeposit.IsZero() {
 		account.Metadata["opening_deposit"] = input.OpeningDeposit.String()
 	}
@@ -175,6 +210,14 @@ func (s *DefaultAccountService) Get(ctx context.Context, id uuid.UUID) (*models.
 	return account, nil
 }
 
+func (s *DefaultAccountService) GetByWalletID(ctx context.Context, walletID string) (*models.Account, error) {
+	account, err := s.accountRepository.GetByWalletID(ctx, walletID)
+	if err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
 func (s *DefaultAccountService) Activate(ctx context.Context, id uuid.UUID) (*models.Account, error) {
 	account, err := s.accountRepository.Get(ctx, id)
 	if err != nil {
@@ -188,6 +231,9 @@ func (s *DefaultAccountService) Activate(ctx context.Context, id uuid.UUID) (*mo
 		now := time.Now().UTC()
 		account.Status = models.AccountStatusActive
 		account.ActivatedAt = &now
+		if account.Term != nil && account.Term.StartDate == nil {
+			startTerm(account, normalizeUsageDate(now))
+		}
 	default:
 		return nil, fmt.Errorf("%w: cannot activate account in status %s", ErrAccountInvalidStateTransition, account.Status)
 	}
@@ -335,6 +381,12 @@ func (s *DefaultAccountService) ValidateCredit(ctx context.Context, id uuid.UUID
 	if !product.Rules.AllowCredits {
 		return nil, fmt.Errorf("%w: product does not allow credits", ErrAccountValidation)
 	}
+	if termRunning(account) {
+		return nil, fmt.Errorf("%w: term deposits cannot be topped up before maturity", ErrAccountValidation)
+	}
+	if account.Loan != nil {
+		return nil, fmt.Errorf("%w: loans are repaid from their settlement account", ErrAccountValidation)
+	}
 
 	if product.Rules.TransactionLimits != nil && product.Rules.TransactionLimits.SingleCreditLimit != nil {
 		limit, err := ruleAmountToAtomic(*product.Rules.TransactionLimits.SingleCreditLimit, account.Currency)
@@ -414,6 +466,50 @@ func (s *DefaultAccountService) TouchActivity(ctx context.Context, id uuid.UUID,
 	return account, nil
 }
 
+// Overdraft returns nil when the product of the account does not allow
+// negative balances. Without an overdraft_limit the overdraft is unbounded.
+func (s *DefaultAccountService) Overdraft(ctx context.Context, id uuid.UUID) (*Overdraft, error) {
+	account, product, err := s.loadAccountAndProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !product.Rules.AllowNegativeBalance {
+		return nil, nil
+	}
+	if product.Rules.OverdraftLimit == nil {
+		return &Overdraft{Unbounded: true}, nil
+	}
+	limit, err := ruleAmountToAtomic(*product.Rules.OverdraftLimit, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return &Overdraft{Limit: limit}, nil
+}
+
+// ApplyKYCRestriction restricts the debits of the account when the KYC level
+// of its client no longer meets the product requirement, and lifts the
+// restriction once it does again.
+func (s *DefaultAccountService) ApplyKYCRestriction(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	account, product, err := s.loadAccountAndProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.clientRepository.Get(ctx, account.ClientID)
+	if err != nil {
+		return nil, resolveClientRepositoryError(err)
+	}
+
+	restricted := client.KYCLevel < product.Rules.RequiresKYCLevel
+	if account.KYCRestricted == restricted {
+		return account, nil
+	}
+	account.KYCRestricted = restricted
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
 func (s *DefaultAccountService) RecordCreditUsage(ctx context.Context, id uuid.UUID, amount int64, reference string, usageAt time.Time) error {
 	return s.recordUsage(ctx, id, amount, reference, usageAt, false)
 }
@@ -422,6 +518,119 @@ func (s *DefaultAccountService) RecordDebitUsage(ctx context.Context, id uuid.UU
 	return s.recordUsage(ctx, id, amount, reference, usageAt, true)
 }
 
+// newAccountTerm returns the term of an account opened on a fixed-term
+// product. The principal is the opening deposit; the term starts when the
+// account is activated.
+func (s *DefaultAccountService) newAccountTerm(ctx context.Context, product *models.Product, client *models.Client, input OpenAccountInput) (*models.AccountTerm, error) {
+	config := product.TermConfig
+	if config == nil {
+		if input.RolloverOption != "" || input.PayoutAccountID != nil {
+			return nil, fmt.Errorf("%w: rollover options only apply to term deposit products", ErrAccountValidation)
+		}
+		return nil, nil
+	}
+
+	principal, err := currencyregistry.ParseAmount(input.OpeningDeposit.String(), product.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid opening_deposit: %w", ErrAccountValidation, err)
+	}
+	if principal <= 0 {
+		return nil, fmt.Errorf("%w: term deposits require an opening_deposit", ErrAccountValidation)
+	}
+
+	option := strings.TrimSpace(input.RolloverOption)
+	if option == "" {
+		option = defaultRollover(config)
+	}
+	if err := validateRollover(ctx, s.accountRepository, config, client.ID, product.Currency, option, input.PayoutAccountID); err != nil {
+		return nil, err
+	}
+
+	return &models.AccountTerm{
+		Length:          config.Length,
+		Unit:            config.Unit,
+		Status:          models.TermStatusRunning,
+		Principal:       principal,
+		RolloverOption:  option,
+		PayoutAccountID: input.PayoutAccountID,
+	}, nil
+}
+
+// newAccountLoan returns the loan of an account opened on a loan product. The
+// loan is approved at opening and disbursed to the settlement account by the
+// loan servicing job once the account is active.
+func (s *DefaultAccountService) newAccountLoan(ctx context.Context, product *models.Product, client *models.Client, input OpenAccountInput, openingDeposit decimal.Decimal) (*models.AccountLoan, error) {
+	config := product.LoanConfig
+	if config == nil {
+		if input.Principal != "" || input.SettlementAccountID != nil {
+			return nil, fmt.Errorf("%w: principal and settlement_account_id only apply to loan products", ErrAccountValidation)
+		}
+		return nil, nil
+	}
+	if !openingDeposit.IsZero() {
+		return nil, fmt.Errorf("%w: loan accounts do not take an opening_deposit", ErrAccountValidation)
+	}
+
+	principal, err := currencyregistry.ParseAmount(input.Principal.String(), product.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid principal: %w", ErrAccountValidation, err)
+	}
+	if principal <= 0 {
+		return nil, fmt.Errorf("%w: principal must be positive", ErrAccountValidation)
+	}
+	if config.MinPrincipal != "" {
+		minPrincipal, err := ruleAmountToAtomic(config.MinPrincipal, product.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if principal < minPrincipal {
+			return nil, fmt.Errorf("%w: principal is below product min_principal", ErrAccountValidation)
+		}
+	}
+	if config.MaxPrincipal != nil {
+		maxPrincipal, err := ruleAmountToAtomic(*config.MaxPrincipal, product.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if principal > maxPrincipal {
+			return nil, fmt.Errorf("%w: principal exceeds product max_principal", ErrAccountValidation)
+		}
+	}
+
+	if input.SettlementAccountID == nil {
+		return nil, fmt.Errorf("%w: loans require a settlement_account_id", ErrAccountValidation)
+	}
+	settlement, err := s.accountRepository.Get(ctx, *input.SettlementAccountID)
+	if err != nil {
+		if postgres.IsNotFoundError(err) || errors.Is(err, postgres.ErrNotFound) {
+			return nil, fmt.Errorf("%w: settlement account %s not found", ErrAccountValidation, *input.SettlementAccountID)
+		}
+		return nil, err
+	}
+	if settlement.ClientID != client.ID {
+		return nil, fmt.Errorf("%w: settlement account must belong to the same client", ErrAccountValidation)
+	}
+	if settlement.Currency != product.Currency {
+		return nil, fmt.Errorf("%w: settlement account must be in %s", ErrAccountValidation, product.Currency)
+	}
+	if settlement.Loan != nil || settlement.Term != nil || settlement.Status == models.AccountStatusClosed {
+		return nil, fmt.Errorf("%w: settlement account must be an open deposit account", ErrAccountValidation)
+	}
+
+	return &models.AccountLoan{
+		Principal:           principal,
+		Rate:                config.Rate,
+		PenaltyRate:         config.PenaltyRate,
+		Amortization:        config.Amortization,
+		Installments:        config.Installments,
+		RepaymentFrequency:  config.RepaymentFrequency,
+		GraceDays:           config.GraceDays,
+		Status:              models.LoanStatusApproved,
+		SettlementAccountID: settlement.ID,
+		PenaltyAccrued:      decimal.Zero,
+	}, nil
+}
+
 func normalizeAccountMetadata(input map[string]any) map[string]any {
 	if input == nil {
 		return map[string]any{}
@@ -449,9 +658,27 @@ func (s *DefaultAccountService) validateDebitLike(ctx context.Context, id uuid.U
 	if account.FreezeDebits {
 		return nil, fmt.Errorf("%w: account debits are frozen", ErrAccountValidation)
 	}
+	if product.Rules.RequiresKYCLevel > 0 {
+		// The KYC level is checked on every debit rather than trusted to
+		// KYCRestricted, which the KYC expiry job only sets after expiring
+		// the record and may miss when it fails meanwhile.
+		client, err := s.clientRepository.Get(ctx, account.ClientID)
+		if err != nil {
+			return nil, resolveClientRepositoryError(err)
+		}
+		if client.KYCLevel < product.Rules.RequiresKYCLevel {
+			return nil, fmt.Errorf("%w: account debits are restricted until the client KYC is renewed", ErrAccountValidation)
+		}
+	}
+	if account.Loan != nil {
+		return nil, fmt.Errorf("%w: loans are disbursed to their settlement account", ErrAccountValidation)
+	}
 	if !product.Rules.AllowDebits {
 		return nil, fmt.Errorf("%w: product does not allow debits", ErrAccountValidation)
 	}
+	if operation == "debit" && termRunning(account) {
+		return nil, fmt.Errorf("%w: term deposit is locked until %s", ErrAccountValidation, account.MaturityDate.Format(time.DateOnly))
+	}
 
 	if product.Rules.TransactionLimits != nil && product.Rules.TransactionLimits.SingleDebitLimit != nil {
 		limit, err := ruleAmountToAtomic(*product.Rules.TransactionLimits.SingleDebitLimit, account.Currency)
@@ -480,7 +707,7 @@ func (s *DefaultAccountService) validateDebitLike(ctx context.Context, id uuid.U
 	resultingBalance := currentBalance - amount
 	if !product.Rules.AllowNegativeBalance {
 		if resultingBalance < 0 {
-			return nil, fmt.Errorf("%w: %s would overdraw the account", ErrAccountValidation, operation)
+			return nil, fmt.Errorf("%w: %w: %s would overdraw the account", ErrAccountValidation, ErrAccountInsufficientFunds, operation)
 		}
 
 		minBalance, err := ruleAmountToAtomic(product.Rules.MinBalance, account.Currency)
@@ -490,6 +717,14 @@ func (s *DefaultAccountService) validateDebitLike(ctx context.Context, id uuid.U
 		if resultingBalance < minBalance {
 			return nil, fmt.Errorf("%w: %s would breach product min_balance", ErrAccountValidation, operation)
 		}
+	} else if product.Rules.OverdraftLimit != nil {
+		overdraftLimit, err := ruleAmountToAtomic(*product.Rules.OverdraftLimit, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if resultingBalance < -overdraftLimit {
+			return nil, fmt.Errorf("%w: %w: %s would exceed product overdraft_limit", ErrAccountValidation, ErrAccountInsufficientFunds, operation)
+		}
 	}
 
 	return account, nil
@@ -583,27 +818,11 @@ func (s *DefaultAccountService) loadAccountAndProduct(ctx context.Context, id uu
 }
 
 func ruleAmountToAtomic(value string, currency string) (int64, error) {
-	value = strings.TrimSpace(value)
-	if value == "" {
-		return 0, nil
-	}
-	if !strings.Contains(value, ".") {
-		ret, err := decimal.NewFromString(value)
-		if err != nil {
-			return 0, fmt.Errorf("%w: invalid product rule amount %q", ErrAccountValidation, value)
-		}
-		return ret.IntPart(), nil
-	}
-	dec, err := decimal.NewFromString(value)
+	ret, err := currencyregistry.ParseAmount(value, currency)
 	if err != nil {
-		return 0, fmt.Errorf("%w: invalid product rule amount %q", ErrAccountValidation, value)
-	}
-	definition, ok := currencyregistry.Lookup(currency)
-	if !ok {
-		definition = currencyregistry.Definition{Precision: 2, Enabled: true}
+		return 0, fmt.Errorf("%w: invalid product rule amount: %w", ErrAccountValidation, err)
 	}
-	scaled := dec.Shift(int32(definition.Precision)).Round(0)
-	return scaled.IntPart(), nil
+	return ret, nil
 }
 
 func normalizeUsageDate(usageAt time.Time) time.Time {
@@ -194,6 +194,117 @@ func TestAccountServiceValidateDebitRespectsDailyLimit(t *testing.T) {
 	require.ErrorIs(t, err, ErrAccountValidation)
 }
 
+func TestAccountServiceApplyKYCRestriction(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	clientRepo := newClientRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	service := NewAccountService(accountRepo, clientRepo, productRepo, newDailyUsageRepositoryStub())
+
+	client := &models.Client{
+		ClientNumber: "CL-2026-000011",
+		Type:         models.ClientTypeIndividual,
+		Status:       models.ClientStatusActive,
+		KYCLevel:     0,
+	}
+	require.NoError(t, clientRepo.Create(context.Background(), client))
+	product := &models.Product{
+		ID:       uuid.New(),
+		Code:     "SAV-USD-011",
+		Name:     "Savings USD",
+		Category: "savings",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			AllowCredits:     true,
+			AllowDebits:      true,
+			MinBalance:       "0",
+			RequiresKYCLevel: 2,
+		},
+	}
+	require.NoError(t, productRepo.Create(context.Background(), product))
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "9999999911",
+		ClientID:      client.ID,
+		ProductID:     product.ID,
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "wallet-11",
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	// Debits are refused as soon as the KYC level falls short, before the
+	// restriction is applied.
+	_, err := service.ValidateDebit(context.Background(), account.ID, 10, 100, time.Now().UTC())
+	require.ErrorIs(t, err, ErrAccountValidation)
+
+	restricted, err := service.ApplyKYCRestriction(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.True(t, restricted.KYCRestricted)
+	_, err = service.ValidateDebit(context.Background(), account.ID, 10, 100, time.Now().UTC())
+	require.ErrorIs(t, err, ErrAccountValidation)
+	_, err = service.ValidateCredit(context.Background(), account.ID, 10, 100, time.Now().UTC())
+	require.NoError(t, err)
+
+	// Debits resume as soon as the client is verified again, before the
+	// restriction is lifted.
+	client.KYCLevel = 2
+	require.NoError(t, clientRepo.Update(context.Background(), client))
+	_, err = service.ValidateDebit(context.Background(), account.ID, 10, 100, time.Now().UTC())
+	require.NoError(t, err)
+
+	lifted, err := service.ApplyKYCRestriction(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.False(t, lifted.KYCRestricted)
+}
+
+func TestAccountServiceValidateDebitRespectsOverdraftLimit(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	service := NewAccountService(accountRepo, newClientRepositoryStub(), productRepo, newDailyUsageRepositoryStub())
+
+	productID := uuid.New()
+	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
+		ID:       productID,
+		Code:     "OD-USD-001",
+		Name:     "Overdraft USD",
+		Category: "current",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			AllowDebits:          true,
+			AllowNegativeBalance: true,
+			OverdraftLimit:       strPtr("500.00"),
+			MinBalance:           "0",
+		},
+	}))
+
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "9999999992",
+		ProductID:     productID,
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "wallet-2",
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	now := time.Now().UTC()
+	_, err := service.ValidateDebit(context.Background(), account.ID, 60_000, 10_000, now)
+	require.NoError(t, err)
+	_, err = service.ValidateDebit(context.Background(), account.ID, 60_001, 10_000, now)
+	require.ErrorIs(t, err, ErrAccountValidation)
+	require.ErrorContains(t, err, "overdraft_limit")
+
+	overdraft, err := service.Overdraft(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, &Overdraft{Limit: 50_000}, overdraft)
+}
+
 func TestAccountServiceRecordCreditUsage(t *testing.T) {
 	t.Parallel()
 
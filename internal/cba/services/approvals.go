@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+)
+
+var (
+	ErrApprovalValidation     = errors.New("approval validation failed")
+	ErrApprovalNotFound       = errors.New("approval not found")
+	ErrApprovalDecided        = errors.New("approval request is already decided")
+	ErrApprovalSameActor      = errors.New("approval requires a different actor")
+	ErrApprovalPolicyNotFound = errors.New("approval policy not found")
+	ErrApprovalNotRequired    = errors.New("approval not required")
+	ErrApprovalActorRequired  = errors.New("approval actor is required")
+)
+
+type ApprovalService interface {
+	CreatePolicy(context.Context, CreateApprovalPolicyInput) (*models.ApprovalPolicy, error)
+	UpdatePolicy(context.Context, uuid.UUID, UpdateApprovalPolicyInput) (*models.ApprovalPolicy, error)
+	ListPolicies(context.Context, repositories.ApprovalPolicyFilter) ([]models.ApprovalPolicy, error)
+	// Submit holds the operation for approval when an enabled policy applies
+	// to it and returns ErrApprovalNotRequired otherwise.
+	Submit(context.Context, SubmitApprovalInput) (*models.ApprovalRequest, error)
+	Get(context.Context, uuid.UUID) (*models.ApprovalRequest, error)
+	List(context.Context, repositories.ApprovalRequestFilter) ([]models.ApprovalRequest, error)
+	Approve(context.Context, uuid.UUID, DecideApprovalInput) (*models.ApprovalRequest, error)
+	Reject(context.Context, uuid.UUID, DecideApprovalInput) (*models.ApprovalRequest, error)
+	// RetryExecution returns an approved request whose execution was never
+	// recorded, for its operation to be executed again, and records who
+	// retried it.
+	RetryExecution(ctx context.Context, id uuid.UUID, actor string) (*models.ApprovalRequest, error)
+	// CompleteExecution records the outcome of the operation of an approved
+	// request.
+	CompleteExecution(context.Context, uuid.UUID, ApprovalExecution) (*models.ApprovalRequest, error)
+	Events(context.Context, uuid.UUID) ([]models.ApprovalEvent, error)
+}
+
+type CreateApprovalPolicyInput struct {
+	Operation string     `json:"operation"`
+	ProductID *uuid.UUID `json:"product_id,omitempty"`
+	Currency  string     `json:"currency,omitempty"`
+	MinAmount *string    `json:"min_amount,omitempty"`
+	CreatedBy string     `json:"-"`
+}
+
+type UpdateApprovalPolicyInput struct {
+	MinAmount *string `json:"min_amount,omitempty"`
+	Enabled   *bool   `json:"enabled,omitempty"`
+}
+
+// SubmitApprovalInput describes an operation and the API call performing it.
+// Amount is in atomic units of Currency.
+type SubmitApprovalInput struct {
+	Operation      string
+	ResourceID     uuid.UUID
+	ProductID      *uuid.UUID
+	Currency       string
+	Amount         *int64
+	Method         string
+	Path           string
+	Payload        json.RawMessage
+	IdempotencyKey string
+	RequestedBy    string
+}
+
+type DecideApprovalInput struct {
+	Comment   string `json:"comment,omitempty"`
+	DecidedBy string `json:"-"`
+}
+
+// ApprovalExecution is the response of the operation of an approved request.
+type ApprovalExecution struct {
+	Status int
+	Body   []byte
+}
+
+type DefaultApprovalService struct {
+	policyRepository  repositories.ApprovalPolicyRepository
+	requestRepository repositories.ApprovalRequestRepository
+	eventRepository   repositories.ApprovalEventRepository
+}
+
+func NewApprovalService(
+	policyRepository repositories.ApprovalPolicyRepository,
+	requestRepository repositories.ApprovalRequestRepository,
+	eventRepository repositories.ApprovalEventRepository,
+) ApprovalService {
+	return &DefaultApprovalService{
+		policyRepository:  policyRepository,
+		requestRepository: requestRepository,
+		eventRepository:   eventRepository,
+	}
+}
+
+func (s *DefaultApprovalService) CreatePolicy(ctx context.Context, input CreateApprovalPolicyInput) (*models.ApprovalPolicy, error) {
+	operation := strings.ToLower(strings.TrimSpace(input.Operation))
+	if !slices.Contains(models.ApprovalOperations, operation) {
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrApprovalValidation, input.Operation)
+	}
+	policy := &models.ApprovalPolicy{
+		Operation: operation,
+		ProductID: input.ProductID,
+		Currency:  strings.ToUpper(strings.TrimSpace(input.Currency)),
+		MinAmount: input.MinAmount,
+		Enabled:   true,
+		CreatedBy: input.CreatedBy,
+	}
+	if err := validateApprovalPolicy(policy); err != nil {
+		return nil, err
+	}
+	if err := s.policyRepository.Create(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func (s *DefaultApprovalService) UpdatePolicy(ctx context.Context, id uuid.UUID, input UpdateApprovalPolicyInput) (*models.ApprovalPolicy, error) {
+	policy, err := s.policyRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveApprovalPolicyRepositoryError(err)
+	}
+	if input.MinAmount != nil {
+		policy.MinAmount = input.MinAmount
+		if strings.TrimSpace(*input.MinAmount) == "" {
+			policy.MinAmount = nil
+		}
+	}
+	if input.Enabled != nil {
+		policy.Enabled = *input.Enabled
+	}
+	if err := validateApprovalPolicy(policy); err != nil {
+		return nil, err
+	}
+	if err := s.policyRepository.Update(ctx, policy); err != nil {
+		return nil, resolveApprovalPolicyRepositoryError(err)
+	}
+	return policy, nil
+}
+
+// validateApprovalPolicy checks the amount threshold of the policy, which
+// only applies to the operations moving funds and requires a currency.
+func validateApprovalPolicy(policy *models.ApprovalPolicy) error {
+	if policy.MinAmount == nil {
+		return nil
+	}
+	switch policy.Operation {
+	case models.ApprovalOperationAccountDebit, models.ApprovalOperationFeeWaive:
+	default:
+		return fmt.Errorf("%w: min_amount does not apply to %s", ErrApprovalValidation, policy.Operation)
+	}
+	if policy.Currency == "" {
+		return fmt.Errorf("%w: min_amount requires a currency", ErrApprovalValidation)
+	}
+	minAmount := strings.TrimSpace(*policy.MinAmount)
+	if amount, err := currencyregistry.ParseAmount(minAmount, policy.Currency); err != nil || amount < 0 {
+		return fmt.Errorf("%w: invalid min_amount", ErrApprovalValidation)
+	}
+	policy.MinAmount = &minAmount
+	return nil
+}
+
+func (s *DefaultApprovalService) ListPolicies(ctx context.Context, filter repositories.ApprovalPolicyFilter) ([]models.ApprovalPolicy, error) {
+	return s.policyRepository.List(ctx, filter)
+}
+
+func (s *DefaultApprovalService) Submit(ctx context.Context, input SubmitApprovalInput) (*models.ApprovalRequest, error) {
+	enabled := true
+	policies, err := s.policyRepository.List(ctx, repositories.ApprovalPolicyFilter{
+		Operation: &input.Operation,
+		Enabled:   &enabled,
+	})
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(policies, func(policy models.ApprovalPolicy) bool {
+		return approvalPolicyApplies(policy, input)
+	})
+	if index < 0 {
+		return nil, ErrApprovalNotRequired
+	}
+
+	requestedBy := strings.TrimSpace(input.RequestedBy)
+	if requestedBy == "" {
+		return nil, fmt.Errorf("%w: %s requires approval", ErrApprovalActorRequired, input.Operation)
+	}
+	request := &models.ApprovalRequest{
+		PolicyID:       policies[index].ID,
+		Operation:      input.Operation,
+		Status:         models.ApprovalStatusPending,
+		ResourceID:     input.ResourceID,
+		ProductID:      input.ProductID,
+		Currency:       input.Currency,
+		Amount:         input.Amount,
+		Method:         input.Method,
+		Path:           input.Path,
+		Payload:        input.Payload,
+		IdempotencyKey: input.IdempotencyKey,
+		RequestedBy:    requestedBy,
+	}
+	if len(request.Payload) > 0 && !json.Valid(request.Payload) {
+		return nil, fmt.Errorf("%w: invalid payload", ErrApprovalValidation)
+	}
+	if request.IdempotencyKey == "" {
+		request.ID = uuid.New()
+		request.IdempotencyKey = "approval:" + request.ID.String()
+	}
+	if err := s.requestRepository.Create(ctx, request); err != nil {
+		return nil, err
+	}
+	if err := s.recordEvent(ctx, request.ID, models.ApprovalEventSubmitted, requestedBy, ""); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// approvalPolicyApplies reports whether the policy holds the operation. The
+// operations without an amount never reach the threshold of a policy.
+func approvalPolicyApplies(policy models.ApprovalPolicy, input SubmitApprovalInput) bool {
+	if policy.ProductID != nil && (input.ProductID == nil || *policy.ProductID != *input.ProductID) {
+		return false
+	}
+	if policy.Currency != "" && !strings.EqualFold(policy.Currency, input.Currency) {
+		return false
+	}
+	if policy.MinAmount == nil {
+		return true
+	}
+	if input.Amount == nil {
+		return false
+	}
+	minAmount, err := currencyregistry.ParseAmount(*policy.MinAmount, policy.Currency)
+	if err != nil {
+		return true
+	}
+	return *input.Amount >= minAmount
+}
+
+func (s *DefaultApprovalService) Get(ctx context.Context, id uuid.UUID) (*models.ApprovalRequest, error) {
+	request, err := s.requestRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveApprovalRepositoryError(err)
+	}
+	return request, nil
+}
+
+func (s *DefaultApprovalService) List(ctx context.Context, filter repositories.ApprovalRequestFilter) ([]models.ApprovalRequest, error) {
+	return s.requestRepository.List(ctx, filter)
+}
+
+func (s *DefaultApprovalService) Approve(ctx context.Context, id uuid.UUID, input DecideApprovalInput) (*models.ApprovalRequest, error) {
+	return s.decide(ctx, id, models.ApprovalStatusApproved, input)
+}
+
+func (s *DefaultApprovalService) Reject(ctx context.Context, id uuid.UUID, input DecideApprovalInput) (*models.ApprovalRequest, error) {
+	if strings.TrimSpace(input.Comment) == "" {
+		return nil, fmt.Errorf("%w: comment is required to reject a request", ErrApprovalValidation)
+	}
+	return s.decide(ctx, id, models.ApprovalStatusRejected, input)
+}
+
+// decide moves a pending request to the given status. The request is only
+// updated while still pending so that it is decided once, even when two
+// actors decide it concurrently.
+func (s *DefaultApprovalService) decide(ctx context.Context, id uuid.UUID, status string, input DecideApprovalInput) (*models.ApprovalRequest, error) {
+	decidedBy := strings.TrimSpace(input.DecidedBy)
+	if decidedBy == "" {
+		return nil, fmt.Errorf("%w: the deciding actor is unknown", ErrApprovalActorRequired)
+	}
+	request, err := s.requestRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveApprovalRepositoryError(err)
+	}
+	if request.Status != models.ApprovalStatusPending {
+		return nil, fmt.Errorf("%w: request is %s", ErrApprovalDecided, request.Status)
+	}
+	if strings.EqualFold(decidedBy, request.RequestedBy) {
+		return nil, fmt.Errorf("%w: %s requested the operation", ErrApprovalSameActor, decidedBy)
+	}
+
+	now := time.Now().UTC()
+	request.Status = status
+	request.DecidedBy = decidedBy
+	request.DecidedAt = &now
+	request.Comment = strings.TrimSpace(input.Comment)
+	if err := s.requestRepository.Transition(ctx, request, models.ApprovalStatusPending); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, fmt.Errorf("%w: request was decided concurrently", ErrApprovalDecided)
+		}
+		return nil, err
+	}
+	if err := s.recordEvent(ctx, request.ID, status, decidedBy, request.Comment); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *DefaultApprovalService) RetryExecution(ctx context.Context, id uuid.UUID, actor string) (*models.ApprovalRequest, error) {
+	actor = strings.TrimSpace(actor)
+	if actor == "" {
+		return nil, fmt.Errorf("%w: the retrying actor is unknown", ErrApprovalActorRequired)
+	}
+	request, err := s.requestRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveApprovalRepositoryError(err)
+	}
+	if request.Status != models.ApprovalStatusApproved {
+		return nil, fmt.Errorf("%w: request is %s", ErrApprovalDecided, request.Status)
+	}
+	if err := s.recordEvent(ctx, request.ID, models.ApprovalEventExecutionRetried, actor, ""); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *DefaultApprovalService) CompleteExecution(ctx context.Context, id uuid.UUID, execution ApprovalExecution) (*models.ApprovalRequest, error) {
+	request, err := s.requestRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveApprovalRepositoryError(err)
+	}
+	if request.Status != models.ApprovalStatusApproved {
+		return nil, fmt.Errorf("%w: request is %s", ErrApprovalDecided, request.Status)
+	}
+
+	now := time.Now().UTC()
+	request.Status = models.ApprovalStatusExecuted
+	if execution.Status >= 300 {
+		request.Status = models.ApprovalStatusFailed
+	}
+	request.ResultStatus = execution.Status
+	request.Result = nil
+	if json.Valid(execution.Body) {
+		request.Result = execution.Body
+	}
+	request.ExecutedAt = &now
+	if err := s.requestRepository.Transition(ctx, request, models.ApprovalStatusApproved); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, fmt.Errorf("%w: request was executed concurrently", ErrApprovalDecided)
+		}
+		return nil, err
+	}
+	comment := ""
+	if request.Status == models.ApprovalStatusFailed {
+		comment = strings.TrimSpace(string(execution.Body))
+	}
+	if err := s.recordEvent(ctx, request.ID, request.Status, "", comment); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *DefaultApprovalService) Events(ctx context.Context, id uuid.UUID) ([]models.ApprovalEvent, error) {
+	if _, err := s.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.eventRepository.ListByRequest(ctx, id)
+}
+
+func (s *DefaultApprovalService) recordEvent(ctx context.Context, requestID uuid.UUID, action, actor, comment string) error {
+	return s.eventRepository.Create(ctx, &models.ApprovalEvent{
+		RequestID: requestID,
+		Action:    action,
+		Actor:     actor,
+		Comment:   comment,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func resolveApprovalRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrApprovalNotFound
+	default:
+		return err
+	}
+}
+
+func resolveApprovalPolicyRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrApprovalPolicyNotFound
+	default:
+		return err
+	}
+}
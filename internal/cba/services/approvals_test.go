@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+type approvalPolicyRepositoryStub struct {
+	policies []*models.ApprovalPolicy
+}
+
+func newApprovalPolicyRepositoryStub() *approvalPolicyRepositoryStub {
+	return &approvalPolicyRepositoryStub{}
+}
+
+func (s *approvalPolicyRepositoryStub) Create(_ context.Context, policy *models.ApprovalPolicy) error {
+	if policy.ID == uuid.Nil {
+		policy.ID = uuid.New()
+	}
+	copied := *policy
+	s.policies = append(s.policies, &copied)
+	return nil
+}
+
+func (s *approvalPolicyRepositoryStub) Update(_ context.Context, policy *models.ApprovalPolicy) error {
+	for i, existing := range s.policies {
+		if existing.ID == policy.ID {
+			copied := *policy
+			s.policies[i] = &copied
+			return nil
+		}
+	}
+	return postgres.ErrNotFound
+}
+
+func (s *approvalPolicyRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.ApprovalPolicy, error) {
+	for _, policy := range s.policies {
+		if policy.ID == id {
+			copied := *policy
+			return &copied, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *approvalPolicyRepositoryStub) List(_ context.Context, filter repositories.ApprovalPolicyFilter) ([]models.ApprovalPolicy, error) {
+	ret := make([]models.ApprovalPolicy, 0)
+	for _, policy := range s.policies {
+		if filter.Operation != nil && policy.Operation != *filter.Operation {
+			continue
+		}
+		if filter.Enabled != nil && policy.Enabled != *filter.Enabled {
+			continue
+		}
+		ret = append(ret, *policy)
+	}
+	return ret, nil
+}
+
+type approvalRequestRepositoryStub struct {
+	requests map[uuid.UUID]*models.ApprovalRequest
+}
+
+func newApprovalRequestRepositoryStub() *approvalRequestRepositoryStub {
+	return &approvalRequestRepositoryStub{
+		requests: map[uuid.UUID]*models.ApprovalRequest{},
+	}
+}
+
+func (s *approvalRequestRepositoryStub) Create(_ context.Context, request *models.ApprovalRequest) error {
+	if request.ID == uuid.Nil {
+		request.ID = uuid.New()
+	}
+	copied := *request
+	s.requests[request.ID] = &copied
+	return nil
+}
+
+func (s *approvalRequestRepositoryStub) Transition(_ context.Context, request *models.ApprovalRequest, from string) error {
+	existing, ok := s.requests[request.ID]
+	if !ok || existing.Status != from {
+		return postgres.ErrNotFound
+	}
+	copied := *request
+	s.requests[request.ID] = &copied
+	return nil
+}
+
+func (s *approvalRequestRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.ApprovalRequest, error) {
+	request, ok := s.requests[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	copied := *request
+	return &copied, nil
+}
+
+func (s *approvalRequestRepositoryStub) List(_ context.Context, filter repositories.ApprovalRequestFilter) ([]models.ApprovalRequest, error) {
+	ret := make([]models.ApprovalRequest, 0)
+	for _, request := range s.requests {
+		if filter.Operation != nil && request.Operation != *filter.Operation {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, request.Status) {
+			continue
+		}
+		if filter.ResourceID != nil && request.ResourceID != *filter.ResourceID {
+			continue
+		}
+		ret = append(ret, *request)
+	}
+	return ret, nil
+}
+
+type approvalEventRepositoryStub struct {
+	events []models.ApprovalEvent
+}
+
+func newApprovalEventRepositoryStub() *approvalEventRepositoryStub {
+	return &approvalEventRepositoryStub{}
+}
+
+func (s *approvalEventRepositoryStub) Create(_ context.Context, event *models.ApprovalEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	s.events = append(s.events, *event)
+	return nil
+}
+
+func (s *approvalEventRepositoryStub) ListByRequest(_ context.Context, requestID uuid.UUID) ([]models.ApprovalEvent, error) {
+	ret := make([]models.ApprovalEvent, 0)
+	for _, event := range s.events {
+		if event.RequestID == requestID {
+			ret = append(ret, event)
+		}
+	}
+	return ret, nil
+}
+
+func TestApprovalPolicies(t *testing.T) {
+	t.Parallel()
+
+	service := NewApprovalService(newApprovalPolicyRepositoryStub(), newApprovalRequestRepositoryStub(), newApprovalEventRepositoryStub())
+	productID := uuid.New()
+
+	for name, input := range map[string]CreateApprovalPolicyInput{
+		"unknown operation":          {Operation: "account_open"},
+		"threshold without currency": {Operation: models.ApprovalOperationAccountDebit, MinAmount: strPtr("1000.00")},
+		"threshold on close":         {Operation: models.ApprovalOperationAccountClose, Currency: "USD", MinAmount: strPtr("1000.00")},
+		"invalid threshold":          {Operation: models.ApprovalOperationAccountDebit, Currency: "USD", MinAmount: strPtr("lots")},
+	} {
+		_, err := service.CreatePolicy(context.Background(), input)
+		require.ErrorIs(t, err, ErrApprovalValidation, name)
+	}
+
+	_, err := service.CreatePolicy(context.Background(), CreateApprovalPolicyInput{
+		Operation: models.ApprovalOperationAccountDebit,
+		Currency:  "usd",
+		MinAmount: strPtr("1000.00"),
+	})
+	require.NoError(t, err)
+	closePolicy, err := service.CreatePolicy(context.Background(), CreateApprovalPolicyInput{
+		Operation: models.ApprovalOperationAccountClose,
+		ProductID: &productID,
+	})
+	require.NoError(t, err)
+
+	submit := func(operation string, productID uuid.UUID, amount *int64) error {
+		_, err := service.Submit(context.Background(), SubmitApprovalInput{
+			Operation:   operation,
+			ResourceID:  uuid.New(),
+			ProductID:   &productID,
+			Currency:    "USD",
+			Amount:      amount,
+			Method:      http.MethodPost,
+			Path:        "/ledgertrack/accounts/" + uuid.NewString() + "/debit",
+			RequestedBy: "maker",
+		})
+		return err
+	}
+	below, above := int64(99_999), int64(100_000)
+	require.ErrorIs(t, submit(models.ApprovalOperationAccountDebit, productID, &below), ErrApprovalNotRequired)
+	require.NoError(t, submit(models.ApprovalOperationAccountDebit, productID, &above))
+	require.ErrorIs(t, submit(models.ApprovalOperationAccountClose, uuid.New(), nil), ErrApprovalNotRequired)
+	require.NoError(t, submit(models.ApprovalOperationAccountClose, productID, nil))
+
+	disabled := false
+	_, err = service.UpdatePolicy(context.Background(), closePolicy.ID, UpdateApprovalPolicyInput{Enabled: &disabled})
+	require.NoError(t, err)
+	require.ErrorIs(t, submit(models.ApprovalOperationAccountClose, productID, nil), ErrApprovalNotRequired)
+
+	_, err = service.UpdatePolicy(context.Background(), uuid.New(), UpdateApprovalPolicyInput{Enabled: &disabled})
+	require.ErrorIs(t, err, ErrApprovalPolicyNotFound)
+}
+
+func TestApprovalRequestLifecycle(t *testing.T) {
+	t.Parallel()
+
+	eventRepo := newApprovalEventRepositoryStub()
+	service := NewApprovalService(newApprovalPolicyRepositoryStub(), newApprovalRequestRepositoryStub(), eventRepo)
+	policy, err := service.CreatePolicy(context.Background(), CreateApprovalPolicyInput{
+		Operation: models.ApprovalOperationProductActivate,
+	})
+	require.NoError(t, err)
+
+	productID := uuid.New()
+	input := SubmitApprovalInput{
+		Operation:  models.ApprovalOperationProductActivate,
+		ResourceID: productID,
+		ProductID:  &productID,
+		Method:     http.MethodPost,
+		Path:       "/ledgertrack/products/" + productID.String() + "/activate",
+	}
+	_, err = service.Submit(context.Background(), input)
+	require.ErrorIs(t, err, ErrApprovalActorRequired)
+
+	input.RequestedBy = "maker"
+	request, err := service.Submit(context.Background(), input)
+	require.NoError(t, err)
+	require.Equal(t, models.ApprovalStatusPending, request.Status)
+	require.Equal(t, policy.ID, request.PolicyID)
+	require.Equal(t, "approval:"+request.ID.String(), request.IdempotencyKey)
+
+	_, err = service.Approve(context.Background(), request.ID, DecideApprovalInput{DecidedBy: "MAKER"})
+	require.ErrorIs(t, err, ErrApprovalSameActor)
+	_, err = service.Approve(context.Background(), request.ID, DecideApprovalInput{})
+	require.ErrorIs(t, err, ErrApprovalActorRequired)
+	_, err = service.Reject(context.Background(), request.ID, DecideApprovalInput{DecidedBy: "checker"})
+	require.ErrorIs(t, err, ErrApprovalValidation)
+
+	approved, err := service.Approve(context.Background(), request.ID, DecideApprovalInput{DecidedBy: "checker", Comment: "checked"})
+	require.NoError(t, err)
+	require.Equal(t, models.ApprovalStatusApproved, approved.Status)
+	require.Equal(t, "checker", approved.DecidedBy)
+	require.NotNil(t, approved.DecidedAt)
+
+	_, err = service.Approve(context.Background(), request.ID, DecideApprovalInput{DecidedBy: "other"})
+	require.ErrorIs(t, err, ErrApprovalDecided)
+
+	body, err := json.Marshal(map[string]any{"data": map[string]any{"status": models.ProductStatusActive}})
+	require.NoError(t, err)
+	executed, err := service.CompleteExecution(context.Background(), request.ID, ApprovalExecution{Status: http.StatusOK, Body: body})
+	require.NoError(t, err)
+	require.Equal(t, models.ApprovalStatusExecuted, executed.Status)
+	require.Equal(t, http.StatusOK, executed.ResultStatus)
+	require.JSONEq(t, string(body), string(executed.Result))
+
+	_, err = service.CompleteExecution(context.Background(), request.ID, ApprovalExecution{Status: http.StatusOK})
+	require.ErrorIs(t, err, ErrApprovalDecided)
+
+	events, err := service.Events(context.Background(), request.ID)
+	require.NoError(t, err)
+	actions := make([]string, 0, len(events))
+	for _, event := range events {
+		actions = append(actions, event.Action)
+	}
+	require.Equal(t, []string{models.ApprovalEventSubmitted, models.ApprovalStatusApproved, models.ApprovalStatusExecuted}, actions)
+	require.Equal(t, "maker", events[0].Actor)
+	require.Equal(t, "checker", events[1].Actor)
+
+	rejected, err := service.Submit(context.Background(), input)
+	require.NoError(t, err)
+	rejected, err = service.Reject(context.Background(), rejected.ID, DecideApprovalInput{DecidedBy: "checker", Comment: "not yet"})
+	require.NoError(t, err)
+	require.Equal(t, models.ApprovalStatusRejected, rejected.Status)
+	_, err = service.CompleteExecution(context.Background(), rejected.ID, ApprovalExecution{Status: http.StatusOK})
+	require.ErrorIs(t, err, ErrApprovalDecided)
+
+	_, err = service.Get(context.Background(), uuid.New())
+	require.ErrorIs(t, err, ErrApprovalNotFound)
+}
@@ -42,43 +42,59 @@ type CreateClientInput struct {
 	Contact        models.ClientContact   `json:"contact"`
 	IndividualData *models.IndividualData `json:"individual_data,omitempty"`
 	CorporateData  *models.CorporateData  `json:"corporate_data,omitempty"`
+	TaxResidency   string                 `json:"tax_residency,omitempty"`
 }
 
 type PatchClientInput struct {
 	Contact        *models.ClientContact   `json:"contact,omitempty"`
 	IndividualData **models.IndividualData `json:"individual_data,omitempty"`
 	CorporateData  **models.CorporateData  `json:"corporate_data,omitempty"`
+	TaxResidency   *string                 `json:"tax_residency,omitempty"`
+	TaxExemption   **models.TaxExemption   `json:"tax_exemption,omitempty"`
 }
 
 type SuspendClientInput struct {
 	Reason string `json:"reason"`
 }
 
+// ClientScreener screens clients against the sanctions and PEP watchlists
+// when they are onboarded and when their names change.
+type ClientScreener interface {
+	ScreenClient(context.Context, *models.Client) error
+}
+
 type DefaultClientService struct {
 	clientRepository  repositories.ClientRepository
 	accountRepository repositories.AccountRepository
+	screener          ClientScreener
 }
 
+// NewClientService creates a client service. Clients are not screened when
+// screener is nil.
 func NewClientService(
 	clientRepository repositories.ClientRepository,
 	accountRepository repositories.AccountRepository,
+	screener ClientScreener,
 ) ClientService {
 	return &DefaultClientService{
 		clientRepository:  clientRepository,
 		accountRepository: accountRepository,
+		screener:          screener,
 	}
 }
 
 func (s *DefaultClientService) Create(ctx context.Context, input CreateClientInput) (*models.Client, error) {
 	client := &models.Client{
-		Type:           normalizeClientType(input.Type),
-		Status:         models.ClientStatusPending,
-		KYCLevel:       0,
-		KYCStatus:      models.KYCStatusPending,
-		KYCData:        map[string]any{},
-		Contact:        normalizeContact(input.Contact),
-		IndividualData: normalizeIndividualData(input.IndividualData),
-		CorporateData:  normalizeCorporateData(input.CorporateData),
+		Type:            normalizeClientType(input.Type),
+		Status:          models.ClientStatusPending,
+		KYCLevel:        0,
+		KYCStatus:       models.KYCStatusPending,
+		KYCData:         map[string]any{},
+		Contact:         normalizeContact(input.Contact),
+		IndividualData:  normalizeIndividualData(input.IndividualData),
+		CorporateData:   normalizeCorporateData(input.CorporateData),
+		TaxResidency:    strings.ToUpper(strings.TrimSpace(input.TaxResidency)),
+		ScreeningStatus: models.ScreeningStatusClear,
 	}
 
 	if err := validateClient(client); err != nil {
@@ -94,6 +110,11 @@ func (s *DefaultClientService) Create(ctx context.Context, input CreateClientInp
 	if err := s.clientRepository.Create(ctx, client); err != nil {
 		return nil, resolveClientRepositoryError(err)
 	}
+	if s.screener != nil {
+		if err := s.screener.ScreenClient(ctx, client); err != nil {
+			return nil, err
+		}
+	}
 
 	return client, nil
 }
@@ -125,6 +146,12 @@ func (s *DefaultClientService) Patch(ctx context.Context, id uuid.UUID, input Pa
 	if input.CorporateData != nil {
 		client.CorporateData = normalizeCorporateData(*input.CorporateData)
 	}
+	if input.TaxResidency != nil {
+		client.TaxResidency = strings.ToUpper(strings.TrimSpace(*input.TaxResidency))
+	}
+	if input.TaxExemption != nil {
+		client.TaxExemption = *input.TaxExemption
+	}
 
 	if err := validateClient(client); err != nil {
 		return nil, err
@@ -133,6 +160,11 @@ func (s *DefaultClientService) Patch(ctx context.Context, id uuid.UUID, input Pa
 	if err := s.clientRepository.Update(ctx, client); err != nil {
 		return nil, resolveClientRepositoryError(err)
 	}
+	if s.screener != nil && (input.IndividualData != nil || input.CorporateData != nil) {
+		if err := s.screener.ScreenClient(ctx, client); err != nil {
+			return nil, err
+		}
+	}
 
 	return client, nil
 }
@@ -150,6 +182,9 @@ func (s *DefaultClientService) Activate(ctx context.Context, id uuid.UUID) (*mod
 		if client.KYCLevel < 1 || client.KYCStatus != models.KYCStatusVerified {
 			return nil, fmt.Errorf("%w: client requires verified KYC level 1 or higher", ErrClientKYCRequirement)
 		}
+		if client.ScreeningStatus == models.ScreeningStatusBlocked {
+			return nil, fmt.Errorf("%w: client %s cannot be activated", ErrScreeningBlocked, client.ClientNumber)
+		}
 		client.Status = models.ClientStatusActive
 	default:
 		return nil, fmt.Errorf("%w: cannot activate client in status %s", ErrClientInvalidStateTransition, client.Status)
@@ -204,6 +239,9 @@ func (s *DefaultClientService) Reactivate(ctx context.Context, id uuid.UUID) (*m
 		if client.KYCLevel < 1 || client.KYCStatus != models.KYCStatusVerified {
 			return nil, fmt.Errorf("%w: client requires verified KYC level 1 or higher", ErrClientKYCRequirement)
 		}
+		if client.ScreeningStatus == models.ScreeningStatusBlocked {
+			return nil, fmt.Errorf("%w: client %s cannot be reactivated", ErrScreeningBlocked, client.ClientNumber)
+		}
 		client.Status = models.ClientStatusActive
 	default:
 		return nil, fmt.Errorf("%w: cannot reactivate client in status %s", ErrClientInvalidStateTransition, client.Status)
@@ -384,6 +422,13 @@ func validateClient(client *models.Client) error {
 		return fmt.Errorf("%w: kyc_level must be between 0 and 3", ErrClientValidation)
 	}
 
+	if client.TaxResidency != "" && len(client.TaxResidency) != 2 {
+		return fmt.Errorf("%w: tax_residency must be a two letter country code", ErrClientValidation)
+	}
+	if client.TaxExemption != nil && strings.TrimSpace(client.TaxExemption.Reason) == "" {
+		return fmt.Errorf("%w: tax_exemption requires a reason", ErrClientValidation)
+	}
+
 	return nil
 }
 
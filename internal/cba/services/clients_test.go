@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 
@@ -134,12 +135,26 @@ func (s *accountRepositoryStub) List(_ context.Context, filter repositories.Acco
 		if filter.ClientID != nil && account.ClientID != *filter.ClientID {
 			continue
 		}
+		if filter.HolderID != nil && !slices.ContainsFunc(account.Holders, func(holder models.AccountHolder) bool {
+			return holder.ClientID == *filter.HolderID
+		}) {
+			continue
+		}
 		if filter.ProductID != nil && account.ProductID != *filter.ProductID {
 			continue
 		}
 		if filter.Status != nil && account.Status != *filter.Status {
 			continue
 		}
+		if filter.MaturesBy != nil && (account.MaturityDate == nil || account.MaturityDate.After(*filter.MaturesBy)) {
+			continue
+		}
+		if filter.Loans && account.Loan == nil {
+			continue
+		}
+		if filter.KYCRestricted && !account.KYCRestricted {
+			continue
+		}
 		ret = append(ret, *account)
 	}
 	return ret, nil
@@ -182,6 +197,16 @@ func (s *kycRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.KYCRec
 	return &copied, nil
 }
 
+func (s *kycRepositoryStub) GetByProviderReference(_ context.Context, provider, reference string) (*models.KYCRecord, error) {
+	for _, record := range s.records {
+		if record.Provider == provider && record.ProviderReference == reference {
+			copied := *record
+			return &copied, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
 func (s *kycRepositoryStub) ListByClient(_ context.Context, clientID uuid.UUID) ([]models.KYCRecord, error) {
 	ret := make([]models.KYCRecord, 0)
 	for _, record := range s.records {
@@ -192,6 +217,23 @@ func (s *kycRepositoryStub) ListByClient(_ context.Context, clientID uuid.UUID)
 	return ret, nil
 }
 
+func (s *kycRepositoryStub) List(_ context.Context, filter repositories.KYCFilter) ([]models.KYCRecord, error) {
+	ret := make([]models.KYCRecord, 0)
+	for _, record := range s.records {
+		if filter.Status != nil && record.Status != *filter.Status {
+			continue
+		}
+		if filter.ExpiresBefore != nil && (record.ExpiresAt == nil || record.ExpiresAt.After(*filter.ExpiresBefore)) {
+			continue
+		}
+		if filter.Unnotified && record.ExpiryNotifiedAt != nil {
+			continue
+		}
+		ret = append(ret, *record)
+	}
+	return ret, nil
+}
+
 type dailyUsageRepositoryStub struct {
 	usages map[string]*models.AccountDailyUsage
 }
@@ -230,6 +272,18 @@ func (s *dailyUsageRepositoryStub) GetForDate(_ context.Context, accountID uuid.
 	return &copied, nil
 }
 
+func (s *dailyUsageRepositoryStub) ListBetween(_ context.Context, accountID uuid.UUID, from, to time.Time) ([]models.AccountDailyUsage, error) {
+	ret := make([]models.AccountDailyUsage, 0)
+	for _, usage := range s.usages {
+		date := usage.UsageDate.UTC().Truncate(24 * time.Hour)
+		if usage.AccountID != accountID || date.Before(from.UTC().Truncate(24*time.Hour)) || date.After(to.UTC().Truncate(24*time.Hour)) {
+			continue
+		}
+		ret = append(ret, *usage)
+	}
+	return ret, nil
+}
+
 func dailyUsageKey(accountID uuid.UUID, usageDate time.Time) string {
 	return accountID.String() + "|" + usageDate.UTC().Truncate(24*time.Hour).Format("2006-01-02")
 }
@@ -281,6 +335,68 @@ func (s *interestAccrualRepositoryStub) ListByAccount(_ context.Context, account
 	return ret, nil
 }
 
+type interestRateRepositoryStub struct {
+	versions []models.InterestRateVersion
+}
+
+func newInterestRateRepositoryStub() *interestRateRepositoryStub {
+	return &interestRateRepositoryStub{}
+}
+
+func (s *interestRateRepositoryStub) Create(_ context.Context, version *models.InterestRateVersion) error {
+	if version.ID == uuid.Nil {
+		version.ID = uuid.New()
+	}
+	s.versions = append(s.versions, *version)
+	return nil
+}
+
+func (s *interestRateRepositoryStub) ListByProduct(_ context.Context, productID uuid.UUID) ([]models.InterestRateVersion, error) {
+	ret := make([]models.InterestRateVersion, 0)
+	for _, version := range s.versions {
+		if version.ProductID == productID {
+			ret = append(ret, version)
+		}
+	}
+	return ret, nil
+}
+
+type taxPostingRepositoryStub struct {
+	postings []models.TaxPosting
+}
+
+func newTaxPostingRepositoryStub() *taxPostingRepositoryStub {
+	return &taxPostingRepositoryStub{}
+}
+
+func (s *taxPostingRepositoryStub) Create(_ context.Context, posting *models.TaxPosting) error {
+	if posting.ID == uuid.Nil {
+		posting.ID = uuid.New()
+	}
+	s.postings = append(s.postings, *posting)
+	return nil
+}
+
+func (s *taxPostingRepositoryStub) GetByInterestReference(_ context.Context, reference string) (*models.TaxPosting, error) {
+	for _, posting := range s.postings {
+		if posting.InterestReference == reference {
+			copied := posting
+			return &copied, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *taxPostingRepositoryStub) ListByAccount(_ context.Context, accountID uuid.UUID) ([]models.TaxPosting, error) {
+	ret := make([]models.TaxPosting, 0)
+	for _, posting := range s.postings {
+		if posting.AccountID == accountID {
+			ret = append(ret, posting)
+		}
+	}
+	return ret, nil
+}
+
 type feePostingRepositoryStub struct {
 	postings map[string]*models.FeePosting
 }
@@ -318,6 +434,33 @@ func (s *feePostingRepositoryStub) GetByReference(_ context.Context, reference s
 	return &copied, nil
 }
 
+func (s *feePostingRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.FeePosting, error) {
+	for _, posting := range s.postings {
+		if posting.ID == id {
+			copied := *posting
+			return &copied, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *feePostingRepositoryStub) List(_ context.Context, filter repositories.FeePostingFilter) ([]models.FeePosting, error) {
+	ret := make([]models.FeePosting, 0)
+	for _, posting := range s.postings {
+		if filter.AccountID != nil && posting.AccountID != *filter.AccountID {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, posting.Status) {
+			continue
+		}
+		if filter.EventType != nil && posting.EventType != *filter.EventType {
+			continue
+		}
+		ret = append(ret, *posting)
+	}
+	return ret, nil
+}
+
 func (s *feePostingRepositoryStub) ListByAccount(_ context.Context, accountID uuid.UUID) ([]models.FeePosting, error) {
 	ret := make([]models.FeePosting, 0)
 	for _, posting := range s.postings {
@@ -338,10 +481,51 @@ func (s *feePostingRepositoryStub) ListPendingRecovery(_ context.Context) ([]mod
 	return ret, nil
 }
 
+type loanInstallmentRepositoryStub struct {
+	installments map[uuid.UUID]*models.LoanInstallment
+}
+
+func newLoanInstallmentRepositoryStub() *loanInstallmentRepositoryStub {
+	return &loanInstallmentRepositoryStub{
+		installments: map[uuid.UUID]*models.LoanInstallment{},
+	}
+}
+
+func (s *loanInstallmentRepositoryStub) Create(_ context.Context, installment *models.LoanInstallment) error {
+	if installment.ID == uuid.Nil {
+		installment.ID = uuid.New()
+	}
+	copied := *installment
+	s.installments[installment.ID] = &copied
+	return nil
+}
+
+func (s *loanInstallmentRepositoryStub) Update(_ context.Context, installment *models.LoanInstallment) error {
+	if _, ok := s.installments[installment.ID]; !ok {
+		return postgres.ErrNotFound
+	}
+	copied := *installment
+	s.installments[installment.ID] = &copied
+	return nil
+}
+
+func (s *loanInstallmentRepositoryStub) ListByAccount(_ context.Context, accountID uuid.UUID) ([]models.LoanInstallment, error) {
+	ret := make([]models.LoanInstallment, 0)
+	for _, installment := range s.installments {
+		if installment.AccountID == accountID {
+			ret = append(ret, *installment)
+		}
+	}
+	slices.SortFunc(ret, func(a, b models.LoanInstallment) int {
+		return a.Number - b.Number
+	})
+	return ret, nil
+}
+
 func TestClientServiceCreateIndividual(t *testing.T) {
 	t.Parallel()
 
-	service := NewClientService(newClientRepositoryStub(), newAccountRepositoryStub())
+	service := NewClientService(newClientRepositoryStub(), newAccountRepositoryStub(), nil)
 	client, err := service.Create(context.Background(), CreateClientInput{
 		Type: "individual",
 		Contact: models.ClientContact{
@@ -363,7 +547,7 @@ func TestClientServiceActivateRequiresVerifiedKYC(t *testing.T) {
 	t.Parallel()
 
 	clientRepo := newClientRepositoryStub()
-	clientService := NewClientService(clientRepo, newAccountRepositoryStub())
+	clientService := NewClientService(clientRepo, newAccountRepositoryStub(), nil)
 
 	client, err := clientService.Create(context.Background(), CreateClientInput{
 		Type: "individual",
@@ -385,9 +569,9 @@ func TestKYCServiceVerifyPromotesClientLevel(t *testing.T) {
 	t.Parallel()
 
 	clientRepo := newClientRepositoryStub()
-	clientService := NewClientService(clientRepo, newAccountRepositoryStub())
+	clientService := NewClientService(clientRepo, newAccountRepositoryStub(), nil)
 	kycRepo := newKYCRepositoryStub()
-	kycService := NewKYCService(clientRepo, kycRepo)
+	kycService := NewKYCService(clientRepo, kycRepo, nil, nil)
 
 	client, err := clientService.Create(context.Background(), CreateClientInput{
 		Type: "individual",
@@ -424,9 +608,9 @@ func TestKYCServiceRejectRequiresReason(t *testing.T) {
 	t.Parallel()
 
 	clientRepo := newClientRepositoryStub()
-	clientService := NewClientService(clientRepo, newAccountRepositoryStub())
+	clientService := NewClientService(clientRepo, newAccountRepositoryStub(), nil)
 	kycRepo := newKYCRepositoryStub()
-	kycService := NewKYCService(clientRepo, kycRepo)
+	kycService := NewKYCService(clientRepo, kycRepo, nil, nil)
 
 	client, err := clientService.Create(context.Background(), CreateClientInput{
 		Type: "individual",
@@ -456,7 +640,7 @@ func TestClientServiceCloseBlockedByOpenAccounts(t *testing.T) {
 
 	clientRepo := newClientRepositoryStub()
 	accountRepo := newAccountRepositoryStub()
-	clientService := NewClientService(clientRepo, accountRepo)
+	clientService := NewClientService(clientRepo, accountRepo, nil)
 
 	client, err := clientService.Create(context.Background(), CreateClientInput{
 		Type: "corporate",
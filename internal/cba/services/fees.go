@@ -17,8 +17,11 @@ import (
 )
 
 var (
-	ErrFeeValidation    = errors.New("fee validation failed")
-	ErrFeeNotApplicable = errors.New("fee is not applicable")
+	ErrFeeValidation      = errors.New("fee validation failed")
+	ErrFeeNotApplicable   = errors.New("fee is not applicable")
+	ErrFeeNoRecovery      = errors.New("no fee recovery possible")
+	ErrFeeResolved        = errors.New("fee posting is already resolved")
+	ErrFeePostingNotFound = errors.New("fee posting not found")
 )
 
 type FeeService interface {
@@ -26,23 +29,53 @@ type FeeService interface {
 	PrepareMaintenanceFee(context.Context, uuid.UUID, time.Time) (*models.FeePosting, error)
 	MarkPosted(context.Context, string) (*models.FeePosting, error)
 	MarkPendingRecovery(context.Context, string, map[string]any) (*models.FeePosting, error)
+	PreparePenaltyFee(context.Context, uuid.UUID, PenaltyTrigger) (*models.FeePosting, error)
+	Get(context.Context, uuid.UUID) (*models.FeePosting, error)
+	List(context.Context, repositories.FeePostingFilter) ([]models.FeePosting, error)
+	PlanRecovery(context.Context, string, int64, time.Time) (*models.FeeRecovery, error)
+	CompleteRecovery(context.Context, string, models.FeeRecovery) (*models.FeePosting, error)
+	AbandonRecovery(context.Context, string, models.FeeRecovery, string) (*models.FeePosting, error)
+	MarkWriteoffRequired(context.Context, string, string) (*models.FeePosting, error)
+	Waive(context.Context, uuid.UUID, ResolveFeeInput) (*models.FeePosting, error)
+	WriteOff(context.Context, uuid.UUID, ResolveFeeInput) (*models.FeePosting, error)
+}
+
+// ResolveFeeInput records who waived or wrote off a fee and why.
+type ResolveFeeInput struct {
+	Reason     string `json:"reason"`
+	ResolvedBy string `json:"resolved_by,omitempty"`
+}
+
+// PenaltyTrigger is an occurrence which may incur a penalty fee. Amount is in
+// atomic units: the balance of the account for minimum_balance, dormancy and
+// early_closure, the debit for insufficient_funds and excess_withdrawals.
+// Reference is the triggering debit; the other penalties are charged at most
+// once per month, or once per account for early_closure.
+type PenaltyTrigger struct {
+	Penalty   string
+	Amount    int64
+	Reference string
+	At        time.Time
 }
 
 type DefaultFeeService struct {
 	accountRepository repositories.AccountRepository
 	productRepository repositories.ProductRepository
 	feeRepository     repositories.FeePostingRepository
+	dailyUsageRepo    repositories.DailyUsageRepository
 }
 
 func NewFeeService(
 	accountRepository repositories.AccountRepository,
 	productRepository repositories.ProductRepository,
 	feeRepository repositories.FeePostingRepository,
+	dailyUsageRepo repositories.DailyUsageRepository,
 ) FeeService {
 	return &DefaultFeeService{
 		accountRepository: accountRepository,
 		productRepository: productRepository,
 		feeRepository:     feeRepository,
+		dailyUsageRepo:    dailyUsageRepo,
 	}
 }
 
@@ -64,7 +97,7 @@ func (s *DefaultFeeService) PrepareTransactionFees(ctx context.Context, accountI
 		if feeRule.Event != event {
 			continue
 		}
-		feeAmount, currency, err := s.calculateTransactionFeeAmount(account.Currency, feeRule, amountMinorUnits)
+		feeAmount, currency, err := calculateTransactionFeeAmount(account.Currency, feeRule, amountMinorUnits)
 		if err != nil {
 			return nil, err
 		}
@@ -183,6 +216,384 @@ func (s *DefaultFeeService) MarkPendingRecovery(ctx context.Context, reference s
 	return posting, nil
 }
 
+func (s *DefaultFeeService) Get(ctx context.Context, id uuid.UUID) (*models.FeePosting, error) {
+	posting, err := s.feeRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveFeePostingNotFound(err)
+	}
+	return posting, nil
+}
+
+func (s *DefaultFeeService) List(ctx context.Context, filter repositories.FeePostingFilter) ([]models.FeePosting, error) {
+	return s.feeRepository.List(ctx, filter)
+}
+
+// PlanRecovery plans collecting as much of an outstanding fee as the
+// available balance allows above the product minimum balance. After an
+// unsuccessful attempt the fee is only retried once the account has been
+// credited. A planned recovery is returned again until it is completed.
+func (s *DefaultFeeService) PlanRecovery(ctx context.Context, reference string, available int64, when time.Time) (*models.FeeRecovery, error) {
+	posting, err := s.feeRepository.GetByReference(ctx, reference)
+	if err != nil {
+		return nil, resolveFeeRepositoryError(err)
+	}
+	if posting.Recovery != nil {
+		return posting.Recovery, nil
+	}
+	if posting.Status != models.FeePostingStatusPendingRecovery {
+		return nil, fmt.Errorf("%w: fee posting is %s", ErrFeeValidation, posting.Status)
+	}
+	account, product, err := s.loadAccountAndProduct(ctx, posting.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	when = normalizeUsageDate(when)
+	credited, err := s.creditedSinceLastAttempt(ctx, posting, when)
+	if err != nil {
+		return nil, err
+	}
+	if !credited {
+		return nil, ErrFeeNoRecovery
+	}
+
+	outstanding, _, err := decimalToMinorHalfUp(FeeOutstanding(*posting), posting.Currency)
+	if err != nil {
+		return nil, err
+	}
+	recovered, _, err := decimalToMinorHalfUp(posting.RecoveredAmount, posting.Currency)
+	if err != nil {
+		return nil, err
+	}
+	var funds int64
+	if recoverableAccount(account) && posting.Currency == account.Currency {
+		minBalance, err := ruleAmountToAtomic(product.Rules.MinBalance, account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		funds = available - max(minBalance, 0)
+	}
+	amount := min(funds, outstanding)
+	if amount <= 0 {
+		if posting.Metadata == nil {
+			posting.Metadata = map[string]any{}
+		}
+		posting.Metadata["recovery_attempted"] = when.Format(time.RFC3339)
+		if err := s.feeRepository.Update(ctx, posting); err != nil {
+			return nil, err
+		}
+		return nil, ErrFeeNoRecovery
+	}
+
+	posting.Recovery = &models.FeeRecovery{
+		Reference: fmt.Sprintf("%s:recovery:%d", posting.Reference, recovered),
+		Date:      when,
+		Amount:    amount,
+	}
+	if err := s.feeRepository.Update(ctx, posting); err != nil {
+		return nil, err
+	}
+	return posting.Recovery, nil
+}
+
+// CompleteRecovery applies a planned recovery once it was collected. The fee
+// is posted when nothing is left outstanding. Completing a recovery twice is a
+// no-op.
+func (s *DefaultFeeService) CompleteRecovery(ctx context.Context, reference string, recovery models.FeeRecovery) (*models.FeePosting, error) {
+	posting, err := s.feeRepository.GetByReference(ctx, reference)
+	if err != nil {
+		return nil, resolveFeeRepositoryError(err)
+	}
+	if posting.Recovery == nil || posting.Recovery.Reference != recovery.Reference {
+		return posting, nil
+	}
+
+	posting.RecoveredAmount = posting.RecoveredAmount.Add(minorUnitsToDecimal(posting.Recovery.Amount, posting.Currency))
+	posting.Recovery = nil
+	if posting.Metadata == nil {
+		posting.Metadata = map[string]any{}
+	}
+	posting.Metadata["recovered_at"] = recovery.Date.Format(time.DateOnly)
+	delete(posting.Metadata, "recovery_error")
+	if !FeeOutstanding(*posting).IsPositive() {
+		posting.Status = models.FeePostingStatusPosted
+	}
+	if err := s.feeRepository.Update(ctx, posting); err != nil {
+		return nil, err
+	}
+	return posting, nil
+}
+
+// AbandonRecovery drops a planned recovery which was not collected. The fee
+// is retried once the account is credited. Abandoning a recovery which is no
+// longer planned is a no-op.
+func (s *DefaultFeeService) AbandonRecovery(ctx context.Context, reference string, recovery models.FeeRecovery, reason string) (*models.FeePosting, error) {
+	posting, err := s.feeRepository.GetByReference(ctx, reference)
+	if err != nil {
+		return nil, resolveFeeRepositoryError(err)
+	}
+	if posting.Recovery == nil || posting.Recovery.Reference != recovery.Reference {
+		return posting, nil
+	}
+
+	posting.Recovery = nil
+	if posting.Metadata == nil {
+		posting.Metadata = map[string]any{}
+	}
+	posting.Metadata["recovery_attempted"] = recovery.Date.Format(time.RFC3339)
+	posting.Metadata["recovery_error"] = reason
+	if err := s.feeRepository.Update(ctx, posting); err != nil {
+		return nil, err
+	}
+	return posting, nil
+}
+
+// MarkWriteoffRequired escalates a fee which could not be recovered so that
+// it gets waived or written off.
+func (s *DefaultFeeService) MarkWriteoffRequired(ctx context.Context, reference, reason string) (*models.FeePosting, error) {
+	posting, err := s.feeRepository.GetByReference(ctx, reference)
+	if err != nil {
+		return nil, resolveFeeRepositoryError(err)
+	}
+	switch {
+	case posting.Status == models.FeePostingStatusWriteoffRequired:
+		return posting, nil
+	case posting.Status != models.FeePostingStatusPendingRecovery:
+		return nil, fmt.Errorf("%w: fee posting is %s", ErrFeeResolved, posting.Status)
+	case posting.Recovery != nil:
+		return nil, fmt.Errorf("%w: a recovery is in progress", ErrFeeValidation)
+	}
+
+	posting.Status = models.FeePostingStatusWriteoffRequired
+	if posting.Metadata == nil {
+		posting.Metadata = map[string]any{}
+	}
+	posting.Metadata["writeoff_reason"] = reason
+	if err := s.feeRepository.Update(ctx, posting); err != nil {
+		return nil, err
+	}
+	return posting, nil
+}
+
+// Waive cancels what is left outstanding of a fee. Waiving a waived fee is a
+// no-op.
+func (s *DefaultFeeService) Waive(ctx context.Context, id uuid.UUID, input ResolveFeeInput) (*models.FeePosting, error) {
+	return s.resolve(ctx, id, models.FeePostingStatusWaived, input)
+}
+
+// WriteOff writes off what is left outstanding of a fee as a bad debt. Writing
+// off a written off fee is a no-op so that the bad debt entry can be retried.
+func (s *DefaultFeeService) WriteOff(ctx context.Context, id uuid.UUID, input ResolveFeeInput) (*models.FeePosting, error) {
+	return s.resolve(ctx, id, models.FeePostingStatusWrittenOff, input)
+}
+
+func (s *DefaultFeeService) resolve(ctx context.Context, id uuid.UUID, status string, input ResolveFeeInput) (*models.FeePosting, error) {
+	posting, err := s.feeRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveFeePostingNotFound(err)
+	}
+	switch posting.Status {
+	case status:
+		return posting, nil
+	case models.FeePostingStatusPendingRecovery, models.FeePostingStatusWriteoffRequired:
+	default:
+		return nil, fmt.Errorf("%w: fee posting is %s", ErrFeeResolved, posting.Status)
+	}
+	if posting.Recovery != nil {
+		return nil, fmt.Errorf("%w: a recovery is in progress", ErrFeeValidation)
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrFeeValidation)
+	}
+
+	posting.Status = status
+	if posting.Metadata == nil {
+		posting.Metadata = map[string]any{}
+	}
+	posting.Metadata["resolution_reason"] = reason
+	posting.Metadata["resolved_at"] = time.Now().UTC().Format(time.RFC3339)
+	if input.ResolvedBy != "" {
+		posting.Metadata["resolved_by"] = input.ResolvedBy
+	}
+	if err := s.feeRepository.Update(ctx, posting); err != nil {
+		return nil, err
+	}
+	return posting, nil
+}
+
+// creditedSinceLastAttempt reports whether the account was credited since the
+// last unsuccessful recovery attempt, if any.
+func (s *DefaultFeeService) creditedSinceLastAttempt(ctx context.Context, posting *models.FeePosting, when time.Time) (bool, error) {
+	raw, _ := posting.Metadata["recovery_attempted"].(string)
+	attempted, err := time.Parse(time.RFC3339, raw)
+	if err != nil || s.dailyUsageRepo == nil {
+		return true, nil
+	}
+	usages, err := s.dailyUsageRepo.ListBetween(ctx, posting.AccountID, normalizeUsageDate(attempted), when)
+	if err != nil {
+		return false, err
+	}
+	for _, usage := range usages {
+		if usage.CreditCount > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FeeOutstanding returns the part of a fee which was not recovered yet.
+func FeeOutstanding(posting models.FeePosting) decimal.Decimal {
+	return posting.Amount.Sub(posting.RecoveredAmount)
+}
+
+// recoverableAccount reports whether fees can be collected from the account.
+// Dormant accounts remain chargeable.
+func recoverableAccount(account *models.Account) bool {
+	switch account.Status {
+	case models.AccountStatusActive, models.AccountStatusDormant:
+		return !account.FreezeDebits
+	default:
+		return false
+	}
+}
+
+// PreparePenaltyFee records the penalty fee incurred by trigger, if any, as
+// a fee posting pending recovery. An early closure penalty the closing
+// balance cannot cover is recorded as requiring a write-off since the account
+// is about to be closed. Preparing the same penalty twice returns the recorded
+// posting.
+func (s *DefaultFeeService) PreparePenaltyFee(ctx context.Context, accountID uuid.UUID, trigger PenaltyTrigger) (*models.FeePosting, error) {
+	account, product, err := s.loadAccountAndProduct(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := penaltyFeeRule(product.FeeSchedule, trigger.Penalty)
+	if err != nil {
+		return nil, err
+	}
+	at := normalizeUsageDate(trigger.At)
+	assessedOn, linkedReference, err := s.assessPenalty(ctx, account, product, trigger, at)
+	if err != nil {
+		return nil, err
+	}
+
+	reference := penaltyFeeReference(trigger.Penalty, linkedReference)
+	if existing, err := s.feeRepository.GetByReference(ctx, reference); err == nil {
+		return existing, nil
+	} else if !postgres.IsNotFoundError(err) && !errors.Is(err, postgres.ErrNotFound) {
+		return nil, err
+	}
+
+	feeAmount, currency, err := calculateTransactionFeeAmount(account.Currency, *rule, assessedOn)
+	if err != nil {
+		return nil, err
+	}
+	if feeAmount.LessThanOrEqual(decimal.Zero) {
+		return nil, ErrFeeNotApplicable
+	}
+
+	posting := &models.FeePosting{
+		ID:              uuid.New(),
+		AccountID:       account.ID,
+		EventType:       trigger.Penalty,
+		Reference:       reference,
+		LinkedReference: linkedReference,
+		Amount:          feeAmount,
+		Currency:        currency,
+		Status:          models.FeePostingStatusPendingRecovery,
+		Metadata: map[string]any{
+			"fee_type":    rule.Type,
+			"fee_event":   rule.Event,
+			"account_id":  account.ID.String(),
+			"assessed_on": assessedOn,
+			"assessed_at": at.Format(time.DateOnly),
+		},
+	}
+	if trigger.Penalty == models.PenaltyEarlyClosure {
+		amount, _, err := decimalToMinorHalfUp(feeAmount, currency)
+		if err != nil {
+			return nil, err
+		}
+		if currency != account.Currency || amount > trigger.Amount {
+			posting.Status = models.FeePostingStatusWriteoffRequired
+			posting.Metadata["writeoff_reason"] = "closing balance does not cover the penalty"
+		}
+	}
+	if err := s.feeRepository.Create(ctx, posting); err != nil {
+		return nil, err
+	}
+	return posting, nil
+}
+
+// assessPenalty checks whether trigger incurs its penalty and returns the
+// amount the penalty is assessed on with the reference it is charged under.
+func (s *DefaultFeeService) assessPenalty(ctx context.Context, account *models.Account, product *models.Product, trigger PenaltyTrigger, at time.Time) (int64, string, error) {
+	penalty := product.FeeSchedule.PenaltyFees[trigger.Penalty]
+	monthly := fmt.Sprintf("%s:%s", account.ID, at.Format("2006-01"))
+
+	switch trigger.Penalty {
+	case models.PenaltyMinimumBalance:
+		minBalance, err := ruleAmountToAtomic(product.Rules.MinBalance, account.Currency)
+		if err != nil {
+			return 0, "", err
+		}
+		if trigger.Amount >= minBalance {
+			return 0, "", ErrFeeNotApplicable
+		}
+		return minBalance - trigger.Amount, monthly, nil
+	case models.PenaltyDormancy:
+		if account.Status != models.AccountStatusDormant {
+			return 0, "", ErrFeeNotApplicable
+		}
+		return max(trigger.Amount, 0), monthly, nil
+	case models.PenaltyInsufficientFunds:
+		if trigger.Reference == "" {
+			return 0, "", fmt.Errorf("%w: reference is required", ErrFeeValidation)
+		}
+		return trigger.Amount, trigger.Reference, nil
+	case models.PenaltyExcessWithdrawals:
+		if trigger.Reference == "" {
+			return 0, "", fmt.Errorf("%w: reference is required", ErrFeeValidation)
+		}
+		withdrawals, err := s.countWithdrawals(ctx, account.ID, penalty.Period, at)
+		if err != nil {
+			return 0, "", err
+		}
+		if withdrawals <= int64(penalty.FreeWithdrawals) {
+			return 0, "", ErrFeeNotApplicable
+		}
+		return trigger.Amount, trigger.Reference, nil
+	case models.PenaltyEarlyClosure:
+		if at.After(normalizeUsageDate(account.OpenedAt).AddDate(0, 0, penalty.WithinDays)) {
+			return 0, "", ErrFeeNotApplicable
+		}
+		return max(trigger.Amount, 0), account.ID.String(), nil
+	default:
+		return 0, "", fmt.Errorf("%w: %s penalty fees are not prepared by the fee service", ErrFeeValidation, trigger.Penalty)
+	}
+}
+
+// countWithdrawals counts the debits recorded in the daily or monthly period
+// containing at.
+func (s *DefaultFeeService) countWithdrawals(ctx context.Context, accountID uuid.UUID, period string, at time.Time) (int64, error) {
+	if s.dailyUsageRepo == nil {
+		return 0, nil
+	}
+	from := at
+	if period == models.PenaltyPeriodMonthly {
+		from = time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	usages, err := s.dailyUsageRepo.ListBetween(ctx, accountID, from, at)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	for _, usage := range usages {
+		count += usage.DebitCount
+	}
+	return count, nil
+}
+
 func (s *DefaultFeeService) loadAccountAndProduct(ctx context.Context, id uuid.UUID) (*models.Account, *models.Product, error) {
 	account, err := s.accountRepository.Get(ctx, id)
 	if err != nil {
@@ -195,7 +606,7 @@ func (s *DefaultFeeService) loadAccountAndProduct(ctx context.Context, id uuid.U
 	return account, product, nil
 }
 
-func (s *DefaultFeeService) calculateTransactionFeeAmount(accountCurrency string, rule models.TransactionFee, amountMinorUnits int64) (decimal.Decimal, string, error) {
+func calculateTransactionFeeAmount(accountCurrency string, rule models.TransactionFee, amountMinorUnits int64) (decimal.Decimal, string, error) {
 	currency := strings.TrimSpace(rule.Currency)
 	if currency == "" {
 		currency = accountCurrency
@@ -241,6 +652,26 @@ func (s *DefaultFeeService) calculateTransactionFeeAmount(accountCurrency string
 	return feeAmount.RoundBank(8), currency, nil
 }
 
+// penaltyFeeRule returns the charge of the penalty fee configured under name
+// as a fee rule keyed by the penalty instead of a transaction event.
+func penaltyFeeRule(schedule *models.FeeSchedule, name string) (*models.TransactionFee, error) {
+	if schedule == nil {
+		return nil, ErrFeeNotApplicable
+	}
+	penalty, ok := schedule.PenaltyFees[name]
+	if !ok {
+		return nil, ErrFeeNotApplicable
+	}
+	return &models.TransactionFee{
+		Event:    name,
+		Type:     penalty.Type,
+		Value:    penalty.Value,
+		Min:      penalty.Min,
+		Max:      penalty.Max,
+		Currency: penalty.Currency,
+	}, nil
+}
+
 func isMaintenanceBoundary(frequency string, scheduledFor time.Time) bool {
 	if !isMonthEnd(normalizeUsageDate(scheduledFor)) {
 		return false
@@ -263,3 +694,12 @@ func resolveFeeRepositoryError(err error) error {
 		return err
 	}
 }
+
+func resolveFeePostingNotFound(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrFeePostingNotFound
+	default:
+		return err
+	}
+}
@@ -2,13 +2,16 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 
 	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
 )
 
 func TestFeeServicePrepareTransactionFeesPercentageWithBounds(t *testing.T) {
@@ -17,7 +20,7 @@ func TestFeeServicePrepareTransactionFeesPercentageWithBounds(t *testing.T) {
 	accountRepo := newAccountRepositoryStub()
 	productRepo := newProductRepositoryStub()
 	feeRepo := newFeePostingRepositoryStub()
-	service := NewFeeService(accountRepo, productRepo, feeRepo)
+	service := NewFeeService(accountRepo, productRepo, feeRepo, newDailyUsageRepositoryStub())
 
 	productID := uuid.New()
 	min := "1.50"
@@ -65,7 +68,7 @@ func TestFeeServicePrepareMaintenanceFee(t *testing.T) {
 	accountRepo := newAccountRepositoryStub()
 	productRepo := newProductRepositoryStub()
 	feeRepo := newFeePostingRepositoryStub()
-	service := NewFeeService(accountRepo, productRepo, feeRepo)
+	service := NewFeeService(accountRepo, productRepo, feeRepo, newDailyUsageRepositoryStub())
 
 	productID := uuid.New()
 	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
@@ -105,7 +108,7 @@ func TestFeeServiceMarkPosted(t *testing.T) {
 	accountRepo := newAccountRepositoryStub()
 	productRepo := newProductRepositoryStub()
 	feeRepo := newFeePostingRepositoryStub()
-	service := NewFeeService(accountRepo, productRepo, feeRepo)
+	service := NewFeeService(accountRepo, productRepo, feeRepo, newDailyUsageRepositoryStub())
 
 	require.NoError(t, feeRepo.Create(context.Background(), &models.FeePosting{
 		ID:        uuid.New(),
@@ -118,3 +121,246 @@ func TestFeeServiceMarkPosted(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, models.FeePostingStatusPosted, posting.Status)
 }
+
+func TestFeeServicePreparePenaltyFee(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	feeRepo := newFeePostingRepositoryStub()
+	dailyUsageRepo := newDailyUsageRepositoryStub()
+	service := NewFeeService(accountRepo, productRepo, feeRepo, dailyUsageRepo)
+
+	productID := uuid.New()
+	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
+		ID:       productID,
+		Code:     "SAV-USD-PEN",
+		Name:     "Savings With Penalties",
+		Category: "savings",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			MinBalance: "100.00",
+		},
+		FeeSchedule: &models.FeeSchedule{
+			PenaltyFees: map[string]models.PenaltyFee{
+				models.PenaltyMinimumBalance:    {Type: "percentage", Value: "10"},
+				models.PenaltyInsufficientFunds: {Type: "flat", Value: "15.00"},
+				models.PenaltyExcessWithdrawals: {Type: "flat", Value: "2.00", FreeWithdrawals: 3, Period: models.PenaltyPeriodMonthly},
+				models.PenaltyEarlyClosure:      {Type: "flat", Value: "25.00", WithinDays: 90},
+			},
+		},
+	}))
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "3000000003",
+		ProductID:     productID,
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "wallet-fee-3",
+		OpenedAt:      time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+	at := time.Date(2026, 5, 20, 0, 0, 0, 0, time.UTC)
+
+	// The minimum balance penalty is assessed on the shortfall, once a month.
+	_, err := service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyMinimumBalance, Amount: 10_000, At: at})
+	require.ErrorIs(t, err, ErrFeeNotApplicable)
+	posting, err := service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyMinimumBalance, Amount: 4_000, At: at})
+	require.NoError(t, err)
+	require.Equal(t, "6", posting.Amount.String())
+	require.Equal(t, "penalty:minimum_balance:"+account.ID.String()+":2026-05", posting.Reference)
+	again, err := service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyMinimumBalance, Amount: 1_000, At: at.AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	require.Equal(t, posting.ID, again.ID)
+
+	_, err = service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyInsufficientFunds, Amount: 50_000, At: at})
+	require.ErrorIs(t, err, ErrFeeValidation)
+	posting, err = service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyInsufficientFunds, Amount: 50_000, Reference: "debit-1", At: at})
+	require.NoError(t, err)
+	require.Equal(t, "15", posting.Amount.String())
+	require.Equal(t, models.FeePostingStatusPendingRecovery, posting.Status)
+
+	// Withdrawals are only penalised past the free allowance of the month.
+	require.NoError(t, dailyUsageRepo.Create(context.Background(), &models.AccountDailyUsage{AccountID: account.ID, UsageDate: at.AddDate(0, 0, -5), DebitCount: 2}))
+	require.NoError(t, dailyUsageRepo.Create(context.Background(), &models.AccountDailyUsage{AccountID: account.ID, UsageDate: at, DebitCount: 1}))
+	_, err = service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyExcessWithdrawals, Amount: 1_000, Reference: "debit-2", At: at})
+	require.ErrorIs(t, err, ErrFeeNotApplicable)
+	require.NoError(t, dailyUsageRepo.Update(context.Background(), &models.AccountDailyUsage{AccountID: account.ID, UsageDate: at, DebitCount: 2}))
+	posting, err = service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyExcessWithdrawals, Amount: 1_000, Reference: "debit-3", At: at})
+	require.NoError(t, err)
+	require.Equal(t, "penalty:excess_withdrawals:debit-3", posting.Reference)
+
+	// An early closure the closing balance cannot cover is written off.
+	_, err = service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyEarlyClosure, Amount: 1_000, At: account.OpenedAt.AddDate(0, 0, 91)})
+	require.ErrorIs(t, err, ErrFeeNotApplicable)
+	posting, err = service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyEarlyClosure, Amount: 1_000, At: at})
+	require.NoError(t, err)
+	require.Equal(t, models.FeePostingStatusWriteoffRequired, posting.Status)
+
+	_, err = service.PreparePenaltyFee(context.Background(), account.ID, PenaltyTrigger{Penalty: models.PenaltyDormancy, Amount: 1_000, At: at})
+	require.ErrorIs(t, err, ErrFeeNotApplicable)
+}
+
+func TestFeeScheduleDropsLegacyPenaltyFees(t *testing.T) {
+	t.Parallel()
+
+	// Products saved before penalty fees were typed hold free-form entries.
+	schedule := &models.FeeSchedule{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"penalty_fees": {
+			"early_withdrawal": {"type": "flat", "value": "10.00"},
+			"minimum_balance": "5.00",
+			"dormancy": {"note": "to be decided"},
+			"late_payment": {"type": "flat", "value": "2.00"}
+		}
+	}`), schedule))
+	require.Equal(t, models.PenaltyFees{
+		models.PenaltyEarlyWithdrawal: {Type: "flat", Value: "10.00"},
+	}, schedule.PenaltyFees)
+	require.NoError(t, validateFeeSchedule(schedule))
+}
+
+func TestFeeServiceRecoveryLifecycle(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	feeRepo := newFeePostingRepositoryStub()
+	dailyUsageRepo := newDailyUsageRepositoryStub()
+	service := NewFeeService(accountRepo, productRepo, feeRepo, dailyUsageRepo)
+
+	productID := uuid.New()
+	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
+		ID:       productID,
+		Code:     "CUR-USD-REC",
+		Name:     "Current Recovery",
+		Category: "current",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			MinBalance: "5.00",
+		},
+	}))
+	account := &models.Account{
+		ID:            uuid.New(),
+		AccountNumber: "3000000004",
+		ProductID:     productID,
+		Currency:      "USD",
+		Status:        models.AccountStatusActive,
+		WalletID:      "wallet-fee-4",
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+	posting := &models.FeePosting{
+		ID:        uuid.New(),
+		AccountID: account.ID,
+		EventType: "maintenance",
+		Reference: "maintenance:" + account.ID.String() + ":2026-04-30",
+		Amount:    decimal.RequireFromString("10.00"),
+		Currency:  "USD",
+		Status:    models.FeePostingStatusPendingRecovery,
+	}
+	require.NoError(t, feeRepo.Create(context.Background(), posting))
+	at := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)
+
+	// Only what is above the minimum balance is recovered.
+	recovery, err := service.PlanRecovery(context.Background(), posting.Reference, 800, at)
+	require.NoError(t, err)
+	require.EqualValues(t, 300, recovery.Amount)
+	require.Equal(t, posting.Reference+":recovery:0", recovery.Reference)
+	retried, err := service.PlanRecovery(context.Background(), posting.Reference, 5_000, at)
+	require.NoError(t, err)
+	require.Equal(t, recovery, retried)
+
+	_, err = service.Waive(context.Background(), posting.ID, ResolveFeeInput{Reason: "goodwill"})
+	require.ErrorIs(t, err, ErrFeeValidation)
+
+	updated, err := service.CompleteRecovery(context.Background(), posting.Reference, *recovery)
+	require.NoError(t, err)
+	require.Equal(t, "3", updated.RecoveredAmount.String())
+	require.Equal(t, models.FeePostingStatusPendingRecovery, updated.Status)
+	updated, err = service.CompleteRecovery(context.Background(), posting.Reference, *recovery)
+	require.NoError(t, err)
+	require.Equal(t, "3", updated.RecoveredAmount.String())
+
+	// Once nothing is left, the fee waits for the account to be credited.
+	_, err = service.PlanRecovery(context.Background(), posting.Reference, 500, at)
+	require.ErrorIs(t, err, ErrFeeNoRecovery)
+	_, err = service.PlanRecovery(context.Background(), posting.Reference, 5_000, at.AddDate(0, 0, 1))
+	require.ErrorIs(t, err, ErrFeeNoRecovery)
+	require.NoError(t, dailyUsageRepo.Create(context.Background(), &models.AccountDailyUsage{AccountID: account.ID, UsageDate: at.AddDate(0, 0, 2), CreditCount: 1}))
+	recovery, err = service.PlanRecovery(context.Background(), posting.Reference, 5_000, at.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.EqualValues(t, 700, recovery.Amount)
+	require.Equal(t, posting.Reference+":recovery:300", recovery.Reference)
+
+	// A recovery which was not collected is planned again.
+	abandoned, err := service.AbandonRecovery(context.Background(), posting.Reference, *recovery, "account debits are frozen")
+	require.NoError(t, err)
+	require.Nil(t, abandoned.Recovery)
+	require.Equal(t, "account debits are frozen", abandoned.Metadata["recovery_error"])
+	recovery, err = service.PlanRecovery(context.Background(), posting.Reference, 5_000, at.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	require.EqualValues(t, 700, recovery.Amount)
+
+	updated, err = service.CompleteRecovery(context.Background(), posting.Reference, *recovery)
+	require.NoError(t, err)
+	require.Equal(t, models.FeePostingStatusPosted, updated.Status)
+	require.True(t, FeeOutstanding(*updated).IsZero())
+}
+
+func TestFeeServiceWaiveAndWriteOff(t *testing.T) {
+	t.Parallel()
+
+	feeRepo := newFeePostingRepositoryStub()
+	service := NewFeeService(newAccountRepositoryStub(), newProductRepositoryStub(), feeRepo, newDailyUsageRepositoryStub())
+
+	accountID := uuid.New()
+	aged := &models.FeePosting{
+		ID:              uuid.New(),
+		AccountID:       accountID,
+		EventType:       "maintenance",
+		Reference:       "maintenance:" + accountID.String() + ":2026-01-31",
+		Amount:          decimal.RequireFromString("10.00"),
+		RecoveredAmount: decimal.RequireFromString("4.00"),
+		Currency:        "USD",
+		Status:          models.FeePostingStatusPendingRecovery,
+	}
+	waived := &models.FeePosting{
+		ID:        uuid.New(),
+		AccountID: accountID,
+		EventType: models.PenaltyInsufficientFunds,
+		Reference: "penalty:insufficient_funds:debit-1",
+		Amount:    decimal.RequireFromString("15.00"),
+		Currency:  "USD",
+		Status:    models.FeePostingStatusPendingRecovery,
+	}
+	require.NoError(t, feeRepo.Create(context.Background(), aged))
+	require.NoError(t, feeRepo.Create(context.Background(), waived))
+
+	posting, err := service.MarkWriteoffRequired(context.Background(), aged.Reference, "outstanding for more than 90 days")
+	require.NoError(t, err)
+	require.Equal(t, models.FeePostingStatusWriteoffRequired, posting.Status)
+
+	_, err = service.WriteOff(context.Background(), aged.ID, ResolveFeeInput{})
+	require.ErrorIs(t, err, ErrFeeValidation)
+	posting, err = service.WriteOff(context.Background(), aged.ID, ResolveFeeInput{Reason: "uncollectable", ResolvedBy: "ops"})
+	require.NoError(t, err)
+	require.Equal(t, models.FeePostingStatusWrittenOff, posting.Status)
+	require.Equal(t, "6", FeeOutstanding(*posting).String())
+	require.Equal(t, "ops", posting.Metadata["resolved_by"])
+	_, err = service.WriteOff(context.Background(), aged.ID, ResolveFeeInput{Reason: "uncollectable"})
+	require.NoError(t, err)
+	_, err = service.Waive(context.Background(), aged.ID, ResolveFeeInput{Reason: "goodwill"})
+	require.ErrorIs(t, err, ErrFeeResolved)
+
+	posting, err = service.Waive(context.Background(), waived.ID, ResolveFeeInput{Reason: "goodwill"})
+	require.NoError(t, err)
+	require.Equal(t, models.FeePostingStatusWaived, posting.Status)
+
+	postings, err := service.List(context.Background(), repositories.FeePostingFilter{Statuses: []string{models.FeePostingStatusWaived}})
+	require.NoError(t, err)
+	require.Len(t, postings, 1)
+	_, err = service.Get(context.Background(), uuid.New())
+	require.ErrorIs(t, err, ErrFeePostingNotFound)
+}
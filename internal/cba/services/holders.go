@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+)
+
+var ErrAccountAuthorizationRequired = errors.New("account authorization required")
+
+type AddAccountHolderInput struct {
+	ClientID     uuid.UUID `json:"client_id"`
+	Role         string    `json:"role"`
+	SigningLimit *string   `json:"signing_limit,omitempty"`
+}
+
+type SetSigningRuleInput struct {
+	SigningRule string `json:"signing_rule"`
+}
+
+type AccountHolderService interface {
+	AddHolder(context.Context, uuid.UUID, AddAccountHolderInput) (*models.Account, error)
+	RemoveHolder(context.Context, uuid.UUID, uuid.UUID) (*models.Account, error)
+	SetSigningRule(context.Context, uuid.UUID, SetSigningRuleInput) (*models.Account, error)
+	// AuthorizeDebit checks the clients signing a debit or a lien of the
+	// given amount satisfy the signing rule of the account.
+	AuthorizeDebit(context.Context, uuid.UUID, int64, []uuid.UUID) error
+}
+
+type DefaultAccountHolderService struct {
+	accountRepository repositories.AccountRepository
+	clientRepository  repositories.ClientRepository
+}
+
+func NewAccountHolderService(
+	accountRepository repositories.AccountRepository,
+	clientRepository repositories.ClientRepository,
+) AccountHolderService {
+	return &DefaultAccountHolderService{
+		accountRepository: accountRepository,
+		clientRepository:  clientRepository,
+	}
+}
+
+// AddHolder adds a secondary or mandate holder to the account. Joint accounts
+// default to any one of their holders signing the debits.
+func (s *DefaultAccountHolderService) AddHolder(ctx context.Context, accountID uuid.UUID, input AddAccountHolderInput) (*models.Account, error) {
+	account, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	if account.Status == models.AccountStatusClosed {
+		return nil, fmt.Errorf("%w: cannot add a holder to a closed account", ErrAccountInvalidStateTransition)
+	}
+
+	switch input.Role {
+	case models.AccountHolderRoleSecondary, models.AccountHolderRoleMandate:
+	default:
+		return nil, fmt.Errorf("%w: holder role must be %s or %s", ErrAccountValidation, models.AccountHolderRoleSecondary, models.AccountHolderRoleMandate)
+	}
+	if input.SigningLimit != nil {
+		limit := strings.TrimSpace(*input.SigningLimit)
+		amount, err := currencyregistry.ParseAmount(limit, account.Currency)
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("%w: invalid signing_limit", ErrAccountValidation)
+		}
+		input.SigningLimit = &limit
+	}
+
+	holders := accountHolders(account)
+	if slices.ContainsFunc(holders, func(holder models.AccountHolder) bool {
+		return holder.ClientID == input.ClientID
+	}) {
+		return nil, fmt.Errorf("%w: client %s already holds the account", ErrAccountValidation, input.ClientID)
+	}
+	client, err := s.clientRepository.Get(ctx, input.ClientID)
+	if err != nil {
+		return nil, resolveClientRepositoryError(err)
+	}
+	if client.Status != models.ClientStatusActive {
+		return nil, fmt.Errorf("%w: client must be active to hold an account", ErrAccountValidation)
+	}
+
+	account.Holders = append(holders, models.AccountHolder{
+		ClientID:     client.ID,
+		Role:         input.Role,
+		SigningLimit: input.SigningLimit,
+		AddedAt:      time.Now().UTC(),
+	})
+	if account.SigningRule == "" {
+		account.SigningRule = models.SigningRuleAnyOne
+	}
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
+func (s *DefaultAccountHolderService) RemoveHolder(ctx context.Context, accountID, clientID uuid.UUID) (*models.Account, error) {
+	account, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	if clientID == account.ClientID {
+		return nil, fmt.Errorf("%w: the primary holder cannot be removed", ErrAccountValidation)
+	}
+
+	holders := accountHolders(account)
+	index := slices.IndexFunc(holders, func(holder models.AccountHolder) bool {
+		return holder.ClientID == clientID
+	})
+	if index < 0 {
+		return nil, fmt.Errorf("%w: client %s does not hold the account", ErrAccountValidation, clientID)
+	}
+	account.Holders = slices.Delete(holders, index, index+1)
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
+func (s *DefaultAccountHolderService) SetSigningRule(ctx context.Context, accountID uuid.UUID, input SetSigningRuleInput) (*models.Account, error) {
+	switch input.SigningRule {
+	case "", models.SigningRuleAnyOne, models.SigningRuleAll:
+	default:
+		return nil, fmt.Errorf("%w: signing_rule must be %s or %s", ErrAccountValidation, models.SigningRuleAnyOne, models.SigningRuleAll)
+	}
+
+	account, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	if account.Status == models.AccountStatusClosed {
+		return nil, fmt.Errorf("%w: cannot change the signing rule of a closed account", ErrAccountInvalidStateTransition)
+	}
+	if input.SigningRule == "" && len(accountHolders(account)) > 1 {
+		return nil, fmt.Errorf("%w: joint accounts require a signing rule", ErrAccountValidation)
+	}
+
+	account.SigningRule = input.SigningRule
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
+// accountSigner is a client allowed to sign the debits of an account. The
+// authorized signatories of a corporate holder sign on its behalf.
+type accountSigner struct {
+	clientID string
+	limit    *string
+	required bool
+}
+
+func (s *DefaultAccountHolderService) AuthorizeDebit(ctx context.Context, accountID uuid.UUID, amount int64, signatories []uuid.UUID) error {
+	account, err := s.accountRepository.Get(ctx, accountID)
+	if err != nil {
+		return resolveAccountRepositoryError(err)
+	}
+
+	signers := make([]accountSigner, 0)
+	corporate := false
+	for _, holder := range accountHolders(account) {
+		required := holder.Role != models.AccountHolderRoleMandate
+		client, err := s.clientRepository.Get(ctx, holder.ClientID)
+		if err != nil {
+			return resolveClientRepositoryError(err)
+		}
+		if client.Type == models.ClientTypeCorporate && client.CorporateData != nil && len(client.CorporateData.AuthorizedSignatories) > 0 {
+			corporate = true
+			for _, signatory := range client.CorporateData.AuthorizedSignatories {
+				signer := accountSigner{clientID: signatory.ClientID, required: required}
+				if signatory.SigningLimit != "" {
+					signer.limit = &signatory.SigningLimit
+				}
+				signers = append(signers, signer)
+			}
+			continue
+		}
+		signers = append(signers, accountSigner{
+			clientID: holder.ClientID.String(),
+			limit:    holder.SigningLimit,
+			required: required,
+		})
+	}
+
+	rule := account.SigningRule
+	if rule == "" {
+		if !corporate {
+			return nil
+		}
+		rule = models.SigningRuleAnyOne
+	}
+	if len(signatories) == 0 {
+		return fmt.Errorf("%w: the account requires the signature of its holders", ErrAccountAuthorizationRequired)
+	}
+
+	// Signatories signing above their limit do not count towards the rule.
+	authorized := map[string]struct{}{}
+	var overLimit error
+	for _, signatory := range signatories {
+		index := slices.IndexFunc(signers, func(signer accountSigner) bool {
+			return strings.EqualFold(signer.clientID, signatory.String())
+		})
+		if index < 0 {
+			return fmt.Errorf("%w: client %s cannot sign for the account", ErrAccountAuthorizationRequired, signatory)
+		}
+		if limit := signers[index].limit; limit != nil {
+			limitAmount, err := currencyregistry.ParseAmount(*limit, account.Currency)
+			if err != nil {
+				return fmt.Errorf("%w: invalid signing limit for client %s", ErrAccountValidation, signatory)
+			}
+			if amount > limitAmount {
+				overLimit = fmt.Errorf("%w: amount exceeds the signing limit of client %s", ErrAccountAuthorizationRequired, signatory)
+				continue
+			}
+		}
+		authorized[signatory.String()] = struct{}{}
+	}
+
+	switch rule {
+	case models.SigningRuleAll:
+		for _, signer := range signers {
+			if _, ok := authorized[signer.clientID]; signer.required && !ok {
+				if overLimit != nil {
+					return overLimit
+				}
+				return fmt.Errorf("%w: client %s must sign as well", ErrAccountAuthorizationRequired, signer.clientID)
+			}
+		}
+	default:
+		if len(authorized) == 0 {
+			return overLimit
+		}
+	}
+	return nil
+}
+
+// accountHolders returns the holders of the account, starting with its
+// primary holder for the accounts opened before joint accounts.
+func accountHolders(account *models.Account) []models.AccountHolder {
+	if slices.ContainsFunc(account.Holders, func(holder models.AccountHolder) bool {
+		return holder.Role == models.AccountHolderRolePrimary
+	}) {
+		return account.Holders
+	}
+	return append([]models.AccountHolder{{
+		ClientID: account.ClientID,
+		Role:     models.AccountHolderRolePrimary,
+		AddedAt:  account.OpenedAt,
+	}}, account.Holders...)
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+func TestAccountHolderServiceSigningRules(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	clientRepo := newClientRepositoryStub()
+	service := NewAccountHolderService(accountRepo, clientRepo)
+
+	clients := make([]*models.Client, 3)
+	for i := range clients {
+		clients[i] = &models.Client{
+			ID:           uuid.New(),
+			ClientNumber: "CL-2026-00040" + string(rune('1'+i)),
+			Type:         models.ClientTypeIndividual,
+			Status:       models.ClientStatusActive,
+		}
+		require.NoError(t, clientRepo.Create(context.Background(), clients[i]))
+	}
+	primary, secondary, mandate := clients[0], clients[1], clients[2]
+
+	account := &models.Account{
+		ID:       uuid.New(),
+		ClientID: primary.ID,
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	// Sole accounts need no signature.
+	require.NoError(t, service.AuthorizeDebit(context.Background(), account.ID, 10_000, nil))
+
+	_, err := service.AddHolder(context.Background(), account.ID, AddAccountHolderInput{ClientID: secondary.ID, Role: models.AccountHolderRolePrimary})
+	require.ErrorIs(t, err, ErrAccountValidation)
+	_, err = service.AddHolder(context.Background(), account.ID, AddAccountHolderInput{ClientID: primary.ID, Role: models.AccountHolderRoleSecondary})
+	require.ErrorIs(t, err, ErrAccountValidation)
+
+	joint, err := service.AddHolder(context.Background(), account.ID, AddAccountHolderInput{ClientID: secondary.ID, Role: models.AccountHolderRoleSecondary})
+	require.NoError(t, err)
+	require.Equal(t, models.SigningRuleAnyOne, joint.SigningRule)
+	joint, err = service.AddHolder(context.Background(), account.ID, AddAccountHolderInput{ClientID: mandate.ID, Role: models.AccountHolderRoleMandate, SigningLimit: strPtr("100.00")})
+	require.NoError(t, err)
+	require.Len(t, joint.Holders, 3)
+	require.Equal(t, models.AccountHolderRolePrimary, joint.Holders[0].Role)
+
+	held, err := accountRepo.List(context.Background(), repositories.AccountFilter{HolderID: &mandate.ID})
+	require.NoError(t, err)
+	require.Len(t, held, 1)
+
+	require.ErrorIs(t, service.AuthorizeDebit(context.Background(), account.ID, 5_000, nil), ErrAccountAuthorizationRequired)
+	require.ErrorIs(t, service.AuthorizeDebit(context.Background(), account.ID, 5_000, []uuid.UUID{uuid.New()}), ErrAccountAuthorizationRequired)
+	require.NoError(t, service.AuthorizeDebit(context.Background(), account.ID, 5_000, []uuid.UUID{secondary.ID}))
+	require.NoError(t, service.AuthorizeDebit(context.Background(), account.ID, 10_000, []uuid.UUID{mandate.ID}))
+	require.ErrorIs(t, service.AuthorizeDebit(context.Background(), account.ID, 10_001, []uuid.UUID{mandate.ID}), ErrAccountAuthorizationRequired)
+
+	_, err = service.SetSigningRule(context.Background(), account.ID, SetSigningRuleInput{SigningRule: "two"})
+	require.ErrorIs(t, err, ErrAccountValidation)
+	_, err = service.SetSigningRule(context.Background(), account.ID, SetSigningRuleInput{})
+	require.ErrorIs(t, err, ErrAccountValidation)
+	_, err = service.SetSigningRule(context.Background(), account.ID, SetSigningRuleInput{SigningRule: models.SigningRuleAll})
+	require.NoError(t, err)
+
+	// Every holder signs; mandates cannot stand in for them.
+	require.ErrorIs(t, service.AuthorizeDebit(context.Background(), account.ID, 5_000, []uuid.UUID{secondary.ID, mandate.ID}), ErrAccountAuthorizationRequired)
+	require.NoError(t, service.AuthorizeDebit(context.Background(), account.ID, 50_000, []uuid.UUID{primary.ID, secondary.ID, mandate.ID}))
+
+	_, err = service.RemoveHolder(context.Background(), account.ID, primary.ID)
+	require.ErrorIs(t, err, ErrAccountValidation)
+	joint, err = service.RemoveHolder(context.Background(), account.ID, secondary.ID)
+	require.NoError(t, err)
+	require.Len(t, joint.Holders, 2)
+	require.NoError(t, service.AuthorizeDebit(context.Background(), account.ID, 5_000, []uuid.UUID{primary.ID}))
+}
+
+func TestAccountHolderServiceCorporateSigningLimits(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	clientRepo := newClientRepositoryStub()
+	service := NewAccountHolderService(accountRepo, clientRepo)
+
+	director, treasurer := uuid.New(), uuid.New()
+	corporate := &models.Client{
+		ID:           uuid.New(),
+		ClientNumber: "CL-2026-000410",
+		Type:         models.ClientTypeCorporate,
+		Status:       models.ClientStatusActive,
+		CorporateData: &models.CorporateData{
+			LegalName: "Acme Ltd",
+			AuthorizedSignatories: []models.AuthorizedSignatory{
+				{ClientID: director.String(), Role: "director"},
+				{ClientID: treasurer.String(), Role: "treasurer", SigningLimit: "1000.00"},
+			},
+		},
+	}
+	require.NoError(t, clientRepo.Create(context.Background(), corporate))
+	account := &models.Account{
+		ID:       uuid.New(),
+		ClientID: corporate.ID,
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	require.ErrorIs(t, service.AuthorizeDebit(context.Background(), account.ID, 50_000, nil), ErrAccountAuthorizationRequired)
+	require.ErrorIs(t, service.AuthorizeDebit(context.Background(), account.ID, 50_000, []uuid.UUID{corporate.ID}), ErrAccountAuthorizationRequired)
+	require.NoError(t, service.AuthorizeDebit(context.Background(), account.ID, 100_000, []uuid.UUID{treasurer}))
+	require.ErrorIs(t, service.AuthorizeDebit(context.Background(), account.ID, 100_001, []uuid.UUID{treasurer}), ErrAccountAuthorizationRequired)
+	require.NoError(t, service.AuthorizeDebit(context.Background(), account.ID, 100_001, []uuid.UUID{treasurer, director}))
+}
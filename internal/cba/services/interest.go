@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,9 +29,30 @@ type InterestPostingPreview struct {
 	TotalAccrued   decimal.Decimal
 	PostableAmount int64
 	Remainder      decimal.Decimal
+	// Withholding is the tax withheld from the postable amount, nil when the
+	// product does not withhold tax.
+	Withholding *WithholdingTax
+}
+
+// NetAmount is the interest credited to the account once the tax is withheld.
+func (p InterestPostingPreview) NetAmount() int64 {
+	if p.Withholding == nil {
+		return p.PostableAmount
+	}
+	return p.PostableAmount - p.Withholding.Amount
+}
+
+type WithholdingTax struct {
+	Residency  string
+	ClientType string
+	Rate       decimal.Decimal
+	Amount     int64
+	Exempt     bool
 }
 
 type InterestService interface {
+	// Accrue accrues the interest of the account for the date on its end of
+	// day balance, at the rate in effect on that date.
 	Accrue(context.Context, uuid.UUID, int64, time.Time) (*models.InterestAccrual, error)
 	IsPostingDue(context.Context, uuid.UUID, time.Time) (bool, error)
 	PreviewPosting(context.Context, uuid.UUID) (*InterestPostingPreview, error)
@@ -41,17 +63,26 @@ type DefaultInterestService struct {
 	accountRepository  repositories.AccountRepository
 	productRepository  repositories.ProductRepository
 	interestRepository repositories.InterestAccrualRepository
+	rateRepository     repositories.InterestRateRepository
+	clientRepository   repositories.ClientRepository
+	taxRepository      repositories.TaxPostingRepository
 }
 
 func NewInterestService(
 	accountRepository repositories.AccountRepository,
 	productRepository repositories.ProductRepository,
 	interestRepository repositories.InterestAccrualRepository,
+	rateRepository repositories.InterestRateRepository,
+	clientRepository repositories.ClientRepository,
+	taxRepository repositories.TaxPostingRepository,
 ) InterestService {
 	return &DefaultInterestService{
 		accountRepository:  accountRepository,
 		productRepository:  productRepository,
 		interestRepository: interestRepository,
+		rateRepository:     rateRepository,
+		clientRepository:   clientRepository,
+		taxRepository:      taxRepository,
 	}
 }
 
@@ -64,6 +95,10 @@ func (s *DefaultInterestService) Accrue(ctx context.Context, accountID uuid.UUID
 	if account.Status != models.AccountStatusActive || config == nil || config.Type == "" || config.Type == "none" {
 		return nil, ErrInterestNotApplicable
 	}
+	// Broken terms forfeit their interest.
+	if account.Term != nil && !termRunning(account) {
+		return nil, ErrInterestNotApplicable
+	}
 
 	accrualDate = normalizeUsageDate(accrualDate)
 	if existing, ok, err := s.findExistingAccrual(ctx, accountID, accrualDate); err != nil {
@@ -80,11 +115,16 @@ func (s *DefaultInterestService) Accrue(ctx context.Context, accountID uuid.UUID
 		return nil, ErrInterestNotApplicable
 	}
 
+	config, rateVersion, err := s.rateVersionAt(ctx, product, accrualDate)
+	if err != nil {
+		return nil, err
+	}
 	rate, err := resolveInterestRate(config, balanceBasis)
 	if err != nil {
 		return nil, err
 	}
-	amount, shouldAccrue, err := calculateAccrualAmount(config, balanceBasis, rate, accrualDate)
+	dayCount := dayCountConvention(config)
+	amount, shouldAccrue, err := calculateAccrualAmount(config, dayCount, balanceBasis, rate, accrualDate)
 	if err != nil {
 		return nil, err
 	}
@@ -100,6 +140,8 @@ func (s *DefaultInterestService) Accrue(ctx context.Context, accountID uuid.UUID
 		Rate:         rate,
 		Amount:       amount,
 		Posted:       false,
+		DayCount:     dayCount,
+		RateVersion:  rateVersion,
 		Metadata: map[string]any{
 			"currency":          account.Currency,
 			"interest_type":     config.Type,
@@ -119,7 +161,7 @@ func (s *DefaultInterestService) Accrue(ctx context.Context, accountID uuid.UUID
 }
 
 func (s *DefaultInterestService) IsPostingDue(ctx context.Context, accountID uuid.UUID, when time.Time) (bool, error) {
-	_, product, err := s.loadAccountAndProduct(ctx, accountID)
+	account, product, err := s.loadAccountAndProduct(ctx, accountID)
 	if err != nil {
 		return false, err
 	}
@@ -127,6 +169,9 @@ func (s *DefaultInterestService) IsPostingDue(ctx context.Context, accountID uui
 	if config == nil || config.Type == "" || config.Type == "none" {
 		return false, nil
 	}
+	if account.Term != nil && !termRunning(account) {
+		return false, nil
+	}
 	when = normalizeUsageDate(when)
 	if isPostingBoundary(config.PostingFrequency, when) {
 		return true, nil
@@ -148,7 +193,7 @@ func (s *DefaultInterestService) IsPostingDue(ctx context.Context, accountID uui
 }
 
 func (s *DefaultInterestService) PreviewPosting(ctx context.Context, accountID uuid.UUID) (*InterestPostingPreview, error) {
-	account, _, err := s.loadAccountAndProduct(ctx, accountID)
+	account, product, err := s.loadAccountAndProduct(ctx, accountID)
 	if err != nil {
 		return nil, err
 	}
@@ -174,6 +219,10 @@ func (s *DefaultInterestService) PreviewPosting(ctx context.Context, accountID u
 	if err != nil {
 		return nil, err
 	}
+	withholding, err := s.withholdingTax(ctx, account, product, postableMinor)
+	if err != nil {
+		return nil, err
+	}
 	return &InterestPostingPreview{
 		AccountID:      account.ID,
 		Currency:       account.Currency,
@@ -181,6 +230,7 @@ func (s *DefaultInterestService) PreviewPosting(ctx context.Context, accountID u
 		TotalAccrued:   total,
 		PostableAmount: postableMinor,
 		Remainder:      total.Sub(postedMajor),
+		Withholding:    withholding,
 	}, nil
 }
 
@@ -210,6 +260,12 @@ func (s *DefaultInterestService) MarkPosted(ctx context.Context, preview Interes
 		}
 	}
 
+	if preview.Withholding != nil {
+		if err := s.recordTaxPosting(ctx, account, preview, postedReference); err != nil {
+			return err
+		}
+	}
+
 	account.InterestAccrued = account.InterestAccrued.Sub(postedMajor)
 	if account.InterestAccrued.IsNegative() {
 		account.InterestAccrued = decimal.Zero
@@ -217,6 +273,78 @@ func (s *DefaultInterestService) MarkPosted(ctx context.Context, preview Interes
 	return resolveAccountRepositoryError(s.accountRepository.Update(ctx, account))
 }
 
+// withholdingTax returns the tax to withhold from the interest paid to the
+// account, at the first rate of the product matching the tax residency and
+// type of its client.
+func (s *DefaultInterestService) withholdingTax(ctx context.Context, account *models.Account, product *models.Product, gross int64) (*WithholdingTax, error) {
+	if product.TaxConfig == nil || len(product.TaxConfig.WithholdingRates) == 0 {
+		return nil, nil
+	}
+	client, err := s.clientRepository.Get(ctx, account.ClientID)
+	if err != nil {
+		return nil, resolveClientRepositoryError(err)
+	}
+
+	tax := &WithholdingTax{
+		Residency:  clientTaxResidency(client),
+		ClientType: client.Type,
+		Rate:       decimal.Zero,
+	}
+	if exemption := client.TaxExemption; exemption != nil && (exemption.ValidUntil == nil || !time.Now().UTC().After(*exemption.ValidUntil)) {
+		tax.Exempt = true
+		return tax, nil
+	}
+	for _, rate := range product.TaxConfig.WithholdingRates {
+		if rate.Residency != "" && !strings.EqualFold(rate.Residency, tax.Residency) {
+			continue
+		}
+		if rate.ClientType != "" && rate.ClientType != tax.ClientType {
+			continue
+		}
+		tax.Rate, err = decimal.NewFromString(rate.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid withholding tax rate", ErrInterestValidation)
+		}
+		tax.Amount = decimal.NewFromInt(gross).Mul(tax.Rate).Div(decimal.NewFromInt(100)).Round(0).IntPart()
+		break
+	}
+	return tax, nil
+}
+
+func (s *DefaultInterestService) recordTaxPosting(ctx context.Context, account *models.Account, preview InterestPostingPreview, reference string) error {
+	_, err := s.taxRepository.GetByInterestReference(ctx, reference)
+	switch {
+	case err == nil:
+		return nil
+	case !postgres.IsNotFoundError(err) && !errors.Is(err, postgres.ErrNotFound):
+		return err
+	}
+
+	return s.taxRepository.Create(ctx, &models.TaxPosting{
+		AccountID:         account.ID,
+		ClientID:          account.ClientID,
+		InterestReference: reference,
+		Currency:          account.Currency,
+		Residency:         preview.Withholding.Residency,
+		ClientType:        preview.Withholding.ClientType,
+		Rate:              preview.Withholding.Rate,
+		GrossAmount:       preview.PostableAmount,
+		TaxAmount:         preview.Withholding.Amount,
+		NetAmount:         preview.NetAmount(),
+		Exempt:            preview.Withholding.Exempt,
+	})
+}
+
+func clientTaxResidency(client *models.Client) string {
+	if client.TaxResidency != "" {
+		return client.TaxResidency
+	}
+	if client.Contact.Address != nil {
+		return strings.ToUpper(client.Contact.Address.Country)
+	}
+	return ""
+}
+
 func (s *DefaultInterestService) findExistingAccrual(ctx context.Context, accountID uuid.UUID, accrualDate time.Time) (*models.InterestAccrual, bool, error) {
 	accruals, err := s.interestRepository.ListByAccount(ctx, accountID)
 	if err != nil {
@@ -230,6 +358,27 @@ func (s *DefaultInterestService) findExistingAccrual(ctx context.Context, accoun
 	return nil, false, nil
 }
 
+// rateVersionAt returns the interest config of the product with the rate and
+// tiers in effect on the date, along with their version. The interest config
+// of the product holds the first version.
+func (s *DefaultInterestService) rateVersionAt(ctx context.Context, product *models.Product, date time.Time) (*models.InterestConfig, int, error) {
+	versions, err := s.rateRepository.ListByProduct(ctx, product.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	config := *product.InterestConfig
+	version := 1
+	for _, rateVersion := range versions {
+		if normalizeUsageDate(rateVersion.EffectiveFrom).After(date) {
+			break
+		}
+		config.Rate = rateVersion.Rate
+		config.Tiers = rateVersion.Tiers
+		version = rateVersion.Version
+	}
+	return &config, version, nil
+}
+
 func (s *DefaultInterestService) loadAccountAndProduct(ctx context.Context, id uuid.UUID) (*models.Account, *models.Product, error) {
 	account, err := s.accountRepository.Get(ctx, id)
 	if err != nil {
@@ -272,19 +421,76 @@ func resolveInterestRate(config *models.InterestConfig, balanceBasis decimal.Dec
 	return rate, nil
 }
 
-func calculateAccrualAmount(config *models.InterestConfig, balanceBasis, annualRate decimal.Decimal, when time.Time) (decimal.Decimal, bool, error) {
-	rateFactor := annualRate.Div(decimal.NewFromInt(100))
+// calculateAccrualAmount returns the interest accrued over the period ending
+// with the day, which is the day itself for daily accruals and its month for
+// monthly ones.
+func calculateAccrualAmount(config *models.InterestConfig, dayCount string, balanceBasis, annualRate decimal.Decimal, when time.Time) (decimal.Decimal, bool, error) {
+	var start time.Time
 	switch config.AccrualFrequency {
 	case "daily":
-		return balanceBasis.Mul(rateFactor).Div(decimal.NewFromInt(365)).Round(12), true, nil
+		start = when
 	case "monthly":
 		if !isMonthEnd(when) {
 			return decimal.Zero, false, nil
 		}
-		return balanceBasis.Mul(rateFactor).Div(decimal.NewFromInt(12)).Round(12), true, nil
+		start = time.Date(when.Year(), when.Month(), 1, 0, 0, 0, 0, time.UTC)
 	default:
 		return decimal.Zero, false, fmt.Errorf("%w: unsupported accrual frequency %s", ErrInterestValidation, config.AccrualFrequency)
 	}
+
+	days, basis, err := dayCountFraction(dayCount, start, when.AddDate(0, 0, 1))
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	// On 30/360 the 30th of a 31 day month accrues nothing, the 31st counting
+	// as the 30th.
+	if days == 0 {
+		return decimal.Zero, false, nil
+	}
+	rateFactor := annualRate.Div(decimal.NewFromInt(100))
+	return balanceBasis.Mul(rateFactor).Mul(decimal.NewFromInt(days)).Div(decimal.NewFromInt(basis)).Round(12), true, nil
+}
+
+// dayCountConvention returns the day count convention of the interest config.
+// Configs without one keep the split they were accruing with before
+// conventions were configurable.
+func dayCountConvention(config *models.InterestConfig) string {
+	if config.DayCount != "" {
+		return config.DayCount
+	}
+	if config.AccrualFrequency == "monthly" {
+		return models.DayCount30360
+	}
+	return models.DayCountActual365
+}
+
+// dayCountFraction returns the number of days from start to end and the days
+// in a year under the convention.
+func dayCountFraction(convention string, start, end time.Time) (int64, int64, error) {
+	actualDays := int64(end.Sub(start).Hours() / 24)
+	switch convention {
+	case models.DayCountActual365:
+		return actualDays, 365, nil
+	case models.DayCountActual360:
+		return actualDays, 360, nil
+	case models.DayCount30360:
+		return days30360(start, end), 360, nil
+	default:
+		return 0, 0, fmt.Errorf("%w: unsupported day count %s", ErrInterestValidation, convention)
+	}
+}
+
+// days30360 counts the days from start to end as if every month had 30 days,
+// following the 30/360 bond basis.
+func days30360(start, end time.Time) int64 {
+	startDay, endDay := start.Day(), end.Day()
+	if startDay == 31 {
+		startDay = 30
+	}
+	if endDay == 31 && startDay == 30 {
+		endDay = 30
+	}
+	return int64(360*(end.Year()-start.Year()) + 30*(int(end.Month())-int(start.Month())) + endDay - startDay)
 }
 
 func isPostingBoundary(frequency string, when time.Time) bool {
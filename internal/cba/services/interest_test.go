@@ -18,7 +18,7 @@ func TestInterestServiceAccrueSimpleDaily(t *testing.T) {
 	accountRepo := newAccountRepositoryStub()
 	productRepo := newProductRepositoryStub()
 	interestRepo := newInterestAccrualRepositoryStub()
-	service := NewInterestService(accountRepo, productRepo, interestRepo)
+	service := NewInterestService(accountRepo, productRepo, interestRepo, newInterestRateRepositoryStub(), newClientRepositoryStub(), newTaxPostingRepositoryStub())
 
 	productID := uuid.New()
 	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
@@ -63,7 +63,7 @@ func TestInterestServicePreviewPostingRoundsHalfUp(t *testing.T) {
 	accountRepo := newAccountRepositoryStub()
 	productRepo := newProductRepositoryStub()
 	interestRepo := newInterestAccrualRepositoryStub()
-	service := NewInterestService(accountRepo, productRepo, interestRepo)
+	service := NewInterestService(accountRepo, productRepo, interestRepo, newInterestRateRepositoryStub(), newClientRepositoryStub(), newTaxPostingRepositoryStub())
 
 	productID := uuid.New()
 	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
@@ -117,7 +117,7 @@ func TestInterestServicePostingBoundary(t *testing.T) {
 	accountRepo := newAccountRepositoryStub()
 	productRepo := newProductRepositoryStub()
 	interestRepo := newInterestAccrualRepositoryStub()
-	service := NewInterestService(accountRepo, productRepo, interestRepo)
+	service := NewInterestService(accountRepo, productRepo, interestRepo, newInterestRateRepositoryStub(), newClientRepositoryStub(), newTaxPostingRepositoryStub())
 
 	productID := uuid.New()
 	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
@@ -152,3 +152,186 @@ func TestInterestServicePostingBoundary(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, due)
 }
+
+func TestInterestServiceAccrueWithDayCountAndRateVersions(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	interestRepo := newInterestAccrualRepositoryStub()
+	rateRepo := newInterestRateRepositoryStub()
+	service := NewInterestService(accountRepo, productRepo, interestRepo, rateRepo, newClientRepositoryStub(), newTaxPostingRepositoryStub())
+
+	newAccount := func(dayCount string) *models.Account {
+		product := &models.Product{
+			ID:       uuid.New(),
+			Code:     "SAV-USD-" + dayCount,
+			Name:     "Interest Savings " + dayCount,
+			Category: "savings",
+			Currency: "USD",
+			Status:   models.ProductStatusActive,
+			InterestConfig: &models.InterestConfig{
+				Type:             "simple",
+				Rate:             "36",
+				AccrualFrequency: "daily",
+				PostingFrequency: "monthly",
+				DayCount:         dayCount,
+			},
+		}
+		require.NoError(t, productRepo.Create(context.Background(), product))
+		account := &models.Account{
+			ID:              uuid.New(),
+			ProductID:       product.ID,
+			Currency:        "USD",
+			Status:          models.AccountStatusActive,
+			InterestAccrued: decimal.Zero,
+		}
+		require.NoError(t, accountRepo.Create(context.Background(), account))
+		return account
+	}
+
+	// ACT/360 splits the rate over 360 days, and the rate change applies
+	// from its effective date only.
+	account := newAccount(models.DayCountActual360)
+	require.NoError(t, rateRepo.Create(context.Background(), &models.InterestRateVersion{
+		ProductID:     account.ProductID,
+		Version:       2,
+		EffectiveFrom: time.Date(2026, 5, 16, 0, 0, 0, 0, time.UTC),
+		Rate:          "72",
+	}))
+
+	accrual, err := service.Accrue(context.Background(), account.ID, 10000, time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "0.1", accrual.Amount.String())
+	require.Equal(t, models.DayCountActual360, accrual.DayCount)
+	require.Equal(t, 1, accrual.RateVersion)
+
+	accrual, err = service.Accrue(context.Background(), account.ID, 10000, time.Date(2026, 5, 16, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "72", accrual.Rate.String())
+	require.Equal(t, "0.2", accrual.Amount.String())
+	require.Equal(t, 2, accrual.RateVersion)
+
+	// 30/360 skips a day of the long months and makes up for the short
+	// February.
+	account = newAccount(models.DayCount30360)
+	_, err = service.Accrue(context.Background(), account.ID, 10000, time.Date(2026, 1, 30, 0, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, ErrInterestNotApplicable)
+
+	accrual, err = service.Accrue(context.Background(), account.ID, 10000, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "0.3", accrual.Amount.String())
+	require.Equal(t, models.DayCount30360, accrual.DayCount)
+
+	// Products without a convention keep accruing daily on ACT/365.
+	account = newAccount("")
+	accrual, err = service.Accrue(context.Background(), account.ID, 36500, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, "0.36", accrual.Amount.String())
+	require.Equal(t, models.DayCountActual365, accrual.DayCount)
+}
+
+func TestInterestServiceWithholdsTaxByResidency(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	interestRepo := newInterestAccrualRepositoryStub()
+	clientRepo := newClientRepositoryStub()
+	taxRepo := newTaxPostingRepositoryStub()
+	service := NewInterestService(accountRepo, productRepo, interestRepo, newInterestRateRepositoryStub(), clientRepo, taxRepo)
+
+	productID := uuid.New()
+	require.NoError(t, productRepo.Create(context.Background(), &models.Product{
+		ID:       productID,
+		Code:     "SAV-USD-TAX",
+		Name:     "Taxed Savings",
+		Category: "savings",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		InterestConfig: &models.InterestConfig{
+			Type:             "simple",
+			Rate:             "10",
+			AccrualFrequency: "daily",
+			PostingFrequency: "monthly",
+		},
+		TaxConfig: &models.TaxConfig{
+			WithholdingRates: []models.WithholdingTaxRate{
+				{Residency: "FR", ClientType: models.ClientTypeIndividual, Rate: "30"},
+				{Rate: "15"},
+			},
+		},
+	}))
+
+	validUntil := time.Now().UTC().AddDate(1, 0, 0)
+	for name, tc := range map[string]struct {
+		client    models.Client
+		rate      string
+		tax       int64
+		residency string
+		exempt    bool
+	}{
+		"matching residency": {
+			client:    models.Client{TaxResidency: "FR"},
+			rate:      "30",
+			tax:       300,
+			residency: "FR",
+		},
+		"address fallback": {
+			client:    models.Client{Contact: models.ClientContact{Address: &models.Address{Country: "de"}}},
+			rate:      "15",
+			tax:       150,
+			residency: "DE",
+		},
+		"exempt": {
+			client: models.Client{TaxResidency: "FR", TaxExemption: &models.TaxExemption{
+				Reason:     "pension fund",
+				ValidUntil: &validUntil,
+			}},
+			rate:      "0",
+			residency: "FR",
+			exempt:    true,
+		},
+	} {
+		client := tc.client
+		client.ID = uuid.New()
+		client.ClientNumber = "CL-TAX-" + name
+		client.Type = models.ClientTypeIndividual
+		require.NoError(t, clientRepo.Create(context.Background(), &client))
+
+		account := &models.Account{
+			ID:              uuid.New(),
+			ClientID:        client.ID,
+			ProductID:       productID,
+			Currency:        "USD",
+			Status:          models.AccountStatusActive,
+			WalletID:        "wallet-tax-" + name,
+			InterestAccrued: decimal.RequireFromString("10"),
+		}
+		require.NoError(t, accountRepo.Create(context.Background(), account))
+		require.NoError(t, interestRepo.Create(context.Background(), &models.InterestAccrual{
+			ID:          uuid.New(),
+			AccountID:   account.ID,
+			AccrualDate: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+			Amount:      decimal.RequireFromString("10"),
+		}))
+
+		preview, err := service.PreviewPosting(context.Background(), account.ID)
+		require.NoError(t, err, name)
+		require.NotNil(t, preview.Withholding, name)
+		require.EqualValues(t, 1_000, preview.PostableAmount, name)
+		require.Equal(t, tc.rate, preview.Withholding.Rate.String(), name)
+		require.Equal(t, tc.tax, preview.Withholding.Amount, name)
+		require.Equal(t, tc.exempt, preview.Withholding.Exempt, name)
+		require.Equal(t, 1_000-tc.tax, preview.NetAmount(), name)
+
+		require.NoError(t, service.MarkPosted(context.Background(), *preview, "interest:"+name))
+		require.NoError(t, service.MarkPosted(context.Background(), *preview, "interest:"+name))
+		postings, err := taxRepo.ListByAccount(context.Background(), account.ID)
+		require.NoError(t, err, name)
+		require.Len(t, postings, 1, name)
+		require.Equal(t, tc.residency, postings[0].Residency, name)
+		require.Equal(t, tc.tax, postings[0].TaxAmount, name)
+		require.Equal(t, 1_000-tc.tax, postings[0].NetAmount, name)
+	}
+}
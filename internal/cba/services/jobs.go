@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+// MaxJobTriggerDays bounds the number of business dates a single trigger can
+// queue.
+const MaxJobTriggerDays = 92
+
+var (
+	ErrJobValidation  = errors.New("job validation failed")
+	ErrJobRunNotFound = errors.New("job run not found")
+	ErrJobRunConflict = errors.New("job run already queued")
+)
+
+type JobService interface {
+	Trigger(context.Context, TriggerJobInput) ([]models.JobRun, error)
+	Get(context.Context, uuid.UUID) (*models.JobRun, error)
+	List(context.Context, repositories.JobRunFilter) ([]models.JobRun, error)
+	Leases(context.Context) ([]models.JobLease, error)
+}
+
+// TriggerJobInput queues a run of Job for every business date from From to To
+// inclusive. To defaults to From.
+type TriggerJobInput struct {
+	Job         string
+	From        time.Time
+	To          *time.Time
+	RequestedBy string
+}
+
+type DefaultJobService struct {
+	jobRunRepository   repositories.JobRunRepository
+	jobLeaseRepository repositories.JobLeaseRepository
+}
+
+func NewJobService(
+	jobRunRepository repositories.JobRunRepository,
+	jobLeaseRepository repositories.JobLeaseRepository,
+) JobService {
+	return &DefaultJobService{
+		jobRunRepository:   jobRunRepository,
+		jobLeaseRepository: jobLeaseRepository,
+	}
+}
+
+func (s *DefaultJobService) Trigger(ctx context.Context, input TriggerJobInput) ([]models.JobRun, error) {
+	job := strings.ToLower(strings.TrimSpace(input.Job))
+	if !slices.Contains(models.Jobs, job) {
+		return nil, fmt.Errorf("%w: unknown job %q", ErrJobValidation, input.Job)
+	}
+	if input.From.IsZero() {
+		return nil, fmt.Errorf("%w: from is required", ErrJobValidation)
+	}
+	from := input.From.UTC().Truncate(24 * time.Hour)
+	to := from
+	if input.To != nil {
+		to = input.To.UTC().Truncate(24 * time.Hour)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrJobValidation)
+	}
+	if to.After(time.Now().UTC().Truncate(24 * time.Hour)) {
+		return nil, fmt.Errorf("%w: business dates in the future cannot be run", ErrJobValidation)
+	}
+	days := int(to.Sub(from)/(24*time.Hour)) + 1
+	if days > MaxJobTriggerDays {
+		return nil, fmt.Errorf("%w: at most %d business dates can be triggered at once", ErrJobValidation, MaxJobTriggerDays)
+	}
+
+	active, err := s.jobRunRepository.List(ctx, repositories.JobRunFilter{
+		Job:      &job,
+		Statuses: []string{models.JobRunStatusPending, models.JobRunStatusRunning},
+		From:     &from,
+		To:       &to,
+		Limit:    1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(active) > 0 {
+		return nil, fmt.Errorf("%w: %s for %s", ErrJobRunConflict, job, active[0].BusinessDate.Format(time.DateOnly))
+	}
+
+	runs := make([]models.JobRun, 0, days)
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		run := models.JobRun{
+			Job:          job,
+			BusinessDate: date,
+			Trigger:      models.JobRunTriggerManual,
+			Status:       models.JobRunStatusPending,
+			RequestedBy:  input.RequestedBy,
+		}
+		if err := s.jobRunRepository.Create(ctx, &run); err != nil {
+			return nil, resolveJobRunRepositoryError(err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+func (s *DefaultJobService) Get(ctx context.Context, id uuid.UUID) (*models.JobRun, error) {
+	run, err := s.jobRunRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveJobRunRepositoryError(err)
+	}
+	return run, nil
+}
+
+func (s *DefaultJobService) List(ctx context.Context, filter repositories.JobRunFilter) ([]models.JobRun, error) {
+	if filter.Job != nil && !slices.Contains(models.Jobs, *filter.Job) {
+		return nil, fmt.Errorf("%w: unknown job %q", ErrJobValidation, *filter.Job)
+	}
+	return s.jobRunRepository.List(ctx, filter)
+}
+
+// Leases lists the scheduler leases, telling which worker runs the jobs and
+// until when its lease holds.
+func (s *DefaultJobService) Leases(ctx context.Context) ([]models.JobLease, error) {
+	return s.jobLeaseRepository.List(ctx)
+}
+
+func resolveJobRunRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrJobRunNotFound
+	case errors.Is(err, postgres.ErrConstraintsFailed{}):
+		return ErrJobRunConflict
+	default:
+		return err
+	}
+}
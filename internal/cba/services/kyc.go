@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -16,14 +17,20 @@ import (
 )
 
 var (
-	ErrKYCValidation = errors.New("kyc validation failed")
-	ErrKYCNotFound   = errors.New("kyc record not found")
+	ErrKYCValidation        = errors.New("kyc validation failed")
+	ErrKYCNotFound          = errors.New("kyc record not found")
+	ErrKYCProvider          = errors.New("kyc provider failed")
+	ErrKYCCallbackSignature = errors.New("invalid kyc callback signature")
 )
 
 type KYCService interface {
 	Submit(context.Context, uuid.UUID, SubmitKYCInput) (*models.KYCRecord, error)
 	Verify(context.Context, uuid.UUID, uuid.UUID, VerifyKYCInput) (*models.KYCRecord, error)
 	Reject(context.Context, uuid.UUID, uuid.UUID, RejectKYCInput) (*models.KYCRecord, error)
+	Refresh(context.Context, uuid.UUID, uuid.UUID) (*models.KYCRecord, error)
+	HandleCallback(context.Context, http.Header, []byte) (*models.KYCRecord, error)
+	Expire(context.Context, uuid.UUID, uuid.UUID) (*models.KYCRecord, error)
+	MarkExpiryNotified(context.Context, uuid.UUID, uuid.UUID) (*models.KYCRecord, error)
 	History(context.Context, uuid.UUID) ([]models.KYCRecord, error)
 }
 
@@ -42,18 +49,33 @@ type RejectKYCInput struct {
 	Reason string `json:"reason"`
 }
 
+// DefaultKYCService validates submissions against the configured level
+// requirements. When a provider is configured, submissions are sent to it
+// and its decisions move records out of pending; Verify and Reject remain
+// available as manual overrides.
 type DefaultKYCService struct {
 	clientRepository repositories.ClientRepository
 	kycRepository    repositories.KYCRepository
+	requirements     KYCRequirements
+	provider         KYCProvider
 }
 
+// NewKYCService builds a KYCService. A nil requirements uses
+// DefaultKYCRequirements and a nil provider keeps verification manual.
 func NewKYCService(
 	clientRepository repositories.ClientRepository,
 	kycRepository repositories.KYCRepository,
+	requirements KYCRequirements,
+	provider KYCProvider,
 ) KYCService {
+	if len(requirements) == 0 {
+		requirements = DefaultKYCRequirements()
+	}
 	return &DefaultKYCService{
 		clientRepository: clientRepository,
 		kycRepository:    kycRepository,
+		requirements:     requirements,
+		provider:         provider,
 	}
 }
 
@@ -72,10 +94,23 @@ func (s *DefaultKYCService) Submit(ctx context.Context, clientID uuid.UUID, inpu
 		Payload:     normalizeMap(input.Payload),
 	}
 
-	if err := validateKYCSubmission(client, record); err != nil {
+	if err := s.requirements.Validate(client, record); err != nil {
 		return nil, err
 	}
 
+	decision := KYCDecision{Status: models.KYCStatusPending}
+	if s.provider != nil {
+		// The record is only stored once the provider has accepted it, so
+		// it needs its ID beforehand to serve as the provider reference.
+		record.ID = uuid.New()
+		decision, err = s.provider.Submit(ctx, *client, *record)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrKYCProvider, err)
+		}
+		record.Provider = s.provider.Name()
+		record.ProviderReference = decision.Reference
+	}
+
 	if err := s.kycRepository.Create(ctx, record); err != nil {
 		return nil, resolveKYCRepositoryError(err)
 	}
@@ -86,7 +121,7 @@ func (s *DefaultKYCService) Submit(ctx context.Context, clientID uuid.UUID, inpu
 		return nil, resolveClientRepositoryError(err)
 	}
 
-	return record, nil
+	return s.applyDecision(ctx, client, record, decision)
 }
 
 func (s *DefaultKYCService) Verify(ctx context.Context, clientID, kycID uuid.UUID, input VerifyKYCInput) (*models.KYCRecord, error) {
@@ -95,26 +130,199 @@ func (s *DefaultKYCService) Verify(ctx context.Context, clientID, kycID uuid.UUI
 		return nil, err
 	}
 
-	if record.Status == models.KYCStatusVerified {
-		return record, nil
+	expiresAt, err := parseOptionalTimestamp(input.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now().UTC()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrKYCValidation)
+	}
+
+	return s.verify(ctx, client, record, strings.TrimSpace(input.Verifier), expiresAt)
+}
+
+func (s *DefaultKYCService) Reject(ctx context.Context, clientID, kycID uuid.UUID, input RejectKYCInput) (*models.KYCRecord, error) {
+	client, record, err := s.loadClientAndRecord(ctx, clientID, kycID)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" && record.Status != models.KYCStatusRejected {
+		return nil, fmt.Errorf("%w: reason is required", ErrKYCValidation)
+	}
+
+	return s.reject(ctx, client, record, reason)
+}
+
+// Refresh polls the provider for the decision on a pending record.
+func (s *DefaultKYCService) Refresh(ctx context.Context, clientID, kycID uuid.UUID) (*models.KYCRecord, error) {
+	client, record, err := s.loadClientAndRecord(ctx, clientID, kycID)
+	if err != nil {
+		return nil, err
+	}
+	if record.Provider == "" {
+		return nil, fmt.Errorf("%w: kyc record was not submitted to a provider", ErrKYCValidation)
+	}
+	if s.provider == nil || s.provider.Name() != record.Provider {
+		return nil, fmt.Errorf("%w: kyc provider %s is not configured", ErrKYCValidation, record.Provider)
 	}
 	if record.Status != models.KYCStatusPending {
-		return nil, fmt.Errorf("%w: only pending KYC records can be verified", ErrKYCValidation)
+		return record, nil
 	}
 
-	expiresAt, err := parseOptionalTimestamp(input.ExpiresAt)
+	decision, err := s.provider.Poll(ctx, record.ProviderReference)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrKYCProvider, err)
+	}
+
+	return s.applyDecision(ctx, client, record, decision)
+}
+
+// HandleCallback applies a decision pushed by the provider.
+func (s *DefaultKYCService) HandleCallback(ctx context.Context, header http.Header, body []byte) (*models.KYCRecord, error) {
+	if s.provider == nil {
+		return nil, fmt.Errorf("%w: no kyc provider is configured", ErrKYCValidation)
+	}
+
+	decision, err := s.provider.ParseCallback(ctx, header, body)
 	if err != nil {
 		return nil, err
 	}
-	if expiresAt != nil && expiresAt.Before(time.Now().UTC()) {
-		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrKYCValidation)
+
+	record, err := s.kycRepository.GetByProviderReference(ctx, s.provider.Name(), decision.Reference)
+	if err != nil {
+		return nil, resolveKYCRepositoryError(err)
+	}
+	client, err := s.clientRepository.Get(ctx, record.ClientID)
+	if err != nil {
+		return nil, resolveClientRepositoryError(err)
+	}
+
+	return s.applyDecision(ctx, client, record, decision)
+}
+
+// Expire marks a verified record past its expiry as expired and downgrades
+// the client to the highest level still verified by its other records.
+func (s *DefaultKYCService) Expire(ctx context.Context, clientID, kycID uuid.UUID) (*models.KYCRecord, error) {
+	client, record, err := s.loadClientAndRecord(ctx, clientID, kycID)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Status == models.KYCStatusExpired {
+		return record, nil
+	}
+	if record.Status != models.KYCStatusVerified {
+		return nil, fmt.Errorf("%w: only verified KYC records can expire", ErrKYCValidation)
+	}
+	now := time.Now().UTC()
+	if record.ExpiresAt == nil || record.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: kyc record has not expired", ErrKYCValidation)
+	}
+
+	record.Status = models.KYCStatusExpired
+	if err := s.kycRepository.Update(ctx, record); err != nil {
+		return nil, resolveKYCRepositoryError(err)
+	}
+
+	records, err := s.kycRepository.ListByClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	level, verified := 0, false
+	for _, other := range records {
+		if other.ID == record.ID || other.Status != models.KYCStatusVerified {
+			continue
+		}
+		if other.ExpiresAt != nil && !other.ExpiresAt.After(now) {
+			continue
+		}
+		if !verified || other.Level > level {
+			level = other.Level
+		}
+		verified = true
+	}
+
+	client.KYCLevel = level
+	if verified {
+		client.KYCStatus = models.KYCStatusVerified
+	} else {
+		client.KYCStatus = models.KYCStatusExpired
+	}
+	if err := s.clientRepository.Update(ctx, client); err != nil {
+		return nil, resolveClientRepositoryError(err)
+	}
+
+	return record, nil
+}
+
+// MarkExpiryNotified records that the upcoming expiry of a record was
+// notified, so that it is notified only once.
+func (s *DefaultKYCService) MarkExpiryNotified(ctx context.Context, clientID, kycID uuid.UUID) (*models.KYCRecord, error) {
+	_, record, err := s.loadClientAndRecord(ctx, clientID, kycID)
+	if err != nil {
+		return nil, err
+	}
+	if record.ExpiryNotifiedAt != nil {
+		return record, nil
+	}
+
+	now := time.Now().UTC()
+	record.ExpiryNotifiedAt = &now
+	if err := s.kycRepository.Update(ctx, record); err != nil {
+		return nil, resolveKYCRepositoryError(err)
+	}
+	return record, nil
+}
+
+func (s *DefaultKYCService) History(ctx context.Context, clientID uuid.UUID) ([]models.KYCRecord, error) {
+	if _, err := s.clientRepository.Get(ctx, clientID); err != nil {
+		return nil, resolveClientRepositoryError(err)
+	}
+	return s.kycRepository.ListByClient(ctx, clientID)
+}
+
+// applyDecision moves a pending record to the status decided by the
+// provider. Records already decided, for instance by a manual override,
+// are left as they are.
+func (s *DefaultKYCService) applyDecision(ctx context.Context, client *models.Client, record *models.KYCRecord, decision KYCDecision) (*models.KYCRecord, error) {
+	if record.Status != models.KYCStatusPending {
+		return record, nil
+	}
+
+	switch decision.Status {
+	case models.KYCStatusPending:
+		return record, nil
+	case models.KYCStatusVerified:
+		return s.verify(ctx, client, record, record.Provider, decision.ExpiresAt)
+	case models.KYCStatusRejected:
+		reason := strings.TrimSpace(decision.Reason)
+		if reason == "" {
+			reason = "rejected by " + record.Provider
+		}
+		return s.reject(ctx, client, record, reason)
+	default:
+		return nil, fmt.Errorf("%w: unknown decision status %q", ErrKYCProvider, decision.Status)
+	}
+}
+
+func (s *DefaultKYCService) verify(ctx context.Context, client *models.Client, record *models.KYCRecord, verifier string, expiresAt *time.Time) (*models.KYCRecord, error) {
+	if record.Status == models.KYCStatusVerified {
+		return record, nil
+	}
+	if record.Status != models.KYCStatusPending {
+		return nil, fmt.Errorf("%w: only pending KYC records can be verified", ErrKYCValidation)
 	}
 
 	record.Status = models.KYCStatusVerified
 	now := time.Now().UTC()
 	record.VerifiedAt = &now
-	record.Verifier = strings.TrimSpace(input.Verifier)
-	record.ExpiresAt = expiresAt
+	record.Verifier = verifier
+	if expiresAt != nil {
+		expiresAt := expiresAt.UTC()
+		record.ExpiresAt = &expiresAt
+	}
 
 	if err := s.kycRepository.Update(ctx, record); err != nil {
 		return nil, resolveKYCRepositoryError(err)
@@ -132,12 +340,7 @@ func (s *DefaultKYCService) Verify(ctx context.Context, clientID, kycID uuid.UUI
 	return record, nil
 }
 
-func (s *DefaultKYCService) Reject(ctx context.Context, clientID, kycID uuid.UUID, input RejectKYCInput) (*models.KYCRecord, error) {
-	client, record, err := s.loadClientAndRecord(ctx, clientID, kycID)
-	if err != nil {
-		return nil, err
-	}
-
+func (s *DefaultKYCService) reject(ctx context.Context, client *models.Client, record *models.KYCRecord, reason string) (*models.KYCRecord, error) {
 	if record.Status == models.KYCStatusRejected {
 		return record, nil
 	}
@@ -145,11 +348,6 @@ func (s *DefaultKYCService) Reject(ctx context.Context, clientID, kycID uuid.UUI
 		return nil, fmt.Errorf("%w: only pending KYC records can be rejected", ErrKYCValidation)
 	}
 
-	reason := strings.TrimSpace(input.Reason)
-	if reason == "" {
-		return nil, fmt.Errorf("%w: reason is required", ErrKYCValidation)
-	}
-
 	record.Status = models.KYCStatusRejected
 	record.Reason = reason
 	if err := s.kycRepository.Update(ctx, record); err != nil {
@@ -168,13 +366,6 @@ func (s *DefaultKYCService) Reject(ctx context.Context, clientID, kycID uuid.UUI
 	return record, nil
 }
 
-func (s *DefaultKYCService) History(ctx context.Context, clientID uuid.UUID) ([]models.KYCRecord, error) {
-	if _, err := s.clientRepository.Get(ctx, clientID); err != nil {
-		return nil, resolveClientRepositoryError(err)
-	}
-	return s.kycRepository.ListByClient(ctx, clientID)
-}
-
 func (s *DefaultKYCService) loadClientAndRecord(ctx context.Context, clientID, kycID uuid.UUID) (*models.Client, *models.KYCRecord, error) {
 	client, err := s.clientRepository.Get(ctx, clientID)
 	if err != nil {
@@ -190,36 +381,6 @@ func (s *DefaultKYCService) loadClientAndRecord(ctx context.Context, clientID, k
 	return client, record, nil
 }
 
-func validateKYCSubmission(client *models.Client, record *models.KYCRecord) error {
-	if record.Level < 0 || record.Level > 3 {
-		return fmt.Errorf("%w: level must be between 0 and 3", ErrKYCValidation)
-	}
-
-	if !hasKYCName(client, record.Payload) {
-		return fmt.Errorf("%w: level 0 requires a client name", ErrKYCValidation)
-	}
-	if !hasPayloadOrClientValue(record.Payload, "phone", client.Contact.Phone) {
-		return fmt.Errorf("%w: level 0 requires phone", ErrKYCValidation)
-	}
-
-	if record.Level >= 1 && !hasNationalID(client, record.Payload) {
-		return fmt.Errorf("%w: level 1 requires national_id_number", ErrKYCValidation)
-	}
-	if record.Level >= 2 {
-		if !hasDocumentType(record.Documents, "government_id") {
-			return fmt.Errorf("%w: level 2 requires a government_id document", ErrKYCValidation)
-		}
-		if !hasDocumentType(record.Documents, "proof_of_address") {
-			return fmt.Errorf("%w: level 2 requires a proof_of_address document", ErrKYCValidation)
-		}
-	}
-	if record.Level >= 3 && !hasLevelThreeEvidence(record.Payload) {
-		return fmt.Errorf("%w: level 3 requires biometric or in-person verification evidence", ErrKYCValidation)
-	}
-
-	return nil
-}
-
 func normalizeKYCDocuments(documents []models.KYCDocument) []models.KYCDocument {
 	ret := make([]models.KYCDocument, 0, len(documents))
 	for _, document := range documents {
@@ -304,16 +465,6 @@ func hasDocumentType(documents []models.KYCDocument, docType string) bool {
 	return false
 }
 
-func hasLevelThreeEvidence(payload map[string]any) bool {
-	if value, ok := payload["biometric_reference"].(string); ok && strings.TrimSpace(value) != "" {
-		return true
-	}
-	if value, ok := payload["in_person_verification"].(bool); ok && value {
-		return true
-	}
-	return false
-}
-
 func parseOptionalTimestamp(value *string) (*time.Time, error) {
 	if value == nil {
 		return nil, nil
@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+)
+
+const (
+	// HeaderKYCSignature carries the hex encoded HMAC-SHA256 of a KYC
+	// provider payload, computed with the secret shared with the provider.
+	HeaderKYCSignature = "Formance-KYC-Signature"
+	// HeaderKYCTimestamp and HeaderKYCNonce are required on callbacks, whose
+	// signature covers "{timestamp}.{nonce}.{body}". The timestamp is in
+	// Unix seconds and must be within KYCCallbackTolerance of the current
+	// time, and a nonce is only accepted once in that window.
+	HeaderKYCTimestamp = "Formance-KYC-Timestamp"
+	HeaderKYCNonce     = "Formance-KYC-Nonce"
+)
+
+// KYCCallbackTolerance is how far the timestamp of a callback may be from
+// the current time.
+const KYCCallbackTolerance = 5 * time.Minute
+
+// KYCDecision is the outcome of a check as reported by a KYCProvider.
+// Status is one of the KYC statuses: pending while the provider is still
+// checking, then verified or rejected.
+type KYCDecision struct {
+	Reference string     `json:"reference"`
+	Status    string     `json:"status"`
+	Reason    string     `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// KYCProvider checks KYC submissions on behalf of KYCService. A provider may
+// decide synchronously from Submit, or later through Poll or a callback.
+type KYCProvider interface {
+	Name() string
+	Submit(ctx context.Context, client models.Client, record models.KYCRecord) (KYCDecision, error)
+	Poll(ctx context.Context, reference string) (KYCDecision, error)
+	ParseCallback(ctx context.Context, header http.Header, body []byte) (KYCDecision, error)
+}
+
+// HTTPKYCProvider talks to a provider over HTTP. Submissions are posted to
+// the provider URL, checks are polled at URL/{reference}, and both requests
+// and callbacks are signed with the shared secret.
+type HTTPKYCProvider struct {
+	url        string
+	secret     []byte
+	httpClient *http.Client
+
+	mu     sync.Mutex
+	nonces map[string]time.Time
+}
+
+func NewHTTPKYCProvider(providerURL, secret string, httpClient *http.Client) *HTTPKYCProvider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &HTTPKYCProvider{
+		url:        strings.TrimSuffix(providerURL, "/"),
+		secret:     []byte(secret),
+		httpClient: httpClient,
+		nonces:     map[string]time.Time{},
+	}
+}
+
+func (p *HTTPKYCProvider) Name() string {
+	return "http"
+}
+
+type httpKYCSubmission struct {
+	Reference string               `json:"reference"`
+	ClientID  string               `json:"client_id"`
+	Client    models.Client        `json:"client"`
+	Level     int                  `json:"level"`
+	Documents []models.KYCDocument `json:"documents"`
+	Payload   map[string]any       `json:"payload"`
+}
+
+func (p *HTTPKYCProvider) Submit(ctx context.Context, client models.Client, record models.KYCRecord) (KYCDecision, error) {
+	body, err := json.Marshal(httpKYCSubmission{
+		Reference: record.ID.String(),
+		ClientID:  client.ID.String(),
+		Client:    client,
+		Level:     record.Level,
+		Documents: record.Documents,
+		Payload:   record.Payload,
+	})
+	if err != nil {
+		return KYCDecision{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return KYCDecision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderKYCSignature, p.sign(body))
+
+	decision, err := p.do(req)
+	if err != nil {
+		return KYCDecision{}, err
+	}
+	if decision.Reference == "" {
+		decision.Reference = record.ID.String()
+	}
+	return decision, nil
+}
+
+func (p *HTTPKYCProvider) Poll(ctx context.Context, reference string) (KYCDecision, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url+"/"+url.PathEscape(reference), nil)
+	if err != nil {
+		return KYCDecision{}, err
+	}
+	req.Header.Set(HeaderKYCSignature, p.sign([]byte(reference)))
+
+	decision, err := p.do(req)
+	if err != nil {
+		return KYCDecision{}, err
+	}
+	if decision.Reference == "" {
+		decision.Reference = reference
+	}
+	return decision, nil
+}
+
+// ParseCallback authenticates a callback before decoding it. Callbacks
+// signed too long ago, or whose nonce was already seen, are rejected so a
+// captured callback cannot be replayed.
+func (p *HTTPKYCProvider) ParseCallback(_ context.Context, header http.Header, body []byte) (KYCDecision, error) {
+	timestamp := header.Get(HeaderKYCTimestamp)
+	nonce := header.Get(HeaderKYCNonce)
+	if timestamp == "" || nonce == "" {
+		return KYCDecision{}, fmt.Errorf("%w: timestamp and nonce are required", ErrKYCCallbackSignature)
+	}
+	signature, err := hex.DecodeString(header.Get(HeaderKYCSignature))
+	if err != nil || !hmac.Equal(signature, p.mac(callbackPayload(timestamp, nonce, body))) {
+		return KYCDecision{}, ErrKYCCallbackSignature
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return KYCDecision{}, fmt.Errorf("%w: invalid timestamp", ErrKYCCallbackSignature)
+	}
+	now := time.Now()
+	if signedAt := time.Unix(seconds, 0); signedAt.Before(now.Add(-KYCCallbackTolerance)) || signedAt.After(now.Add(KYCCallbackTolerance)) {
+		return KYCDecision{}, fmt.Errorf("%w: timestamp is out of tolerance", ErrKYCCallbackSignature)
+	}
+	if !p.useNonce(nonce, now) {
+		return KYCDecision{}, fmt.Errorf("%w: nonce was already used", ErrKYCCallbackSignature)
+	}
+
+	decision := KYCDecision{}
+	if err := json.Unmarshal(body, &decision); err != nil {
+		return KYCDecision{}, fmt.Errorf("%w: invalid callback payload: %s", ErrKYCValidation, err)
+	}
+	if decision.Reference == "" {
+		return KYCDecision{}, fmt.Errorf("%w: callback reference is required", ErrKYCValidation)
+	}
+	return decision, nil
+}
+
+func (p *HTTPKYCProvider) do(req *http.Request) (KYCDecision, error) {
+	rsp, err := p.httpClient.Do(req)
+	if err != nil {
+		return KYCDecision{}, err
+	}
+	defer func() {
+		_ = rsp.Body.Close()
+	}()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(rsp.Body, 1024))
+		return KYCDecision{}, fmt.Errorf("provider responded with status %d: %s", rsp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	decision := KYCDecision{}
+	if err := json.NewDecoder(rsp.Body).Decode(&decision); err != nil {
+		return KYCDecision{}, fmt.Errorf("decoding provider response: %w", err)
+	}
+	return decision, nil
+}
+
+// useNonce records a callback nonce, reporting false if it was already used.
+// Nonces are forgotten once their callback would be out of tolerance anyway.
+func (p *HTTPKYCProvider) useNonce(nonce string, now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for seen, expiresAt := range p.nonces {
+		if now.After(expiresAt) {
+			delete(p.nonces, seen)
+		}
+	}
+	if _, ok := p.nonces[nonce]; ok {
+		return false
+	}
+	p.nonces[nonce] = now.Add(2 * KYCCallbackTolerance)
+	return true
+}
+
+func callbackPayload(timestamp, nonce string, body []byte) []byte {
+	return append([]byte(timestamp+"."+nonce+"."), body...)
+}
+
+func (p *HTTPKYCProvider) mac(data []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+func (p *HTTPKYCProvider) sign(data []byte) string {
+	return hex.EncodeToString(p.mac(data))
+}
+
+// LocalKYCProvider keeps checks in memory and leaves them pending until
+// Decide is called. It stands in for a real provider locally and in tests.
+// Callbacks are not signed.
+type LocalKYCProvider struct {
+	mu        sync.Mutex
+	decisions map[string]KYCDecision
+}
+
+func NewLocalKYCProvider() *LocalKYCProvider {
+	return &LocalKYCProvider{
+		decisions: map[string]KYCDecision{},
+	}
+}
+
+func (p *LocalKYCProvider) Name() string {
+	return "local"
+}
+
+func (p *LocalKYCProvider) Submit(_ context.Context, _ models.Client, record models.KYCRecord) (KYCDecision, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	decision := KYCDecision{
+		Reference: "local-" + record.ID.String(),
+		Status:    models.KYCStatusPending,
+	}
+	p.decisions[decision.Reference] = decision
+	return decision, nil
+}
+
+func (p *LocalKYCProvider) Poll(_ context.Context, reference string) (KYCDecision, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	decision, ok := p.decisions[reference]
+	if !ok {
+		return KYCDecision{}, fmt.Errorf("unknown reference %s", reference)
+	}
+	return decision, nil
+}
+
+func (p *LocalKYCProvider) ParseCallback(_ context.Context, _ http.Header, body []byte) (KYCDecision, error) {
+	decision := KYCDecision{}
+	if err := json.Unmarshal(body, &decision); err != nil {
+		return KYCDecision{}, fmt.Errorf("%w: invalid callback payload: %s", ErrKYCValidation, err)
+	}
+	return decision, nil
+}
+
+// Decide records the outcome of a pending check, as a provider would before
+// answering polls or sending its callback.
+func (p *LocalKYCProvider) Decide(reference, status, reason string) (KYCDecision, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	decision, ok := p.decisions[reference]
+	if !ok {
+		return KYCDecision{}, fmt.Errorf("unknown reference %s", reference)
+	}
+	decision.Status = status
+	decision.Reason = reason
+	p.decisions[reference] = decision
+	return decision, nil
+}
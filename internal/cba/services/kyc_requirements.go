@@ -0,0 +1,115 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+)
+
+// KYCLevelRequirements lists what a submission must carry to reach a level.
+// Fields are looked up in the submission payload and on the client, Documents
+// are document types, and AnyOf is satisfied by any one of its fields.
+type KYCLevelRequirements struct {
+	Fields    []string `json:"fields,omitempty"`
+	Documents []string `json:"documents,omitempty"`
+	AnyOf     []string `json:"any_of,omitempty"`
+}
+
+// KYCRequirements holds the requirements of each level, indexed by level.
+// Requirements are cumulative: a level 2 submission must also satisfy
+// levels 0 and 1.
+type KYCRequirements []KYCLevelRequirements
+
+// DefaultKYCRequirements are the requirements used when none are configured.
+func DefaultKYCRequirements() KYCRequirements {
+	return KYCRequirements{
+		{Fields: []string{"name", "phone"}},
+		{Fields: []string{"national_id_number"}},
+		{Documents: []string{"government_id", "proof_of_address"}},
+		{AnyOf: []string{"biometric_reference", "in_person_verification"}},
+	}
+}
+
+// LoadKYCRequirements reads a JSON array of level requirements from a file.
+func LoadKYCRequirements(path string) (KYCRequirements, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	requirements := KYCRequirements{}
+	if err := json.Unmarshal(data, &requirements); err != nil {
+		return nil, fmt.Errorf("decoding kyc requirements: %w", err)
+	}
+	if len(requirements) == 0 {
+		return nil, fmt.Errorf("kyc requirements file %s defines no level", path)
+	}
+	return requirements, nil
+}
+
+func (r KYCRequirements) MaxLevel() int {
+	return len(r) - 1
+}
+
+func (r KYCRequirements) Validate(client *models.Client, record *models.KYCRecord) error {
+	if record.Level < 0 || record.Level > r.MaxLevel() {
+		return fmt.Errorf("%w: level must be between 0 and %d", ErrKYCValidation, r.MaxLevel())
+	}
+
+	for level := 0; level <= record.Level; level++ {
+		requirements := r[level]
+		for _, field := range requirements.Fields {
+			if !hasKYCField(client, record.Payload, field) {
+				return fmt.Errorf("%w: level %d requires %s", ErrKYCValidation, level, field)
+			}
+		}
+		for _, docType := range requirements.Documents {
+			if !hasDocumentType(record.Documents, docType) {
+				return fmt.Errorf("%w: level %d requires a %s document", ErrKYCValidation, level, docType)
+			}
+		}
+		if len(requirements.AnyOf) > 0 && !hasAnyKYCField(client, record.Payload, requirements.AnyOf) {
+			return fmt.Errorf("%w: level %d requires one of %s", ErrKYCValidation, level, strings.Join(requirements.AnyOf, ", "))
+		}
+	}
+
+	return nil
+}
+
+func hasKYCField(client *models.Client, payload map[string]any, field string) bool {
+	switch field {
+	case "name":
+		return hasKYCName(client, payload)
+	case "phone":
+		return hasPayloadOrClientValue(payload, "phone", client.Contact.Phone)
+	case "email":
+		return hasPayloadOrClientValue(payload, "email", client.Contact.Email)
+	case "national_id_number":
+		return hasNationalID(client, payload)
+	}
+	return hasKYCValue(payload[field]) || (client.KYCData != nil && hasKYCValue(client.KYCData[field]))
+}
+
+func hasAnyKYCField(client *models.Client, payload map[string]any, fields []string) bool {
+	for _, field := range fields {
+		if hasKYCField(client, payload, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasKYCValue(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v) != ""
+	case bool:
+		return v
+	case nil:
+		return false
+	default:
+		return true
+	}
+}
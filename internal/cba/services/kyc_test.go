@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+)
+
+func newKYCTestClient(t *testing.T, clientService ClientService) *models.Client {
+	t.Helper()
+
+	client, err := clientService.Create(context.Background(), CreateClientInput{
+		Type: "individual",
+		Contact: models.ClientContact{
+			Phone: "08000000000",
+		},
+		IndividualData: &models.IndividualData{
+			FirstName:        "Ada",
+			LastName:         "Lovelace",
+			NationalIDNumber: "NIN-123",
+		},
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestKYCRequirementsFromConfiguration(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "kyc.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"fields": ["name"]},
+		{"fields": ["tax_id"], "documents": ["passport"]}
+	]`), 0o600))
+	requirements, err := LoadKYCRequirements(path)
+	require.NoError(t, err)
+	require.Equal(t, 1, requirements.MaxLevel())
+
+	clientRepo := newClientRepositoryStub()
+	client := newKYCTestClient(t, NewClientService(clientRepo, newAccountRepositoryStub(), nil))
+	kycService := NewKYCService(clientRepo, newKYCRepositoryStub(), requirements, nil)
+
+	_, err = kycService.Submit(context.Background(), client.ID, SubmitKYCInput{Level: 2})
+	require.ErrorIs(t, err, ErrKYCValidation)
+	_, err = kycService.Submit(context.Background(), client.ID, SubmitKYCInput{
+		Level:     1,
+		Documents: []models.KYCDocument{{Type: "passport"}},
+	})
+	require.ErrorIs(t, err, ErrKYCValidation)
+	_, err = kycService.Submit(context.Background(), client.ID, SubmitKYCInput{
+		Level:     1,
+		Documents: []models.KYCDocument{{Type: "passport"}},
+		Payload:   map[string]any{"tax_id": "TIN-42"},
+	})
+	require.NoError(t, err)
+
+	_, err = LoadKYCRequirements(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestKYCServiceProviderDecisions(t *testing.T) {
+	t.Parallel()
+
+	clientRepo := newClientRepositoryStub()
+	clientService := NewClientService(clientRepo, newAccountRepositoryStub(), nil)
+	client := newKYCTestClient(t, clientService)
+	provider := NewLocalKYCProvider()
+	kycService := NewKYCService(clientRepo, newKYCRepositoryStub(), nil, provider)
+
+	submit := func() *models.KYCRecord {
+		record, err := kycService.Submit(context.Background(), client.ID, SubmitKYCInput{Level: 1})
+		require.NoError(t, err)
+		require.Equal(t, models.KYCStatusPending, record.Status)
+		require.Equal(t, "local", record.Provider)
+		require.Equal(t, "local-"+record.ID.String(), record.ProviderReference)
+		return record
+	}
+
+	// Polling leaves the record pending until the provider decides.
+	polled := submit()
+	refreshed, err := kycService.Refresh(context.Background(), client.ID, polled.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.KYCStatusPending, refreshed.Status)
+	_, err = provider.Decide(polled.ProviderReference, models.KYCStatusVerified, "")
+	require.NoError(t, err)
+	refreshed, err = kycService.Refresh(context.Background(), client.ID, polled.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.KYCStatusVerified, refreshed.Status)
+	require.Equal(t, "local", refreshed.Verifier)
+	stored, err := clientService.Get(context.Background(), client.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, stored.KYCLevel)
+
+	// Callbacks are matched on the provider reference.
+	called := submit()
+	body, err := json.Marshal(KYCDecision{
+		Reference: called.ProviderReference,
+		Status:    models.KYCStatusRejected,
+		Reason:    "document mismatch",
+	})
+	require.NoError(t, err)
+	rejected, err := kycService.HandleCallback(context.Background(), http.Header{}, body)
+	require.NoError(t, err)
+	require.Equal(t, models.KYCStatusRejected, rejected.Status)
+	require.Equal(t, "document mismatch", rejected.Reason)
+
+	// A late decision does not override one already taken.
+	body, err = json.Marshal(KYCDecision{Reference: called.ProviderReference, Status: models.KYCStatusVerified})
+	require.NoError(t, err)
+	late, err := kycService.HandleCallback(context.Background(), http.Header{}, body)
+	require.NoError(t, err)
+	require.Equal(t, models.KYCStatusRejected, late.Status)
+
+	body, err = json.Marshal(KYCDecision{Reference: "local-unknown", Status: models.KYCStatusVerified})
+	require.NoError(t, err)
+	_, err = kycService.HandleCallback(context.Background(), http.Header{}, body)
+	require.ErrorIs(t, err, ErrKYCNotFound)
+
+	manual := NewKYCService(clientRepo, newKYCRepositoryStub(), nil, nil)
+	record, err := manual.Submit(context.Background(), client.ID, SubmitKYCInput{Level: 1})
+	require.NoError(t, err)
+	_, err = manual.Refresh(context.Background(), client.ID, record.ID)
+	require.ErrorIs(t, err, ErrKYCValidation)
+}
+
+func TestHTTPKYCProvider(t *testing.T) {
+	t.Parallel()
+
+	const secret = "s3cr3t"
+	signer := NewHTTPKYCProvider("", secret, nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			submission := httpKYCSubmission{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&submission))
+			_ = json.NewEncoder(w).Encode(KYCDecision{
+				Reference: "prv-" + submission.Reference,
+				Status:    models.KYCStatusPending,
+			})
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(KYCDecision{Status: models.KYCStatusVerified})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	clientRepo := newClientRepositoryStub()
+	client := newKYCTestClient(t, NewClientService(clientRepo, newAccountRepositoryStub(), nil))
+	provider := NewHTTPKYCProvider(server.URL, secret, server.Client())
+	kycService := NewKYCService(clientRepo, newKYCRepositoryStub(), nil, provider)
+
+	record, err := kycService.Submit(context.Background(), client.ID, SubmitKYCInput{Level: 1})
+	require.NoError(t, err)
+	require.Equal(t, "http", record.Provider)
+	require.Equal(t, "prv-"+record.ID.String(), record.ProviderReference)
+
+	body, err := json.Marshal(KYCDecision{Reference: record.ProviderReference, Status: models.KYCStatusVerified})
+	require.NoError(t, err)
+	callback := func(signedAt time.Time, nonce string) http.Header {
+		timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+		header := http.Header{}
+		header.Set(HeaderKYCTimestamp, timestamp)
+		header.Set(HeaderKYCNonce, nonce)
+		header.Set(HeaderKYCSignature, signer.sign(callbackPayload(timestamp, nonce, body)))
+		return header
+	}
+
+	header := callback(time.Now(), "n1")
+	header.Set(HeaderKYCSignature, "deadbeef")
+	_, err = kycService.HandleCallback(context.Background(), header, body)
+	require.ErrorIs(t, err, ErrKYCCallbackSignature)
+
+	unsigned := http.Header{}
+	unsigned.Set(HeaderKYCSignature, signer.sign(body))
+	_, err = kycService.HandleCallback(context.Background(), unsigned, body)
+	require.ErrorIs(t, err, ErrKYCCallbackSignature)
+
+	_, err = kycService.HandleCallback(context.Background(), callback(time.Now().Add(-time.Hour), "n1"), body)
+	require.ErrorIs(t, err, ErrKYCCallbackSignature)
+
+	verified, err := kycService.HandleCallback(context.Background(), callback(time.Now(), "n1"), body)
+	require.NoError(t, err)
+	require.Equal(t, models.KYCStatusVerified, verified.Status)
+
+	// The same callback cannot be replayed.
+	_, err = kycService.HandleCallback(context.Background(), callback(time.Now(), "n1"), body)
+	require.ErrorIs(t, err, ErrKYCCallbackSignature)
+
+	decision, err := provider.Poll(context.Background(), record.ProviderReference)
+	require.NoError(t, err)
+	require.Equal(t, record.ProviderReference, decision.Reference)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(failing.Close)
+	kycRepo := newKYCRepositoryStub()
+	unavailable := NewKYCService(clientRepo, kycRepo, nil, NewHTTPKYCProvider(failing.URL, secret, failing.Client()))
+	_, err = unavailable.Submit(context.Background(), client.ID, SubmitKYCInput{Level: 1})
+	require.ErrorIs(t, err, ErrKYCProvider)
+	require.Empty(t, kycRepo.records)
+}
+
+func TestKYCServiceExpireDowngradesClient(t *testing.T) {
+	t.Parallel()
+
+	clientRepo := newClientRepositoryStub()
+	clientService := NewClientService(clientRepo, newAccountRepositoryStub(), nil)
+	client := newKYCTestClient(t, clientService)
+	kycRepo := newKYCRepositoryStub()
+	kycService := NewKYCService(clientRepo, kycRepo, nil, nil)
+
+	verify := func(level int) *models.KYCRecord {
+		record, err := kycService.Submit(context.Background(), client.ID, SubmitKYCInput{Level: level})
+		require.NoError(t, err)
+		record, err = kycService.Verify(context.Background(), client.ID, record.ID, VerifyKYCInput{Verifier: "ops"})
+		require.NoError(t, err)
+		return record
+	}
+	expire := func(record *models.KYCRecord) {
+		past := time.Now().UTC().Add(-time.Hour)
+		stored := kycRepo.records[record.ID]
+		stored.ExpiresAt = &past
+	}
+
+	levelZero := verify(0)
+	levelOne := verify(1)
+
+	_, err := kycService.Expire(context.Background(), client.ID, levelOne.ID)
+	require.ErrorIs(t, err, ErrKYCValidation)
+
+	expire(levelOne)
+	expired, err := kycService.Expire(context.Background(), client.ID, levelOne.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.KYCStatusExpired, expired.Status)
+	stored, err := clientService.Get(context.Background(), client.ID)
+	require.NoError(t, err)
+	require.Equal(t, 0, stored.KYCLevel)
+	require.Equal(t, models.KYCStatusVerified, stored.KYCStatus)
+
+	expire(levelZero)
+	_, err = kycService.Expire(context.Background(), client.ID, levelZero.ID)
+	require.NoError(t, err)
+	stored, err = clientService.Get(context.Background(), client.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.KYCStatusExpired, stored.KYCStatus)
+
+	notified, err := kycService.MarkExpiryNotified(context.Background(), client.ID, levelZero.ID)
+	require.NoError(t, err)
+	require.NotNil(t, notified.ExpiryNotifiedAt)
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+var (
+	ErrLoanValidation  = errors.New("loan validation failed")
+	ErrLoanNoRepayment = errors.New("no loan repayment to collect")
+)
+
+type LoanService interface {
+	ListServiced(context.Context) ([]models.Account, error)
+	Schedule(context.Context, uuid.UUID) ([]models.LoanInstallment, error)
+	SettlementAccount(context.Context, uuid.UUID) (*models.Account, error)
+	CompleteDisbursement(context.Context, uuid.UUID, time.Time) (*models.Account, error)
+	Accrue(context.Context, uuid.UUID, time.Time) (*models.Account, error)
+	PlanRepayment(context.Context, uuid.UUID, int64, time.Time) (*models.LoanRepayment, error)
+	CompleteRepayment(context.Context, uuid.UUID, models.LoanRepayment) (*models.Account, error)
+}
+
+type DefaultLoanService struct {
+	accountRepository     repositories.AccountRepository
+	installmentRepository repositories.LoanInstallmentRepository
+}
+
+func NewLoanService(
+	accountRepository repositories.AccountRepository,
+	installmentRepository repositories.LoanInstallmentRepository,
+) LoanService {
+	return &DefaultLoanService{
+		accountRepository:     accountRepository,
+		installmentRepository: installmentRepository,
+	}
+}
+
+// ListServiced lists the active loan accounts which are not repaid yet.
+func (s *DefaultLoanService) ListServiced(ctx context.Context) ([]models.Account, error) {
+	status := models.AccountStatusActive
+	accounts, err := s.accountRepository.List(ctx, repositories.AccountFilter{
+		Status: &status,
+		Loans:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]models.Account, 0, len(accounts))
+	for _, account := range accounts {
+		if account.Loan != nil && account.Loan.Status != models.LoanStatusRepaid {
+			ret = append(ret, account)
+		}
+	}
+	return ret, nil
+}
+
+func (s *DefaultLoanService) Schedule(ctx context.Context, accountID uuid.UUID) ([]models.LoanInstallment, error) {
+	if _, err := s.loadLoan(ctx, accountID); err != nil {
+		return nil, err
+	}
+	return s.installmentRepository.ListByAccount(ctx, accountID)
+}
+
+func (s *DefaultLoanService) SettlementAccount(ctx context.Context, accountID uuid.UUID) (*models.Account, error) {
+	account, err := s.loadLoan(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	settlement, err := s.accountRepository.Get(ctx, account.Loan.SettlementAccountID)
+	if err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	if settlement.Status == models.AccountStatusClosed {
+		return nil, fmt.Errorf("%w: settlement account %s is closed", ErrLoanValidation, settlement.ID)
+	}
+	return settlement, nil
+}
+
+// CompleteDisbursement records that the principal was moved to the settlement
+// account on when and draws up the repayment schedule from that date.
+// Completing a disbursement twice is a no-op.
+func (s *DefaultLoanService) CompleteDisbursement(ctx context.Context, accountID uuid.UUID, when time.Time) (*models.Account, error) {
+	account, err := s.loadLoan(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Loan.Status != models.LoanStatusApproved {
+		return account, nil
+	}
+
+	disbursedAt := normalizeUsageDate(when)
+	existing, err := s.installmentRepository.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) == 0 {
+		installments, err := buildLoanSchedule(account.ID, account.Loan, disbursedAt)
+		if err != nil {
+			return nil, err
+		}
+		for i := range installments {
+			if err := s.installmentRepository.Create(ctx, &installments[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	account.Loan.Status = models.LoanStatusActive
+	account.Loan.DisbursedAt = &disbursedAt
+	account.Loan.AccruedThrough = &disbursedAt
+	account.Loan.OutstandingPrincipal = account.Loan.Principal
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
+// Accrue brings the loan up to date on when. Interest income accrues on the
+// outstanding principal, or on the original principal of flat loans, and is
+// billed as installments fall due. Penalty interest accrues on the overdue
+// amounts. Accruing a date twice is a no-op.
+func (s *DefaultLoanService) Accrue(ctx context.Context, accountID uuid.UUID, when time.Time) (*models.Account, error) {
+	account, err := s.loadLoan(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	loan := account.Loan
+	if loan.DisbursedAt == nil || loan.Status == models.LoanStatusRepaid {
+		return nil, fmt.Errorf("%w: loan is %s", ErrLoanValidation, loan.Status)
+	}
+	when = normalizeUsageDate(when)
+	if loan.AccruedThrough != nil && !when.After(*loan.AccruedThrough) {
+		return account, nil
+	}
+	days := 1
+	if loan.AccruedThrough != nil {
+		days = int(when.Sub(*loan.AccruedThrough).Hours() / 24)
+	}
+
+	rate, err := decimal.NewFromString(loan.Rate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid loan rate", ErrLoanValidation)
+	}
+	basis := loan.OutstandingPrincipal
+	if loan.Amortization == models.LoanAmortizationFlat {
+		basis = loan.Principal
+	}
+	account.InterestAccrued = account.InterestAccrued.Add(dailyLoanInterest(basis, rate, days, account.Currency))
+
+	installments, err := s.installmentRepository.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range installments {
+		installment := &installments[i]
+		if installment.Status == models.InstallmentStatusPaid || installment.DueDate.After(when) {
+			continue
+		}
+		status := models.InstallmentStatusDue
+		if when.After(installment.DueDate.AddDate(0, 0, loan.GraceDays)) {
+			status = models.InstallmentStatusOverdue
+		}
+		if installment.Status == status {
+			continue
+		}
+		if installment.Status == models.InstallmentStatusScheduled {
+			account.InterestAccrued = decimal.Max(account.InterestAccrued.Sub(minorUnitsToDecimal(installment.Interest, account.Currency)), decimal.Zero)
+		}
+		installment.Status = status
+		if err := s.installmentRepository.Update(ctx, installment); err != nil {
+			return nil, err
+		}
+	}
+
+	refreshLoanArrears(loan, installments, when)
+	if loan.PenaltyRate != "" && loan.ArrearsAmount > 0 {
+		penaltyRate, err := decimal.NewFromString(loan.PenaltyRate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid loan penalty rate", ErrLoanValidation)
+		}
+		loan.PenaltyAccrued = loan.PenaltyAccrued.Add(dailyLoanInterest(loan.ArrearsAmount, penaltyRate, days, account.Currency))
+	}
+	loan.AccruedThrough = &when
+
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
+// PlanRepayment allocates what can be collected from the available balance of
+// the settlement account to the accrued penalty, then the interest due, then
+// the principal due, oldest installments first. The plan is recorded on the
+// loan until it is completed so a retried collection posts the same amounts.
+// Its reference includes the amount repaid so far, so every repayment of a
+// day is posted under its own reference.
+func (s *DefaultLoanService) PlanRepayment(ctx context.Context, accountID uuid.UUID, available int64, when time.Time) (*models.LoanRepayment, error) {
+	account, err := s.loadLoan(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	loan := account.Loan
+	if loan.PendingRepayment != nil {
+		return loan.PendingRepayment, nil
+	}
+	if loan.DisbursedAt == nil || loan.Status == models.LoanStatusRepaid {
+		return nil, fmt.Errorf("%w: loan is %s", ErrLoanValidation, loan.Status)
+	}
+
+	installments, err := s.installmentRepository.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	penaltyDue, _, err := decimalToMinorHalfUp(loan.PenaltyAccrued, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	var interestDue, principalDue int64
+	for _, installment := range installments {
+		if !installmentDue(installment) {
+			continue
+		}
+		interestDue += installment.Interest - installment.InterestPaid
+		principalDue += installment.Principal - installment.PrincipalPaid
+	}
+
+	funds := max(available, 0)
+	when = normalizeUsageDate(when)
+	repayment := &models.LoanRepayment{
+		Reference: fmt.Sprintf("loan:%s:%s:repayment:%d", account.ID, when.Format(time.DateOnly), loan.AmountRepaid),
+		Date:      when,
+	}
+	repayment.Penalty = min(funds, penaltyDue)
+	funds -= repayment.Penalty
+	repayment.Interest = min(funds, interestDue)
+	funds -= repayment.Interest
+	repayment.Principal = min(funds, principalDue)
+	if repayment.Penalty+repayment.Interest+repayment.Principal == 0 {
+		return nil, ErrLoanNoRepayment
+	}
+
+	loan.PendingRepayment = repayment
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return repayment, nil
+}
+
+// CompleteRepayment applies a planned repayment once it was collected. The
+// loan is repaid and its account closed when nothing is left owing.
+// Completing a repayment twice is a no-op.
+func (s *DefaultLoanService) CompleteRepayment(ctx context.Context, accountID uuid.UUID, repayment models.LoanRepayment) (*models.Account, error) {
+	account, err := s.loadLoan(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	loan := account.Loan
+	if loan.PendingRepayment == nil || loan.PendingRepayment.Reference != repayment.Reference {
+		return account, nil
+	}
+	repayment = *loan.PendingRepayment
+
+	installments, err := s.installmentRepository.ListByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	interest, principal := repayment.Interest, repayment.Principal
+	for i := range installments {
+		installment := &installments[i]
+		if !installmentDue(*installment) {
+			continue
+		}
+		interestPaid := min(interest, installment.Interest-installment.InterestPaid)
+		principalPaid := min(principal, installment.Principal-installment.PrincipalPaid)
+		if interestPaid == 0 && principalPaid == 0 {
+			continue
+		}
+		interest -= interestPaid
+		principal -= principalPaid
+		installment.InterestPaid += interestPaid
+		installment.PrincipalPaid += principalPaid
+		if installment.InterestPaid == installment.Interest && installment.PrincipalPaid == installment.Principal {
+			installment.Status = models.InstallmentStatusPaid
+			installment.PaidAt = &now
+		}
+		if err := s.installmentRepository.Update(ctx, installment); err != nil {
+			return nil, err
+		}
+	}
+
+	loan.PenaltyAccrued = decimal.Max(loan.PenaltyAccrued.Sub(minorUnitsToDecimal(repayment.Penalty, account.Currency)), decimal.Zero)
+	loan.OutstandingPrincipal -= repayment.Principal
+	loan.AmountRepaid += repayment.Penalty + repayment.Interest + repayment.Principal
+	loan.PendingRepayment = nil
+	refreshLoanArrears(loan, installments, repayment.Date)
+
+	if loan.OutstandingPrincipal <= 0 && loan.PenaltyAccrued.IsZero() && allInstallmentsPaid(installments) {
+		loan.Status = models.LoanStatusRepaid
+		loan.RepaidAt = &now
+		account.InterestAccrued = decimal.Zero
+		account.Status = models.AccountStatusClosed
+		account.ClosedAt = &now
+	}
+
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
+func (s *DefaultLoanService) loadLoan(ctx context.Context, id uuid.UUID) (*models.Account, error) {
+	account, err := s.accountRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	if account.Loan == nil {
+		return nil, fmt.Errorf("%w: account is not a loan", ErrLoanValidation)
+	}
+	return account, nil
+}
+
+// buildLoanSchedule splits the principal into installments falling due every
+// repayment period after start. Flat loans charge interest on the original
+// principal, reducing balance loans pay a constant installment and bullet
+// loans only pay interest until the last installment. The last installment
+// takes the rounding remainder of the principal.
+func buildLoanSchedule(accountID uuid.UUID, loan *models.AccountLoan, start time.Time) ([]models.LoanInstallment, error) {
+	rate, err := decimal.NewFromString(loan.Rate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid loan rate", ErrLoanValidation)
+	}
+	periodsPerYear := int64(12)
+	if loan.RepaymentFrequency == models.RepaymentFrequencyWeekly {
+		periodsPerYear = 52
+	}
+	periodRate := rate.Div(decimal.NewFromInt(100)).Div(decimal.NewFromInt(periodsPerYear))
+	principal := decimal.NewFromInt(loan.Principal)
+	count := loan.Installments
+
+	var payment int64
+	if loan.Amortization == models.LoanAmortizationReducingBalance && !periodRate.IsZero() {
+		factor := periodRate.Add(decimal.NewFromInt(1)).Pow(decimal.NewFromInt(int64(count)))
+		payment = principal.Mul(periodRate).Mul(factor).Div(factor.Sub(decimal.NewFromInt(1))).Round(0).IntPart()
+	}
+
+	ret := make([]models.LoanInstallment, 0, count)
+	outstanding := loan.Principal
+	for number := 1; number <= count; number++ {
+		var installmentPrincipal, interest int64
+		switch loan.Amortization {
+		case models.LoanAmortizationFlat:
+			interest = principal.Mul(periodRate).Round(0).IntPart()
+			installmentPrincipal = loan.Principal / int64(count)
+		case models.LoanAmortizationReducingBalance:
+			interest = decimal.NewFromInt(outstanding).Mul(periodRate).Round(0).IntPart()
+			installmentPrincipal = payment - interest
+			if payment == 0 {
+				installmentPrincipal = loan.Principal / int64(count)
+			}
+		case models.LoanAmortizationBullet:
+			interest = principal.Mul(periodRate).Round(0).IntPart()
+		default:
+			return nil, fmt.Errorf("%w: invalid amortization %s", ErrLoanValidation, loan.Amortization)
+		}
+		if number == count {
+			installmentPrincipal = outstanding
+		}
+		installmentPrincipal = min(max(installmentPrincipal, 0), outstanding)
+		outstanding -= installmentPrincipal
+
+		ret = append(ret, models.LoanInstallment{
+			AccountID: accountID,
+			Number:    number,
+			DueDate:   loanDueDate(start, loan.RepaymentFrequency, number),
+			Principal: installmentPrincipal,
+			Interest:  interest,
+			Status:    models.InstallmentStatusScheduled,
+		})
+	}
+	return ret, nil
+}
+
+func loanDueDate(start time.Time, frequency string, number int) time.Time {
+	if frequency == models.RepaymentFrequencyWeekly {
+		return start.AddDate(0, 0, 7*number)
+	}
+	return termMaturityDate(start, number, models.TermUnitMonths)
+}
+
+// dailyLoanInterest is the interest in major units earned by an amount in
+// atomic units over days at an annual rate in percent.
+func dailyLoanInterest(amount int64, annualRate decimal.Decimal, days int, currency string) decimal.Decimal {
+	return minorUnitsToDecimal(amount, currency).
+		Mul(annualRate.Div(decimal.NewFromInt(100))).
+		Mul(decimal.NewFromInt(int64(days))).
+		Div(decimal.NewFromInt(365)).
+		Round(12)
+}
+
+// refreshLoanArrears recomputes the arrears of the loan on when. Days past due
+// count from the oldest unpaid due installment while the arrears only sum the
+// installments past their grace period.
+func refreshLoanArrears(loan *models.AccountLoan, installments []models.LoanInstallment, when time.Time) {
+	loan.ArrearsAmount = 0
+	loan.DaysPastDue = 0
+	for _, installment := range installments {
+		if !installmentDue(installment) {
+			continue
+		}
+		if loan.DaysPastDue == 0 {
+			loan.DaysPastDue = max(int(when.Sub(installment.DueDate).Hours()/24), 0)
+		}
+		if installment.Status == models.InstallmentStatusOverdue {
+			loan.ArrearsAmount += installment.Principal - installment.PrincipalPaid + installment.Interest - installment.InterestPaid
+		}
+	}
+	if loan.Status == models.LoanStatusActive || loan.Status == models.LoanStatusInArrears {
+		loan.Status = models.LoanStatusActive
+		if loan.ArrearsAmount > 0 {
+			loan.Status = models.LoanStatusInArrears
+		}
+	}
+}
+
+func installmentDue(installment models.LoanInstallment) bool {
+	return installment.Status == models.InstallmentStatusDue || installment.Status == models.InstallmentStatusOverdue
+}
+
+func allInstallmentsPaid(installments []models.LoanInstallment) bool {
+	for _, installment := range installments {
+		if installment.Status != models.InstallmentStatusPaid {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,320 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+)
+
+func newLoanFixture(t *testing.T, accountRepo *accountRepositoryStub, productRepo *productRepositoryStub, clientRepo *clientRepositoryStub) (*models.Client, *models.Product, *models.Account) {
+	t.Helper()
+
+	client := &models.Client{
+		ID:           uuid.New(),
+		ClientNumber: "CL-2026-000200",
+		Type:         models.ClientTypeIndividual,
+		Status:       models.ClientStatusActive,
+		KYCLevel:     1,
+		KYCStatus:    models.KYCStatusVerified,
+	}
+	require.NoError(t, clientRepo.Create(context.Background(), client))
+
+	product := &models.Product{
+		ID:       uuid.New(),
+		Code:     "LN-USD-12M",
+		Name:     "Personal Loan 12 Months",
+		Category: "loan",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			MinOpeningBalance: "0",
+			MinBalance:        "0",
+		},
+		LoanConfig: &models.LoanConfig{
+			Amortization:       models.LoanAmortizationFlat,
+			Rate:               "12",
+			Installments:       2,
+			RepaymentFrequency: models.RepaymentFrequencyMonthly,
+			MinPrincipal:       "100.00",
+			MaxPrincipal:       strPtr("5000.00"),
+			PenaltyRate:        "24",
+			GraceDays:          5,
+		},
+	}
+	require.NoError(t, productRepo.Create(context.Background(), product))
+
+	settlement := &models.Account{
+		ID:       uuid.New(),
+		ClientID: client.ID,
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+		WalletID: "wallet-current",
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), settlement))
+	return client, product, settlement
+}
+
+func TestLoanOpenValidatesPrincipalAndSettlementAccount(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	clientRepo := newClientRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	service := NewAccountService(accountRepo, clientRepo, productRepo, newDailyUsageRepositoryStub())
+	client, product, settlement := newLoanFixture(t, accountRepo, productRepo, clientRepo)
+
+	otherClientAccount := &models.Account{ID: uuid.New(), ClientID: uuid.New(), Currency: "USD", Status: models.AccountStatusActive}
+	require.NoError(t, accountRepo.Create(context.Background(), otherClientAccount))
+
+	for name, input := range map[string]OpenAccountInput{
+		"missing settlement account": {Principal: json.Number("1000.00")},
+		"foreign settlement account": {Principal: json.Number("1000.00"), SettlementAccountID: &otherClientAccount.ID},
+		"below min principal":        {Principal: json.Number("50.00"), SettlementAccountID: &settlement.ID},
+		"above max principal":        {Principal: json.Number("6000.00"), SettlementAccountID: &settlement.ID},
+		"opening deposit":            {Principal: json.Number("1000.00"), SettlementAccountID: &settlement.ID, OpeningDeposit: json.Number("10.00")},
+	} {
+		input.ClientID = client.ID
+		input.ProductID = product.ID
+		_, err := service.Open(context.Background(), input)
+		require.ErrorIs(t, err, ErrAccountValidation, name)
+	}
+
+	account, err := service.Open(context.Background(), OpenAccountInput{
+		ClientID:            client.ID,
+		ProductID:           product.ID,
+		Principal:           json.Number("1000.00"),
+		SettlementAccountID: &settlement.ID,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, account.Loan)
+	require.EqualValues(t, 100_000, account.Loan.Principal)
+	require.Equal(t, models.LoanStatusApproved, account.Loan.Status)
+	require.Equal(t, settlement.ID, account.Loan.SettlementAccountID)
+
+	now := time.Now().UTC()
+	_, err = service.ValidateCredit(context.Background(), account.ID, 100, 0, now)
+	require.ErrorIs(t, err, ErrAccountValidation)
+	_, err = service.ValidateDebit(context.Background(), account.ID, 100, 0, now)
+	require.ErrorIs(t, err, ErrAccountValidation)
+}
+
+func TestBuildLoanSchedule(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	for name, tc := range map[string]struct {
+		amortization string
+		check        func(*testing.T, []models.LoanInstallment)
+	}{
+		models.LoanAmortizationFlat: {
+			check: func(t *testing.T, installments []models.LoanInstallment) {
+				for _, installment := range installments {
+					require.EqualValues(t, 10_000, installment.Principal)
+					require.EqualValues(t, 1_200, installment.Interest)
+				}
+			},
+		},
+		models.LoanAmortizationReducingBalance: {
+			check: func(t *testing.T, installments []models.LoanInstallment) {
+				require.EqualValues(t, 1_200, installments[0].Interest)
+				for _, installment := range installments[:len(installments)-1] {
+					require.EqualValues(t, 10_662, installment.Principal+installment.Interest)
+				}
+				require.Less(t, installments[len(installments)-1].Interest, installments[0].Interest)
+			},
+		},
+		models.LoanAmortizationBullet: {
+			check: func(t *testing.T, installments []models.LoanInstallment) {
+				for _, installment := range installments[:len(installments)-1] {
+					require.Zero(t, installment.Principal)
+					require.EqualValues(t, 1_200, installment.Interest)
+				}
+				require.EqualValues(t, 120_000, installments[len(installments)-1].Principal)
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			installments, err := buildLoanSchedule(uuid.New(), &models.AccountLoan{
+				Principal:          120_000,
+				Rate:               "12",
+				Amortization:       name,
+				Installments:       12,
+				RepaymentFrequency: models.RepaymentFrequencyMonthly,
+			}, start)
+			require.NoError(t, err)
+			require.Len(t, installments, 12)
+
+			var principal int64
+			for i, installment := range installments {
+				require.Equal(t, i+1, installment.Number)
+				require.Equal(t, models.InstallmentStatusScheduled, installment.Status)
+				principal += installment.Principal
+			}
+			require.EqualValues(t, 120_000, principal)
+			require.Equal(t, time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC), installments[0].DueDate)
+			tc.check(t, installments)
+		})
+	}
+}
+
+func TestLoanServicingLifecycle(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	installmentRepo := newLoanInstallmentRepositoryStub()
+	service := NewLoanService(accountRepo, installmentRepo)
+
+	settlementID := uuid.New()
+	account := &models.Account{
+		ID:       uuid.New(),
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+		Loan: &models.AccountLoan{
+			Principal:           100_000,
+			Rate:                "12",
+			PenaltyRate:         "24",
+			Amortization:        models.LoanAmortizationFlat,
+			Installments:        2,
+			RepaymentFrequency:  models.RepaymentFrequencyMonthly,
+			GraceDays:           5,
+			Status:              models.LoanStatusApproved,
+			SettlementAccountID: settlementID,
+			PenaltyAccrued:      decimal.Zero,
+		},
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	serviced, err := service.ListServiced(context.Background())
+	require.NoError(t, err)
+	require.Len(t, serviced, 1)
+
+	disbursedAt := time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)
+	_, err = service.CompleteDisbursement(context.Background(), account.ID, disbursedAt)
+	require.NoError(t, err)
+	_, err = service.CompleteDisbursement(context.Background(), account.ID, disbursedAt.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	schedule, err := service.Schedule(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, schedule, 2)
+
+	// The first installment falls due and is partially repaid.
+	firstDue := time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)
+	loaded, err := service.Accrue(context.Background(), account.ID, firstDue)
+	require.NoError(t, err)
+	require.Equal(t, models.LoanStatusActive, loaded.Loan.Status)
+	require.Zero(t, loaded.Loan.ArrearsAmount)
+
+	repayment, err := service.PlanRepayment(context.Background(), account.ID, 20_000, firstDue)
+	require.NoError(t, err)
+	require.Equal(t, "loan:"+account.ID.String()+":2026-02-15:repayment:0", repayment.Reference)
+	require.EqualValues(t, 0, repayment.Penalty)
+	require.EqualValues(t, 1_000, repayment.Interest)
+	require.EqualValues(t, 19_000, repayment.Principal)
+
+	retried, err := service.PlanRepayment(context.Background(), account.ID, 0, firstDue)
+	require.NoError(t, err)
+	require.Equal(t, repayment, retried)
+
+	loaded, err = service.CompleteRepayment(context.Background(), account.ID, *repayment)
+	require.NoError(t, err)
+	require.EqualValues(t, 81_000, loaded.Loan.OutstandingPrincipal)
+	require.EqualValues(t, 20_000, loaded.Loan.AmountRepaid)
+	require.Nil(t, loaded.Loan.PendingRepayment)
+
+	// The rest of the installment goes unpaid past the grace period.
+	loaded, err = service.Accrue(context.Background(), account.ID, firstDue.AddDate(0, 0, 10))
+	require.NoError(t, err)
+	require.Equal(t, models.LoanStatusInArrears, loaded.Loan.Status)
+	require.EqualValues(t, 31_000, loaded.Loan.ArrearsAmount)
+	require.Equal(t, 10, loaded.Loan.DaysPastDue)
+	require.True(t, loaded.Loan.PenaltyAccrued.IsPositive())
+
+	repayment, err = service.PlanRepayment(context.Background(), account.ID, 100_000, firstDue.AddDate(0, 0, 10))
+	require.NoError(t, err)
+	require.EqualValues(t, 204, repayment.Penalty)
+	require.EqualValues(t, 0, repayment.Interest)
+	require.EqualValues(t, 31_000, repayment.Principal)
+
+	loaded, err = service.CompleteRepayment(context.Background(), account.ID, *repayment)
+	require.NoError(t, err)
+	require.Equal(t, models.LoanStatusActive, loaded.Loan.Status)
+	require.Zero(t, loaded.Loan.DaysPastDue)
+	require.True(t, loaded.Loan.PenaltyAccrued.IsZero())
+
+	_, err = service.PlanRepayment(context.Background(), account.ID, 100_000, firstDue.AddDate(0, 0, 11))
+	require.ErrorIs(t, err, ErrLoanNoRepayment)
+
+	// The last installment repays the loan and closes the account.
+	lastDue := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	_, err = service.Accrue(context.Background(), account.ID, lastDue)
+	require.NoError(t, err)
+	repayment, err = service.PlanRepayment(context.Background(), account.ID, 100_000, lastDue)
+	require.NoError(t, err)
+	require.EqualValues(t, 1_000, repayment.Interest)
+	require.EqualValues(t, 50_000, repayment.Principal)
+
+	loaded, err = service.CompleteRepayment(context.Background(), account.ID, *repayment)
+	require.NoError(t, err)
+	require.Equal(t, models.LoanStatusRepaid, loaded.Loan.Status)
+	require.Equal(t, models.AccountStatusClosed, loaded.Status)
+	require.Zero(t, loaded.Loan.OutstandingPrincipal)
+
+	serviced, err = service.ListServiced(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, serviced)
+}
+
+func TestLoanRepaymentsOfTheSameDayHaveDistinctReferences(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	service := NewLoanService(accountRepo, newLoanInstallmentRepositoryStub())
+
+	account := &models.Account{
+		ID:       uuid.New(),
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+		Loan: &models.AccountLoan{
+			Principal:           100_000,
+			Rate:                "12",
+			Amortization:        models.LoanAmortizationFlat,
+			Installments:        2,
+			RepaymentFrequency:  models.RepaymentFrequencyMonthly,
+			Status:              models.LoanStatusApproved,
+			SettlementAccountID: uuid.New(),
+			PenaltyAccrued:      decimal.Zero,
+		},
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	_, err := service.CompleteDisbursement(context.Background(), account.ID, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	due := time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)
+	_, err = service.Accrue(context.Background(), account.ID, due)
+	require.NoError(t, err)
+
+	first, err := service.PlanRepayment(context.Background(), account.ID, 10_000, due)
+	require.NoError(t, err)
+	_, err = service.CompleteRepayment(context.Background(), account.ID, *first)
+	require.NoError(t, err)
+
+	second, err := service.PlanRepayment(context.Background(), account.ID, 10_000, due)
+	require.NoError(t, err)
+	require.Equal(t, "loan:"+account.ID.String()+":2026-02-15:repayment:10000", second.Reference)
+	require.NotEqual(t, first.Reference, second.Reference)
+
+	// Completing the first repayment again does not apply the second one.
+	loaded, err := service.CompleteRepayment(context.Background(), account.ID, *first)
+	require.NoError(t, err)
+	require.Equal(t, second, loaded.Loan.PendingRepayment)
+	require.EqualValues(t, 91_000, loaded.Loan.OutstandingPrincipal)
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+var (
+	ErrMonitoringValidation        = errors.New("transaction monitoring validation failed")
+	ErrMonitoringAlertNotFound     = errors.New("monitoring alert not found")
+	ErrMonitoringAlertInvalidState = errors.New("monitoring alert cannot move to this status")
+)
+
+type MonitoringService interface {
+	// Monitor records the credits and debits of CBA accounts in a committed
+	// ledger transaction, evaluates the rules against them and returns the
+	// alerts opened or updated.
+	Monitor(ctx context.Context, ledger string, transaction ledgerinternal.Transaction) ([]models.MonitoringAlert, error)
+	Rules() []MonitoringRule
+	ListAlerts(context.Context, repositories.MonitoringAlertFilter) ([]models.MonitoringAlert, error)
+	GetAlert(context.Context, uuid.UUID) (*models.MonitoringAlert, error)
+	// AssignAlert puts an alert under investigation by the assignee, the
+	// actor when none is given.
+	AssignAlert(context.Context, uuid.UUID, AssignAlertInput) (*models.MonitoringAlert, error)
+	EscalateAlert(context.Context, uuid.UUID, TriageAlertInput) (*models.MonitoringAlert, error)
+	CloseAlert(context.Context, uuid.UUID, CloseAlertInput) (*models.MonitoringAlert, error)
+}
+
+type TriageAlertInput struct {
+	Note  string `json:"note,omitempty"`
+	Actor string `json:"-"`
+}
+
+type AssignAlertInput struct {
+	TriageAlertInput
+	Assignee string `json:"assignee,omitempty"`
+}
+
+type CloseAlertInput struct {
+	TriageAlertInput
+	Resolution string `json:"resolution"`
+}
+
+type DefaultMonitoringService struct {
+	accountRepository     repositories.AccountRepository
+	transactionRepository repositories.MonitoredTransactionRepository
+	alertRepository       repositories.MonitoringAlertRepository
+	rules                 []MonitoringRule
+}
+
+// NewMonitoringService evaluates rules, or DefaultMonitoringRules when
+// rules is empty.
+func NewMonitoringService(
+	accountRepository repositories.AccountRepository,
+	transactionRepository repositories.MonitoredTransactionRepository,
+	alertRepository repositories.MonitoringAlertRepository,
+	rules []MonitoringRule,
+) MonitoringService {
+	if len(rules) == 0 {
+		rules = DefaultMonitoringRules()
+	}
+	return &DefaultMonitoringService{
+		accountRepository:     accountRepository,
+		transactionRepository: transactionRepository,
+		alertRepository:       alertRepository,
+		rules:                 rules,
+	}
+}
+
+func (s *DefaultMonitoringService) Rules() []MonitoringRule {
+	return s.rules
+}
+
+func (s *DefaultMonitoringService) Monitor(ctx context.Context, ledger string, transaction ledgerinternal.Transaction) ([]models.MonitoringAlert, error) {
+	if transaction.ID == nil {
+		return nil, nil
+	}
+
+	alerts := make([]models.MonitoringAlert, 0)
+	for _, movement := range walletMovements(transaction) {
+		account, err := s.accountRepository.GetByWalletID(ctx, movement.walletID)
+		if err != nil {
+			if postgres.IsNotFoundError(err) || errors.Is(err, postgres.ErrNotFound) {
+				// Not the wallet of a CBA account.
+				continue
+			}
+			return nil, err
+		}
+
+		monitored := &models.MonitoredTransaction{
+			AccountID:     account.ID,
+			Ledger:        ledger,
+			TransactionID: *transaction.ID,
+			Reference:     transaction.Reference,
+			Direction:     movement.direction,
+			Amount:        movement.amount,
+			Currency:      movement.currency,
+			Timestamp:     transaction.Timestamp.Time.UTC(),
+		}
+		if err := s.transactionRepository.Create(ctx, monitored); err != nil {
+			if !errors.Is(err, postgres.ErrConstraintsFailed{}) {
+				return nil, err
+			}
+			// Already recorded: the transaction is evaluated again if the
+			// evaluation did not complete.
+			monitored, err = s.transactionRepository.GetByPosting(ctx, ledger, *transaction.ID, account.ID, movement.direction)
+			if err != nil {
+				return nil, err
+			}
+			if monitored.EvaluatedAt != nil {
+				continue
+			}
+		}
+
+		raised, err := s.evaluate(ctx, account, *monitored)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.transactionRepository.MarkEvaluated(ctx, monitored); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, raised...)
+	}
+	return alerts, nil
+}
+
+func (s *DefaultMonitoringService) evaluate(ctx context.Context, account *models.Account, current models.MonitoredTransaction) ([]models.MonitoringAlert, error) {
+	var longest MonitoringWindow
+	for _, rule := range s.rules {
+		longest = max(longest, rule.Window)
+	}
+	from := current.Timestamp.Add(-time.Duration(longest))
+	history, err := s.transactionRepository.List(ctx, repositories.MonitoredTransactionFilter{
+		AccountID: &account.ID,
+		From:      &from,
+	})
+	if err != nil {
+		return nil, err
+	}
+	lastActivity, err := s.lastActivity(ctx, account, current)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]models.MonitoringAlert, 0)
+	for _, rule := range s.rules {
+		match := rule.evaluate(account, current, history, lastActivity)
+		if match == nil {
+			continue
+		}
+		alert, err := s.raise(ctx, account, rule, match)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+	return alerts, nil
+}
+
+// lastActivity is the most recent transaction of the account before
+// current, or its last recorded activity, or its opening.
+func (s *DefaultMonitoringService) lastActivity(ctx context.Context, account *models.Account, current models.MonitoredTransaction) (time.Time, error) {
+	last := account.OpenedAt
+	if account.LastActivityAt != nil && account.LastActivityAt.Before(current.Timestamp) && account.LastActivityAt.After(last) {
+		last = *account.LastActivityAt
+	}
+	previous, err := s.transactionRepository.List(ctx, repositories.MonitoredTransactionFilter{
+		AccountID: &account.ID,
+		Before:    &current.Timestamp,
+		Limit:     1,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(previous) > 0 && previous[0].Timestamp.After(last) {
+		last = previous[0].Timestamp
+	}
+	return last, nil
+}
+
+// raise opens an alert for the match, or adds its transactions to the
+// evidence of the alert of the same rule still under review.
+func (s *DefaultMonitoringService) raise(ctx context.Context, account *models.Account, rule MonitoringRule, match *monitoringMatch) (*models.MonitoringAlert, error) {
+	existing, err := s.alertRepository.List(ctx, repositories.MonitoringAlertFilter{
+		AccountID: &account.ID,
+		Rule:      &rule.Name,
+		Statuses:  []string{models.AlertStatusOpen, models.AlertStatusInvestigating, models.AlertStatusEscalated},
+		Limit:     1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		alert := &existing[0]
+		alert.Evidence = mergeAlertEvidence(alert.Evidence, match.transactions)
+		alert.Summary = match.summary
+		if err := s.alertRepository.Update(ctx, alert); err != nil {
+			return nil, err
+		}
+		return alert, nil
+	}
+
+	alert := &models.MonitoringAlert{
+		AccountID: account.ID,
+		ClientID:  account.ClientID,
+		Rule:      rule.Name,
+		RuleType:  rule.Type,
+		Severity:  rule.severity(),
+		Status:    models.AlertStatusOpen,
+		Summary:   match.summary,
+		Evidence:  mergeAlertEvidence(nil, match.transactions),
+		Notes:     []models.AlertNote{},
+	}
+	if err := s.alertRepository.Create(ctx, alert); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+func (s *DefaultMonitoringService) ListAlerts(ctx context.Context, filter repositories.MonitoringAlertFilter) ([]models.MonitoringAlert, error) {
+	return s.alertRepository.List(ctx, filter)
+}
+
+func (s *DefaultMonitoringService) GetAlert(ctx context.Context, id uuid.UUID) (*models.MonitoringAlert, error) {
+	alert, err := s.alertRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveMonitoringAlertRepositoryError(err)
+	}
+	return alert, nil
+}
+
+func (s *DefaultMonitoringService) AssignAlert(ctx context.Context, id uuid.UUID, input AssignAlertInput) (*models.MonitoringAlert, error) {
+	return s.triage(ctx, id, "assigned", input.TriageAlertInput, func(alert *models.MonitoringAlert, actor string) error {
+		if alert.Status == models.AlertStatusClosed {
+			return fmt.Errorf("%w: alert is closed", ErrMonitoringAlertInvalidState)
+		}
+		alert.Assignee = strings.TrimSpace(input.Assignee)
+		if alert.Assignee == "" {
+			alert.Assignee = actor
+		}
+		if alert.Status == models.AlertStatusOpen {
+			alert.Status = models.AlertStatusInvestigating
+		}
+		return nil
+	})
+}
+
+func (s *DefaultMonitoringService) EscalateAlert(ctx context.Context, id uuid.UUID, input TriageAlertInput) (*models.MonitoringAlert, error) {
+	return s.triage(ctx, id, models.AlertStatusEscalated, input, func(alert *models.MonitoringAlert, _ string) error {
+		switch alert.Status {
+		case models.AlertStatusOpen, models.AlertStatusInvestigating:
+		default:
+			return fmt.Errorf("%w: alert is %s", ErrMonitoringAlertInvalidState, alert.Status)
+		}
+		if strings.TrimSpace(input.Note) == "" {
+			return fmt.Errorf("%w: note is required to escalate an alert", ErrMonitoringValidation)
+		}
+		alert.Status = models.AlertStatusEscalated
+		return nil
+	})
+}
+
+func (s *DefaultMonitoringService) CloseAlert(ctx context.Context, id uuid.UUID, input CloseAlertInput) (*models.MonitoringAlert, error) {
+	return s.triage(ctx, id, models.AlertStatusClosed, input.TriageAlertInput, func(alert *models.MonitoringAlert, _ string) error {
+		if alert.Status == models.AlertStatusClosed {
+			return fmt.Errorf("%w: alert is already closed", ErrMonitoringAlertInvalidState)
+		}
+		switch input.Resolution {
+		case models.AlertResolutionFalsePositive, models.AlertResolutionLegitimate, models.AlertResolutionReported:
+		default:
+			return fmt.Errorf("%w: resolution must be one of %s, %s or %s", ErrMonitoringValidation,
+				models.AlertResolutionFalsePositive, models.AlertResolutionLegitimate, models.AlertResolutionReported)
+		}
+		if strings.TrimSpace(input.Note) == "" {
+			return fmt.Errorf("%w: note is required to close an alert", ErrMonitoringValidation)
+		}
+		now := time.Now().UTC()
+		alert.Status = models.AlertStatusClosed
+		alert.Resolution = input.Resolution
+		alert.ClosedAt = &now
+		return nil
+	})
+}
+
+// triage applies a triage action to an alert and records it in its notes.
+func (s *DefaultMonitoringService) triage(ctx context.Context, id uuid.UUID, action string, input TriageAlertInput, apply func(*models.MonitoringAlert, string) error) (*models.MonitoringAlert, error) {
+	actor := strings.TrimSpace(input.Actor)
+	if actor == "" {
+		return nil, fmt.Errorf("%w: the actor is unknown", ErrMonitoringValidation)
+	}
+	alert, err := s.alertRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveMonitoringAlertRepositoryError(err)
+	}
+
+	from := alert.Status
+	if err := apply(alert, actor); err != nil {
+		return nil, err
+	}
+	alert.Notes = append(alert.Notes, models.AlertNote{
+		Action:    action,
+		Actor:     actor,
+		Note:      strings.TrimSpace(input.Note),
+		CreatedAt: time.Now().UTC(),
+	})
+	if err := s.alertRepository.Transition(ctx, alert, from); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, fmt.Errorf("%w: alert was updated concurrently", ErrMonitoringAlertInvalidState)
+		}
+		return nil, err
+	}
+	return alert, nil
+}
+
+// walletMovement is the amount credited to or debited from the available
+// balance of a wallet by a transaction.
+type walletMovement struct {
+	walletID  string
+	currency  string
+	direction string
+	amount    decimal.Decimal
+}
+
+// walletMovements sums the postings of a transaction per wallet available
+// address. Postings between addresses of the same wallet, such as liens,
+// are not movements.
+func walletMovements(transaction ledgerinternal.Transaction) []walletMovement {
+	type key struct {
+		walletID  string
+		asset     string
+		direction string
+	}
+	totals := map[key]*big.Int{}
+	add := func(k key, amount *big.Int) {
+		if totals[k] == nil {
+			totals[k] = new(big.Int)
+		}
+		totals[k].Add(totals[k], amount)
+	}
+	for _, posting := range transaction.Postings {
+		if posting.Amount == nil || posting.Amount.Sign() <= 0 {
+			continue
+		}
+		source, sourceAvailable := parseWalletAddress(posting.Source)
+		destination, destinationAvailable := parseWalletAddress(posting.Destination)
+		if source != "" && source == destination {
+			continue
+		}
+		if destinationAvailable {
+			add(key{destination, posting.Asset, models.MonitoringDirectionCredit}, posting.Amount)
+		}
+		if sourceAvailable {
+			add(key{source, posting.Asset, models.MonitoringDirectionDebit}, posting.Amount)
+		}
+	}
+
+	movements := make([]walletMovement, 0, len(totals))
+	for k, total := range totals {
+		currency, precision := splitAsset(k.asset)
+		movements = append(movements, walletMovement{
+			walletID:  k.walletID,
+			currency:  currency,
+			direction: k.direction,
+			amount:    decimal.NewFromBigInt(total, -int32(precision)),
+		})
+	}
+	sort.Slice(movements, func(i, j int) bool {
+		if movements[i].walletID != movements[j].walletID {
+			return movements[i].walletID < movements[j].walletID
+		}
+		return movements[i].direction < movements[j].direction
+	})
+	return movements
+}
+
+// parseWalletAddress returns the wallet of an address of the form
+// users:{walletID}:wallets:{currency}:{balance} and whether it is the
+// available balance.
+func parseWalletAddress(address string) (string, bool) {
+	rest, ok := strings.CutPrefix(address, "users:")
+	if !ok {
+		return "", false
+	}
+	walletID, balance, ok := strings.Cut(rest, ":wallets:")
+	if !ok || walletID == "" || strings.Contains(walletID, ":") {
+		return "", false
+	}
+	return walletID, strings.HasSuffix(balance, ":available")
+}
+
+// splitAsset splits an asset such as USD/2 into its currency and precision.
+func splitAsset(asset string) (string, int) {
+	currency, precision, ok := strings.Cut(asset, "/")
+	if !ok {
+		return asset, 0
+	}
+	value, err := strconv.Atoi(precision)
+	if err != nil {
+		return currency, 0
+	}
+	return currency, value
+}
+
+func mergeAlertEvidence(evidence []models.AlertEvidence, transactions []models.MonitoredTransaction) []models.AlertEvidence {
+	ret := append([]models.AlertEvidence{}, evidence...)
+	for _, transaction := range transactions {
+		seen := false
+		for _, existing := range ret {
+			if existing.Ledger == transaction.Ledger && existing.TransactionID == transaction.TransactionID && existing.Direction == transaction.Direction {
+				seen = true
+				break
+			}
+		}
+		if seen {
+			continue
+		}
+		ret = append(ret, models.AlertEvidence{
+			Ledger:        transaction.Ledger,
+			TransactionID: transaction.TransactionID,
+			Reference:     transaction.Reference,
+			Direction:     transaction.Direction,
+			Amount:        transaction.Amount,
+			Currency:      transaction.Currency,
+			Timestamp:     transaction.Timestamp,
+			Link:          fmt.Sprintf("/v2/%s/transactions/%d", transaction.Ledger, transaction.TransactionID),
+		})
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Timestamp.Before(ret[j].Timestamp)
+	})
+	return ret
+}
+
+func resolveMonitoringAlertRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrMonitoringAlertNotFound
+	default:
+		return err
+	}
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+)
+
+// MonitoringWindow is a duration written as "24h" or "30m" in the rules
+// file.
+type MonitoringWindow time.Duration
+
+func (w MonitoringWindow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(w).String())
+}
+
+func (w *MonitoringWindow) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*w = MonitoringWindow(duration)
+	return nil
+}
+
+// MonitoringRule is evaluated against every credit and debit of the CBA
+// accounts. Amounts are in major units and the rule applies to every
+// currency unless Currency is set. Which of the other fields are used
+// depends on the type:
+//
+//   - velocity: more than MaxCount transactions, or more than MaxAmount in
+//     total, within Window.
+//   - structuring: at least MinCount transactions within Window whose amount
+//     is below Threshold by less than Margin, a fraction of Threshold.
+//   - round_amount: a transaction of at least MinAmount which is a multiple
+//     of Multiple.
+//   - rapid_in_out: debits within Window amounting to at least Ratio of the
+//     credits received in the same window, when those reach MinAmount.
+//   - dormant_activity: a transaction of at least MinAmount on an account
+//     which is dormant or had no activity for DormantDays.
+type MonitoringRule struct {
+	Name        string           `json:"name"`
+	Type        string           `json:"type"`
+	Severity    string           `json:"severity,omitempty"`
+	Direction   string           `json:"direction,omitempty"`
+	Currency    string           `json:"currency,omitempty"`
+	Window      MonitoringWindow `json:"window,omitempty"`
+	MaxCount    int              `json:"max_count,omitempty"`
+	MaxAmount   decimal.Decimal  `json:"max_amount,omitempty"`
+	Threshold   decimal.Decimal  `json:"threshold,omitempty"`
+	Margin      decimal.Decimal  `json:"margin,omitempty"`
+	MinCount    int              `json:"min_count,omitempty"`
+	Multiple    decimal.Decimal  `json:"multiple,omitempty"`
+	MinAmount   decimal.Decimal  `json:"min_amount,omitempty"`
+	Ratio       decimal.Decimal  `json:"ratio,omitempty"`
+	DormantDays int              `json:"dormant_days,omitempty"`
+}
+
+// DefaultMonitoringRules are the rules used when none are configured.
+func DefaultMonitoringRules() []MonitoringRule {
+	return []MonitoringRule{
+		{
+			Name:     "high-velocity",
+			Type:     models.MonitoringRuleVelocity,
+			Severity: models.AlertSeverityMedium,
+			Window:   MonitoringWindow(24 * time.Hour),
+			MaxCount: 20,
+		},
+		{
+			Name:      "structuring",
+			Type:      models.MonitoringRuleStructuring,
+			Severity:  models.AlertSeverityHigh,
+			Window:    MonitoringWindow(72 * time.Hour),
+			Threshold: decimal.NewFromInt(10000),
+			Margin:    decimal.NewFromFloat(0.1),
+			MinCount:  3,
+		},
+		{
+			Name:      "round-amount",
+			Type:      models.MonitoringRuleRoundAmount,
+			Severity:  models.AlertSeverityLow,
+			Multiple:  decimal.NewFromInt(1000),
+			MinAmount: decimal.NewFromInt(5000),
+		},
+		{
+			Name:      "rapid-in-out",
+			Type:      models.MonitoringRuleRapidInOut,
+			Severity:  models.AlertSeverityHigh,
+			Window:    MonitoringWindow(48 * time.Hour),
+			Ratio:     decimal.NewFromFloat(0.9),
+			MinAmount: decimal.NewFromInt(5000),
+		},
+		{
+			Name:        "dormant-reactivation",
+			Type:        models.MonitoringRuleDormantActivity,
+			Severity:    models.AlertSeverityMedium,
+			DormantDays: 180,
+		},
+	}
+}
+
+// LoadMonitoringRules reads a JSON array of rules from a file.
+func LoadMonitoringRules(path string) ([]MonitoringRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]MonitoringRule, 0)
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("decoding monitoring rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("monitoring rules file %s defines no rule", path)
+	}
+	if err := ValidateMonitoringRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func ValidateMonitoringRules(rules []MonitoringRule) error {
+	names := map[string]struct{}{}
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("%w: every rule requires a name", ErrMonitoringValidation)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return fmt.Errorf("%w: rule %s is defined twice", ErrMonitoringValidation, rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("%w: rule %s: %v", ErrMonitoringValidation, rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r MonitoringRule) validate() error {
+	switch r.Severity {
+	case "", models.AlertSeverityLow, models.AlertSeverityMedium, models.AlertSeverityHigh:
+	default:
+		return fmt.Errorf("unknown severity %s", r.Severity)
+	}
+	switch r.Direction {
+	case "", models.MonitoringDirectionCredit, models.MonitoringDirectionDebit:
+	default:
+		return fmt.Errorf("direction must be credit or debit")
+	}
+	if r.MinAmount.IsNegative() {
+		return fmt.Errorf("min_amount must not be negative")
+	}
+
+	switch r.Type {
+	case models.MonitoringRuleVelocity:
+		if r.Window <= 0 {
+			return fmt.Errorf("window is required")
+		}
+		if r.MaxCount <= 0 && !r.MaxAmount.IsPositive() {
+			return fmt.Errorf("max_count or max_amount is required")
+		}
+	case models.MonitoringRuleStructuring:
+		if r.Window <= 0 {
+			return fmt.Errorf("window is required")
+		}
+		if !r.Threshold.IsPositive() {
+			return fmt.Errorf("threshold must be positive")
+		}
+		if !r.Margin.IsPositive() || r.Margin.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+			return fmt.Errorf("margin must be between 0 and 1")
+		}
+		if r.MinCount <= 0 {
+			return fmt.Errorf("min_count must be positive")
+		}
+	case models.MonitoringRuleRoundAmount:
+		if !r.Multiple.IsPositive() {
+			return fmt.Errorf("multiple must be positive")
+		}
+	case models.MonitoringRuleRapidInOut:
+		if r.Window <= 0 {
+			return fmt.Errorf("window is required")
+		}
+		if !r.Ratio.IsPositive() || r.Ratio.GreaterThan(decimal.NewFromInt(1)) {
+			return fmt.Errorf("ratio must be between 0 and 1")
+		}
+	case models.MonitoringRuleDormantActivity:
+		if r.DormantDays <= 0 {
+			return fmt.Errorf("dormant_days must be positive")
+		}
+	default:
+		return fmt.Errorf("unknown type %s", r.Type)
+	}
+	return nil
+}
+
+func (r MonitoringRule) severity() string {
+	if r.Severity == "" {
+		return models.AlertSeverityMedium
+	}
+	return r.Severity
+}
+
+func (r MonitoringRule) applies(transaction models.MonitoredTransaction) bool {
+	if r.Currency != "" && r.Currency != transaction.Currency {
+		return false
+	}
+	return r.Direction == "" || r.Direction == transaction.Direction
+}
+
+// monitoringMatch is what a rule found: the transactions supporting it and
+// a sentence describing them.
+type monitoringMatch struct {
+	summary      string
+	transactions []models.MonitoredTransaction
+}
+
+// evaluate checks the rule when current is recorded. history holds the
+// transactions of the account, most recent first, current included.
+// lastActivity is when the account was last active before current.
+func (r MonitoringRule) evaluate(account *models.Account, current models.MonitoredTransaction, history []models.MonitoredTransaction, lastActivity time.Time) *monitoringMatch {
+	if r.Type != models.MonitoringRuleRapidInOut && !r.applies(current) {
+		return nil
+	}
+	// window returns the transactions in the currency of current within
+	// the window ending with it.
+	window := func() []models.MonitoredTransaction {
+		from := current.Timestamp.Add(-time.Duration(r.Window))
+		ret := make([]models.MonitoredTransaction, 0)
+		for _, transaction := range history {
+			if transaction.Currency != current.Currency || transaction.Timestamp.Before(from) || transaction.Timestamp.After(current.Timestamp) {
+				continue
+			}
+			ret = append(ret, transaction)
+		}
+		return ret
+	}
+
+	switch r.Type {
+	case models.MonitoringRuleVelocity:
+		matching := make([]models.MonitoredTransaction, 0)
+		total := decimal.Zero
+		for _, transaction := range window() {
+			if r.applies(transaction) {
+				matching = append(matching, transaction)
+				total = total.Add(transaction.Amount)
+			}
+		}
+		if (r.MaxCount > 0 && len(matching) > r.MaxCount) || (r.MaxAmount.IsPositive() && total.GreaterThan(r.MaxAmount)) {
+			return &monitoringMatch{
+				summary:      fmt.Sprintf("%d transactions totalling %s %s within %s", len(matching), total, current.Currency, time.Duration(r.Window)),
+				transactions: matching,
+			}
+		}
+	case models.MonitoringRuleStructuring:
+		floor := r.Threshold.Sub(r.Threshold.Mul(r.Margin))
+		nearThreshold := func(amount decimal.Decimal) bool {
+			return amount.GreaterThanOrEqual(floor) && amount.LessThan(r.Threshold)
+		}
+		if !nearThreshold(current.Amount) {
+			return nil
+		}
+		matching := make([]models.MonitoredTransaction, 0)
+		for _, transaction := range window() {
+			if r.applies(transaction) && nearThreshold(transaction.Amount) {
+				matching = append(matching, transaction)
+			}
+		}
+		if len(matching) >= r.MinCount {
+			return &monitoringMatch{
+				summary:      fmt.Sprintf("%d transactions between %s and %s %s within %s, just below the %s threshold", len(matching), floor, r.Threshold, current.Currency, time.Duration(r.Window), r.Threshold),
+				transactions: matching,
+			}
+		}
+	case models.MonitoringRuleRoundAmount:
+		if current.Amount.GreaterThanOrEqual(r.MinAmount) && current.Amount.Mod(r.Multiple).IsZero() {
+			return &monitoringMatch{
+				summary:      fmt.Sprintf("%s of a round amount of %s %s", current.Direction, current.Amount, current.Currency),
+				transactions: []models.MonitoredTransaction{current},
+			}
+		}
+	case models.MonitoringRuleRapidInOut:
+		if current.Direction != models.MonitoringDirectionDebit || (r.Currency != "" && r.Currency != current.Currency) {
+			return nil
+		}
+		credited, debited := decimal.Zero, decimal.Zero
+		matching := make([]models.MonitoredTransaction, 0)
+		for _, transaction := range window() {
+			if transaction.Direction == models.MonitoringDirectionCredit {
+				credited = credited.Add(transaction.Amount)
+			} else {
+				debited = debited.Add(transaction.Amount)
+			}
+			matching = append(matching, transaction)
+		}
+		if credited.IsPositive() && credited.GreaterThanOrEqual(r.MinAmount) && debited.GreaterThanOrEqual(credited.Mul(r.Ratio)) {
+			return &monitoringMatch{
+				summary:      fmt.Sprintf("%s %s credited then %s %s debited within %s", credited, current.Currency, debited, current.Currency, time.Duration(r.Window)),
+				transactions: matching,
+			}
+		}
+	case models.MonitoringRuleDormantActivity:
+		if current.Amount.LessThan(r.MinAmount) {
+			return nil
+		}
+		inactive := current.Timestamp.Sub(lastActivity)
+		if account.Status == models.AccountStatusDormant || inactive >= time.Duration(r.DormantDays)*24*time.Hour {
+			return &monitoringMatch{
+				summary:      fmt.Sprintf("%s of %s %s after %d days of inactivity", current.Direction, current.Amount, current.Currency, int(inactive.Hours()/24)),
+				transactions: []models.MonitoredTransaction{current},
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,492 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	libtime "github.com/formancehq/go-libs/v3/time"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+func newMonitoringTestTransaction(id uint64, timestamp time.Time, postings ...ledgerinternal.Posting) ledgerinternal.Transaction {
+	return ledgerinternal.NewTransaction().
+		WithID(id).
+		WithTimestamp(libtime.New(timestamp)).
+		WithPostings(postings...)
+}
+
+func TestLoadMonitoringRules(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"name": "large-credits", "type": "velocity", "severity": "high", "direction": "credit", "window": "1h", "max_amount": "50000"},
+		{"name": "round-usd", "type": "round_amount", "currency": "USD", "multiple": "500"}
+	]`), 0o600))
+	rules, err := LoadMonitoringRules(path)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	require.Equal(t, MonitoringWindow(time.Hour), rules[0].Window)
+	require.True(t, rules[0].MaxAmount.Equal(decimal.NewFromInt(50000)))
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name": "structuring", "type": "structuring", "window": "24h", "threshold": "10000", "margin": "2", "min_count": 3}]`), 0o600))
+	_, err = LoadMonitoringRules(path)
+	require.ErrorIs(t, err, ErrMonitoringValidation)
+
+	require.NoError(t, ValidateMonitoringRules(DefaultMonitoringRules()))
+}
+
+func TestMonitoringRules(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	account := &models.Account{
+		ID:       uuid.New(),
+		Status:   models.AccountStatusActive,
+		OpenedAt: now.AddDate(-1, 0, 0),
+	}
+	transaction := func(direction string, amount int64, ago time.Duration) models.MonitoredTransaction {
+		return models.MonitoredTransaction{
+			AccountID: account.ID,
+			Direction: direction,
+			Amount:    decimal.NewFromInt(amount),
+			Currency:  "USD",
+			Timestamp: now.Add(-ago),
+		}
+	}
+	rules := map[string]MonitoringRule{}
+	for _, rule := range DefaultMonitoringRules() {
+		rules[rule.Type] = rule
+	}
+
+	type testCase struct {
+		name         string
+		rule         MonitoringRule
+		history      []models.MonitoredTransaction
+		lastActivity time.Time
+		matches      int
+	}
+	testCases := []testCase{
+		{
+			name: "velocity over the count",
+			rule: MonitoringRule{Type: models.MonitoringRuleVelocity, Window: MonitoringWindow(time.Hour), MaxCount: 2},
+			history: []models.MonitoredTransaction{
+				transaction(models.MonitoringDirectionCredit, 10, 0),
+				transaction(models.MonitoringDirectionCredit, 10, 10*time.Minute),
+				transaction(models.MonitoringDirectionCredit, 10, 20*time.Minute),
+				transaction(models.MonitoringDirectionCredit, 10, 2*time.Hour),
+			},
+			matches: 3,
+		},
+		{
+			name: "velocity within the count",
+			rule: MonitoringRule{Type: models.MonitoringRuleVelocity, Window: MonitoringWindow(time.Hour), MaxCount: 3},
+			history: []models.MonitoredTransaction{
+				transaction(models.MonitoringDirectionCredit, 10, 0),
+				transaction(models.MonitoringDirectionCredit, 10, 10*time.Minute),
+				transaction(models.MonitoringDirectionCredit, 10, 2*time.Hour),
+			},
+		},
+		{
+			name: "structuring",
+			rule: rules[models.MonitoringRuleStructuring],
+			history: []models.MonitoredTransaction{
+				transaction(models.MonitoringDirectionCredit, 9500, 0),
+				transaction(models.MonitoringDirectionCredit, 9900, 24*time.Hour),
+				transaction(models.MonitoringDirectionCredit, 150, 30*time.Hour),
+				transaction(models.MonitoringDirectionCredit, 9100, 48*time.Hour),
+			},
+			matches: 3,
+		},
+		{
+			name: "amounts over the threshold are not structuring",
+			rule: rules[models.MonitoringRuleStructuring],
+			history: []models.MonitoredTransaction{
+				transaction(models.MonitoringDirectionCredit, 9500, 0),
+				transaction(models.MonitoringDirectionCredit, 10000, 24*time.Hour),
+				transaction(models.MonitoringDirectionCredit, 9100, 48*time.Hour),
+			},
+		},
+		{
+			name:    "round amount",
+			rule:    rules[models.MonitoringRuleRoundAmount],
+			history: []models.MonitoredTransaction{transaction(models.MonitoringDirectionDebit, 25000, 0)},
+			matches: 1,
+		},
+		{
+			name:    "round amount below the minimum",
+			rule:    rules[models.MonitoringRuleRoundAmount],
+			history: []models.MonitoredTransaction{transaction(models.MonitoringDirectionDebit, 3000, 0)},
+		},
+		{
+			name: "rapid in and out",
+			rule: rules[models.MonitoringRuleRapidInOut],
+			history: []models.MonitoredTransaction{
+				transaction(models.MonitoringDirectionDebit, 9600, 0),
+				transaction(models.MonitoringDirectionCredit, 10000, 5*time.Hour),
+			},
+			matches: 2,
+		},
+		{
+			name: "credits mostly kept",
+			rule: rules[models.MonitoringRuleRapidInOut],
+			history: []models.MonitoredTransaction{
+				transaction(models.MonitoringDirectionDebit, 2000, 0),
+				transaction(models.MonitoringDirectionCredit, 10000, 5*time.Hour),
+			},
+		},
+		{
+			name:         "reactivation after a long inactivity",
+			rule:         rules[models.MonitoringRuleDormantActivity],
+			history:      []models.MonitoredTransaction{transaction(models.MonitoringDirectionDebit, 10, 0)},
+			lastActivity: now.AddDate(0, -7, 0),
+			matches:      1,
+		},
+		{
+			name:         "regular activity",
+			rule:         rules[models.MonitoringRuleDormantActivity],
+			history:      []models.MonitoredTransaction{transaction(models.MonitoringDirectionDebit, 10, 0)},
+			lastActivity: now.AddDate(0, 0, -3),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			lastActivity := tc.lastActivity
+			if lastActivity.IsZero() {
+				lastActivity = now.Add(-time.Hour)
+			}
+			match := tc.rule.evaluate(account, tc.history[0], tc.history, lastActivity)
+			if tc.matches == 0 {
+				require.Nil(t, match)
+				return
+			}
+			require.NotNil(t, match)
+			require.Len(t, match.transactions, tc.matches)
+			require.NotEmpty(t, match.summary)
+		})
+	}
+}
+
+func TestMonitoringServiceRaisesAlerts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	accountRepo := newAccountRepositoryStub()
+	transactionRepo := newMonitoredTransactionRepositoryStub()
+	alertRepo := newMonitoringAlertRepositoryStub()
+	structuring := DefaultMonitoringRules()[1]
+	service := NewMonitoringService(accountRepo, transactionRepo, alertRepo, []MonitoringRule{structuring})
+
+	account := &models.Account{
+		ID:       uuid.New(),
+		ClientID: uuid.New(),
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+		WalletID: "client-CL-2026-000001-CUR-USD-001",
+		OpenedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, accountRepo.Create(ctx, account))
+	available := "users:" + account.WalletID + ":wallets:USD:available"
+	lien := "users:" + account.WalletID + ":wallets:USD:lien"
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	alerts, err := service.Monitor(ctx, "ledgertrack", newMonitoringTestTransaction(1, start,
+		ledgerinternal.NewPosting("world", available, "USD/2", big.NewInt(950000)),
+	))
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	// Liens move funds within the wallet and are not monitored.
+	alerts, err = service.Monitor(ctx, "ledgertrack", newMonitoringTestTransaction(2, start.Add(time.Hour),
+		ledgerinternal.NewPosting(available, lien, "USD/2", big.NewInt(950000)),
+		ledgerinternal.NewPosting("world", "users:unknown:wallets:USD:available", "USD/2", big.NewInt(950000)),
+	))
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+	require.Len(t, transactionRepo.transactions, 1)
+
+	alerts, err = service.Monitor(ctx, "ledgertrack", newMonitoringTestTransaction(3, start.Add(2*time.Hour),
+		ledgerinternal.NewPosting("world", available, "USD/2", big.NewInt(980000)),
+	))
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	alerts, err = service.Monitor(ctx, "ledgertrack", newMonitoringTestTransaction(4, start.Add(3*time.Hour),
+		ledgerinternal.NewPosting("world", available, "USD/2", big.NewInt(920000)),
+	))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	alert := alerts[0]
+	require.Equal(t, account.ID, alert.AccountID)
+	require.Equal(t, account.ClientID, alert.ClientID)
+	require.Equal(t, models.MonitoringRuleStructuring, alert.RuleType)
+	require.Equal(t, models.AlertSeverityHigh, alert.Severity)
+	require.Equal(t, models.AlertStatusOpen, alert.Status)
+	require.Len(t, alert.Evidence, 3)
+	require.Equal(t, uint64(1), alert.Evidence[0].TransactionID)
+	require.Equal(t, "/v2/ledgertrack/transactions/1", alert.Evidence[0].Link)
+	require.True(t, alert.Evidence[0].Amount.Equal(decimal.NewFromInt(9500)))
+
+	// A committed transaction is monitored once.
+	alerts, err = service.Monitor(ctx, "ledgertrack", newMonitoringTestTransaction(4, start.Add(3*time.Hour),
+		ledgerinternal.NewPosting("world", available, "USD/2", big.NewInt(920000)),
+	))
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	// Further matches are added to the alert under review.
+	alerts, err = service.Monitor(ctx, "ledgertrack", newMonitoringTestTransaction(5, start.Add(4*time.Hour),
+		ledgerinternal.NewPosting(available, "world", "USD/2", big.NewInt(990000)),
+	))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.Equal(t, alert.ID, alerts[0].ID)
+	require.Len(t, alerts[0].Evidence, 4)
+	require.Equal(t, models.MonitoringDirectionDebit, alerts[0].Evidence[3].Direction)
+	require.Len(t, alertRepo.alerts, 1)
+}
+
+func TestMonitoringServiceEvaluatesAgainAnInterruptedEvaluation(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	accountRepo := newAccountRepositoryStub()
+	transactionRepo := newMonitoredTransactionRepositoryStub()
+	alertRepo := newMonitoringAlertRepositoryStub()
+	roundAmount := DefaultMonitoringRules()[2]
+	service := NewMonitoringService(accountRepo, transactionRepo, alertRepo, []MonitoringRule{roundAmount})
+
+	account := &models.Account{
+		ID:       uuid.New(),
+		ClientID: uuid.New(),
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+		WalletID: "client-CL-2026-000002-CUR-USD-001",
+		OpenedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, accountRepo.Create(ctx, account))
+	transaction := newMonitoringTestTransaction(1, time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		ledgerinternal.NewPosting("world", "users:"+account.WalletID+":wallets:USD:available", "USD/2", big.NewInt(2_000_000)),
+	)
+
+	// The transaction is recorded before the alert fails to be opened.
+	alertRepo.err = errors.New("connection reset")
+	_, err := service.Monitor(ctx, "ledgertrack", transaction)
+	require.Error(t, err)
+	require.Len(t, transactionRepo.transactions, 1)
+	require.Nil(t, transactionRepo.transactions[0].EvaluatedAt)
+
+	alertRepo.err = nil
+	alerts, err := service.Monitor(ctx, "ledgertrack", transaction)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.Len(t, transactionRepo.transactions, 1)
+	require.NotNil(t, transactionRepo.transactions[0].EvaluatedAt)
+
+	alerts, err = service.Monitor(ctx, "ledgertrack", transaction)
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+}
+
+func TestMonitoringAlertTriage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	alertRepo := newMonitoringAlertRepositoryStub()
+	service := NewMonitoringService(newAccountRepositoryStub(), newMonitoredTransactionRepositoryStub(), alertRepo, nil)
+	alert := &models.MonitoringAlert{
+		AccountID: uuid.New(),
+		Rule:      "round-amount",
+		RuleType:  models.MonitoringRuleRoundAmount,
+		Severity:  models.AlertSeverityLow,
+		Status:    models.AlertStatusOpen,
+	}
+	require.NoError(t, alertRepo.Create(ctx, alert))
+
+	_, err := service.AssignAlert(ctx, alert.ID, AssignAlertInput{})
+	require.ErrorIs(t, err, ErrMonitoringValidation)
+
+	assigned, err := service.AssignAlert(ctx, alert.ID, AssignAlertInput{TriageAlertInput: TriageAlertInput{Actor: "analyst"}})
+	require.NoError(t, err)
+	require.Equal(t, models.AlertStatusInvestigating, assigned.Status)
+	require.Equal(t, "analyst", assigned.Assignee)
+
+	_, err = service.EscalateAlert(ctx, alert.ID, TriageAlertInput{Actor: "analyst"})
+	require.ErrorIs(t, err, ErrMonitoringValidation)
+	escalated, err := service.EscalateAlert(ctx, alert.ID, TriageAlertInput{Actor: "analyst", Note: "cash deposits from several branches"})
+	require.NoError(t, err)
+	require.Equal(t, models.AlertStatusEscalated, escalated.Status)
+
+	reassigned, err := service.AssignAlert(ctx, alert.ID, AssignAlertInput{TriageAlertInput: TriageAlertInput{Actor: "analyst"}, Assignee: "mlro"})
+	require.NoError(t, err)
+	require.Equal(t, models.AlertStatusEscalated, reassigned.Status)
+	require.Equal(t, "mlro", reassigned.Assignee)
+
+	_, err = service.CloseAlert(ctx, alert.ID, CloseAlertInput{TriageAlertInput: TriageAlertInput{Actor: "mlro", Note: "filed"}, Resolution: "dismissed"})
+	require.ErrorIs(t, err, ErrMonitoringValidation)
+	closed, err := service.CloseAlert(ctx, alert.ID, CloseAlertInput{TriageAlertInput: TriageAlertInput{Actor: "mlro", Note: "filed"}, Resolution: models.AlertResolutionReported})
+	require.NoError(t, err)
+	require.Equal(t, models.AlertStatusClosed, closed.Status)
+	require.Equal(t, models.AlertResolutionReported, closed.Resolution)
+	require.NotNil(t, closed.ClosedAt)
+	require.Len(t, closed.Notes, 4)
+	require.Equal(t, "mlro", closed.Notes[3].Actor)
+
+	_, err = service.EscalateAlert(ctx, alert.ID, TriageAlertInput{Actor: "mlro", Note: "again"})
+	require.ErrorIs(t, err, ErrMonitoringAlertInvalidState)
+	_, err = service.GetAlert(ctx, uuid.New())
+	require.ErrorIs(t, err, ErrMonitoringAlertNotFound)
+}
+
+type monitoredTransactionRepositoryStub struct {
+	transactions []models.MonitoredTransaction
+}
+
+func newMonitoredTransactionRepositoryStub() *monitoredTransactionRepositoryStub {
+	return &monitoredTransactionRepositoryStub{}
+}
+
+func (s *monitoredTransactionRepositoryStub) Create(_ context.Context, transaction *models.MonitoredTransaction) error {
+	for _, existing := range s.transactions {
+		if existing.Ledger == transaction.Ledger && existing.TransactionID == transaction.TransactionID &&
+			existing.AccountID == transaction.AccountID && existing.Direction == transaction.Direction {
+			return postgres.ErrConstraintsFailed{}
+		}
+	}
+	if transaction.ID == uuid.Nil {
+		transaction.ID = uuid.New()
+	}
+	s.transactions = append(s.transactions, *transaction)
+	return nil
+}
+
+func (s *monitoredTransactionRepositoryStub) GetByPosting(_ context.Context, ledger string, transactionID uint64, accountID uuid.UUID, direction string) (*models.MonitoredTransaction, error) {
+	for _, existing := range s.transactions {
+		if existing.Ledger == ledger && existing.TransactionID == transactionID &&
+			existing.AccountID == accountID && existing.Direction == direction {
+			return &existing, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (s *monitoredTransactionRepositoryStub) MarkEvaluated(_ context.Context, transaction *models.MonitoredTransaction) error {
+	evaluatedAt := time.Now().UTC()
+	transaction.EvaluatedAt = &evaluatedAt
+	for i, existing := range s.transactions {
+		if existing.ID == transaction.ID {
+			s.transactions[i].EvaluatedAt = &evaluatedAt
+		}
+	}
+	return nil
+}
+
+func (s *monitoredTransactionRepositoryStub) List(_ context.Context, filter repositories.MonitoredTransactionFilter) ([]models.MonitoredTransaction, error) {
+	ret := make([]models.MonitoredTransaction, 0, len(s.transactions))
+	for _, transaction := range s.transactions {
+		if filter.AccountID != nil && transaction.AccountID != *filter.AccountID {
+			continue
+		}
+		if filter.From != nil && transaction.Timestamp.Before(*filter.From) {
+			continue
+		}
+		if filter.Before != nil && !transaction.Timestamp.Before(*filter.Before) {
+			continue
+		}
+		ret = append(ret, transaction)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Timestamp.After(ret[j].Timestamp)
+	})
+	if filter.Limit > 0 && len(ret) > filter.Limit {
+		ret = ret[:filter.Limit]
+	}
+	return ret, nil
+}
+
+type monitoringAlertRepositoryStub struct {
+	alerts map[uuid.UUID]*models.MonitoringAlert
+	err    error
+}
+
+func newMonitoringAlertRepositoryStub() *monitoringAlertRepositoryStub {
+	return &monitoringAlertRepositoryStub{
+		alerts: map[uuid.UUID]*models.MonitoringAlert{},
+	}
+}
+
+func (s *monitoringAlertRepositoryStub) Create(_ context.Context, alert *models.MonitoringAlert) error {
+	if s.err != nil {
+		return s.err
+	}
+	if alert.ID == uuid.Nil {
+		alert.ID = uuid.New()
+	}
+	copied := *alert
+	s.alerts[alert.ID] = &copied
+	return nil
+}
+
+func (s *monitoringAlertRepositoryStub) Update(_ context.Context, alert *models.MonitoringAlert) error {
+	if _, ok := s.alerts[alert.ID]; !ok {
+		return postgres.ErrNotFound
+	}
+	copied := *alert
+	s.alerts[alert.ID] = &copied
+	return nil
+}
+
+func (s *monitoringAlertRepositoryStub) Transition(_ context.Context, alert *models.MonitoringAlert, from string) error {
+	stored, ok := s.alerts[alert.ID]
+	if !ok || stored.Status != from {
+		return postgres.ErrNotFound
+	}
+	copied := *alert
+	s.alerts[alert.ID] = &copied
+	return nil
+}
+
+func (s *monitoringAlertRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.MonitoringAlert, error) {
+	alert, ok := s.alerts[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	copied := *alert
+	return &copied, nil
+}
+
+func (s *monitoringAlertRepositoryStub) List(_ context.Context, filter repositories.MonitoringAlertFilter) ([]models.MonitoringAlert, error) {
+	ret := make([]models.MonitoringAlert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		if filter.AccountID != nil && alert.AccountID != *filter.AccountID {
+			continue
+		}
+		if filter.Rule != nil && alert.Rule != *filter.Rule {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, alert.Status) {
+			continue
+		}
+		ret = append(ret, *alert)
+	}
+	return ret, nil
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -31,6 +35,8 @@ type ProductService interface {
 	Patch(context.Context, uuid.UUID, PatchProductInput) (*models.Product, error)
 	Activate(context.Context, uuid.UUID) (*models.Product, error)
 	Retire(context.Context, uuid.UUID) (*models.Product, error)
+	ChangeInterestRate(context.Context, uuid.UUID, ChangeInterestRateInput) (*models.InterestRateVersion, error)
+	ListInterestRates(context.Context, uuid.UUID) ([]models.InterestRateVersion, error)
 }
 
 type ProductRulesInput struct {
@@ -38,6 +44,7 @@ type ProductRulesInput struct {
 	MinBalance           *string                    `json:"min_balance,omitempty"`
 	MaxBalance           *string                    `json:"max_balance,omitempty"`
 	AllowNegativeBalance *bool                      `json:"allow_negative_balance,omitempty"`
+	OverdraftLimit       *string                    `json:"overdraft_limit,omitempty"`
 	AllowDebits          *bool                      `json:"allow_debits,omitempty"`
 	AllowCredits         *bool                      `json:"allow_credits,omitempty"`
 	RequiresKYCLevel     *int                       `json:"requires_kyc_level,omitempty"`
@@ -55,6 +62,9 @@ type CreateProductInput struct {
 	Rules          *ProductRulesInput    `json:"rules,omitempty"`
 	InterestConfig *models.InterestConfig `json:"interest_config,omitempty"`
 	FeeSchedule    *models.FeeSchedule   `json:"fee_schedule,omitempty"`
+	TermConfig     *models.TermConfig    `json:"term_config,omitempty"`
+	LoanConfig     *models.LoanConfig    `json:"loan_config,omitempty"`
+	TaxConfig      *models.TaxConfig     `json:"tax_config,omitempty"`
 }
 
 type PatchProductInput struct {
@@ -66,15 +76,30 @@ type PatchProductInput struct {
 	Rules          *ProductRulesInput     `json:"rules,omitempty"`
 	InterestConfig **models.InterestConfig `json:"interest_config,omitempty"`
 	FeeSchedule    **models.FeeSchedule   `json:"fee_schedule,omitempty"`
+	TermConfig     **models.TermConfig    `json:"term_config,omitempty"`
+	LoanConfig     **models.LoanConfig    `json:"loan_config,omitempty"`
+	TaxConfig      **models.TaxConfig     `json:"tax_config,omitempty"`
+}
+
+// ChangeInterestRateInput changes the interest rate of a product from a date.
+// The tiers of a tiered product are replaced as a whole; the rate or tiers
+// left out are carried over from the version in effect before.
+type ChangeInterestRateInput struct {
+	Rate          string                `json:"rate,omitempty"`
+	Tiers         []models.InterestTier `json:"tiers,omitempty"`
+	EffectiveFrom string                `json:"effective_from"`
+	CreatedBy     string                `json:"created_by,omitempty"`
 }
 
 type DefaultProductService struct {
 	productRepository repositories.ProductRepository
+	rateRepository    repositories.InterestRateRepository
 }
 
-func NewProductService(productRepository repositories.ProductRepository) ProductService {
+func NewProductService(productRepository repositories.ProductRepository, rateRepository repositories.InterestRateRepository) ProductService {
 	return &DefaultProductService{
 		productRepository: productRepository,
+		rateRepository:    rateRepository,
 	}
 }
 
@@ -110,7 +135,12 @@ func (s *DefaultProductService) Patch(ctx context.Context, id uuid.UUID, input P
 	}
 
 	if product.Status == models.ProductStatusActive && touchesRestrictedActiveFields(input) {
-		return nil, fmt.Errorf("%w: code, category, currency, and rules are immutable after activation", ErrProductActivePatchRestricted)
+		return nil, fmt.Errorf("%w: code, category, currency, rules, term_config, and loan_config are immutable after activation", ErrProductActivePatchRestricted)
+	}
+	// Rewriting the rate in place would change the rate of the days not
+	// accrued yet, including past ones.
+	if product.Status == models.ProductStatusActive && changesInterestRate(product.InterestConfig, input.InterestConfig) {
+		return nil, fmt.Errorf("%w: the interest rate of an active product is changed with an effective date", ErrProductActivePatchRestricted)
 	}
 
 	applyPatch(product, input)
@@ -173,6 +203,80 @@ func (s *DefaultProductService) Retire(ctx context.Context, id uuid.UUID) (*mode
 	return product, nil
 }
 
+// ChangeInterestRate records a new version of the interest rate of the product
+// applying from its effective date. Versions only apply from today onwards so
+// that the accruals already computed, or still to catch up on, keep the rate
+// they were in effect with.
+func (s *DefaultProductService) ChangeInterestRate(ctx context.Context, id uuid.UUID, input ChangeInterestRateInput) (*models.InterestRateVersion, error) {
+	product, err := s.productRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveProductRepositoryError(err)
+	}
+	if product.InterestConfig == nil || product.InterestConfig.Type == "" || product.InterestConfig.Type == "none" {
+		return nil, fmt.Errorf("%w: product does not pay interest", ErrProductValidation)
+	}
+	if product.Status == models.ProductStatusRetired {
+		return nil, fmt.Errorf("%w: cannot change the interest rate of a retired product", ErrProductInvalidStateTransition)
+	}
+
+	effectiveFrom, err := time.Parse(time.DateOnly, strings.TrimSpace(input.EffectiveFrom))
+	if err != nil {
+		return nil, fmt.Errorf("%w: effective_from must be a date formatted as %s", ErrProductValidation, time.DateOnly)
+	}
+	if effectiveFrom.Before(time.Now().UTC().Truncate(24 * time.Hour)) {
+		return nil, fmt.Errorf("%w: effective_from cannot be in the past", ErrProductValidation)
+	}
+
+	versions, err := s.rateRepository.ListByProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	current := models.InterestRateVersion{
+		Version: 1,
+		Rate:    product.InterestConfig.Rate,
+		Tiers:   product.InterestConfig.Tiers,
+	}
+	if len(versions) > 0 {
+		current = versions[len(versions)-1]
+		if !effectiveFrom.After(current.EffectiveFrom) {
+			return nil, fmt.Errorf("%w: effective_from must be after %s when version %d applies", ErrProductValidation, current.EffectiveFrom.Format(time.DateOnly), current.Version)
+		}
+	}
+
+	version := &models.InterestRateVersion{
+		ProductID:     product.ID,
+		Version:       current.Version + 1,
+		EffectiveFrom: effectiveFrom,
+		Rate:          strings.TrimSpace(input.Rate),
+		Tiers:         input.Tiers,
+		CreatedBy:     strings.TrimSpace(input.CreatedBy),
+	}
+	if version.Rate == "" {
+		version.Rate = current.Rate
+	}
+	if version.Tiers == nil {
+		version.Tiers = current.Tiers
+	}
+	config := *product.InterestConfig
+	config.Rate = version.Rate
+	config.Tiers = version.Tiers
+	if err := validateInterestConfig(&config); err != nil {
+		return nil, err
+	}
+
+	if err := s.rateRepository.Create(ctx, version); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+func (s *DefaultProductService) ListInterestRates(ctx context.Context, id uuid.UUID) ([]models.InterestRateVersion, error) {
+	if _, err := s.productRepository.Get(ctx, id); err != nil {
+		return nil, resolveProductRepositoryError(err)
+	}
+	return s.rateRepository.ListByProduct(ctx, id)
+}
+
 func buildProduct(input CreateProductInput) (*models.Product, error) {
 	product := &models.Product{
 		Code:           strings.TrimSpace(input.Code),
@@ -184,6 +288,9 @@ func buildProduct(input CreateProductInput) (*models.Product, error) {
 		Rules:          normalizeRules(input.Rules),
 		InterestConfig: input.InterestConfig,
 		FeeSchedule:    input.FeeSchedule,
+		TermConfig:     input.TermConfig,
+		LoanConfig:     input.LoanConfig,
+		TaxConfig:      input.TaxConfig,
 	}
 
 	if err := validateProduct(product); err != nil {
@@ -218,10 +325,30 @@ func applyPatch(product *models.Product, input PatchProductInput) {
 	if input.FeeSchedule != nil {
 		product.FeeSchedule = *input.FeeSchedule
 	}
+	if input.TermConfig != nil {
+		product.TermConfig = *input.TermConfig
+	}
+	if input.LoanConfig != nil {
+		product.LoanConfig = *input.LoanConfig
+	}
+	if input.TaxConfig != nil {
+		product.TaxConfig = *input.TaxConfig
+	}
 }
 
 func touchesRestrictedActiveFields(input PatchProductInput) bool {
-	return input.Code != nil || input.Category != nil || input.Currency != nil || input.Rules != nil
+	return input.Code != nil || input.Category != nil || input.Currency != nil || input.Rules != nil || input.TermConfig != nil || input.LoanConfig != nil
+}
+
+func changesInterestRate(current *models.InterestConfig, patch **models.InterestConfig) bool {
+	if patch == nil {
+		return false
+	}
+	next := *patch
+	if current == nil || next == nil {
+		return current != next
+	}
+	return current.Rate != next.Rate || !reflect.DeepEqual(current.Tiers, next.Tiers)
 }
 
 func normalizeRules(input *ProductRulesInput) models.ProductRules {
@@ -251,6 +378,10 @@ func normalizeRules(input *ProductRulesInput) models.ProductRules {
 	if input.AllowNegativeBalance != nil {
 		ret.AllowNegativeBalance = *input.AllowNegativeBalance
 	}
+	if input.OverdraftLimit != nil {
+		overdraftLimit := strings.TrimSpace(*input.OverdraftLimit)
+		ret.OverdraftLimit = &overdraftLimit
+	}
 	if input.AllowDebits != nil {
 		ret.AllowDebits = *input.AllowDebits
 	}
@@ -297,7 +428,7 @@ func validateProduct(product *models.Product) error {
 		return fmt.Errorf("%w: invalid status %s", ErrProductValidation, product.Status)
 	}
 
-	if err := validateRules(product.Rules); err != nil {
+	if err := validateRules(product.Rules, product.Currency); err != nil {
 		return err
 	}
 	if err := validateInterestConfig(product.InterestConfig); err != nil {
@@ -306,11 +437,31 @@ func validateProduct(product *models.Product) error {
 	if err := validateFeeSchedule(product.FeeSchedule); err != nil {
 		return err
 	}
+	if err := validateTermConfig(product.TermConfig); err != nil {
+		return err
+	}
+	if product.InterestConfig != nil && product.InterestConfig.PostingFrequency == "maturity" && product.TermConfig == nil {
+		return fmt.Errorf("%w: posting_frequency maturity requires a term_config", ErrProductValidation)
+	}
+	if err := validateLoanConfig(product.LoanConfig, product.Currency); err != nil {
+		return err
+	}
+	if err := validateTaxConfig(product.TaxConfig); err != nil {
+		return err
+	}
+	if product.LoanConfig != nil {
+		if product.TermConfig != nil {
+			return fmt.Errorf("%w: a product cannot have both a term_config and a loan_config", ErrProductValidation)
+		}
+		if product.InterestConfig != nil && product.InterestConfig.Type != "" && product.InterestConfig.Type != "none" {
+			return fmt.Errorf("%w: loan products charge loan_config.rate rather than interest_config", ErrProductValidation)
+		}
+	}
 
 	return nil
 }
 
-func validateRules(rules models.ProductRules) error {
+func validateRules(rules models.ProductRules, currency string) error {
 	minOpening, err := parseDecimalField("min_opening_balance", rules.MinOpeningBalance)
 	if err != nil {
 		return err
@@ -322,6 +473,12 @@ func validateRules(rules models.ProductRules) error {
 	if minOpening.IsNegative() || minBalance.IsNegative() {
 		return fmt.Errorf("%w: minimum balances cannot be negative", ErrProductValidation)
 	}
+	if err := validateAmountPrecision("min_opening_balance", rules.MinOpeningBalance, currency); err != nil {
+		return err
+	}
+	if err := validateAmountPrecision("min_balance", rules.MinBalance, currency); err != nil {
+		return err
+	}
 	if rules.MaxBalance != nil {
 		maxBalance, err := parseDecimalField("max_balance", *rules.MaxBalance)
 		if err != nil {
@@ -330,6 +487,24 @@ func validateRules(rules models.ProductRules) error {
 		if maxBalance.LessThan(minBalance) {
 			return fmt.Errorf("%w: max_balance cannot be less than min_balance", ErrProductValidation)
 		}
+		if err := validateAmountPrecision("max_balance", *rules.MaxBalance, currency); err != nil {
+			return err
+		}
+	}
+	if rules.OverdraftLimit != nil {
+		if !rules.AllowNegativeBalance {
+			return fmt.Errorf("%w: overdraft_limit requires allow_negative_balance", ErrProductValidation)
+		}
+		overdraftLimit, err := parseDecimalField("overdraft_limit", *rules.OverdraftLimit)
+		if err != nil {
+			return err
+		}
+		if overdraftLimit.IsNegative() {
+			return fmt.Errorf("%w: overdraft_limit cannot be negative", ErrProductValidation)
+		}
+		if err := validateAmountPrecision("overdraft_limit", *rules.OverdraftLimit, currency); err != nil {
+			return err
+		}
 	}
 	if rules.RequiresKYCLevel < 0 || rules.RequiresKYCLevel > 3 {
 		return fmt.Errorf("%w: requires_kyc_level must be between 0 and 3", ErrProductValidation)
@@ -359,6 +534,9 @@ func validateRules(rules models.ProductRules) error {
 			if err != nil || value.IsNegative() {
 				return fmt.Errorf("%w: transaction limit values must be non-negative decimals", ErrProductValidation)
 			}
+			if err := validateAmountPrecision("transaction limit", *limit, currency); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -382,6 +560,11 @@ func validateInterestConfig(config *models.InterestConfig) error {
 	if _, err := parseDecimalField("interest rate", config.Rate); err != nil {
 		return err
 	}
+	switch config.DayCount {
+	case "", models.DayCountActual365, models.DayCountActual360, models.DayCount30360:
+	default:
+		return fmt.Errorf("%w: invalid day_count %s", ErrProductValidation, config.DayCount)
+	}
 
 	switch config.AccrualFrequency {
 	case "daily", "monthly":
@@ -449,30 +632,193 @@ func validateFeeSchedule(schedule *models.FeeSchedule) error {
 		default:
 			return fmt.Errorf("%w: invalid transaction fee event %s", ErrProductValidation, fee.Event)
 		}
-		switch fee.Type {
-		case "flat", "percentage":
+		if err := validateFeeRule("transaction fee", fee); err != nil {
+			return err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(schedule.PenaltyFees)) {
+		if err := validatePenaltyFee(name, schedule.PenaltyFees[name]); err != nil {
+			return err
+		}
+		rule, err := penaltyFeeRule(schedule, name)
+		if err != nil {
+			return err
+		}
+		if err := validateFeeRule(name+" penalty fee", *rule); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePenaltyFee checks the trigger of a penalty fee. Only
+// excess_withdrawals and early_closure take trigger settings.
+func validatePenaltyFee(name string, penalty models.PenaltyFee) error {
+	switch name {
+	case models.PenaltyExcessWithdrawals:
+		if penalty.FreeWithdrawals < 0 {
+			return fmt.Errorf("%w: %s penalty fee free_withdrawals cannot be negative", ErrProductValidation, name)
+		}
+		switch penalty.Period {
+		case models.PenaltyPeriodDaily, models.PenaltyPeriodMonthly:
 		default:
-			return fmt.Errorf("%w: invalid transaction fee type %s", ErrProductValidation, fee.Type)
+			return fmt.Errorf("%w: invalid %s penalty fee period %s", ErrProductValidation, name, penalty.Period)
+		}
+		if penalty.WithinDays != 0 {
+			return fmt.Errorf("%w: %s penalty fee does not take within_days", ErrProductValidation, name)
+		}
+	case models.PenaltyEarlyClosure:
+		if penalty.WithinDays <= 0 {
+			return fmt.Errorf("%w: %s penalty fee within_days must be positive", ErrProductValidation, name)
+		}
+		if penalty.FreeWithdrawals != 0 || penalty.Period != "" {
+			return fmt.Errorf("%w: %s penalty fee does not take free_withdrawals or period", ErrProductValidation, name)
+		}
+	case models.PenaltyEarlyWithdrawal, models.PenaltyMinimumBalance, models.PenaltyInsufficientFunds, models.PenaltyDormancy:
+		if penalty.FreeWithdrawals != 0 || penalty.Period != "" || penalty.WithinDays != 0 {
+			return fmt.Errorf("%w: %s penalty fee does not take free_withdrawals, period or within_days", ErrProductValidation, name)
+		}
+	default:
+		return fmt.Errorf("%w: invalid penalty fee %s", ErrProductValidation, name)
+	}
+	return nil
+}
+
+func validateFeeRule(name string, fee models.TransactionFee) error {
+	switch fee.Type {
+	case "flat", "percentage":
+	default:
+		return fmt.Errorf("%w: invalid %s type %s", ErrProductValidation, name, fee.Type)
+	}
+	if _, err := parseDecimalField(name+" value", fee.Value); err != nil {
+		return err
+	}
+	for _, maybeValue := range []*string{fee.Min, fee.Max} {
+		if maybeValue == nil {
+			continue
 		}
-		if _, err := parseDecimalField("transaction fee value", fee.Value); err != nil {
+		if _, err := parseDecimalField(name+" bounds", *maybeValue); err != nil {
 			return err
 		}
-		for _, maybeValue := range []*string{fee.Min, fee.Max} {
-			if maybeValue == nil {
-				continue
-			}
-			if _, err := parseDecimalField("transaction fee bounds", *maybeValue); err != nil {
-				return err
-			}
+	}
+	if fee.Currency != "" {
+		definition, ok := currencyregistry.Lookup(fee.Currency)
+		if !ok || !definition.Enabled {
+			return fmt.Errorf("%w: %s currency %s is not enabled", ErrProductValidation, name, fee.Currency)
 		}
-		if fee.Currency != "" {
-			definition, ok := currencyregistry.Lookup(fee.Currency)
-			if !ok || !definition.Enabled {
-				return fmt.Errorf("%w: transaction fee currency %s is not enabled", ErrProductValidation, fee.Currency)
-			}
+	}
+	return nil
+}
+
+func validateTermConfig(config *models.TermConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.Length <= 0 {
+		return fmt.Errorf("%w: term_config.length must be positive", ErrProductValidation)
+	}
+	switch config.Unit {
+	case models.TermUnitDays, models.TermUnitMonths:
+	default:
+		return fmt.Errorf("%w: invalid term_config.unit %s", ErrProductValidation, config.Unit)
+	}
+	for _, option := range config.RolloverOptions {
+		if !isRolloverOption(option) {
+			return fmt.Errorf("%w: invalid rollover option %s", ErrProductValidation, option)
 		}
 	}
+	if config.DefaultRollover != "" && !termRolloverAllowed(config, config.DefaultRollover) {
+		return fmt.Errorf("%w: default_rollover %s is not an allowed rollover option", ErrProductValidation, config.DefaultRollover)
+	}
+	return nil
+}
 
+func validateLoanConfig(config *models.LoanConfig, currency string) error {
+	if config == nil {
+		return nil
+	}
+	switch config.Amortization {
+	case models.LoanAmortizationFlat, models.LoanAmortizationReducingBalance, models.LoanAmortizationBullet:
+	default:
+		return fmt.Errorf("%w: invalid loan_config.amortization %s", ErrProductValidation, config.Amortization)
+	}
+	switch config.RepaymentFrequency {
+	case models.RepaymentFrequencyWeekly, models.RepaymentFrequencyMonthly:
+	default:
+		return fmt.Errorf("%w: invalid loan_config.repayment_frequency %s", ErrProductValidation, config.RepaymentFrequency)
+	}
+	if config.Installments <= 0 {
+		return fmt.Errorf("%w: loan_config.installments must be positive", ErrProductValidation)
+	}
+	if config.GraceDays < 0 {
+		return fmt.Errorf("%w: loan_config.grace_days cannot be negative", ErrProductValidation)
+	}
+	rate, err := parseDecimalField("loan_config.rate", config.Rate)
+	if err != nil {
+		return err
+	}
+	if rate.IsNegative() {
+		return fmt.Errorf("%w: loan_config.rate cannot be negative", ErrProductValidation)
+	}
+	if config.PenaltyRate != "" {
+		penaltyRate, err := parseDecimalField("loan_config.penalty_rate", config.PenaltyRate)
+		if err != nil {
+			return err
+		}
+		if penaltyRate.IsNegative() {
+			return fmt.Errorf("%w: loan_config.penalty_rate cannot be negative", ErrProductValidation)
+		}
+	}
+	minPrincipal := decimal.Zero
+	if config.MinPrincipal != "" {
+		minPrincipal, err = parseDecimalField("loan_config.min_principal", config.MinPrincipal)
+		if err != nil {
+			return err
+		}
+		if minPrincipal.IsNegative() {
+			return fmt.Errorf("%w: loan_config.min_principal cannot be negative", ErrProductValidation)
+		}
+		if err := validateAmountPrecision("loan_config.min_principal", config.MinPrincipal, currency); err != nil {
+			return err
+		}
+	}
+	if config.MaxPrincipal != nil {
+		maxPrincipal, err := parseDecimalField("loan_config.max_principal", *config.MaxPrincipal)
+		if err != nil {
+			return err
+		}
+		if maxPrincipal.LessThan(minPrincipal) {
+			return fmt.Errorf("%w: loan_config.max_principal cannot be less than min_principal", ErrProductValidation)
+		}
+		if err := validateAmountPrecision("loan_config.max_principal", *config.MaxPrincipal, currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTaxConfig(config *models.TaxConfig) error {
+	if config == nil {
+		return nil
+	}
+	for _, rate := range config.WithholdingRates {
+		if rate.Residency != "" && len(rate.Residency) != 2 {
+			return fmt.Errorf("%w: withholding tax residency must be a two letter country code", ErrProductValidation)
+		}
+		switch rate.ClientType {
+		case "", models.ClientTypeIndividual, models.ClientTypeCorporate:
+		default:
+			return fmt.Errorf("%w: invalid withholding tax client_type %s", ErrProductValidation, rate.ClientType)
+		}
+		value, err := parseDecimalField("withholding tax rate", rate.Rate)
+		if err != nil {
+			return err
+		}
+		if value.IsNegative() || value.GreaterThan(decimal.NewFromInt(100)) {
+			return fmt.Errorf("%w: withholding tax rate must be between 0 and 100", ErrProductValidation)
+		}
+	}
 	return nil
 }
 
@@ -484,6 +830,15 @@ func parseDecimalField(name, value string) (decimal.Decimal, error) {
 	return parsed, nil
 }
 
+// validateAmountPrecision rejects amounts the currency cannot hold, such as
+// 10.123 USD, which would otherwise fail every posting against the product.
+func validateAmountPrecision(name, value, currency string) error {
+	if _, err := currencyregistry.ParseAmount(value, currency); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrProductValidation, name, err)
+	}
+	return nil
+}
+
 func resolveProductRepositoryError(err error) error {
 	switch {
 	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
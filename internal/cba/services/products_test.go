@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -66,7 +67,7 @@ func (s *productRepositoryStub) List(_ context.Context, _ repositories.ProductFi
 func TestProductServiceCreateDefaults(t *testing.T) {
 	t.Parallel()
 
-	service := NewProductService(newProductRepositoryStub())
+	service := NewProductService(newProductRepositoryStub(), newInterestRateRepositoryStub())
 	product, err := service.Create(context.Background(), CreateProductInput{
 		Code:     "SAV-NGN-001",
 		Name:     "Personal Savings NGN",
@@ -85,7 +86,7 @@ func TestProductServiceCreateDefaults(t *testing.T) {
 func TestProductServiceRejectsDisabledCurrency(t *testing.T) {
 	t.Parallel()
 
-	service := NewProductService(newProductRepositoryStub())
+	service := NewProductService(newProductRepositoryStub(), newInterestRateRepositoryStub())
 	_, err := service.Create(context.Background(), CreateProductInput{
 		Code:     "SAV-XYZ-001",
 		Name:     "Unsupported",
@@ -95,11 +96,49 @@ func TestProductServiceRejectsDisabledCurrency(t *testing.T) {
 	require.ErrorIs(t, err, ErrProductValidation)
 }
 
+func TestProductServiceRejectsRuleAmountsBeyondCurrencyPrecision(t *testing.T) {
+	t.Parallel()
+
+	service := NewProductService(newProductRepositoryStub(), newInterestRateRepositoryStub())
+	allowNegativeBalance := true
+	for name, rules := range map[string]*ProductRulesInput{
+		"min opening balance": {MinOpeningBalance: strPtr("10.123")},
+		"max balance":         {MaxBalance: strPtr("1000.001")},
+		"overdraft limit":     {AllowNegativeBalance: &allowNegativeBalance, OverdraftLimit: strPtr("50.555")},
+		"transaction limit": {TransactionLimits: &models.TransactionLimits{
+			SingleDebitLimit: strPtr("99.999"),
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := service.Create(context.Background(), CreateProductInput{
+				Code:     "SAV-USD-001",
+				Name:     "Savings USD",
+				Category: "savings",
+				Currency: "USD",
+				Rules:    rules,
+			})
+			require.ErrorIs(t, err, ErrProductValidation)
+		})
+	}
+
+	product, err := service.Create(context.Background(), CreateProductInput{
+		Code:     "SAV-USD-002",
+		Name:     "Savings USD",
+		Category: "savings",
+		Currency: "USD",
+		Rules:    &ProductRulesInput{MinOpeningBalance: strPtr("10.50")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "10.50", product.Rules.MinOpeningBalance)
+}
+
 func TestProductServiceRejectsRestrictedActivePatch(t *testing.T) {
 	t.Parallel()
 
 	repo := newProductRepositoryStub()
-	service := NewProductService(repo)
+	service := NewProductService(repo, newInterestRateRepositoryStub())
 	product, err := service.Create(context.Background(), CreateProductInput{
 		Code:     "SAV-USD-001",
 		Name:     "Personal Savings USD",
@@ -118,10 +157,75 @@ func TestProductServiceRejectsRestrictedActivePatch(t *testing.T) {
 	require.ErrorIs(t, err, ErrProductActivePatchRestricted)
 }
 
+func TestProductServiceChangeInterestRate(t *testing.T) {
+	t.Parallel()
+
+	repo := newProductRepositoryStub()
+	service := NewProductService(repo, newInterestRateRepositoryStub())
+	product, err := service.Create(context.Background(), CreateProductInput{
+		Code:           "SAV-USD-RATE",
+		Name:           "Savings USD",
+		Category:       "savings",
+		Currency:       "USD",
+		InterestConfig: &models.InterestConfig{Type: "simple", Rate: "4", AccrualFrequency: "daily", PostingFrequency: "monthly", DayCount: models.DayCountActual360},
+	})
+	require.NoError(t, err)
+	product, err = service.Activate(context.Background(), product.ID)
+	require.NoError(t, err)
+
+	nextConfig := *product.InterestConfig
+	nextConfig.Rate = "5"
+	patch := &nextConfig
+	_, err = service.Patch(context.Background(), product.ID, PatchProductInput{InterestConfig: &patch})
+	require.ErrorIs(t, err, ErrProductActivePatchRestricted)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for name, input := range map[string]ChangeInterestRateInput{
+		"missing date": {Rate: "5"},
+		"past date":    {Rate: "5", EffectiveFrom: today.AddDate(0, 0, -1).Format(time.DateOnly)},
+		"invalid rate": {Rate: "five", EffectiveFrom: today.Format(time.DateOnly)},
+	} {
+		_, err := service.ChangeInterestRate(context.Background(), product.ID, input)
+		require.ErrorIs(t, err, ErrProductValidation, name)
+	}
+
+	version, err := service.ChangeInterestRate(context.Background(), product.ID, ChangeInterestRateInput{
+		Rate:          "5",
+		EffectiveFrom: today.AddDate(0, 0, 7).Format(time.DateOnly),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, version.Version)
+
+	_, err = service.ChangeInterestRate(context.Background(), product.ID, ChangeInterestRateInput{
+		Rate:          "6",
+		EffectiveFrom: today.AddDate(0, 0, 7).Format(time.DateOnly),
+	})
+	require.ErrorIs(t, err, ErrProductValidation)
+
+	version, err = service.ChangeInterestRate(context.Background(), product.ID, ChangeInterestRateInput{
+		EffectiveFrom: today.AddDate(0, 1, 0).Format(time.DateOnly),
+		CreatedBy:     "treasury",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, version.Version)
+	require.Equal(t, "5", version.Rate)
+
+	versions, err := service.ListInterestRates(context.Background(), product.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	// The day count convention is not part of the rate history.
+	nextConfig = *product.InterestConfig
+	nextConfig.DayCount = models.DayCount30360
+	patch = &nextConfig
+	_, err = service.Patch(context.Background(), product.ID, PatchProductInput{InterestConfig: &patch})
+	require.NoError(t, err)
+}
+
 func TestProductServiceActivate(t *testing.T) {
 	t.Parallel()
 
-	service := NewProductService(newProductRepositoryStub())
+	service := NewProductService(newProductRepositoryStub(), newInterestRateRepositoryStub())
 	product, err := service.Create(context.Background(), CreateProductInput{
 		Code:     "CUR-USD-001",
 		Name:     "Corporate Current USD",
@@ -134,3 +238,189 @@ func TestProductServiceActivate(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, models.ProductStatusActive, activated.Status)
 }
+
+func TestProductServiceValidatesTermConfig(t *testing.T) {
+	t.Parallel()
+
+	service := NewProductService(newProductRepositoryStub(), newInterestRateRepositoryStub())
+	for name, tc := range map[string]CreateProductInput{
+		"maturity posting without term": {
+			InterestConfig: &models.InterestConfig{Type: "simple", Rate: "4", AccrualFrequency: "daily", PostingFrequency: "maturity"},
+		},
+		"invalid unit": {
+			TermConfig: &models.TermConfig{Length: 3, Unit: "weeks"},
+		},
+		"default rollover not allowed": {
+			TermConfig: &models.TermConfig{Length: 3, Unit: models.TermUnitMonths, RolloverOptions: []string{models.RolloverPayout}, DefaultRollover: models.RolloverPrincipal},
+		},
+		"invalid early withdrawal penalty": {
+			TermConfig:  &models.TermConfig{Length: 3, Unit: models.TermUnitMonths},
+			FeeSchedule: &models.FeeSchedule{PenaltyFees: map[string]models.PenaltyFee{models.PenaltyEarlyWithdrawal: {Type: "tiered", Value: "1"}}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.Code = "FD-USD-001"
+			tc.Name = "Fixed Deposit USD"
+			tc.Category = "fixed_deposit"
+			tc.Currency = "USD"
+			_, err := service.Create(context.Background(), tc)
+			require.ErrorIs(t, err, ErrProductValidation)
+		})
+	}
+
+	product, err := service.Create(context.Background(), CreateProductInput{
+		Code:           "FD-USD-002",
+		Name:           "Fixed Deposit USD",
+		Category:       "fixed_deposit",
+		Currency:       "USD",
+		InterestConfig: &models.InterestConfig{Type: "simple", Rate: "4", AccrualFrequency: "daily", PostingFrequency: "maturity"},
+		TermConfig:     &models.TermConfig{Length: 12, Unit: models.TermUnitMonths, AllowEarlyBreak: true},
+		FeeSchedule:    &models.FeeSchedule{PenaltyFees: map[string]models.PenaltyFee{models.PenaltyEarlyWithdrawal: {Type: "flat", Value: "25.00"}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 12, product.TermConfig.Length)
+}
+
+func TestProductServiceValidatesLoanConfig(t *testing.T) {
+	t.Parallel()
+
+	service := NewProductService(newProductRepositoryStub(), newInterestRateRepositoryStub())
+	loanConfig := func() *models.LoanConfig {
+		return &models.LoanConfig{
+			Amortization:       models.LoanAmortizationReducingBalance,
+			Rate:               "18",
+			Installments:       12,
+			RepaymentFrequency: models.RepaymentFrequencyMonthly,
+		}
+	}
+	for name, tc := range map[string]func(*CreateProductInput){
+		"invalid amortization": func(input *CreateProductInput) {
+			input.LoanConfig.Amortization = "balloon"
+		},
+		"invalid frequency": func(input *CreateProductInput) {
+			input.LoanConfig.RepaymentFrequency = "daily"
+		},
+		"no installments": func(input *CreateProductInput) {
+			input.LoanConfig.Installments = 0
+		},
+		"max below min principal": func(input *CreateProductInput) {
+			input.LoanConfig.MinPrincipal = "1000"
+			input.LoanConfig.MaxPrincipal = strPtr("500")
+		},
+		"term loan": func(input *CreateProductInput) {
+			input.TermConfig = &models.TermConfig{Length: 3, Unit: models.TermUnitMonths}
+		},
+		"overdraft limit without negative balance": func(input *CreateProductInput) {
+			input.LoanConfig = nil
+			input.Rules = &ProductRulesInput{OverdraftLimit: strPtr("500")}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			input := CreateProductInput{
+				Code:       "LN-USD-001",
+				Name:       "Personal Loan USD",
+				Category:   "loan",
+				Currency:   "USD",
+				LoanConfig: loanConfig(),
+			}
+			tc(&input)
+			_, err := service.Create(context.Background(), input)
+			require.ErrorIs(t, err, ErrProductValidation)
+		})
+	}
+
+	product, err := service.Create(context.Background(), CreateProductInput{
+		Code:       "LN-USD-002",
+		Name:       "Personal Loan USD",
+		Category:   "loan",
+		Currency:   "USD",
+		LoanConfig: loanConfig(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.LoanAmortizationReducingBalance, product.LoanConfig.Amortization)
+}
+
+func TestProductServiceValidatesPenaltyFees(t *testing.T) {
+	t.Parallel()
+
+	service := NewProductService(newProductRepositoryStub(), newInterestRateRepositoryStub())
+	for name, penalties := range map[string]map[string]models.PenaltyFee{
+		"unknown penalty":                     {"late_payment": {Type: "flat", Value: "5.00"}},
+		"excess withdrawals without period":   {models.PenaltyExcessWithdrawals: {Type: "flat", Value: "1.00", FreeWithdrawals: 4}},
+		"negative free withdrawals":           {models.PenaltyExcessWithdrawals: {Type: "flat", Value: "1.00", FreeWithdrawals: -1, Period: models.PenaltyPeriodMonthly}},
+		"early closure without window":        {models.PenaltyEarlyClosure: {Type: "flat", Value: "25.00"}},
+		"minimum balance with trigger fields": {models.PenaltyMinimumBalance: {Type: "flat", Value: "5.00", WithinDays: 30}},
+		"invalid fee type":                    {models.PenaltyDormancy: {Type: "tiered", Value: "5.00"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := service.Create(context.Background(), CreateProductInput{
+				Code:        "SAV-USD-001",
+				Name:        "Savings USD",
+				Category:    "savings",
+				Currency:    "USD",
+				FeeSchedule: &models.FeeSchedule{PenaltyFees: penalties},
+			})
+			require.ErrorIs(t, err, ErrProductValidation)
+		})
+	}
+
+	product, err := service.Create(context.Background(), CreateProductInput{
+		Code:     "SAV-USD-002",
+		Name:     "Savings USD",
+		Category: "savings",
+		Currency: "USD",
+		FeeSchedule: &models.FeeSchedule{PenaltyFees: map[string]models.PenaltyFee{
+			models.PenaltyMinimumBalance:    {Type: "flat", Value: "5.00"},
+			models.PenaltyInsufficientFunds: {Type: "flat", Value: "15.00"},
+			models.PenaltyExcessWithdrawals: {Type: "percentage", Value: "1", Min: strPtr("0.50"), FreeWithdrawals: 4, Period: models.PenaltyPeriodMonthly},
+			models.PenaltyDormancy:          {Type: "flat", Value: "20.00"},
+			models.PenaltyEarlyClosure:      {Type: "flat", Value: "25.00", WithinDays: 90},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, product.FeeSchedule.PenaltyFees, 5)
+}
+
+func TestProductServiceValidatesTaxConfig(t *testing.T) {
+	t.Parallel()
+
+	service := NewProductService(newProductRepositoryStub(), newInterestRateRepositoryStub())
+	for name, rate := range map[string]models.WithholdingTaxRate{
+		"invalid residency":   {Residency: "FRA", Rate: "30"},
+		"invalid client type": {ClientType: "trust", Rate: "30"},
+		"invalid rate":        {Rate: "thirty"},
+		"rate above 100":      {Rate: "101"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := service.Create(context.Background(), CreateProductInput{
+				Code:      "SAV-USD-001",
+				Name:      "Savings USD",
+				Category:  "savings",
+				Currency:  "USD",
+				TaxConfig: &models.TaxConfig{WithholdingRates: []models.WithholdingTaxRate{rate}},
+			})
+			require.ErrorIs(t, err, ErrProductValidation)
+		})
+	}
+
+	product, err := service.Create(context.Background(), CreateProductInput{
+		Code:     "SAV-USD-002",
+		Name:     "Savings USD",
+		Category: "savings",
+		Currency: "USD",
+		TaxConfig: &models.TaxConfig{WithholdingRates: []models.WithholdingTaxRate{
+			{Residency: "FR", ClientType: models.ClientTypeIndividual, Rate: "30"},
+			{Rate: "15"},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, product.TaxConfig.WithholdingRates, 2)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/formancehq/ledger/internal/cba/models"
 	"github.com/formancehq/ledger/internal/cba/repositories"
 	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
 	storagecommon "github.com/formancehq/ledger/internal/storage/common"
 	ledgerstore "github.com/formancehq/ledger/internal/storage/ledger"
 )
@@ -136,11 +137,42 @@ type InterestFeeReport struct {
 	Totals   InterestFeeTotals           `json:"totals"`
 }
 
+type WithholdingTaxAccountSummary struct {
+	AccountID       uuid.UUID `json:"account_id"`
+	AccountNumber   string    `json:"account_number"`
+	ClientID        uuid.UUID `json:"client_id"`
+	Currency        string    `json:"currency"`
+	InterestAccrued string    `json:"interest_accrued"`
+	GrossInterest   int64     `json:"gross_interest"`
+	TaxWithheld     int64     `json:"tax_withheld"`
+	NetInterest     int64     `json:"net_interest"`
+	ExemptInterest  int64     `json:"exempt_interest"`
+	PostingCount    int64     `json:"posting_count"`
+}
+
+// WithholdingTaxTotals sums the tax postings of a currency for the clients of
+// a tax residency.
+type WithholdingTaxTotals struct {
+	Currency       string `json:"currency"`
+	Residency      string `json:"residency"`
+	GrossInterest  int64  `json:"gross_interest"`
+	TaxWithheld    int64  `json:"tax_withheld"`
+	NetInterest    int64  `json:"net_interest"`
+	ExemptInterest int64  `json:"exempt_interest"`
+	PostingCount   int64  `json:"posting_count"`
+}
+
+type WithholdingTaxReport struct {
+	Accounts []WithholdingTaxAccountSummary `json:"accounts"`
+	Totals   []WithholdingTaxTotals         `json:"totals"`
+}
+
 type ReportingService interface {
 	ClientPortfolio(context.Context, ledgercontroller.Controller, uuid.UUID) (*ClientPortfolioReport, error)
 	AccountStatement(context.Context, ledgercontroller.Controller, uuid.UUID, StatementReportFilter) (*AccountStatementReport, error)
 	DailyTransactionSummary(context.Context, ledgercontroller.Controller, DailyTransactionSummaryFilter) (*DailyTransactionSummaryReport, error)
 	InterestFeeSummary(context.Context, InterestFeeReportFilter) (*InterestFeeReport, error)
+	WithholdingTaxSummary(context.Context, InterestFeeReportFilter) (*WithholdingTaxReport, error)
 }
 
 type DefaultReportingService struct {
@@ -148,6 +180,7 @@ type DefaultReportingService struct {
 	accountRepository  repositories.AccountRepository
 	interestRepository repositories.InterestAccrualRepository
 	feeRepository      repositories.FeePostingRepository
+	taxRepository      repositories.TaxPostingRepository
 }
 
 func NewReportingService(
@@ -155,12 +188,14 @@ func NewReportingService(
 	accountRepository repositories.AccountRepository,
 	interestRepository repositories.InterestAccrualRepository,
 	feeRepository repositories.FeePostingRepository,
+	taxRepository repositories.TaxPostingRepository,
 ) ReportingService {
 	return &DefaultReportingService{
 		clientRepository:   clientRepository,
 		accountRepository:  accountRepository,
 		interestRepository: interestRepository,
 		feeRepository:      feeRepository,
+		taxRepository:      taxRepository,
 	}
 }
 
@@ -451,6 +486,92 @@ func (s *DefaultReportingService) InterestFeeSummary(ctx context.Context, filter
 	return report, nil
 }
 
+// WithholdingTaxSummary reports the interest accrued by the accounts over the
+// period along with the interest paid and the tax withheld from it.
+func (s *DefaultReportingService) WithholdingTaxSummary(ctx context.Context, filter InterestFeeReportFilter) (*WithholdingTaxReport, error) {
+	accounts, err := s.listAccountsForScope(ctx, filter.ClientID, filter.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &WithholdingTaxReport{
+		Accounts: make([]WithholdingTaxAccountSummary, 0, len(accounts)),
+		Totals:   make([]WithholdingTaxTotals, 0),
+	}
+	totals := map[string]*WithholdingTaxTotals{}
+
+	for _, account := range accounts {
+		accruals, err := s.interestRepository.ListByAccount(ctx, account.ID)
+		if err != nil {
+			return nil, err
+		}
+		postings, err := s.taxRepository.ListByAccount(ctx, account.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		accountSummary := WithholdingTaxAccountSummary{
+			AccountID:     account.ID,
+			AccountNumber: account.AccountNumber,
+			ClientID:      account.ClientID,
+			Currency:      account.Currency,
+		}
+		accountInterest := decimal.Zero
+		for _, accrual := range accruals {
+			if withinDateRange(accrual.AccrualDate, filter.StartTime, filter.EndTime) {
+				accountInterest = accountInterest.Add(accrual.Amount)
+			}
+		}
+		accountSummary.InterestAccrued = accountInterest.String()
+
+		for _, posting := range postings {
+			if !withinDateRange(posting.CreatedAt, filter.StartTime, filter.EndTime) {
+				continue
+			}
+			key := posting.Currency + "|" + posting.Residency
+			total, ok := totals[key]
+			if !ok {
+				total = &WithholdingTaxTotals{
+					Currency:  posting.Currency,
+					Residency: posting.Residency,
+				}
+				totals[key] = total
+			}
+
+			exempt := int64(0)
+			if posting.Exempt {
+				exempt = posting.GrossAmount
+			}
+			accountSummary.GrossInterest += posting.GrossAmount
+			accountSummary.TaxWithheld += posting.TaxAmount
+			accountSummary.NetInterest += posting.NetAmount
+			accountSummary.ExemptInterest += exempt
+			accountSummary.PostingCount++
+			total.GrossInterest += posting.GrossAmount
+			total.TaxWithheld += posting.TaxAmount
+			total.NetInterest += posting.NetAmount
+			total.ExemptInterest += exempt
+			total.PostingCount++
+		}
+		report.Accounts = append(report.Accounts, accountSummary)
+	}
+
+	sort.Slice(report.Accounts, func(i, j int) bool {
+		return report.Accounts[i].AccountNumber < report.Accounts[j].AccountNumber
+	})
+	for _, total := range totals {
+		report.Totals = append(report.Totals, *total)
+	}
+	sort.Slice(report.Totals, func(i, j int) bool {
+		if report.Totals[i].Currency != report.Totals[j].Currency {
+			return report.Totals[i].Currency < report.Totals[j].Currency
+		}
+		return report.Totals[i].Residency < report.Totals[j].Residency
+	})
+
+	return report, nil
+}
+
 func (s *DefaultReportingService) getClient(ctx context.Context, clientID uuid.UUID) (*models.Client, error) {
 	client, err := s.clientRepository.Get(ctx, clientID)
 	if err != nil {
@@ -537,7 +658,7 @@ func listTransactionsForAccounts(ctx context.Context, ledger ledgercontroller.Co
 
 func reportingBalanceChange(tx ledgerinternal.Transaction, trackedAccount, currency string) (int64, int64, int64) {
 	var balanceBefore, balanceAfter int64
-	assetName := fmt.Sprintf("%s/2", currency)
+	assetName := currencyregistry.Asset(currency)
 	if vol, ok := tx.PostCommitVolumes[trackedAccount]; ok {
 		if v, ok := vol[assetName]; ok {
 			balanceAfter = v.Balance().Int64()
@@ -566,7 +687,7 @@ func reportingAvailableBalance(ctx context.Context, ledger ledgercontroller.Cont
 	if err != nil {
 		return 0, err
 	}
-	assetName := fmt.Sprintf("%s/2", account.Currency)
+	assetName := currencyregistry.Asset(account.Currency)
 	if amount, ok := balancesMap[assetName]; ok && amount != nil {
 		return amount.Int64(), nil
 	}
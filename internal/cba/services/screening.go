@@ -0,0 +1,381 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+var (
+	ErrScreeningValidation   = errors.New("screening validation failed")
+	ErrScreeningCaseNotFound = errors.New("screening case not found")
+	ErrScreeningCaseReviewed = errors.New("screening case is already reviewed")
+	ErrScreeningBlocked      = errors.New("blocked by sanctions screening")
+)
+
+// DefaultScreeningThreshold is the minimum similarity between a screened name
+// and a watchlist entry for it to be a hit.
+const DefaultScreeningThreshold = 0.9
+
+// CounterpartyMetadataKeys are the transaction metadata naming the other
+// party of a credit or a debit.
+var CounterpartyMetadataKeys = []string{"counterparty", "counterparty_name", "beneficiary_name", "originator_name", "sender_name", "recipient_name"}
+
+type ScreeningService interface {
+	// ScreenClient screens the client and its beneficial owners against the
+	// current watchlists, opens a case for each new hit, and records the
+	// resulting screening status on the client.
+	ScreenClient(context.Context, *models.Client) error
+	// ScreenTransaction screens the counterparties named in the metadata of
+	// a transaction and returns ErrScreeningBlocked when the client or one of
+	// the counterparties is blocked.
+	ScreenTransaction(context.Context, ScreenTransactionInput) error
+	// WatchlistVersion is the version of the current watchlists, empty when
+	// none is configured.
+	WatchlistVersion() (string, error)
+	ListCases(context.Context, repositories.ScreeningCaseFilter) ([]models.ScreeningCase, error)
+	GetCase(context.Context, uuid.UUID) (*models.ScreeningCase, error)
+	// ClearCase closes a case as a false positive.
+	ClearCase(context.Context, uuid.UUID, ReviewScreeningCaseInput) (*models.ScreeningCase, error)
+	// ConfirmCase closes a case as a true match, which blocks the client.
+	ConfirmCase(context.Context, uuid.UUID, ReviewScreeningCaseInput) (*models.ScreeningCase, error)
+}
+
+type ScreenTransactionInput struct {
+	ClientID  uuid.UUID
+	AccountID uuid.UUID
+	Reference string
+	Metadata  map[string]string
+}
+
+type ReviewScreeningCaseInput struct {
+	Note       string `json:"note,omitempty"`
+	ReviewedBy string `json:"-"`
+}
+
+type DefaultScreeningService struct {
+	clientRepository repositories.ClientRepository
+	caseRepository   repositories.ScreeningCaseRepository
+	watchlists       *WatchlistStore
+	threshold        float64
+}
+
+// NewScreeningService screens against the lists of watchlists, or against
+// no list at all when it is nil. A threshold of 0 selects
+// DefaultScreeningThreshold.
+func NewScreeningService(
+	clientRepository repositories.ClientRepository,
+	caseRepository repositories.ScreeningCaseRepository,
+	watchlists *WatchlistStore,
+	threshold float64,
+) ScreeningService {
+	if watchlists == nil {
+		watchlists = NewWatchlistStore()
+	}
+	if threshold <= 0 {
+		threshold = DefaultScreeningThreshold
+	}
+	return &DefaultScreeningService{
+		clientRepository: clientRepository,
+		caseRepository:   caseRepository,
+		watchlists:       watchlists,
+		threshold:        threshold,
+	}
+}
+
+type screeningSubject struct {
+	Type string
+	Name string
+}
+
+func (s *DefaultScreeningService) ScreenClient(ctx context.Context, client *models.Client) error {
+	watchlist, err := s.watchlists.Current()
+	if err != nil {
+		return err
+	}
+	cases, err := s.caseRepository.List(ctx, repositories.ScreeningCaseFilter{ClientID: &client.ID})
+	if err != nil {
+		return err
+	}
+
+	for _, subject := range clientScreeningSubjects(client) {
+		opened, err := s.openCases(ctx, watchlist, cases, &models.ScreeningCase{
+			ClientID:    client.ID,
+			SubjectType: subject.Type,
+			SubjectName: subject.Name,
+		})
+		if err != nil {
+			return err
+		}
+		cases = append(cases, opened...)
+	}
+
+	now := time.Now().UTC()
+	client.ScreeningStatus = screeningStatus(cases)
+	client.ScreeningVersion = watchlist.Version
+	client.ScreenedAt = &now
+	if err := s.clientRepository.Update(ctx, client); err != nil {
+		return resolveClientRepositoryError(err)
+	}
+	return nil
+}
+
+func (s *DefaultScreeningService) ScreenTransaction(ctx context.Context, input ScreenTransactionInput) error {
+	client, err := s.clientRepository.Get(ctx, input.ClientID)
+	if err != nil {
+		return resolveClientRepositoryError(err)
+	}
+	if client.ScreeningStatus == models.ScreeningStatusBlocked {
+		return fmt.Errorf("%w: client %s is blocked", ErrScreeningBlocked, client.ClientNumber)
+	}
+
+	names := make([]string, 0)
+	for _, key := range CounterpartyMetadataKeys {
+		if name := strings.TrimSpace(input.Metadata[key]); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	watchlist, err := s.watchlists.Current()
+	if err != nil {
+		return err
+	}
+	subjectType := models.ScreeningSubjectCounterparty
+	cases, err := s.caseRepository.List(ctx, repositories.ScreeningCaseFilter{
+		ClientID:    &client.ID,
+		SubjectType: &subjectType,
+	})
+	if err != nil {
+		return err
+	}
+
+	accountID := input.AccountID
+	blocked := ""
+	for _, name := range names {
+		opened, err := s.openCases(ctx, watchlist, cases, &models.ScreeningCase{
+			ClientID:    client.ID,
+			AccountID:   &accountID,
+			SubjectType: models.ScreeningSubjectCounterparty,
+			SubjectName: name,
+			Reference:   input.Reference,
+		})
+		if err != nil {
+			return err
+		}
+		cases = append(cases, opened...)
+		if blocked == "" && screeningStatus(screeningCasesOf(cases, name)) == models.ScreeningStatusBlocked {
+			blocked = name
+		}
+	}
+
+	if _, err := s.refreshClientStatus(ctx, client.ID); err != nil {
+		return err
+	}
+	if blocked != "" {
+		return fmt.Errorf("%w: counterparty %s", ErrScreeningBlocked, blocked)
+	}
+	return nil
+}
+
+// openCases opens a case, built from the template, for each watchlist entry
+// matching its subject name which has never been reviewed or opened for the
+// same subject. A hit cleared once is not raised again.
+func (s *DefaultScreeningService) openCases(ctx context.Context, watchlist *Watchlist, existing []models.ScreeningCase, template *models.ScreeningCase) ([]models.ScreeningCase, error) {
+	opened := make([]models.ScreeningCase, 0)
+	key := screeningSubjectKey(template.SubjectName)
+	for _, match := range watchlist.Match(template.SubjectName, s.threshold) {
+		seen := false
+		for _, screeningCase := range existing {
+			if screeningCase.SubjectType == template.SubjectType &&
+				screeningSubjectKey(screeningCase.SubjectName) == key &&
+				screeningCase.List == match.Entry.List &&
+				screeningCase.EntryID == match.Entry.ID {
+				seen = true
+				break
+			}
+		}
+		if seen {
+			continue
+		}
+
+		screeningCase := *template
+		screeningCase.ID = uuid.Nil
+		screeningCase.List = match.Entry.List
+		screeningCase.ListVersion = watchlist.Version
+		screeningCase.Category = match.Entry.Category
+		screeningCase.EntryID = match.Entry.ID
+		screeningCase.EntryName = match.Entry.Name
+		screeningCase.Score = match.Score
+		screeningCase.Action = models.ScreeningActionFlag
+		if match.Entry.Category == models.WatchlistCategorySanctions {
+			screeningCase.Action = models.ScreeningActionBlock
+		}
+		screeningCase.Status = models.ScreeningCaseStatusOpen
+		if err := s.caseRepository.Create(ctx, &screeningCase); err != nil {
+			if errors.Is(err, postgres.ErrConstraintsFailed{}) {
+				// Opened concurrently for the same subject.
+				continue
+			}
+			return nil, err
+		}
+		opened = append(opened, screeningCase)
+	}
+	return opened, nil
+}
+
+func (s *DefaultScreeningService) WatchlistVersion() (string, error) {
+	watchlist, err := s.watchlists.Current()
+	if err != nil {
+		return "", err
+	}
+	return watchlist.Version, nil
+}
+
+func (s *DefaultScreeningService) ListCases(ctx context.Context, filter repositories.ScreeningCaseFilter) ([]models.ScreeningCase, error) {
+	return s.caseRepository.List(ctx, filter)
+}
+
+func (s *DefaultScreeningService) GetCase(ctx context.Context, id uuid.UUID) (*models.ScreeningCase, error) {
+	screeningCase, err := s.caseRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveScreeningCaseRepositoryError(err)
+	}
+	return screeningCase, nil
+}
+
+func (s *DefaultScreeningService) ClearCase(ctx context.Context, id uuid.UUID, input ReviewScreeningCaseInput) (*models.ScreeningCase, error) {
+	if strings.TrimSpace(input.Note) == "" {
+		return nil, fmt.Errorf("%w: note is required to clear a case", ErrScreeningValidation)
+	}
+	return s.review(ctx, id, models.ScreeningCaseStatusCleared, input)
+}
+
+func (s *DefaultScreeningService) ConfirmCase(ctx context.Context, id uuid.UUID, input ReviewScreeningCaseInput) (*models.ScreeningCase, error) {
+	return s.review(ctx, id, models.ScreeningCaseStatusConfirmed, input)
+}
+
+// review closes an open case and updates the screening status of its client
+// accordingly.
+func (s *DefaultScreeningService) review(ctx context.Context, id uuid.UUID, status string, input ReviewScreeningCaseInput) (*models.ScreeningCase, error) {
+	reviewedBy := strings.TrimSpace(input.ReviewedBy)
+	if reviewedBy == "" {
+		return nil, fmt.Errorf("%w: the reviewer is unknown", ErrScreeningValidation)
+	}
+	screeningCase, err := s.caseRepository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveScreeningCaseRepositoryError(err)
+	}
+	if screeningCase.Status != models.ScreeningCaseStatusOpen {
+		return nil, fmt.Errorf("%w: case is %s", ErrScreeningCaseReviewed, screeningCase.Status)
+	}
+
+	now := time.Now().UTC()
+	screeningCase.Status = status
+	screeningCase.ReviewedBy = reviewedBy
+	screeningCase.ReviewNote = strings.TrimSpace(input.Note)
+	screeningCase.ReviewedAt = &now
+	if err := s.caseRepository.Transition(ctx, screeningCase, models.ScreeningCaseStatusOpen); err != nil {
+		if errors.Is(err, postgres.ErrNotFound) {
+			return nil, fmt.Errorf("%w: case was reviewed concurrently", ErrScreeningCaseReviewed)
+		}
+		return nil, err
+	}
+	if _, err := s.refreshClientStatus(ctx, screeningCase.ClientID); err != nil {
+		return nil, err
+	}
+	return screeningCase, nil
+}
+
+func (s *DefaultScreeningService) refreshClientStatus(ctx context.Context, clientID uuid.UUID) (*models.Client, error) {
+	cases, err := s.caseRepository.List(ctx, repositories.ScreeningCaseFilter{ClientID: &clientID})
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.clientRepository.Get(ctx, clientID)
+	if err != nil {
+		return nil, resolveClientRepositoryError(err)
+	}
+	status := screeningStatus(cases)
+	if client.ScreeningStatus == status {
+		return client, nil
+	}
+	client.ScreeningStatus = status
+	if err := s.clientRepository.Update(ctx, client); err != nil {
+		return nil, resolveClientRepositoryError(err)
+	}
+	return client, nil
+}
+
+// screeningStatus is blocked while a sanctions hit is open or once any hit
+// is confirmed, and flagged while other hits are open.
+func screeningStatus(cases []models.ScreeningCase) string {
+	status := models.ScreeningStatusClear
+	for _, screeningCase := range cases {
+		switch {
+		case screeningCase.Status == models.ScreeningCaseStatusConfirmed,
+			screeningCase.Status == models.ScreeningCaseStatusOpen && screeningCase.Action == models.ScreeningActionBlock:
+			return models.ScreeningStatusBlocked
+		case screeningCase.Status == models.ScreeningCaseStatusOpen:
+			status = models.ScreeningStatusFlagged
+		}
+	}
+	return status
+}
+
+func screeningCasesOf(cases []models.ScreeningCase, name string) []models.ScreeningCase {
+	key := screeningSubjectKey(name)
+	ret := make([]models.ScreeningCase, 0)
+	for _, screeningCase := range cases {
+		if screeningSubjectKey(screeningCase.SubjectName) == key {
+			ret = append(ret, screeningCase)
+		}
+	}
+	return ret
+}
+
+func screeningSubjectKey(name string) string {
+	return strings.Join(nameTokens(name), " ")
+}
+
+func clientScreeningSubjects(client *models.Client) []screeningSubject {
+	subjects := make([]screeningSubject, 0)
+	add := func(subjectType string, parts ...string) {
+		if name := strings.Join(strings.Fields(strings.Join(parts, " ")), " "); name != "" {
+			subjects = append(subjects, screeningSubject{Type: subjectType, Name: name})
+		}
+	}
+	if client.IndividualData != nil {
+		add(models.ScreeningSubjectClient, client.IndividualData.FirstName, client.IndividualData.MiddleName, client.IndividualData.LastName)
+	}
+	if client.CorporateData != nil {
+		add(models.ScreeningSubjectClient, client.CorporateData.LegalName)
+		if client.CorporateData.TradingName != nil {
+			add(models.ScreeningSubjectClient, *client.CorporateData.TradingName)
+		}
+		for _, owner := range client.CorporateData.BeneficialOwners {
+			add(models.ScreeningSubjectBeneficialOwner, owner.Name)
+		}
+	}
+	return subjects
+}
+
+func resolveScreeningCaseRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrScreeningCaseNotFound
+	default:
+		return err
+	}
+}
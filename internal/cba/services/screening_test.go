@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+const testUNWatchlist = `<?xml version="1.0" encoding="UTF-8"?>
+<CONSOLIDATED_LIST>
+	<INDIVIDUALS>
+		<INDIVIDUAL>
+			<DATAID>6908555</DATAID>
+			<FIRST_NAME>Viktor</FIRST_NAME>
+			<SECOND_NAME>Petrovich</SECOND_NAME>
+			<THIRD_NAME>Krumm</THIRD_NAME>
+			<UN_LIST_TYPE>DPRK</UN_LIST_TYPE>
+			<NATIONALITY><VALUE>Utopia</VALUE></NATIONALITY>
+			<INDIVIDUAL_ALIAS><ALIAS_NAME>Viktor Krum</ALIAS_NAME></INDIVIDUAL_ALIAS>
+		</INDIVIDUAL>
+	</INDIVIDUALS>
+	<ENTITIES>
+		<ENTITY>
+			<DATAID>110456</DATAID>
+			<FIRST_NAME>Acme Shipping Company</FIRST_NAME>
+			<UN_LIST_TYPE>DPRK</UN_LIST_TYPE>
+			<ENTITY_ALIAS><ALIAS_NAME>Acme Maritime</ALIAS_NAME></ENTITY_ALIAS>
+		</ENTITY>
+	</ENTITIES>
+</CONSOLIDATED_LIST>`
+
+func writeTestWatchlists(t *testing.T) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	sanctions := filepath.Join(dir, "ofac.csv")
+	require.NoError(t, os.WriteFile(sanctions, []byte(
+		"ent_num,sdn_name,aka,programs\n"+
+			"36,\"José Álvarez Montoya\",Pepe Alvarez;J. Montoya,SDGT\n"+
+			"37,Boris Badenov,,CYBER2\n",
+	), 0o600))
+	peps := filepath.Join(dir, "peps.csv")
+	require.NoError(t, os.WriteFile(peps, []byte(
+		"id,name,category,country\n"+
+			"pep-1,Natasha Fatale,pep,PT\n",
+	), 0o600))
+	un := filepath.Join(dir, "un.xml")
+	require.NoError(t, os.WriteFile(un, []byte(testUNWatchlist), 0o600))
+	return []string{sanctions, peps, un}
+}
+
+func TestLoadWatchlist(t *testing.T) {
+	t.Parallel()
+
+	paths := writeTestWatchlists(t)
+	watchlist, err := LoadWatchlist(paths...)
+	require.NoError(t, err)
+	require.Len(t, watchlist.Entries, 5)
+	require.Len(t, watchlist.Version, 16)
+
+	byID := map[string]WatchlistEntry{}
+	for _, entry := range watchlist.Entries {
+		byID[entry.ID] = entry
+	}
+	require.Equal(t, WatchlistEntry{
+		ID:       "36",
+		List:     "ofac",
+		Category: models.WatchlistCategorySanctions,
+		Name:     "José Álvarez Montoya",
+		Aliases:  []string{"Pepe Alvarez", "J. Montoya"},
+		Program:  "SDGT",
+	}, byID["36"])
+	require.Equal(t, models.WatchlistCategoryPEP, byID["pep-1"].Category)
+	require.Equal(t, "Viktor Petrovich Krumm", byID["6908555"].Name)
+	require.Equal(t, "un", byID["6908555"].List)
+	require.Equal(t, "Utopia", byID["6908555"].Country)
+	require.Equal(t, []string{"Acme Maritime"}, byID["110456"].Aliases)
+
+	for name, expected := range map[string]string{
+		"Jose Alvarez Montoya":   "36",
+		"MONTOYA, José Álvarez":  "36",
+		"Jose Alvares Montoya":   "36",
+		"Pepe Álvarez":           "36",
+		"Krumm Viktor Petrovich": "6908555",
+		"Acme Maritime":          "110456",
+	} {
+		matches := watchlist.Match(name, DefaultScreeningThreshold)
+		require.NotEmpty(t, matches, name)
+		require.Equal(t, expected, matches[0].Entry.ID, name)
+	}
+	require.Empty(t, watchlist.Match("Ada Lovelace", DefaultScreeningThreshold))
+	require.Empty(t, watchlist.Match("Boris Johnson", DefaultScreeningThreshold))
+
+	// Rewriting a list changes the version the store serves.
+	store := NewWatchlistStore(paths...)
+	current, err := store.Current()
+	require.NoError(t, err)
+	require.Equal(t, watchlist.Version, current.Version)
+	require.NoError(t, os.WriteFile(paths[1], []byte("name,category\nNatasha Fatale,pep\nFearless Leader,pep\n"), 0o600))
+	require.NoError(t, os.Chtimes(paths[1], time.Now(), time.Now().Add(time.Minute)))
+	current, err = store.Current()
+	require.NoError(t, err)
+	require.NotEqual(t, watchlist.Version, current.Version)
+	require.Len(t, current.Entries, 6)
+
+	_, err = LoadWatchlist(filepath.Join(t.TempDir(), "missing.csv"))
+	require.Error(t, err)
+}
+
+func TestScreeningServiceScreensClients(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clientRepo := newClientRepositoryStub()
+	caseRepo := newScreeningCaseRepositoryStub()
+	screeningService := NewScreeningService(clientRepo, caseRepo, NewWatchlistStore(writeTestWatchlists(t)...), 0)
+	clientService := NewClientService(clientRepo, newAccountRepositoryStub(), screeningService)
+
+	clear := newKYCTestClient(t, clientService)
+	require.Equal(t, models.ScreeningStatusClear, clear.ScreeningStatus)
+	require.NotEmpty(t, clear.ScreeningVersion)
+	require.Empty(t, caseRepo.cases)
+
+	sanctioned, err := clientService.Create(ctx, CreateClientInput{
+		Type:    "individual",
+		Contact: models.ClientContact{Phone: "08000000001"},
+		IndividualData: &models.IndividualData{
+			FirstName: "Jose",
+			LastName:  "Alvarez Montoya",
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.ScreeningStatusBlocked, sanctioned.ScreeningStatus)
+	cases, err := screeningService.ListCases(ctx, repositories.ScreeningCaseFilter{ClientID: &sanctioned.ID})
+	require.NoError(t, err)
+	require.Len(t, cases, 1)
+	require.Equal(t, models.ScreeningActionBlock, cases[0].Action)
+	require.Equal(t, "36", cases[0].EntryID)
+
+	// Blocked clients are not activated, even with a verified KYC.
+	stored := clientRepo.clients[sanctioned.ID]
+	stored.KYCLevel, stored.KYCStatus = 1, models.KYCStatusVerified
+	_, err = clientService.Activate(ctx, sanctioned.ID)
+	require.ErrorIs(t, err, ErrScreeningBlocked)
+
+	_, err = screeningService.ClearCase(ctx, cases[0].ID, ReviewScreeningCaseInput{ReviewedBy: "compliance"})
+	require.ErrorIs(t, err, ErrScreeningValidation)
+	_, err = screeningService.ClearCase(ctx, cases[0].ID, ReviewScreeningCaseInput{Note: "different date of birth"})
+	require.ErrorIs(t, err, ErrScreeningValidation)
+	cleared, err := screeningService.ClearCase(ctx, cases[0].ID, ReviewScreeningCaseInput{
+		Note:       "different date of birth",
+		ReviewedBy: "compliance",
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.ScreeningCaseStatusCleared, cleared.Status)
+	require.Equal(t, "compliance", cleared.ReviewedBy)
+	_, err = screeningService.ConfirmCase(ctx, cases[0].ID, ReviewScreeningCaseInput{ReviewedBy: "compliance"})
+	require.ErrorIs(t, err, ErrScreeningCaseReviewed)
+
+	activated, err := clientService.Activate(ctx, sanctioned.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ScreeningStatusClear, activated.ScreeningStatus)
+
+	// A cleared hit is not raised again when the client is screened again.
+	require.NoError(t, screeningService.ScreenClient(ctx, activated))
+	require.Len(t, caseRepo.cases, 1)
+
+	// Beneficial owners are screened with their company, and PEP hits only
+	// flag the client until they are reviewed.
+	corporate, err := clientService.Create(ctx, CreateClientInput{
+		Type:    "corporate",
+		Contact: models.ClientContact{Phone: "08000000002"},
+		CorporateData: &models.CorporateData{
+			LegalName: "Formance Ltd",
+			BeneficialOwners: []models.BeneficialOwner{
+				{Name: "Natasha Fatale", OwnershipPct: "40"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, models.ScreeningStatusFlagged, corporate.ScreeningStatus)
+	subjectType := models.ScreeningSubjectBeneficialOwner
+	cases, err = screeningService.ListCases(ctx, repositories.ScreeningCaseFilter{
+		ClientID:    &corporate.ID,
+		SubjectType: &subjectType,
+	})
+	require.NoError(t, err)
+	require.Len(t, cases, 1)
+	require.Equal(t, models.ScreeningActionFlag, cases[0].Action)
+
+	_, err = screeningService.ConfirmCase(ctx, cases[0].ID, ReviewScreeningCaseInput{ReviewedBy: "compliance"})
+	require.NoError(t, err)
+	blocked, err := clientService.Get(ctx, corporate.ID)
+	require.NoError(t, err)
+	require.Equal(t, models.ScreeningStatusBlocked, blocked.ScreeningStatus)
+
+	_, err = screeningService.GetCase(ctx, uuid.New())
+	require.ErrorIs(t, err, ErrScreeningCaseNotFound)
+}
+
+func TestScreeningServiceScreensCounterparties(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clientRepo := newClientRepositoryStub()
+	caseRepo := newScreeningCaseRepositoryStub()
+	screeningService := NewScreeningService(clientRepo, caseRepo, NewWatchlistStore(writeTestWatchlists(t)...), 0)
+	client := newKYCTestClient(t, NewClientService(clientRepo, newAccountRepositoryStub(), screeningService))
+	accountID := uuid.New()
+
+	require.NoError(t, screeningService.ScreenTransaction(ctx, ScreenTransactionInput{
+		ClientID:  client.ID,
+		AccountID: accountID,
+		Metadata:  map[string]string{"beneficiary_name": "Grace Hopper"},
+	}))
+
+	err := screeningService.ScreenTransaction(ctx, ScreenTransactionInput{
+		ClientID:  client.ID,
+		AccountID: accountID,
+		Reference: "trf-1",
+		Metadata:  map[string]string{"beneficiary_name": "Boris Badenov"},
+	})
+	require.ErrorIs(t, err, ErrScreeningBlocked)
+	require.Len(t, caseRepo.cases, 1)
+	for _, screeningCase := range caseRepo.cases {
+		require.Equal(t, models.ScreeningSubjectCounterparty, screeningCase.SubjectType)
+		require.Equal(t, "trf-1", screeningCase.Reference)
+		require.Equal(t, accountID, *screeningCase.AccountID)
+	}
+
+	// The open sanctions hit blocks every transaction of the client.
+	err = screeningService.ScreenTransaction(ctx, ScreenTransactionInput{
+		ClientID:  client.ID,
+		AccountID: accountID,
+		Metadata:  map[string]string{"beneficiary_name": "Grace Hopper"},
+	})
+	require.ErrorIs(t, err, ErrScreeningBlocked)
+	require.Len(t, caseRepo.cases, 1)
+}
+
+type screeningCaseRepositoryStub struct {
+	cases map[uuid.UUID]*models.ScreeningCase
+}
+
+func newScreeningCaseRepositoryStub() *screeningCaseRepositoryStub {
+	return &screeningCaseRepositoryStub{
+		cases: map[uuid.UUID]*models.ScreeningCase{},
+	}
+}
+
+func (s *screeningCaseRepositoryStub) Create(_ context.Context, screeningCase *models.ScreeningCase) error {
+	if screeningCase.ID == uuid.Nil {
+		screeningCase.ID = uuid.New()
+	}
+	copied := *screeningCase
+	s.cases[screeningCase.ID] = &copied
+	return nil
+}
+
+func (s *screeningCaseRepositoryStub) Transition(_ context.Context, screeningCase *models.ScreeningCase, from string) error {
+	stored, ok := s.cases[screeningCase.ID]
+	if !ok || stored.Status != from {
+		return postgres.ErrNotFound
+	}
+	copied := *screeningCase
+	s.cases[screeningCase.ID] = &copied
+	return nil
+}
+
+func (s *screeningCaseRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.ScreeningCase, error) {
+	screeningCase, ok := s.cases[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	copied := *screeningCase
+	return &copied, nil
+}
+
+func (s *screeningCaseRepositoryStub) List(_ context.Context, filter repositories.ScreeningCaseFilter) ([]models.ScreeningCase, error) {
+	ret := make([]models.ScreeningCase, 0, len(s.cases))
+	for _, screeningCase := range s.cases {
+		if filter.ClientID != nil && screeningCase.ClientID != *filter.ClientID {
+			continue
+		}
+		if filter.SubjectType != nil && screeningCase.SubjectType != *filter.SubjectType {
+			continue
+		}
+		ret = append(ret, *screeningCase)
+	}
+	return ret, nil
+}
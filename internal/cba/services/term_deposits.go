@@ -0,0 +1,425 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+	"github.com/formancehq/ledger/internal/cba/repositories"
+)
+
+var (
+	ErrTermDepositValidation = errors.New("term deposit validation failed")
+	ErrTermDepositNotMatured = errors.New("term deposit has not matured")
+)
+
+// MaturityPlan is what happens to a term deposit at maturity. PayoutAmount is
+// moved to PayoutAccount before the maturity is completed; the remaining
+// balance becomes the principal of the next term unless the account is paid
+// out.
+type MaturityPlan struct {
+	AccountID      uuid.UUID
+	MaturityDate   time.Time
+	RolloverOption string
+	Balance        int64
+	Interest       int64
+	PayoutAccount  *models.Account
+	PayoutAmount   int64
+}
+
+// TermBreak is an early break of a term deposit. Penalty is nil when the
+// product does not charge an early withdrawal penalty.
+type TermBreak struct {
+	Account       *models.Account
+	Penalty       *models.FeePosting
+	PenaltyAmount int64
+}
+
+type SetRolloverInput struct {
+	RolloverOption  string     `json:"rollover_option"`
+	PayoutAccountID *uuid.UUID `json:"payout_account_id,omitempty"`
+}
+
+type TermDepositService interface {
+	ListMaturing(context.Context, time.Time) ([]models.Account, error)
+	SetRollover(context.Context, uuid.UUID, SetRolloverInput) (*models.Account, error)
+	PlanMaturity(context.Context, uuid.UUID, int64, time.Time) (*MaturityPlan, error)
+	CompleteMaturity(context.Context, MaturityPlan) (*models.Account, error)
+	Break(context.Context, uuid.UUID, int64, string) (*TermBreak, error)
+	MarkPenaltyDebited(context.Context, string) error
+}
+
+type DefaultTermDepositService struct {
+	accountRepository repositories.AccountRepository
+	productRepository repositories.ProductRepository
+	feeRepository     repositories.FeePostingRepository
+}
+
+func NewTermDepositService(
+	accountRepository repositories.AccountRepository,
+	productRepository repositories.ProductRepository,
+	feeRepository repositories.FeePostingRepository,
+) TermDepositService {
+	return &DefaultTermDepositService{
+		accountRepository: accountRepository,
+		productRepository: productRepository,
+		feeRepository:     feeRepository,
+	}
+}
+
+// ListMaturing lists the active term deposits maturing on or before asOf.
+func (s *DefaultTermDepositService) ListMaturing(ctx context.Context, asOf time.Time) ([]models.Account, error) {
+	status := models.AccountStatusActive
+	asOf = normalizeUsageDate(asOf)
+	accounts, err := s.accountRepository.List(ctx, repositories.AccountFilter{
+		Status:    &status,
+		MaturesBy: &asOf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]models.Account, 0, len(accounts))
+	for _, account := range accounts {
+		if termRunning(&account) {
+			ret = append(ret, account)
+		}
+	}
+	return ret, nil
+}
+
+func (s *DefaultTermDepositService) SetRollover(ctx context.Context, accountID uuid.UUID, input SetRolloverInput) (*models.Account, error) {
+	account, product, err := s.loadTermDeposit(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !termRunning(account) {
+		return nil, fmt.Errorf("%w: term is %s", ErrTermDepositValidation, account.Term.Status)
+	}
+
+	option := strings.TrimSpace(input.RolloverOption)
+	if err := validateRollover(ctx, s.accountRepository, product.TermConfig, account.ClientID, account.Currency, option, input.PayoutAccountID); err != nil {
+		return nil, err
+	}
+	account.Term.RolloverOption = option
+	account.Term.PayoutAccountID = input.PayoutAccountID
+
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
+// PlanMaturity plans the maturity of the account given its available balance
+// once the interest of the term was posted. The interest is what the balance
+// earned above the principal.
+func (s *DefaultTermDepositService) PlanMaturity(ctx context.Context, accountID uuid.UUID, balance int64, when time.Time) (*MaturityPlan, error) {
+	account, _, err := s.loadTermDeposit(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if !termRunning(account) {
+		return nil, fmt.Errorf("%w: term is %s", ErrTermDepositValidation, account.Term.Status)
+	}
+	if account.MaturityDate == nil {
+		return nil, fmt.Errorf("%w: term has not started", ErrTermDepositNotMatured)
+	}
+	if account.MaturityDate.After(normalizeUsageDate(when)) {
+		return nil, fmt.Errorf("%w: matures on %s", ErrTermDepositNotMatured, account.MaturityDate.Format(time.DateOnly))
+	}
+
+	plan := &MaturityPlan{
+		AccountID:      account.ID,
+		MaturityDate:   normalizeUsageDate(*account.MaturityDate),
+		RolloverOption: account.Term.RolloverOption,
+		Balance:        balance,
+		Interest:       max(balance-account.Term.Principal, 0),
+	}
+	switch plan.RolloverOption {
+	case models.RolloverPrincipal:
+		plan.PayoutAmount = plan.Interest
+	case models.RolloverPayout:
+		plan.PayoutAmount = balance
+	}
+	if plan.PayoutAmount > 0 {
+		plan.PayoutAccount, err = s.payoutAccount(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// CompleteMaturity rolls the account over into a new term, or closes it when
+// it was paid out. Completing a maturity twice is a no-op.
+func (s *DefaultTermDepositService) CompleteMaturity(ctx context.Context, plan MaturityPlan) (*models.Account, error) {
+	account, _, err := s.loadTermDeposit(ctx, plan.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if !termRunning(account) || account.MaturityDate == nil || !normalizeUsageDate(*account.MaturityDate).Equal(plan.MaturityDate) {
+		return account, nil
+	}
+
+	now := time.Now().UTC()
+	if plan.RolloverOption == models.RolloverPayout {
+		account.Term.Status = models.TermStatusMatured
+		account.Term.ClosedAt = &now
+		account.Status = models.AccountStatusClosed
+		account.ClosedAt = &now
+	} else {
+		account.Term.Principal = plan.Balance - plan.PayoutAmount
+		account.Term.Rollovers++
+		startTerm(account, plan.MaturityDate)
+	}
+
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return account, nil
+}
+
+// Break ends the term before maturity. The early withdrawal penalty is
+// computed on the available balance and recorded as a fee posting pending
+// recovery under a reference derived from the break reference. The unposted
+// interest of the term is forfeited and the account becomes a plain account.
+// Breaking again with the same reference returns the recorded break.
+func (s *DefaultTermDepositService) Break(ctx context.Context, accountID uuid.UUID, balance int64, reference string) (*TermBreak, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return nil, fmt.Errorf("%w: reference is required", ErrTermDepositValidation)
+	}
+	account, product, err := s.loadTermDeposit(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	penaltyReference := penaltyFeeReference(models.PenaltyEarlyWithdrawal, reference)
+
+	if account.Term.Status == models.TermStatusBroken {
+		posting, err := s.feeRepository.GetByReference(ctx, penaltyReference)
+		switch {
+		case err == nil:
+			amount, _, err := decimalToMinorHalfUp(posting.Amount, posting.Currency)
+			if err != nil {
+				return nil, err
+			}
+			return &TermBreak{Account: account, Penalty: posting, PenaltyAmount: amount}, nil
+		case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+			return nil, fmt.Errorf("%w: term is already broken", ErrTermDepositValidation)
+		default:
+			return nil, err
+		}
+	}
+	if !termRunning(account) {
+		return nil, fmt.Errorf("%w: term is %s", ErrTermDepositValidation, account.Term.Status)
+	}
+	if account.Status != models.AccountStatusActive {
+		return nil, fmt.Errorf("%w: account must be active to break its term", ErrTermDepositValidation)
+	}
+	if product.TermConfig == nil || !product.TermConfig.AllowEarlyBreak {
+		return nil, fmt.Errorf("%w: product does not allow breaking the term early", ErrTermDepositValidation)
+	}
+
+	ret := &TermBreak{Account: account}
+	rule, err := penaltyFeeRule(product.FeeSchedule, models.PenaltyEarlyWithdrawal)
+	switch {
+	case err == nil:
+		feeAmount, currency, err := calculateTransactionFeeAmount(account.Currency, *rule, balance)
+		if err != nil {
+			return nil, err
+		}
+		if currency != account.Currency {
+			return nil, fmt.Errorf("%w: early withdrawal penalty must be charged in %s", ErrTermDepositValidation, account.Currency)
+		}
+		ret.PenaltyAmount, _, err = decimalToMinorHalfUp(feeAmount, currency)
+		if err != nil {
+			return nil, err
+		}
+		ret.PenaltyAmount = min(ret.PenaltyAmount, max(balance, 0))
+	case errors.Is(err, ErrFeeNotApplicable):
+	default:
+		return nil, err
+	}
+
+	if ret.PenaltyAmount > 0 {
+		ret.Penalty = &models.FeePosting{
+			ID:              uuid.New(),
+			AccountID:       account.ID,
+			EventType:       models.PenaltyEarlyWithdrawal,
+			Reference:       penaltyReference,
+			LinkedReference: reference,
+			Amount:          minorUnitsToDecimal(ret.PenaltyAmount, account.Currency),
+			Currency:        account.Currency,
+			Status:          models.FeePostingStatusPendingRecovery,
+			Metadata: map[string]any{
+				"fee_type":   rule.Type,
+				"fee_event":  rule.Event,
+				"account_id": account.ID.String(),
+			},
+		}
+		if err := s.feeRepository.Create(ctx, ret.Penalty); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().UTC()
+	account.Term.Status = models.TermStatusBroken
+	account.Term.ClosedAt = &now
+	account.MaturityDate = nil
+	account.InterestAccrued = decimal.Zero
+	if err := s.accountRepository.Update(ctx, account); err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	return ret, nil
+}
+
+// MarkPenaltyDebited records that the penalty of the break was debited from
+// the wallet. The fee stays pending recovery until the maintenance fee job
+// books the fee income.
+func (s *DefaultTermDepositService) MarkPenaltyDebited(ctx context.Context, reference string) error {
+	posting, err := s.feeRepository.GetByReference(ctx, penaltyFeeReference(models.PenaltyEarlyWithdrawal, reference))
+	if err != nil {
+		return resolveFeeRepositoryError(err)
+	}
+	if posting.Metadata == nil {
+		posting.Metadata = map[string]any{}
+	}
+	posting.Metadata["wallet_posted"] = true
+	return s.feeRepository.Update(ctx, posting)
+}
+
+func (s *DefaultTermDepositService) loadTermDeposit(ctx context.Context, id uuid.UUID) (*models.Account, *models.Product, error) {
+	account, err := s.accountRepository.Get(ctx, id)
+	if err != nil {
+		return nil, nil, resolveAccountRepositoryError(err)
+	}
+	if account.Term == nil {
+		return nil, nil, fmt.Errorf("%w: account is not a term deposit", ErrTermDepositValidation)
+	}
+	product, err := s.productRepository.Get(ctx, account.ProductID)
+	if err != nil {
+		return nil, nil, resolveProductRepositoryError(err)
+	}
+	return account, product, nil
+}
+
+func (s *DefaultTermDepositService) payoutAccount(ctx context.Context, account *models.Account) (*models.Account, error) {
+	if account.Term.PayoutAccountID == nil {
+		return nil, fmt.Errorf("%w: %s rollover requires a payout account", ErrTermDepositValidation, account.Term.RolloverOption)
+	}
+	payout, err := s.accountRepository.Get(ctx, *account.Term.PayoutAccountID)
+	if err != nil {
+		return nil, resolveAccountRepositoryError(err)
+	}
+	if payout.Status == models.AccountStatusClosed {
+		return nil, fmt.Errorf("%w: payout account %s is closed", ErrTermDepositValidation, payout.ID)
+	}
+	return payout, nil
+}
+
+// validateRollover checks a rollover option against the product term
+// configuration. Options paying out require a payout account of the same
+// client and currency.
+func validateRollover(
+	ctx context.Context,
+	accountRepository repositories.AccountRepository,
+	config *models.TermConfig,
+	clientID uuid.UUID,
+	currency string,
+	option string,
+	payoutAccountID *uuid.UUID,
+) error {
+	if !isRolloverOption(option) {
+		return fmt.Errorf("%w: invalid rollover option %q", ErrAccountValidation, option)
+	}
+	if !termRolloverAllowed(config, option) {
+		return fmt.Errorf("%w: product does not allow rollover option %s", ErrAccountValidation, option)
+	}
+	if option == models.RolloverPrincipalAndInterest {
+		if payoutAccountID != nil {
+			return fmt.Errorf("%w: payout_account_id is not used by rollover option %s", ErrAccountValidation, option)
+		}
+		return nil
+	}
+	if payoutAccountID == nil {
+		return fmt.Errorf("%w: rollover option %s requires a payout_account_id", ErrAccountValidation, option)
+	}
+
+	payout, err := accountRepository.Get(ctx, *payoutAccountID)
+	if err != nil {
+		if postgres.IsNotFoundError(err) || errors.Is(err, postgres.ErrNotFound) {
+			return fmt.Errorf("%w: payout account %s not found", ErrAccountValidation, *payoutAccountID)
+		}
+		return err
+	}
+	if payout.ClientID != clientID {
+		return fmt.Errorf("%w: payout account must belong to the same client", ErrAccountValidation)
+	}
+	if payout.Currency != currency {
+		return fmt.Errorf("%w: payout account must be in %s", ErrAccountValidation, currency)
+	}
+	if payout.Term != nil || payout.Status == models.AccountStatusClosed {
+		return fmt.Errorf("%w: payout account must be an open non-term account", ErrAccountValidation)
+	}
+	return nil
+}
+
+func isRolloverOption(option string) bool {
+	switch option {
+	case models.RolloverPrincipal, models.RolloverPrincipalAndInterest, models.RolloverPayout:
+		return true
+	default:
+		return false
+	}
+}
+
+func termRolloverAllowed(config *models.TermConfig, option string) bool {
+	return config != nil && (len(config.RolloverOptions) == 0 || slices.Contains(config.RolloverOptions, option))
+}
+
+func defaultRollover(config *models.TermConfig) string {
+	switch {
+	case config.DefaultRollover != "":
+		return config.DefaultRollover
+	case len(config.RolloverOptions) > 0:
+		return config.RolloverOptions[0]
+	default:
+		return models.RolloverPrincipalAndInterest
+	}
+}
+
+func termRunning(account *models.Account) bool {
+	return account.Term != nil && account.Term.Status == models.TermStatusRunning
+}
+
+// startTerm starts a new term of the account on start.
+func startTerm(account *models.Account, start time.Time) {
+	maturity := termMaturityDate(start, account.Term.Length, account.Term.Unit)
+	account.Term.StartDate = &start
+	account.Term.Status = models.TermStatusRunning
+	account.MaturityDate = &maturity
+}
+
+// termMaturityDate adds the term to start. Monthly terms starting late in a
+// month mature on the last day of the target month rather than spilling into
+// the next one.
+func termMaturityDate(start time.Time, length int, unit string) time.Time {
+	if unit == models.TermUnitDays {
+		return start.AddDate(0, 0, length)
+	}
+	firstOfMonth := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, length, 0)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), min(start.Day(), lastDay), 0, 0, 0, 0, time.UTC)
+}
+
+func penaltyFeeReference(penalty, reference string) string {
+	return fmt.Sprintf("penalty:%s:%s", penalty, reference)
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+)
+
+func newTermDepositFixture(t *testing.T, productRepo *productRepositoryStub, clientRepo *clientRepositoryStub) (*models.Client, *models.Product) {
+	t.Helper()
+
+	client := &models.Client{
+		ID:           uuid.New(),
+		ClientNumber: "CL-2026-000100",
+		Type:         models.ClientTypeIndividual,
+		Status:       models.ClientStatusActive,
+		KYCLevel:     1,
+		KYCStatus:    models.KYCStatusVerified,
+	}
+	require.NoError(t, clientRepo.Create(context.Background(), client))
+
+	product := &models.Product{
+		ID:       uuid.New(),
+		Code:     "FD-USD-3M",
+		Name:     "Fixed Deposit 3 Months",
+		Category: "fixed_deposit",
+		Currency: "USD",
+		Status:   models.ProductStatusActive,
+		Rules: models.ProductRules{
+			MinOpeningBalance: "0",
+			MinBalance:        "0",
+			AllowDebits:       true,
+			AllowCredits:      true,
+		},
+		TermConfig: &models.TermConfig{
+			Length:          3,
+			Unit:            models.TermUnitMonths,
+			AllowEarlyBreak: true,
+		},
+		FeeSchedule: &models.FeeSchedule{
+			PenaltyFees: map[string]models.PenaltyFee{
+				models.PenaltyEarlyWithdrawal: {
+					Type:  "percentage",
+					Value: "1",
+					Min:   strPtr("5.00"),
+				},
+			},
+		},
+	}
+	require.NoError(t, productRepo.Create(context.Background(), product))
+	return client, product
+}
+
+func TestTermDepositOpenLocksDebitsUntilMaturity(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	clientRepo := newClientRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	service := NewAccountService(accountRepo, clientRepo, productRepo, newDailyUsageRepositoryStub())
+	client, product := newTermDepositFixture(t, productRepo, clientRepo)
+
+	account, err := service.Open(context.Background(), OpenAccountInput{
+		ClientID:       client.ID,
+		ProductID:      product.ID,
+		OpeningDeposit: json.Number("1000.00"),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, account.Term)
+	require.EqualValues(t, 100_000, account.Term.Principal)
+	require.Equal(t, models.RolloverPrincipalAndInterest, account.Term.RolloverOption)
+	require.Nil(t, account.MaturityDate)
+
+	account, err = service.Activate(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.NotNil(t, account.MaturityDate)
+	require.Equal(t, termMaturityDate(*account.Term.StartDate, 3, models.TermUnitMonths), *account.MaturityDate)
+
+	now := time.Now().UTC()
+	_, err = service.ValidateDebit(context.Background(), account.ID, 100, 100_000, now)
+	require.ErrorIs(t, err, ErrAccountValidation)
+	require.ErrorContains(t, err, "locked until")
+	_, err = service.ValidateCredit(context.Background(), account.ID, 100, 100_000, now)
+	require.ErrorIs(t, err, ErrAccountValidation)
+	_, err = service.ValidateLien(context.Background(), account.ID, 100, 100_000, now)
+	require.NoError(t, err)
+}
+
+func TestTermDepositOpenRequiresPayoutAccount(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	clientRepo := newClientRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	service := NewAccountService(accountRepo, clientRepo, productRepo, newDailyUsageRepositoryStub())
+	client, product := newTermDepositFixture(t, productRepo, clientRepo)
+
+	_, err := service.Open(context.Background(), OpenAccountInput{
+		ClientID:       client.ID,
+		ProductID:      product.ID,
+		OpeningDeposit: json.Number("1000.00"),
+		RolloverOption: models.RolloverPayout,
+	})
+	require.ErrorIs(t, err, ErrAccountValidation)
+
+	otherClientAccount := &models.Account{
+		ID:       uuid.New(),
+		ClientID: uuid.New(),
+		Currency: "USD",
+		Status:   models.AccountStatusActive,
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), otherClientAccount))
+	_, err = service.Open(context.Background(), OpenAccountInput{
+		ClientID:        client.ID,
+		ProductID:       product.ID,
+		OpeningDeposit:  json.Number("1000.00"),
+		RolloverOption:  models.RolloverPayout,
+		PayoutAccountID: &otherClientAccount.ID,
+	})
+	require.ErrorIs(t, err, ErrAccountValidation)
+}
+
+func TestTermDepositMaturityRollsOverPrincipal(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	_, product := newTermDepositFixture(t, productRepo, newClientRepositoryStub())
+	service := NewTermDepositService(accountRepo, productRepo, newFeePostingRepositoryStub())
+
+	clientID := uuid.New()
+	payout := &models.Account{ID: uuid.New(), ClientID: clientID, Currency: "USD", Status: models.AccountStatusActive}
+	require.NoError(t, accountRepo.Create(context.Background(), payout))
+
+	start := time.Date(2026, 2, 15, 0, 0, 0, 0, time.UTC)
+	maturity := time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC)
+	account := &models.Account{
+		ID:           uuid.New(),
+		ClientID:     clientID,
+		ProductID:    product.ID,
+		Currency:     "USD",
+		Status:       models.AccountStatusActive,
+		MaturityDate: &maturity,
+		Term: &models.AccountTerm{
+			Length:          3,
+			Unit:            models.TermUnitMonths,
+			Status:          models.TermStatusRunning,
+			Principal:       100_000,
+			StartDate:       &start,
+			RolloverOption:  models.RolloverPrincipal,
+			PayoutAccountID: &payout.ID,
+		},
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	_, err := service.PlanMaturity(context.Background(), account.ID, 101_500, maturity.AddDate(0, 0, -1))
+	require.ErrorIs(t, err, ErrTermDepositNotMatured)
+
+	maturing, err := service.ListMaturing(context.Background(), maturity)
+	require.NoError(t, err)
+	require.Len(t, maturing, 1)
+	require.Equal(t, account.ID, maturing[0].ID)
+
+	plan, err := service.PlanMaturity(context.Background(), account.ID, 101_500, maturity)
+	require.NoError(t, err)
+	require.EqualValues(t, 1_500, plan.Interest)
+	require.EqualValues(t, 1_500, plan.PayoutAmount)
+	require.Equal(t, payout.ID, plan.PayoutAccount.ID)
+
+	matured, err := service.CompleteMaturity(context.Background(), *plan)
+	require.NoError(t, err)
+	require.Equal(t, models.AccountStatusActive, matured.Status)
+	require.Equal(t, models.TermStatusRunning, matured.Term.Status)
+	require.EqualValues(t, 100_000, matured.Term.Principal)
+	require.Equal(t, 1, matured.Term.Rollovers)
+	require.Equal(t, time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC), *matured.MaturityDate)
+
+	again, err := service.CompleteMaturity(context.Background(), *plan)
+	require.NoError(t, err)
+	require.Equal(t, 1, again.Term.Rollovers)
+}
+
+func TestTermDepositBreakChargesPenalty(t *testing.T) {
+	t.Parallel()
+
+	accountRepo := newAccountRepositoryStub()
+	productRepo := newProductRepositoryStub()
+	feeRepo := newFeePostingRepositoryStub()
+	_, product := newTermDepositFixture(t, productRepo, newClientRepositoryStub())
+	service := NewTermDepositService(accountRepo, productRepo, feeRepo)
+
+	maturity := time.Date(2026, 8, 15, 0, 0, 0, 0, time.UTC)
+	account := &models.Account{
+		ID:           uuid.New(),
+		ProductID:    product.ID,
+		Currency:     "USD",
+		Status:       models.AccountStatusActive,
+		MaturityDate: &maturity,
+		Term: &models.AccountTerm{
+			Length:         3,
+			Unit:           models.TermUnitMonths,
+			Status:         models.TermStatusRunning,
+			Principal:      100_000,
+			RolloverOption: models.RolloverPrincipalAndInterest,
+		},
+	}
+	require.NoError(t, accountRepo.Create(context.Background(), account))
+
+	termBreak, err := service.Break(context.Background(), account.ID, 100_000, "break-1")
+	require.NoError(t, err)
+	require.EqualValues(t, 1_000, termBreak.PenaltyAmount)
+	require.NotNil(t, termBreak.Penalty)
+	require.Equal(t, models.PenaltyEarlyWithdrawal, termBreak.Penalty.EventType)
+	require.Equal(t, models.FeePostingStatusPendingRecovery, termBreak.Penalty.Status)
+	require.Equal(t, models.TermStatusBroken, termBreak.Account.Term.Status)
+	require.Nil(t, termBreak.Account.MaturityDate)
+
+	require.NoError(t, service.MarkPenaltyDebited(context.Background(), "break-1"))
+	posting, err := feeRepo.GetByReference(context.Background(), termBreak.Penalty.Reference)
+	require.NoError(t, err)
+	require.Equal(t, true, posting.Metadata["wallet_posted"])
+
+	retried, err := service.Break(context.Background(), account.ID, 99_000, "break-1")
+	require.NoError(t, err)
+	require.EqualValues(t, 1_000, retried.PenaltyAmount)
+
+	_, err = service.Break(context.Background(), account.ID, 99_000, "break-2")
+	require.ErrorIs(t, err, ErrTermDepositValidation)
+}
+
+func TestTermMaturityDate(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		start    time.Time
+		length   int
+		unit     string
+		expected time.Time
+	}{
+		"days":         {start: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), length: 90, unit: models.TermUnitDays, expected: time.Date(2026, 8, 13, 0, 0, 0, 0, time.UTC)},
+		"months":       {start: time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC), length: 6, unit: models.TermUnitMonths, expected: time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC)},
+		"end of month": {start: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), length: 1, unit: models.TermUnitMonths, expected: time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expected, termMaturityDate(tc.start, tc.length, tc.unit))
+		})
+	}
+}
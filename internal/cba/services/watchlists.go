@@ -0,0 +1,390 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/formancehq/ledger/internal/cba/models"
+)
+
+// WatchlistEntry is a sanctioned or politically exposed person or entity.
+// List is the name of the file the entry was loaded from.
+type WatchlistEntry struct {
+	ID       string   `json:"id"`
+	List     string   `json:"list"`
+	Category string   `json:"category"`
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Program  string   `json:"program,omitempty"`
+	Country  string   `json:"country,omitempty"`
+}
+
+// Watchlist holds the entries of every configured list. Version changes
+// whenever the content of one of the lists does.
+type Watchlist struct {
+	Version string
+	Entries []WatchlistEntry
+}
+
+// LoadWatchlist reads the lists at paths. Files ending in .xml are read as
+// UN consolidated lists, the other ones as CSV.
+func LoadWatchlist(paths ...string) (*Watchlist, error) {
+	watchlist := &Watchlist{}
+	if len(paths) == 0 {
+		return watchlist, nil
+	}
+
+	sorted := append([]string(nil), paths...)
+	sort.Strings(sorted)
+	hash := sha256.New()
+	for _, path := range sorted {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = hash.Write([]byte(path))
+		_, _ = hash.Write(data)
+
+		list := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		var entries []WatchlistEntry
+		if strings.EqualFold(filepath.Ext(path), ".xml") {
+			entries, err = parseXMLWatchlist(list, data)
+		} else {
+			entries, err = parseCSVWatchlist(list, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading watchlist %s: %w", path, err)
+		}
+		watchlist.Entries = append(watchlist.Entries, entries...)
+	}
+	watchlist.Version = hex.EncodeToString(hash.Sum(nil))[:16]
+
+	return watchlist, nil
+}
+
+// csvWatchlistColumns maps the columns of the CSV lists, OFAC style headers
+// included, to the entry fields.
+var csvWatchlistColumns = map[string]string{
+	"id":          "id",
+	"uid":         "id",
+	"ent_num":     "id",
+	"reference":   "id",
+	"name":        "name",
+	"full_name":   "name",
+	"sdn_name":    "name",
+	"whole_name":  "name",
+	"aliases":     "aliases",
+	"aka":         "aliases",
+	"category":    "category",
+	"program":     "program",
+	"programs":    "program",
+	"country":     "country",
+	"nationality": "country",
+}
+
+// parseCSVWatchlist reads a CSV list with a header row. Aliases are separated
+// by semicolons and entries are sanctions unless their category says pep.
+func parseCSVWatchlist(list string, data []byte) ([]WatchlistEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, column := range header {
+		if field, ok := csvWatchlistColumns[strings.ToLower(strings.TrimSpace(column))]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("missing name column")
+	}
+
+	entries := make([]WatchlistEntry, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		entry := WatchlistEntry{
+			ID:       value("id"),
+			List:     list,
+			Category: models.WatchlistCategorySanctions,
+			Name:     value("name"),
+			Program:  value("program"),
+			Country:  value("country"),
+		}
+		if entry.Name == "" {
+			continue
+		}
+		if entry.ID == "" {
+			entry.ID = fmt.Sprintf("%d", line)
+		}
+		if strings.EqualFold(value("category"), models.WatchlistCategoryPEP) {
+			entry.Category = models.WatchlistCategoryPEP
+		}
+		for _, alias := range strings.Split(value("aliases"), ";") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Aliases = append(entry.Aliases, alias)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+type unWatchlist struct {
+	Individuals []unWatchlistRecord `xml:"INDIVIDUALS>INDIVIDUAL"`
+	Entities    []unWatchlistRecord `xml:"ENTITIES>ENTITY"`
+}
+
+type unWatchlistRecord struct {
+	DataID          string   `xml:"DATAID"`
+	FirstName       string   `xml:"FIRST_NAME"`
+	SecondName      string   `xml:"SECOND_NAME"`
+	ThirdName       string   `xml:"THIRD_NAME"`
+	FourthName      string   `xml:"FOURTH_NAME"`
+	ListType        string   `xml:"UN_LIST_TYPE"`
+	Nationality     string   `xml:"NATIONALITY>VALUE"`
+	IndividualAlias []string `xml:"INDIVIDUAL_ALIAS>ALIAS_NAME"`
+	EntityAlias     []string `xml:"ENTITY_ALIAS>ALIAS_NAME"`
+}
+
+// parseXMLWatchlist reads the individuals and entities of a UN consolidated
+// sanctions list.
+func parseXMLWatchlist(list string, data []byte) ([]WatchlistEntry, error) {
+	document := unWatchlist{}
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	entries := make([]WatchlistEntry, 0, len(document.Individuals)+len(document.Entities))
+	for _, record := range append(document.Individuals, document.Entities...) {
+		name := strings.Join(strings.Fields(strings.Join([]string{record.FirstName, record.SecondName, record.ThirdName, record.FourthName}, " ")), " ")
+		if name == "" {
+			continue
+		}
+		entry := WatchlistEntry{
+			ID:       record.DataID,
+			List:     list,
+			Category: models.WatchlistCategorySanctions,
+			Name:     name,
+			Program:  strings.TrimSpace(record.ListType),
+			Country:  strings.TrimSpace(record.Nationality),
+		}
+		for _, alias := range append(record.IndividualAlias, record.EntityAlias...) {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				entry.Aliases = append(entry.Aliases, alias)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// WatchlistStore serves the configured lists and reloads them when one of
+// the files changes, so that updated lists are used without a restart.
+type WatchlistStore struct {
+	paths []string
+
+	mu      sync.Mutex
+	stamps  map[string]watchlistFileStamp
+	current *Watchlist
+}
+
+type watchlistFileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func NewWatchlistStore(paths ...string) *WatchlistStore {
+	return &WatchlistStore{
+		paths: paths,
+	}
+}
+
+// Current returns the lists, reloading them first if a file changed since
+// they were last read.
+func (s *WatchlistStore) Current() (*Watchlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stamps := make(map[string]watchlistFileStamp, len(s.paths))
+	changed := s.current == nil
+	for _, path := range s.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[path] = watchlistFileStamp{size: info.Size(), modTime: info.ModTime()}
+		if stamps[path] != s.stamps[path] {
+			changed = true
+		}
+	}
+	if !changed {
+		return s.current, nil
+	}
+
+	watchlist, err := LoadWatchlist(s.paths...)
+	if err != nil {
+		return nil, err
+	}
+	s.current = watchlist
+	s.stamps = stamps
+	return watchlist, nil
+}
+
+// WatchlistMatch is an entry matching a screened name. Score ranges from 0
+// to 1, 1 being an exact match on the name or one of the aliases.
+type WatchlistMatch struct {
+	Entry WatchlistEntry
+	Score float64
+}
+
+// Match returns the entries matching name with a score of at least
+// threshold, best matches first.
+func (w *Watchlist) Match(name string, threshold float64) []WatchlistMatch {
+	tokens := nameTokens(name)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	matches := make([]WatchlistMatch, 0)
+	for _, entry := range w.Entries {
+		best := 0.0
+		for _, candidate := range append([]string{entry.Name}, entry.Aliases...) {
+			if score := nameSimilarity(tokens, nameTokens(candidate)); score > best {
+				best = score
+			}
+		}
+		if best >= threshold {
+			matches = append(matches, WatchlistMatch{Entry: entry, Score: best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
+
+var nameFolding = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "æ", "ae",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "œ", "oe",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ý", "y", "ÿ", "y", "ß", "ss",
+)
+
+// nameTokens lowercases the name, folds the common accented letters and
+// splits it on anything that is not a letter or a digit.
+func nameTokens(name string) []string {
+	folded := nameFolding.Replace(strings.ToLower(name))
+	return strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// nameSimilarity compares two tokenized names regardless of the order of
+// their tokens: every token is paired with its closest counterpart on the
+// other side and the Jaro-Winkler similarities are averaged over both names,
+// so that an extra or missing name part lowers the score.
+func nameSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, side := range [][2][]string{{a, b}, {b, a}} {
+		for _, token := range side[0] {
+			best := 0.0
+			for _, other := range side[1] {
+				if score := jaroWinkler(token, other); score > best {
+					best = score
+				}
+			}
+			total += best
+		}
+	}
+	return total / float64(len(a)+len(b))
+}
+
+func jaroWinkler(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	window = max(window, 0)
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		for j := max(0, i-window); j < min(len(rb), i+window+1); j++ {
+			if matchedB[j] || ra[i] != rb[j] {
+				continue
+			}
+			matchedA[i], matchedB[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
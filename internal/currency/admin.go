@@ -0,0 +1,315 @@
+package currency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+)
+
+var (
+	ErrCurrencyValidation = errors.New("currency validation failed")
+	ErrCurrencyNotFound   = errors.New("currency not found")
+	ErrCurrencyExists     = errors.New("currency already exists")
+)
+
+const (
+	AuditActionCreated = "created"
+	AuditActionUpdated = "updated"
+
+	// MaxPrecision keeps one whole unit representable in an int64 amount.
+	MaxPrecision = 18
+
+	// ChangesChannel is the postgres notification channel on which the code
+	// of each created or updated currency is sent.
+	ChangesChannel = "currency_changes"
+)
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9]{2,16}$`)
+
+type AuditRecord struct {
+	bun.BaseModel `bun:"_system.currency_audits,alias:currency_audits"`
+
+	ID        uuid.UUID      `json:"id" bun:"id,type:uuid,pk"`
+	Code      string         `json:"code" bun:"code,type:varchar(16),notnull"`
+	Actor     *string        `json:"actor,omitempty" bun:"actor,type:varchar(255)"`
+	Action    string         `json:"action" bun:"action,type:varchar(32),notnull"`
+	Before    map[string]any `json:"before,omitempty" bun:"before,type:jsonb"`
+	After     map[string]any `json:"after,omitempty" bun:"after,type:jsonb"`
+	CreatedAt time.Time      `json:"createdAt" bun:"created_at,type:timestamp without time zone,notnull"`
+}
+
+type CreateInput struct {
+	Code      string
+	Precision int
+	// Enabled defaults to true.
+	Enabled *bool
+	Actor   *string
+}
+
+type UpdateInput struct {
+	// Changing the precision changes the asset new amounts are posted in.
+	// It is refused while balances are held in the current asset: they are
+	// moved first with the currency rebase endpoint of each ledger.
+	Precision *int
+	Enabled   *bool
+	Actor     *string
+}
+
+type AuditFilter struct {
+	Code   *string
+	Limit  int
+	Offset int
+}
+
+// AdminService manages the rows of _system.currencies. Every write reloads
+// the registry of the current instance and notifies the other ones on
+// ChangesChannel when it commits.
+type AdminService interface {
+	Create(context.Context, CreateInput) (*Record, error)
+	Update(context.Context, string, UpdateInput) (*Record, error)
+	Get(context.Context, string) (*Record, error)
+	List(context.Context) ([]Record, error)
+	ListAudits(context.Context, AuditFilter) ([]AuditRecord, error)
+}
+
+// BalanceChecker lists the ledgers whose accounts still hold a balance in an
+// asset, leftovers of a rebase aside.
+type BalanceChecker interface {
+	LedgersHolding(ctx context.Context, asset string) ([]string, error)
+}
+
+type DefaultAdminService struct {
+	db       bun.IDB
+	balances BalanceChecker
+}
+
+// NewAdminService returns an AdminService. Without a BalanceChecker, the
+// precision of a currency can be changed whatever the balances.
+func NewAdminService(db bun.IDB, balances BalanceChecker) *DefaultAdminService {
+	return &DefaultAdminService{
+		db:       db,
+		balances: balances,
+	}
+}
+
+func (s *DefaultAdminService) Create(ctx context.Context, input CreateInput) (*Record, error) {
+	code := normalizeCode(input.Code)
+	if !codePattern.MatchString(code) {
+		return nil, fmt.Errorf("%w: code must be 2 to 16 letters or digits", ErrCurrencyValidation)
+	}
+	if err := validatePrecision(input.Precision); err != nil {
+		return nil, err
+	}
+	enabled := true
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+
+	now := time.Now().UTC()
+	record := &Record{
+		Code:      code,
+		Precision: input.Precision,
+		Enabled:   enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := s.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewInsert().
+			Model(record).
+			On("conflict (code) do nothing").
+			Exec(ctx)
+		if err != nil {
+			return postgres.ResolveError(err)
+		}
+		if rows, _ := res.RowsAffected(); rows == 0 {
+			return fmt.Errorf("%w: %s", ErrCurrencyExists, code)
+		}
+		if err := insertAudit(ctx, tx, code, AuditActionCreated, input.Actor, nil, auditState(*record)); err != nil {
+			return err
+		}
+		return notifyChange(ctx, tx, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return record, s.reload(ctx)
+}
+
+func (s *DefaultAdminService) Update(ctx context.Context, code string, input UpdateInput) (*Record, error) {
+	code = normalizeCode(code)
+	if input.Precision == nil && input.Enabled == nil {
+		return nil, fmt.Errorf("%w: nothing to update", ErrCurrencyValidation)
+	}
+	if input.Precision != nil {
+		if err := validatePrecision(*input.Precision); err != nil {
+			return nil, err
+		}
+	}
+
+	record := &Record{}
+	err := s.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		if err := tx.NewSelect().
+			Model(record).
+			Where("code = ?", code).
+			For("update").
+			Scan(ctx); err != nil {
+			return resolveCurrencyError(err, code)
+		}
+
+		before := auditState(*record)
+		if input.Precision != nil && *input.Precision != record.Precision {
+			if err := s.checkNoBalances(ctx, code, record.Precision, *input.Precision); err != nil {
+				return err
+			}
+			record.Precision = *input.Precision
+		}
+		if input.Enabled != nil {
+			record.Enabled = *input.Enabled
+		}
+		after := auditState(*record)
+		if before["precision"] == after["precision"] && before["enabled"] == after["enabled"] {
+			return nil
+		}
+
+		record.UpdatedAt = time.Now().UTC()
+		if _, err := tx.NewUpdate().
+			Model(record).
+			Column("precision", "enabled", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return postgres.ResolveError(err)
+		}
+		if err := insertAudit(ctx, tx, code, AuditActionUpdated, input.Actor, before, after); err != nil {
+			return err
+		}
+		return notifyChange(ctx, tx, code)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return record, s.reload(ctx)
+}
+
+func (s *DefaultAdminService) Get(ctx context.Context, code string) (*Record, error) {
+	code = normalizeCode(code)
+	record := &Record{}
+	if err := s.db.NewSelect().
+		Model(record).
+		Where("code = ?", code).
+		Scan(ctx); err != nil {
+		return nil, resolveCurrencyError(err, code)
+	}
+	return record, nil
+}
+
+func (s *DefaultAdminService) List(ctx context.Context) ([]Record, error) {
+	records := make([]Record, 0)
+	if err := s.db.NewSelect().
+		Model(&records).
+		OrderExpr("code asc").
+		Scan(ctx); err != nil {
+		return nil, postgres.ResolveError(err)
+	}
+	return records, nil
+}
+
+func (s *DefaultAdminService) ListAudits(ctx context.Context, filter AuditFilter) ([]AuditRecord, error) {
+	audits := make([]AuditRecord, 0)
+	query := s.db.NewSelect().
+		Model(&audits).
+		OrderExpr("created_at desc")
+	if filter.Code != nil {
+		query = query.Where("code = ?", normalizeCode(*filter.Code))
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, postgres.ResolveError(err)
+	}
+	return audits, nil
+}
+
+// checkNoBalances refuses to switch the asset of a currency while balances
+// are held in the current one, which would be left behind.
+func (s *DefaultAdminService) checkNoBalances(ctx context.Context, code string, from, to int) error {
+	if s.balances == nil {
+		return nil
+	}
+	asset := AssetWithPrecision(code, from)
+	ledgers, err := s.balances.LedgersHolding(ctx, asset)
+	if err != nil {
+		return fmt.Errorf("checking %s balances: %w", asset, err)
+	}
+	if len(ledgers) > 0 {
+		return fmt.Errorf("%w: balances are still held in %s in ledgers %s, rebase them to precision %d first",
+			ErrCurrencyValidation, asset, strings.Join(ledgers, ", "), to)
+	}
+	return nil
+}
+
+// notifyChange tells the other instances, once tx commits, that the currency
+// changed.
+func notifyChange(ctx context.Context, tx bun.Tx, code string) error {
+	if _, err := tx.ExecContext(ctx, "select pg_notify(?, ?)", ChangesChannel, code); err != nil {
+		return postgres.ResolveError(err)
+	}
+	return nil
+}
+
+func (s *DefaultAdminService) reload(ctx context.Context) error {
+	if err := Load(ctx, s.db); err != nil {
+		return fmt.Errorf("reloading currency registry: %w", err)
+	}
+	return nil
+}
+
+func insertAudit(ctx context.Context, db bun.IDB, code, action string, actor *string, before, after map[string]any) error {
+	_, err := db.NewInsert().
+		Model(&AuditRecord{
+			ID:        uuid.New(),
+			Code:      code,
+			Actor:     actor,
+			Action:    action,
+			Before:    before,
+			After:     after,
+			CreatedAt: time.Now().UTC(),
+		}).
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func auditState(record Record) map[string]any {
+	return map[string]any{
+		"precision": record.Precision,
+		"enabled":   record.Enabled,
+	}
+}
+
+func validatePrecision(precision int) error {
+	if precision < 0 || precision > MaxPrecision {
+		return fmt.Errorf("%w: precision must be between 0 and %d", ErrCurrencyValidation, MaxPrecision)
+	}
+	return nil
+}
+
+func resolveCurrencyError(err error, code string) error {
+	if errors.Is(err, sql.ErrNoRows) || postgres.IsNotFoundError(postgres.ResolveError(err)) {
+		return fmt.Errorf("%w: %s", ErrCurrencyNotFound, code)
+	}
+	return postgres.ResolveError(err)
+}
@@ -0,0 +1,106 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+const (
+	AmountReasonMalformed  = "malformed"
+	AmountReasonNegative   = "negative"
+	AmountReasonTooPrecise = "too_precise"
+	AmountReasonOverflow   = "overflow"
+)
+
+// AmountError describes why an amount could not be converted to the atomic
+// units of a currency. It matches ErrInvalidAmount with errors.Is.
+type AmountError struct {
+	Value     string `json:"value"`
+	Currency  string `json:"currency"`
+	Precision int    `json:"precision"`
+	Reason    string `json:"reason"`
+}
+
+func (e *AmountError) Error() string {
+	switch e.Reason {
+	case AmountReasonNegative:
+		return fmt.Sprintf("amount %s must not be negative", e.Value)
+	case AmountReasonTooPrecise:
+		return fmt.Sprintf("amount %s has more than %d decimals allowed by %s", e.Value, e.Precision, e.Currency)
+	case AmountReasonOverflow:
+		return fmt.Sprintf("amount %s overflows %s atomic units", e.Value, e.Currency)
+	default:
+		return fmt.Sprintf("amount %q is not a decimal number", e.Value)
+	}
+}
+
+func (e *AmountError) Unwrap() error {
+	return ErrInvalidAmount
+}
+
+// ParseAmount converts value to atomic units of code without going through
+// floating point. Integers are taken as atomic units already; decimals are
+// major units, scaled by the registered precision of the currency. An empty
+// value parses as zero.
+func ParseAmount(value, code string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	precision := Precision(code)
+	newError := func(reason string) error {
+		return &AmountError{
+			Value:     value,
+			Currency:  normalizeCode(code),
+			Precision: precision,
+			Reason:    reason,
+		}
+	}
+
+	digits := value
+	if strings.HasPrefix(digits, "-") {
+		if !isDigits(strings.Replace(digits[1:], ".", "", 1)) {
+			return 0, newError(AmountReasonMalformed)
+		}
+		return 0, newError(AmountReasonNegative)
+	}
+
+	integer, fraction, isDecimal := strings.Cut(digits, ".")
+	if !isDigits(integer) || (isDecimal && !isDigits(fraction)) {
+		return 0, newError(AmountReasonMalformed)
+	}
+	if isDecimal {
+		// Trailing zeros carry no value: 10.50 is a valid USD amount.
+		fraction = strings.TrimRight(fraction, "0")
+		if len(fraction) > precision {
+			return 0, newError(AmountReasonTooPrecise)
+		}
+		digits = integer + fraction + strings.Repeat("0", precision-len(fraction))
+	}
+
+	ret, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return 0, newError(AmountReasonMalformed)
+	}
+	if !ret.IsInt64() {
+		return 0, newError(AmountReasonOverflow)
+	}
+	return ret.Int64(), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package currency
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAmount(t *testing.T) {
+	SetDefinitions(map[string]Definition{
+		"JPY": {Precision: 0, Enabled: true},
+		"KWD": {Precision: 3, Enabled: true},
+		"USD": {Precision: 2, Enabled: true},
+	})
+
+	type testCase struct {
+		name     string
+		value    string
+		currency string
+		expected int64
+		reason   string
+	}
+
+	testCases := []testCase{
+		{name: "empty", value: "", currency: "USD", expected: 0},
+		{name: "atomic units", value: "1050", currency: "USD", expected: 1050},
+		{name: "decimal", value: "10.5", currency: "USD", expected: 1050},
+		{name: "trailing zeros", value: "10.500", currency: "USD", expected: 1050},
+		{name: "three decimals", value: "1.234", currency: "KWD", expected: 1234},
+		{name: "zero precision", value: "100.0", currency: "JPY", expected: 100},
+		{name: "unknown currency uses default precision", value: "1.01", currency: "XYZ", expected: 101},
+		{name: "beyond float64 precision", value: "92233720368547758.07", currency: "USD", expected: 9223372036854775807},
+		{name: "too precise", value: "10.555", currency: "USD", reason: AmountReasonTooPrecise},
+		{name: "too precise for zero precision", value: "1.5", currency: "JPY", reason: AmountReasonTooPrecise},
+		{name: "negative", value: "-100", currency: "USD", reason: AmountReasonNegative},
+		{name: "negative decimal", value: "-1.00", currency: "USD", reason: AmountReasonNegative},
+		{name: "overflow", value: "92233720368547758.08", currency: "USD", reason: AmountReasonOverflow},
+		{name: "exponent", value: "1e3", currency: "USD", reason: AmountReasonMalformed},
+		{name: "missing integer part", value: ".5", currency: "USD", reason: AmountReasonMalformed},
+		{name: "garbage", value: "12abc", currency: "USD", reason: AmountReasonMalformed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ret, err := ParseAmount(tc.value, tc.currency)
+			if tc.reason == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, ret)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidAmount)
+			amountErr := &AmountError{}
+			require.True(t, errors.As(err, &amountErr))
+			require.Equal(t, tc.reason, amountErr.Reason)
+		})
+	}
+}
@@ -2,9 +2,17 @@ package currency
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/uptrace/bun"
 	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
 )
 
 type Loader struct {
@@ -19,13 +27,200 @@ func (l *Loader) Load(ctx context.Context) error {
 	return Load(ctx, l.db)
 }
 
-func NewFXModule() fx.Option {
-	return fx.Options(
+// Refresher reloads the registry periodically, so that currencies changed
+// through another instance are picked up without a restart.
+type Refresher struct {
+	stopChannel chan chan struct{}
+	logger      logging.Logger
+	loader      *Loader
+	interval    time.Duration
+}
+
+func NewRefresher(logger logging.Logger, loader *Loader, interval time.Duration) *Refresher {
+	return &Refresher{
+		stopChannel: make(chan chan struct{}),
+		logger:      logger,
+		loader:      loader,
+		interval:    interval,
+	}
+}
+
+func (r *Refresher) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.loader.Load(ctx); err != nil {
+				r.logger.Errorf("error refreshing currency registry: %v", err)
+			}
+		case ch := <-r.stopChannel:
+			close(ch)
+			return
+		}
+	}
+}
+
+func (r *Refresher) Stop(ctx context.Context) error {
+	ch := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case r.stopChannel <- ch:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+	return nil
+}
+
+// listenerRetryDelay is the delay before listening again after the
+// connection of a Listener was lost.
+const listenerRetryDelay = 5 * time.Second
+
+// Listener reloads the registry as soon as a currency is changed through
+// another instance, so that amounts are not posted in the asset of a former
+// precision until the next refresh.
+type Listener struct {
+	logger logging.Logger
+	db     *bun.DB
+	loader *Loader
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func NewListener(logger logging.Logger, db *bun.DB, loader *Loader) *Listener {
+	return &Listener{
+		logger: logger,
+		db:     db,
+		loader: loader,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (l *Listener) Run(ctx context.Context) {
+	defer close(l.done)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-l.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		l.logger.Errorf("error listening to currency changes: %v", err)
+
+		select {
+		case <-time.After(listenerRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	return conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unexpected driver connection %T", driverConn)
+		}
+		pgxConn := stdlibConn.Conn()
+		if _, err := pgxConn.Exec(ctx, "listen "+pgx.Identifier{ChangesChannel}.Sanitize()); err != nil {
+			return err
+		}
+
+		// Changes made before listening are picked up first.
+		if err := l.loader.Load(ctx); err != nil {
+			return errors.Join(err, driver.ErrBadConn)
+		}
+		for {
+			if _, err := pgxConn.WaitForNotification(ctx); err != nil {
+				// The connection still listens: it is closed rather than
+				// returned to the pool.
+				return errors.Join(err, driver.ErrBadConn)
+			}
+			if err := l.loader.Load(ctx); err != nil {
+				l.logger.Errorf("error reloading currency registry: %v", err)
+			}
+		}
+	})
+}
+
+func (l *Listener) Stop(ctx context.Context) error {
+	close(l.stop)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.done:
+	}
+	return nil
+}
+
+type ModuleConfig struct {
+	// RefreshInterval is the delay between two reloads of the registry, a
+	// fallback for the changes a Listener may have missed. Zero disables
+	// the refresh.
+	RefreshInterval time.Duration
+}
+
+func NewFXModule(cfg ModuleConfig) fx.Option {
+	options := []fx.Option{
 		fx.Provide(NewLoader),
+		fx.Provide(func(params struct {
+			fx.In
+
+			DB       *bun.DB
+			Balances BalanceChecker `optional:"true"`
+		}) AdminService {
+			return NewAdminService(params.DB, params.Balances)
+		}),
 		fx.Invoke(func(lc fx.Lifecycle, loader *Loader) {
 			lc.Append(fx.Hook{
 				OnStart: loader.Load,
 			})
 		}),
-	)
+		fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, db *bun.DB, loader *Loader) {
+			listener := NewListener(logger, db, loader)
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go listener.Run(context.WithoutCancel(ctx))
+					return nil
+				},
+				OnStop: listener.Stop,
+			})
+		}),
+	}
+	if cfg.RefreshInterval > 0 {
+		options = append(options, fx.Invoke(func(lc fx.Lifecycle, logger logging.Logger, loader *Loader) {
+			refresher := NewRefresher(logger, loader, cfg.RefreshInterval)
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go refresher.Run(context.WithoutCancel(ctx))
+					return nil
+				},
+				OnStop: refresher.Stop,
+			})
+		}))
+	}
+	return fx.Options(options...)
 }
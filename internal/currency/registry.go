@@ -2,6 +2,7 @@ package currency
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"strings"
 	"sync"
@@ -10,6 +11,9 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// DefaultPrecision is assumed for currencies missing from the registry.
+const DefaultPrecision = 2
+
 type Definition struct {
 	Precision int
 	Enabled   bool
@@ -24,11 +28,11 @@ type Currency struct {
 type Record struct {
 	bun.BaseModel `bun:"_system.currencies,alias:currencies"`
 
-	Code      string    `bun:"code,type:varchar(16),pk"`
-	Precision int       `bun:"precision,type:int,notnull"`
-	Enabled   bool      `bun:"enabled,type:boolean,notnull"`
-	CreatedAt time.Time `bun:"created_at,type:timestamp without time zone,nullzero"`
-	UpdatedAt time.Time `bun:"updated_at,type:timestamp without time zone,nullzero"`
+	Code      string    `json:"code" bun:"code,type:varchar(16),pk"`
+	Precision int       `json:"precision" bun:"precision,type:int,notnull"`
+	Enabled   bool      `json:"enabled" bun:"enabled,type:boolean,notnull"`
+	CreatedAt time.Time `json:"createdAt" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt time.Time `json:"updatedAt" bun:"updated_at,type:timestamp without time zone,nullzero"`
 }
 
 var (
@@ -83,6 +87,26 @@ func Lookup(code string) (Definition, bool) {
 	return definition, ok
 }
 
+// Precision returns the registered precision of code, or DefaultPrecision
+// when the currency is unknown.
+func Precision(code string) int {
+	definition, ok := Lookup(code)
+	if !ok {
+		return DefaultPrecision
+	}
+	return definition.Precision
+}
+
+// Asset returns the ledger asset holding amounts of code in its registered
+// precision, e.g. USD/2 or JPY/0.
+func Asset(code string) string {
+	return AssetWithPrecision(code, Precision(code))
+}
+
+func AssetWithPrecision(code string, precision int) string {
+	return fmt.Sprintf("%s/%d", normalizeCode(code), precision)
+}
+
 func EnabledCodes() []string {
 	registryMu.RLock()
 	defer registryMu.RUnlock()
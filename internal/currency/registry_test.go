@@ -0,0 +1,21 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsset(t *testing.T) {
+	SetDefinitions(map[string]Definition{
+		"JPY": {Precision: 0, Enabled: true},
+		"KWD": {Precision: 3, Enabled: true},
+		"USD": {Precision: 2, Enabled: true},
+	})
+
+	require.Equal(t, "JPY/0", Asset("JPY"))
+	require.Equal(t, "KWD/3", Asset("kwd"))
+	require.Equal(t, "USD/2", Asset("USD"))
+	require.Equal(t, "XYZ/2", Asset("XYZ"))
+	require.Equal(t, 3, Precision("KWD"))
+}
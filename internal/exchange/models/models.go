@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	RateAuditActionPublished  = "published"
+	RateAuditActionSuperseded = "superseded"
+	RateAuditActionRevoked    = "revoked"
+)
+
+// Rate is the mid-market price of one unit of BaseCurrency in QuoteCurrency,
+// valid from ValidFrom until ValidUntil (open-ended when nil). SpreadBps is
+// taken from the mid rate when converting for a customer.
+type Rate struct {
+	bun.BaseModel `bun:"_system.fx_rates,alias:fx_rates"`
+
+	ID            uuid.UUID  `json:"id" bun:"id,type:uuid,pk"`
+	BaseCurrency  string     `json:"base_currency" bun:"base_currency,type:varchar(16),notnull"`
+	QuoteCurrency string     `json:"quote_currency" bun:"quote_currency,type:varchar(16),notnull"`
+	Rate          string     `json:"rate" bun:"rate,type:numeric,notnull"`
+	SpreadBps     int        `json:"spread_bps" bun:"spread_bps,type:integer,notnull"`
+	Source        string     `json:"source" bun:"source,type:varchar(64),notnull"`
+	ValidFrom     time.Time  `json:"valid_from" bun:"valid_from,type:timestamp without time zone,notnull"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty" bun:"valid_until,type:timestamp without time zone,nullzero"`
+	CreatedAt     time.Time  `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+}
+
+// Pair is the rate pair as used in FX position account names, e.g. USD_EUR.
+func (r Rate) Pair() string {
+	return r.BaseCurrency + "_" + r.QuoteCurrency
+}
+
+type RateAudit struct {
+	bun.BaseModel `bun:"_system.fx_rate_audits,alias:fx_rate_audits"`
+
+	ID            uuid.UUID      `json:"id" bun:"id,type:uuid,pk"`
+	RateID        uuid.UUID      `json:"rate_id" bun:"rate_id,type:uuid,notnull"`
+	BaseCurrency  string         `json:"base_currency" bun:"base_currency,type:varchar(16),notnull"`
+	QuoteCurrency string         `json:"quote_currency" bun:"quote_currency,type:varchar(16),notnull"`
+	Actor         *string        `json:"actor,omitempty" bun:"actor,type:varchar(255),nullzero"`
+	Action        string         `json:"action" bun:"action,type:varchar(32),notnull"`
+	Before        map[string]any `json:"before,omitempty" bun:"before,type:jsonb,nullzero"`
+	After         map[string]any `json:"after,omitempty" bun:"after,type:jsonb,nullzero"`
+	CreatedAt     time.Time      `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+}
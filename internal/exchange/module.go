@@ -0,0 +1,53 @@
+package exchange
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+	"go.uber.org/fx"
+
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	"github.com/formancehq/ledger/internal/exchange/repositories"
+	"github.com/formancehq/ledger/internal/exchange/services"
+)
+
+type ModuleConfig struct {
+	// RatesFile, when set, is imported on startup through a FileRateProvider.
+	RatesFile string
+}
+
+func NewFXModule(cfg ModuleConfig) fx.Option {
+	options := []fx.Option{
+		fx.Provide(
+			func(db *bun.DB) repositories.RateRepository {
+				return repositories.NewRateRepository(db)
+			},
+			func(db *bun.DB) repositories.RateAuditRepository {
+				return repositories.NewRateAuditRepository(db)
+			},
+			func(
+				rateRepo repositories.RateRepository,
+				auditRepo repositories.RateAuditRepository,
+			) services.RateService {
+				return services.NewRateService(rateRepo, auditRepo)
+			},
+			func(
+				system systemcontroller.Controller,
+				rateService services.RateService,
+			) services.ConversionService {
+				return services.NewConversionService(system, rateService)
+			},
+		),
+	}
+	if cfg.RatesFile != "" {
+		options = append(options, fx.Invoke(func(lc fx.Lifecycle, rateService services.RateService) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					_, err := rateService.Import(ctx, services.NewFileRateProvider(cfg.RatesFile), nil)
+					return err
+				},
+			})
+		}))
+	}
+	return fx.Options(options...)
+}
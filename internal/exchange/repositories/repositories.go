@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/exchange/models"
+)
+
+type RateFilter struct {
+	BaseCurrency  *string
+	QuoteCurrency *string
+	// EffectiveAt keeps only the rates whose validity window contains it.
+	EffectiveAt *time.Time
+	Limit       int
+	Offset      int
+}
+
+type RateAuditFilter struct {
+	RateID        *uuid.UUID
+	BaseCurrency  *string
+	QuoteCurrency *string
+	Limit         int
+	Offset        int
+}
+
+type RateRepository interface {
+	Create(context.Context, *models.Rate) error
+	UpdateValidity(context.Context, *models.Rate) error
+	Get(context.Context, uuid.UUID) (*models.Rate, error)
+	GetEffective(context.Context, string, string, time.Time) (*models.Rate, error)
+	List(context.Context, RateFilter) ([]models.Rate, error)
+}
+
+type RateAuditRepository interface {
+	Create(context.Context, *models.RateAudit) error
+	List(context.Context, RateAuditFilter) ([]models.RateAudit, error)
+}
+
+type BunRateRepository struct {
+	db bun.IDB
+}
+
+func NewRateRepository(db bun.IDB) *BunRateRepository {
+	return &BunRateRepository{db: db}
+}
+
+func (r *BunRateRepository) Create(ctx context.Context, rate *models.Rate) error {
+	setUUID(&rate.ID)
+	if rate.CreatedAt.IsZero() {
+		rate.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.NewInsert().Model(rate).Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+// UpdateValidity only writes the end of the validity window: rates are
+// otherwise immutable, a new price is published as a new rate.
+func (r *BunRateRepository) UpdateValidity(ctx context.Context, rate *models.Rate) error {
+	_, err := r.db.NewUpdate().
+		Model(rate).
+		Column("valid_until").
+		WherePK().
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunRateRepository) Get(ctx context.Context, id uuid.UUID) (*models.Rate, error) {
+	rate := &models.Rate{}
+	err := r.db.NewSelect().
+		Model(rate).
+		Where("id = ?", id).
+		Scan(ctx)
+	return rate, postgres.ResolveError(err)
+}
+
+func (r *BunRateRepository) GetEffective(ctx context.Context, base, quote string, at time.Time) (*models.Rate, error) {
+	rate := &models.Rate{}
+	err := r.db.NewSelect().
+		Model(rate).
+		Where("base_currency = ?", base).
+		Where("quote_currency = ?", quote).
+		Where("valid_from <= ?", at).
+		Where("(valid_until is null or valid_until > ?)", at).
+		OrderExpr("valid_from desc, created_at desc").
+		Limit(1).
+		Scan(ctx)
+	return rate, postgres.ResolveError(err)
+}
+
+func (r *BunRateRepository) List(ctx context.Context, filter RateFilter) ([]models.Rate, error) {
+	rates := make([]models.Rate, 0)
+	q := r.db.NewSelect().Model(&rates)
+	if filter.BaseCurrency != nil {
+		q = q.Where("base_currency = ?", *filter.BaseCurrency)
+	}
+	if filter.QuoteCurrency != nil {
+		q = q.Where("quote_currency = ?", *filter.QuoteCurrency)
+	}
+	if filter.EffectiveAt != nil {
+		q = q.Where("valid_from <= ?", *filter.EffectiveAt).
+			Where("(valid_until is null or valid_until > ?)", *filter.EffectiveAt)
+	}
+	q = q.OrderExpr("valid_from desc, created_at desc")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	err := q.Scan(ctx)
+	return rates, postgres.ResolveError(err)
+}
+
+type BunRateAuditRepository struct {
+	db bun.IDB
+}
+
+func NewRateAuditRepository(db bun.IDB) *BunRateAuditRepository {
+	return &BunRateAuditRepository{db: db}
+}
+
+func (r *BunRateAuditRepository) Create(ctx context.Context, audit *models.RateAudit) error {
+	setUUID(&audit.ID)
+	if audit.CreatedAt.IsZero() {
+		audit.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.NewInsert().Model(audit).Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunRateAuditRepository) List(ctx context.Context, filter RateAuditFilter) ([]models.RateAudit, error) {
+	audits := make([]models.RateAudit, 0)
+	q := r.db.NewSelect().Model(&audits)
+	if filter.RateID != nil {
+		q = q.Where("rate_id = ?", *filter.RateID)
+	}
+	if filter.BaseCurrency != nil {
+		q = q.Where("base_currency = ?", *filter.BaseCurrency)
+	}
+	if filter.QuoteCurrency != nil {
+		q = q.Where("quote_currency = ?", *filter.QuoteCurrency)
+	}
+	q = q.OrderExpr("created_at desc")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	err := q.Scan(ctx)
+	return audits, postgres.ResolveError(err)
+}
+
+func setUUID(id *uuid.UUID) {
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/formancehq/go-libs/v3/metadata"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	"github.com/formancehq/go-libs/v3/query"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/machine/vm"
+	storagecommon "github.com/formancehq/ledger/internal/storage/common"
+)
+
+var ErrConversionValidation = errors.New("fx conversion validation failed")
+
+// RealizedPnLAccount receives, in the revenue ledger of the quote currency,
+// the spread earned on each conversion.
+const RealizedPnLAccount = "revenue:fx_gain"
+
+type ConversionService interface {
+	Quote(context.Context, QuoteInput) (*Quote, error)
+	Convert(context.Context, ConvertInput) (*ConversionResult, error)
+}
+
+type QuoteInput struct {
+	From   string
+	To     string
+	Amount int64
+	// Rate overrides the stored rate of the pair. No spread is applied to
+	// an overridden rate, so such conversions book no P&L.
+	Rate string
+	// At selects the stored rate effective at that time, now by default.
+	At *time.Time
+}
+
+// Quote describes a conversion of Amount atomic units of From. Amounts are
+// rounded down, so that the customer is never credited more than the rate
+// allows.
+type Quote struct {
+	From            string     `json:"from"`
+	To              string     `json:"to"`
+	Amount          int64      `json:"amount"`
+	RateID          *uuid.UUID `json:"rate_id,omitempty"`
+	Rate            string     `json:"rate"`
+	SpreadBps       int        `json:"spread_bps"`
+	CustomerRate    string     `json:"customer_rate"`
+	ConvertedAmount int64      `json:"converted_amount"`
+	MidAmount       int64      `json:"mid_amount"`
+	PnLAmount       int64      `json:"pnl_amount"`
+	PositionAccount string     `json:"position_account"`
+}
+
+type ConvertInput struct {
+	QuoteInput
+	Ledger             string
+	SourceAccount      string
+	DestinationAccount string
+	Reference          string
+	// IdempotencyKey defaults to one derived from the reference, so that a
+	// retried conversion replays both the conversion and its P&L posting.
+	IdempotencyKey string
+	Metadata       map[string]string
+}
+
+type ConversionResult struct {
+	Quote          *Quote                      `json:"quote"`
+	Transaction    *ledgerinternal.Transaction `json:"transaction"`
+	PnLLedger      string                      `json:"pnl_ledger,omitempty"`
+	PnLTransaction *ledgerinternal.Transaction `json:"pnl_transaction,omitempty"`
+}
+
+type DefaultConversionService struct {
+	system      systemcontroller.Controller
+	rateService RateService
+}
+
+// NewConversionService builds a conversion service. rateService may be nil,
+// in which case every conversion must carry its own rate.
+func NewConversionService(system systemcontroller.Controller, rateService RateService) *DefaultConversionService {
+	return &DefaultConversionService{
+		system:      system,
+		rateService: rateService,
+	}
+}
+
+func (s *DefaultConversionService) Quote(ctx context.Context, input QuoteInput) (*Quote, error) {
+	from := strings.ToUpper(strings.TrimSpace(input.From))
+	to := strings.ToUpper(strings.TrimSpace(input.To))
+	if from == "" || to == "" || from == to {
+		return nil, fmt.Errorf("%w: two different currencies are required", ErrConversionValidation)
+	}
+	if input.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrConversionValidation)
+	}
+
+	quote := &Quote{
+		From:            from,
+		To:              to,
+		Amount:          input.Amount,
+		PositionAccount: PositionAccount(from, to),
+	}
+
+	var mid decimal.Decimal
+	if input.Rate != "" {
+		var err error
+		mid, err = decimal.NewFromString(strings.TrimSpace(input.Rate))
+		if err != nil || !mid.IsPositive() {
+			return nil, fmt.Errorf("%w: rate must be a positive decimal", ErrConversionValidation)
+		}
+	} else {
+		if s.rateService == nil {
+			return nil, fmt.Errorf("%w: a rate is required between %s and %s", ErrConversionValidation, from, to)
+		}
+		at := time.Now()
+		if input.At != nil {
+			at = *input.At
+		}
+		rate, err := s.rateService.GetEffective(ctx, from, to, at)
+		if err != nil {
+			return nil, err
+		}
+		mid = decimal.RequireFromString(rate.Rate)
+		quote.RateID = &rate.ID
+		quote.SpreadBps = rate.SpreadBps
+	}
+
+	// Spreads are in basis points: shifting by -4 divides exactly by 10000.
+	customer := mid.Mul(decimal.New(int64(maxSpreadBps-quote.SpreadBps), 0)).Shift(-4)
+
+	return price(quote, mid, customer)
+}
+
+// price converts the amount of quote at the mid and customer rates.
+func price(quote *Quote, mid, customer decimal.Decimal) (*Quote, error) {
+	quote.Rate = mid.String()
+	quote.CustomerRate = customer.String()
+
+	var err error
+	if quote.MidAmount, err = convertAmount(quote.Amount, quote.From, quote.To, mid); err != nil {
+		return nil, err
+	}
+	if quote.ConvertedAmount, err = convertAmount(quote.Amount, quote.From, quote.To, customer); err != nil {
+		return nil, err
+	}
+	if quote.ConvertedAmount <= 0 {
+		return nil, fmt.Errorf("%w: %d %s converts to nothing", ErrConversionValidation, quote.Amount, quote.From)
+	}
+	quote.PnLAmount = quote.MidAmount - quote.ConvertedAmount
+
+	return quote, nil
+}
+
+// Convert moves Amount from the source account to the FX position account
+// of the pair and the converted amount from the position account to the
+// destination account, in one transaction. The position account therefore
+// holds the open position of the pair: long From, short To. The spread is
+// then reported as realized P&L in the revenue ledger of To.
+func (s *DefaultConversionService) Convert(ctx context.Context, input ConvertInput) (*ConversionResult, error) {
+	if input.SourceAccount == "" || input.DestinationAccount == "" {
+		return nil, fmt.Errorf("%w: source and destination accounts are required", ErrConversionValidation)
+	}
+	if strings.TrimSpace(input.Reference) == "" {
+		return nil, fmt.Errorf("%w: reference is required", ErrConversionValidation)
+	}
+
+	quote, err := s.previousQuote(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if quote == nil {
+		quote, err = s.Quote(ctx, input.QuoteInput)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	idempotencyKey := input.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = fmt.Sprintf("fx-conversion:%s:%s", input.Ledger, input.Reference)
+	}
+
+	txMetadata := metadata.Metadata{}
+	for k, v := range input.Metadata {
+		txMetadata[k] = v
+	}
+	txMetadata["fx_rate"] = quote.Rate
+	txMetadata["fx_customer_rate"] = quote.CustomerRate
+	txMetadata["fx_account"] = quote.PositionAccount
+	if quote.RateID != nil {
+		txMetadata["fx_rate_id"] = quote.RateID.String()
+	}
+
+	tx, err := s.post(ctx, input.Ledger, idempotencyKey, input.Reference, txMetadata, fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+		send [%s %d] (
+			source = @%s allowing unbounded overdraft
+			destination = @%s
+		)
+	`, currencyregistry.Asset(quote.From), quote.Amount, input.SourceAccount, quote.PositionAccount,
+		currencyregistry.Asset(quote.To), quote.ConvertedAmount, quote.PositionAccount, input.DestinationAccount))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConversionResult{
+		Quote:       quote,
+		Transaction: tx,
+	}
+	if quote.PnLAmount <= 0 {
+		return result, nil
+	}
+
+	result.PnLLedger = fmt.Sprintf("revenue-%s", quote.To)
+	result.PnLTransaction, err = s.post(ctx, result.PnLLedger, idempotencyKey+":pnl", input.Reference+"-fx-pnl", metadata.Metadata{
+		"fx_conversion_ledger": input.Ledger,
+		"fx_conversion_tx_id":  fmt.Sprintf("%d", *tx.ID),
+		"fx_account":           quote.PositionAccount,
+	}, fmt.Sprintf(`
+		send [%s %d] (
+			source = @world
+			destination = @%s
+		)
+	`, currencyregistry.Asset(quote.To), quote.PnLAmount, RealizedPnLAccount))
+	if err != nil {
+		// The conversion itself is booked: retrying with the same reference
+		// prices it again at the rates stored on it, so that it is replayed
+		// and the missing P&L posted even if the rate moved in between.
+		return result, fmt.Errorf("reporting fx pnl: %w", err)
+	}
+
+	return result, nil
+}
+
+// previousQuote returns the quote of an earlier attempt of the conversion,
+// priced at the rates stored in the metadata of its transaction, or nil when
+// the reference has not been booked as a conversion yet.
+func (s *DefaultConversionService) previousQuote(ctx context.Context, input ConvertInput) (*Quote, error) {
+	l, err := s.system.GetLedgerController(ctx, input.Ledger)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := l.GetTransaction(ctx, storagecommon.ResourceQuery[any]{
+		Builder: query.Match("reference", input.Reference),
+	})
+	if err != nil {
+		if postgres.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Any other transaction holding the reference makes the posting fail.
+	mid, err := decimal.NewFromString(tx.Metadata["fx_rate"])
+	if err != nil || !mid.IsPositive() {
+		return nil, nil
+	}
+	customer, err := decimal.NewFromString(tx.Metadata["fx_customer_rate"])
+	if err != nil {
+		return nil, nil
+	}
+
+	from := strings.ToUpper(strings.TrimSpace(input.From))
+	to := strings.ToUpper(strings.TrimSpace(input.To))
+	quote := &Quote{
+		From:            from,
+		To:              to,
+		Amount:          input.Amount,
+		SpreadBps:       maxSpreadBps - int(customer.Div(mid).Shift(4).Round(0).IntPart()),
+		PositionAccount: PositionAccount(from, to),
+	}
+	if rateID, err := uuid.Parse(tx.Metadata["fx_rate_id"]); err == nil {
+		quote.RateID = &rateID
+	}
+	return price(quote, mid, customer)
+}
+
+func (s *DefaultConversionService) post(
+	ctx context.Context,
+	ledgerName string,
+	idempotencyKey string,
+	reference string,
+	txMetadata metadata.Metadata,
+	script string,
+) (*ledgerinternal.Transaction, error) {
+	l, err := s.system.GetLedgerController(ctx, ledgerName)
+	if err != nil {
+		return nil, err
+	}
+	_, created, _, err := l.CreateTransaction(ctx, ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
+		IdempotencyKey: idempotencyKey,
+		Input: ledgercontroller.CreateTransaction{
+			RunScript: vm.RunScript{
+				Script:    vm.Script{Plain: script},
+				Reference: reference,
+				Metadata:  txMetadata,
+			},
+			Runtime: ledgerinternal.RuntimeMachine,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created.Transaction, nil
+}
+
+// PositionAccount is the account holding the open FX position of a pair.
+func PositionAccount(from, to string) string {
+	return fmt.Sprintf("system:fx:%s_%s", strings.ToUpper(from), strings.ToUpper(to))
+}
+
+// convertAmount converts amount, in atomic units of from, to atomic units of
+// to at rate, rounding down.
+func convertAmount(amount int64, from, to string, rate decimal.Decimal) (int64, error) {
+	shift := currencyregistry.Precision(to) - currencyregistry.Precision(from)
+	converted := decimal.New(amount, 0).Mul(rate).Shift(int32(shift)).Floor()
+	if !converted.BigInt().IsInt64() {
+		return 0, fmt.Errorf("%w: converted amount overflows %s atomic units", ErrConversionValidation, to)
+	}
+	return converted.IntPart(), nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/metadata"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	"github.com/formancehq/ledger/internal/exchange/models"
+	storagecommon "github.com/formancehq/ledger/internal/storage/common"
+)
+
+type effectiveRateStub struct {
+	RateService
+	rate *models.Rate
+}
+
+func (s effectiveRateStub) GetEffective(_ context.Context, base, quote string, _ time.Time) (*models.Rate, error) {
+	if s.rate == nil || s.rate.BaseCurrency != base || s.rate.QuoteCurrency != quote {
+		return nil, ErrRateNotFound
+	}
+	return s.rate, nil
+}
+
+var _ RateService = effectiveRateStub{}
+
+func TestQuoteWithStoredRateBooksSpread(t *testing.T) {
+	rate := &models.Rate{
+		ID:            uuid.New(),
+		BaseCurrency:  "USD",
+		QuoteCurrency: "EUR",
+		Rate:          "0.92",
+		SpreadBps:     50,
+	}
+	service := NewConversionService(nil, effectiveRateStub{rate: rate})
+
+	quote, err := service.Quote(context.Background(), QuoteInput{From: "USD", To: "EUR", Amount: 10000})
+	require.NoError(t, err)
+	require.Equal(t, &rate.ID, quote.RateID)
+	require.Equal(t, "0.9154", quote.CustomerRate)
+	require.EqualValues(t, 9200, quote.MidAmount)
+	require.EqualValues(t, 9154, quote.ConvertedAmount)
+	require.EqualValues(t, 46, quote.PnLAmount)
+	require.Equal(t, "system:fx:USD_EUR", quote.PositionAccount)
+}
+
+func TestQuoteShiftsPrecisionAndRoundsDown(t *testing.T) {
+	service := NewConversionService(nil, nil)
+
+	quote, err := service.Quote(context.Background(), QuoteInput{From: "USD", To: "JPY", Amount: 10, Rate: "149.555"})
+	require.NoError(t, err)
+	require.EqualValues(t, 14, quote.ConvertedAmount)
+	require.Zero(t, quote.PnLAmount)
+
+	quote, err = service.Quote(context.Background(), QuoteInput{From: "JPY", To: "KWD", Amount: 1000, Rate: "0.00205"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2050, quote.ConvertedAmount)
+}
+
+func TestQuoteErrors(t *testing.T) {
+	service := NewConversionService(nil, nil)
+
+	_, err := service.Quote(context.Background(), QuoteInput{From: "USD", To: "EUR", Amount: 100})
+	require.ErrorIs(t, err, ErrConversionValidation)
+
+	_, err = service.Quote(context.Background(), QuoteInput{From: "USD", To: "USD", Amount: 100, Rate: "1"})
+	require.ErrorIs(t, err, ErrConversionValidation)
+
+	_, err = service.Quote(context.Background(), QuoteInput{From: "USD", To: "JPY", Amount: 1, Rate: "0.5"})
+	require.ErrorIs(t, err, ErrConversionValidation)
+
+	_, err = NewConversionService(nil, effectiveRateStub{}).Quote(context.Background(), QuoteInput{From: "USD", To: "EUR", Amount: 100})
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestConvertRetryReusesTheRatesOfTheFirstAttempt(t *testing.T) {
+	rate := &models.Rate{
+		ID:            uuid.New(),
+		BaseCurrency:  "USD",
+		QuoteCurrency: "EUR",
+		Rate:          "0.95",
+		SpreadBps:     50,
+	}
+	// The conversion was booked at 0.92 before its P&L posting failed.
+	ledgerController := &conversionLedgerControllerStub{
+		booked: ledgerinternal.NewTransaction().WithID(7).WithReference("fx-1").WithMetadata(metadata.Metadata{
+			"fx_rate":          "0.92",
+			"fx_customer_rate": "0.9154",
+			"fx_account":       "system:fx:USD_EUR",
+			"fx_rate_id":       rate.ID.String(),
+		}),
+	}
+	service := NewConversionService(&conversionSystemControllerStub{ledgerController: ledgerController}, effectiveRateStub{rate: rate})
+
+	result, err := service.Convert(context.Background(), ConvertInput{
+		QuoteInput:         QuoteInput{From: "USD", To: "EUR", Amount: 10000},
+		Ledger:             "default",
+		SourceAccount:      "users:user123:wallets:USD:available",
+		DestinationAccount: "users:user123:wallets:EUR:available",
+		Reference:          "fx-1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0.92", result.Quote.Rate)
+	require.Equal(t, 50, result.Quote.SpreadBps)
+	require.EqualValues(t, 9154, result.Quote.ConvertedAmount)
+	require.EqualValues(t, 46, result.Quote.PnLAmount)
+	require.Len(t, ledgerController.scripts, 2)
+	require.Contains(t, ledgerController.scripts[0], "send [EUR/2 9154]")
+	require.Contains(t, ledgerController.scripts[1], "send [EUR/2 46]")
+}
+
+type conversionSystemControllerStub struct {
+	systemcontroller.Controller
+	ledgerController ledgercontroller.Controller
+}
+
+func (s *conversionSystemControllerStub) GetLedgerController(context.Context, string) (ledgercontroller.Controller, error) {
+	return s.ledgerController, nil
+}
+
+// conversionLedgerControllerStub holds the transaction booked by an earlier
+// attempt, if any, and replays it.
+type conversionLedgerControllerStub struct {
+	ledgercontroller.Controller
+	booked  ledgerinternal.Transaction
+	scripts []string
+}
+
+func (s *conversionLedgerControllerStub) GetTransaction(context.Context, storagecommon.ResourceQuery[any]) (*ledgerinternal.Transaction, error) {
+	if s.booked.ID == nil {
+		return nil, postgres.ErrNotFound
+	}
+	return &s.booked, nil
+}
+
+func (s *conversionLedgerControllerStub) CreateTransaction(_ context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledgerinternal.Log, *ledgerinternal.CreatedTransaction, bool, error) {
+	s.scripts = append(s.scripts, params.Input.Script.Plain)
+	tx := s.booked
+	if params.Input.Reference != s.booked.Reference {
+		tx = ledgerinternal.NewTransaction().WithID(uint64(len(s.scripts)))
+	}
+	return &ledgerinternal.Log{}, &ledgerinternal.CreatedTransaction{Transaction: tx}, false, nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// RateQuote is a rate as delivered by a RateProvider.
+type RateQuote struct {
+	BaseCurrency  string     `json:"base_currency"`
+	QuoteCurrency string     `json:"quote_currency"`
+	Rate          string     `json:"rate"`
+	SpreadBps     int        `json:"spread_bps"`
+	ValidFrom     *time.Time `json:"valid_from,omitempty"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty"`
+}
+
+// RateProvider is a source of rates imported with RateService.Import.
+type RateProvider interface {
+	Name() string
+	Rates(context.Context) ([]RateQuote, error)
+}
+
+// FileRateProvider reads a JSON array of quotes from a file.
+type FileRateProvider struct {
+	path string
+}
+
+func NewFileRateProvider(path string) *FileRateProvider {
+	return &FileRateProvider{path: path}
+}
+
+func (p *FileRateProvider) Name() string {
+	return "file"
+}
+
+func (p *FileRateProvider) Rates(_ context.Context) ([]RateQuote, error) {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+	quotes := make([]RateQuote, 0)
+	if err := json.Unmarshal(data, &quotes); err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// StaticRateProvider serves a fixed set of quotes. It backs rates pushed
+// through the API and stands in for a market data feed in tests.
+type StaticRateProvider struct {
+	name   string
+	quotes []RateQuote
+}
+
+func NewStaticRateProvider(name string, quotes ...RateQuote) *StaticRateProvider {
+	return &StaticRateProvider{
+		name:   name,
+		quotes: quotes,
+	}
+}
+
+func (p *StaticRateProvider) Name() string {
+	return p.name
+}
+
+func (p *StaticRateProvider) Rates(_ context.Context) ([]RateQuote, error) {
+	return p.quotes, nil
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/exchange/models"
+	"github.com/formancehq/ledger/internal/exchange/repositories"
+)
+
+var (
+	ErrRateValidation = errors.New("fx rate validation failed")
+	ErrRateNotFound   = errors.New("fx rate not found")
+)
+
+const maxSpreadBps = 10000
+
+type RateService interface {
+	Publish(context.Context, PublishRateInput) (*models.Rate, error)
+	Revoke(context.Context, uuid.UUID, *string) (*models.Rate, error)
+	Import(context.Context, RateProvider, *string) ([]models.Rate, error)
+	Get(context.Context, uuid.UUID) (*models.Rate, error)
+	GetEffective(context.Context, string, string, time.Time) (*models.Rate, error)
+	List(context.Context, repositories.RateFilter) ([]models.Rate, error)
+	ListAudits(context.Context, repositories.RateAuditFilter) ([]models.RateAudit, error)
+}
+
+type PublishRateInput struct {
+	BaseCurrency  string
+	QuoteCurrency string
+	Rate          string
+	SpreadBps     int
+	Source        string
+	// ValidFrom defaults to now. The rate in effect at ValidFrom, if any, is
+	// closed at ValidFrom.
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+	Actor      *string
+}
+
+type DefaultRateService struct {
+	rateRepo  repositories.RateRepository
+	auditRepo repositories.RateAuditRepository
+}
+
+func NewRateService(
+	rateRepo repositories.RateRepository,
+	auditRepo repositories.RateAuditRepository,
+) *DefaultRateService {
+	return &DefaultRateService{
+		rateRepo:  rateRepo,
+		auditRepo: auditRepo,
+	}
+}
+
+func (s *DefaultRateService) Publish(ctx context.Context, input PublishRateInput) (*models.Rate, error) {
+	base, quote, err := normalizePair(input.BaseCurrency, input.QuoteCurrency)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decimal.NewFromString(strings.TrimSpace(input.Rate))
+	if err != nil || !value.IsPositive() {
+		return nil, fmt.Errorf("%w: rate must be a positive decimal", ErrRateValidation)
+	}
+	if input.SpreadBps < 0 || input.SpreadBps >= maxSpreadBps {
+		return nil, fmt.Errorf("%w: spread_bps must be between 0 and %d", ErrRateValidation, maxSpreadBps-1)
+	}
+	source := strings.TrimSpace(input.Source)
+	if source == "" {
+		source = "manual"
+	}
+	validFrom := time.Now().UTC()
+	if input.ValidFrom != nil {
+		validFrom = input.ValidFrom.UTC()
+	}
+	var validUntil *time.Time
+	if input.ValidUntil != nil {
+		until := input.ValidUntil.UTC()
+		if !until.After(validFrom) {
+			return nil, fmt.Errorf("%w: valid_until must be after valid_from", ErrRateValidation)
+		}
+		validUntil = &until
+	}
+
+	current, err := s.rateRepo.GetEffective(ctx, base, quote, validFrom)
+	switch {
+	case err == nil:
+		if sameRate(*current, value, input.SpreadBps, source, validFrom, validUntil) {
+			// Re-imported quote: keep the rate and its audit trail as is.
+			return current, nil
+		}
+		if current.ValidUntil == nil || current.ValidUntil.After(validFrom) {
+			before := rateAuditState(*current)
+			current.ValidUntil = &validFrom
+			if err := s.rateRepo.UpdateValidity(ctx, current); err != nil {
+				return nil, err
+			}
+			s.audit(ctx, current, models.RateAuditActionSuperseded, input.Actor, before, rateAuditState(*current))
+		}
+	case isNotFound(err):
+	default:
+		return nil, err
+	}
+
+	rate := &models.Rate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		Rate:          value.String(),
+		SpreadBps:     input.SpreadBps,
+		Source:        source,
+		ValidFrom:     validFrom,
+		ValidUntil:    validUntil,
+	}
+	if err := s.rateRepo.Create(ctx, rate); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, rate, models.RateAuditActionPublished, input.Actor, nil, rateAuditState(*rate))
+
+	return rate, nil
+}
+
+// Revoke ends the validity of a rate now. Conversions of the pair fail until
+// another rate is published.
+func (s *DefaultRateService) Revoke(ctx context.Context, id uuid.UUID, actor *string) (*models.Rate, error) {
+	rate, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if rate.ValidUntil != nil && !rate.ValidUntil.After(now) {
+		return rate, nil
+	}
+
+	before := rateAuditState(*rate)
+	if rate.ValidFrom.After(now) {
+		// Not yet effective: close the window on itself.
+		now = rate.ValidFrom
+	}
+	rate.ValidUntil = &now
+	if err := s.rateRepo.UpdateValidity(ctx, rate); err != nil {
+		return nil, err
+	}
+	s.audit(ctx, rate, models.RateAuditActionRevoked, actor, before, rateAuditState(*rate))
+
+	return rate, nil
+}
+
+// Import publishes every quote of the provider, tagging the rates with the
+// provider name as source. It stops on the first invalid quote.
+func (s *DefaultRateService) Import(ctx context.Context, provider RateProvider, actor *string) ([]models.Rate, error) {
+	quotes, err := provider.Rates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading rates from %s: %w", provider.Name(), err)
+	}
+
+	ret := make([]models.Rate, 0, len(quotes))
+	for _, quote := range quotes {
+		rate, err := s.Publish(ctx, PublishRateInput{
+			BaseCurrency:  quote.BaseCurrency,
+			QuoteCurrency: quote.QuoteCurrency,
+			Rate:          quote.Rate,
+			SpreadBps:     quote.SpreadBps,
+			Source:        provider.Name(),
+			ValidFrom:     quote.ValidFrom,
+			ValidUntil:    quote.ValidUntil,
+			Actor:         actor,
+		})
+		if err != nil {
+			return ret, fmt.Errorf("importing %s/%s: %w", quote.BaseCurrency, quote.QuoteCurrency, err)
+		}
+		ret = append(ret, *rate)
+	}
+	return ret, nil
+}
+
+func (s *DefaultRateService) Get(ctx context.Context, id uuid.UUID) (*models.Rate, error) {
+	rate, err := s.rateRepo.Get(ctx, id)
+	if err != nil {
+		return nil, resolveRateRepositoryError(err)
+	}
+	return rate, nil
+}
+
+func (s *DefaultRateService) GetEffective(ctx context.Context, base, quote string, at time.Time) (*models.Rate, error) {
+	base, quote, err := normalizePair(base, quote)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := s.rateRepo.GetEffective(ctx, base, quote, at.UTC())
+	if err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%w: no %s/%s rate effective at %s", ErrRateNotFound, base, quote, at.UTC().Format(time.RFC3339))
+		}
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *DefaultRateService) List(ctx context.Context, filter repositories.RateFilter) ([]models.Rate, error) {
+	return s.rateRepo.List(ctx, filter)
+}
+
+func (s *DefaultRateService) ListAudits(ctx context.Context, filter repositories.RateAuditFilter) ([]models.RateAudit, error) {
+	return s.auditRepo.List(ctx, filter)
+}
+
+func (s *DefaultRateService) audit(ctx context.Context, rate *models.Rate, action string, actor *string, before, after map[string]any) {
+	_ = s.auditRepo.Create(ctx, &models.RateAudit{
+		RateID:        rate.ID,
+		BaseCurrency:  rate.BaseCurrency,
+		QuoteCurrency: rate.QuoteCurrency,
+		Actor:         actor,
+		Action:        action,
+		Before:        before,
+		After:         after,
+	})
+}
+
+func rateAuditState(rate models.Rate) map[string]any {
+	return map[string]any{
+		"rate":        rate.Rate,
+		"spread_bps":  rate.SpreadBps,
+		"source":      rate.Source,
+		"valid_from":  rate.ValidFrom,
+		"valid_until": rate.ValidUntil,
+	}
+}
+
+func sameRate(rate models.Rate, value decimal.Decimal, spreadBps int, source string, validFrom time.Time, validUntil *time.Time) bool {
+	current, err := decimal.NewFromString(rate.Rate)
+	if err != nil || !current.Equal(value) {
+		return false
+	}
+	if rate.SpreadBps != spreadBps || rate.Source != source || !rate.ValidFrom.Equal(validFrom) {
+		return false
+	}
+	if rate.ValidUntil == nil || validUntil == nil {
+		return rate.ValidUntil == nil && validUntil == nil
+	}
+	return rate.ValidUntil.Equal(*validUntil)
+}
+
+func normalizePair(base, quote string) (string, string, error) {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	quote = strings.ToUpper(strings.TrimSpace(quote))
+	if base == "" || quote == "" {
+		return "", "", fmt.Errorf("%w: base and quote currencies are required", ErrRateValidation)
+	}
+	if base == quote {
+		return "", "", fmt.Errorf("%w: base and quote currencies must differ", ErrRateValidation)
+	}
+	for _, code := range []string{base, quote} {
+		if _, ok := currencyregistry.Lookup(code); !ok {
+			return "", "", fmt.Errorf("%w: unknown currency %s", ErrRateValidation, code)
+		}
+	}
+	return base, quote, nil
+}
+
+func isNotFound(err error) bool {
+	return postgres.IsNotFoundError(err) || errors.Is(err, postgres.ErrNotFound)
+}
+
+func resolveRateRepositoryError(err error) error {
+	if isNotFound(err) {
+		return ErrRateNotFound
+	}
+	return err
+}
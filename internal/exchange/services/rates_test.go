@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/exchange/models"
+	"github.com/formancehq/ledger/internal/exchange/repositories"
+)
+
+type memoryRateRepository struct {
+	rates []*models.Rate
+}
+
+func (r *memoryRateRepository) Create(_ context.Context, rate *models.Rate) error {
+	rate.ID = uuid.New()
+	r.rates = append(r.rates, rate)
+	return nil
+}
+
+func (r *memoryRateRepository) UpdateValidity(_ context.Context, rate *models.Rate) error {
+	for _, existing := range r.rates {
+		if existing.ID == rate.ID {
+			existing.ValidUntil = rate.ValidUntil
+			return nil
+		}
+	}
+	return postgres.ErrNotFound
+}
+
+func (r *memoryRateRepository) Get(_ context.Context, id uuid.UUID) (*models.Rate, error) {
+	for _, rate := range r.rates {
+		if rate.ID == id {
+			return rate, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (r *memoryRateRepository) GetEffective(_ context.Context, base, quote string, at time.Time) (*models.Rate, error) {
+	var ret *models.Rate
+	for _, rate := range r.rates {
+		if rate.BaseCurrency != base || rate.QuoteCurrency != quote || rate.ValidFrom.After(at) {
+			continue
+		}
+		if rate.ValidUntil != nil && !rate.ValidUntil.After(at) {
+			continue
+		}
+		if ret == nil || rate.ValidFrom.After(ret.ValidFrom) {
+			ret = rate
+		}
+	}
+	if ret == nil {
+		return nil, postgres.ErrNotFound
+	}
+	return ret, nil
+}
+
+func (r *memoryRateRepository) List(context.Context, repositories.RateFilter) ([]models.Rate, error) {
+	ret := make([]models.Rate, 0, len(r.rates))
+	for _, rate := range r.rates {
+		ret = append(ret, *rate)
+	}
+	return ret, nil
+}
+
+type memoryRateAuditRepository struct {
+	audits []models.RateAudit
+}
+
+func (r *memoryRateAuditRepository) Create(_ context.Context, audit *models.RateAudit) error {
+	r.audits = append(r.audits, *audit)
+	return nil
+}
+
+func (r *memoryRateAuditRepository) List(context.Context, repositories.RateAuditFilter) ([]models.RateAudit, error) {
+	return r.audits, nil
+}
+
+func TestPublishSupersedesEffectiveRate(t *testing.T) {
+	ctx := context.Background()
+	rates := &memoryRateRepository{}
+	audits := &memoryRateAuditRepository{}
+	service := NewRateService(rates, audits)
+
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+
+	first, err := service.Publish(ctx, PublishRateInput{BaseCurrency: "usd", QuoteCurrency: "eur", Rate: "0.92", ValidFrom: &t0})
+	require.NoError(t, err)
+	require.Equal(t, "USD", first.BaseCurrency)
+	require.Equal(t, "manual", first.Source)
+
+	second, err := service.Publish(ctx, PublishRateInput{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: "0.93", SpreadBps: 25, ValidFrom: &t1})
+	require.NoError(t, err)
+	require.NotNil(t, first.ValidUntil)
+	require.True(t, first.ValidUntil.Equal(t1))
+
+	effective, err := service.GetEffective(ctx, "USD", "EUR", t0.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, first.ID, effective.ID)
+
+	effective, err = service.GetEffective(ctx, "USD", "EUR", t1)
+	require.NoError(t, err)
+	require.Equal(t, second.ID, effective.ID)
+
+	require.Len(t, audits.audits, 3)
+	require.Equal(t, models.RateAuditActionPublished, audits.audits[0].Action)
+	require.Equal(t, models.RateAuditActionSuperseded, audits.audits[1].Action)
+	require.Equal(t, models.RateAuditActionPublished, audits.audits[2].Action)
+}
+
+func TestImportSkipsUnchangedQuotes(t *testing.T) {
+	ctx := context.Background()
+	rates := &memoryRateRepository{}
+	audits := &memoryRateAuditRepository{}
+	service := NewRateService(rates, audits)
+
+	validFrom := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider := NewStaticRateProvider("feed", RateQuote{
+		BaseCurrency:  "USD",
+		QuoteCurrency: "JPY",
+		Rate:          "149.5",
+		ValidFrom:     &validFrom,
+	})
+
+	_, err := service.Import(ctx, provider, nil)
+	require.NoError(t, err)
+	_, err = service.Import(ctx, provider, nil)
+	require.NoError(t, err)
+
+	require.Len(t, rates.rates, 1)
+	require.Equal(t, "feed", rates.rates[0].Source)
+	require.Len(t, audits.audits, 1)
+}
+
+func TestPublishValidation(t *testing.T) {
+	service := NewRateService(&memoryRateRepository{}, &memoryRateAuditRepository{})
+
+	for _, input := range []PublishRateInput{
+		{BaseCurrency: "USD", QuoteCurrency: "USD", Rate: "1"},
+		{BaseCurrency: "USD", QuoteCurrency: "XXX", Rate: "1"},
+		{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: "-1"},
+		{BaseCurrency: "USD", QuoteCurrency: "EUR", Rate: "1", SpreadBps: 10000},
+	} {
+		_, err := service.Publish(context.Background(), input)
+		require.ErrorIs(t, err, ErrRateValidation)
+	}
+}
@@ -0,0 +1,12 @@
+package services
+
+import currencyregistry "github.com/formancehq/ledger/internal/currency"
+
+func init() {
+	currencyregistry.SetDefinitions(map[string]currencyregistry.Definition{
+		"USD": {Precision: 2, Enabled: true},
+		"EUR": {Precision: 2, Enabled: true},
+		"JPY": {Precision: 0, Enabled: true},
+		"KWD": {Precision: 3, Enabled: true},
+	})
+}
@@ -3,6 +3,7 @@ package system
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/uptrace/bun"
 
@@ -500,6 +501,662 @@ func GetMigrator(db bun.IDB, options ...migrations.Option) *migrations.Migrator
 				})
 			},
 		},
+		migrations.Migration{
+			Name: "Add wallet debit saga tables",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.wallet_debit_sagas (
+							id uuid primary key default gen_random_uuid(),
+							ledger varchar(255) not null,
+							wallet_id varchar(255) not null,
+							currency varchar(16) not null,
+							operation varchar(32) not null,
+							reference varchar(255) not null,
+							status varchar(32) not null,
+							attempts integer not null default 0,
+							last_error text,
+							metadata jsonb not null default '{}'::jsonb,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc'),
+							completed_at timestamp without time zone
+						);
+						create unique index if not exists idx_wallet_debit_sagas_reference on _system.wallet_debit_sagas(ledger, reference) where status <> 'aborted';
+						create index if not exists idx_wallet_debit_sagas_status on _system.wallet_debit_sagas(status, updated_at);
+						create index if not exists idx_wallet_debit_sagas_wallet on _system.wallet_debit_sagas(wallet_id, created_at desc);
+
+						create table if not exists _system.wallet_debit_saga_legs (
+							id uuid primary key default gen_random_uuid(),
+							saga_id uuid not null references _system.wallet_debit_sagas(id) on delete cascade,
+							sequence integer not null,
+							name varchar(64) not null,
+							ledger varchar(255) not null,
+							reference varchar(255) not null,
+							idempotency_key varchar(255) not null,
+							script text not null,
+							metadata jsonb not null default '{}'::jsonb,
+							status varchar(32) not null,
+							attempts integer not null default 0,
+							transaction_id bigint,
+							revert_transaction_id bigint,
+							last_error text,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc'),
+							unique (saga_id, sequence)
+						);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add wallet liens table",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.wallet_liens (
+							id uuid primary key default gen_random_uuid(),
+							ledger varchar(255) not null,
+							wallet_id varchar(255) not null,
+							currency varchar(16) not null,
+							account varchar(255) not null,
+							reference varchar(255) not null,
+							status varchar(32) not null,
+							amount bigint not null,
+							captured_amount bigint not null default 0,
+							released_amount bigint not null default 0,
+							expires_at timestamp without time zone,
+							metadata jsonb not null default '{}'::jsonb,
+							version integer not null default 0,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc'),
+							closed_at timestamp without time zone,
+							unique (ledger, reference)
+						);
+						create index if not exists idx_wallet_liens_wallet on _system.wallet_liens(wallet_id, created_at desc);
+						create index if not exists idx_wallet_liens_expiry on _system.wallet_liens(expires_at) where status = 'active';
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add fx rates tables",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.fx_rates (
+							id uuid primary key default gen_random_uuid(),
+							base_currency varchar(16) not null,
+							quote_currency varchar(16) not null,
+							rate numeric not null check (rate > 0),
+							spread_bps integer not null default 0,
+							source varchar(64) not null,
+							valid_from timestamp without time zone not null,
+							valid_until timestamp without time zone,
+							created_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_fx_rates_pair on _system.fx_rates(base_currency, quote_currency, valid_from desc);
+
+						create table if not exists _system.fx_rate_audits (
+							id uuid primary key default gen_random_uuid(),
+							rate_id uuid not null references _system.fx_rates(id),
+							base_currency varchar(16) not null,
+							quote_currency varchar(16) not null,
+							actor varchar(255),
+							action varchar(32) not null,
+							before jsonb,
+							after jsonb,
+							created_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_fx_rate_audits_pair on _system.fx_rate_audits(base_currency, quote_currency, created_at desc);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add currency audits table",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.currency_audits (
+							id uuid primary key default gen_random_uuid(),
+							code varchar(16) not null,
+							actor varchar(255),
+							action varchar(32) not null,
+							before jsonb,
+							after jsonb,
+							created_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_currency_audits_code on _system.currency_audits(code, created_at desc);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add wallets table",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.wallets (
+							ledger varchar(255) not null,
+							id varchar(255) not null,
+							user_id varchar(255) not null,
+							currency varchar(16) not null,
+							status varchar(32) not null,
+							labels jsonb not null default '{}'::jsonb,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc'),
+							closed_at timestamp without time zone,
+							primary key (ledger, id)
+						);
+						create index if not exists idx_wallets_user on _system.wallets(ledger, user_id);
+						create index if not exists idx_wallets_labels on _system.wallets using gin (labels);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add wallet standing instructions tables",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.wallet_standing_instructions (
+							id uuid primary key,
+							ledger varchar(255) not null,
+							source_wallet_id varchar(255) not null,
+							destination_wallet_id varchar(255) not null,
+							currency varchar(16) not null,
+							amount bigint not null,
+							schedule varchar(255) not null,
+							start_at timestamp without time zone not null,
+							end_at timestamp without time zone,
+							next_run_at timestamp without time zone,
+							status varchar(32) not null,
+							max_attempts integer not null,
+							retry_interval_seconds bigint not null,
+							failure_policy varchar(32) not null,
+							consecutive_failures integer not null default 0,
+							last_executed_at timestamp without time zone,
+							metadata jsonb not null default '{}'::jsonb,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_wallet_standing_instructions_source on _system.wallet_standing_instructions(ledger, source_wallet_id);
+						create index if not exists idx_wallet_standing_instructions_due on _system.wallet_standing_instructions(next_run_at) where status = 'active';
+
+						create table if not exists _system.wallet_standing_instruction_executions (
+							id uuid primary key,
+							instruction_id uuid not null references _system.wallet_standing_instructions(id) on delete cascade,
+							occurrence_at timestamp without time zone not null,
+							reference varchar(255) not null,
+							status varchar(32) not null,
+							attempts integer not null default 0,
+							next_attempt_at timestamp without time zone,
+							transaction_id bigint,
+							last_error text,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc'),
+							unique (instruction_id, occurrence_at)
+						);
+						create index if not exists idx_wallet_standing_instruction_executions_retry on _system.wallet_standing_instruction_executions(next_attempt_at) where status = 'retrying';
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba job runs table",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.cba_job_runs (
+							id uuid primary key,
+							job varchar(64) not null,
+							business_date date not null,
+							trigger varchar(32) not null,
+							status varchar(32) not null,
+							requested_by varchar(255),
+							processed integer not null default 0,
+							skipped integer not null default 0,
+							failed integer not null default 0,
+							errors jsonb not null default '[]'::jsonb,
+							started_at timestamp without time zone,
+							finished_at timestamp without time zone,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_cba_job_runs_job_date on _system.cba_job_runs(job, business_date);
+						create unique index if not exists idx_cba_job_runs_active on _system.cba_job_runs(job, business_date) where status in ('pending', 'running');
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba job leases table",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.cba_job_leases (
+							name varchar(64) primary key,
+							holder varchar(255) not null,
+							acquired_at timestamp without time zone not null,
+							renewed_at timestamp without time zone not null,
+							expires_at timestamp without time zone not null
+						);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba term deposit columns",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.products add column if not exists term_config jsonb;
+						alter table _system.accounts add column if not exists term jsonb;
+						alter table _system.accounts add column if not exists maturity_date date;
+						create index if not exists idx_accounts_maturity_date on _system.accounts(maturity_date) where maturity_date is not null;
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba loan columns and installments table",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.products add column if not exists loan_config jsonb;
+						alter table _system.accounts add column if not exists loan jsonb;
+						create table if not exists _system.loan_installments (
+							id uuid primary key,
+							account_id uuid not null references _system.accounts(id),
+							number integer not null,
+							due_date date not null,
+							principal bigint not null,
+							interest bigint not null,
+							principal_paid bigint not null default 0,
+							interest_paid bigint not null default 0,
+							status varchar(32) not null,
+							paid_at timestamp without time zone,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create unique index if not exists idx_loan_installments_account_number on _system.loan_installments(account_id, number);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba fee posting recovery columns",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.fee_postings add column if not exists recovered_amount numeric not null default 0;
+						alter table _system.fee_postings add column if not exists recovery jsonb;
+						create index if not exists idx_fee_postings_status on _system.fee_postings(status);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba interest day count and rate versions",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.interest_accruals add column if not exists day_count varchar(16) not null default '';
+						alter table _system.interest_accruals add column if not exists rate_version integer not null default 1;
+						create table if not exists _system.interest_rate_versions (
+							id uuid primary key,
+							product_id uuid not null references _system.products(id),
+							version integer not null,
+							effective_from date not null,
+							rate varchar(32),
+							tiers jsonb,
+							created_by varchar(255),
+							created_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create unique index if not exists idx_interest_rate_versions_product_version on _system.interest_rate_versions(product_id, version);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba withholding tax",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.products add column if not exists tax_config jsonb;
+						alter table _system.clients add column if not exists tax_residency varchar(2);
+						alter table _system.clients add column if not exists tax_exemption jsonb;
+						create table if not exists _system.tax_postings (
+							id uuid primary key,
+							account_id uuid not null references _system.accounts(id),
+							client_id uuid not null references _system.clients(id),
+							interest_reference varchar(255) not null,
+							currency varchar(16) not null,
+							residency varchar(2),
+							client_type varchar(32) not null,
+							rate numeric not null,
+							gross_amount bigint not null,
+							tax_amount bigint not null,
+							net_amount bigint not null,
+							exempt boolean not null default false,
+							created_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create unique index if not exists idx_tax_postings_interest_reference on _system.tax_postings(interest_reference);
+						create index if not exists idx_tax_postings_account on _system.tax_postings(account_id);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba account holders and signing rules",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.accounts add column if not exists holders jsonb not null default '[]'::jsonb;
+						alter table _system.accounts add column if not exists signing_rule varchar(16);
+						update _system.accounts
+						set holders = jsonb_build_array(jsonb_build_object(
+							'client_id', client_id,
+							'role', 'primary',
+							'added_at', to_char(opened_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+						))
+						where holders = '[]'::jsonb;
+						create index if not exists idx_accounts_holders on _system.accounts using gin (holders jsonb_path_ops);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba approvals",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.approval_policies (
+							id uuid primary key,
+							operation varchar(64) not null,
+							product_id uuid,
+							currency varchar(16),
+							min_amount varchar(64),
+							enabled boolean not null default true,
+							created_by varchar(255),
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_approval_policies_operation on _system.approval_policies(operation);
+						create table if not exists _system.approval_requests (
+							id uuid primary key,
+							policy_id uuid not null references _system.approval_policies(id),
+							operation varchar(64) not null,
+							status varchar(32) not null,
+							resource_id uuid not null,
+							product_id uuid,
+							currency varchar(16),
+							amount bigint,
+							method varchar(16) not null,
+							path text not null,
+							payload jsonb,
+							idempotency_key varchar(255) not null,
+							requested_by varchar(255) not null,
+							decided_by varchar(255),
+							decided_at timestamp without time zone,
+							comment text,
+							result_status integer,
+							result jsonb,
+							executed_at timestamp without time zone,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_approval_requests_status on _system.approval_requests(status, created_at);
+						create index if not exists idx_approval_requests_resource on _system.approval_requests(resource_id);
+						create table if not exists _system.approval_events (
+							id uuid primary key,
+							request_id uuid not null references _system.approval_requests(id),
+							action varchar(32) not null,
+							actor varchar(255),
+							comment text,
+							created_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_approval_events_request on _system.approval_events(request_id, created_at);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba kyc provider columns",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.kyc_records
+						add column if not exists provider varchar(64),
+						add column if not exists provider_reference varchar(255);
+						create index if not exists idx_kyc_records_provider_reference on _system.kyc_records(provider, provider_reference);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba kyc expiry",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.kyc_records
+						add column if not exists expiry_notified_at timestamp without time zone;
+						create index if not exists idx_kyc_records_status_expires on _system.kyc_records(status, expires_at);
+						alter table _system.accounts
+						add column if not exists kyc_restricted boolean not null default false;
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba screening cases",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.clients
+						add column if not exists screening_status varchar(32) not null default 'clear',
+						add column if not exists screening_version varchar(64),
+						add column if not exists screened_at timestamp without time zone;
+						create table if not exists _system.screening_cases (
+							id uuid primary key,
+							client_id uuid not null references _system.clients(id),
+							account_id uuid,
+							subject_type varchar(32) not null,
+							subject_name varchar(255) not null,
+							reference varchar(255),
+							list varchar(255) not null,
+							list_version varchar(64) not null,
+							category varchar(32) not null,
+							entry_id varchar(255) not null,
+							entry_name varchar(255) not null,
+							score double precision not null,
+							action varchar(32) not null,
+							status varchar(32) not null,
+							reviewed_by varchar(255),
+							review_note text,
+							reviewed_at timestamp without time zone,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create unique index if not exists idx_screening_cases_hit on _system.screening_cases(client_id, subject_type, subject_name, list, entry_id);
+						create index if not exists idx_screening_cases_status on _system.screening_cases(status, created_at);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba transaction monitoring",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.monitored_transactions (
+							id uuid primary key,
+							account_id uuid not null references _system.accounts(id),
+							ledger varchar(255) not null,
+							transaction_id numeric not null,
+							reference varchar(255),
+							direction varchar(32) not null,
+							amount numeric not null,
+							currency varchar(16) not null,
+							timestamp timestamp without time zone not null,
+							created_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create unique index if not exists idx_monitored_transactions_posting on _system.monitored_transactions(ledger, transaction_id, account_id, direction);
+						create index if not exists idx_monitored_transactions_account on _system.monitored_transactions(account_id, timestamp);
+						create table if not exists _system.monitoring_alerts (
+							id uuid primary key,
+							account_id uuid not null references _system.accounts(id),
+							client_id uuid not null references _system.clients(id),
+							rule varchar(255) not null,
+							rule_type varchar(32) not null,
+							severity varchar(32) not null,
+							status varchar(32) not null,
+							summary text not null,
+							evidence jsonb not null default '[]'::jsonb,
+							assignee varchar(255),
+							resolution varchar(32),
+							notes jsonb not null default '[]'::jsonb,
+							closed_at timestamp without time zone,
+							created_at timestamp without time zone not null default (now() at time zone 'utc'),
+							updated_at timestamp without time zone not null default (now() at time zone 'utc')
+						);
+						create index if not exists idx_monitoring_alerts_account on _system.monitoring_alerts(account_id, rule, status);
+						create index if not exists idx_monitoring_alerts_status on _system.monitoring_alerts(status, created_at);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba listing indexes",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create index if not exists idx_products_created_at on _system.products(created_at, id);
+						create index if not exists idx_clients_created_at on _system.clients(created_at, id);
+						create index if not exists idx_accounts_opened_at on _system.accounts(opened_at, id);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba job run owner and heartbeat",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.cba_job_runs add column if not exists owner varchar(255);
+						alter table _system.cba_job_runs add column if not exists heartbeat_at timestamp without time zone;
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba monitoring cursors table",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						create table if not exists _system.cba_monitoring_cursors (
+							ledger varchar(255) primary key,
+							last_log_id numeric,
+							updated_at timestamp without time zone not null
+						);
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add cba search indexes",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					// The trigram indexes need the pg_trgm extension, installed
+					// beforehand in any schema. Without it, the client searches
+					// scan the tables.
+					var schema string
+					err := tx.NewRaw(`
+						select n.nspname
+						from pg_opclass c
+						join pg_am a on a.oid = c.opcmethod
+						join pg_namespace n on n.oid = c.opcnamespace
+						where c.opcname = 'gin_trgm_ops' and a.amname = 'gin'
+						limit 1
+					`).Scan(ctx, &schema)
+					if errors.Is(err, sql.ErrNoRows) {
+						return nil
+					}
+					if err != nil {
+						return err
+					}
+
+					_, err = tx.ExecContext(ctx, `
+						create index if not exists idx_clients_search_number on _system.clients using gin (client_number ?0.gin_trgm_ops);
+						create index if not exists idx_clients_search_email on _system.clients using gin ((contact->>'email') ?0.gin_trgm_ops);
+						create index if not exists idx_clients_search_phone on _system.clients using gin ((contact->>'phone') ?0.gin_trgm_ops);
+						create index if not exists idx_clients_search_full_name on _system.clients using gin ((trim(coalesce(individual_data->>'first_name', '') || coalesce(' ' || (individual_data->>'middle_name'), '') || coalesce(' ' || (individual_data->>'last_name'), ''))) ?0.gin_trgm_ops);
+						create index if not exists idx_clients_search_national_id on _system.clients using gin ((individual_data->>'national_id_number') ?0.gin_trgm_ops);
+						create index if not exists idx_clients_search_legal_name on _system.clients using gin ((corporate_data->>'legal_name') ?0.gin_trgm_ops);
+						create index if not exists idx_clients_search_trading_name on _system.clients using gin ((corporate_data->>'trading_name') ?0.gin_trgm_ops);
+						create index if not exists idx_accounts_search_number on _system.accounts using gin (account_number ?0.gin_trgm_ops);
+					`, bun.Ident(schema))
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add wallet lien pending movements",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.wallet_liens add column if not exists pending jsonb;
+						create index if not exists idx_wallet_liens_pending on _system.wallet_liens(updated_at) where pending is not null;
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add wallet standing instruction versions",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.wallet_standing_instructions add column if not exists version integer not null default 0;
+					`)
+					return err
+				})
+			},
+		},
+		migrations.Migration{
+			Name: "Add monitored transaction evaluation dates",
+			Up: func(ctx context.Context, db bun.IDB) error {
+				return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+					_, err := tx.ExecContext(ctx, `
+						alter table _system.monitored_transactions add column if not exists evaluated_at timestamp without time zone;
+						update _system.monitored_transactions set evaluated_at = created_at where evaluated_at is null;
+					`)
+					return err
+				})
+			},
+		},
 	)
 
 	return migrator
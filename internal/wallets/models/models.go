@@ -0,0 +1,211 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	DebitSagaOperationDebit       = "debit"
+	DebitSagaOperationLienRelease = "lien_release"
+
+	DebitSagaStatusPending      = "pending"
+	DebitSagaStatusCompleted    = "completed"
+	DebitSagaStatusCompensating = "compensating"
+	DebitSagaStatusCompensated  = "compensated"
+	DebitSagaStatusAborted      = "aborted"
+
+	DebitSagaLegStatusPending  = "pending"
+	DebitSagaLegStatusPosted   = "posted"
+	DebitSagaLegStatusFailed   = "failed"
+	DebitSagaLegStatusReverted = "reverted"
+	DebitSagaLegStatusSkipped  = "skipped"
+
+	DebitSagaLegWallet               = "wallet"
+	DebitSagaLegChannel              = "channel"
+	DebitSagaLegRevenueUserFee       = "revenue-user-fee"
+	DebitSagaLegRevenueProcessingFee = "revenue-processing-fee"
+
+	LienStatusActive   = "active"
+	LienStatusCaptured = "captured"
+	LienStatusReleased = "released"
+	LienStatusExpired  = "expired"
+
+	WalletStatusActive = "active"
+	WalletStatusFrozen = "frozen"
+	WalletStatusClosed = "closed"
+
+	StandingInstructionStatusActive    = "active"
+	StandingInstructionStatusPaused    = "paused"
+	StandingInstructionStatusCompleted = "completed"
+	StandingInstructionStatusCancelled = "cancelled"
+
+	// StandingInstructionFailureSkip gives up on an occurrence once its
+	// attempts are exhausted and keeps the schedule going.
+	StandingInstructionFailureSkip = "skip"
+	// StandingInstructionFailurePause pauses the instruction instead.
+	StandingInstructionFailurePause = "pause"
+
+	StandingInstructionExecutionPending   = "pending"
+	StandingInstructionExecutionSucceeded = "succeeded"
+	StandingInstructionExecutionRetrying  = "retrying"
+	StandingInstructionExecutionFailed    = "failed"
+)
+
+// DebitSaga journals a wallet debit that spans several ledgers. Every leg is
+// written before the first ledger call so that a crash leaves a record that
+// the recovery runner can either finish or compensate.
+type DebitSaga struct {
+	bun.BaseModel `bun:"_system.wallet_debit_sagas,alias:wallet_debit_sagas"`
+
+	ID          uuid.UUID      `json:"id" bun:"id,type:uuid,pk"`
+	Ledger      string         `json:"ledger" bun:"ledger,type:varchar(255),notnull"`
+	WalletID    string         `json:"wallet_id" bun:"wallet_id,type:varchar(255),notnull"`
+	Currency    string         `json:"currency" bun:"currency,type:varchar(16),notnull"`
+	Operation   string         `json:"operation" bun:"operation,type:varchar(32),notnull"`
+	Reference   string         `json:"reference" bun:"reference,type:varchar(255),notnull"`
+	Status      string         `json:"status" bun:"status,type:varchar(32),notnull"`
+	Attempts    int            `json:"attempts" bun:"attempts,type:integer,notnull"`
+	LastError   *string        `json:"last_error,omitempty" bun:"last_error,type:text,nullzero"`
+	Metadata    map[string]any `json:"metadata,omitempty" bun:"metadata,type:jsonb,notnull,default:'{}'::jsonb"`
+	CreatedAt   time.Time      `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt   time.Time      `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty" bun:"completed_at,type:timestamp without time zone,nullzero"`
+
+	Legs []DebitSagaLeg `json:"legs" bun:"rel:has-many,join:id=saga_id"`
+}
+
+type DebitSagaLeg struct {
+	bun.BaseModel `bun:"_system.wallet_debit_saga_legs,alias:wallet_debit_saga_legs"`
+
+	ID                  uuid.UUID         `json:"id" bun:"id,type:uuid,pk"`
+	SagaID              uuid.UUID         `json:"saga_id" bun:"saga_id,type:uuid,notnull"`
+	Sequence            int               `json:"sequence" bun:"sequence,type:integer,notnull"`
+	Name                string            `json:"name" bun:"name,type:varchar(64),notnull"`
+	Ledger              string            `json:"ledger" bun:"ledger,type:varchar(255),notnull"`
+	Reference           string            `json:"reference" bun:"reference,type:varchar(255),notnull"`
+	IdempotencyKey      string            `json:"idempotency_key" bun:"idempotency_key,type:varchar(255),notnull"`
+	Script              string            `json:"script" bun:"script,type:text,notnull"`
+	Metadata            map[string]string `json:"metadata,omitempty" bun:"metadata,type:jsonb,notnull,default:'{}'::jsonb"`
+	Status              string            `json:"status" bun:"status,type:varchar(32),notnull"`
+	Attempts            int               `json:"attempts" bun:"attempts,type:integer,notnull"`
+	TransactionID       *int64            `json:"transaction_id,omitempty" bun:"transaction_id,type:bigint,nullzero"`
+	RevertTransactionID *int64            `json:"revert_transaction_id,omitempty" bun:"revert_transaction_id,type:bigint,nullzero"`
+	LastError           *string           `json:"last_error,omitempty" bun:"last_error,type:text,nullzero"`
+	CreatedAt           time.Time         `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt           time.Time         `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
+
+// Lien is a hold on part of a wallet's available balance. Each lien owns a
+// ledger sub-account under the wallet, so the ledger balance of that account
+// is always Amount - CapturedAmount - ReleasedAmount, once Pending is settled.
+type Lien struct {
+	bun.BaseModel `bun:"_system.wallet_liens,alias:wallet_liens"`
+
+	ID             uuid.UUID         `json:"id" bun:"id,type:uuid,pk"`
+	Ledger         string            `json:"ledger" bun:"ledger,type:varchar(255),notnull"`
+	WalletID       string            `json:"wallet_id" bun:"wallet_id,type:varchar(255),notnull"`
+	Currency       string            `json:"currency" bun:"currency,type:varchar(16),notnull"`
+	Account        string            `json:"account" bun:"account,type:varchar(255),notnull"`
+	Reference      string            `json:"reference" bun:"reference,type:varchar(255),notnull"`
+	Status         string            `json:"status" bun:"status,type:varchar(32),notnull"`
+	Amount         int64             `json:"amount" bun:"amount,type:bigint,notnull"`
+	CapturedAmount int64             `json:"captured_amount" bun:"captured_amount,type:bigint,notnull"`
+	ReleasedAmount int64             `json:"released_amount" bun:"released_amount,type:bigint,notnull"`
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty" bun:"expires_at,type:timestamp without time zone,nullzero"`
+	Metadata       map[string]string `json:"metadata,omitempty" bun:"metadata,type:jsonb,notnull,default:'{}'::jsonb"`
+	// Pending is the movement recorded on the lien and not yet known to be
+	// posted to the ledger.
+	Pending   *LienMovement `json:"pending,omitempty" bun:"pending,type:jsonb,nullzero"`
+	Version   int           `json:"version" bun:"version,type:integer,notnull"`
+	CreatedAt time.Time     `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt time.Time     `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+	ClosedAt  *time.Time    `json:"closed_at,omitempty" bun:"closed_at,type:timestamp without time zone,nullzero"`
+}
+
+// LienMovement moves funds in or out of a lien. It is recorded on the lien
+// before being posted, so that a movement interrupted halfway is replayed
+// with the same idempotency key and script rather than lost or posted twice.
+type LienMovement struct {
+	Operation string `json:"operation"`
+	Amount    int64  `json:"amount"`
+	// Released is what a capture returns to the available balance along
+	// with the captured amount.
+	Released       int64             `json:"released,omitempty"`
+	Reference      string            `json:"reference,omitempty"`
+	IdempotencyKey string            `json:"idempotency_key"`
+	Script         string            `json:"script"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	ExpiresAt      *time.Time        `json:"expires_at,omitempty"`
+}
+
+// Remaining is the amount still held by the lien.
+func (l Lien) Remaining() int64 {
+	return l.Amount - l.CapturedAmount - l.ReleasedAmount
+}
+
+// Wallet registers a wallet of a ledger. Its accounts live under
+// users:{UserID}:wallets:{Currency}, and ID is always {UserID}-{Currency}.
+type Wallet struct {
+	bun.BaseModel `bun:"_system.wallets,alias:wallets"`
+
+	Ledger    string            `json:"ledger" bun:"ledger,type:varchar(255),pk"`
+	ID        string            `json:"id" bun:"id,type:varchar(255),pk"`
+	UserID    string            `json:"user_id" bun:"user_id,type:varchar(255),notnull"`
+	Currency  string            `json:"currency" bun:"currency,type:varchar(16),notnull"`
+	Status    string            `json:"status" bun:"status,type:varchar(32),notnull"`
+	Labels    map[string]string `json:"labels" bun:"labels,type:jsonb,notnull,default:'{}'::jsonb"`
+	CreatedAt time.Time         `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt time.Time         `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+	ClosedAt  *time.Time        `json:"closed_at,omitempty" bun:"closed_at,type:timestamp without time zone,nullzero"`
+}
+
+// StandingInstruction moves Amount from one wallet to another on every
+// occurrence of Schedule, a cron expression or a calendar rule, from StartAt
+// until EndAt.
+type StandingInstruction struct {
+	bun.BaseModel `bun:"_system.wallet_standing_instructions,alias:wallet_standing_instructions"`
+
+	ID                  uuid.UUID         `json:"id" bun:"id,type:uuid,pk"`
+	Ledger              string            `json:"ledger" bun:"ledger,type:varchar(255),notnull"`
+	SourceWalletID      string            `json:"source_wallet_id" bun:"source_wallet_id,type:varchar(255),notnull"`
+	DestinationWalletID string            `json:"destination_wallet_id" bun:"destination_wallet_id,type:varchar(255),notnull"`
+	Currency            string            `json:"currency" bun:"currency,type:varchar(16),notnull"`
+	Amount              int64             `json:"amount" bun:"amount,type:bigint,notnull"`
+	Schedule            string            `json:"schedule" bun:"schedule,type:varchar(255),notnull"`
+	StartAt             time.Time         `json:"start_at" bun:"start_at,type:timestamp without time zone,notnull"`
+	EndAt               *time.Time        `json:"end_at,omitempty" bun:"end_at,type:timestamp without time zone,nullzero"`
+	NextRunAt           *time.Time        `json:"next_run_at,omitempty" bun:"next_run_at,type:timestamp without time zone,nullzero"`
+	Status              string            `json:"status" bun:"status,type:varchar(32),notnull"`
+	MaxAttempts         int               `json:"max_attempts" bun:"max_attempts,type:integer,notnull"`
+	RetryInterval       int64             `json:"retry_interval_seconds" bun:"retry_interval_seconds,type:bigint,notnull"`
+	FailurePolicy       string            `json:"failure_policy" bun:"failure_policy,type:varchar(32),notnull"`
+	ConsecutiveFailures int               `json:"consecutive_failures" bun:"consecutive_failures,type:integer,notnull"`
+	LastExecutedAt      *time.Time        `json:"last_executed_at,omitempty" bun:"last_executed_at,type:timestamp without time zone,nullzero"`
+	Metadata            map[string]string `json:"metadata,omitempty" bun:"metadata,type:jsonb,notnull,default:'{}'::jsonb"`
+	Version             int               `json:"version" bun:"version,type:integer,notnull"`
+	CreatedAt           time.Time         `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt           time.Time         `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
+
+// StandingInstructionExecution is the history of one occurrence of a
+// standing instruction. There is at most one per occurrence, and its
+// Reference is the ledger reference of the transfer, so an occurrence is
+// never paid twice.
+type StandingInstructionExecution struct {
+	bun.BaseModel `bun:"_system.wallet_standing_instruction_executions,alias:wallet_standing_instruction_executions"`
+
+	ID            uuid.UUID  `json:"id" bun:"id,type:uuid,pk"`
+	InstructionID uuid.UUID  `json:"instruction_id" bun:"instruction_id,type:uuid,notnull"`
+	OccurrenceAt  time.Time  `json:"occurrence_at" bun:"occurrence_at,type:timestamp without time zone,notnull"`
+	Reference     string     `json:"reference" bun:"reference,type:varchar(255),notnull"`
+	Status        string     `json:"status" bun:"status,type:varchar(32),notnull"`
+	Attempts      int        `json:"attempts" bun:"attempts,type:integer,notnull"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" bun:"next_attempt_at,type:timestamp without time zone,nullzero"`
+	TransactionID *uint64    `json:"transaction_id,omitempty" bun:"transaction_id,type:bigint,nullzero"`
+	LastError     *string    `json:"last_error,omitempty" bun:"last_error,type:text,nullzero"`
+	CreatedAt     time.Time  `json:"created_at" bun:"created_at,type:timestamp without time zone,nullzero"`
+	UpdatedAt     time.Time  `json:"updated_at" bun:"updated_at,type:timestamp without time zone,nullzero"`
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidWalletID reports a wallet ID, or a user ID, that cannot be mapped
+// to ledger accounts.
+var ErrInvalidWalletID = errors.New("invalid walletID")
+
+const (
+	// MaxUserIDLength bounds user IDs so that their escaped form stays a
+	// reasonable address segment.
+	MaxUserIDLength = 128
+
+	// escapedSegmentPrefix marks user segments holding an escaped user ID.
+	// User IDs starting with it are always escaped, so that decoding is never
+	// ambiguous.
+	escapedSegmentPrefix = "__"
+)
+
+var (
+	userSegmentRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	currencyRegexp    = regexp.MustCompile(`^[A-Z0-9]{2,16}$`)
+)
+
+// WalletID identifies a wallet as {UserID}-{Currency}. Currencies never hold
+// a dash, so the ID splits back on its last dash whatever the user ID is.
+type WalletID struct {
+	UserID   string
+	Currency string
+}
+
+func NewWalletID(userID, currency string) (WalletID, error) {
+	if err := ValidateUserID(userID); err != nil {
+		return WalletID{}, err
+	}
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyRegexp.MatchString(currency) {
+		return WalletID{}, fmt.Errorf("%w: invalid currency %q", ErrInvalidWalletID, currency)
+	}
+	return WalletID{
+		UserID:   userID,
+		Currency: currency,
+	}, nil
+}
+
+// ParseWalletID parses an ID built by WalletID.String, including the
+// user-CCY IDs handed out before user IDs were escaped.
+func ParseWalletID(walletID string) (WalletID, error) {
+	lastDash := strings.LastIndex(walletID, "-")
+	if lastDash <= 0 || lastDash == len(walletID)-1 {
+		return WalletID{}, fmt.Errorf("%w: expected {userID}-{currency}, got %q", ErrInvalidWalletID, walletID)
+	}
+	return NewWalletID(walletID[:lastDash], walletID[lastDash+1:])
+}
+
+func (id WalletID) String() string {
+	return id.UserID + "-" + id.Currency
+}
+
+// Segment is the user ID as a ledger address segment.
+func (id WalletID) Segment() string {
+	return EncodeUserSegment(id.UserID)
+}
+
+// AccountPrefix is the root of the wallet accounts, ending with a colon.
+func (id WalletID) AccountPrefix() string {
+	return fmt.Sprintf("users:%s:wallets:%s:", id.Segment(), id.Currency)
+}
+
+func (id WalletID) AvailableAccount() string {
+	return id.AccountPrefix() + "available"
+}
+
+// PooledLienAccount holds the liens placed before liens were tracked
+// individually.
+func (id WalletID) PooledLienAccount() string {
+	return id.AccountPrefix() + "lien"
+}
+
+// LienAccountPrefix is the root of the accounts of the individual liens.
+func (id WalletID) LienAccountPrefix() string {
+	return id.AccountPrefix() + "liens:"
+}
+
+// LienAccount holds the funds of a single lien.
+func (id WalletID) LienAccount(lienID uuid.UUID) string {
+	return id.LienAccountPrefix() + lienID.String()
+}
+
+func ValidateUserID(userID string) error {
+	switch {
+	case userID == "":
+		return fmt.Errorf("%w: userID is required", ErrInvalidWalletID)
+	case len(userID) > MaxUserIDLength:
+		return fmt.Errorf("%w: userID is longer than %d bytes", ErrInvalidWalletID, MaxUserIDLength)
+	case !utf8.ValidString(userID):
+		return fmt.Errorf("%w: userID is not valid UTF-8", ErrInvalidWalletID)
+	}
+	for _, r := range userID {
+		// Slashes would not survive the wallet routes, and spaces or control
+		// characters are almost always a client bug.
+		if r == '/' || unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("%w: userID contains %q", ErrInvalidWalletID, r)
+		}
+	}
+	return nil
+}
+
+// EncodeUserSegment maps a user ID to a valid address segment. User IDs that
+// already are valid segments are kept as is, which keeps the accounts of
+// existing wallets. Others are prefixed with "__" and every byte outside
+// [a-zA-Z0-9-] is written as _XX, its hexadecimal value.
+func EncodeUserSegment(userID string) string {
+	if userSegmentRegexp.MatchString(userID) && !strings.HasPrefix(userID, escapedSegmentPrefix) {
+		return userID
+	}
+
+	b := strings.Builder{}
+	b.WriteString(escapedSegmentPrefix)
+	for i := 0; i < len(userID); i++ {
+		c := userID[i]
+		if c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "_%02X", c)
+	}
+	return b.String()
+}
+
+// DecodeUserSegment reverses EncodeUserSegment.
+func DecodeUserSegment(segment string) (string, error) {
+	if !strings.HasPrefix(segment, escapedSegmentPrefix) {
+		return segment, nil
+	}
+
+	encoded := segment[len(escapedSegmentPrefix):]
+	b := strings.Builder{}
+	for i := 0; i < len(encoded); i++ {
+		if encoded[i] != '_' {
+			b.WriteByte(encoded[i])
+			continue
+		}
+		if i+3 > len(encoded) {
+			return "", fmt.Errorf("%w: truncated escape in %q", ErrInvalidWalletID, segment)
+		}
+		c, err := strconv.ParseUint(encoded[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid escape in %q", ErrInvalidWalletID, segment)
+		}
+		b.WriteByte(byte(c))
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletIDLegacyIDsKeepTheirAccounts(t *testing.T) {
+	t.Parallel()
+
+	id, err := ParseWalletID("user_1-usd")
+	require.NoError(t, err)
+	require.Equal(t, WalletID{UserID: "user_1", Currency: "USD"}, id)
+	require.Equal(t, "user_1-USD", id.String())
+	require.Equal(t, "users:user_1:wallets:USD:available", id.AvailableAccount())
+	require.Equal(t, "users:user_1:wallets:USD:lien", id.PooledLienAccount())
+}
+
+func TestWalletIDRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, userID := range []string{
+		"bob",
+		"8f14e45f-ceea-467f-a0e6-1b6e3f2a9c10",
+		"tenant:42:bob",
+		"__bob",
+		"a_b.c@example.com",
+		"zoë",
+	} {
+		t.Run(userID, func(t *testing.T) {
+			t.Parallel()
+
+			id, err := NewWalletID(userID, "EUR")
+			require.NoError(t, err)
+
+			parsed, err := ParseWalletID(id.String())
+			require.NoError(t, err)
+			require.Equal(t, id, parsed)
+
+			segment := id.Segment()
+			require.Regexp(t, userSegmentRegexp, segment)
+			decoded, err := DecodeUserSegment(segment)
+			require.NoError(t, err)
+			require.Equal(t, userID, decoded)
+		})
+	}
+}
+
+func TestEncodeUserSegment(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "8f14e45f-ceea", EncodeUserSegment("8f14e45f-ceea"))
+	require.Equal(t, "__tenant_3A42", EncodeUserSegment("tenant:42"))
+	// The escape prefix is itself escaped, so "__5F" cannot collide with an
+	// escaped user ID.
+	require.Equal(t, "___5F_5Fbob", EncodeUserSegment("__bob"))
+	require.Equal(t, "__a_5Fb_2E", EncodeUserSegment("a_b."))
+}
+
+func TestWalletIDValidation(t *testing.T) {
+	t.Parallel()
+
+	for _, walletID := range []string{
+		"",
+		"USD",
+		"-USD",
+		"bob-",
+		"bob-U",
+		"bob-US.D",
+		"bob smith-USD",
+		"bob/alice-USD",
+		"bob\n-USD",
+	} {
+		_, err := ParseWalletID(walletID)
+		require.ErrorIs(t, err, ErrInvalidWalletID, walletID)
+	}
+
+	_, err := DecodeUserSegment("__bob_3")
+	require.ErrorIs(t, err, ErrInvalidWalletID)
+	_, err = DecodeUserSegment("__bob_ZZ")
+	require.ErrorIs(t, err, ErrInvalidWalletID)
+}
@@ -0,0 +1,58 @@
+package wallets
+
+import (
+	"github.com/uptrace/bun"
+	"go.uber.org/fx"
+
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	"github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+	"github.com/formancehq/ledger/internal/wallets/services"
+)
+
+func NewFXModule() fx.Option {
+	return fx.Options(
+		fx.Provide(
+			func(db *bun.DB) repositories.DebitSagaRepository {
+				return repositories.NewDebitSagaRepository(db)
+			},
+			func(
+				system systemcontroller.Controller,
+				debitSagaRepository repositories.DebitSagaRepository,
+			) services.DebitSagaCoordinator {
+				return services.NewDebitSagaCoordinator(system, debitSagaRepository)
+			},
+			func(db *bun.DB) repositories.LienRepository {
+				return repositories.NewLienRepository(db)
+			},
+			func(
+				system systemcontroller.Controller,
+				lienRepository repositories.LienRepository,
+			) services.LienService {
+				return services.NewLienService(system, lienRepository)
+			},
+			func(db *bun.DB) repositories.WalletRepository {
+				return repositories.NewWalletRepository(db)
+			},
+			func(
+				system systemcontroller.Controller,
+				walletRepository repositories.WalletRepository,
+			) services.WalletService {
+				return services.NewWalletService(system, walletRepository)
+			},
+			func(system systemcontroller.Controller) currency.BalanceChecker {
+				return services.NewCurrencyBalanceChecker(system)
+			},
+			func(db *bun.DB) repositories.StandingInstructionRepository {
+				return repositories.NewStandingInstructionRepository(db)
+			},
+			func(
+				system systemcontroller.Controller,
+				standingInstructionRepository repositories.StandingInstructionRepository,
+				walletService services.WalletService,
+			) services.StandingInstructionService {
+				return services.NewStandingInstructionService(system, standingInstructionRepository, walletService)
+			},
+		),
+	)
+}
@@ -0,0 +1,567 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/wallets/models"
+)
+
+var (
+	// ErrLienVersionMismatch reports a lien updated concurrently since it was read.
+	ErrLienVersionMismatch = errors.New("lien was updated concurrently")
+	// ErrStandingInstructionVersionMismatch reports a standing instruction
+	// updated concurrently since it was read.
+	ErrStandingInstructionVersionMismatch = errors.New("standing instruction was updated concurrently")
+)
+
+type DebitSagaFilter struct {
+	Ledger        *string
+	WalletID      *string
+	Statuses      []string
+	UpdatedBefore *time.Time
+	Limit         int
+	Offset        int
+}
+
+type LienFilter struct {
+	Ledger        *string
+	WalletID      *string
+	Statuses      []string
+	ExpiresBefore *time.Time
+	// Pending only keeps liens with a movement waiting to be settled.
+	Pending       bool
+	UpdatedBefore *time.Time
+	Limit         int
+	Offset        int
+}
+
+type WalletFilter struct {
+	Ledger   *string
+	UserID   *string
+	Currency *string
+	Statuses []string
+	// Labels only keeps wallets carrying every one of these labels.
+	Labels map[string]string
+	// Search matches the beginning of the wallet ID, case insensitively.
+	Search *string
+	Limit  int
+	Offset int
+}
+
+type StandingInstructionFilter struct {
+	Ledger         *string
+	SourceWalletID *string
+	Statuses       []string
+	// DueBefore only keeps instructions whose next occurrence is due.
+	DueBefore *time.Time
+	Limit     int
+	Offset    int
+}
+
+type StandingInstructionExecutionFilter struct {
+	InstructionID *uuid.UUID
+	Statuses      []string
+	// RetryBefore only keeps executions whose next attempt is due.
+	RetryBefore *time.Time
+	Limit       int
+	Offset      int
+}
+
+type DebitSagaRepository interface {
+	Create(context.Context, *models.DebitSaga) error
+	Save(context.Context, *models.DebitSaga) error
+	Get(context.Context, uuid.UUID) (*models.DebitSaga, error)
+	GetByReference(context.Context, string, string) (*models.DebitSaga, error)
+	List(context.Context, DebitSagaFilter) ([]models.DebitSaga, error)
+}
+
+type LienRepository interface {
+	Create(context.Context, *models.Lien) error
+	Update(context.Context, *models.Lien) error
+	Delete(context.Context, uuid.UUID) error
+	Get(context.Context, uuid.UUID) (*models.Lien, error)
+	GetByReference(context.Context, string, string) (*models.Lien, error)
+	List(context.Context, LienFilter) ([]models.Lien, error)
+}
+
+type WalletRepository interface {
+	Create(context.Context, *models.Wallet) error
+	Update(context.Context, *models.Wallet) error
+	Get(context.Context, string, string) (*models.Wallet, error)
+	List(context.Context, WalletFilter) ([]models.Wallet, error)
+}
+
+type StandingInstructionRepository interface {
+	Create(context.Context, *models.StandingInstruction) error
+	Update(context.Context, *models.StandingInstruction) error
+	UpdateRun(context.Context, *models.StandingInstruction) error
+	Get(context.Context, uuid.UUID) (*models.StandingInstruction, error)
+	List(context.Context, StandingInstructionFilter) ([]models.StandingInstruction, error)
+	// CreateExecution records the execution of an occurrence. When the
+	// occurrence already has one, it is loaded into the argument instead and
+	// created is false.
+	CreateExecution(context.Context, *models.StandingInstructionExecution) (created bool, err error)
+	UpdateExecution(context.Context, *models.StandingInstructionExecution) error
+	GetExecution(context.Context, uuid.UUID) (*models.StandingInstructionExecution, error)
+	ListExecutions(context.Context, StandingInstructionExecutionFilter) ([]models.StandingInstructionExecution, error)
+}
+
+type BunDebitSagaRepository struct {
+	db bun.IDB
+}
+
+func NewDebitSagaRepository(db bun.IDB) *BunDebitSagaRepository {
+	return &BunDebitSagaRepository{db: db}
+}
+
+type BunLienRepository struct {
+	db bun.IDB
+}
+
+func NewLienRepository(db bun.IDB) *BunLienRepository {
+	return &BunLienRepository{db: db}
+}
+
+func (r *BunDebitSagaRepository) Create(ctx context.Context, saga *models.DebitSaga) error {
+	setUUID(&saga.ID)
+	now := time.Now().UTC()
+	if saga.CreatedAt.IsZero() {
+		saga.CreatedAt = now
+	}
+	saga.UpdatedAt = now
+	for i := range saga.Legs {
+		setUUID(&saga.Legs[i].ID)
+		saga.Legs[i].SagaID = saga.ID
+		saga.Legs[i].CreatedAt = now
+		saga.Legs[i].UpdatedAt = now
+	}
+
+	err := r.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().Model(saga).Returning("*").Exec(ctx); err != nil {
+			return err
+		}
+		if len(saga.Legs) == 0 {
+			return nil
+		}
+		_, err := tx.NewInsert().Model(&saga.Legs).Returning("*").Exec(ctx)
+		return err
+	})
+	return postgres.ResolveError(err)
+}
+
+// Save persists the saga status and the state of every leg in a single transaction.
+func (r *BunDebitSagaRepository) Save(ctx context.Context, saga *models.DebitSaga) error {
+	now := time.Now().UTC()
+	saga.UpdatedAt = now
+
+	err := r.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewUpdate().
+			Model(saga).
+			Column("status", "attempts", "last_error", "metadata", "updated_at", "completed_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+		for i := range saga.Legs {
+			saga.Legs[i].UpdatedAt = now
+			if _, err := tx.NewUpdate().
+				Model(&saga.Legs[i]).
+				Column("status", "attempts", "transaction_id", "revert_transaction_id", "last_error", "updated_at").
+				WherePK().
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return postgres.ResolveError(err)
+}
+
+func (r *BunDebitSagaRepository) Get(ctx context.Context, id uuid.UUID) (*models.DebitSaga, error) {
+	saga := &models.DebitSaga{}
+	err := r.db.NewSelect().
+		Model(saga).
+		Relation("Legs", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.OrderExpr("sequence asc")
+		}).
+		Where("wallet_debit_sagas.id = ?", id).
+		Scan(ctx)
+	return saga, postgres.ResolveError(err)
+}
+
+func (r *BunDebitSagaRepository) GetByReference(ctx context.Context, ledger, reference string) (*models.DebitSaga, error) {
+	saga := &models.DebitSaga{}
+	err := r.db.NewSelect().
+		Model(saga).
+		Relation("Legs", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.OrderExpr("sequence asc")
+		}).
+		Where("wallet_debit_sagas.ledger = ?", ledger).
+		Where("wallet_debit_sagas.reference = ?", reference).
+		Where("wallet_debit_sagas.status <> ?", models.DebitSagaStatusAborted).
+		Limit(1).
+		Scan(ctx)
+	return saga, postgres.ResolveError(err)
+}
+
+func (r *BunDebitSagaRepository) List(ctx context.Context, filter DebitSagaFilter) ([]models.DebitSaga, error) {
+	sagas := make([]models.DebitSaga, 0)
+	q := r.db.NewSelect().
+		Model(&sagas).
+		Relation("Legs", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.OrderExpr("sequence asc")
+		})
+	if filter.Ledger != nil {
+		q = q.Where("wallet_debit_sagas.ledger = ?", *filter.Ledger)
+	}
+	if filter.WalletID != nil {
+		q = q.Where("wallet_debit_sagas.wallet_id = ?", *filter.WalletID)
+	}
+	if len(filter.Statuses) > 0 {
+		q = q.Where("wallet_debit_sagas.status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.UpdatedBefore != nil {
+		q = q.Where("wallet_debit_sagas.updated_at < ?", *filter.UpdatedBefore)
+	}
+	q = q.OrderExpr("wallet_debit_sagas.created_at asc")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	err := q.Scan(ctx)
+	return sagas, postgres.ResolveError(err)
+}
+
+func (r *BunLienRepository) Create(ctx context.Context, lien *models.Lien) error {
+	setUUID(&lien.ID)
+	now := time.Now().UTC()
+	if lien.CreatedAt.IsZero() {
+		lien.CreatedAt = now
+	}
+	lien.UpdatedAt = now
+	_, err := r.db.NewInsert().Model(lien).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+// Update saves the lien only if nobody else updated it since it was read,
+// and returns ErrLienVersionMismatch otherwise.
+func (r *BunLienRepository) Update(ctx context.Context, lien *models.Lien) error {
+	version := lien.Version
+	lien.Version++
+	lien.UpdatedAt = time.Now().UTC()
+	ret, err := r.db.NewUpdate().
+		Model(lien).
+		Column("status", "amount", "captured_amount", "released_amount", "expires_at", "metadata", "pending", "version", "updated_at", "closed_at").
+		WherePK().
+		Where("version = ?", version).
+		Exec(ctx)
+	if err != nil {
+		lien.Version = version
+		return postgres.ResolveError(err)
+	}
+	if affected, err := ret.RowsAffected(); err == nil && affected == 0 {
+		lien.Version = version
+		return ErrLienVersionMismatch
+	}
+	return nil
+}
+
+func (r *BunLienRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.NewDelete().Model((*models.Lien)(nil)).Where("id = ?", id).Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunLienRepository) Get(ctx context.Context, id uuid.UUID) (*models.Lien, error) {
+	lien := &models.Lien{}
+	err := r.db.NewSelect().Model(lien).Where("id = ?", id).Scan(ctx)
+	return lien, postgres.ResolveError(err)
+}
+
+func (r *BunLienRepository) GetByReference(ctx context.Context, ledger, reference string) (*models.Lien, error) {
+	lien := &models.Lien{}
+	err := r.db.NewSelect().
+		Model(lien).
+		Where("ledger = ?", ledger).
+		Where("reference = ?", reference).
+		Scan(ctx)
+	return lien, postgres.ResolveError(err)
+}
+
+func (r *BunLienRepository) List(ctx context.Context, filter LienFilter) ([]models.Lien, error) {
+	liens := make([]models.Lien, 0)
+	q := r.db.NewSelect().Model(&liens)
+	if filter.Ledger != nil {
+		q = q.Where("ledger = ?", *filter.Ledger)
+	}
+	if filter.WalletID != nil {
+		q = q.Where("wallet_id = ?", *filter.WalletID)
+	}
+	if len(filter.Statuses) > 0 {
+		q = q.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.ExpiresBefore != nil {
+		q = q.Where("expires_at is not null and expires_at <= ?", *filter.ExpiresBefore)
+	}
+	if filter.Pending {
+		q = q.Where("pending is not null")
+	}
+	if filter.UpdatedBefore != nil {
+		q = q.Where("updated_at <= ?", *filter.UpdatedBefore)
+	}
+	q = q.OrderExpr("created_at desc")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	err := q.Scan(ctx)
+	return liens, postgres.ResolveError(err)
+}
+
+type BunWalletRepository struct {
+	db bun.IDB
+}
+
+func NewWalletRepository(db bun.IDB) *BunWalletRepository {
+	return &BunWalletRepository{db: db}
+}
+
+func (r *BunWalletRepository) Create(ctx context.Context, wallet *models.Wallet) error {
+	now := time.Now().UTC()
+	if wallet.CreatedAt.IsZero() {
+		wallet.CreatedAt = now
+	}
+	wallet.UpdatedAt = now
+	_, err := r.db.NewInsert().Model(wallet).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunWalletRepository) Update(ctx context.Context, wallet *models.Wallet) error {
+	wallet.UpdatedAt = time.Now().UTC()
+	_, err := r.db.NewUpdate().
+		Model(wallet).
+		Column("status", "labels", "updated_at", "closed_at").
+		WherePK().
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunWalletRepository) Get(ctx context.Context, ledger, id string) (*models.Wallet, error) {
+	wallet := &models.Wallet{}
+	err := r.db.NewSelect().
+		Model(wallet).
+		Where("ledger = ?", ledger).
+		Where("id = ?", id).
+		Scan(ctx)
+	return wallet, postgres.ResolveError(err)
+}
+
+func (r *BunWalletRepository) List(ctx context.Context, filter WalletFilter) ([]models.Wallet, error) {
+	wallets := make([]models.Wallet, 0)
+	q := r.db.NewSelect().Model(&wallets)
+	if filter.Ledger != nil {
+		q = q.Where("ledger = ?", *filter.Ledger)
+	}
+	if filter.UserID != nil {
+		q = q.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Currency != nil {
+		q = q.Where("currency = ?", *filter.Currency)
+	}
+	if len(filter.Statuses) > 0 {
+		q = q.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if len(filter.Labels) > 0 {
+		q = q.Where("labels @> ?::jsonb", filter.Labels)
+	}
+	if filter.Search != nil {
+		q = q.Where("id ilike ?", escapeLike(*filter.Search)+"%")
+	}
+	q = q.OrderExpr("created_at desc, id asc")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	err := q.Scan(ctx)
+	return wallets, postgres.ResolveError(err)
+}
+
+type BunStandingInstructionRepository struct {
+	db bun.IDB
+}
+
+func NewStandingInstructionRepository(db bun.IDB) *BunStandingInstructionRepository {
+	return &BunStandingInstructionRepository{db: db}
+}
+
+func (r *BunStandingInstructionRepository) Create(ctx context.Context, instruction *models.StandingInstruction) error {
+	setUUID(&instruction.ID)
+	now := time.Now().UTC()
+	if instruction.CreatedAt.IsZero() {
+		instruction.CreatedAt = now
+	}
+	instruction.UpdatedAt = now
+	_, err := r.db.NewInsert().Model(instruction).Returning("*").Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+// Update saves the instruction only if nobody else updated it since it was
+// read, and returns ErrStandingInstructionVersionMismatch otherwise.
+func (r *BunStandingInstructionRepository) Update(ctx context.Context, instruction *models.StandingInstruction) error {
+	return r.update(ctx, instruction, r.db.NewUpdate().
+		Column(
+			"amount", "schedule", "end_at", "next_run_at", "status", "max_attempts", "retry_interval_seconds",
+			"failure_policy", "consecutive_failures", "last_executed_at", "metadata", "version", "updated_at",
+		))
+}
+
+// UpdateRun saves the progress of a run of an active instruction: its next
+// occurrence, its failures, its last execution and the status it leaves
+// active for. It returns ErrStandingInstructionVersionMismatch when the
+// instruction was paused, cancelled or edited since it was read.
+func (r *BunStandingInstructionRepository) UpdateRun(ctx context.Context, instruction *models.StandingInstruction) error {
+	return r.update(ctx, instruction, r.db.NewUpdate().
+		Column("next_run_at", "status", "consecutive_failures", "last_executed_at", "version", "updated_at").
+		Where("status = ?", models.StandingInstructionStatusActive))
+}
+
+func (r *BunStandingInstructionRepository) update(ctx context.Context, instruction *models.StandingInstruction, query *bun.UpdateQuery) error {
+	version := instruction.Version
+	instruction.Version++
+	instruction.UpdatedAt = time.Now().UTC()
+	ret, err := query.
+		Model(instruction).
+		WherePK().
+		Where("version = ?", version).
+		Exec(ctx)
+	if err != nil {
+		instruction.Version = version
+		return postgres.ResolveError(err)
+	}
+	if affected, err := ret.RowsAffected(); err == nil && affected == 0 {
+		instruction.Version = version
+		return ErrStandingInstructionVersionMismatch
+	}
+	return nil
+}
+
+func (r *BunStandingInstructionRepository) Get(ctx context.Context, id uuid.UUID) (*models.StandingInstruction, error) {
+	instruction := &models.StandingInstruction{}
+	err := r.db.NewSelect().Model(instruction).Where("id = ?", id).Scan(ctx)
+	return instruction, postgres.ResolveError(err)
+}
+
+func (r *BunStandingInstructionRepository) List(ctx context.Context, filter StandingInstructionFilter) ([]models.StandingInstruction, error) {
+	instructions := make([]models.StandingInstruction, 0)
+	q := r.db.NewSelect().Model(&instructions)
+	if filter.Ledger != nil {
+		q = q.Where("ledger = ?", *filter.Ledger)
+	}
+	if filter.SourceWalletID != nil {
+		q = q.Where("source_wallet_id = ?", *filter.SourceWalletID)
+	}
+	if len(filter.Statuses) > 0 {
+		q = q.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.DueBefore != nil {
+		q = q.Where("next_run_at is not null and next_run_at <= ?", *filter.DueBefore).
+			OrderExpr("next_run_at asc")
+	}
+	q = q.OrderExpr("created_at desc")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	err := q.Scan(ctx)
+	return instructions, postgres.ResolveError(err)
+}
+
+func (r *BunStandingInstructionRepository) CreateExecution(ctx context.Context, execution *models.StandingInstructionExecution) (bool, error) {
+	setUUID(&execution.ID)
+	now := time.Now().UTC()
+	execution.CreatedAt = now
+	execution.UpdatedAt = now
+	ret, err := r.db.NewInsert().
+		Model(execution).
+		On("conflict (instruction_id, occurrence_at) do nothing").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return false, postgres.ResolveError(err)
+	}
+	if affected, err := ret.RowsAffected(); err == nil && affected > 0 {
+		return true, nil
+	}
+
+	err = r.db.NewSelect().
+		Model(execution).
+		Where("instruction_id = ?", execution.InstructionID).
+		Where("occurrence_at = ?", execution.OccurrenceAt).
+		Scan(ctx)
+	return false, postgres.ResolveError(err)
+}
+
+func (r *BunStandingInstructionRepository) UpdateExecution(ctx context.Context, execution *models.StandingInstructionExecution) error {
+	execution.UpdatedAt = time.Now().UTC()
+	_, err := r.db.NewUpdate().
+		Model(execution).
+		Column("status", "attempts", "next_attempt_at", "transaction_id", "last_error", "updated_at").
+		WherePK().
+		Exec(ctx)
+	return postgres.ResolveError(err)
+}
+
+func (r *BunStandingInstructionRepository) GetExecution(ctx context.Context, id uuid.UUID) (*models.StandingInstructionExecution, error) {
+	execution := &models.StandingInstructionExecution{}
+	err := r.db.NewSelect().Model(execution).Where("id = ?", id).Scan(ctx)
+	return execution, postgres.ResolveError(err)
+}
+
+func (r *BunStandingInstructionRepository) ListExecutions(ctx context.Context, filter StandingInstructionExecutionFilter) ([]models.StandingInstructionExecution, error) {
+	executions := make([]models.StandingInstructionExecution, 0)
+	q := r.db.NewSelect().Model(&executions)
+	if filter.InstructionID != nil {
+		q = q.Where("instruction_id = ?", *filter.InstructionID)
+	}
+	if len(filter.Statuses) > 0 {
+		q = q.Where("status in (?)", bun.In(filter.Statuses))
+	}
+	if filter.RetryBefore != nil {
+		q = q.Where("next_attempt_at is not null and next_attempt_at <= ?", *filter.RetryBefore)
+	}
+	q = q.OrderExpr("occurrence_at desc")
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		q = q.Offset(filter.Offset)
+	}
+	err := q.Scan(ctx)
+	return executions, postgres.ResolveError(err)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func setUUID(id *uuid.UUID) {
+	if *id == uuid.Nil {
+		*id = uuid.New()
+	}
+}
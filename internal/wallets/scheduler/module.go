@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/fx"
+)
+
+type DebitSagaRecoveryRunnerConfig struct {
+	Schedule cron.Schedule
+	// StaleAfter is how long a saga must have been left untouched before the
+	// runner considers it abandoned by the request that started it.
+	StaleAfter time.Duration
+	// MaxAttempts is the number of forward attempts after which a pending
+	// saga is compensated instead of retried.
+	MaxAttempts int
+}
+
+type LienExpiryRunnerConfig struct {
+	Schedule cron.Schedule
+}
+
+type StandingInstructionRunnerConfig struct {
+	Schedule cron.Schedule
+}
+
+type ModuleConfig struct {
+	DebitSagaRecoveryRunnerConfig   DebitSagaRecoveryRunnerConfig
+	LienExpiryRunnerConfig          LienExpiryRunnerConfig
+	StandingInstructionRunnerConfig StandingInstructionRunnerConfig
+}
+
+func NewFXModule(cfg ModuleConfig) fx.Option {
+	return fx.Options(
+		NewDebitSagaRecoveryRunnerModule(cfg.DebitSagaRecoveryRunnerConfig),
+		NewLienExpiryRunnerModule(cfg.LienExpiryRunnerConfig),
+		NewStandingInstructionRunnerModule(cfg.StandingInstructionRunnerConfig),
+	)
+}
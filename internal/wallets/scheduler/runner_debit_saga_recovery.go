@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+	"github.com/formancehq/ledger/internal/wallets/services"
+)
+
+type DebitSagaRecoveryRunner struct {
+	stopChannel chan chan struct{}
+	logger      logging.Logger
+	coordinator services.DebitSagaCoordinator
+	cfg         DebitSagaRecoveryRunnerConfig
+}
+
+func NewDebitSagaRecoveryRunner(
+	logger logging.Logger,
+	coordinator services.DebitSagaCoordinator,
+	cfg DebitSagaRecoveryRunnerConfig,
+) *DebitSagaRecoveryRunner {
+	return &DebitSagaRecoveryRunner{
+		stopChannel: make(chan chan struct{}),
+		logger:      logger,
+		coordinator: coordinator,
+		cfg:         cfg,
+	}
+}
+
+func (r *DebitSagaRecoveryRunner) Run(ctx context.Context) error {
+	now := time.Now()
+	next := r.cfg.Schedule.Next(now).Sub(now)
+
+	for {
+		select {
+		case <-time.After(next):
+			if err := r.run(ctx, time.Now().UTC()); err != nil {
+				r.logger.Errorf("error recovering wallet debit sagas: %v", err)
+			}
+
+			now = time.Now()
+			next = r.cfg.Schedule.Next(now).Sub(now)
+		case ch := <-r.stopChannel:
+			close(ch)
+			return nil
+		}
+	}
+}
+
+func (r *DebitSagaRecoveryRunner) Stop(ctx context.Context) error {
+	ch := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case r.stopChannel <- ch:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+	return nil
+}
+
+func (r *DebitSagaRecoveryRunner) run(ctx context.Context, when time.Time) error {
+	updatedBefore := when.Add(-r.cfg.StaleAfter)
+	sagas, err := r.coordinator.List(ctx, repositories.DebitSagaFilter{
+		Statuses:      []string{models.DebitSagaStatusPending, models.DebitSagaStatusCompensating},
+		UpdatedBefore: &updatedBefore,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, saga := range sagas {
+		if saga.Status == models.DebitSagaStatusPending && saga.Attempts < r.cfg.MaxAttempts {
+			if _, err := r.coordinator.Resume(ctx, saga.ID); err != nil {
+				r.logger.Errorf("resuming wallet debit saga %s: %v", saga.ID, err)
+			}
+			continue
+		}
+		if _, err := r.coordinator.Compensate(ctx, saga.ID); err != nil {
+			r.logger.Errorf("compensating wallet debit saga %s: %v", saga.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func NewDebitSagaRecoveryRunnerModule(cfg DebitSagaRecoveryRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			coordinator services.DebitSagaCoordinator,
+		) *DebitSagaRecoveryRunner {
+			return NewDebitSagaRecoveryRunner(logger, coordinator, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, runner *DebitSagaRecoveryRunner) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go func() {
+						if err := runner.Run(context.WithoutCancel(ctx)); err != nil {
+							panic(err)
+						}
+					}()
+					return nil
+				},
+				OnStop: runner.Stop,
+			})
+		}),
+	)
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+	"github.com/formancehq/ledger/internal/wallets/services"
+)
+
+type LienExpiryRunner struct {
+	stopChannel chan chan struct{}
+	logger      logging.Logger
+	lienService services.LienService
+	cfg         LienExpiryRunnerConfig
+}
+
+func NewLienExpiryRunner(
+	logger logging.Logger,
+	lienService services.LienService,
+	cfg LienExpiryRunnerConfig,
+) *LienExpiryRunner {
+	return &LienExpiryRunner{
+		stopChannel: make(chan chan struct{}),
+		logger:      logger,
+		lienService: lienService,
+		cfg:         cfg,
+	}
+}
+
+func (r *LienExpiryRunner) Run(ctx context.Context) error {
+	now := time.Now()
+	next := r.cfg.Schedule.Next(now).Sub(now)
+
+	for {
+		select {
+		case <-time.After(next):
+			if err := r.run(ctx, time.Now().UTC()); err != nil {
+				r.logger.Errorf("error expiring wallet liens: %v", err)
+			}
+
+			now = time.Now()
+			next = r.cfg.Schedule.Next(now).Sub(now)
+		case ch := <-r.stopChannel:
+			close(ch)
+			return nil
+		}
+	}
+}
+
+func (r *LienExpiryRunner) Stop(ctx context.Context) error {
+	ch := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case r.stopChannel <- ch:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+	return nil
+}
+
+// lienSettleDelay leaves the requests still settling their lien movement
+// the time to do it before the runner does.
+const lienSettleDelay = time.Minute
+
+func (r *LienExpiryRunner) run(ctx context.Context, when time.Time) error {
+	// Movements left pending by a crash or a ledger failure are settled
+	// first, which also expires the liens due to be expired meanwhile.
+	settleBefore := when.Add(-lienSettleDelay)
+	pending, err := r.lienService.List(ctx, repositories.LienFilter{
+		Pending:       true,
+		UpdatedBefore: &settleBefore,
+	})
+	if err != nil {
+		return err
+	}
+	for _, lien := range pending {
+		if _, err := r.lienService.Settle(ctx, lien.ID); err != nil {
+			r.logger.Errorf("settling wallet lien %s: %v", lien.ID, err)
+		}
+	}
+
+	liens, err := r.lienService.List(ctx, repositories.LienFilter{
+		Statuses:      []string{models.LienStatusActive},
+		ExpiresBefore: &when,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, lien := range liens {
+		if _, err := r.lienService.Expire(ctx, lien.ID, when); err != nil {
+			r.logger.Errorf("expiring wallet lien %s: %v", lien.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func NewLienExpiryRunnerModule(cfg LienExpiryRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			lienService services.LienService,
+		) *LienExpiryRunner {
+			return NewLienExpiryRunner(logger, lienService, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, runner *LienExpiryRunner) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go func() {
+						if err := runner.Run(context.WithoutCancel(ctx)); err != nil {
+							panic(err)
+						}
+					}()
+					return nil
+				},
+				OnStop: runner.Stop,
+			})
+		}),
+	)
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	cbascheduler "github.com/formancehq/ledger/internal/cba/scheduler"
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+	"github.com/formancehq/ledger/internal/wallets/services"
+)
+
+// StandingInstructionRunner executes the due standing instructions. It only
+// runs on the worker holding the scheduler lease, so that two workers never
+// run the same instruction at once.
+type StandingInstructionRunner struct {
+	stopChannel                chan chan struct{}
+	logger                     logging.Logger
+	standingInstructionService services.StandingInstructionService
+	elector                    *cbascheduler.LeaderElector
+	cfg                        StandingInstructionRunnerConfig
+}
+
+func NewStandingInstructionRunner(
+	logger logging.Logger,
+	standingInstructionService services.StandingInstructionService,
+	elector *cbascheduler.LeaderElector,
+	cfg StandingInstructionRunnerConfig,
+) *StandingInstructionRunner {
+	return &StandingInstructionRunner{
+		stopChannel:                make(chan chan struct{}),
+		logger:                     logger,
+		standingInstructionService: standingInstructionService,
+		elector:                    elector,
+		cfg:                        cfg,
+	}
+}
+
+func (r *StandingInstructionRunner) Run(ctx context.Context) error {
+	now := time.Now()
+	next := r.cfg.Schedule.Next(now).Sub(now)
+
+	for {
+		select {
+		case <-time.After(next):
+			if r.elector.IsLeader() {
+				runCtx, cancel := r.elector.WithLeadership(ctx)
+				if err := r.run(runCtx, time.Now().UTC()); err != nil {
+					r.logger.Errorf("error running standing instructions: %v", err)
+				}
+				cancel()
+			}
+
+			now = time.Now()
+			next = r.cfg.Schedule.Next(now).Sub(now)
+		case ch := <-r.stopChannel:
+			close(ch)
+			return nil
+		}
+	}
+}
+
+func (r *StandingInstructionRunner) Stop(ctx context.Context) error {
+	ch := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case r.stopChannel <- ch:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ch:
+		}
+	}
+	return nil
+}
+
+// run retries the failed executions whose retry is due, then executes the
+// occurrences due since the last run.
+func (r *StandingInstructionRunner) run(ctx context.Context, when time.Time) error {
+	executions, err := r.standingInstructionService.ListExecutions(ctx, repositories.StandingInstructionExecutionFilter{
+		Statuses:    []string{models.StandingInstructionExecutionRetrying},
+		RetryBefore: &when,
+	})
+	if err != nil {
+		return err
+	}
+	for _, execution := range executions {
+		if _, err := r.standingInstructionService.Retry(ctx, execution.ID, when); err != nil {
+			r.logger.Errorf("retrying standing instruction execution %s: %v", execution.ID, err)
+		}
+	}
+
+	instructions, err := r.standingInstructionService.List(ctx, repositories.StandingInstructionFilter{
+		Statuses:  []string{models.StandingInstructionStatusActive},
+		DueBefore: &when,
+	})
+	if err != nil {
+		return err
+	}
+	for _, instruction := range instructions {
+		if _, err := r.standingInstructionService.RunDue(ctx, instruction.ID, when); err != nil {
+			r.logger.Errorf("running standing instruction %s: %v", instruction.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func NewStandingInstructionRunnerModule(cfg StandingInstructionRunnerConfig) fx.Option {
+	return fx.Options(
+		fx.Provide(func(
+			logger logging.Logger,
+			standingInstructionService services.StandingInstructionService,
+			elector *cbascheduler.LeaderElector,
+		) *StandingInstructionRunner {
+			return NewStandingInstructionRunner(logger, standingInstructionService, elector, cfg)
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, runner *StandingInstructionRunner) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					go func() {
+						if err := runner.Run(context.WithoutCancel(ctx)); err != nil {
+							panic(err)
+						}
+					}()
+					return nil
+				},
+				OnStop: runner.Stop,
+			})
+		}),
+	)
+}
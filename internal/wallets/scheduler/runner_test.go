@@ -0,0 +1,246 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/logging"
+
+	cbascheduler "github.com/formancehq/ledger/internal/cba/scheduler"
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+	"github.com/formancehq/ledger/internal/wallets/services"
+)
+
+func TestDebitSagaRecoveryRunnerResumesOrCompensates(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	retryable := uuid.New()
+	exhausted := uuid.New()
+	compensating := uuid.New()
+
+	var resumed, compensated []uuid.UUID
+	coordinator := &debitSagaCoordinatorStub{
+		listFunc: func(_ context.Context, filter repositories.DebitSagaFilter) ([]models.DebitSaga, error) {
+			require.ElementsMatch(t, []string{models.DebitSagaStatusPending, models.DebitSagaStatusCompensating}, filter.Statuses)
+			require.NotNil(t, filter.UpdatedBefore)
+			require.Equal(t, now.Add(-time.Minute), *filter.UpdatedBefore)
+			return []models.DebitSaga{
+				{ID: retryable, Status: models.DebitSagaStatusPending, Attempts: 1},
+				{ID: exhausted, Status: models.DebitSagaStatusPending, Attempts: 3},
+				{ID: compensating, Status: models.DebitSagaStatusCompensating, Attempts: 1},
+			}, nil
+		},
+		resumeFunc: func(_ context.Context, id uuid.UUID) (*services.DebitSagaResult, error) {
+			resumed = append(resumed, id)
+			return &services.DebitSagaResult{}, nil
+		},
+		compensateFunc: func(_ context.Context, id uuid.UUID) (*models.DebitSaga, error) {
+			compensated = append(compensated, id)
+			return &models.DebitSaga{}, nil
+		},
+	}
+
+	runner := NewDebitSagaRecoveryRunner(logging.Testing(), coordinator, DebitSagaRecoveryRunnerConfig{
+		Schedule:    cron.Every(time.Minute),
+		StaleAfter:  time.Minute,
+		MaxAttempts: 3,
+	})
+
+	require.NoError(t, runner.run(context.Background(), now))
+	require.Equal(t, []uuid.UUID{retryable}, resumed)
+	require.Equal(t, []uuid.UUID{exhausted, compensating}, compensated)
+}
+
+func TestLienExpiryRunnerExpiresDueLiens(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	first := uuid.New()
+	second := uuid.New()
+
+	pending := uuid.New()
+
+	var expired, settled []uuid.UUID
+	lienService := &lienServiceStub{
+		listFunc: func(_ context.Context, filter repositories.LienFilter) ([]models.Lien, error) {
+			if filter.Pending {
+				require.NotNil(t, filter.UpdatedBefore)
+				require.Equal(t, now.Add(-lienSettleDelay), *filter.UpdatedBefore)
+				return []models.Lien{{ID: pending}}, nil
+			}
+			require.Equal(t, []string{models.LienStatusActive}, filter.Statuses)
+			require.NotNil(t, filter.ExpiresBefore)
+			require.Equal(t, now, *filter.ExpiresBefore)
+			return []models.Lien{{ID: first}, {ID: second}}, nil
+		},
+		expireFunc: func(_ context.Context, id uuid.UUID, when time.Time) (*services.LienResult, error) {
+			require.Equal(t, now, when)
+			expired = append(expired, id)
+			if id == first {
+				return nil, services.ErrLienInvalidState
+			}
+			return &services.LienResult{}, nil
+		},
+		settleFunc: func(_ context.Context, id uuid.UUID) (*services.LienResult, error) {
+			settled = append(settled, id)
+			return &services.LienResult{}, nil
+		},
+	}
+
+	runner := NewLienExpiryRunner(logging.Testing(), lienService, LienExpiryRunnerConfig{
+		Schedule: cron.Every(time.Minute),
+	})
+
+	require.NoError(t, runner.run(context.Background(), now))
+	require.Equal(t, []uuid.UUID{pending}, settled)
+	require.Equal(t, []uuid.UUID{first, second}, expired)
+}
+
+func TestStandingInstructionRunnerRetriesThenRunsDueInstructions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	execution := uuid.New()
+	first := uuid.New()
+	second := uuid.New()
+
+	var calls []string
+	standingInstructionService := &standingInstructionServiceStub{
+		listExecutionsFunc: func(_ context.Context, filter repositories.StandingInstructionExecutionFilter) ([]models.StandingInstructionExecution, error) {
+			require.Equal(t, []string{models.StandingInstructionExecutionRetrying}, filter.Statuses)
+			require.NotNil(t, filter.RetryBefore)
+			require.Equal(t, now, *filter.RetryBefore)
+			return []models.StandingInstructionExecution{{ID: execution}}, nil
+		},
+		retryFunc: func(_ context.Context, id uuid.UUID, when time.Time) (*models.StandingInstructionExecution, error) {
+			require.Equal(t, now, when)
+			calls = append(calls, "retry "+id.String())
+			return &models.StandingInstructionExecution{}, nil
+		},
+		listFunc: func(_ context.Context, filter repositories.StandingInstructionFilter) ([]models.StandingInstruction, error) {
+			require.Equal(t, []string{models.StandingInstructionStatusActive}, filter.Statuses)
+			require.NotNil(t, filter.DueBefore)
+			require.Equal(t, now, *filter.DueBefore)
+			return []models.StandingInstruction{{ID: first}, {ID: second}}, nil
+		},
+		runDueFunc: func(_ context.Context, id uuid.UUID, when time.Time) (*models.StandingInstruction, error) {
+			require.Equal(t, now, when)
+			calls = append(calls, "run "+id.String())
+			if id == first {
+				return nil, services.ErrStandingInstructionValidation
+			}
+			return &models.StandingInstruction{}, nil
+		},
+	}
+
+	elector := cbascheduler.NewLeaderElector(logging.Testing(), nil, cbascheduler.LeaderElectionConfig{})
+	runner := NewStandingInstructionRunner(logging.Testing(), standingInstructionService, elector, StandingInstructionRunnerConfig{
+		Schedule: cron.Every(time.Minute),
+	})
+
+	require.NoError(t, runner.run(context.Background(), now))
+	require.Equal(t, []string{
+		"retry " + execution.String(),
+		"run " + first.String(),
+		"run " + second.String(),
+	}, calls)
+}
+
+type debitSagaCoordinatorStub struct {
+	listFunc       func(context.Context, repositories.DebitSagaFilter) ([]models.DebitSaga, error)
+	resumeFunc     func(context.Context, uuid.UUID) (*services.DebitSagaResult, error)
+	compensateFunc func(context.Context, uuid.UUID) (*models.DebitSaga, error)
+}
+
+func (s *debitSagaCoordinatorStub) Run(context.Context, services.DebitSagaPlan) (*services.DebitSagaResult, error) {
+	return nil, nil
+}
+func (s *debitSagaCoordinatorStub) Resume(ctx context.Context, id uuid.UUID) (*services.DebitSagaResult, error) {
+	if s.resumeFunc != nil {
+		return s.resumeFunc(ctx, id)
+	}
+	return nil, nil
+}
+func (s *debitSagaCoordinatorStub) Compensate(ctx context.Context, id uuid.UUID) (*models.DebitSaga, error) {
+	if s.compensateFunc != nil {
+		return s.compensateFunc(ctx, id)
+	}
+	return nil, nil
+}
+func (s *debitSagaCoordinatorStub) Get(context.Context, uuid.UUID) (*models.DebitSaga, error) {
+	return nil, nil
+}
+func (s *debitSagaCoordinatorStub) List(ctx context.Context, filter repositories.DebitSagaFilter) ([]models.DebitSaga, error) {
+	if s.listFunc != nil {
+		return s.listFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
+type lienServiceStub struct {
+	listFunc   func(context.Context, repositories.LienFilter) ([]models.Lien, error)
+	expireFunc func(context.Context, uuid.UUID, time.Time) (*services.LienResult, error)
+	settleFunc func(context.Context, uuid.UUID) (*services.LienResult, error)
+}
+
+func (s *lienServiceStub) Place(context.Context, services.PlaceLienInput) (*services.LienResult, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) TopUp(context.Context, uuid.UUID, services.LienMovementInput) (*services.LienResult, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) Capture(context.Context, uuid.UUID, services.CaptureLienInput) (*services.LienResult, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) Release(context.Context, uuid.UUID, services.LienMovementInput) (*services.LienResult, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) Expire(ctx context.Context, id uuid.UUID, now time.Time) (*services.LienResult, error) {
+	if s.expireFunc != nil {
+		return s.expireFunc(ctx, id, now)
+	}
+	return nil, nil
+}
+func (s *lienServiceStub) Settle(ctx context.Context, id uuid.UUID) (*services.LienResult, error) {
+	if s.settleFunc != nil {
+		return s.settleFunc(ctx, id)
+	}
+	return nil, nil
+}
+func (s *lienServiceStub) Get(context.Context, uuid.UUID) (*models.Lien, error) {
+	return nil, nil
+}
+func (s *lienServiceStub) List(ctx context.Context, filter repositories.LienFilter) ([]models.Lien, error) {
+	if s.listFunc != nil {
+		return s.listFunc(ctx, filter)
+	}
+	return nil, nil
+}
+
+type standingInstructionServiceStub struct {
+	services.StandingInstructionService
+	listFunc           func(context.Context, repositories.StandingInstructionFilter) ([]models.StandingInstruction, error)
+	listExecutionsFunc func(context.Context, repositories.StandingInstructionExecutionFilter) ([]models.StandingInstructionExecution, error)
+	runDueFunc         func(context.Context, uuid.UUID, time.Time) (*models.StandingInstruction, error)
+	retryFunc          func(context.Context, uuid.UUID, time.Time) (*models.StandingInstructionExecution, error)
+}
+
+func (s *standingInstructionServiceStub) List(ctx context.Context, filter repositories.StandingInstructionFilter) ([]models.StandingInstruction, error) {
+	return s.listFunc(ctx, filter)
+}
+func (s *standingInstructionServiceStub) ListExecutions(ctx context.Context, filter repositories.StandingInstructionExecutionFilter) ([]models.StandingInstructionExecution, error) {
+	return s.listExecutionsFunc(ctx, filter)
+}
+func (s *standingInstructionServiceStub) RunDue(ctx context.Context, id uuid.UUID, when time.Time) (*models.StandingInstruction, error) {
+	return s.runDueFunc(ctx, id, when)
+}
+func (s *standingInstructionServiceStub) Retry(ctx context.Context, id uuid.UUID, when time.Time) (*models.StandingInstructionExecution, error) {
+	return s.retryFunc(ctx, id, when)
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/formancehq/go-libs/v3/bun/bunpaginate"
+	"github.com/formancehq/go-libs/v3/metadata"
+	"github.com/formancehq/go-libs/v3/query"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/machine/vm"
+	storagecommon "github.com/formancehq/ledger/internal/storage/common"
+	ledgerstore "github.com/formancehq/ledger/internal/storage/ledger"
+	systemstore "github.com/formancehq/ledger/internal/storage/system"
+)
+
+var (
+	ErrCurrencyRebaseValidation = errors.New("currency rebase validation failed")
+	ErrCurrencyRebaseIncomplete = errors.New("currency rebase incomplete")
+)
+
+const (
+	// CurrencyRebaseModeRescale keeps the value of every balance: 12345 in
+	// JPY/2 (123.45 yen) becomes 123 in JPY/0. Sub-unit remainders stay on
+	// the rounding account.
+	CurrencyRebaseModeRescale = "rescale"
+	// CurrencyRebaseModeRelabel keeps the integer amount of every balance and
+	// only moves it to the registered asset. Use it when the amounts were
+	// already posted in the registered precision under the wrong asset.
+	CurrencyRebaseModeRelabel = "relabel"
+
+	currencyRebasePageSize  = 100
+	currencyRebaseMaxPasses = 5
+)
+
+type CurrencyRebaseInput struct {
+	Currency      string
+	FromPrecision int
+	// ToPrecision defaults to the registered precision. Setting it allows
+	// rebasing balances before the registry is switched to that precision.
+	ToPrecision *int
+	Mode        string
+	DryRun      bool
+}
+
+type CurrencyRebaseEntry struct {
+	Account       string   `json:"account"`
+	FromAmount    *big.Int `json:"fromAmount"`
+	ToAmount      *big.Int `json:"toAmount"`
+	TransactionID *uint64  `json:"transactionID,omitempty"`
+
+	// volumes identify the state of the account the entry was computed from.
+	volumes string
+}
+
+type CurrencyRebaseResult struct {
+	Currency        string                `json:"currency"`
+	FromAsset       string                `json:"fromAsset"`
+	ToAsset         string                `json:"toAsset"`
+	Mode            string                `json:"mode"`
+	DryRun          bool                  `json:"dryRun"`
+	RoundingAccount string                `json:"roundingAccount"`
+	Entries         []CurrencyRebaseEntry `json:"entries"`
+}
+
+// RebaseCurrency moves every non-zero balance held in the legacy asset of a
+// currency to the asset of the target precision, one transaction per
+// account. Each transaction moves the balance read just before, so postings
+// made meanwhile would leave a residue or fail: balances are read again
+// after every pass and the rebase only returns once none is left, up to
+// currencyRebaseMaxPasses passes. Transactions carry an idempotency key
+// derived from the account volumes, so an interrupted rebase can simply be
+// run again.
+func RebaseCurrency(ctx context.Context, l ledgercontroller.Controller, input CurrencyRebaseInput) (*CurrencyRebaseResult, error) {
+	code := strings.ToUpper(strings.TrimSpace(input.Currency))
+	definition, ok := currencyregistry.Lookup(code)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown currency %s", ErrCurrencyRebaseValidation, code)
+	}
+	if input.FromPrecision < 0 {
+		return nil, fmt.Errorf("%w: fromPrecision must not be negative", ErrCurrencyRebaseValidation)
+	}
+	toPrecision := definition.Precision
+	if input.ToPrecision != nil {
+		toPrecision = *input.ToPrecision
+		if toPrecision < 0 || toPrecision > currencyregistry.MaxPrecision {
+			return nil, fmt.Errorf("%w: toPrecision must be between 0 and %d", ErrCurrencyRebaseValidation, currencyregistry.MaxPrecision)
+		}
+	}
+	if toPrecision == input.FromPrecision {
+		return nil, fmt.Errorf("%w: %s balances are already in precision %d", ErrCurrencyRebaseValidation, code, toPrecision)
+	}
+	mode := input.Mode
+	if mode == "" {
+		mode = CurrencyRebaseModeRescale
+	}
+	if mode != CurrencyRebaseModeRescale && mode != CurrencyRebaseModeRelabel {
+		return nil, fmt.Errorf("%w: unsupported mode %q", ErrCurrencyRebaseValidation, input.Mode)
+	}
+
+	result := &CurrencyRebaseResult{
+		Currency:        code,
+		FromAsset:       currencyregistry.AssetWithPrecision(code, input.FromPrecision),
+		ToAsset:         currencyregistry.AssetWithPrecision(code, toPrecision),
+		Mode:            mode,
+		DryRun:          input.DryRun,
+		RoundingAccount: currencyRebaseRoundingAccount(code),
+		Entries:         []CurrencyRebaseEntry{},
+	}
+
+	if input.DryRun {
+		entries, err := collectCurrencyRebaseEntries(ctx, l, result, input.FromPrecision, toPrecision)
+		if err != nil {
+			return nil, err
+		}
+		result.Entries = entries
+		return result, nil
+	}
+
+	for range currencyRebaseMaxPasses {
+		entries, err := collectCurrencyRebaseEntries(ctx, l, result, input.FromPrecision, toPrecision)
+		if err != nil {
+			return result, err
+		}
+		if len(entries) == 0 {
+			return result, nil
+		}
+		for _, entry := range entries {
+			_, created, _, err := l.CreateTransaction(ctx, ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
+				IdempotencyKey: fmt.Sprintf("currency-rebase:%s:%s:%s:%s", result.FromAsset, result.ToAsset, entry.Account, entry.volumes),
+				Input: ledgercontroller.CreateTransaction{
+					RunScript: vm.RunScript{
+						Script: vm.Script{Plain: currencyRebaseScript(result, entry)},
+						Metadata: metadata.Metadata{
+							"currency_rebase_from": result.FromAsset,
+							"currency_rebase_to":   result.ToAsset,
+							"currency_rebase_mode": result.Mode,
+						},
+					},
+					Runtime: ledgerinternal.RuntimeMachine,
+				},
+			})
+			if err != nil {
+				// The balance was spent since it was read, the next pass
+				// moves what is left of it.
+				if errors.Is(err, &ledgercontroller.ErrInsufficientFunds{}) {
+					continue
+				}
+				return result, fmt.Errorf("rebasing %s: %w", entry.Account, err)
+			}
+			entry.TransactionID = created.Transaction.ID
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+
+	return result, fmt.Errorf("%w: %s balances kept changing after %d passes, run the rebase again", ErrCurrencyRebaseIncomplete, result.FromAsset, currencyRebaseMaxPasses)
+}
+
+// collectCurrencyRebaseEntries reads every balance before posting anything:
+// each rebase adds a volume row, which would shift the offsets of the pages
+// still to read.
+func collectCurrencyRebaseEntries(ctx context.Context, l ledgercontroller.Controller, result *CurrencyRebaseResult, fromPrecision, toPrecision int) ([]CurrencyRebaseEntry, error) {
+	entries := make([]CurrencyRebaseEntry, 0)
+	var offset uint64
+	order := bunpaginate.Order(bunpaginate.OrderAsc)
+	for {
+		cursor, err := l.GetVolumesWithBalances(ctx, storagecommon.OffsetPaginatedQuery[ledgerstore.GetVolumesOptions]{
+			InitialPaginatedQuery: storagecommon.InitialPaginatedQuery[ledgerstore.GetVolumesOptions]{
+				Column:   "account",
+				Order:    &order,
+				PageSize: currencyRebasePageSize,
+			},
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range cursor.Data {
+			if row.Asset != result.FromAsset || row.Account == result.RoundingAccount {
+				continue
+			}
+			if row.Balance == nil || row.Balance.Sign() == 0 {
+				continue
+			}
+			entries = append(entries, CurrencyRebaseEntry{
+				Account:    row.Account,
+				FromAmount: new(big.Int).Set(row.Balance),
+				ToAmount:   rebaseAmount(row.Balance, fromPrecision, toPrecision, result.Mode),
+				volumes:    fmt.Sprintf("%s:%s", row.Input, row.Output),
+			})
+		}
+		if !cursor.HasMore {
+			return entries, nil
+		}
+		offset += uint64(len(cursor.Data))
+	}
+}
+
+// CurrencyBalanceChecker looks for balances in every ledger, so that the
+// registry only switches the precision of a currency once they were rebased.
+type CurrencyBalanceChecker struct {
+	system systemcontroller.Controller
+}
+
+func NewCurrencyBalanceChecker(system systemcontroller.Controller) *CurrencyBalanceChecker {
+	return &CurrencyBalanceChecker{system: system}
+}
+
+func (c *CurrencyBalanceChecker) LedgersHolding(ctx context.Context, asset string) ([]string, error) {
+	code, _, _ := strings.Cut(asset, "/")
+	ledgers := make([]string, 0)
+	err := storagecommon.Iterate(ctx, storagecommon.InitialPaginatedQuery[systemstore.ListLedgersQueryPayload]{
+		PageSize: currencyRebasePageSize,
+	},
+		c.system.ListLedgers,
+		func(cursor *bunpaginate.Cursor[ledgerinternal.Ledger]) error {
+			for _, ledger := range cursor.Data {
+				l, err := c.system.GetLedgerController(ctx, ledger.Name)
+				if err != nil {
+					return err
+				}
+				balances, err := l.GetVolumesWithBalances(ctx, storagecommon.OffsetPaginatedQuery[ledgerstore.GetVolumesOptions]{
+					InitialPaginatedQuery: storagecommon.InitialPaginatedQuery[ledgerstore.GetVolumesOptions]{
+						PageSize: 1,
+						Options: storagecommon.ResourceQuery[ledgerstore.GetVolumesOptions]{
+							Builder: query.And(
+								query.Or(query.Gt("balance["+asset+"]", 0), query.Lt("balance["+asset+"]", 0)),
+								query.Not(query.Match("address", currencyRebaseRoundingAccount(code))),
+							),
+						},
+					},
+				})
+				if err != nil {
+					return fmt.Errorf("reading balances of ledger %s: %w", ledger.Name, err)
+				}
+				if len(balances.Data) > 0 {
+					ledgers = append(ledgers, ledger.Name)
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return ledgers, nil
+}
+
+// currencyRebaseRoundingAccount keeps what a rebase to a lower precision
+// cannot represent.
+func currencyRebaseRoundingAccount(code string) string {
+	return fmt.Sprintf("system:rebase:%s", code)
+}
+
+// rebaseAmount converts amount between precisions, truncating toward zero
+// when the target precision is lower.
+func rebaseAmount(amount *big.Int, from, to int, mode string) *big.Int {
+	ret := new(big.Int).Set(amount)
+	if mode == CurrencyRebaseModeRelabel {
+		return ret
+	}
+	if to > from {
+		return ret.Mul(ret, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(to-from)), nil))
+	}
+	return ret.Quo(ret, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(from-to)), nil))
+}
+
+// currencyRebaseScript swaps the legacy balance of the account for its
+// rebased amount, using the rounding account as counterparty. Negative
+// balances (control or world accounts) are swapped in the other direction.
+func currencyRebaseScript(result *CurrencyRebaseResult, entry CurrencyRebaseEntry) string {
+	from, to := entry.FromAmount, entry.ToAmount
+	if from.Sign() > 0 {
+		return fmt.Sprintf(`
+		send [%s %s] (
+			source = @%s
+			destination = @%s
+		)
+		send [%s %s] (
+			source = @%s allowing unbounded overdraft
+			destination = @%s
+		)
+	`, result.FromAsset, from, entry.Account, result.RoundingAccount,
+			result.ToAsset, to, result.RoundingAccount, entry.Account)
+	}
+
+	return fmt.Sprintf(`
+		send [%s %s] (
+			source = @%s allowing unbounded overdraft
+			destination = @%s
+		)
+		send [%s %s] (
+			source = @%s allowing unbounded overdraft
+			destination = @%s
+		)
+	`, result.FromAsset, new(big.Int).Neg(from), result.RoundingAccount, entry.Account,
+		result.ToAsset, new(big.Int).Neg(to), entry.Account, result.RoundingAccount)
+}
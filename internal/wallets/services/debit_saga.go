@@ -0,0 +1,521 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/metadata"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	"github.com/formancehq/ledger/internal/machine/vm"
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+)
+
+var (
+	ErrDebitSagaValidation   = errors.New("debit saga validation failed")
+	ErrDebitSagaNotFound     = errors.New("debit saga not found")
+	ErrDebitSagaConflict     = errors.New("debit saga already exists for reference")
+	ErrDebitSagaInProgress   = errors.New("debit saga is still in progress")
+	ErrDebitSagaInvalidState = errors.New("debit saga is not in a valid state for this operation")
+	ErrDebitSagaCompensated  = errors.New("debit saga failed and was compensated")
+	ErrDebitSagaUnreconciled = errors.New("debit saga failed and could not be fully compensated")
+)
+
+const debitSagaMetadataKey = "wallet_saga_id"
+
+// DebitSagaCoordinator posts the legs of a wallet debit across the wallet,
+// channel and revenue ledgers. Either every leg ends up posted, or every
+// posted leg is reverted.
+type DebitSagaCoordinator interface {
+	Run(context.Context, DebitSagaPlan) (*DebitSagaResult, error)
+	Resume(context.Context, uuid.UUID) (*DebitSagaResult, error)
+	Compensate(context.Context, uuid.UUID) (*models.DebitSaga, error)
+	Get(context.Context, uuid.UUID) (*models.DebitSaga, error)
+	List(context.Context, repositories.DebitSagaFilter) ([]models.DebitSaga, error)
+}
+
+type DebitSagaPlan struct {
+	WalletID  string
+	Currency  string
+	Operation string
+	Reference string
+	Metadata  map[string]any
+	Legs      []DebitSagaLegPlan
+}
+
+// DebitSagaLegPlan describes one ledger transaction of the saga. An empty
+// idempotency key is replaced by one derived from the saga and leg name.
+type DebitSagaLegPlan struct {
+	Name           string
+	Ledger         string
+	Reference      string
+	IdempotencyKey string
+	Script         string
+	Metadata       map[string]string
+}
+
+type DebitSagaResult struct {
+	Saga         *models.DebitSaga
+	Transactions map[string]ledgerinternal.Transaction
+}
+
+type DefaultDebitSagaCoordinator struct {
+	system     systemcontroller.Controller
+	repository repositories.DebitSagaRepository
+}
+
+// NewDebitSagaCoordinator builds a coordinator journaling into repository.
+// A nil repository disables the journal: failed legs are still compensated
+// in-line, but a crash between two legs cannot be recovered.
+func NewDebitSagaCoordinator(
+	system systemcontroller.Controller,
+	repository repositories.DebitSagaRepository,
+) DebitSagaCoordinator {
+	if repository == nil {
+		repository = nopDebitSagaRepository{}
+	}
+	return &DefaultDebitSagaCoordinator{
+		system:     system,
+		repository: repository,
+	}
+}
+
+func (c *DefaultDebitSagaCoordinator) Run(ctx context.Context, plan DebitSagaPlan) (*DebitSagaResult, error) {
+	saga, err := c.begin(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	if saga.Status == models.DebitSagaStatusCompleted {
+		// The caller retried with the same idempotency key: every leg is
+		// replayed from the ledgers without posting anything new.
+		return c.advance(ctx, saga)
+	}
+
+	result, err := c.advance(ctx, saga)
+	if err == nil {
+		return result, nil
+	}
+
+	failed := firstFailedLeg(saga)
+	// The leg may have failed because the caller went away, the compensation
+	// must go through all the same.
+	if compensateErr := c.compensate(context.WithoutCancel(ctx), saga); compensateErr != nil {
+		return nil, fmt.Errorf("%w: saga %s: %w", ErrDebitSagaUnreconciled, saga.ID, errors.Join(err, compensateErr))
+	}
+	if failed == nil || failed.Sequence == 0 {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %s leg: %w", ErrDebitSagaCompensated, failed.Name, err)
+}
+
+func (c *DefaultDebitSagaCoordinator) Resume(ctx context.Context, id uuid.UUID) (*DebitSagaResult, error) {
+	saga, err := c.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if saga.Status != models.DebitSagaStatusPending {
+		return nil, fmt.Errorf("%w: saga %s is %s", ErrDebitSagaInvalidState, saga.ID, saga.Status)
+	}
+	return c.advance(ctx, saga)
+}
+
+func (c *DefaultDebitSagaCoordinator) Compensate(ctx context.Context, id uuid.UUID) (*models.DebitSaga, error) {
+	saga, err := c.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if saga.Status != models.DebitSagaStatusPending && saga.Status != models.DebitSagaStatusCompensating {
+		return nil, fmt.Errorf("%w: saga %s is %s", ErrDebitSagaInvalidState, saga.ID, saga.Status)
+	}
+	if err := c.compensate(ctx, saga); err != nil {
+		return saga, err
+	}
+	return saga, nil
+}
+
+func (c *DefaultDebitSagaCoordinator) Get(ctx context.Context, id uuid.UUID) (*models.DebitSaga, error) {
+	saga, err := c.repository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveDebitSagaRepositoryError(err)
+	}
+	return saga, nil
+}
+
+func (c *DefaultDebitSagaCoordinator) List(ctx context.Context, filter repositories.DebitSagaFilter) ([]models.DebitSaga, error) {
+	return c.repository.List(ctx, filter)
+}
+
+func (c *DefaultDebitSagaCoordinator) begin(ctx context.Context, plan DebitSagaPlan) (*models.DebitSaga, error) {
+	if err := validateDebitSagaPlan(plan); err != nil {
+		return nil, err
+	}
+
+	saga := &models.DebitSaga{
+		ID:        uuid.New(),
+		Ledger:    plan.Legs[0].Ledger,
+		WalletID:  plan.WalletID,
+		Currency:  plan.Currency,
+		Operation: plan.Operation,
+		Reference: plan.Reference,
+		Status:    models.DebitSagaStatusPending,
+		Metadata:  plan.Metadata,
+		Legs:      make([]models.DebitSagaLeg, 0, len(plan.Legs)),
+	}
+	if saga.Metadata == nil {
+		saga.Metadata = map[string]any{}
+	}
+	for i, legPlan := range plan.Legs {
+		leg := models.DebitSagaLeg{
+			Sequence:       i,
+			Name:           legPlan.Name,
+			Ledger:         legPlan.Ledger,
+			Reference:      legPlan.Reference,
+			IdempotencyKey: legPlan.IdempotencyKey,
+			Script:         legPlan.Script,
+			Metadata:       map[string]string{},
+			Status:         models.DebitSagaLegStatusPending,
+		}
+		if leg.IdempotencyKey == "" {
+			leg.IdempotencyKey = fmt.Sprintf("wallet-saga:%s:%s", saga.ID, leg.Name)
+		}
+		for k, v := range legPlan.Metadata {
+			leg.Metadata[k] = v
+		}
+		leg.Metadata[debitSagaMetadataKey] = saga.ID.String()
+		saga.Legs = append(saga.Legs, leg)
+	}
+
+	err := c.repository.Create(ctx, saga)
+	if err == nil {
+		return saga, nil
+	}
+	if !errors.Is(err, postgres.ErrConstraintsFailed{}) {
+		return nil, err
+	}
+
+	existing, lookupErr := c.repository.GetByReference(ctx, saga.Ledger, saga.Reference)
+	if lookupErr != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDebitSagaConflict, saga.Reference)
+	}
+	idempotencyKey := plan.Legs[0].IdempotencyKey
+	if idempotencyKey == "" || len(existing.Legs) == 0 || existing.Legs[0].IdempotencyKey != idempotencyKey || !sameLegs(existing, plan) {
+		return nil, fmt.Errorf("%w: %s", ErrDebitSagaConflict, saga.Reference)
+	}
+	switch existing.Status {
+	case models.DebitSagaStatusCompleted:
+		return existing, nil
+	case models.DebitSagaStatusPending, models.DebitSagaStatusCompensating:
+		return nil, fmt.Errorf("%w: saga %s", ErrDebitSagaInProgress, existing.ID)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrDebitSagaConflict, saga.Reference)
+	}
+}
+
+// advance posts every leg in order. Legs already posted are replayed with
+// their idempotency key, which returns the original transaction.
+func (c *DefaultDebitSagaCoordinator) advance(ctx context.Context, saga *models.DebitSaga) (*DebitSagaResult, error) {
+	result := &DebitSagaResult{
+		Saga:         saga,
+		Transactions: map[string]ledgerinternal.Transaction{},
+	}
+
+	if saga.Status == models.DebitSagaStatusPending {
+		saga.Attempts++
+	}
+	for i := range saga.Legs {
+		leg := &saga.Legs[i]
+		switch leg.Status {
+		case models.DebitSagaLegStatusPosted:
+		case models.DebitSagaLegStatusPending, models.DebitSagaLegStatusFailed:
+			// Record the attempt before calling the ledger so that a crash in
+			// between leaves a trace the compensation can act on.
+			leg.Attempts++
+			if err := c.repository.Save(ctx, saga); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("%w: leg %s is %s", ErrDebitSagaInvalidState, leg.Name, leg.Status)
+		}
+
+		tx, err := c.post(ctx, leg)
+		if err != nil {
+			if leg.Status != models.DebitSagaLegStatusPosted {
+				leg.Status = models.DebitSagaLegStatusFailed
+			}
+			leg.LastError = errorMessage(err)
+			saga.LastError = errorMessage(fmt.Errorf("%s leg: %w", leg.Name, err))
+			if saveErr := c.repository.Save(ctx, saga); saveErr != nil {
+				return nil, errors.Join(err, saveErr)
+			}
+			return nil, err
+		}
+
+		leg.Status = models.DebitSagaLegStatusPosted
+		leg.TransactionID = transactionID(tx.ID)
+		leg.LastError = nil
+		result.Transactions[leg.Name] = *tx
+	}
+
+	if saga.Status != models.DebitSagaStatusCompleted {
+		now := time.Now().UTC()
+		saga.Status = models.DebitSagaStatusCompleted
+		saga.LastError = nil
+		saga.CompletedAt = &now
+		if err := c.repository.Save(ctx, saga); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// compensate reverts posted legs in reverse order. A saga where nothing had
+// been posted ends up aborted rather than compensated. Legs whose outcome
+// cannot be established are left for the next compensation, the saga staying
+// compensating meanwhile.
+func (c *DefaultDebitSagaCoordinator) compensate(ctx context.Context, saga *models.DebitSaga) error {
+	saga.Status = models.DebitSagaStatusCompensating
+	if err := c.repository.Save(ctx, saga); err != nil {
+		return err
+	}
+
+	var unresolved error
+	for i := len(saga.Legs) - 1; i >= 0; i-- {
+		leg := &saga.Legs[i]
+		switch leg.Status {
+		case models.DebitSagaLegStatusPosted:
+		case models.DebitSagaLegStatusPending, models.DebitSagaLegStatusFailed:
+			if leg.Status == models.DebitSagaLegStatusPending && leg.Attempts == 0 {
+				leg.Status = models.DebitSagaLegStatusSkipped
+				continue
+			}
+			// A failed attempt may still have committed, after a timeout or a
+			// cancellation for instance. Replaying it with the same idempotency
+			// key returns the committed transaction if there is one, or posts it
+			// now so that it can be reverted below.
+			tx, err := c.post(ctx, leg)
+			if err != nil {
+				leg.LastError = errorMessage(err)
+				if !ledgerRejected(err) {
+					unresolved = errors.Join(unresolved, fmt.Errorf("replaying %s leg: %w", leg.Name, err))
+					continue
+				}
+				// The ledger refused the transaction, it was never committed.
+				if leg.Status == models.DebitSagaLegStatusPending {
+					leg.Status = models.DebitSagaLegStatusSkipped
+				}
+				continue
+			}
+			leg.Status = models.DebitSagaLegStatusPosted
+			leg.TransactionID = transactionID(tx.ID)
+		default:
+			continue
+		}
+
+		revertID, err := c.revert(ctx, saga, leg)
+		if err != nil {
+			leg.LastError = errorMessage(err)
+			saga.LastError = errorMessage(fmt.Errorf("reverting %s leg: %w", leg.Name, err))
+			if saveErr := c.repository.Save(ctx, saga); saveErr != nil {
+				return errors.Join(err, saveErr)
+			}
+			return err
+		}
+		leg.Status = models.DebitSagaLegStatusReverted
+		leg.RevertTransactionID = revertID
+	}
+
+	if unresolved != nil {
+		saga.LastError = errorMessage(unresolved)
+		if err := c.repository.Save(ctx, saga); err != nil {
+			return errors.Join(unresolved, err)
+		}
+		return unresolved
+	}
+
+	now := time.Now().UTC()
+	saga.Status = models.DebitSagaStatusAborted
+	for _, leg := range saga.Legs {
+		if leg.Status == models.DebitSagaLegStatusReverted {
+			saga.Status = models.DebitSagaStatusCompensated
+			break
+		}
+	}
+	saga.CompletedAt = &now
+	return c.repository.Save(ctx, saga)
+}
+
+func (c *DefaultDebitSagaCoordinator) post(ctx context.Context, leg *models.DebitSagaLeg) (*ledgerinternal.Transaction, error) {
+	l, err := c.system.GetLedgerController(ctx, leg.Ledger)
+	if err != nil {
+		return nil, err
+	}
+
+	runMetadata := metadata.Metadata{}
+	for k, v := range leg.Metadata {
+		runMetadata[k] = v
+	}
+
+	_, tx, _, err := l.CreateTransaction(ctx, ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
+		IdempotencyKey: leg.IdempotencyKey,
+		Input: ledgercontroller.CreateTransaction{
+			RunScript: vm.RunScript{
+				Script:    vm.Script{Plain: leg.Script},
+				Reference: leg.Reference,
+				Metadata:  runMetadata,
+			},
+			Runtime: ledgerinternal.RuntimeMachine,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tx.Transaction, nil
+}
+
+func (c *DefaultDebitSagaCoordinator) revert(ctx context.Context, saga *models.DebitSaga, leg *models.DebitSagaLeg) (*int64, error) {
+	if leg.TransactionID == nil {
+		return nil, fmt.Errorf("%w: leg %s has no transaction id", ErrDebitSagaInvalidState, leg.Name)
+	}
+	l, err := c.system.GetLedgerController(ctx, leg.Ledger)
+	if err != nil {
+		return nil, err
+	}
+
+	_, reverted, _, err := l.RevertTransaction(ctx, ledgercontroller.Parameters[ledgercontroller.RevertTransaction]{
+		IdempotencyKey: leg.IdempotencyKey + ":revert",
+		Input: ledgercontroller.RevertTransaction{
+			// Compensations must go through even when the counter account has
+			// moved on since the leg was posted.
+			Force:         true,
+			TransactionID: uint64(*leg.TransactionID),
+			Metadata: metadata.Metadata{
+				debitSagaMetadataKey: saga.ID.String(),
+				"wallet_saga_leg":    leg.Name,
+			},
+		},
+	})
+	if errors.Is(err, ledgercontroller.ErrAlreadyReverted{}) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return transactionID(reverted.RevertTransaction.ID), nil
+}
+
+func validateDebitSagaPlan(plan DebitSagaPlan) error {
+	if strings.TrimSpace(plan.Reference) == "" {
+		return fmt.Errorf("%w: reference is required", ErrDebitSagaValidation)
+	}
+	if len(plan.Legs) == 0 {
+		return fmt.Errorf("%w: at least one leg is required", ErrDebitSagaValidation)
+	}
+	seen := map[string]struct{}{}
+	for _, leg := range plan.Legs {
+		if leg.Name == "" || leg.Ledger == "" || leg.Script == "" {
+			return fmt.Errorf("%w: leg name, ledger and script are required", ErrDebitSagaValidation)
+		}
+		if _, ok := seen[leg.Name]; ok {
+			return fmt.Errorf("%w: duplicate leg %s", ErrDebitSagaValidation, leg.Name)
+		}
+		seen[leg.Name] = struct{}{}
+	}
+	return nil
+}
+
+func sameLegs(saga *models.DebitSaga, plan DebitSagaPlan) bool {
+	if len(saga.Legs) != len(plan.Legs) {
+		return false
+	}
+	for i, leg := range saga.Legs {
+		if leg.Name != plan.Legs[i].Name ||
+			leg.Ledger != plan.Legs[i].Ledger ||
+			leg.Reference != plan.Legs[i].Reference ||
+			leg.Script != plan.Legs[i].Script {
+			return false
+		}
+	}
+	return true
+}
+
+func firstFailedLeg(saga *models.DebitSaga) *models.DebitSagaLeg {
+	for i := range saga.Legs {
+		if saga.Legs[i].Status == models.DebitSagaLegStatusFailed {
+			return &saga.Legs[i]
+		}
+	}
+	return nil
+}
+
+// ledgerRejected tells whether the ledger refused a transaction, which then
+// was never committed.
+func ledgerRejected(err error) bool {
+	switch {
+	case errors.Is(err, &ledgercontroller.ErrInsufficientFunds{}),
+		errors.Is(err, ledgercontroller.ErrCompilationFailed{}),
+		errors.Is(err, &ledgercontroller.ErrMetadataOverride{}),
+		errors.Is(err, ledgercontroller.ErrSchemaValidationError{}),
+		errors.Is(err, ledgercontroller.ErrNoPostings):
+		return true
+	default:
+		return false
+	}
+}
+
+func transactionID(id *uint64) *int64 {
+	if id == nil {
+		return nil
+	}
+	x := int64(*id)
+	return &x
+}
+
+func errorMessage(err error) *string {
+	message := err.Error()
+	return &message
+}
+
+func resolveDebitSagaRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrDebitSagaNotFound
+	default:
+		return err
+	}
+}
+
+// nopDebitSagaRepository backs coordinators built without a database.
+type nopDebitSagaRepository struct{}
+
+func (nopDebitSagaRepository) Create(_ context.Context, saga *models.DebitSaga) error {
+	if saga.ID == uuid.Nil {
+		saga.ID = uuid.New()
+	}
+	return nil
+}
+
+func (nopDebitSagaRepository) Save(context.Context, *models.DebitSaga) error {
+	return nil
+}
+
+func (nopDebitSagaRepository) Get(context.Context, uuid.UUID) (*models.DebitSaga, error) {
+	return nil, postgres.ErrNotFound
+}
+
+func (nopDebitSagaRepository) GetByReference(context.Context, string, string) (*models.DebitSaga, error) {
+	return nil, postgres.ErrNotFound
+}
+
+func (nopDebitSagaRepository) List(context.Context, repositories.DebitSagaFilter) ([]models.DebitSaga, error) {
+	return []models.DebitSaga{}, nil
+}
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/formancehq/go-libs/v3/metadata"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/machine/vm"
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+)
+
+var (
+	ErrLienValidation   = errors.New("lien validation failed")
+	ErrLienNotFound     = errors.New("lien not found")
+	ErrLienConflict     = errors.New("lien already exists for reference")
+	ErrLienInvalidState = errors.New("lien is not active")
+)
+
+const (
+	lienOperationPlace   = "place"
+	lienOperationTopUp   = "top_up"
+	lienOperationCapture = "capture"
+	lienOperationRelease = "release"
+	lienOperationExpire  = "expire"
+
+	lienUpdateAttempts = 3
+)
+
+type LienService interface {
+	Place(context.Context, PlaceLienInput) (*LienResult, error)
+	TopUp(context.Context, uuid.UUID, LienMovementInput) (*LienResult, error)
+	Capture(context.Context, uuid.UUID, CaptureLienInput) (*LienResult, error)
+	Release(context.Context, uuid.UUID, LienMovementInput) (*LienResult, error)
+	Expire(context.Context, uuid.UUID, time.Time) (*LienResult, error)
+	Settle(context.Context, uuid.UUID) (*LienResult, error)
+	Get(context.Context, uuid.UUID) (*models.Lien, error)
+	List(context.Context, repositories.LienFilter) ([]models.Lien, error)
+}
+
+type PlaceLienInput struct {
+	Ledger         string
+	WalletID       string
+	Amount         int64
+	Reference      string
+	IdempotencyKey string
+	ExpiresAt      *time.Time
+	Metadata       map[string]string
+}
+
+// LienMovementInput moves funds in or out of a lien. A zero amount captures
+// or releases everything the lien still holds. ExpiresAt is only used by
+// top-ups, to push the expiry of the lien.
+type LienMovementInput struct {
+	Amount         int64
+	Reference      string
+	IdempotencyKey string
+	ExpiresAt      *time.Time
+	Metadata       map[string]string
+}
+
+type CaptureLienInput struct {
+	LienMovementInput
+	// ReleaseRemainder returns whatever the lien still holds after the
+	// capture to the available balance, in the same ledger transaction.
+	ReleaseRemainder bool
+}
+
+type LienResult struct {
+	Lien        *models.Lien
+	Transaction *ledgerinternal.Transaction
+}
+
+type DefaultLienService struct {
+	system     systemcontroller.Controller
+	repository repositories.LienRepository
+}
+
+func NewLienService(system systemcontroller.Controller, repository repositories.LienRepository) *DefaultLienService {
+	return &DefaultLienService{
+		system:     system,
+		repository: repository,
+	}
+}
+
+func (s *DefaultLienService) Place(ctx context.Context, input PlaceLienInput) (*LienResult, error) {
+	if input.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrLienValidation)
+	}
+	if strings.TrimSpace(input.Reference) == "" {
+		return nil, fmt.Errorf("%w: reference is required", ErrLienValidation)
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrLienValidation)
+	}
+	walletID, err := parseLienWalletID(input.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	lien := &models.Lien{
+		ID:        uuid.New(),
+		Ledger:    input.Ledger,
+		WalletID:  input.WalletID,
+		Currency:  walletID.Currency,
+		Reference: input.Reference,
+		Status:    models.LienStatusActive,
+		ExpiresAt: input.ExpiresAt,
+		Metadata:  input.Metadata,
+	}
+	if lien.Metadata == nil {
+		lien.Metadata = map[string]string{}
+	}
+	lien.Account = walletID.LienAccount(lien.ID)
+	// The lien holds nothing until the placement is settled.
+	lien.Pending = newLienMovement(lien, lienOperationPlace, input.Amount, input.Reference, input.IdempotencyKey, input.Metadata, fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, currencyregistry.Asset(walletID.Currency), input.Amount, walletID.AvailableAccount(), lien.Account))
+
+	if err := s.repository.Create(ctx, lien); err != nil {
+		if !errors.Is(err, postgres.ErrConstraintsFailed{}) {
+			return nil, err
+		}
+		existing, lookupErr := s.repository.GetByReference(ctx, input.Ledger, input.Reference)
+		if lookupErr != nil || existing.WalletID != input.WalletID {
+			return nil, fmt.Errorf("%w: %s", ErrLienConflict, input.Reference)
+		}
+		// Same reference on the same wallet: the caller retried the placement.
+		if existing.Pending != nil && existing.Pending.Operation == lienOperationPlace {
+			if existing.Pending.Amount != input.Amount {
+				return nil, fmt.Errorf("%w: %s was placed for %d", ErrLienConflict, input.Reference, existing.Pending.Amount)
+			}
+			return s.settle(ctx, existing)
+		}
+		if existing.Amount != input.Amount {
+			return nil, fmt.Errorf("%w: %s holds %d", ErrLienConflict, input.Reference, existing.Amount)
+		}
+		return &LienResult{Lien: existing}, nil
+	}
+
+	return s.settle(ctx, lien)
+}
+
+func (s *DefaultLienService) TopUp(ctx context.Context, id uuid.UUID, input LienMovementInput) (*LienResult, error) {
+	if input.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrLienValidation)
+	}
+	lien, settled, err := s.active(ctx, id, lienOperationTopUp, input)
+	if err != nil || settled != nil {
+		return settled, err
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrLienValidation)
+	}
+	walletID, err := parseLienWalletID(lien.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	movement := newLienMovement(lien, lienOperationTopUp, input.Amount, input.Reference, input.IdempotencyKey, input.Metadata, fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, currencyregistry.Asset(lien.Currency), input.Amount, walletID.AvailableAccount(), lien.Account))
+	movement.ExpiresAt = input.ExpiresAt
+	return s.move(ctx, lien, movement)
+}
+
+func (s *DefaultLienService) Capture(ctx context.Context, id uuid.UUID, input CaptureLienInput) (*LienResult, error) {
+	lien, settled, err := s.active(ctx, id, lienOperationCapture, input.LienMovementInput)
+	if err != nil || settled != nil {
+		return settled, err
+	}
+	amount, err := movementAmount(lien, input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	walletID, err := parseLienWalletID(lien.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	asset := currencyregistry.Asset(lien.Currency)
+	script := fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @world
+		)
+	`, asset, amount, lien.Account)
+	remainder := lien.Remaining() - amount
+	if input.ReleaseRemainder && remainder > 0 {
+		script += fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, asset, remainder, lien.Account, walletID.AvailableAccount())
+	} else {
+		remainder = 0
+	}
+
+	movement := newLienMovement(lien, lienOperationCapture, amount, input.Reference, input.IdempotencyKey, input.Metadata, script)
+	movement.Released = remainder
+	return s.move(ctx, lien, movement)
+}
+
+func (s *DefaultLienService) Release(ctx context.Context, id uuid.UUID, input LienMovementInput) (*LienResult, error) {
+	lien, settled, err := s.active(ctx, id, lienOperationRelease, input)
+	if err != nil || settled != nil {
+		return settled, err
+	}
+	amount, err := movementAmount(lien, input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return s.release(ctx, lien, amount, lienOperationRelease, input)
+}
+
+// Expire releases whatever an expired lien still holds. Liens without an
+// expiry, or not yet expired at now, are rejected.
+func (s *DefaultLienService) Expire(ctx context.Context, id uuid.UUID, now time.Time) (*LienResult, error) {
+	lien, settled, err := s.active(ctx, id, lienOperationExpire, LienMovementInput{})
+	if err != nil || settled != nil {
+		return settled, err
+	}
+	if lien.ExpiresAt == nil || lien.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: lien %s has not expired", ErrLienInvalidState, lien.ID)
+	}
+	return s.release(ctx, lien, lien.Remaining(), lienOperationExpire, LienMovementInput{})
+}
+
+// Settle settles the movement left pending on a lien, by a crash or a
+// ledger failure, and returns the lien as is when there is none.
+func (s *DefaultLienService) Settle(ctx context.Context, id uuid.UUID) (*LienResult, error) {
+	lien, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if lien.Pending == nil {
+		return &LienResult{Lien: lien}, nil
+	}
+	return s.settle(ctx, lien)
+}
+
+func (s *DefaultLienService) Get(ctx context.Context, id uuid.UUID) (*models.Lien, error) {
+	lien, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveLienRepositoryError(err)
+	}
+	return lien, nil
+}
+
+func (s *DefaultLienService) List(ctx context.Context, filter repositories.LienFilter) ([]models.Lien, error) {
+	return s.repository.List(ctx, filter)
+}
+
+func (s *DefaultLienService) release(ctx context.Context, lien *models.Lien, amount int64, operation string, input LienMovementInput) (*LienResult, error) {
+	walletID, err := parseLienWalletID(lien.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	movement := newLienMovement(lien, operation, amount, input.Reference, input.IdempotencyKey, input.Metadata, "")
+	if amount > 0 {
+		movement.Script = fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, currencyregistry.Asset(lien.Currency), amount, lien.Account, walletID.AvailableAccount())
+	}
+	return s.move(ctx, lien, movement)
+}
+
+// active returns the lien to move funds of. A movement left pending on the
+// lien is settled first; when it is the one the caller retries, the result of
+// settling it is returned instead of the lien.
+func (s *DefaultLienService) active(ctx context.Context, id uuid.UUID, operation string, input LienMovementInput) (*models.Lien, *LienResult, error) {
+	lien, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pending := lien.Pending; pending != nil {
+		settled, err := s.settle(ctx, lien)
+		switch {
+		case pending.Operation == operation && pending.Reference == input.Reference &&
+			(input.IdempotencyKey == "" || input.IdempotencyKey == pending.IdempotencyKey):
+			return nil, settled, err
+		case err == nil:
+			lien = settled.Lien
+		case ledgerRejected(err):
+			// The pending movement was dropped, the caller's can go on.
+			if lien, err = s.Get(ctx, id); err != nil {
+				return nil, nil, err
+			}
+		default:
+			return nil, nil, err
+		}
+	}
+	if lien.Status != models.LienStatusActive {
+		return nil, nil, fmt.Errorf("%w: lien %s is %s", ErrLienInvalidState, lien.ID, lien.Status)
+	}
+	return lien, nil, nil
+}
+
+// move records the movement on the lien, then settles it. Recording it
+// fails when the lien changed since it was read, as the movement was checked
+// against a stale lien.
+func (s *DefaultLienService) move(ctx context.Context, lien *models.Lien, movement *models.LienMovement) (*LienResult, error) {
+	lien.Pending = movement
+	if err := s.repository.Update(ctx, lien); err != nil {
+		if errors.Is(err, repositories.ErrLienVersionMismatch) {
+			return nil, fmt.Errorf("%w: lien %s was updated concurrently", ErrLienConflict, lien.ID)
+		}
+		return nil, err
+	}
+	return s.settle(ctx, lien)
+}
+
+// settle posts the pending movement of the lien and applies it. The posting
+// is replayed with the idempotency key of the movement, so settling the same
+// movement again never moves the funds twice. A movement the ledger may have
+// committed stays pending until settled again; one it refused is dropped,
+// along with the lien when it was being placed.
+func (s *DefaultLienService) settle(ctx context.Context, lien *models.Lien) (*LienResult, error) {
+	movement := *lien.Pending
+
+	var tx *ledgerinternal.Transaction
+	if movement.Script != "" {
+		var err error
+		tx, err = s.post(ctx, lien, movement)
+		if err != nil {
+			if !ledgerRejected(err) {
+				return nil, err
+			}
+			if movement.Operation == lienOperationPlace {
+				if deleteErr := s.repository.Delete(ctx, lien.ID); deleteErr != nil {
+					return nil, errors.Join(err, deleteErr)
+				}
+				return nil, err
+			}
+			if _, dropErr := s.update(ctx, lien, movement.IdempotencyKey, func(*models.Lien) {}); dropErr != nil {
+				return nil, errors.Join(err, dropErr)
+			}
+			return nil, err
+		}
+	}
+
+	lien, err := s.update(ctx, lien, movement.IdempotencyKey, func(lien *models.Lien) {
+		switch movement.Operation {
+		case lienOperationPlace, lienOperationTopUp:
+			lien.Amount += movement.Amount
+			if movement.ExpiresAt != nil {
+				lien.ExpiresAt = movement.ExpiresAt
+			}
+		case lienOperationCapture:
+			lien.CapturedAmount += movement.Amount
+			lien.ReleasedAmount += movement.Released
+		case lienOperationRelease:
+			lien.ReleasedAmount += movement.Amount
+		case lienOperationExpire:
+			lien.ReleasedAmount += movement.Amount
+			lien.Status = models.LienStatusExpired
+		}
+		if lien.Remaining() <= 0 && lien.ClosedAt == nil {
+			now := time.Now().UTC()
+			lien.ClosedAt = &now
+			if lien.Status == models.LienStatusActive {
+				lien.Status = models.LienStatusReleased
+				if lien.CapturedAmount > 0 {
+					lien.Status = models.LienStatusCaptured
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LienResult{Lien: lien, Transaction: tx}, nil
+}
+
+// update applies mutate to the lien and clears the pending movement
+// identified by idempotencyKey. A concurrent update is resolved by reloading
+// the lien, which is returned as is once someone else settled the movement.
+func (s *DefaultLienService) update(ctx context.Context, lien *models.Lien, idempotencyKey string, mutate func(*models.Lien)) (*models.Lien, error) {
+	for attempt := 1; ; attempt++ {
+		if lien.Pending == nil || lien.Pending.IdempotencyKey != idempotencyKey {
+			return lien, nil
+		}
+		mutate(lien)
+		lien.Pending = nil
+
+		err := s.repository.Update(ctx, lien)
+		if err == nil {
+			return lien, nil
+		}
+		if !errors.Is(err, repositories.ErrLienVersionMismatch) || attempt == lienUpdateAttempts {
+			return nil, err
+		}
+		if lien, err = s.Get(ctx, lien.ID); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *DefaultLienService) post(ctx context.Context, lien *models.Lien, movement models.LienMovement) (*ledgerinternal.Transaction, error) {
+	l, err := s.system.GetLedgerController(ctx, lien.Ledger)
+	if err != nil {
+		return nil, err
+	}
+
+	runMetadata := metadata.Metadata{}
+	for k, v := range movement.Metadata {
+		runMetadata[k] = v
+	}
+	runMetadata["lien_id"] = lien.ID.String()
+	runMetadata["lien_operation"] = movement.Operation
+
+	_, tx, _, err := l.CreateTransaction(ctx, ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
+		IdempotencyKey: movement.IdempotencyKey,
+		Input: ledgercontroller.CreateTransaction{
+			RunScript: vm.RunScript{
+				Script:    vm.Script{Plain: movement.Script},
+				Reference: movement.Reference,
+				Metadata:  runMetadata,
+			},
+			Runtime: ledgerinternal.RuntimeMachine,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tx.Transaction, nil
+}
+
+func newLienMovement(lien *models.Lien, operation string, amount int64, reference, idempotencyKey string, txMetadata map[string]string, script string) *models.LienMovement {
+	if idempotencyKey == "" {
+		// Derived from the lien version so that retrying the same operation
+		// replays it instead of moving the funds twice.
+		idempotencyKey = fmt.Sprintf("wallet-lien:%s:%s:%d", lien.ID, operation, lien.Version)
+	}
+	return &models.LienMovement{
+		Operation:      operation,
+		Amount:         amount,
+		Reference:      reference,
+		IdempotencyKey: idempotencyKey,
+		Script:         script,
+		Metadata:       txMetadata,
+	}
+}
+
+func movementAmount(lien *models.Lien, amount int64) (int64, error) {
+	switch {
+	case amount < 0:
+		return 0, fmt.Errorf("%w: amount must not be negative", ErrLienValidation)
+	case amount == 0:
+		return lien.Remaining(), nil
+	case amount > lien.Remaining():
+		return 0, fmt.Errorf("%w: amount %d exceeds the %d held by lien %s", ErrLienValidation, amount, lien.Remaining(), lien.ID)
+	default:
+		return amount, nil
+	}
+}
+
+// parseLienWalletID parses the ID of the wallet a lien is placed on.
+func parseLienWalletID(walletID string) (models.WalletID, error) {
+	id, err := models.ParseWalletID(walletID)
+	if err != nil {
+		return models.WalletID{}, fmt.Errorf("%w: %w", ErrLienValidation, err)
+	}
+	return id, nil
+}
+
+func resolveLienRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrLienNotFound
+	default:
+		return err
+	}
+}
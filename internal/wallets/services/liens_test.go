@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	"github.com/formancehq/ledger/internal/machine"
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+)
+
+func TestLienPlacementInterruptedIsSettledOnRetry(t *testing.T) {
+	t.Parallel()
+
+	repository := newLienRepositoryStub()
+	ledgerController := &lienLedgerControllerStub{}
+	service := NewLienService(&lienSystemControllerStub{ledgerController: ledgerController}, repository)
+
+	input := PlaceLienInput{
+		Ledger:    "default",
+		WalletID:  "user123-USD",
+		Amount:    500,
+		Reference: "order-1",
+	}
+
+	// The ledger may have committed the hold before failing: the lien is kept
+	// pending, holding nothing yet.
+	ledgerController.err = context.DeadlineExceeded
+	_, err := service.Place(context.Background(), input)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	lien, err := repository.GetByReference(context.Background(), "default", "order-1")
+	require.NoError(t, err)
+	require.NotNil(t, lien.Pending)
+	require.Zero(t, lien.Amount)
+
+	// A retry with another amount is not the same placement.
+	ledgerController.err = nil
+	_, err = service.Place(context.Background(), PlaceLienInput{
+		Ledger:    "default",
+		WalletID:  "user123-USD",
+		Amount:    700,
+		Reference: "order-1",
+	})
+	require.ErrorIs(t, err, ErrLienConflict)
+
+	result, err := service.Place(context.Background(), input)
+	require.NoError(t, err)
+	require.Nil(t, result.Lien.Pending)
+	require.EqualValues(t, 500, result.Lien.Amount)
+	require.Equal(t, models.LienStatusActive, result.Lien.Status)
+	require.Len(t, ledgerController.idempotencyKeys, 2)
+	require.Equal(t, ledgerController.idempotencyKeys[0], ledgerController.idempotencyKeys[1])
+}
+
+func TestLienPlacementRefusedByTheLedgerIsDropped(t *testing.T) {
+	t.Parallel()
+
+	repository := newLienRepositoryStub()
+	ledgerController := &lienLedgerControllerStub{err: machine.NewErrInsufficientFund("insufficient funds")}
+	service := NewLienService(&lienSystemControllerStub{ledgerController: ledgerController}, repository)
+
+	_, err := service.Place(context.Background(), PlaceLienInput{
+		Ledger:    "default",
+		WalletID:  "user123-USD",
+		Amount:    500,
+		Reference: "order-1",
+	})
+	require.ErrorIs(t, err, &ledgercontroller.ErrInsufficientFunds{})
+	require.Empty(t, repository.liens)
+}
+
+func TestLienMovementInterruptedIsSettledBeforeTheNextOne(t *testing.T) {
+	t.Parallel()
+
+	repository := newLienRepositoryStub()
+	ledgerController := &lienLedgerControllerStub{}
+	service := NewLienService(&lienSystemControllerStub{ledgerController: ledgerController}, repository)
+
+	placed, err := service.Place(context.Background(), PlaceLienInput{
+		Ledger:    "default",
+		WalletID:  "user123-USD",
+		Amount:    500,
+		Reference: "order-1",
+	})
+	require.NoError(t, err)
+
+	ledgerController.err = context.Canceled
+	_, err = service.Capture(context.Background(), placed.Lien.ID, CaptureLienInput{
+		LienMovementInput: LienMovementInput{Amount: 200, Reference: "capture-1"},
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	// The capture is settled before the release is checked against what the
+	// lien still holds.
+	ledgerController.err = nil
+	result, err := service.Release(context.Background(), placed.Lien.ID, LienMovementInput{})
+	require.NoError(t, err)
+	require.EqualValues(t, 200, result.Lien.CapturedAmount)
+	require.EqualValues(t, 300, result.Lien.ReleasedAmount)
+	require.Equal(t, models.LienStatusCaptured, result.Lien.Status)
+
+	// The capture was settled with the release, retrying it moves nothing.
+	result, err = service.Capture(context.Background(), placed.Lien.ID, CaptureLienInput{
+		LienMovementInput: LienMovementInput{Amount: 200, Reference: "capture-1"},
+	})
+	require.ErrorIs(t, err, ErrLienInvalidState)
+	require.Nil(t, result)
+}
+
+type lienSystemControllerStub struct {
+	systemcontroller.Controller
+	ledgerController ledgercontroller.Controller
+}
+
+func (s *lienSystemControllerStub) GetLedgerController(context.Context, string) (ledgercontroller.Controller, error) {
+	return s.ledgerController, nil
+}
+
+// lienLedgerControllerStub commits every transaction, then fails with err
+// when set, as a ledger timing out after the commit would.
+type lienLedgerControllerStub struct {
+	ledgercontroller.Controller
+	err             error
+	idempotencyKeys []string
+}
+
+func (s *lienLedgerControllerStub) CreateTransaction(_ context.Context, params ledgercontroller.Parameters[ledgercontroller.CreateTransaction]) (*ledgerinternal.Log, *ledgerinternal.CreatedTransaction, bool, error) {
+	s.idempotencyKeys = append(s.idempotencyKeys, params.IdempotencyKey)
+	if s.err != nil {
+		return nil, nil, false, s.err
+	}
+	return &ledgerinternal.Log{}, &ledgerinternal.CreatedTransaction{
+		Transaction: ledgerinternal.NewTransaction().WithID(uint64(len(s.idempotencyKeys))),
+	}, false, nil
+}
+
+type lienRepositoryStub struct {
+	liens map[uuid.UUID]models.Lien
+}
+
+func newLienRepositoryStub() *lienRepositoryStub {
+	return &lienRepositoryStub{liens: map[uuid.UUID]models.Lien{}}
+}
+
+func (r *lienRepositoryStub) Create(_ context.Context, lien *models.Lien) error {
+	for _, existing := range r.liens {
+		if existing.Ledger == lien.Ledger && existing.Reference == lien.Reference {
+			return postgres.ErrConstraintsFailed{}
+		}
+	}
+	r.liens[lien.ID] = *lien
+	return nil
+}
+
+func (r *lienRepositoryStub) Update(_ context.Context, lien *models.Lien) error {
+	if r.liens[lien.ID].Version != lien.Version {
+		return repositories.ErrLienVersionMismatch
+	}
+	lien.Version++
+	r.liens[lien.ID] = *lien
+	return nil
+}
+
+func (r *lienRepositoryStub) Delete(_ context.Context, id uuid.UUID) error {
+	delete(r.liens, id)
+	return nil
+}
+
+func (r *lienRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.Lien, error) {
+	lien, ok := r.liens[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	return &lien, nil
+}
+
+func (r *lienRepositoryStub) GetByReference(_ context.Context, ledger, reference string) (*models.Lien, error) {
+	for _, lien := range r.liens {
+		if lien.Ledger == ledger && lien.Reference == reference {
+			return &lien, nil
+		}
+	}
+	return nil, postgres.ErrNotFound
+}
+
+func (r *lienRepositoryStub) List(context.Context, repositories.LienFilter) ([]models.Lien, error) {
+	ret := make([]models.Lien, 0, len(r.liens))
+	for _, lien := range r.liens {
+		ret = append(ret, lien)
+	}
+	return ret, nil
+}
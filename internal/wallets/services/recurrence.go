@@ -0,0 +1,116 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseRecurrence parses the schedule of a standing instruction. Calendar
+// rules are "daily", "weekly:<mon..sun>" and "monthly:<1..31|last>"; they
+// fall at the time of day of anchor, and monthly days past the end of a
+// month fall on its last day. Anything else must be a standard five fields
+// cron expression, or a descriptor such as @monthly, evaluated in UTC.
+func ParseRecurrence(rule string, anchor time.Time) (cron.Schedule, error) {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+	kind, arg, _ := strings.Cut(rule, ":")
+
+	anchor = anchor.UTC()
+	calendar := calendarSchedule{
+		hour:   anchor.Hour(),
+		minute: anchor.Minute(),
+		second: anchor.Second(),
+	}
+	switch kind {
+	case "daily":
+		if arg != "" {
+			return nil, fmt.Errorf("daily rules take no argument")
+		}
+		calendar.kind = kind
+		return calendar, nil
+	case "weekly":
+		weekday, ok := weekdays[arg]
+		if !ok {
+			return nil, fmt.Errorf("weekly rules take a day of the week, such as weekly:mon")
+		}
+		calendar.kind = kind
+		calendar.weekday = weekday
+		return calendar, nil
+	case "monthly":
+		day := 31
+		if arg != "last" {
+			var err error
+			if day, err = strconv.Atoi(arg); err != nil || day < 1 || day > 31 {
+				return nil, fmt.Errorf("monthly rules take a day between 1 and 31, or last")
+			}
+		}
+		calendar.kind = kind
+		calendar.day = day
+		return calendar, nil
+	}
+
+	schedule, err := cron.ParseStandard(rule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %w", err)
+	}
+	return schedule, nil
+}
+
+// FirstOccurrence returns the first occurrence of schedule at or after start.
+func FirstOccurrence(schedule cron.Schedule, start time.Time) time.Time {
+	// Schedules return occurrences strictly after the given time, at a one
+	// second granularity.
+	return schedule.Next(start.UTC().Truncate(time.Second).Add(-time.Second))
+}
+
+type calendarSchedule struct {
+	kind    string
+	weekday time.Weekday
+	day     int
+
+	hour, minute, second int
+}
+
+func (s calendarSchedule) Next(after time.Time) time.Time {
+	after = after.UTC()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, s.hour, s.minute, s.second, 0, time.UTC)
+	}
+
+	switch s.kind {
+	case "weekly":
+		next := at(after.Year(), after.Month(), after.Day()+(int(s.weekday)-int(after.Weekday())+7)%7)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+		return next
+	case "monthly":
+		for months := 0; ; months++ {
+			first := time.Date(after.Year(), after.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+			lastDay := first.AddDate(0, 1, -1).Day()
+			next := at(first.Year(), first.Month(), min(s.day, lastDay))
+			if next.After(after) {
+				return next
+			}
+		}
+	default:
+		next := at(after.Year(), after.Month(), after.Day())
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 1)
+		}
+		return next
+	}
+}
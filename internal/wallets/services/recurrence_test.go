@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	t.Parallel()
+
+	anchor := time.Date(2026, 1, 31, 9, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		rule     string
+		after    time.Time
+		expected []time.Time
+	}{
+		{
+			rule:  "daily",
+			after: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 3, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			// 2026-03-04 is a Wednesday.
+			rule:  "weekly:mon",
+			after: time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2026, 3, 9, 9, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 16, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			rule:  "monthly:31",
+			after: anchor,
+			expected: []time.Time{
+				time.Date(2026, 2, 28, 9, 30, 0, 0, time.UTC),
+				time.Date(2026, 3, 31, 9, 30, 0, 0, time.UTC),
+				time.Date(2026, 4, 30, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			rule:  "monthly:last",
+			after: time.Date(2026, 12, 31, 12, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2027, 1, 31, 9, 30, 0, 0, time.UTC),
+				time.Date(2027, 2, 28, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			rule:  "0 8 1 * *",
+			after: anchor,
+			expected: []time.Time{
+				time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+			},
+		},
+	} {
+		t.Run(tc.rule, func(t *testing.T) {
+			t.Parallel()
+
+			schedule, err := ParseRecurrence(tc.rule, anchor)
+			require.NoError(t, err)
+
+			next := tc.after
+			for _, expected := range tc.expected {
+				next = schedule.Next(next)
+				require.Equal(t, expected, next)
+			}
+		})
+	}
+}
+
+func TestFirstOccurrenceIncludesStart(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 31, 9, 30, 0, 0, time.UTC)
+	schedule, err := ParseRecurrence("monthly:31", start)
+	require.NoError(t, err)
+	require.Equal(t, start, FirstOccurrence(schedule, start))
+}
+
+func TestParseRecurrenceRejectsInvalidRules(t *testing.T) {
+	t.Parallel()
+
+	for _, rule := range []string{"", "daily:2", "weekly:funday", "monthly:0", "monthly:32", "every tuesday"} {
+		_, err := ParseRecurrence(rule, time.Now())
+		require.Error(t, err, rule)
+	}
+}
@@ -0,0 +1,545 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+
+	"github.com/formancehq/go-libs/v3/metadata"
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	ledgerinternal "github.com/formancehq/ledger/internal"
+	ledgercontroller "github.com/formancehq/ledger/internal/controller/ledger"
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	"github.com/formancehq/ledger/internal/machine/vm"
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+)
+
+var (
+	ErrStandingInstructionValidation   = errors.New("standing instruction validation failed")
+	ErrStandingInstructionNotFound     = errors.New("standing instruction not found")
+	ErrStandingInstructionInvalidState = errors.New("standing instruction does not accept this operation")
+	ErrStandingInstructionConflict     = errors.New("standing instruction was updated concurrently")
+)
+
+const (
+	DefaultStandingInstructionMaxAttempts   = 3
+	DefaultStandingInstructionRetryInterval = time.Hour
+
+	maxStandingInstructionAttempts        = 20
+	minStandingInstructionRetryInterval   = time.Minute
+	standingInstructionCancelledLastError = "standing instruction cancelled"
+)
+
+type StandingInstructionService interface {
+	Create(context.Context, CreateStandingInstructionInput) (*models.StandingInstruction, error)
+	Update(context.Context, uuid.UUID, UpdateStandingInstructionInput) (*models.StandingInstruction, error)
+	Pause(context.Context, uuid.UUID) (*models.StandingInstruction, error)
+	// Resume restarts a paused instruction from its next occurrence: the
+	// occurrences missed while it was paused are not executed.
+	Resume(context.Context, uuid.UUID, time.Time) (*models.StandingInstruction, error)
+	Cancel(context.Context, uuid.UUID) (*models.StandingInstruction, error)
+	Get(context.Context, uuid.UUID) (*models.StandingInstruction, error)
+	List(context.Context, repositories.StandingInstructionFilter) ([]models.StandingInstruction, error)
+	ListExecutions(context.Context, repositories.StandingInstructionExecutionFilter) ([]models.StandingInstructionExecution, error)
+	// RunDue executes, in order, every occurrence of the instruction due at
+	// the given time, including those missed while no worker was running.
+	RunDue(context.Context, uuid.UUID, time.Time) (*models.StandingInstruction, error)
+	// Retry attempts a failed execution again.
+	Retry(context.Context, uuid.UUID, time.Time) (*models.StandingInstructionExecution, error)
+}
+
+type CreateStandingInstructionInput struct {
+	Ledger              string
+	SourceWalletID      string
+	DestinationWalletID string
+	Amount              int64
+	Schedule            string
+	// StartAt defaults to the creation time.
+	StartAt       *time.Time
+	EndAt         *time.Time
+	MaxAttempts   int
+	RetryInterval time.Duration
+	FailurePolicy string
+	Metadata      map[string]string
+}
+
+// UpdateStandingInstructionInput changes the fields which are set. Changing
+// the schedule moves the next occurrence accordingly.
+type UpdateStandingInstructionInput struct {
+	Amount        *int64
+	Schedule      *string
+	EndAt         *time.Time
+	MaxAttempts   *int
+	RetryInterval *time.Duration
+	FailurePolicy *string
+	Metadata      map[string]string
+}
+
+type DefaultStandingInstructionService struct {
+	system        systemcontroller.Controller
+	repository    repositories.StandingInstructionRepository
+	walletService WalletService
+}
+
+// NewStandingInstructionService creates the service. When walletService is
+// set, every execution checks that both wallets accept the transfer.
+func NewStandingInstructionService(
+	system systemcontroller.Controller,
+	repository repositories.StandingInstructionRepository,
+	walletService WalletService,
+) *DefaultStandingInstructionService {
+	return &DefaultStandingInstructionService{
+		system:        system,
+		repository:    repository,
+		walletService: walletService,
+	}
+}
+
+func (s *DefaultStandingInstructionService) Create(ctx context.Context, input CreateStandingInstructionInput) (*models.StandingInstruction, error) {
+	source, err := models.ParseWalletID(input.SourceWalletID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: source wallet: %w", ErrStandingInstructionValidation, err)
+	}
+	destination, err := models.ParseWalletID(input.DestinationWalletID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: destination wallet: %w", ErrStandingInstructionValidation, err)
+	}
+	switch {
+	case source == destination:
+		return nil, fmt.Errorf("%w: source and destination wallets must differ", ErrStandingInstructionValidation)
+	case source.Currency != destination.Currency:
+		return nil, fmt.Errorf("%w: source and destination wallets must share their currency", ErrStandingInstructionValidation)
+	}
+
+	now := time.Now().UTC()
+	startAt := now
+	if input.StartAt != nil {
+		startAt = input.StartAt.UTC()
+	}
+	instruction := &models.StandingInstruction{
+		Ledger:              input.Ledger,
+		SourceWalletID:      source.String(),
+		DestinationWalletID: destination.String(),
+		Currency:            source.Currency,
+		Amount:              input.Amount,
+		Schedule:            strings.TrimSpace(input.Schedule),
+		StartAt:             startAt,
+		EndAt:               input.EndAt,
+		Status:              models.StandingInstructionStatusActive,
+		MaxAttempts:         input.MaxAttempts,
+		RetryInterval:       int64(input.RetryInterval / time.Second),
+		FailurePolicy:       input.FailurePolicy,
+		Metadata:            input.Metadata,
+	}
+	if instruction.MaxAttempts == 0 {
+		instruction.MaxAttempts = DefaultStandingInstructionMaxAttempts
+	}
+	if instruction.RetryInterval == 0 {
+		instruction.RetryInterval = int64(DefaultStandingInstructionRetryInterval / time.Second)
+	}
+	if instruction.FailurePolicy == "" {
+		instruction.FailurePolicy = models.StandingInstructionFailureSkip
+	}
+	if instruction.Metadata == nil {
+		instruction.Metadata = map[string]string{}
+	}
+
+	schedule, err := validateStandingInstruction(instruction)
+	if err != nil {
+		return nil, err
+	}
+	schedulePending(instruction, FirstOccurrence(schedule, startAt))
+
+	if err := s.repository.Create(ctx, instruction); err != nil {
+		return nil, err
+	}
+	return instruction, nil
+}
+
+func (s *DefaultStandingInstructionService) Update(ctx context.Context, id uuid.UUID, input UpdateStandingInstructionInput) (*models.StandingInstruction, error) {
+	instruction, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch instruction.Status {
+	case models.StandingInstructionStatusCompleted, models.StandingInstructionStatusCancelled:
+		return nil, fmt.Errorf("%w: standing instruction %s is %s", ErrStandingInstructionInvalidState, id, instruction.Status)
+	}
+
+	rescheduled := false
+	if input.Amount != nil {
+		instruction.Amount = *input.Amount
+	}
+	if input.Schedule != nil {
+		rescheduled = strings.TrimSpace(*input.Schedule) != instruction.Schedule
+		instruction.Schedule = strings.TrimSpace(*input.Schedule)
+	}
+	if input.EndAt != nil {
+		rescheduled = true
+		instruction.EndAt = input.EndAt
+	}
+	if input.MaxAttempts != nil {
+		instruction.MaxAttempts = *input.MaxAttempts
+	}
+	if input.RetryInterval != nil {
+		instruction.RetryInterval = int64(*input.RetryInterval / time.Second)
+	}
+	if input.FailurePolicy != nil {
+		instruction.FailurePolicy = *input.FailurePolicy
+	}
+	if input.Metadata != nil {
+		instruction.Metadata = input.Metadata
+	}
+
+	schedule, err := validateStandingInstruction(instruction)
+	if err != nil {
+		return nil, err
+	}
+	if rescheduled {
+		schedulePending(instruction, FirstOccurrence(schedule, laterOf(instruction.StartAt, time.Now())))
+	}
+
+	if err := s.repository.Update(ctx, instruction); err != nil {
+		return nil, resolveStandingInstructionRepositoryError(err)
+	}
+	return instruction, nil
+}
+
+func (s *DefaultStandingInstructionService) Pause(ctx context.Context, id uuid.UUID) (*models.StandingInstruction, error) {
+	instruction, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch instruction.Status {
+	case models.StandingInstructionStatusPaused:
+		return instruction, nil
+	case models.StandingInstructionStatusActive:
+	default:
+		return nil, fmt.Errorf("%w: standing instruction %s is %s", ErrStandingInstructionInvalidState, id, instruction.Status)
+	}
+
+	instruction.Status = models.StandingInstructionStatusPaused
+	if err := s.repository.Update(ctx, instruction); err != nil {
+		return nil, resolveStandingInstructionRepositoryError(err)
+	}
+	return instruction, nil
+}
+
+func (s *DefaultStandingInstructionService) Resume(ctx context.Context, id uuid.UUID, now time.Time) (*models.StandingInstruction, error) {
+	instruction, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch instruction.Status {
+	case models.StandingInstructionStatusActive:
+		return instruction, nil
+	case models.StandingInstructionStatusPaused:
+	default:
+		return nil, fmt.Errorf("%w: standing instruction %s is %s", ErrStandingInstructionInvalidState, id, instruction.Status)
+	}
+
+	schedule, err := ParseRecurrence(instruction.Schedule, instruction.StartAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStandingInstructionValidation, err)
+	}
+	instruction.Status = models.StandingInstructionStatusActive
+	instruction.ConsecutiveFailures = 0
+	schedulePending(instruction, FirstOccurrence(schedule, laterOf(instruction.StartAt, now)))
+	if err := s.repository.Update(ctx, instruction); err != nil {
+		return nil, resolveStandingInstructionRepositoryError(err)
+	}
+	return instruction, nil
+}
+
+// Cancel stops the instruction for good, and gives up on the executions
+// still waiting for a retry.
+func (s *DefaultStandingInstructionService) Cancel(ctx context.Context, id uuid.UUID) (*models.StandingInstruction, error) {
+	instruction, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch instruction.Status {
+	case models.StandingInstructionStatusCancelled:
+		return instruction, nil
+	case models.StandingInstructionStatusCompleted:
+		return nil, fmt.Errorf("%w: standing instruction %s is %s", ErrStandingInstructionInvalidState, id, instruction.Status)
+	}
+
+	instruction.Status = models.StandingInstructionStatusCancelled
+	instruction.NextRunAt = nil
+	if err := s.repository.Update(ctx, instruction); err != nil {
+		return nil, resolveStandingInstructionRepositoryError(err)
+	}
+
+	executions, err := s.repository.ListExecutions(ctx, repositories.StandingInstructionExecutionFilter{
+		InstructionID: &instruction.ID,
+		Statuses:      []string{models.StandingInstructionExecutionRetrying},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range executions {
+		lastError := standingInstructionCancelledLastError
+		executions[i].Status = models.StandingInstructionExecutionFailed
+		executions[i].NextAttemptAt = nil
+		executions[i].LastError = &lastError
+		if err := s.repository.UpdateExecution(ctx, &executions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return instruction, nil
+}
+
+func (s *DefaultStandingInstructionService) Get(ctx context.Context, id uuid.UUID) (*models.StandingInstruction, error) {
+	instruction, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return nil, resolveStandingInstructionRepositoryError(err)
+	}
+	return instruction, nil
+}
+
+func (s *DefaultStandingInstructionService) List(ctx context.Context, filter repositories.StandingInstructionFilter) ([]models.StandingInstruction, error) {
+	return s.repository.List(ctx, filter)
+}
+
+func (s *DefaultStandingInstructionService) ListExecutions(ctx context.Context, filter repositories.StandingInstructionExecutionFilter) ([]models.StandingInstructionExecution, error) {
+	return s.repository.ListExecutions(ctx, filter)
+}
+
+func (s *DefaultStandingInstructionService) RunDue(ctx context.Context, id uuid.UUID, now time.Time) (*models.StandingInstruction, error) {
+	instruction, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := ParseRecurrence(instruction.Schedule, instruction.StartAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStandingInstructionValidation, err)
+	}
+
+	for instruction.Status == models.StandingInstructionStatusActive &&
+		instruction.NextRunAt != nil &&
+		!instruction.NextRunAt.After(now) {
+		occurrence := *instruction.NextRunAt
+		execution := &models.StandingInstructionExecution{
+			InstructionID: instruction.ID,
+			OccurrenceAt:  occurrence,
+			Reference:     fmt.Sprintf("standing-instruction:%s:%d", instruction.ID, occurrence.Unix()),
+			Status:        models.StandingInstructionExecutionPending,
+		}
+		// An execution left pending by a crash is attempted again; one
+		// already attempted only needs the schedule to move on.
+		if _, err := s.repository.CreateExecution(ctx, execution); err != nil {
+			return nil, err
+		}
+		if execution.Status == models.StandingInstructionExecutionPending {
+			if err := s.attempt(ctx, instruction, execution, now); err != nil {
+				return nil, err
+			}
+		}
+
+		schedulePending(instruction, schedule.Next(occurrence))
+		if err := s.repository.UpdateRun(ctx, instruction); err != nil {
+			if errors.Is(err, repositories.ErrStandingInstructionVersionMismatch) {
+				// Paused, cancelled or edited during the run: the change is
+				// kept, and the occurrences executed here are not executed
+				// again by the next run.
+				return s.Get(ctx, id)
+			}
+			return nil, err
+		}
+	}
+	return instruction, nil
+}
+
+func (s *DefaultStandingInstructionService) Retry(ctx context.Context, id uuid.UUID, now time.Time) (*models.StandingInstructionExecution, error) {
+	execution, err := s.repository.GetExecution(ctx, id)
+	if err != nil {
+		return nil, resolveStandingInstructionRepositoryError(err)
+	}
+	if execution.Status != models.StandingInstructionExecutionRetrying {
+		return nil, fmt.Errorf("%w: execution %s is %s", ErrStandingInstructionInvalidState, id, execution.Status)
+	}
+	instruction, err := s.Get(ctx, execution.InstructionID)
+	if err != nil {
+		return nil, err
+	}
+	if instruction.Status == models.StandingInstructionStatusPaused {
+		// Retries wait for the instruction to be resumed.
+		return execution, nil
+	}
+
+	if err := s.attempt(ctx, instruction, execution, now); err != nil {
+		return nil, err
+	}
+	// The outcome is kept on the execution even if the instruction changed
+	// in the meantime.
+	if err := s.repository.UpdateRun(ctx, instruction); err != nil &&
+		!errors.Is(err, repositories.ErrStandingInstructionVersionMismatch) {
+		return nil, err
+	}
+	return execution, nil
+}
+
+// attempt posts the transfer of an execution and records the outcome on the
+// execution and the instruction. Only storage errors are returned: a failed
+// transfer is part of the outcome.
+func (s *DefaultStandingInstructionService) attempt(
+	ctx context.Context,
+	instruction *models.StandingInstruction,
+	execution *models.StandingInstructionExecution,
+	now time.Time,
+) error {
+	execution.Attempts++
+	tx, err := s.transfer(ctx, instruction, execution)
+	switch {
+	case err == nil:
+		execution.Status = models.StandingInstructionExecutionSucceeded
+		execution.TransactionID = tx.ID
+		execution.NextAttemptAt = nil
+		execution.LastError = nil
+		instruction.ConsecutiveFailures = 0
+		instruction.LastExecutedAt = &now
+	case execution.Attempts < instruction.MaxAttempts:
+		lastError := err.Error()
+		nextAttemptAt := now.Add(time.Duration(instruction.RetryInterval) * time.Second)
+		execution.Status = models.StandingInstructionExecutionRetrying
+		execution.NextAttemptAt = &nextAttemptAt
+		execution.LastError = &lastError
+	default:
+		lastError := err.Error()
+		execution.Status = models.StandingInstructionExecutionFailed
+		execution.NextAttemptAt = nil
+		execution.LastError = &lastError
+		instruction.ConsecutiveFailures++
+		if instruction.FailurePolicy == models.StandingInstructionFailurePause &&
+			instruction.Status == models.StandingInstructionStatusActive {
+			instruction.Status = models.StandingInstructionStatusPaused
+		}
+	}
+	return s.repository.UpdateExecution(ctx, execution)
+}
+
+func (s *DefaultStandingInstructionService) transfer(
+	ctx context.Context,
+	instruction *models.StandingInstruction,
+	execution *models.StandingInstructionExecution,
+) (*ledgerinternal.Transaction, error) {
+	if s.walletService != nil {
+		if _, err := s.walletService.Check(ctx, instruction.Ledger, instruction.SourceWalletID, WalletOperationDebit); err != nil {
+			return nil, fmt.Errorf("source wallet: %w", err)
+		}
+		if _, err := s.walletService.Check(ctx, instruction.Ledger, instruction.DestinationWalletID, WalletOperationCredit); err != nil {
+			return nil, fmt.Errorf("destination wallet: %w", err)
+		}
+	}
+	source, err := models.ParseWalletID(instruction.SourceWalletID)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := models.ParseWalletID(instruction.DestinationWalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := s.system.GetLedgerController(ctx, instruction.Ledger)
+	if err != nil {
+		return nil, err
+	}
+
+	runMetadata := metadata.Metadata{}
+	for k, v := range instruction.Metadata {
+		runMetadata[k] = v
+	}
+	runMetadata["standing_instruction_id"] = instruction.ID.String()
+	runMetadata["standing_instruction_occurrence"] = execution.OccurrenceAt.Format(time.RFC3339)
+	runMetadata["transfer_source_wallet"] = instruction.SourceWalletID
+	runMetadata["transfer_destination_wallet"] = instruction.DestinationWalletID
+
+	// The idempotency key makes a retry after a lost response replay the
+	// transfer instead of failing on the reference.
+	_, created, _, err := l.CreateTransaction(ctx, ledgercontroller.Parameters[ledgercontroller.CreateTransaction]{
+		IdempotencyKey: execution.Reference,
+		Input: ledgercontroller.CreateTransaction{
+			RunScript: vm.RunScript{
+				Script: vm.Script{Plain: fmt.Sprintf(`
+		send [%s %d] (
+			source = @%s
+			destination = @%s
+		)
+	`, currencyregistry.Asset(instruction.Currency), instruction.Amount, source.AvailableAccount(), destination.AvailableAccount())},
+				Reference: execution.Reference,
+				Metadata:  runMetadata,
+			},
+			Runtime: ledgerinternal.RuntimeMachine,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created.Transaction, nil
+}
+
+func validateStandingInstruction(instruction *models.StandingInstruction) (cron.Schedule, error) {
+	switch {
+	case instruction.Amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", ErrStandingInstructionValidation)
+	case instruction.MaxAttempts < 1 || instruction.MaxAttempts > maxStandingInstructionAttempts:
+		return nil, fmt.Errorf("%w: maxAttempts must be between 1 and %d", ErrStandingInstructionValidation, maxStandingInstructionAttempts)
+	case time.Duration(instruction.RetryInterval)*time.Second < minStandingInstructionRetryInterval:
+		return nil, fmt.Errorf("%w: retryInterval must be at least %s", ErrStandingInstructionValidation, minStandingInstructionRetryInterval)
+	case instruction.FailurePolicy != models.StandingInstructionFailureSkip &&
+		instruction.FailurePolicy != models.StandingInstructionFailurePause:
+		return nil, fmt.Errorf("%w: failurePolicy must be %s or %s", ErrStandingInstructionValidation,
+			models.StandingInstructionFailureSkip, models.StandingInstructionFailurePause)
+	case instruction.EndAt != nil && !instruction.EndAt.After(instruction.StartAt):
+		return nil, fmt.Errorf("%w: endAt must be after startAt", ErrStandingInstructionValidation)
+	}
+	if _, ok := currencyregistry.Lookup(instruction.Currency); !ok {
+		return nil, fmt.Errorf("%w: currency %s not supported", ErrStandingInstructionValidation, instruction.Currency)
+	}
+
+	schedule, err := ParseRecurrence(instruction.Schedule, instruction.StartAt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrStandingInstructionValidation, err)
+	}
+	return schedule, nil
+}
+
+// schedulePending sets the next occurrence of the instruction, and completes
+// it once the next occurrence falls after its end.
+func schedulePending(instruction *models.StandingInstruction, next time.Time) {
+	if next.IsZero() || instruction.EndAt != nil && next.After(*instruction.EndAt) {
+		instruction.NextRunAt = nil
+		if instruction.Status == models.StandingInstructionStatusActive {
+			instruction.Status = models.StandingInstructionStatusCompleted
+		}
+		return
+	}
+	instruction.NextRunAt = &next
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a.UTC()
+	}
+	return b.UTC()
+}
+
+func resolveStandingInstructionRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrStandingInstructionNotFound
+	case errors.Is(err, repositories.ErrStandingInstructionVersionMismatch):
+		return ErrStandingInstructionConflict
+	default:
+		return err
+	}
+}
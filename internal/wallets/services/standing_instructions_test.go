@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+)
+
+func TestStandingInstructionRunKeepsAPauseMadeDuringTheRun(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.UTC)
+	due := now.Add(-time.Hour)
+	instruction := models.StandingInstruction{
+		ID:                  uuid.New(),
+		Ledger:              "default",
+		SourceWalletID:      "user123-USD",
+		DestinationWalletID: "user456-USD",
+		Currency:            "USD",
+		Amount:              1000,
+		Schedule:            "daily",
+		StartAt:             due,
+		NextRunAt:           &due,
+		Status:              models.StandingInstructionStatusActive,
+		MaxAttempts:         1,
+		FailurePolicy:       models.StandingInstructionFailureSkip,
+	}
+	repository := newStandingInstructionRepositoryStub(instruction)
+	service := NewStandingInstructionService(&lienSystemControllerStub{ledgerController: &lienLedgerControllerStub{}}, repository, nil)
+
+	// The instruction is paused while its occurrence is transferred.
+	repository.onUpdateExecution = func() {
+		_, err := service.Pause(context.Background(), instruction.ID)
+		require.NoError(t, err)
+	}
+
+	ret, err := service.RunDue(context.Background(), instruction.ID, now)
+	require.NoError(t, err)
+	require.Equal(t, models.StandingInstructionStatusPaused, ret.Status)
+	require.Equal(t, models.StandingInstructionStatusPaused, repository.instructions[instruction.ID].Status)
+	require.Len(t, repository.executions, 1)
+	for _, execution := range repository.executions {
+		require.Equal(t, models.StandingInstructionExecutionSucceeded, execution.Status)
+	}
+}
+
+type standingInstructionRepositoryStub struct {
+	repositories.StandingInstructionRepository
+	instructions      map[uuid.UUID]models.StandingInstruction
+	executions        map[uuid.UUID]models.StandingInstructionExecution
+	onUpdateExecution func()
+}
+
+func newStandingInstructionRepositoryStub(instructions ...models.StandingInstruction) *standingInstructionRepositoryStub {
+	ret := &standingInstructionRepositoryStub{
+		instructions: map[uuid.UUID]models.StandingInstruction{},
+		executions:   map[uuid.UUID]models.StandingInstructionExecution{},
+	}
+	for _, instruction := range instructions {
+		ret.instructions[instruction.ID] = instruction
+	}
+	return ret
+}
+
+func (r *standingInstructionRepositoryStub) Update(_ context.Context, instruction *models.StandingInstruction) error {
+	if r.instructions[instruction.ID].Version != instruction.Version {
+		return repositories.ErrStandingInstructionVersionMismatch
+	}
+	instruction.Version++
+	r.instructions[instruction.ID] = *instruction
+	return nil
+}
+
+func (r *standingInstructionRepositoryStub) UpdateRun(ctx context.Context, instruction *models.StandingInstruction) error {
+	if r.instructions[instruction.ID].Status != models.StandingInstructionStatusActive {
+		return repositories.ErrStandingInstructionVersionMismatch
+	}
+	return r.Update(ctx, instruction)
+}
+
+func (r *standingInstructionRepositoryStub) Get(_ context.Context, id uuid.UUID) (*models.StandingInstruction, error) {
+	instruction, ok := r.instructions[id]
+	if !ok {
+		return nil, postgres.ErrNotFound
+	}
+	return &instruction, nil
+}
+
+func (r *standingInstructionRepositoryStub) CreateExecution(_ context.Context, execution *models.StandingInstructionExecution) (bool, error) {
+	for _, existing := range r.executions {
+		if existing.InstructionID == execution.InstructionID && existing.OccurrenceAt.Equal(execution.OccurrenceAt) {
+			*execution = existing
+			return false, nil
+		}
+	}
+	execution.ID = uuid.New()
+	r.executions[execution.ID] = *execution
+	return true, nil
+}
+
+func (r *standingInstructionRepositoryStub) UpdateExecution(_ context.Context, execution *models.StandingInstructionExecution) error {
+	r.executions[execution.ID] = *execution
+	if r.onUpdateExecution != nil {
+		r.onUpdateExecution()
+		r.onUpdateExecution = nil
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/formancehq/go-libs/v3/platform/postgres"
+	"github.com/formancehq/go-libs/v3/query"
+
+	systemcontroller "github.com/formancehq/ledger/internal/controller/system"
+	currencyregistry "github.com/formancehq/ledger/internal/currency"
+	storagecommon "github.com/formancehq/ledger/internal/storage/common"
+	ledgerstore "github.com/formancehq/ledger/internal/storage/ledger"
+	"github.com/formancehq/ledger/internal/wallets/models"
+	"github.com/formancehq/ledger/internal/wallets/repositories"
+)
+
+var (
+	ErrWalletValidation   = errors.New("wallet validation failed")
+	ErrWalletNotFound     = errors.New("wallet not found")
+	ErrWalletInvalidState = errors.New("wallet does not accept this operation")
+)
+
+const (
+	// WalletOperationCredit is accepted by active and frozen wallets.
+	WalletOperationCredit = "credit"
+	// WalletOperationDebit covers everything taking funds out of the
+	// available balance, liens included. Only active wallets accept it.
+	WalletOperationDebit = "debit"
+)
+
+type WalletService interface {
+	// Create registers a wallet. Registering an existing wallet returns it
+	// unchanged, with created set to false.
+	Create(context.Context, CreateWalletInput) (wallet *models.Wallet, created bool, err error)
+	Get(context.Context, string, string) (*models.Wallet, error)
+	List(context.Context, repositories.WalletFilter) ([]models.Wallet, error)
+	Freeze(context.Context, string, string) (*models.Wallet, error)
+	Unfreeze(context.Context, string, string) (*models.Wallet, error)
+	Close(context.Context, string, string) (*models.Wallet, error)
+	UpdateLabels(context.Context, string, string, map[string]string) (*models.Wallet, error)
+	// Check returns the wallet if it is registered and accepts the operation.
+	Check(context.Context, string, string, string) (*models.Wallet, error)
+}
+
+type CreateWalletInput struct {
+	Ledger   string
+	UserID   string
+	Currency string
+	Labels   map[string]string
+}
+
+type DefaultWalletService struct {
+	system     systemcontroller.Controller
+	repository repositories.WalletRepository
+}
+
+func NewWalletService(system systemcontroller.Controller, repository repositories.WalletRepository) *DefaultWalletService {
+	return &DefaultWalletService{
+		system:     system,
+		repository: repository,
+	}
+}
+
+func (s *DefaultWalletService) Create(ctx context.Context, input CreateWalletInput) (*models.Wallet, bool, error) {
+	id, err := models.NewWalletID(strings.TrimSpace(input.UserID), input.Currency)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %w", ErrWalletValidation, err)
+	}
+	definition, ok := currencyregistry.Lookup(id.Currency)
+	if !ok || !definition.Enabled {
+		return nil, false, fmt.Errorf("%w: currency %s not supported or disabled", ErrWalletValidation, id.Currency)
+	}
+
+	wallet := &models.Wallet{
+		Ledger:   input.Ledger,
+		ID:       id.String(),
+		UserID:   id.UserID,
+		Currency: id.Currency,
+		Status:   models.WalletStatusActive,
+		Labels:   input.Labels,
+	}
+	if wallet.Labels == nil {
+		wallet.Labels = map[string]string{}
+	}
+	if err := s.repository.Create(ctx, wallet); err != nil {
+		if !errors.Is(err, postgres.ErrConstraintsFailed{}) {
+			return nil, false, err
+		}
+		existing, err := s.Get(ctx, input.Ledger, wallet.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+	return wallet, true, nil
+}
+
+// Get returns a registered wallet. The ID is normalized as on creation, so
+// any spelling of the currency finds the wallet. Wallets funded before the
+// registry existed are registered, active, the first time they are looked
+// up; other unknown wallets are not found.
+func (s *DefaultWalletService) Get(ctx context.Context, ledger, walletID string) (*models.Wallet, error) {
+	id, err := models.ParseWalletID(walletID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWalletValidation, err)
+	}
+	wallet, err := s.repository.Get(ctx, ledger, id.String())
+	if err == nil {
+		return wallet, nil
+	}
+	if err = resolveWalletRepositoryError(err); !errors.Is(err, ErrWalletNotFound) {
+		return nil, err
+	}
+	return s.adopt(ctx, ledger, id)
+}
+
+// adopt registers a wallet whose available account already exists in the
+// ledger.
+func (s *DefaultWalletService) adopt(ctx context.Context, ledger string, id models.WalletID) (*models.Wallet, error) {
+	l, err := s.system.GetLedgerController(ctx, ledger)
+	if err != nil {
+		return nil, err
+	}
+	account, err := l.GetAccount(ctx, storagecommon.ResourceQuery[any]{
+		Builder: query.Match("address", id.AvailableAccount()),
+	})
+	if err != nil {
+		if postgres.IsNotFoundError(err) {
+			return nil, ErrWalletNotFound
+		}
+		return nil, err
+	}
+
+	wallet := &models.Wallet{
+		Ledger:    ledger,
+		ID:        id.String(),
+		UserID:    id.UserID,
+		Currency:  id.Currency,
+		Status:    models.WalletStatusActive,
+		Labels:    map[string]string{},
+		CreatedAt: account.FirstUsage.Time.UTC(),
+	}
+	if err := s.repository.Create(ctx, wallet); err != nil {
+		if !errors.Is(err, postgres.ErrConstraintsFailed{}) {
+			return nil, err
+		}
+		// Adopted concurrently.
+		wallet, err = s.repository.Get(ctx, ledger, id.String())
+		if err != nil {
+			return nil, resolveWalletRepositoryError(err)
+		}
+	}
+	return wallet, nil
+}
+
+func (s *DefaultWalletService) List(ctx context.Context, filter repositories.WalletFilter) ([]models.Wallet, error) {
+	return s.repository.List(ctx, filter)
+}
+
+func (s *DefaultWalletService) Freeze(ctx context.Context, ledger, walletID string) (*models.Wallet, error) {
+	return s.transition(ctx, ledger, walletID, models.WalletStatusFrozen, models.WalletStatusActive)
+}
+
+func (s *DefaultWalletService) Unfreeze(ctx context.Context, ledger, walletID string) (*models.Wallet, error) {
+	return s.transition(ctx, ledger, walletID, models.WalletStatusActive, models.WalletStatusFrozen)
+}
+
+// Close closes an empty wallet for good. Funds left on the available balance
+// or held by liens must be moved out first.
+func (s *DefaultWalletService) Close(ctx context.Context, ledger, walletID string) (*models.Wallet, error) {
+	wallet, err := s.Get(ctx, ledger, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Status == models.WalletStatusClosed {
+		return wallet, nil
+	}
+
+	l, err := s.system.GetLedgerController(ctx, ledger)
+	if err != nil {
+		return nil, err
+	}
+	id := models.WalletID{UserID: wallet.UserID, Currency: wallet.Currency}
+	balances, err := l.GetAggregatedBalances(ctx, storagecommon.ResourceQuery[ledgerstore.GetAggregatedVolumesOptions]{
+		Builder: query.Or(
+			query.Match("address", id.AccountPrefix()),
+			query.Match("address", id.LienAccountPrefix()),
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for asset, balance := range balances {
+		if balance.Sign() != 0 {
+			return nil, fmt.Errorf("%w: wallet %s still holds %s %s", ErrWalletInvalidState, walletID, balance, asset)
+		}
+	}
+
+	now := time.Now().UTC()
+	wallet.Status = models.WalletStatusClosed
+	wallet.ClosedAt = &now
+	if err := s.repository.Update(ctx, wallet); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// UpdateLabels replaces the labels of the wallet.
+func (s *DefaultWalletService) UpdateLabels(ctx context.Context, ledger, walletID string, labels map[string]string) (*models.Wallet, error) {
+	wallet, err := s.Get(ctx, ledger, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	wallet.Labels = labels
+	if err := s.repository.Update(ctx, wallet); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+func (s *DefaultWalletService) Check(ctx context.Context, ledger, walletID, operation string) (*models.Wallet, error) {
+	wallet, err := s.Get(ctx, ledger, walletID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case wallet.Status == models.WalletStatusActive:
+	case wallet.Status == models.WalletStatusFrozen && operation == WalletOperationCredit:
+	default:
+		return nil, fmt.Errorf("%w: wallet %s is %s", ErrWalletInvalidState, walletID, wallet.Status)
+	}
+	return wallet, nil
+}
+
+func (s *DefaultWalletService) transition(ctx context.Context, ledger, walletID, to, from string) (*models.Wallet, error) {
+	wallet, err := s.Get(ctx, ledger, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Status == to {
+		return wallet, nil
+	}
+	if wallet.Status != from {
+		return nil, fmt.Errorf("%w: wallet %s is %s", ErrWalletInvalidState, walletID, wallet.Status)
+	}
+	wallet.Status = to
+	if err := s.repository.Update(ctx, wallet); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+func resolveWalletRepositoryError(err error) error {
+	switch {
+	case postgres.IsNotFoundError(err), errors.Is(err, postgres.ErrNotFound):
+		return ErrWalletNotFound
+	default:
+		return err
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/formancehq/ledger/internal/replication"
 	innergrpc "github.com/formancehq/ledger/internal/replication/grpc"
 	"github.com/formancehq/ledger/internal/storage"
+	walletscheduler "github.com/formancehq/ledger/internal/wallets/scheduler"
 )
 
 type GRPCServerModuleConfig struct {
@@ -27,6 +28,7 @@ type ModuleConfig struct {
 	ReplicationConfig         replication.WorkerModuleConfig
 	BucketCleanupRunnerConfig storage.BucketCleanupRunnerConfig
 	CBASchedulerConfig        scheduler.ModuleConfig
+	WalletSchedulerConfig     walletscheduler.ModuleConfig
 }
 
 // NewFXModule constructs an fx.Option that installs the storage async block runner,
@@ -39,6 +41,7 @@ func NewFXModule(cfg ModuleConfig) fx.Option {
 		replication.NewWorkerFXModule(cfg.ReplicationConfig),
 		storage.NewBucketCleanupRunnerModule(cfg.BucketCleanupRunnerConfig),
 		scheduler.NewFXModule(cfg.CBASchedulerConfig),
+		walletscheduler.NewFXModule(cfg.WalletSchedulerConfig),
 	)
 }
 